@@ -3,10 +3,12 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"runtime"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
@@ -16,16 +18,54 @@ import (
 
 	"time"
 	"wut/internal/alias"
+	"wut/internal/config"
 	appctx "wut/internal/context"
+	"wut/internal/db"
+	"wut/internal/terminal"
 	"wut/internal/ui"
 )
 
+// aliasDBTimeout bounds how long alias lookups wait for the database
+const aliasDBTimeout = 100 * time.Millisecond
+
 // aliasCmd manages shell aliases
 var aliasCmd = &cobra.Command{
 	Use:   "alias",
 	Short: "Manage shell aliases",
-	Long:  `View, add, and generate smart aliases for your shell.`,
-	RunE:  runAlias,
+	Long: `View, add, and generate smart aliases for your shell.
+
+WUT aliases are stored in the WUT database and work in every shell. Their
+commands may use $1 to $9 and $@ for the arguments given when they run.`,
+	Example: `  wut alias add deploy "git push origin main && kubectl rollout restart deployment/api"
+  wut alias add logs "kubectl logs -f deployment/$1" --description "Follow a deployment's logs"
+  wut alias list
+  wut alias rm deploy
+  wut suggest deploy --exec`,
+	RunE: runAlias,
+}
+
+var aliasAddCmd = &cobra.Command{
+	Use:   "add <name> <command>",
+	Short: "Add or replace a WUT alias",
+	Args:  cobra.MinimumNArgs(2),
+	RunE:  runAliasAdd,
+}
+
+var aliasListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List WUT aliases",
+	Args:    cobra.NoArgs,
+	RunE:    runAliasList,
+}
+
+var aliasRemoveCmd = &cobra.Command{
+	Use:               "remove <name>",
+	Aliases:           []string{"rm", "delete"},
+	Short:             "Remove a WUT alias",
+	Args:              cobra.ExactArgs(1),
+	RunE:              runAliasRemove,
+	ValidArgsFunction: completeAliasNames,
 }
 
 var (
@@ -39,8 +79,15 @@ var (
 	aliasShell    string
 )
 
+var aliasAddDesc string
+
 func init() {
 	rootCmd.AddCommand(aliasCmd)
+	aliasCmd.AddCommand(aliasAddCmd)
+	aliasCmd.AddCommand(aliasListCmd)
+	aliasCmd.AddCommand(aliasRemoveCmd)
+
+	aliasAddCmd.Flags().StringVar(&aliasAddDesc, "description", "", "what the alias does")
 
 	aliasCmd.Flags().BoolVarP(&aliasList, "list", "l", false, "list all aliases")
 	aliasCmd.Flags().BoolVarP(&aliasAdd, "add", "a", false, "add a new alias")
@@ -81,9 +128,182 @@ func runAlias(cmd *cobra.Command, args []string) error {
 	}
 
 	// Default: list aliases
+	if err := printUserAliases(false); err != nil {
+		return err
+	}
 	return listAliases(manager)
 }
 
+func runAliasAdd(cmd *cobra.Command, args []string) error {
+	return saveUserAlias(args[0], strings.Join(args[1:], " "), aliasAddDesc)
+}
+
+func runAliasList(cmd *cobra.Command, args []string) error {
+	return printUserAliases(true)
+}
+
+func runAliasRemove(cmd *cobra.Command, args []string) error {
+	store, err := getDB()
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer store.Close()
+
+	if err := store.DeleteAlias(context.Background(), args[0]); err != nil {
+		if errors.Is(err, db.ErrAliasNotFound) {
+			return fmt.Errorf("no alias named %s", args[0])
+		}
+		return fmt.Errorf("failed to remove alias: %w", err)
+	}
+	fmt.Printf("%s Removed alias %s\n", ui.Green("✓"), ui.Cyan(args[0]))
+	return nil
+}
+
+// saveUserAlias stores an alias in the WUT database
+func saveUserAlias(name, command, description string) error {
+	store, err := getDB()
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer store.Close()
+
+	if err := store.SaveAlias(context.Background(), name, command, description); err != nil {
+		return fmt.Errorf("failed to save alias: %w", err)
+	}
+
+	fmt.Printf("%s %s = %s\n", ui.Green("✓"), ui.Cyan(name), command)
+	if params := db.AliasParams(command); len(params) > 0 {
+		fmt.Printf("   %s\n", ui.Muted("Takes "+strings.Join(params, " ")+" - e.g. wut suggest "+name+" <args> --exec"))
+	}
+	return nil
+}
+
+// printUserAliases lists the aliases in the WUT database. With showEmpty a
+// hint is printed when there are none.
+func printUserAliases(showEmpty bool) error {
+	store, err := getDB()
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer store.Close()
+
+	aliases, err := store.ListAliases(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to list aliases: %w", err)
+	}
+	if len(aliases) == 0 {
+		if showEmpty {
+			fmt.Println("No WUT aliases yet. Add one with: wut alias add <name> <command>")
+		}
+		return nil
+	}
+
+	headerStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(ui.ColorBrand)
+
+	fmt.Println()
+	fmt.Println(headerStyle.Render("WUT"))
+	fmt.Println()
+	for _, a := range aliases {
+		fmt.Printf("  %s = %s\n", ui.Green(a.Name), a.Command)
+		if a.Description != "" {
+			fmt.Printf("     %s\n", a.Description)
+		}
+	}
+	fmt.Println()
+	return nil
+}
+
+// completeAliasNames completes the names of WUT aliases
+func completeAliasNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var names []string
+	for _, a := range loadUserAliases() {
+		if strings.HasPrefix(a.Name, toComplete) {
+			names = append(names, a.Name)
+		}
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// userAliasNames returns the names of the WUT aliases in store, for the
+// corrector
+func userAliasNames(store *db.Storage) []string {
+	aliases, err := store.ListAliases(context.Background())
+	if err != nil {
+		return nil
+	}
+	names := make([]string, len(aliases))
+	for i, a := range aliases {
+		names[i] = a.Name
+	}
+	return names
+}
+
+// findUserAlias returns the WUT alias with the given name, or nil
+func findUserAlias(name string) *db.UserAlias {
+	if !db.ValidAliasName(name) {
+		return nil
+	}
+	store, err := db.OpenReadOnly(config.GetDatabasePath(), aliasDBTimeout)
+	if err != nil {
+		return nil
+	}
+	defer store.Close()
+
+	a, err := store.GetAlias(context.Background(), name)
+	if err != nil {
+		return nil
+	}
+	return a
+}
+
+// promptAliasArgs asks for the arguments of an alias command that uses $1 to
+// $9 or $@ and expands it. Without a terminal the parameters expand to
+// nothing.
+func promptAliasArgs(command string) string {
+	params := db.AliasParams(command)
+	if len(params) == 0 || !terminal.IsInteractive() {
+		return db.ExpandAlias(command, nil)
+	}
+
+	fmt.Println()
+	fmt.Println(ui.Muted("Fill in the alias arguments (leave empty to skip):"))
+	var args []string
+	for _, p := range params {
+		if p == "$@" {
+			prompt := "arguments ($@):"
+			if len(args) > 0 {
+				prompt = "more arguments ($@):"
+			}
+			args = append(args, strings.Fields(askChoice(prompt, ""))...)
+			continue
+		}
+		n, _ := strconv.Atoi(p[1:])
+		for len(args) < n {
+			args = append(args, "")
+		}
+		args[n-1] = askChoice(p+":", "")
+	}
+	return db.ExpandAlias(command, args)
+}
+
+// loadUserAliases reads the WUT aliases without waiting on a database held by
+// another process; callers treat a failure as having no aliases
+func loadUserAliases() []db.UserAlias {
+	store, err := db.OpenReadOnly(config.GetDatabasePath(), aliasDBTimeout)
+	if err != nil {
+		return nil
+	}
+	defer store.Close()
+
+	aliases, _ := store.ListAliases(context.Background())
+	return aliases
+}
+
 func generateAliases(manager *alias.Manager) error {
 	cmdCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -105,7 +325,7 @@ func generateAliases(manager *alias.Manager) error {
 	// Display suggestions
 	headerStyle := lipgloss.NewStyle().
 		Bold(true).
-		Foreground(lipgloss.Color("#7C3AED"))
+		Foreground(ui.ColorBrand)
 
 	fmt.Println()
 	fmt.Println(headerStyle.Render("✨ Suggested Aliases for Your Project"))
@@ -114,7 +334,7 @@ func generateAliases(manager *alias.Manager) error {
 	for _, a := range suggestions {
 		nameStyle := lipgloss.NewStyle().
 			Bold(true).
-			Foreground(lipgloss.Color("#10B981")).
+			Foreground(ui.ColorSuccess).
 			Render(a.Name)
 
 		fmt.Printf("  %s = %s\n", nameStyle, a.Command)
@@ -139,7 +359,7 @@ func generateAliases(manager *alias.Manager) error {
 	for _, a := range newPopular[:min(5, len(newPopular))] {
 		nameStyle := lipgloss.NewStyle().
 			Bold(true).
-			Foreground(lipgloss.Color("#3B82F6")).
+			Foreground(ui.ColorPrimary).
 			Render(a.Name)
 
 		fmt.Printf("  %s = %s\n", nameStyle, a.Command)
@@ -175,7 +395,7 @@ func listAliases(manager *alias.Manager) error {
 
 	headerStyle := lipgloss.NewStyle().
 		Bold(true).
-		Foreground(lipgloss.Color("#7C3AED"))
+		Foreground(ui.ColorBrand)
 
 	fmt.Println()
 
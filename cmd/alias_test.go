@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestAliasAddThenApply checks that an alias added with --add is written to
+// the shell config by a later --apply
+func TestAliasAddThenApply(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	rc := filepath.Join(home, ".bashrc")
+	if err := os.WriteFile(rc, []byte("export EDITOR=vim\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	origAdd, origApply, origShell := aliasAdd, aliasApply, aliasShell
+	origName, origCommand, origDesc := aliasName, aliasCommand, aliasDesc
+	t.Cleanup(func() {
+		aliasAdd, aliasApply, aliasShell = origAdd, origApply, origShell
+		aliasName, aliasCommand, aliasDesc = origName, origCommand, origDesc
+	})
+
+	aliasShell = "bash"
+	aliasAdd, aliasName, aliasCommand, aliasDesc = true, "gs", "git status", "Show status"
+	if err := runAlias(aliasCmd, nil); err != nil {
+		t.Fatalf("runAlias(--add) error = %v", err)
+	}
+
+	aliasAdd, aliasApply = false, true
+	if err := runAlias(aliasCmd, nil); err != nil {
+		t.Fatalf("runAlias(--apply) error = %v", err)
+	}
+
+	data, err := os.ReadFile(rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "alias gs='git status' # Show status") {
+		t.Errorf(".bashrc is missing the added alias:\n%s", data)
+	}
+	if !strings.HasPrefix(string(data), "export EDITOR=vim\n") {
+		t.Errorf(".bashrc lost its existing content:\n%s", data)
+	}
+}
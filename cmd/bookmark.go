@@ -2,57 +2,114 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"math"
+	"os"
 	"strings"
 
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/reflow/truncate"
+	"github.com/spf13/cobra"
+
 	"wut/internal/config"
 	"wut/internal/db"
 	"wut/internal/logger"
+	"wut/internal/terminal"
 	"wut/internal/ui"
-
-	"github.com/charmbracelet/lipgloss"
-	"github.com/spf13/cobra"
 )
 
 var bookmarkCmd = &cobra.Command{
 	Use:     "bookmark",
 	Aliases: []string{"b", "bm"},
-	Short:   "Save and organize your favorite commands with labels",
-	Long: `Bookmark system allows you to record, tag, and organize favorite WUT commands.
-You can label them and add notes for quick recall.`,
+	Short:   "Pin favorite commands and find them again",
+	Long: `Bookmark commands you run often, tag them and add notes for quick recall.
+
+Bookmarked commands are ranked first by 'wut smart' and 'wut suggest'. In the
+suggestion views, press b to bookmark the highlighted command.`,
+	Example: `  wut bookmark add "git push origin main" --tag deploy --note "prod release"
+  wut bookmark list
+  wut bookmark list --tag deploy
+  wut bookmark rm 482913
+  wut bookmark export bookmarks.json`,
 	RunE: runBookmarkList,
 }
 
 var bookmarkAddCmd = &cobra.Command{
-	Use:   "add [command]",
-	Short: "Add a new mapped command",
+	Use:   "add <command>",
+	Short: "Bookmark a command",
+	Args:  cobra.MinimumNArgs(1),
 	RunE:  runBookmarkAdd,
 }
 
+var bookmarkListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "Browse bookmarks: copy, run or delete them",
+	Args:    cobra.NoArgs,
+	RunE:    runBookmarkList,
+}
+
 var bookmarkRemoveCmd = &cobra.Command{
-	Use:     "remove [id/label]",
-	Aliases: []string{"rm", "delete"},
-	Short:   "Remove a bookmark",
-	RunE:    runBookmarkRemove,
+	Use:               "remove <id>",
+	Aliases:           []string{"rm", "delete"},
+	Short:             "Remove a bookmark",
+	Args:              cobra.ExactArgs(1),
+	RunE:              runBookmarkRemove,
+	ValidArgsFunction: completeBookmarkIDs,
 }
 
 var bookmarkSearchCmd = &cobra.Command{
-	Use:   "search [query]",
-	Short: "Search through your bookmarks",
+	Use:   "search <query>",
+	Short: "Search bookmarks by command, tag or note",
+	Args:  cobra.MinimumNArgs(1),
 	RunE:  runBookmarkSearch,
 }
 
-var bmLabel string
-var bmNotes string
+var bookmarkExportCmd = &cobra.Command{
+	Use:   "export <file>",
+	Short: "Export bookmarks to a JSON file",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runBookmarkExport,
+}
+
+var bookmarkImportCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Import bookmarks from a JSON file",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runBookmarkImport,
+}
+
+var (
+	bmTags    []string
+	bmLabel   string
+	bmNote    string
+	bmListTag string
+	bmRaw     bool
+)
 
 func init() {
 	rootCmd.AddCommand(bookmarkCmd)
 	bookmarkCmd.AddCommand(bookmarkAddCmd)
+	bookmarkCmd.AddCommand(bookmarkListCmd)
 	bookmarkCmd.AddCommand(bookmarkRemoveCmd)
 	bookmarkCmd.AddCommand(bookmarkSearchCmd)
-
-	bookmarkAddCmd.Flags().StringVarP(&bmLabel, "label", "l", "default", "Label for the command")
-	bookmarkAddCmd.Flags().StringVarP(&bmNotes, "notes", "n", "", "Optional notes")
+	bookmarkCmd.AddCommand(bookmarkExportCmd)
+	bookmarkCmd.AddCommand(bookmarkImportCmd)
+
+	bookmarkAddCmd.Flags().StringSliceVarP(&bmTags, "tag", "t", nil, "tag the bookmark (repeatable)")
+	bookmarkAddCmd.Flags().StringVarP(&bmNote, "note", "n", "", "note shown with the bookmark")
+	bookmarkAddCmd.Flags().StringVar(&bmNote, "notes", "", "note shown with the bookmark")
+	bookmarkAddCmd.Flags().StringVarP(&bmLabel, "label", "l", "", "tag the bookmark")
+	_ = bookmarkAddCmd.Flags().MarkHidden("notes")
+	_ = bookmarkAddCmd.Flags().MarkDeprecated("label", "use --tag instead")
+
+	for _, c := range []*cobra.Command{bookmarkCmd, bookmarkListCmd} {
+		c.Flags().StringVarP(&bmListTag, "tag", "t", "", "only show bookmarks with this tag")
+		c.Flags().BoolVarP(&bmRaw, "raw", "r", false, "print plain text instead of the interactive view")
+	}
 }
 
 func getDB() (*db.Storage, error) {
@@ -60,71 +117,144 @@ func getDB() (*db.Storage, error) {
 }
 
 func runBookmarkAdd(cmd *cobra.Command, args []string) error {
-	if len(args) == 0 {
-		return fmt.Errorf("please provide the command to bookmark. Ex: wut bookmark add 'docker ps' -l docker")
+	commandStr := strings.Join(args, " ")
+	tags := bmTags
+	if bmLabel != "" {
+		tags = append(tags, bmLabel)
 	}
 
-	commandStr := strings.Join(args, " ")
 	store, err := getDB()
 	if err != nil {
 		return fmt.Errorf("failed to open database: %w", err)
 	}
 	defer store.Close()
 
-	err = store.AddBookmark(context.Background(), commandStr, bmLabel, bmNotes)
+	bm, err := store.AddBookmark(context.Background(), commandStr, tags, bmNote)
 	if err != nil {
 		return fmt.Errorf("failed to add bookmark: %w", err)
 	}
 
-	fmt.Printf("%s Successfully bookmarked command: %s\n", ui.Green("✓"), ui.Cyan(commandStr))
-	fmt.Printf("   Label: %s\n", ui.Accent(bmLabel))
+	fmt.Printf("%s Bookmarked %s %s\n", ui.Green("✓"), ui.Cyan(bm.Command), ui.Muted("("+bm.ShortID()+")"))
+	if len(bm.Tags) > 0 {
+		fmt.Printf("   Tags: %s\n", ui.Accent(strings.Join(bm.Tags, ", ")))
+	}
 	return nil
 }
 
-func printBookmarks(bookmarks []db.Bookmark) {
+// saveBookmark bookmarks a command picked in a suggestion view
+func saveBookmark(store *db.Storage, command string) error {
+	if store == nil {
+		return fmt.Errorf("database unavailable")
+	}
+	_, err := store.AddBookmark(context.Background(), command, nil, "")
+	return err
+}
+
+// bookmarkCommand bookmarks a command with a short-lived database handle,
+// for views that do not hold the WUT database open
+func bookmarkCommand(command string) error {
+	store, err := getDB()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	return saveBookmark(store, command)
+}
+
+// bookmarkedCommands returns the commands of all bookmarks, or nil when the
+// database is busy or missing
+func bookmarkedCommands() []string {
+	store, err := db.OpenReadOnly(config.GetDatabasePath(), aliasDBTimeout)
+	if err != nil {
+		return nil
+	}
+	defer store.Close()
+
+	bookmarks, err := store.GetBookmarks(context.Background())
+	if err != nil {
+		return nil
+	}
+	commands := make([]string, len(bookmarks))
+	for i, bm := range bookmarks {
+		commands[i] = bm.Command
+	}
+	return commands
+}
+
+func printBookmarks(w io.Writer, bookmarks []db.Bookmark) {
 	if len(bookmarks) == 0 {
-		fmt.Println("No bookmarks found.")
+		fmt.Fprintln(w, "No bookmarks found.")
 		return
 	}
 
-	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#7C3AED"))
-	fmt.Println(titleStyle.Render("📌 Your Bookmarks"))
-	fmt.Println()
+	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(ui.ColorBrand)
+	tagStyle := lipgloss.NewStyle().Foreground(ui.ColorSuccess).Bold(true) // Emerald
+	cmdStyle := lipgloss.NewStyle().Foreground(ui.ColorInfo)               // Blue
+	fmt.Fprintln(w, titleStyle.Render("⭐ Your Bookmarks"))
+	fmt.Fprintln(w)
 
 	for _, bm := range bookmarks {
-		labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#10B981")).Bold(true) // Emerald
-		cmdStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#60A5FA"))              // Blue
-
-		fmt.Printf(" %s [%s] %s\n", ui.Muted(bm.ID[len(bm.ID)-6:]), labelStyle.Render(bm.Label), cmdStyle.Render(bm.Command))
+		tags := ""
+		if len(bm.Tags) > 0 {
+			tags = tagStyle.Render("["+strings.Join(bm.Tags, ", ")+"]") + " "
+		}
+		fmt.Fprintf(w, " %s %s%s\n", ui.Muted(bm.ShortID()), tags, cmdStyle.Render(bm.Command))
 		if bm.Notes != "" {
-			fmt.Printf("      %s\n", ui.Muted("🗒️  "+bm.Notes))
+			fmt.Fprintf(w, "        %s\n", ui.Muted("🗒️  "+bm.Notes))
 		}
 	}
-	fmt.Println()
-	fmt.Println(ui.Muted("Use 'wut bookmark remove <id>' to delete, or 'wut bookmark add' to save new ones."))
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, ui.Muted("Use 'wut bookmark rm <id>' to delete, or 'wut bookmark add' to save new ones."))
 }
 
 func runBookmarkList(cmd *cobra.Command, args []string) error {
-	logger.Info("listing bookmarks")
+	logger.With("bookmark").Info("listing bookmarks")
 	store, err := getDB()
 	if err != nil {
 		return fmt.Errorf("failed to open database: %w", err)
 	}
-	defer store.Close()
 
-	bms, err := store.GetBookmarks(context.Background())
+	var bms []db.Bookmark
+	if bmListTag != "" {
+		bms, err = store.BookmarksWithTag(context.Background(), bmListTag)
+	} else {
+		bms, err = store.GetBookmarks(context.Background())
+	}
 	if err != nil {
+		store.Close()
 		return fmt.Errorf("failed to get bookmarks: %w", err)
 	}
 
-	printBookmarks(bms)
+	if bmRaw || len(bms) == 0 || !terminal.IsInteractive() {
+		store.Close()
+		printBookmarks(os.Stdout, bms)
+		return nil
+	}
+
+	// Newest first, like the history view
+	for i, j := 0, len(bms)-1; i < j; i, j = i+1, j-1 {
+		bms[i], bms[j] = bms[j], bms[i]
+	}
+
+	finalModel, err := tea.NewProgram(newBookmarkModel(bms, store)).Run()
+	// Close before running a picked command so its history can be recorded
+	store.Close()
+	if err != nil {
+		return fmt.Errorf("error running bookmark UI: %w", err)
+	}
+
+	m, ok := finalModel.(bookmarkModel)
+	if !ok {
+		return nil
+	}
+	printUncopiedCommand(m.uncopied)
+	if m.executed != "" {
+		return runPickedCommand(cmd.Context(), m.executed)
+	}
 	return nil
 }
 
 func runBookmarkSearch(cmd *cobra.Command, args []string) error {
-	if len(args) == 0 {
-		return fmt.Errorf("please provide a search query")
-	}
 	query := strings.Join(args, " ")
 
 	store, err := getDB()
@@ -138,43 +268,278 @@ func runBookmarkSearch(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to search bookmarks: %w", err)
 	}
 
-	printBookmarks(bms)
+	printBookmarks(os.Stdout, bms)
 	return nil
 }
 
 func runBookmarkRemove(cmd *cobra.Command, args []string) error {
-	if len(args) == 0 {
-		return fmt.Errorf("please provide the ID or label of the bookmark to delete")
+	store, err := getDB()
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer store.Close()
+
+	bm, err := store.FindBookmark(context.Background(), args[0])
+	if errors.Is(err, db.ErrBookmarkNotFound) {
+		return fmt.Errorf("no bookmark with ID %s (see 'wut bookmark list')", args[0])
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := store.DeleteBookmark(context.Background(), bm.ID); err != nil {
+		return fmt.Errorf("failed to remove bookmark: %w", err)
 	}
-	idOrLabel := args[0]
 
+	fmt.Printf("%s Removed bookmark %s\n", ui.Green("✓"), ui.Cyan(bm.Command))
+	return nil
+}
+
+func runBookmarkExport(cmd *cobra.Command, args []string) error {
 	store, err := getDB()
 	if err != nil {
 		return fmt.Errorf("failed to open database: %w", err)
 	}
 	defer store.Close()
 
-	bms, err := store.GetBookmarks(context.Background())
+	if err := store.ExportBookmarks(context.Background(), args[0]); err != nil {
+		return fmt.Errorf("failed to export bookmarks: %w", err)
+	}
+	fmt.Printf("✅ Bookmarks exported to %s\n", args[0])
+	return nil
+}
+
+func runBookmarkImport(cmd *cobra.Command, args []string) error {
+	store, err := getDB()
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to open database: %w", err)
 	}
+	defer store.Close()
 
-	var foundID string
-	for _, bm := range bms {
-		if strings.HasSuffix(bm.ID, idOrLabel) || bm.ID == idOrLabel || bm.Label == idOrLabel {
-			foundID = bm.ID
-			break
-		}
+	n, err := store.ImportBookmarks(context.Background(), args[0])
+	if err != nil {
+		return fmt.Errorf("failed to import bookmarks: %w", err)
 	}
+	fmt.Printf("✅ Imported %d bookmarks from %s\n", n, args[0])
+	return nil
+}
 
-	if foundID == "" {
-		return fmt.Errorf("bookmark not found with identifier: %s", idOrLabel)
+func completeBookmarkIDs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
 	}
+	store, err := db.OpenReadOnly(config.GetDatabasePath(), aliasDBTimeout)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	defer store.Close()
 
-	if err := store.DeleteBookmark(context.Background(), foundID); err != nil {
-		return err
+	bookmarks, _ := store.GetBookmarks(context.Background())
+	var ids []string
+	for _, bm := range bookmarks {
+		if strings.HasPrefix(bm.ShortID(), toComplete) {
+			ids = append(ids, bm.ShortID()+"\t"+bm.Command)
+		}
 	}
+	return ids, cobra.ShellCompDirectiveNoFileComp
+}
+
+// ── Bookmark browser ─────────────────────────────────────────────────────────
+
+type bookmarkModel struct {
+	bookmarks []db.Bookmark
+	store     *db.Storage
+	cursor    int
+	page      int
+	pageSize  int
+	numPages  int
+	msg       string
+	width     int
+	uncopied  string
+	executed  string
+
+	// pendingDelete is the ID awaiting a second d keypress
+	pendingDelete string
+}
+
+func newBookmarkModel(bookmarks []db.Bookmark, store *db.Storage) bookmarkModel {
+	m := bookmarkModel{bookmarks: bookmarks, store: store, pageSize: 8}
+	return m.repaginate()
+}
+
+func (m bookmarkModel) repaginate() bookmarkModel {
+	m.numPages = max(int(math.Ceil(float64(len(m.bookmarks))/float64(m.pageSize))), 1)
+	m.cursor = min(m.cursor, max(len(m.bookmarks)-1, 0))
+	m.page = m.cursor / m.pageSize
+	return m
+}
 
-	fmt.Printf("%s Bookmark removed successfully.\n", ui.Green("✓"))
+func (m bookmarkModel) Init() tea.Cmd {
 	return nil
 }
+
+func (m bookmarkModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+	case clearMsg:
+		m.msg = ""
+	case tea.KeyMsg:
+		key := msg.String()
+		if key != "d" {
+			m.pendingDelete = ""
+		}
+
+		switch key {
+		case "ctrl+c", "q", "esc":
+			return m, tea.Quit
+		case "up", "k":
+			if m.cursor > 0 {
+				m.cursor--
+				m.page = m.cursor / m.pageSize
+			}
+		case "down", "j":
+			if m.cursor < len(m.bookmarks)-1 {
+				m.cursor++
+				m.page = m.cursor / m.pageSize
+			}
+		case "left", "h", "pgup":
+			if m.page > 0 {
+				m.page--
+				m.cursor = m.page * m.pageSize
+			}
+		case "right", "l", "pgdown":
+			if m.page < m.numPages-1 {
+				m.page++
+				m.cursor = m.page * m.pageSize
+			}
+		case "c", "y":
+			if m.cursor < len(m.bookmarks) {
+				targetCmd := db.FillPlaceholders(m.bookmarks[m.cursor].Command, nil)
+				method, err := terminal.Copy(targetCmd)
+				if err != nil {
+					m.uncopied = targetCmd
+					m.msg = "❌ Copy failed - command will be printed on exit"
+					return m, tickClearMsg()
+				}
+				m.uncopied = ""
+				m.msg = "📋 Copied via " + method
+				return m, tickClearMsg()
+			}
+		case "enter", "e":
+			if m.cursor < len(m.bookmarks) {
+				m.executed = m.bookmarks[m.cursor].Command
+				return m, tea.Quit
+			}
+		case "d":
+			if m.cursor >= len(m.bookmarks) {
+				break
+			}
+			bm := m.bookmarks[m.cursor]
+			if m.pendingDelete != bm.ID {
+				m.pendingDelete = bm.ID
+				m.msg = "Press d again to delete this bookmark"
+				return m, nil
+			}
+			m.pendingDelete = ""
+			if err := m.store.DeleteBookmark(context.Background(), bm.ID); err != nil {
+				m.msg = "❌ Delete failed: " + err.Error()
+				return m, tickClearMsg()
+			}
+			m.bookmarks = append(m.bookmarks[:m.cursor:m.cursor], m.bookmarks[m.cursor+1:]...)
+			m = m.repaginate()
+			m.msg = "🗑️  Deleted " + bm.Command
+			if len(m.bookmarks) == 0 {
+				return m, tea.Quit
+			}
+			return m, tickClearMsg()
+		}
+	}
+	return m, nil
+}
+
+func (m bookmarkModel) View() string {
+	if len(m.bookmarks) == 0 {
+		return "No bookmarks left.\n"
+	}
+
+	w := m.width
+	if w <= 0 {
+		w = 80
+	}
+	boxPadX := 2
+	if w < 60 {
+		boxPadX = 1
+	}
+	boxWidth := max(w-2, 30)
+	innerWidth := max(boxWidth-2-boxPadX*2, 20)
+
+	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(ui.ColorBrand)
+	metaStyle := lipgloss.NewStyle().Foreground(ui.ColorSubtle)
+	tagStyle := lipgloss.NewStyle().Foreground(ui.ColorSuccess)
+	idStyle := lipgloss.NewStyle().Foreground(ui.ColorMuted).Width(6)
+
+	var sb strings.Builder
+	sb.WriteString(headerStyle.Render("⭐ Bookmarks"))
+	if m.msg != "" {
+		sb.WriteString("  " + lipgloss.NewStyle().Foreground(ui.ColorText).Bold(true).Render(m.msg))
+	}
+	sb.WriteString("\n\n")
+
+	// cursor(2) + space + id(6) + space
+	availWidth := max(innerWidth-10, 10)
+
+	start := m.page * m.pageSize
+	end := min(start+m.pageSize, len(m.bookmarks))
+	for i := start; i < end; i++ {
+		bm := m.bookmarks[i]
+		cursor := "  "
+		cmdStyle := lipgloss.NewStyle().Bold(true).Foreground(ui.ColorSuccess)
+		if m.cursor == i {
+			cursor = "👉"
+			cmdStyle = lipgloss.NewStyle().Bold(true).Foreground(ui.ColorOnAccent).Background(ui.ColorPrimary).Padding(0, 1)
+		}
+		if bm.ID == m.pendingDelete {
+			cmdStyle = cmdStyle.Background(ui.ColorError)
+		}
+
+		dispCmd := bm.Command
+		if lipgloss.Width(dispCmd) > availWidth {
+			dispCmd = truncate.StringWithTail(dispCmd, uint(availWidth), "...")
+		}
+		sb.WriteString(fmt.Sprintf("%s %s %s\n", cursor, idStyle.Render(bm.ShortID()), cmdStyle.Render(dispCmd)))
+
+		var meta []string
+		if len(bm.Tags) > 0 {
+			meta = append(meta, tagStyle.Render("#"+strings.Join(bm.Tags, " #")))
+		}
+		if bm.Notes != "" {
+			meta = append(meta, metaStyle.Render(bm.Notes))
+		}
+		line := strings.Join(meta, metaStyle.Render("  ·  "))
+		if lipgloss.Width(line) > availWidth {
+			line = truncate.StringWithTail(line, uint(availWidth), "...")
+		}
+		sb.WriteString(strings.Repeat(" ", 10) + line + "\n\n")
+	}
+
+	footerStyle := lipgloss.NewStyle().Foreground(ui.ColorHighlight).Bold(true)
+	sb.WriteString(footerStyle.Render(fmt.Sprintf("Page %d/%d", m.page+1, m.numPages)))
+
+	var footerNav string
+	if w >= 90 {
+		footerNav = " | [↑/↓] Navigate | [←/→] Page | [c] Copy | [enter] Run | [d] Delete | [q] Quit"
+	} else if w >= 60 {
+		footerNav = " | ↑/↓ nav | ←/→ page | c copy | enter run | d delete | q quit"
+	} else {
+		footerNav = " | ↑/↓ | ←/→ | c | enter | d | q"
+	}
+	sb.WriteString(metaStyle.Render(footerNav))
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(ui.ColorBrand).
+		Padding(1, boxPadX).
+		Width(boxWidth).
+		Render(sb.String())
+}
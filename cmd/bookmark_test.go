@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"wut/internal/db"
+)
+
+func TestBookmarkModelDeleteAndRun(t *testing.T) {
+	store, err := db.NewStorage(filepath.Join(t.TempDir(), "wut.db"))
+	if err != nil {
+		t.Fatalf("NewStorage() error = %v", err)
+	}
+	defer store.Close()
+	for _, command := range []string{"make test", "docker compose up -d"} {
+		if _, err := store.AddBookmark(t.Context(), command, nil, ""); err != nil {
+			t.Fatalf("AddBookmark() error = %v", err)
+		}
+	}
+	bookmarks, _ := store.GetBookmarks(t.Context())
+
+	key := func(m bookmarkModel, k string) bookmarkModel {
+		msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+		if k == "enter" {
+			msg = tea.KeyMsg{Type: tea.KeyEnter}
+		}
+		next, _ := m.Update(msg)
+		return next.(bookmarkModel)
+	}
+
+	m := newBookmarkModel(bookmarks, store)
+	m = key(m, "d")
+	if len(m.bookmarks) != 2 {
+		t.Fatal("a single d deleted the bookmark")
+	}
+	m = key(m, "d")
+	if len(m.bookmarks) != 1 || m.bookmarks[0].Command != "docker compose up -d" {
+		t.Fatalf("after d d bookmarks = %+v", m.bookmarks)
+	}
+	if left, _ := store.GetBookmarks(t.Context()); len(left) != 1 {
+		t.Errorf("stored bookmarks after delete = %+v", left)
+	}
+
+	m = key(m, "enter")
+	if m.executed != "docker compose up -d" {
+		t.Errorf("executed = %q, want the highlighted bookmark", m.executed)
+	}
+}
@@ -78,12 +78,12 @@ var bugReportCmd = &cobra.Command{
 		}
 
 		fmt.Println()
-		header := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#10B981")).Render("✅ Bug report generated successfully!")
+		header := lipgloss.NewStyle().Bold(true).Foreground(ui.ColorSuccess).Render("✅ Bug report generated successfully!")
 		fmt.Printf("%s\n\n", header)
 
-		fmt.Printf("File saved to: %s\n", lipgloss.NewStyle().Foreground(lipgloss.Color("#3B82F6")).Render(zipFileName))
+		fmt.Printf("File saved to: %s\n", lipgloss.NewStyle().Foreground(ui.ColorPrimary).Render(zipFileName))
 		fmt.Println("\nPlease attach this file when opening an issue on GitHub:")
-		fmt.Println(lipgloss.NewStyle().Foreground(lipgloss.Color("#FF70A6")).Render("https://github.com/thirawat27/wut/issues/new"))
+		fmt.Println(lipgloss.NewStyle().Foreground(ui.ColorPink).Render("https://github.com/thirawat27/wut/issues/new"))
 
 		return nil
 	},
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+
+	"wut/internal/config"
+	"wut/internal/smart"
+	"wut/internal/terminal"
+	"wut/internal/ui"
+)
+
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+
+	orig := os.Stdout
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("pipe: %v", err)
+	}
+	os.Stdout = w
+
+	done := make(chan string)
+	go func() {
+		var buf bytes.Buffer
+		_, _ = io.Copy(&buf, r)
+		done <- buf.String()
+	}()
+
+	fn()
+
+	_ = w.Close()
+	os.Stdout = orig
+	return <-done
+}
+
+func TestNoColorDisablesANSI(t *testing.T) {
+	origProfile := lipgloss.ColorProfile()
+	t.Cleanup(func() {
+		lipgloss.SetColorProfile(origProfile)
+		terminal.SetNoColor(false)
+	})
+
+	config.Set(&config.Config{})
+	suggestions := []smart.Suggestion{
+		{Command: "git status", Description: "Show the working tree status", Source: "Smart History", UsageCount: 3},
+		{Command: "git stash", Description: "Stash local changes", Source: "Context"},
+	}
+
+	// Start from a colored profile so the assertions are meaningful even
+	// though the test binary is not attached to a terminal.
+	lipgloss.SetColorProfile(termenv.TrueColor)
+	var colored bytes.Buffer
+	SimpleOutput(&colored, "git", suggestions)
+	if !strings.Contains(colored.String(), "\x1b[") {
+		t.Fatalf("expected ANSI escapes with color enabled, got %q", colored.String())
+	}
+
+	terminal.SetNoColor(true)
+	ui.ApplyColorMode()
+
+	var plain bytes.Buffer
+	SimpleOutput(&plain, "git", suggestions)
+	if strings.Contains(plain.String(), "\x1b[") {
+		t.Errorf("SimpleOutput emitted ANSI escapes with color disabled: %q", plain.String())
+	}
+
+	out := captureStdout(t, func() {
+		if err := showConfig(); err != nil {
+			t.Errorf("showConfig() error = %v", err)
+		}
+	})
+	if strings.Contains(out, "\x1b[") {
+		t.Errorf("showConfig emitted ANSI escapes with color disabled: %q", out)
+	}
+}
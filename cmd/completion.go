@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"wut/internal/config"
+	"wut/internal/corrector"
+	"wut/internal/db"
+	"wut/internal/shell"
+)
+
+// completionCmd prints the completion script for a shell
+var completionCmd = &cobra.Command{
+	Use:   "completion [bash|zsh|fish|powershell|nushell|elvish]",
+	Short: "Generate shell completion script",
+	Long: `Generate the completion script for the specified shell.
+
+Besides subcommands and flags, the scripts complete configuration keys,
+cached command pages and recent history entries.`,
+	Example: `  source <(wut completion bash)
+  wut completion zsh > "${fpath[1]}/_wut"
+  wut completion fish > ~/.config/fish/completions/wut.fish
+  wut completion powershell | Out-String | Invoke-Expression
+  wut completion nushell | save -f ($nu.default-config-dir | path join autoload wut-completions.nu)
+  wut completion elvish > ~/.config/elvish/lib/wut-completion.elv`,
+	ValidArgs:             []string{"bash", "zsh", "fish", "powershell", "nushell", "elvish"},
+	Args:                  cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	DisableFlagsInUseLine: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return genCompletion(os.Stdout, args[0])
+	},
+}
+
+// completionDBTimeout bounds how long a completion waits for a database
+// another wut process is writing to; completing nothing beats a stalled prompt
+const completionDBTimeout = 25 * time.Millisecond
+
+// completionLimit caps the number of dynamic candidates offered
+const completionLimit = 50
+
+func init() {
+	rootCmd.AddCommand(completionCmd)
+}
+
+// errNoCompletion marks shells cobra cannot generate completions for
+var errNoCompletion = errors.New("completion not supported")
+
+// genCompletion writes the completion script for sh to w
+func genCompletion(w io.Writer, sh string) error {
+	switch sh {
+	case "bash":
+		return rootCmd.GenBashCompletionV2(w, true)
+	case "zsh":
+		return rootCmd.GenZshCompletion(w)
+	case "fish":
+		return rootCmd.GenFishCompletion(w, true)
+	case "powershell", "pwsh":
+		return rootCmd.GenPowerShellCompletionWithDesc(w)
+	case "nushell", "elvish":
+		_, err := io.WriteString(w, shell.CompletionScript(sh))
+		return err
+	default:
+		return errNoCompletion
+	}
+}
+
+// completeConfigKeys completes the keys accepted by `wut config --set`
+func completeConfigKeys(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	var keys []string
+	for key := range configFieldMap {
+		// Skip the camelCase aliases
+		if key == strings.ToLower(key) && strings.HasPrefix(key, toComplete) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeConfigProfiles completes --profile with the existing profiles
+func completeConfigProfiles(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	profiles, _ := config.ListProfiles()
+	return profiles, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeIntentCategories completes the categories of wut suggest --category
+func completeIntentCategories(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return corrector.IntentCategories(), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completePageNames completes command names cached in the TLDR database
+func completePageNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if cmd == suggestCmd && len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	storage, err := db.OpenReadOnly(config.GetTLDRDatabasePath(), completionDBTimeout)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	defer storage.Close()
+
+	names, err := storage.ListCommands(0)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var matches []string
+	for _, name := range names {
+		if strings.HasPrefix(name, toComplete) && !slices.Contains(args, name) {
+			matches = append(matches, name)
+		}
+	}
+	return matches, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeHistoryCommands completes recent distinct commands from the
+// history database
+func completeHistoryCommands(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	storage, err := db.OpenReadOnly(config.GetDatabasePath(), completionDBTimeout)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	defer storage.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), completionDBTimeout)
+	defer cancel()
+
+	entries, err := storage.GetRecentUniqueHistory(ctx, completionLimit*10, 5000)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var matches []string
+	for _, entry := range entries {
+		command := strings.TrimSpace(entry.Command)
+		if strings.Contains(command, "\n") || !strings.HasPrefix(command, toComplete) {
+			continue
+		}
+		matches = append(matches, command)
+		if len(matches) >= completionLimit {
+			break
+		}
+	}
+	return matches, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveKeepOrder
+}
+
+// completionCommandNames are the commands cobra runs to complete or print
+// scripts; they skip initialization so completing stays fast and silent
+var completionCommandNames = map[string]bool{
+	"completion":                    true,
+	cobra.ShellCompRequestCmd:       true,
+	cobra.ShellCompNoDescRequestCmd: true,
+}
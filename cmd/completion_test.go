@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"wut/internal/config"
+	"wut/internal/db"
+)
+
+func TestCompletions(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.Config{}
+	cfg.Database.Path = filepath.Join(dir, "wut.db")
+	config.Set(cfg)
+	t.Cleanup(func() { config.Set(&config.Config{}) })
+
+	keys, _ := completeConfigKeys(configCmd, nil, "ui.confirm")
+	if !slices.Equal(keys, []string{"ui.confirm_before_exec", "ui.confirm_dangerous"}) {
+		t.Errorf("completeConfigKeys(ui.confirm) = %v", keys)
+	}
+
+	// Completing against missing databases must not fail or create them
+	if names, _ := completePageNames(dbSyncCmd, nil, ""); len(names) != 0 {
+		t.Errorf("completePageNames() without a cache = %v", names)
+	}
+
+	history, err := db.NewStorage(config.GetDatabasePath())
+	if err != nil {
+		t.Fatalf("NewStorage() error = %v", err)
+	}
+	if _, err := history.AddHistoryBatch(context.Background(), []db.CommandExecution{
+		{Command: "git status"},
+		{Command: "go test ./..."},
+		{Command: "git push"},
+	}); err != nil {
+		t.Fatalf("AddHistoryBatch() error = %v", err)
+	}
+	history.Close()
+
+	tldr, err := db.NewStorage(config.GetTLDRDatabasePath())
+	if err != nil {
+		t.Fatalf("NewStorage() error = %v", err)
+	}
+	for _, name := range []string{"git", "gzip", "tar"} {
+		if err := tldr.SavePage(&db.Page{Name: name, Platform: "common", Language: "en"}); err != nil {
+			t.Fatalf("SavePage() error = %v", err)
+		}
+	}
+	tldr.Close()
+
+	commands, _ := completeHistoryCommands(historyCmd, nil, "git")
+	if !slices.Equal(commands, []string{"git push", "git status"}) {
+		t.Errorf("completeHistoryCommands(git) = %v, want newest first", commands)
+	}
+
+	names, _ := completePageNames(dbSyncCmd, []string{"git"}, "g")
+	if !slices.Equal(names, []string{"gzip"}) {
+		t.Errorf("completePageNames(g) = %v, want pages not yet given", names)
+	}
+}
@@ -2,14 +2,21 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"os"
 	"reflect"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
 
 	"wut/internal/config"
+	"wut/internal/db"
 	"wut/internal/logger"
+	"wut/internal/performance"
+	"wut/internal/smart"
+	"wut/internal/terminal"
 	"wut/internal/ui"
 
 	"github.com/charmbracelet/bubbles/key"
@@ -17,6 +24,7 @@ import (
 	"github.com/charmbracelet/huh"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 )
 
 // configCmd represents the config command
@@ -26,16 +34,28 @@ var configCmd = &cobra.Command{
 	Long: `View, get, set, and reset configuration values.
 
 Supports dot notation for nested keys (e.g., 'ui.theme', 'fuzzy.enabled').
-Boolean values can be: true, false, 1, 0, yes, no, on, off`,
+Boolean values can be: true, false, 1, 0, yes, no, on, off
+
+Profiles are separate config files, e.g. for work and personal use. The
+selected profile is remembered; --config and WUT_CONFIG still take
+precedence over it.`,
 	Example: `  wut config                          # Show all config
   wut config --list                   # List all keys
   wut config --get ui.theme           # Get specific value
   wut config --set ui.theme dark      # Set value
   wut config --set fuzzy.enabled true # Enable fuzzy matching
+  wut config --set smart.weights.fuzzy_match 0.6
   wut config --edit                   # Open in default editor
   wut config --reset                  # Reset to defaults
+  wut config --diff                   # Show settings changed from the defaults
   wut config --import config.yaml     # Import from file
-  wut config --export backup.yaml     # Export to file`,
+  wut config --import x.yaml --merge  # Only change the settings in x.yaml
+  wut config --export backup.yaml     # Export to file
+  wut config --new-profile work       # Copy the current config to a profile
+  wut config --profile work           # Use the work profile from now on
+  wut config --list-profiles          # List profiles
+  wut config --sources                # List external suggestion sources
+  wut config --disable-source jira    # Stop running a source`,
 	RunE: runConfig,
 }
 
@@ -47,8 +67,19 @@ var (
 	configReset  bool
 	configEdit   bool
 	configImport string
+	configMerge  bool
 	configExport string
 	configPath   bool
+
+	configProfile      string
+	configListProfiles bool
+	configNewProfile   string
+
+	configDiff bool
+
+	configSources       bool
+	configEnableSource  string
+	configDisableSource string
 )
 
 func init() {
@@ -56,13 +87,28 @@ func init() {
 
 	configCmd.Flags().BoolVarP(&configList, "list", "l", false, "list all configuration keys")
 	configCmd.Flags().StringVarP(&configGet, "get", "g", "", "get configuration value by key (supports dot notation)")
-	configCmd.Flags().StringVarP(&configSet, "set", "s", "", "set configuration key (use with --value)")
+	configCmd.Flags().StringVarP(&configSet, "set", "s", "", "set configuration key (value from --value or the next argument)")
 	configCmd.Flags().StringVarP(&configValue, "value", "v", "", "value to set")
 	configCmd.Flags().BoolVarP(&configReset, "reset", "r", false, "reset to default configuration")
 	configCmd.Flags().BoolVarP(&configEdit, "edit", "e", false, "open config file in default editor")
-	configCmd.Flags().StringVar(&configImport, "import", "", "import configuration from file")
+	configCmd.Flags().StringVar(&configImport, "import", "", "import configuration from file, replacing the current one")
+	configCmd.Flags().BoolVar(&configMerge, "merge", false, "with --import, only change the settings the file has")
 	configCmd.Flags().StringVar(&configExport, "export", "", "export configuration to file")
 	configCmd.Flags().BoolVar(&configPath, "path", false, "show config file path")
+	configCmd.Flags().StringVar(&configProfile, "profile", "", "switch to a named config profile (default for config.yaml)")
+	configCmd.Flags().BoolVar(&configListProfiles, "list-profiles", false, "list config profiles")
+	configCmd.Flags().StringVar(&configNewProfile, "new-profile", "", "create a profile as a copy of the current config")
+	configCmd.Flags().BoolVar(&configDiff, "diff", false, "show settings that differ from the defaults (default → current)")
+	configCmd.Flags().BoolVar(&outputJSON, "json", false, "print --diff as JSON")
+	configCmd.Flags().BoolVar(&configSources, "sources", false, "list external suggestion sources")
+	configCmd.Flags().StringVar(&configEnableSource, "enable-source", "", "run an external suggestion source again")
+	configCmd.Flags().StringVar(&configDisableSource, "disable-source", "", "stop running an external suggestion source")
+
+	_ = configCmd.RegisterFlagCompletionFunc("set", completeConfigKeys)
+	_ = configCmd.RegisterFlagCompletionFunc("get", completeConfigKeys)
+	_ = configCmd.RegisterFlagCompletionFunc("profile", completeConfigProfiles)
+	_ = configCmd.RegisterFlagCompletionFunc("enable-source", completeSourceNames)
+	_ = configCmd.RegisterFlagCompletionFunc("disable-source", completeSourceNames)
 }
 
 func runConfig(cmd *cobra.Command, args []string) error {
@@ -74,6 +120,52 @@ func runConfig(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
+	// Handle profiles
+	if configNewProfile != "" {
+		path, err := config.NewProfile(configNewProfile)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("✅ Created profile %s at %s\n", configNewProfile, path)
+		fmt.Println(ui.Muted(fmt.Sprintf("Switch to it with 'wut config --profile %s'.", configNewProfile)))
+		return nil
+	}
+	if configProfile != "" {
+		if err := config.SetActiveProfile(configProfile); err != nil {
+			return err
+		}
+		path, _ := config.ProfilePath(configProfile)
+		fmt.Printf("✅ Switched to profile %s (%s)\n", configProfile, path)
+		if cfgFile != "" {
+			fmt.Println(ui.Muted("--config or " + configFileEnv + " is set and still takes precedence."))
+		}
+		return nil
+	}
+	if configListProfiles {
+		return listConfigProfiles()
+	}
+
+	// Handle external sources
+	if configSources {
+		listConfigSources(os.Stdout, smart.ExternalSources(config.Get().Smart, config.GetSourcesDir()))
+		return nil
+	}
+	if configEnableSource != "" || configDisableSource != "" {
+		name, enabled := configEnableSource, true
+		if name == "" {
+			name, enabled = configDisableSource, false
+		}
+		if err := toggleSource(name, enabled); err != nil {
+			return err
+		}
+		state := "Disabled"
+		if enabled {
+			state = "Enabled"
+		}
+		fmt.Printf("✅ %s source %s\n", state, name)
+		return nil
+	}
+
 	// Handle edit
 	if configEdit {
 		return editConfig()
@@ -85,7 +177,11 @@ func runConfig(cmd *cobra.Command, args []string) error {
 			log.Error("failed to import config", "error", err)
 			return fmt.Errorf("failed to import config: %w", err)
 		}
-		fmt.Printf("Configuration imported from %s\n", configImport)
+		if configMerge {
+			fmt.Printf("Configuration merged from %s\n", configImport)
+		} else {
+			fmt.Printf("Configuration imported from %s\n", configImport)
+		}
 		return nil
 	}
 
@@ -109,6 +205,11 @@ func runConfig(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
+	// Handle diff
+	if configDiff {
+		return diffConfig()
+	}
+
 	// Handle list
 	if configList {
 		return listConfigKeys()
@@ -127,14 +228,25 @@ func runConfig(cmd *cobra.Command, args []string) error {
 
 	// Handle set
 	if configSet != "" {
+		if configValue == "" && len(args) > 0 {
+			configValue = args[0]
+		}
 		if err := setConfigValue(configSet, configValue); err != nil {
 			log.Error("failed to set config value", "key", configSet, "error", err)
 			return err
 		}
 		fmt.Printf("✅ Set %s = %v\n", configSet, configValue)
+		if configSet == "database.type" {
+			printEngineMigrateHint(config.Get().Database.Type)
+		}
 		return nil
 	}
 
+	// Plain text dump when the wizard cannot run (e.g. piped)
+	if !terminal.IsInteractive() {
+		return showConfig()
+	}
+
 	// Default: show configuration wizard (TUI), fall back to plain text on error
 	if err := runConfigUI(); err != nil {
 		return showConfig()
@@ -144,16 +256,25 @@ func runConfig(cmd *cobra.Command, args []string) error {
 
 func runConfigUI() error {
 	cfg := config.Get()
+	encryptData := cfg.Privacy.EncryptData
+	databaseType := cfg.Database.Type
 
 	// Convert numerical settings to strings for inputs
 	fuzzyDistance := strconv.Itoa(cfg.Fuzzy.MaxDistance)
 	fuzzyThreshold := strconv.FormatFloat(cfg.Fuzzy.Threshold, 'f', 2, 64)
+	minConfidence := strconv.FormatFloat(cfg.Corrector.MinConfidence, 'f', 2, 64)
+	historyThreshold := strconv.FormatFloat(cfg.Corrector.HistoryThreshold, 'f', 2, 64)
 	uiPagination := strconv.Itoa(cfg.UI.Pagination)
 	dbSize := strconv.Itoa(cfg.Database.MaxSize)
 	tldrSyncInterval := strconv.Itoa(cfg.TLDR.AutoSyncInterval)
 	historyMaxEntries := strconv.Itoa(cfg.History.MaxEntries)
 	logMaxSize := strconv.Itoa(cfg.Logging.MaxSize)
 	logMaxAge := strconv.Itoa(cfg.Logging.MaxAge)
+	weights := make(map[string]*string, len(scoringWeightKeys))
+	for _, key := range scoringWeightKeys {
+		value := strconv.FormatFloat(scoringWeight(cfg, key), 'f', 2, 64)
+		weights[key] = &value
+	}
 	confirmSave := false
 
 	// Custom keymap: Add Space to Toggle on Confirm, matching other fields
@@ -201,6 +322,24 @@ func runConfigUI() error {
 				Affirmative("  Yes  ").Negative("  No  ").
 				WithButtonAlignment(lipgloss.Left).
 				Value(&cfg.UI.ShowExplanations),
+			huh.NewConfirm().
+				Title("Confirm Before Running").
+				Description("Show a dry-run summary before executing commands from the TUIs").
+				Affirmative("  Yes  ").Negative("  No  ").
+				WithButtonAlignment(lipgloss.Left).
+				Value(&cfg.UI.ConfirmBeforeExec),
+			huh.NewConfirm().
+				Title("Show Preview Pane").
+				Description("Preview TLDR examples for the highlighted command (ctrl+o toggles)").
+				Affirmative("  Yes  ").Negative("  No  ").
+				WithButtonAlignment(lipgloss.Left).
+				Value(&cfg.UI.ShowPreview),
+			huh.NewConfirm().
+				Title("Confirm Dangerous Commands").
+				Description("Require a second keypress before running a destructive suggestion").
+				Affirmative("  Yes  ").Negative("  No  ").
+				WithButtonAlignment(lipgloss.Left).
+				Value(&cfg.UI.ConfirmDangerous),
 		).Title("  Display"),
 
 		// ── 3. Fuzzy Matching ─────────────────────────────────────
@@ -225,6 +364,42 @@ func runConfigUI() error {
 				Title("Match Threshold").
 				Description("Minimum similarity score, 0.0 to 1.0").
 				Value(&fuzzyThreshold),
+			huh.NewSelect[string]().
+				Title("Fuzzy Algorithm").
+				Description("How typos are scored against commands").
+				Options(
+					huh.NewOption("Hybrid (in-order characters, then edit distance)", string(performance.AlgorithmHybrid)),
+					huh.NewOption("Levenshtein", string(performance.AlgorithmLevenshtein)),
+					huh.NewOption("Damerau (swapped letters count once)", string(performance.AlgorithmDamerau)),
+					huh.NewOption("Jaro-Winkler (favors a shared start)", string(performance.AlgorithmJaroWinkler)),
+				).
+				Value(&cfg.Fuzzy.Algorithm),
+			huh.NewInput().
+				Title("Correction Confidence").
+				Description("Minimum confidence for a typo fix, 0.0 to 1.0").
+				Value(&minConfidence),
+			huh.NewConfirm().
+				Title("Correct From History").
+				Description("Propose similar past commands as fixes").
+				Affirmative("  Yes  ").Negative("  No  ").
+				WithButtonAlignment(lipgloss.Left).
+				Value(&cfg.Corrector.UseHistory),
+			huh.NewInput().
+				Title("History Match Threshold").
+				Description("Minimum score for fixing to a past command, 0.0 to 1.0").
+				Value(&historyThreshold),
+			huh.NewConfirm().
+				Title("Keyboard-Aware Correction").
+				Description("Prefer fixes for slips onto neighbouring keys").
+				Affirmative("  Yes  ").Negative("  No  ").
+				WithButtonAlignment(lipgloss.Left).
+				Value(&cfg.Corrector.KeyboardAware),
+			huh.NewConfirm().
+				Title("Spell-Check Task Queries").
+				Description("Fix misspelled words before matching a task to commands").
+				Affirmative("  Yes  ").Negative("  No  ").
+				WithButtonAlignment(lipgloss.Left).
+				Value(&cfg.Corrector.SemanticSpellcheck),
 		).Title("  Fuzzy Matching"),
 
 		// ── 4. TLDR Pages ─────────────────────────────────────────
@@ -287,13 +462,13 @@ func runConfigUI() error {
 				Title("Engine").
 				Description("Storage backend for local data").
 				Options(
-					huh.NewOption("BBolt (default)", "bbolt"),
-					huh.NewOption("SQLite", "sqlite"),
+					huh.NewOption("BBolt (default)", db.EngineBolt),
+					huh.NewOption("SQLite", db.EngineSQLite),
 				).
 				Value(&cfg.Database.Type),
 			huh.NewInput().
 				Title("Max Size (MB)").
-				Description("Maximum database file size").
+				Description("Maximum database file size, also the cap on backups").
 				Value(&dbSize),
 			huh.NewConfirm().
 				Title("Automatic Backups").
@@ -332,14 +507,14 @@ func runConfigUI() error {
 				WithButtonAlignment(lipgloss.Left).
 				Value(&cfg.Privacy.LocalOnly),
 			huh.NewConfirm().
-				Title("Encrypt Data").
-				Description("Encrypt locally stored data at rest").
+				Title("Encrypt History").
+				Description("Encrypt stored commands with a passphrase").
 				Affirmative("  Yes  ").Negative("  No  ").
 				WithButtonAlignment(lipgloss.Left).
 				Value(&cfg.Privacy.EncryptData),
 			huh.NewConfirm().
 				Title("Anonymize Commands").
-				Description("Strip sensitive arguments from history").
+				Description("Redact tokens and passwords before they are stored").
 				Affirmative("  Yes  ").Negative("  No  ").
 				WithButtonAlignment(lipgloss.Left).
 				Value(&cfg.Privacy.AnonymizeCommands),
@@ -357,6 +532,14 @@ func runConfigUI() error {
 					huh.NewOption("Error", "error"),
 				).
 				Value(&cfg.Logging.Level),
+			huh.NewSelect[string]().
+				Title("Log Format").
+				Description("JSON writes one object per line for log aggregators").
+				Options(
+					huh.NewOption("Text", "text"),
+					huh.NewOption("JSON", "json"),
+				).
+				Value(&cfg.Logging.Format),
 			huh.NewInput().
 				Title("Max Log Size (MB)").
 				Description("Rotate log file after this size").
@@ -367,7 +550,39 @@ func runConfigUI() error {
 				Value(&logMaxAge),
 		).Title("  Logging"),
 
-		// ── 10. Confirm ───────────────────────────────────────────
+		// ── 10. Ranking ───────────────────────────────────────────
+		huh.NewGroup(
+			huh.NewInput().
+				Title("Exact Match").
+				Description("Boost when the query equals the command").
+				Value(weights["exact_match"]),
+			huh.NewInput().
+				Title("Prefix Match").
+				Description("Boost when the command starts with the query").
+				Value(weights["prefix_match"]),
+			huh.NewInput().
+				Title("Contains Match").
+				Description("Boost when the command contains the query").
+				Value(weights["contains_match"]),
+			huh.NewInput().
+				Title("Fuzzy Match").
+				Description("Weight of approximate matches").
+				Value(weights["fuzzy_match"]),
+			huh.NewInput().
+				Title("History Frequency").
+				Description("Weight of how often you run a command").
+				Value(weights["history_freq"]),
+			huh.NewInput().
+				Title("Recency").
+				Description("Weight of how recently you ran a command").
+				Value(weights["recency"]),
+			huh.NewInput().
+				Title("Context Relevance").
+				Description("Weight of matches with the current project").
+				Value(weights["context_relevance"]),
+		).Title("  Ranking"),
+
+		// ── 11. Confirm ───────────────────────────────────────────
 		huh.NewGroup(
 			huh.NewConfirm().
 				Title("Save all changes?").
@@ -404,6 +619,12 @@ func runConfigUI() error {
 	if v, err := strconv.ParseFloat(fuzzyThreshold, 64); err == nil {
 		cfg.Fuzzy.Threshold = v
 	}
+	if v, err := strconv.ParseFloat(minConfidence, 64); err == nil {
+		cfg.Corrector.MinConfidence = v
+	}
+	if v, err := strconv.ParseFloat(historyThreshold, 64); err == nil {
+		cfg.Corrector.HistoryThreshold = v
+	}
 	if v, err := strconv.Atoi(uiPagination); err == nil {
 		cfg.UI.Pagination = v
 	}
@@ -422,6 +643,19 @@ func runConfigUI() error {
 	if v, err := strconv.Atoi(logMaxAge); err == nil {
 		cfg.Logging.MaxAge = v
 	}
+	for _, key := range scoringWeightKeys {
+		// Only pin weights that were changed, so the rest follow the defaults
+		if v, err := strconv.ParseFloat(*weights[key], 64); err == nil && *weights[key] != strconv.FormatFloat(scoringWeight(cfg, key), 'f', 2, 64) {
+			_ = setScoringWeight(cfg, key, v)
+		}
+	}
+
+	if cfg.Privacy.EncryptData != encryptData {
+		if err := applyEncryption(context.Background(), cfg.Privacy.EncryptData); err != nil {
+			cfg.Privacy.EncryptData = encryptData
+			fmt.Printf("⚠️  Encryption setting not changed: %v\n", err)
+		}
+	}
 
 	// Save the config
 	config.Set(cfg)
@@ -431,6 +665,9 @@ func runConfigUI() error {
 
 	fmt.Println()
 	fmt.Println("✅ Configuration saved successfully!")
+	if cfg.Database.Type != databaseType {
+		printEngineMigrateHint(cfg.Database.Type)
+	}
 	return nil
 }
 
@@ -438,8 +675,8 @@ func showConfig() error {
 	cfg := config.Get()
 
 	// Styles
-	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#7C3AED"))
-	keyStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#6B7280"))
+	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(ui.ColorBrand)
+	keyStyle := lipgloss.NewStyle().Foreground(ui.ColorMuted)
 	valueStyle := lipgloss.NewStyle().Bold(true)
 
 	fmt.Println()
@@ -458,6 +695,12 @@ func showConfig() error {
 	printConfigItem("  Case Sensitive", fmt.Sprintf("%v", cfg.Fuzzy.CaseSensitive), keyStyle, valueStyle)
 	printConfigItem("  Max Distance", fmt.Sprintf("%d", cfg.Fuzzy.MaxDistance), keyStyle, valueStyle)
 	printConfigItem("  Threshold", fmt.Sprintf("%.2f", cfg.Fuzzy.Threshold), keyStyle, valueStyle)
+	printConfigItem("  Algorithm", cfg.Fuzzy.Algorithm, keyStyle, valueStyle)
+	printConfigItem("  Correction Confidence", fmt.Sprintf("%.2f", cfg.Corrector.MinConfidence), keyStyle, valueStyle)
+	printConfigItem("  Keyboard-Aware", fmt.Sprintf("%v", cfg.Corrector.KeyboardAware), keyStyle, valueStyle)
+	printConfigItem("  Correct From History", fmt.Sprintf("%v", cfg.Corrector.UseHistory), keyStyle, valueStyle)
+	printConfigItem("  History Threshold", fmt.Sprintf("%.2f", cfg.Corrector.HistoryThreshold), keyStyle, valueStyle)
+	printConfigItem("  Spell-Check Task Queries", fmt.Sprintf("%v", cfg.Corrector.SemanticSpellcheck), keyStyle, valueStyle)
 	fmt.Println()
 
 	// UI config
@@ -467,6 +710,9 @@ func showConfig() error {
 	printConfigItem("  Show Explanations", fmt.Sprintf("%v", cfg.UI.ShowExplanations), keyStyle, valueStyle)
 	printConfigItem("  Syntax Highlighting", fmt.Sprintf("%v", cfg.UI.SyntaxHighlighting), keyStyle, valueStyle)
 	printConfigItem("  Pagination", fmt.Sprintf("%d", cfg.UI.Pagination), keyStyle, valueStyle)
+	printConfigItem("  Confirm Before Exec", fmt.Sprintf("%v", cfg.UI.ConfirmBeforeExec), keyStyle, valueStyle)
+	printConfigItem("  Show Preview", fmt.Sprintf("%v", cfg.UI.ShowPreview), keyStyle, valueStyle)
+	printConfigItem("  Confirm Dangerous", fmt.Sprintf("%v", cfg.UI.ConfirmDangerous), keyStyle, valueStyle)
 	fmt.Println()
 
 	// Database config
@@ -475,6 +721,8 @@ func showConfig() error {
 	printConfigItem("  Path", cfg.Database.Path, keyStyle, valueStyle)
 	printConfigItem("  Max Size", fmt.Sprintf("%d MB", cfg.Database.MaxSize), keyStyle, valueStyle)
 	printConfigItem("  Backup Enabled", fmt.Sprintf("%v", cfg.Database.BackupEnabled), keyStyle, valueStyle)
+	printConfigItem("  Backup Interval", fmt.Sprintf("%d hours", cfg.Database.BackupInterval), keyStyle, valueStyle)
+	printConfigItem("  Max Backups", fmt.Sprintf("%d", cfg.Database.MaxBackups), keyStyle, valueStyle)
 	fmt.Println()
 
 	// History config
@@ -510,6 +758,7 @@ func showConfig() error {
 	printConfigItem("  Max Size", fmt.Sprintf("%d MB", cfg.Logging.MaxSize), keyStyle, valueStyle)
 	printConfigItem("  Max Backups", fmt.Sprintf("%d", cfg.Logging.MaxBackups), keyStyle, valueStyle)
 	printConfigItem("  Max Age", fmt.Sprintf("%d days", cfg.Logging.MaxAge), keyStyle, valueStyle)
+	printConfigItem("  Format", cfg.Logging.Format, keyStyle, valueStyle)
 	fmt.Println()
 
 	// TLDR config
@@ -521,6 +770,13 @@ func showConfig() error {
 	printConfigItem("  Default Platform", cfg.TLDR.DefaultPlatform, keyStyle, valueStyle)
 	fmt.Println()
 
+	// Smart ranking weights
+	fmt.Println(headerStyle.Render("Ranking Weights"))
+	for _, key := range scoringWeightKeys {
+		printConfigItem("  "+key, strconv.FormatFloat(scoringWeight(cfg, key), 'f', -1, 64), keyStyle, valueStyle)
+	}
+	fmt.Println()
+
 	// Show config file path
 	fmt.Println(ui.HiBlackf("Configuration file: %s", getConfigFile()))
 	fmt.Println()
@@ -554,6 +810,7 @@ var configFieldMap = map[string]configField{
 	"fuzzy.max_distance":   {[]int{1, 2}, "int", setInt},
 	"fuzzy.maxDistance":    {[]int{1, 2}, "int", setInt},
 	"fuzzy.threshold":      {[]int{1, 3}, "float64", setFloat64},
+	"fuzzy.algorithm":      {[]int{1, 4}, "string", setFuzzyAlgorithm},
 	// UI
 	"ui.theme":               {[]int{2, 0}, "string", setString},
 	"ui.show_confidence":     {[]int{2, 1}, "bool", setBool},
@@ -563,8 +820,14 @@ var configFieldMap = map[string]configField{
 	"ui.syntax_highlighting": {[]int{2, 3}, "bool", setBool},
 	"ui.syntaxHighlighting":  {[]int{2, 3}, "bool", setBool},
 	"ui.pagination":          {[]int{2, 4}, "int", setInt},
+	"ui.confirm_before_exec": {[]int{2, 6}, "bool", setBool},
+	"ui.confirmBeforeExec":   {[]int{2, 6}, "bool", setBool},
+	"ui.show_preview":        {[]int{2, 7}, "bool", setBool},
+	"ui.showPreview":         {[]int{2, 7}, "bool", setBool},
+	"ui.confirm_dangerous":   {[]int{2, 8}, "bool", setBool},
+	"ui.confirmDangerous":    {[]int{2, 8}, "bool", setBool},
 	// Database
-	"database.type":            {[]int{3, 0}, "string", setString},
+	"database.type":            {[]int{3, 0}, "string", setDatabaseType},
 	"database.path":            {[]int{3, 1}, "string", setString},
 	"database.max_size":        {[]int{3, 2}, "int", setInt},
 	"database.maxSize":         {[]int{3, 2}, "int", setInt},
@@ -572,6 +835,8 @@ var configFieldMap = map[string]configField{
 	"database.backupEnabled":   {[]int{3, 3}, "bool", setBool},
 	"database.backup_interval": {[]int{3, 4}, "int", setInt},
 	"database.backupInterval":  {[]int{3, 4}, "int", setInt},
+	"database.max_backups":     {[]int{3, 5}, "int", setInt},
+	"database.maxBackups":      {[]int{3, 5}, "int", setInt},
 	// History
 	"history.enabled":         {[]int{4, 0}, "bool", setBool},
 	"history.max_entries":     {[]int{4, 1}, "int", setInt},
@@ -612,6 +877,7 @@ var configFieldMap = map[string]configField{
 	"logging.maxBackups":  {[]int{8, 3}, "int", setInt},
 	"logging.max_age":     {[]int{8, 4}, "int", setInt},
 	"logging.maxAge":      {[]int{8, 4}, "int", setInt},
+	"logging.format":      {[]int{8, 5}, "string", setString},
 	// TLDR
 	"tldr.enabled":            {[]int{9, 0}, "bool", setBool},
 	"tldr.auto_sync":          {[]int{9, 1}, "bool", setBool},
@@ -626,6 +892,20 @@ var configFieldMap = map[string]configField{
 	"tldr.maxCacheAge":        {[]int{9, 5}, "int", setInt},
 	"tldr.default_platform":   {[]int{9, 6}, "string", setString},
 	"tldr.defaultPlatform":    {[]int{9, 6}, "string", setString},
+	// Smart
+	"smart.source_timeout": {[]int{10, 3}, "int", setInt},
+	"smart.sourceTimeout":  {[]int{10, 3}, "int", setInt},
+	// Corrector
+	"corrector.min_confidence":      {[]int{11, 0}, "float64", setFloat64},
+	"corrector.minConfidence":       {[]int{11, 0}, "float64", setFloat64},
+	"corrector.keyboard_aware":      {[]int{11, 1}, "bool", setBool},
+	"corrector.keyboardAware":       {[]int{11, 1}, "bool", setBool},
+	"corrector.history_threshold":   {[]int{11, 2}, "float64", setFloat64},
+	"corrector.historyThreshold":    {[]int{11, 2}, "float64", setFloat64},
+	"corrector.use_history":         {[]int{11, 3}, "bool", setBool},
+	"corrector.useHistory":          {[]int{11, 3}, "bool", setBool},
+	"corrector.semantic_spellcheck": {[]int{11, 4}, "bool", setBool},
+	"corrector.semanticSpellcheck":  {[]int{11, 4}, "bool", setBool},
 }
 
 var configCustomGetters = map[string]func(any) (any, error){
@@ -650,6 +930,8 @@ var configCustomSetters = map[string]func(any, string) error{
 	"shell.hooks.nushell":    setShellHook("nushell"),
 	"shell.hooks.xonsh":      setShellHook("xonsh"),
 	"shell.hooks.elvish":     setShellHook("elvish"),
+	"privacy.encrypt_data":   setEncryptData,
+	"privacy.encryptdata":    setEncryptData,
 }
 
 // Setter functions
@@ -661,6 +943,31 @@ func setString(v reflect.Value, s string) error {
 	return nil
 }
 
+// databaseEngines are the storage engines WUT implements, the default first
+var databaseEngines = db.Engines
+
+// setDatabaseType accepts only the storage engines WUT implements
+func setDatabaseType(v reflect.Value, s string) error {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if !slices.Contains(databaseEngines, s) {
+		return fmt.Errorf("unsupported database engine %q: available engines are %s", s, strings.Join(databaseEngines, ", "))
+	}
+	return setString(v, s)
+}
+
+// setFuzzyAlgorithm accepts only the algorithms the fuzzy matcher implements
+func setFuzzyAlgorithm(v reflect.Value, s string) error {
+	algorithm, ok := performance.ParseAlgorithm(s)
+	if !ok {
+		names := make([]string, len(performance.Algorithms))
+		for i, a := range performance.Algorithms {
+			names[i] = string(a)
+		}
+		return fmt.Errorf("unsupported fuzzy algorithm %q: available algorithms are %s", s, strings.Join(names, ", "))
+	}
+	return setString(v, string(algorithm))
+}
+
 func setBool(v reflect.Value, s string) error {
 	if v.Kind() != reflect.Bool {
 		return fmt.Errorf("expected bool, got %s", v.Kind())
@@ -700,7 +1007,11 @@ func getConfigValue(key string) (any, error) {
 	key = strings.ToLower(strings.TrimSpace(key))
 	key = strings.ReplaceAll(key, " ", ".")
 
-	cfg := config.Get()
+	return configValueOf(config.Get(), key)
+}
+
+// configValueOf returns the value of a normalized key in cfg
+func configValueOf(cfg *config.Config, key string) (any, error) {
 	if getter, ok := configCustomGetters[key]; ok {
 		return getter(cfg)
 	}
@@ -723,19 +1034,26 @@ func getConfigValue(key string) (any, error) {
 
 func setConfigValue(key, value string) error {
 	if value == "" {
-		return fmt.Errorf("--value is required when using --set")
+		return fmt.Errorf("a value is required: wut config --set <key> <value>")
 	}
 
+	if err := applyConfigValue(config.Get(), key, value); err != nil {
+		return err
+	}
+	return config.Save()
+}
+
+// applyConfigValue validates value and stores it under key in cfg
+func applyConfigValue(cfg *config.Config, key, value string) error {
 	// Normalize key
 	key = strings.ToLower(strings.TrimSpace(key))
 	key = strings.ReplaceAll(key, " ", ".")
 
-	cfg := config.Get()
 	if setter, ok := configCustomSetters[key]; ok {
 		if err := setter(cfg, value); err != nil {
 			return fmt.Errorf("failed to set %s: %w", key, err)
 		}
-		return config.Save()
+		return nil
 	}
 
 	field, ok := configFieldMap[key]
@@ -754,28 +1072,28 @@ func setConfigValue(key, value string) error {
 	if err := field.setter(v, value); err != nil {
 		return fmt.Errorf("failed to set %s: %w", key, err)
 	}
-
-	// Save the config
-	return config.Save()
+	return nil
 }
 
 func listConfigKeys() error {
 	fmt.Println()
-	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#7C3AED"))
+	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(ui.ColorBrand)
 	fmt.Println(headerStyle.Render("Available Configuration Keys"))
 	fmt.Println()
 
 	groups := map[string][]string{
-		"app":      {},
-		"fuzzy":    {},
-		"ui":       {},
-		"database": {},
-		"history":  {},
-		"context":  {},
-		"shell":    {},
-		"privacy":  {},
-		"logging":  {},
-		"tldr":     {},
+		"app":       {},
+		"fuzzy":     {},
+		"ui":        {},
+		"database":  {},
+		"history":   {},
+		"context":   {},
+		"shell":     {},
+		"privacy":   {},
+		"logging":   {},
+		"tldr":      {},
+		"smart":     {},
+		"corrector": {},
 	}
 
 	for key := range configFieldMap {
@@ -800,7 +1118,7 @@ func listConfigKeys() error {
 		}
 	}
 
-	groupOrder := []string{"app", "fuzzy", "ui", "database", "history", "context", "shell", "privacy", "logging", "tldr"}
+	groupOrder := []string{"app", "fuzzy", "ui", "database", "history", "context", "shell", "privacy", "logging", "tldr", "smart", "corrector"}
 	for _, group := range groupOrder {
 		keys := groups[group]
 		if len(keys) == 0 {
@@ -818,6 +1136,7 @@ func listConfigKeys() error {
 	fmt.Println("  wut config --get ui.theme")
 	fmt.Println("  wut config --set fuzzy.enabled --value true")
 	fmt.Println("  wut config --set logging.level --value debug")
+	fmt.Println("  wut config --set smart.weights.fuzzy_match 0.6")
 
 	return nil
 }
@@ -853,6 +1172,76 @@ func setShellHook(name string) func(any, string) error {
 	}
 }
 
+// scoringWeightKeys are the smart.weights keys, in the order of
+// smart.ScoringWeights
+var scoringWeightKeys = []string{
+	"exact_match", "prefix_match", "contains_match", "fuzzy_match",
+	"history_freq", "recency", "context_relevance",
+}
+
+func init() {
+	for _, key := range scoringWeightKeys {
+		configCustomGetters["smart.weights."+key] = getScoringWeight(key)
+		configCustomSetters["smart.weights."+key] = setScoringWeightValue(key)
+	}
+}
+
+// scoringWeightFieldName is the Go field name of a smart.weights key, shared
+// by config.ScoringWeightsConfig and smart.ScoringWeights
+func scoringWeightFieldName(key string) string {
+	var b strings.Builder
+	for part := range strings.SplitSeq(key, "_") {
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return b.String()
+}
+
+// scoringWeight returns the weight the smart engine uses for key: the
+// configured value, or the default when unset
+func scoringWeight(cfg *config.Config, key string) float64 {
+	weights := smart.WeightsFromConfig(cfg.Smart.Weights)
+	return reflect.ValueOf(weights).FieldByName(scoringWeightFieldName(key)).Float()
+}
+
+func setScoringWeight(cfg *config.Config, key string, value float64) error {
+	if value < 0 {
+		return fmt.Errorf("weight cannot be negative: %v", value)
+	}
+	field := reflect.ValueOf(&cfg.Smart.Weights).Elem().FieldByName(scoringWeightFieldName(key))
+	field.Set(reflect.ValueOf(&value))
+	return nil
+}
+
+func getScoringWeight(key string) func(any) (any, error) {
+	return func(cfgAny any) (any, error) {
+		cfg, ok := cfgAny.(*config.Config)
+		if !ok || cfg == nil {
+			return nil, fmt.Errorf("configuration unavailable")
+		}
+		return scoringWeight(cfg, key), nil
+	}
+}
+
+// setScoringWeightValue sets a weight; "default" unsets it
+func setScoringWeightValue(key string) func(any, string) error {
+	return func(cfgAny any, raw string) error {
+		cfg, ok := cfgAny.(*config.Config)
+		if !ok || cfg == nil {
+			return fmt.Errorf("configuration unavailable")
+		}
+		if strings.EqualFold(strings.TrimSpace(raw), "default") {
+			field := reflect.ValueOf(&cfg.Smart.Weights).Elem().FieldByName(scoringWeightFieldName(key))
+			field.SetZero()
+			return nil
+		}
+		value, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+		if err != nil {
+			return fmt.Errorf("invalid float: %s", raw)
+		}
+		return setScoringWeight(cfg, key, value)
+	}
+}
+
 func parseBool(s string) (bool, error) {
 	s = strings.ToLower(strings.TrimSpace(s))
 	switch s {
@@ -865,15 +1254,127 @@ func parseBool(s string) (bool, error) {
 	}
 }
 
+// configKeys returns every config key in order, without the camelCase
+// aliases
+func configKeys() []string {
+	var keys []string
+	for key := range configFieldMap {
+		if key == strings.ToLower(key) {
+			keys = append(keys, key)
+		}
+	}
+	for key := range configCustomGetters {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// configChange is a setting whose value differs from its default
+type configChange struct {
+	Key     string `json:"key"`
+	Default any    `json:"default"`
+	Current any    `json:"current"`
+}
+
+// configChanges returns the settings of current that differ from defaults
+func configChanges(defaults, current *config.Config) ([]configChange, error) {
+	var changes []configChange
+	for _, key := range configKeys() {
+		def, err := configValueOf(defaults, key)
+		if err != nil {
+			return nil, err
+		}
+		cur, err := configValueOf(current, key)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(def, cur) {
+			changes = append(changes, configChange{Key: key, Default: def, Current: cur})
+		}
+	}
+	return changes, nil
+}
+
+// diffConfig prints the settings that differ from what --reset restores
+func diffConfig() error {
+	defaults, err := config.Defaults()
+	if err != nil {
+		return err
+	}
+	changes, err := configChanges(defaults, config.Get())
+	if err != nil {
+		return err
+	}
+
+	if outputJSON {
+		if changes == nil {
+			changes = []configChange{}
+		}
+		return writeJSON(configDiffJSON{SchemaVersion: jsonSchemaVersion, Changes: changes})
+	}
+
+	if len(changes) == 0 {
+		fmt.Println("No settings differ from the defaults")
+		return nil
+	}
+	keyStyle := lipgloss.NewStyle().Foreground(ui.ColorMuted)
+	valueStyle := lipgloss.NewStyle().Bold(true)
+	for _, c := range changes {
+		printConfigItem(c.Key, fmt.Sprintf("%v → %v", c.Default, c.Current), keyStyle, valueStyle)
+	}
+	return nil
+}
+
 func resetConfig() error {
 	return config.Reset()
 }
 
+// editConfig opens the config file in the editor and checks it once the
+// editor exits. A file that no longer parses is opened again or, if the user
+// prefers or cannot be asked, replaced by the copy taken before editing.
 func editConfig() error {
-	return config.Edit()
+	backup, err := config.BackupForEdit()
+	if err != nil {
+		return err
+	}
+	path := config.GetConfigPath()
+
+	for {
+		if err := config.OpenEditor(); err != nil {
+			return err
+		}
+		verr := config.ValidateFile(path)
+		if verr == nil {
+			fmt.Println("✅ Configuration saved")
+			return nil
+		}
+
+		fmt.Println(ui.Error(verr.Error()))
+		if !term.IsTerminal(int(os.Stdin.Fd())) {
+			if err := config.RestoreBackup(backup); err != nil {
+				return err
+			}
+			return fmt.Errorf("%w; restored the previous config", verr)
+		}
+
+		if askChoice("Edit again, or restore the previous config? [E/r]", "e") != "r" {
+			continue
+		}
+		if err := config.RestoreBackup(backup); err != nil {
+			return err
+		}
+		fmt.Println(ui.Muted("Restored the previous config from " + backup))
+		return nil
+	}
 }
 
+// importConfig replaces the config with a file, or with --merge sets only
+// the settings the file has
 func importConfig(path string) error {
+	if configMerge {
+		return config.Merge(path, applyConfigValue)
+	}
 	return config.Import(path)
 }
 
@@ -881,6 +1382,23 @@ func exportConfig(path string) error {
 	return config.Export(path)
 }
 
+// listConfigProfiles prints the profiles, marking the active one
+func listConfigProfiles() error {
+	profiles, err := config.ListProfiles()
+	if err != nil {
+		return err
+	}
+	active := config.ActiveProfile()
+	for _, name := range profiles {
+		if name == active {
+			fmt.Println(ui.Success("* " + name))
+			continue
+		}
+		fmt.Println("  " + name)
+	}
+	return nil
+}
+
 func getConfigFile() string {
 	return config.GetConfigPath()
 }
@@ -988,8 +1506,8 @@ func (m configUI) View() string {
 	}
 
 	// Colors
-	accentDark := lipgloss.Color("#7C3AED")
-	dimText := lipgloss.Color("#6B7280")
+	accentDark := ui.ColorBrand
+	dimText := ui.ColorMuted
 
 	// ── Responsive width ─────────────────────────────────────────────────────
 	uiWidth := 75
@@ -1046,7 +1564,7 @@ func (m configUI) View() string {
 	}
 	headerStyle := lipgloss.NewStyle().
 		Bold(true).
-		Foreground(lipgloss.Color("#FFFFFF")).
+		Foreground(ui.ColorOnAccent).
 		Background(accentDark).
 		Padding(0, 1)
 	headerElements = append(headerElements, headerStyle.Render(titleText))
@@ -1098,11 +1616,11 @@ func (m configUI) View() string {
 func getConfigTheme() *huh.Theme {
 	t := huh.ThemeDracula()
 
-	accent := lipgloss.Color("#A78BFA")
-	dimText := lipgloss.Color("#6B7280")
-	lightText := lipgloss.Color("#E5E7EB")
-	bgActive := lipgloss.Color("#A78BFA")
-	bgInactive := lipgloss.Color("#374151")
+	accent := ui.ColorBrandSoft
+	dimText := ui.ColorMuted
+	lightText := ui.ColorText
+	bgActive := ui.ColorBrandSoft
+	bgInactive := ui.ColorSurface
 
 	// Focused state
 	t.Focused.Base = t.Focused.Base.Border(lipgloss.HiddenBorder())
@@ -1116,7 +1634,7 @@ func getConfigTheme() *huh.Theme {
 	// Yes/No Buttons Styled as solid blocks
 	t.Focused.FocusedButton = lipgloss.NewStyle().
 		Background(bgActive).
-		Foreground(lipgloss.Color("#000000")).
+		Foreground(ui.ColorInverse).
 		Bold(true).
 		Padding(0, 2)
 	t.Focused.BlurredButton = lipgloss.NewStyle().
@@ -1133,12 +1651,12 @@ func getConfigTheme() *huh.Theme {
 
 	// Unfocused confirm
 	t.Blurred.FocusedButton = lipgloss.NewStyle().
-		Background(lipgloss.Color("#4B5563")).
-		Foreground(lipgloss.Color("#9CA3AF")).
+		Background(ui.ColorBorder).
+		Foreground(ui.ColorSubtle).
 		Padding(0, 2)
 	t.Blurred.BlurredButton = lipgloss.NewStyle().
-		Background(lipgloss.Color("#1F2937")).
-		Foreground(lipgloss.Color("#4B5563")).
+		Background(ui.ColorBase).
+		Foreground(ui.ColorBorder).
 		Padding(0, 2)
 
 	return t
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"wut/internal/config"
+	"wut/internal/smart"
+	"wut/internal/ui"
+)
+
+// listConfigSources prints the external suggestion sources, where each comes
+// from and whether it runs
+func listConfigSources(w io.Writer, sources []smart.ExternalSource) {
+	dir := config.GetSourcesDir()
+	if len(sources) == 0 {
+		fmt.Fprintln(w, "No external suggestion sources.")
+		fmt.Fprintln(w, ui.Muted(fmt.Sprintf("Add an executable to %s or a smart.sources entry to the config.", dir)))
+		return
+	}
+
+	fmt.Fprintln(w, ui.Title("External suggestion sources"))
+	for _, source := range sources {
+		state := ui.Green("enabled ")
+		if !source.Enabled {
+			state = ui.Muted("disabled")
+		}
+		from := source.Path
+		if source.Declared {
+			from = strings.Join(append([]string{source.Path}, source.Args...), " ") + "  (config)"
+		}
+		fmt.Fprintf(w, "  %s  %s  %s\n", state, ui.Cyan(source.Name), ui.Muted(from))
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, ui.Muted("Toggle one with 'wut config --enable-source <name>' or '--disable-source <name>'."))
+}
+
+// toggleSource enables or disables the external source called name and saves
+// the config
+func toggleSource(name string, enabled bool) error {
+	cfg := config.Get()
+	if err := setSourceEnabled(cfg, smart.ExternalSources(cfg.Smart, config.GetSourcesDir()), name, enabled); err != nil {
+		return err
+	}
+	return config.Save()
+}
+
+// setSourceEnabled adds name to or removes it from smart.disabled_sources in
+// cfg. name must be one of sources.
+func setSourceEnabled(cfg *config.Config, sources []smart.ExternalSource, name string, enabled bool) error {
+	known := make([]string, 0, len(sources))
+	for _, source := range sources {
+		known = append(known, source.Name)
+	}
+	if !slices.Contains(known, name) {
+		if len(known) == 0 {
+			return fmt.Errorf("unknown source %q: no external sources are registered", name)
+		}
+		return fmt.Errorf("unknown source %q; registered sources: %s", name, strings.Join(known, ", "))
+	}
+
+	disabled := slices.DeleteFunc(slices.Clone(cfg.Smart.DisabledSources), func(s string) bool {
+		return s == name
+	})
+	if !enabled {
+		disabled = append(disabled, name)
+	}
+	cfg.Smart.DisabledSources = disabled
+	return nil
+}
+
+// completeSourceNames completes the names of the external sources
+func completeSourceNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	var names []string
+	for _, source := range smart.ExternalSources(config.Get().Smart, config.GetSourcesDir()) {
+		names = append(names, source.Name)
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
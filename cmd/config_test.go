@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"maps"
+	"strings"
+	"testing"
+
+	"wut/internal/config"
+	"wut/internal/smart"
+)
+
+func TestConfigChanges(t *testing.T) {
+	defaults, err := config.Defaults()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changes, err := configChanges(defaults, defaults); err != nil || len(changes) != 0 {
+		t.Fatalf("configChanges() of the defaults = %+v, %v, want none", changes, err)
+	}
+
+	current := *defaults
+	current.UI.Theme = "dark"
+	current.Shell.Hooks = maps.Clone(defaults.Shell.Hooks)
+	current.Shell.Hooks["zsh"] = false
+	fuzzy := 0.9
+	current.Smart.Weights.FuzzyMatch = &fuzzy
+
+	changes, err := configChanges(defaults, &current)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []configChange{
+		{Key: "shell.hooks.zsh", Default: true, Current: false},
+		{Key: "smart.weights.fuzzy_match", Default: scoringWeight(defaults, "fuzzy_match"), Current: 0.9},
+		{Key: "ui.theme", Default: "auto", Current: "dark"},
+	}
+	if len(changes) != len(want) {
+		t.Fatalf("configChanges() = %+v, want %+v", changes, want)
+	}
+	for i := range want {
+		if changes[i] != want[i] {
+			t.Errorf("configChanges()[%d] = %+v, want %+v", i, changes[i], want[i])
+		}
+	}
+}
+
+func TestSetSourceEnabled(t *testing.T) {
+	sources := []smart.ExternalSource{{Name: "jira", Enabled: true}, {Name: "runbooks", Enabled: true}}
+	cfg := &config.Config{}
+
+	if err := setSourceEnabled(cfg, sources, "jira", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := setSourceEnabled(cfg, sources, "jira", false); err != nil {
+		t.Fatal(err)
+	}
+	if got := cfg.Smart.DisabledSources; len(got) != 1 || got[0] != "jira" {
+		t.Errorf("disabling jira twice left %v, want [jira]", got)
+	}
+	if err := setSourceEnabled(cfg, sources, "jira", true); err != nil || len(cfg.Smart.DisabledSources) != 0 {
+		t.Errorf("enabling jira left %v, %v", cfg.Smart.DisabledSources, err)
+	}
+	if err := setSourceEnabled(cfg, sources, "confluence", false); err == nil || !strings.Contains(err.Error(), "jira, runbooks") {
+		t.Errorf("an unknown source = %v, want an error naming the registered ones", err)
+	}
+}
+
+func TestSetFuzzyAlgorithm(t *testing.T) {
+	cfg := &config.Config{}
+	if err := applyConfigValue(cfg, "fuzzy.algorithm", "Jaro_Winkler"); err != nil || cfg.Fuzzy.Algorithm != "jaro_winkler" {
+		t.Fatalf("setting jaro_winkler left %q, %v", cfg.Fuzzy.Algorithm, err)
+	}
+	if err := applyConfigValue(cfg, "fuzzy.algorithm", "soundex"); err == nil || !strings.Contains(err.Error(), "hybrid, levenshtein, damerau, jaro_winkler") {
+		t.Errorf("an unknown algorithm = %v, want an error naming the implemented ones", err)
+	}
+	if cfg.Fuzzy.Algorithm != "jaro_winkler" {
+		t.Errorf("a rejected algorithm changed the setting to %q", cfg.Fuzzy.Algorithm)
+	}
+}
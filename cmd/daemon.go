@@ -0,0 +1,117 @@
+// Package cmd provides CLI commands for WUT
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"wut/internal/config"
+	"wut/internal/daemon"
+	"wut/internal/logger"
+	"wut/internal/smart"
+	"wut/internal/ui"
+)
+
+// daemonCmd represents the daemon command
+var daemonCmd = &cobra.Command{
+	Use:   "daemon",
+	Short: "Keep a warmed suggestion engine running for faster suggestions",
+	Long: `Run a long-lived process that keeps the smart suggestion engine warm and
+answers wut suggest over a unix socket in the WUT data directory.
+
+While it runs, wut suggest asks it for the commands matching a described
+task instead of loading the engine itself, and answers in-process when the
+daemon is not running or does not answer. The daemon opens the database
+only while answering, so other commands can keep recording history.
+
+The daemon runs in the foreground until interrupted or stopped with
+'wut daemon stop'. Restart it after changing the configuration.`,
+	Example: `  wut daemon &        # Start the daemon in the background
+  wut daemon status   # Check whether it is running
+  wut daemon stop     # Stop it`,
+	Args: cobra.NoArgs,
+	RunE: runDaemon,
+}
+
+// daemonStatusCmd represents the status subcommand
+var daemonStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show whether the daemon is running",
+	Args:  cobra.NoArgs,
+	RunE:  runDaemonStatus,
+}
+
+// daemonStopCmd represents the stop subcommand
+var daemonStopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "Stop the running daemon",
+	Args:  cobra.NoArgs,
+	RunE:  runDaemonStop,
+}
+
+// daemonCallTimeout bounds a status or stop request
+const daemonCallTimeout = 2 * time.Second
+
+func init() {
+	rootCmd.AddCommand(daemonCmd)
+	daemonCmd.AddCommand(daemonStatusCmd)
+	daemonCmd.AddCommand(daemonStopCmd)
+}
+
+func runDaemon(cmd *cobra.Command, args []string) error {
+	log := logger.With("daemon")
+	path := config.GetDaemonSocketPath()
+
+	listener, err := daemon.Listen(path)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(path)
+
+	engine := smart.NewEngine(nil)
+	server := daemon.NewServer(engine, config.GetDatabasePath())
+
+	fmt.Printf("%s Listening on %s (pid %d)\n", ui.Success("✓"), path, os.Getpid())
+	log.Info("daemon started", "socket", path)
+	err = server.Serve(cmd.Context(), listener)
+	log.Info("daemon stopped")
+	return err
+}
+
+func runDaemonStatus(cmd *cobra.Command, args []string) error {
+	ctx, cancel := context.WithTimeout(cmd.Context(), daemonCallTimeout)
+	defer cancel()
+
+	resp, err := daemon.Call(ctx, config.GetDaemonSocketPath(), daemon.Request{Op: daemon.OpPing})
+	if errors.Is(err, daemon.ErrNotRunning) {
+		fmt.Println(ui.Muted("The daemon is not running. Start it with: wut daemon &"))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s Running (pid %d) since %s, %d requests answered\n",
+		ui.Success("✓"), resp.PID, resp.StartedAt.Format(time.DateTime), resp.Requests)
+	return nil
+}
+
+func runDaemonStop(cmd *cobra.Command, args []string) error {
+	ctx, cancel := context.WithTimeout(cmd.Context(), daemonCallTimeout)
+	defer cancel()
+
+	resp, err := daemon.Call(ctx, config.GetDaemonSocketPath(), daemon.Request{Op: daemon.OpStop})
+	if errors.Is(err, daemon.ErrNotRunning) {
+		fmt.Println(ui.Muted("The daemon is not running."))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s Stopped the daemon (pid %d)\n", ui.Success("✓"), resp.PID)
+	return nil
+}
@@ -3,8 +3,13 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
 	"time"
 
@@ -13,6 +18,8 @@ import (
 
 	"wut/internal/config"
 	"wut/internal/db"
+	"wut/internal/logger"
+	"wut/internal/terminal"
 	"wut/internal/ui"
 )
 
@@ -27,9 +34,12 @@ This command allows you to sync and manage the local database.`,
 }
 
 var (
-	dbSyncAll bool
-	dbForce   bool
-	dbOffline bool
+	dbSyncAll    bool
+	dbForce      bool
+	dbOffline    bool
+	dbSyncQuiet  bool
+	dbSyncStatus bool
+	dbSyncAuto   bool
 
 	dbUpdateDays    int
 	dbUpdateOffline bool
@@ -42,12 +52,18 @@ var dbSyncCmd = &cobra.Command{
 	Long: `Download and cache command pages to local database for offline access.
 
 If no commands are specified, syncs popular commands.
-Use --all to sync all available commands.`,
+Use --all to sync all available commands.
+
+With tldr.auto_sync on, pages older than tldr.auto_sync_interval days are
+also updated in the background, at most once per interval: a command that
+finds them stale starts 'wut db sync' in a separate process and goes on
+without waiting. --status shows when that last ran and how it went.`,
 	Example: `  wut db sync                    # Sync popular commands
   wut db sync git docker npm     # Sync specific commands
   wut db sync --all              # Sync all commands (may take a while)
   wut db sync --force            # Force update existing pages
-  wut db sync --offline git      # Import from local tldr-main checkout only`,
+  wut db sync --offline git      # Import from local tldr-main checkout only
+  wut db sync --status           # Show the last automatic sync`,
 	RunE: runDBSync,
 }
 
@@ -80,6 +96,87 @@ By default, uses tldr.auto_sync_interval (7 days unless configured).`,
 	RunE: runDBUpdate,
 }
 
+// dbBackupCmd represents the backup subcommand
+var dbBackupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "Back up the history database",
+	Long: `Write a copy of the database that holds your history, bookmarks and
+aliases to the backups directory next to it.
+
+Backups also run automatically when database.backup_enabled is set, at most
+once every database.backup_interval hours. The newest database.max_backups
+are kept, within database.max_size MB in total.`,
+	Example: `  wut db backup`,
+	Args:    cobra.NoArgs,
+	RunE:    runDBBackup,
+}
+
+// dbRestoreCmd represents the restore subcommand
+var dbRestoreCmd = &cobra.Command{
+	Use:   "restore <file|latest>",
+	Short: "Restore the history database from a backup",
+	Long: `Replace the database with a backup after checking that the backup is a
+readable WUT database. The current database is kept next to it with a
+.pre-restore suffix.`,
+	Example: `  wut db restore latest
+  wut db restore ~/.config/wut/backups/wut-20240101-120000.db`,
+	Args: cobra.ExactArgs(1),
+	RunE: runDBRestore,
+}
+
+// dbCompactCmd represents the compact subcommand
+var dbCompactCmd = &cobra.Command{
+	Use:   "compact",
+	Short: "Shrink the history database",
+	Long: `Rewrite the database that holds your history, bookmarks and aliases into
+a fresh file, giving space freed by deleted entries back to the disk.
+
+When the database is larger than database.max_size MB, the oldest history
+entries are pruned first. Their counts are kept, so 'wut stats' is unchanged.
+This also happens automatically when the database is opened.`,
+	Example: `  wut db compact`,
+	Args:    cobra.NoArgs,
+	RunE:    runDBCompact,
+}
+
+// dbImportPagesCmd represents the import-pages subcommand
+var dbImportPagesCmd = &cobra.Command{
+	Use:   "import-pages <path>",
+	Short: "Import TLDR pages from a local clone or archive",
+	Long: `Import the TLDR pages from a local clone of tldr-pages/tldr, or from a
+.zip or .tar.gz archive of it, without going online.
+
+Pages are found by their pages/<platform>/<command>.md path, and translations
+by pages.<lang>/<platform>/<command>.md. Existing pages are replaced. Pages
+that do not follow the tldr format are skipped and reported with the line
+that breaks it.`,
+	Example: `  wut db import-pages ~/src/tldr
+  wut db import-pages ~/src/tldr/pages.de
+  wut db import-pages ~/Downloads/tldr.zip
+  wut db import-pages tldr-main.tar.gz`,
+	Args: cobra.ExactArgs(1),
+	RunE: runDBImportPages,
+}
+
+var dbMigrateTo string
+
+// dbMigrateCmd represents the migrate subcommand
+var dbMigrateCmd = &cobra.Command{
+	Use:   "migrate --to <engine>",
+	Short: "Move the databases to another storage engine",
+	Long: `Copy the history database and the TLDR page database into new files kept
+in another storage engine, bbolt or sqlite. Each bucket must hold as many
+entries after the copy as before, or the databases are left as they were.
+The originals are kept next to them with a .pre-migrate suffix.
+
+Once both are moved, database.type is set to the new engine, which new
+databases are created with from then on. Close other wut instances first.`,
+	Example: `  wut db migrate --to sqlite
+  wut db migrate --to bbolt`,
+	Args: cobra.NoArgs,
+	RunE: runDBMigrate,
+}
+
 func init() {
 	rootCmd.AddCommand(dbCmd)
 
@@ -87,23 +184,46 @@ func init() {
 	dbCmd.AddCommand(dbStatusCmd)
 	dbCmd.AddCommand(dbClearCmd)
 	dbCmd.AddCommand(dbUpdateCmd)
+	dbCmd.AddCommand(dbBackupCmd)
+	dbCmd.AddCommand(dbRestoreCmd)
+	dbCmd.AddCommand(dbCompactCmd)
+	dbCmd.AddCommand(dbImportPagesCmd)
+	dbCmd.AddCommand(dbMigrateCmd)
 
 	// Sync flags
 	dbSyncCmd.Flags().BoolVarP(&dbSyncAll, "all", "a", false, "sync all commands (may take a while)")
 	dbSyncCmd.Flags().BoolVarP(&dbForce, "force", "f", false, "force update existing pages")
 	dbSyncCmd.Flags().BoolVar(&dbOffline, "offline", false, "sync from local TLDR source only (no network)")
+	dbSyncCmd.Flags().BoolVarP(&dbSyncQuiet, "quiet", "q", false, "print nothing unless the sync fails")
+	dbSyncCmd.Flags().BoolVar(&dbSyncStatus, "status", false, "show when the last automatic sync ran and its result")
+	// --auto is what a command starts in the background when pages are stale
+	dbSyncCmd.Flags().BoolVar(&dbSyncAuto, "auto", false, "update stale pages as the automatic sync")
+	_ = dbSyncCmd.Flags().MarkHidden("auto")
+	dbSyncCmd.ValidArgsFunction = completePageNames
 
 	// Update flags
 	dbUpdateCmd.Flags().IntVar(&dbUpdateDays, "days", 7, "update pages older than this many days")
 	dbUpdateCmd.Flags().BoolVar(&dbUpdateOffline, "offline", false, "update from local TLDR source only (no network)")
+
+	dbMigrateCmd.Flags().StringVar(&dbMigrateTo, "to", "", "storage engine to move to: "+strings.Join(db.Engines, " or "))
+	_ = dbMigrateCmd.MarkFlagRequired("to")
 }
 
 func runDBSync(cmd *cobra.Command, args []string) error {
+	if dbSyncStatus {
+		return runDBSyncStatus()
+	}
+
 	// Get database path
 	dbPath := getDBPath()
 
 	// Create storage
 	storage, err := db.NewStorage(dbPath)
+	// The command that started the automatic sync may still be reading it
+	for wait := time.Now(); dbSyncAuto && errors.Is(err, db.ErrDatabaseLocked) && time.Since(wait) < autoSyncLockWait; {
+		time.Sleep(time.Second)
+		storage, err = db.NewStorage(dbPath)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to open database: %w", err)
 	}
@@ -113,9 +233,17 @@ func runDBSync(cmd *cobra.Command, args []string) error {
 	syncManager := db.NewSyncManager(storage)
 
 	ctx := context.Background()
-	var result *db.SyncResult
+	if dbSyncAuto {
+		tldr := config.Get().TLDR
+		record := syncManager.RunAutoSync(ctx, autoSyncInterval(), tldr.AutoDetectOnline)
+		if !dbSyncQuiet {
+			fmt.Println(formatAutoSyncRecord(record))
+		}
+		return nil
+	}
 
-	err = ui.RunWithSpinner("Syncing command database...", func() error {
+	var result *db.SyncResult
+	sync := func() error {
 		var syncErr error
 		opts := db.SyncOptions{
 			Commands:    args,
@@ -131,7 +259,16 @@ func runDBSync(cmd *cobra.Command, args []string) error {
 			result, syncErr = syncManager.SyncPopularWithOptions(ctx, opts)
 		}
 		return syncErr
-	})
+	}
+
+	if dbSyncQuiet {
+		if err := sync(); err != nil {
+			return fmt.Errorf("sync failed: %w", err)
+		}
+		return nil
+	}
+
+	err = ui.RunWithSpinner("Syncing command database...", sync)
 
 	fmt.Println()
 
@@ -169,11 +306,8 @@ func runDBStatus(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to get stats: %w", err)
 	}
 
-	autoSyncDays := config.Get().TLDR.AutoSyncInterval
-	if autoSyncDays <= 0 {
-		autoSyncDays = 7
-	}
-	stalePages, err := storage.ListStalePages(time.Duration(autoSyncDays)*24*time.Hour, 0)
+	autoSyncDays := int(autoSyncInterval() / (24 * time.Hour))
+	stalePages, err := storage.ListStalePages(autoSyncInterval(), 0)
 	if err != nil {
 		return fmt.Errorf("failed to inspect stale pages: %w", err)
 	}
@@ -183,6 +317,7 @@ func runDBStatus(cmd *cobra.Command, args []string) error {
 	}
 	stats["db_path"] = dbPath
 	stats["db_size_bytes"] = fileInfo.Size()
+	stats["db_engine"] = db.DatabaseEngine(dbPath)
 	stats["stale_pages"] = len(stalePages)
 	stats["stale_threshold_days"] = autoSyncDays
 
@@ -288,6 +423,373 @@ func runDBUpdate(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+func runDBBackup(cmd *cobra.Command, args []string) error {
+	dbPath := config.GetDatabasePath()
+	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+		fmt.Println("ℹ️  Nothing to back up yet")
+		return nil
+	}
+
+	storage, err := db.OpenForReading(dbPath)
+	if err != nil {
+		return err
+	}
+	backup, err := storage.Backup(cmd.Context(), db.BackupDir(dbPath))
+	storage.Close()
+	if err != nil {
+		return err
+	}
+
+	removed, err := db.PruneBackups(db.BackupDir(dbPath), backupPolicy())
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("✅ Backed up to %s (%s)\n", backup.Path, formatBytes(backup.Size))
+	if len(removed) > 0 {
+		fmt.Printf("   Removed %d old backup(s)\n", len(removed))
+	}
+	return nil
+}
+
+func runDBRestore(cmd *cobra.Command, args []string) error {
+	dbPath := config.GetDatabasePath()
+
+	backupPath := args[0]
+	if backupPath == "latest" {
+		latest, err := db.LatestBackup(db.BackupDir(dbPath))
+		if err != nil {
+			return fmt.Errorf("%w in %s", err, db.BackupDir(dbPath))
+		}
+		backupPath = latest.Path
+	}
+	if err := db.ValidateBackup(backupPath); err != nil {
+		return err
+	}
+
+	fmt.Printf("⚠️  Replace the database with %s? [y/N]: ", backupPath)
+	var response string
+	_, _ = fmt.Scanln(&response)
+	if response != "y" && response != "Y" {
+		fmt.Println("Cancelled")
+		return nil
+	}
+
+	if err := db.RestoreBackup(dbPath, backupPath); err != nil {
+		return err
+	}
+
+	fmt.Println("✅ Database restored")
+	fmt.Printf("   The previous database was kept as %s.pre-restore\n", dbPath)
+	return nil
+}
+
+func runDBCompact(cmd *cobra.Command, args []string) error {
+	dbPath := config.GetDatabasePath()
+	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+		fmt.Println("ℹ️  Nothing to compact yet")
+		return nil
+	}
+
+	storage, err := db.NewStorage(dbPath)
+	if err != nil {
+		return err
+	}
+	defer storage.Close()
+	if storage.IsEncrypted() {
+		if err := unlockHistory(storage); err != nil {
+			return err
+		}
+	}
+
+	result, err := storage.Compact(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("✅ Compacted %s: %s → %s\n", dbPath, formatBytes(result.SizeBefore), formatBytes(result.SizeAfter))
+	if result.Pruned > 0 {
+		fmt.Printf("   Pruned %d old history entries to stay within %d MB\n", result.Pruned, config.Get().Database.MaxSize)
+	}
+	return nil
+}
+
+func runDBMigrate(cmd *cobra.Command, args []string) error {
+	to := strings.ToLower(strings.TrimSpace(dbMigrateTo))
+	if !slices.Contains(db.Engines, to) {
+		return fmt.Errorf("unsupported database engine %q: available engines are %s", dbMigrateTo, strings.Join(db.Engines, ", "))
+	}
+
+	for _, path := range []string{config.GetDatabasePath(), config.GetTLDRDatabasePath()} {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			continue
+		}
+		result, err := db.MigrateDatabase(cmd.Context(), path, to, migrateProgress(path))
+		if errors.Is(err, db.ErrSameEngine) {
+			fmt.Printf("ℹ️  %s already uses %s\n", path, to)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Printf("✅ Migrated %s from %s to %s: %d entries in %d buckets\n",
+			path, result.From, result.To, result.Entries, result.Buckets)
+		fmt.Printf("   The %s database was kept as %s\n", result.From, result.Previous)
+	}
+
+	db.SetEngine(to)
+	if cfg := config.Get(); cfg.Database.Type != to {
+		cfg.Database.Type = to
+		if err := config.Save(); err != nil {
+			return fmt.Errorf("failed to save config: %w", err)
+		}
+		fmt.Printf("   database.type is now %s\n", to)
+	}
+	return nil
+}
+
+// migrateProgress redraws the count of copied entries on one line of stderr
+// while a terminal shows it, and clears the line once the copy is done
+func migrateProgress(path string) func(copied, total int) {
+	if !terminal.IsInteractive() {
+		return nil
+	}
+	name := filepath.Base(path)
+	return func(copied, total int) {
+		if copied >= total {
+			fmt.Fprint(os.Stderr, "\r\033[K")
+			return
+		}
+		fmt.Fprintf(os.Stderr, "\r⏳ Copying %s: %d/%d entries (%d%%)", name, copied, total, copied*100/total)
+	}
+}
+
+// printEngineMigrateHint points out the databases that still use another
+// engine than the one database.type was just set to
+func printEngineMigrateHint(engine string) {
+	for _, path := range []string{config.GetDatabasePath(), config.GetTLDRDatabasePath()} {
+		if _, err := os.Stat(path); err == nil && db.DatabaseEngine(path) != engine {
+			fmt.Printf("ℹ️  New databases will use %s. Run 'wut db migrate --to %s' to move the existing ones\n", engine, engine)
+			return
+		}
+	}
+}
+
+func runDBImportPages(cmd *cobra.Command, args []string) error {
+	storage, err := db.NewStorage(getDBPath())
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer storage.Close()
+
+	syncManager := db.NewSyncManager(storage)
+	defer syncManager.Stop()
+
+	var result *db.SyncResult
+	err = ui.RunWithSpinner("Importing command pages...", func() error {
+		var importErr error
+		result, importErr = syncManager.ImportPages(context.Background(), args[0])
+		return importErr
+	})
+	fmt.Println()
+	if err != nil {
+		return fmt.Errorf("import failed: %w", err)
+	}
+
+	fmt.Printf("✅ Imported %d pages from %s\n", result.Downloaded, args[0])
+	platforms := make([]string, 0, len(result.Platforms))
+	for platform := range result.Platforms {
+		platforms = append(platforms, platform)
+	}
+	sort.Slice(platforms, func(i, j int) bool {
+		if result.Platforms[platforms[i]] != result.Platforms[platforms[j]] {
+			return result.Platforms[platforms[i]] > result.Platforms[platforms[j]]
+		}
+		return platforms[i] < platforms[j]
+	})
+	for _, platform := range platforms {
+		fmt.Printf("   %-10s %d\n", platform, result.Platforms[platform])
+	}
+	if result.Failed > 0 {
+		fmt.Println()
+		fmt.Println(ui.Warning(fmt.Sprintf("⚠️  %d page(s) could not be imported", result.Failed)))
+		for _, err := range result.Errors[:min(len(result.Errors), 5)] {
+			fmt.Printf("   %s\n", ui.Muted(err.Error()))
+		}
+	}
+	return nil
+}
+
+// backupPolicy returns the backup settings from the config
+func backupPolicy() db.BackupPolicy {
+	cfg := config.Get().Database
+	return db.BackupPolicy{
+		Interval: time.Duration(cfg.BackupInterval) * time.Hour,
+		Keep:     cfg.MaxBackups,
+		MaxBytes: int64(cfg.MaxSize) * 1024 * 1024,
+	}
+}
+
+// autoBackup backs up the database when backups are enabled and one is due.
+// A failed backup is logged and never stops the command.
+func autoBackup(ctx context.Context, cmd *cobra.Command) {
+	if !config.Get().Database.BackupEnabled || cmd == dbBackupCmd || cmd == dbRestoreCmd {
+		return
+	}
+
+	backup, err := db.AutoBackup(ctx, config.GetDatabasePath(), backupPolicy())
+	if err != nil {
+		logger.With("backup").Warn("automatic backup failed", "error", err)
+		return
+	}
+	if backup != nil {
+		logger.With("backup").Debug("backed up database", "path", backup.Path)
+	}
+}
+
+// autoSyncInterval is how old pages get before the automatic sync updates
+// them, from tldr.auto_sync_interval
+func autoSyncInterval() time.Duration {
+	days := config.Get().TLDR.AutoSyncInterval
+	if days <= 0 {
+		days = 7
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// autoSyncLockWait is how long the automatic sync waits for other wut
+// commands to let go of the TLDR database
+const autoSyncLockWait = time.Minute
+
+// startAutoSync runs the automatic sync in a detached process; tests replace it
+var startAutoSync = func() error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	sync := exec.Command(exe, "db", "sync", "--auto", "--quiet")
+	detach(sync)
+	if err := sync.Start(); err != nil {
+		return err
+	}
+	return sync.Process.Release()
+}
+
+// autoSyncTLDR starts the automatic TLDR sync in the background when
+// tldr.auto_sync is on and the pages are older than the interval. The check
+// reads one record from the TLDR database and gives up at once when another
+// process holds it, so the command is never kept waiting.
+func autoSyncTLDR(cmd *cobra.Command) {
+	tldr := config.Get().TLDR
+	if !tldr.AutoSync || tldr.OfflineMode || cmd.Parent() == dbCmd {
+		return
+	}
+
+	log := logger.With("db-sync")
+	claimed, err := db.ClaimAutoSync(getDBPath(), autoSyncInterval())
+	if err != nil {
+		log.Warn("failed to check for an automatic sync", "error", err)
+		return
+	}
+	if !claimed {
+		return
+	}
+	if err := startAutoSync(); err != nil {
+		log.Warn("failed to start the automatic sync", "error", err)
+		return
+	}
+	log.Debug("started the automatic sync")
+}
+
+// runDBSyncStatus shows when the automatic sync last ran and how it went
+func runDBSyncStatus() error {
+	dbPath := getDBPath()
+	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+		fmt.Println("❌ Local database not found")
+		fmt.Println()
+		fmt.Println("Run 'wut db sync' to create the database")
+		return nil
+	}
+
+	storage, err := db.OpenReadOnly(dbPath, time.Second)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer storage.Close()
+
+	record, err := storage.GetAutoSyncRecord()
+	if err != nil {
+		return fmt.Errorf("failed to read the automatic sync: %w", err)
+	}
+	var lastSync time.Time
+	if meta, err := storage.GetMetadata(); err == nil {
+		lastSync = meta.LastSync
+	}
+	fmt.Println(formatAutoSyncStatus(config.Get().TLDR, lastSync, record, time.Now()))
+	return nil
+}
+
+// formatAutoSyncStatus describes the automatic sync for wut db sync --status
+func formatAutoSyncStatus(tldr config.TLDRConfig, lastSync time.Time, record *db.AutoSyncRecord, now time.Time) string {
+	var b strings.Builder
+	label := lipgloss.NewStyle().Foreground(ui.ColorMuted)
+	line := func(name, value string) {
+		fmt.Fprintf(&b, "  %s %s\n", label.Render(fmt.Sprintf("%-15s", name+":")), value)
+	}
+
+	b.WriteString(lipgloss.NewStyle().Bold(true).Foreground(ui.ColorBrand).Render("🔄 Automatic Sync"))
+	b.WriteString("\n\n")
+
+	interval := autoSyncInterval()
+	switch {
+	case !tldr.AutoSync:
+		line("Auto sync", "off (tldr.auto_sync)")
+	case tldr.OfflineMode:
+		line("Auto sync", "paused while tldr.offline_mode is on")
+	default:
+		line("Auto sync", fmt.Sprintf("every %d days", int(interval/(24*time.Hour))))
+	}
+
+	if lastSync.IsZero() {
+		line("Last sync", "never")
+	} else {
+		line("Last sync", fmt.Sprintf("%s (%s)", lastSync.Format("2006-01-02 15:04"), formatRelativeTime(lastSync, now)))
+	}
+
+	if record == nil {
+		line("Last auto sync", "never")
+		return strings.TrimRight(b.String(), "\n")
+	}
+	line("Last auto sync", fmt.Sprintf("%s (%s)", record.StartedAt.Format("2006-01-02 15:04"), formatRelativeTime(record.StartedAt, now)))
+	line("Result", autoSyncResult(record))
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// formatAutoSyncRecord sums up an automatic sync that just finished
+func formatAutoSyncRecord(record *db.AutoSyncRecord) string {
+	return "Automatic sync: " + autoSyncResult(record)
+}
+
+// autoSyncResult says how an automatic sync went
+func autoSyncResult(record *db.AutoSyncRecord) string {
+	switch record.Status {
+	case db.AutoSyncRunning:
+		if record.Running() {
+			return ui.Yellow("running")
+		}
+		return ui.Red("stopped before finishing")
+	case db.AutoSyncOffline:
+		return ui.Yellow("skipped, no network")
+	case db.AutoSyncFailed:
+		return ui.Red("failed: " + record.Error)
+	}
+	result := ui.Green("succeeded") + fmt.Sprintf(", %d pages updated", record.Updated)
+	if record.Failed > 0 {
+		result += fmt.Sprintf(", %d failed", record.Failed)
+	}
+	return result
+}
+
 // getDBPath returns the path to the database
 func getDBPath() string {
 	return config.GetTLDRDatabasePath()
@@ -300,7 +802,7 @@ func formatSyncResult(result *db.SyncResult) string {
 	// Title
 	title := lipgloss.NewStyle().
 		Bold(true).
-		Foreground(lipgloss.Color("#10B981")).
+		Foreground(ui.ColorSuccess).
 		Render("✅ Sync Complete")
 	b.WriteString(title)
 	b.WriteString("\n\n")
@@ -309,17 +811,17 @@ func formatSyncResult(result *db.SyncResult) string {
 	stats := []struct {
 		label string
 		value int
-		color string
+		color lipgloss.AdaptiveColor
 	}{
-		{"Downloaded", result.Downloaded, "#10B981"},
-		{"Skipped", result.Skipped, "#F59E0B"},
-		{"Failed", result.Failed, "#EF4444"},
+		{"Downloaded", result.Downloaded, ui.ColorSuccess},
+		{"Skipped", result.Skipped, ui.ColorWarning},
+		{"Failed", result.Failed, ui.ColorError},
 	}
 
 	for _, s := range stats {
 		if s.value > 0 {
 			b.WriteString(lipgloss.NewStyle().
-				Foreground(lipgloss.Color(s.color)).
+				Foreground(s.color).
 				Render(fmt.Sprintf("  • %s: %d", s.label, s.value)))
 			b.WriteString("\n")
 		}
@@ -327,7 +829,7 @@ func formatSyncResult(result *db.SyncResult) string {
 
 	// Duration
 	b.WriteString(lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#6B7280")).
+		Foreground(ui.ColorMuted).
 		Render(fmt.Sprintf("  • Duration: %s", result.Duration)))
 	b.WriteString("\n")
 
@@ -335,18 +837,18 @@ func formatSyncResult(result *db.SyncResult) string {
 	if len(result.Errors) > 0 {
 		b.WriteString("\n")
 		b.WriteString(lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#EF4444")).
+			Foreground(ui.ColorError).
 			Render("Errors:"))
 		b.WriteString("\n")
 		for _, err := range result.Errors[:min(len(result.Errors), 5)] {
 			b.WriteString(lipgloss.NewStyle().
-				Foreground(lipgloss.Color("#6B7280")).
+				Foreground(ui.ColorMuted).
 				Render(fmt.Sprintf("  • %v", err)))
 			b.WriteString("\n")
 		}
 		if len(result.Errors) > 5 {
 			b.WriteString(lipgloss.NewStyle().
-				Foreground(lipgloss.Color("#6B7280")).
+				Foreground(ui.ColorMuted).
 				Render(fmt.Sprintf("  ... and %d more errors", len(result.Errors)-5)))
 			b.WriteString("\n")
 		}
@@ -361,7 +863,7 @@ func formatStatus(stats map[string]any) string {
 	// Title
 	title := lipgloss.NewStyle().
 		Bold(true).
-		Foreground(lipgloss.Color("#7C3AED")).
+		Foreground(ui.ColorBrand).
 		Render("📊 Database Status")
 	b.WriteString(title)
 	b.WriteString("\n\n")
@@ -372,7 +874,7 @@ func formatStatus(stats map[string]any) string {
 		totalPages = v
 	}
 	b.WriteString(lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#10B981")).
+		Foreground(ui.ColorSuccess).
 		Render(fmt.Sprintf("  Total Pages: %d", totalPages)))
 	b.WriteString("\n")
 
@@ -382,7 +884,7 @@ func formatStatus(stats map[string]any) string {
 			days = v
 		}
 		b.WriteString(lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#F59E0B")).
+			Foreground(ui.ColorWarning).
 			Render(fmt.Sprintf("  Stale Pages (> %d days): %d", days, stalePages)))
 		b.WriteString("\n")
 	}
@@ -390,21 +892,28 @@ func formatStatus(stats map[string]any) string {
 	// Last sync
 	if lastSync, ok := stats["last_sync"].(time.Time); ok {
 		b.WriteString(lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#3B82F6")).
+			Foreground(ui.ColorPrimary).
 			Render(fmt.Sprintf("  Last Sync: %s", lastSync.Format("2006-01-02 15:04"))))
 		b.WriteString("\n")
 	}
 
 	if sizeBytes, ok := stats["db_size_bytes"].(int64); ok {
 		b.WriteString(lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#10B981")).
+			Foreground(ui.ColorSuccess).
 			Render(fmt.Sprintf("  Database Size: %s", formatBytes(sizeBytes))))
 		b.WriteString("\n")
 	}
 
+	if engine, ok := stats["db_engine"].(string); ok {
+		b.WriteString(lipgloss.NewStyle().
+			Foreground(ui.ColorMuted).
+			Render(fmt.Sprintf("  Engine: %s", engine)))
+		b.WriteString("\n")
+	}
+
 	if dbPath, ok := stats["db_path"].(string); ok && dbPath != "" {
 		b.WriteString(lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#6B7280")).
+			Foreground(ui.ColorMuted).
 			Render(fmt.Sprintf("  Path: %s", dbPath)))
 		b.WriteString("\n")
 	}
@@ -414,12 +923,12 @@ func formatStatus(stats map[string]any) string {
 		b.WriteString("\n")
 		b.WriteString(lipgloss.NewStyle().
 			Bold(true).
-			Foreground(lipgloss.Color("#F59E0B")).
+			Foreground(ui.ColorWarning).
 			Render("Platforms:"))
 		b.WriteString("\n")
 		for platform, count := range platforms {
 			b.WriteString(lipgloss.NewStyle().
-				Foreground(lipgloss.Color("#6B7280")).
+				Foreground(ui.ColorMuted).
 				Render(fmt.Sprintf("  • %s: %d", platform, count)))
 			b.WriteString("\n")
 		}
@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"wut/internal/config"
+	"wut/internal/db"
+)
+
+// TestAutoSyncTLDR checks that a stale TLDR cache starts one background
+// sync, and that another command does not start a second
+func TestAutoSyncTLDR(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Database.Path = filepath.Join(t.TempDir(), "wut.db")
+	cfg.TLDR.AutoSync = true
+	cfg.TLDR.AutoSyncInterval = 7
+	config.Set(cfg)
+	t.Cleanup(func() { config.Set(&config.Config{}) })
+
+	started := 0
+	original := startAutoSync
+	startAutoSync = func() error {
+		started++
+		return nil
+	}
+	t.Cleanup(func() { startAutoSync = original })
+
+	cmd := &cobra.Command{Use: "fix"}
+	rootCmd.AddCommand(cmd)
+	t.Cleanup(func() { rootCmd.RemoveCommand(cmd) })
+
+	// Nothing to sync before wut db sync has created the cache
+	autoSyncTLDR(cmd)
+	if started != 0 {
+		t.Fatal("auto sync started without a TLDR database")
+	}
+
+	storage, err := db.NewStorage(getDBPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = storage.SaveMetadata(&db.Metadata{LastSync: time.Now().Add(-30 * 24 * time.Hour)})
+	storage.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	autoSyncTLDR(cmd)
+	autoSyncTLDR(cmd)
+	if started != 1 {
+		t.Errorf("auto sync started %d times, want once", started)
+	}
+}
+
+func TestFormatAutoSyncStatus(t *testing.T) {
+	config.Set(&config.Config{})
+	now := time.Date(2026, 3, 14, 12, 0, 0, 0, time.UTC)
+	tldr := config.TLDRConfig{AutoSync: true, AutoSyncInterval: 7}
+	record := &db.AutoSyncRecord{
+		StartedAt:  now.Add(-2 * time.Hour),
+		FinishedAt: now.Add(-2 * time.Hour),
+		Status:     db.AutoSyncSucceeded,
+		Updated:    12,
+	}
+
+	out := formatAutoSyncStatus(tldr, now.Add(-2*time.Hour), record, now)
+	for _, want := range []string{"every 7 days", "(2h ago)", "succeeded, 12 pages updated"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("status is missing %q:\n%s", want, out)
+		}
+	}
+
+	tldr.AutoSync = false
+	if out := formatAutoSyncStatus(tldr, time.Time{}, nil, now); !strings.Contains(out, "off") || !strings.Contains(out, "never") {
+		t.Errorf("status of a disabled, never synced cache:\n%s", out)
+	}
+}
+
+func TestRunDBMigrate(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, ".config"))
+	cfg := &config.Config{}
+	cfg.Database.Type = db.EngineBolt
+	cfg.Database.Path = filepath.Join(dir, "wut.db")
+	config.Set(cfg)
+	t.Cleanup(func() {
+		config.Set(&config.Config{})
+		db.SetEngine(db.EngineBolt)
+	})
+	db.SetEngine(db.EngineBolt)
+
+	// Only the history database exists; the missing TLDR cache is skipped
+	storage, err := db.NewStorage(config.GetDatabasePath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = storage.AddHistory(t.Context(), "git status")
+	storage.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	origTo := dbMigrateTo
+	t.Cleanup(func() { dbMigrateTo = origTo })
+	dbMigrateTo = "SQLite"
+	cmd := &cobra.Command{}
+	cmd.SetContext(t.Context())
+	out := captureStdout(t, func() {
+		if err := runDBMigrate(cmd, nil); err != nil {
+			t.Errorf("runDBMigrate() error = %v", err)
+		}
+	})
+	if !strings.Contains(out, "from bbolt to sqlite") || !strings.Contains(out, ".pre-migrate") {
+		t.Errorf("output does not report the migration:\n%s", out)
+	}
+	if got := db.DatabaseEngine(config.GetDatabasePath()); got != db.EngineSQLite {
+		t.Errorf("history database engine = %s, want sqlite", got)
+	}
+	if got := db.DatabaseEngine(config.GetTLDRDatabasePath()); got != db.EngineSQLite {
+		t.Errorf("engine for a new TLDR database = %s, want sqlite", got)
+	}
+	if config.Get().Database.Type != db.EngineSQLite {
+		t.Errorf("database.type = %q, want sqlite", config.Get().Database.Type)
+	}
+
+	out = captureStdout(t, func() {
+		if err := runDBMigrate(cmd, nil); err != nil {
+			t.Errorf("runDBMigrate() again error = %v", err)
+		}
+	})
+	if !strings.Contains(out, "already uses sqlite") {
+		t.Errorf("second migration output = %q, want it skipped", out)
+	}
+
+	dbMigrateTo = "mysql"
+	if err := runDBMigrate(cmd, nil); err == nil {
+		t.Error("runDBMigrate() to an unknown engine succeeded")
+	}
+}
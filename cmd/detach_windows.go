@@ -0,0 +1,19 @@
+//go:build windows
+
+package cmd
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// detachedProcess is DETACHED_PROCESS, which syscall does not define
+const detachedProcess = 0x00000008
+
+// detach starts cmd without a console in a process group of its own, so it
+// outlives the terminal and is not sent its Ctrl+C
+func detach(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP | detachedProcess,
+	}
+}
@@ -0,0 +1,220 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"wut/internal/config"
+	"wut/internal/db"
+	"wut/internal/health"
+	"wut/internal/shell"
+	"wut/internal/terminal"
+	"wut/internal/ui"
+)
+
+// doctorCmd checks the setup and prints a checklist
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check your WUT setup for problems",
+	Long: `Check that WUT is set up correctly and explain how to fix what is not.
+
+Doctor checks the config file, the history database and its size limit, shell
+integration in every detected shell, the clipboard, whether the TLDR pages
+can be downloaded (unless privacy.local_only or tldr.offline_mode is set) and
+what the terminal can display. It exits with an error when a required check
+fails. Use --json to attach the results to a bug report.`,
+	Example: `  wut doctor
+  wut doctor --json > doctor.json`,
+	RunE: runDoctor,
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+
+	doctorCmd.Flags().BoolVar(&outputJSON, "json", false, "print the results as JSON")
+}
+
+func runDoctor(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	// Doctor skips the usual initialization so it still runs when the
+	// config is broken; a bad file is reported by the config check
+	_, _ = config.Load(cfgFile)
+	cfg := config.Get()
+
+	installer := shell.NewInstaller()
+	var shells []shell.IntegrationStatus
+	for _, sh := range shell.IntegrationShells() {
+		shells = append(shells, installer.Status(ctx, sh))
+	}
+
+	checker := health.NewChecker(Version)
+	for _, check := range doctorChecks(installer) {
+		checker.Register(check)
+	}
+	checker.Register(health.Check{
+		Name:     "database size",
+		Checker:  func(context.Context) error { return checkDatabaseSize(config.GetDatabasePath(), cfg.Database.MaxSize) },
+		Critical: false,
+		Hint:     "run 'wut db compact', or raise database.max_size",
+	})
+	skipped := ""
+	switch {
+	case cfg.Privacy.LocalOnly:
+		skipped = "privacy.local_only is on"
+	case cfg.TLDR.OfflineMode:
+		skipped = "tldr.offline_mode is on"
+	default:
+		checker.Register(health.Check{
+			Name:     "tldr network",
+			Checker:  checkTLDRNetwork,
+			Critical: false,
+			Hint:     "check your connection or proxy, or set tldr.offline_mode to use the local cache only",
+		})
+	}
+	caps := terminal.Detect()
+	checker.Register(health.Check{
+		Name:     "terminal",
+		Checker:  func(context.Context) error { return checkTerminal(caps, term.IsTerminal(int(os.Stdin.Fd()))) },
+		Critical: false,
+		Hint:     "run wut in an interactive terminal; set TERM (e.g. xterm-256color) for colors",
+	})
+	report := checker.Check(ctx)
+
+	failed := 0
+	for _, result := range report.Checks {
+		if result.Status != "healthy" && result.Critical {
+			failed++
+		}
+	}
+
+	if outputJSON {
+		if err := writeJSON(newDoctorJSON(report, shells, caps, skipped)); err != nil {
+			return err
+		}
+	} else {
+		printDoctorReport(report, shells, caps, skipped)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d check(s) failed", failed)
+	}
+	return nil
+}
+
+// printDoctorReport prints the shells and the checklist
+func printDoctorReport(report health.Health, shells []shell.IntegrationStatus, caps terminal.Capabilities, skipped string) {
+	fmt.Println(ui.Title("WUT Doctor"))
+	fmt.Println()
+
+	fmt.Println(ui.Title("Shells"))
+	for _, s := range shells {
+		switch {
+		case s.Executable == "":
+			continue
+		case s.Stale():
+			fmt.Printf("  ⚠️  %-11s %s\n", s.Shell, ui.Warning(fmt.Sprintf("integration v%d is outdated", s.Version)))
+		case s.Installed:
+			fmt.Printf("  ✅ %-11s %s\n", s.Shell, ui.Muted("integration installed in "+s.ConfigFile))
+		default:
+			fmt.Printf("  ❌ %-11s %s\n", s.Shell, ui.Muted("integration not installed"))
+		}
+	}
+	fmt.Println()
+
+	fmt.Println(ui.Title("Checks"))
+	failed := 0
+	for _, r := range report.Checks {
+		switch {
+		case r.Status == "healthy":
+			fmt.Printf("  ✅ %s\n", r.Name)
+			continue
+		case r.Critical:
+			failed++
+			fmt.Printf("  ❌ %-18s %s\n", r.Name, r.Error)
+		default:
+			fmt.Printf("  ⚠️  %-18s %s\n", r.Name, r.Error)
+		}
+		if r.Hint != "" {
+			fmt.Printf("     %s\n", ui.Muted("→ "+r.Hint))
+		}
+	}
+	if skipped != "" {
+		fmt.Printf("  %s\n", ui.Muted("–  tldr network       skipped: "+skipped))
+	}
+	fmt.Println()
+
+	fmt.Println(ui.Muted(fmt.Sprintf("  Terminal: %dx%d, %s", caps.Width, caps.Height, strings.Join(terminalFeatures(caps), ", "))))
+	fmt.Println()
+	if failed == 0 {
+		fmt.Println(ui.Success("  Everything required works."))
+	}
+}
+
+// terminalFeatures lists what the terminal supports
+func terminalFeatures(caps terminal.Capabilities) []string {
+	features := []string{}
+	for _, f := range []struct {
+		name string
+		ok   bool
+	}{
+		{"tty", caps.IsTTY},
+		{"color", caps.Color},
+		{"256 colors", caps.Supports256Colors},
+		{"true color", caps.SupportsTrueColor},
+		{"emoji", caps.SupportsEmoji},
+		{"hyperlinks", caps.SupportsHyperlinks},
+	} {
+		if f.ok {
+			features = append(features, f.name)
+		}
+	}
+	if len(features) == 0 {
+		features = append(features, "plain text only")
+	}
+	return features
+}
+
+// checkDatabaseSize reports a history database larger than database.max_size
+func checkDatabaseSize(path string, maxMB int) error {
+	info, err := os.Stat(path)
+	if err != nil || maxMB <= 0 {
+		return nil // a missing database is reported by the history database check
+	}
+	if limit := int64(maxMB) * 1024 * 1024; info.Size() > limit {
+		return fmt.Errorf("%.1f MB, over database.max_size of %d MB", float64(info.Size())/(1024*1024), maxMB)
+	}
+	return nil
+}
+
+// checkTLDRNetwork reports whether the TLDR pages can be downloaded
+func checkTLDRNetwork(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if !db.NewClient().IsOnline(ctx) {
+		return fmt.Errorf("cannot reach the TLDR pages server")
+	}
+	return nil
+}
+
+// checkTerminal reports a terminal that cannot show the interactive views
+// or colors. Output redirected to a file is fine as long as the input is
+// still a terminal, as with `wut doctor --json > doctor.json`.
+func checkTerminal(caps terminal.Capabilities, stdinTTY bool) error {
+	switch {
+	case !caps.IsTTY && !stdinTTY:
+		return fmt.Errorf("output is not a terminal; interactive views fall back to plain text")
+	case os.Getenv("TERM") == "dumb":
+		return fmt.Errorf("TERM=dumb; colors and interactive views are limited")
+	}
+	return nil
+}
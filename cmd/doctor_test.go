@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"wut/internal/health"
+	"wut/internal/terminal"
+)
+
+func TestCheckDatabaseSize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wut.db")
+	if err := checkDatabaseSize(path, 1); err != nil {
+		t.Errorf("missing database: %v", err)
+	}
+	if err := os.WriteFile(path, make([]byte, 2*1024*1024), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkDatabaseSize(path, 1); err == nil {
+		t.Error("2 MB database passed a 1 MB limit")
+	}
+	if err := checkDatabaseSize(path, 0); err != nil {
+		t.Errorf("unlimited database: %v", err)
+	}
+}
+
+func TestCheckTerminal(t *testing.T) {
+	t.Setenv("TERM", "xterm-256color")
+	if err := checkTerminal(terminal.Capabilities{}, false); err == nil {
+		t.Error("no terminal at all passed")
+	}
+	if err := checkTerminal(terminal.Capabilities{}, true); err != nil {
+		t.Errorf("redirected output with a terminal on stdin: %v", err)
+	}
+	t.Setenv("TERM", "dumb")
+	if err := checkTerminal(terminal.Capabilities{IsTTY: true}, true); err == nil {
+		t.Error("TERM=dumb passed")
+	}
+}
+
+func TestDoctorJSON(t *testing.T) {
+	report := health.Health{Checks: []health.Result{
+		{Name: "config", Status: "healthy", Critical: true},
+		{Name: "clipboard", Status: "unhealthy", Error: "none", Hint: "install xclip"},
+	}}
+	doc := newDoctorJSON(report, nil, terminal.Capabilities{}, "privacy.local_only is on")
+	if doc.Status != "pass" || doc.Checks[0].Status != "pass" || doc.Checks[1].Status != "warn" || doc.Checks[1].Hint != "install xclip" {
+		t.Errorf("doctor JSON = %+v", doc)
+	}
+	if len(doc.Skipped) != 1 || doc.Skipped[0].Name != "tldr network" {
+		t.Errorf("Skipped = %+v", doc.Skipped)
+	}
+
+	report.Checks[0].Status = "unhealthy"
+	if doc := newDoctorJSON(report, nil, terminal.Capabilities{}, ""); doc.Status != "fail" || doc.Checks[0].Status != "fail" {
+		t.Errorf("failed required check gave %+v", doc)
+	}
+}
@@ -0,0 +1,170 @@
+// Package cmd provides CLI commands for WUT
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"wut/internal/config"
+	"wut/internal/db"
+)
+
+// dbPassphraseCmd represents the passphrase subcommand
+var dbPassphraseCmd = &cobra.Command{
+	Use:   "passphrase",
+	Short: "Change the history encryption passphrase",
+	Long: `Re-encrypt the history under a new passphrase.
+
+Encryption is turned on and off with privacy.encrypt_data:
+  wut config --set privacy.encrypt_data true`,
+	Args: cobra.NoArgs,
+	RunE: runDBPassphrase,
+}
+
+func init() {
+	dbCmd.AddCommand(dbPassphraseCmd)
+}
+
+func runDBPassphrase(cmd *cobra.Command, args []string) error {
+	storage, err := db.NewStorage(config.GetDatabasePath())
+	if err != nil {
+		return err
+	}
+	defer storage.Close()
+
+	if !storage.IsEncrypted() {
+		return fmt.Errorf("the history is not encrypted; turn it on with 'wut config --set privacy.encrypt_data true'")
+	}
+	if err := unlockHistory(storage); err != nil {
+		return err
+	}
+	passphrase, err := readNewPassphrase()
+	if err != nil {
+		return err
+	}
+	if err := storage.ChangePassphrase(cmd.Context(), passphrase); err != nil {
+		return err
+	}
+
+	fmt.Println("✅ Passphrase changed")
+	return nil
+}
+
+// setEncryptData turns history encryption on or off, converting the stored
+// history, before the setting is saved
+func setEncryptData(cfgAny any, raw string) error {
+	cfg, ok := cfgAny.(*config.Config)
+	if !ok || cfg == nil {
+		return fmt.Errorf("configuration unavailable")
+	}
+	enabled, err := parseBool(raw)
+	if err != nil {
+		return err
+	}
+	if err := applyEncryption(context.Background(), enabled); err != nil {
+		return err
+	}
+	cfg.Privacy.EncryptData = enabled
+	return nil
+}
+
+// applyEncryption encrypts or decrypts the history database to match
+// privacy.encrypt_data
+func applyEncryption(ctx context.Context, enabled bool) error {
+	storage, err := db.NewStorage(config.GetDatabasePath())
+	if err != nil {
+		return err
+	}
+	defer storage.Close()
+
+	if !enabled {
+		if !storage.IsEncrypted() {
+			return nil
+		}
+		if err := unlockHistory(storage); err != nil {
+			return err
+		}
+		n, err := storage.DisableEncryption(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("🔓 Decrypted %d history entries\n", n)
+		return nil
+	}
+
+	if storage.IsEncrypted() {
+		return unlockHistory(storage)
+	}
+	passphrase, err := readNewPassphrase()
+	if err != nil {
+		return err
+	}
+	migrate := true
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		migrate = askYN("Encrypt the history you already have? [Y/n]:", true)
+	}
+	n, err := storage.EnableEncryption(ctx, passphrase, migrate)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("🔒 History encryption is on (%d existing entries encrypted)\n", n)
+	fmt.Printf("   Keep the passphrase safe: it cannot be recovered. Non-interactive runs can use %s.\n", db.PassphraseEnv)
+	return nil
+}
+
+// unlockHistory makes sure encrypted history can be read, asking for the
+// passphrase when neither the OS keyring nor the environment provide it
+func unlockHistory(storage *db.Storage) error {
+	if _, err := storage.GetHistory(context.Background(), 1); !errors.Is(err, db.ErrEncryptionLocked) {
+		return err
+	}
+	passphrase, err := readPassphrase("Passphrase: ")
+	if err != nil {
+		return err
+	}
+	return storage.Unlock(passphrase)
+}
+
+// readNewPassphrase asks for a new passphrase twice, or takes it from the
+// environment
+func readNewPassphrase() (string, error) {
+	if passphrase := os.Getenv(db.PassphraseEnv); passphrase != "" {
+		return passphrase, nil
+	}
+	passphrase, err := readPassphrase("New passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(passphrase) == "" {
+		return "", fmt.Errorf("the passphrase cannot be empty")
+	}
+	again, err := readPassphrase("Repeat passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if again != passphrase {
+		return "", fmt.Errorf("the passphrases do not match")
+	}
+	return passphrase, nil
+}
+
+// readPassphrase reads a passphrase from the terminal without echoing it
+func readPassphrase(prompt string) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", fmt.Errorf("a passphrase is required: set %s", db.PassphraseEnv)
+	}
+	fmt.Print(prompt)
+	passphrase, err := term.ReadPassword(fd)
+	fmt.Println()
+	if err != nil {
+		return "", fmt.Errorf("failed to read passphrase: %w", err)
+	}
+	return string(passphrase), nil
+}
@@ -0,0 +1,276 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"wut/internal/config"
+	"wut/internal/corrector"
+	"wut/internal/db"
+	"wut/internal/logger"
+	"wut/internal/terminal"
+	"wut/internal/ui"
+)
+
+// execForce skips the dry-run confirmation shown before executing commands
+var execForce bool
+
+// executeCommand runs a command picked in WUT through the user's shell and
+// records it in history. historyStore may be nil, in which case the history
+// database is opened on demand. A failing command is returned as
+// *db.ExitError so Execute can propagate its exit status.
+func executeCommand(ctx context.Context, historyStore *db.Storage, command string) error {
+	result, err := db.ExecuteCommand(ctx, command)
+	recordExecution(ctx, historyStore, result)
+
+	if err != nil {
+		var exitErr *db.ExitError
+		if errors.As(err, &exitErr) {
+			return exitErr
+		}
+		return fmt.Errorf("execution failed: %w", err)
+	}
+	return nil
+}
+
+// runPickedCommand fills in the placeholders of a command picked in a TUI,
+// confirms it and runs it
+func runPickedCommand(ctx context.Context, command string) error {
+	command = promptPlaceholders(command)
+	if !confirmExecution(command) {
+		return nil
+	}
+	fmt.Printf("\n⚡ Executing: %s\n\n", command)
+	return executeCommand(ctx, nil, command)
+}
+
+// promptPlaceholders asks for a value for each <placeholder> in command.
+// Without a terminal, or when a value is left empty, the placeholder falls
+// back to its default the same way db.ExecuteCommand strips it.
+func promptPlaceholders(command string) string {
+	placeholders := db.Placeholders(command)
+	if len(placeholders) == 0 || !terminal.IsInteractive() {
+		return db.FillPlaceholders(command, nil)
+	}
+
+	fmt.Println()
+	fmt.Println(ui.Muted("Fill in the placeholders (leave empty to skip):"))
+	values := make([]string, len(placeholders))
+	for i, p := range placeholders {
+		prompt := p.Name + ":"
+		if p.Default != "" {
+			prompt = fmt.Sprintf("%s [%s]:", p.Name, p.Default)
+		}
+		values[i] = askChoice(prompt, "")
+	}
+	return db.FillPlaceholders(command, values)
+}
+
+// recordExecution stores the executed command when history tracking is enabled
+func recordExecution(ctx context.Context, storage *db.Storage, result *db.ExecResult) {
+	if result == nil {
+		return
+	}
+
+	cfg := config.Get()
+	if !cfg.History.Enabled {
+		return
+	}
+
+	log := logger.With("exec")
+	if storage == nil {
+		var err error
+		storage, err = db.NewStorage(config.GetDatabasePath())
+		if err != nil {
+			log.Debug("failed to open history storage", "error", err)
+			return
+		}
+		defer storage.Close()
+	}
+
+	// The command may have been cancelled; still record what happened
+	ctx = context.WithoutCancel(ctx)
+	if err := storage.RecordExecution(ctx, result); err != nil {
+		log.Debug("failed to record execution", "error", err)
+		return
+	}
+	if cfg.History.MaxEntries > 0 {
+		_ = storage.TrimHistory(ctx, cfg.History.MaxEntries)
+	}
+}
+
+// ── Dry-run summary ──────────────────────────────────────────────────────────
+
+// execSummary is a dry-run breakdown of a command: what runs, which flags it
+// passes, which files it touches and whether it is dangerous.
+type execSummary struct {
+	Command   string
+	Base      string
+	Flags     []execSummaryFlag
+	Paths     []string
+	Warnings  []string
+	Dangerous bool
+}
+
+// execSummaryFlag is a single flag together with its meaning, if known
+type execSummaryFlag struct {
+	Flag    string
+	Meaning string
+	Known   bool
+}
+
+// buildExecSummary parses command and runs the corrector's dangerous checks
+// without executing anything.
+func buildExecSummary(command string) *execSummary {
+	parsed := parseCommand(command)
+	summary := &execSummary{
+		Command: parsed.Raw,
+	}
+
+	// Each stage of a chain like "a && b" runs its own command
+	var args []string
+	for _, stage := range parsed.Stages() {
+		if stage.Operator != "" {
+			summary.Base += " " + stage.Operator + " "
+		}
+		summary.Base += stage.Command
+
+		for _, f := range stage.Flags {
+			flag := "--" + f.Name
+			if f.IsShort {
+				flag = "-" + f.Name
+			}
+			meaning, known := corrector.DescribeFlag(stage.Command, flag)
+			summary.Flags = append(summary.Flags, execSummaryFlag{Flag: flag, Meaning: meaning, Known: known})
+		}
+		args = append(args, stage.Args...)
+	}
+
+	summary.Paths = touchedPaths(args)
+
+	if danger := corrector.New().AssessDanger(command); danger != nil {
+		summary.Dangerous = true
+		summary.Warnings = append(summary.Warnings, strings.TrimSpace(strings.TrimPrefix(danger.Explanation, "⚠️")))
+	}
+	if checkIfDangerous(parsed) {
+		summary.Dangerous = true
+	}
+	summary.Warnings = append(summary.Warnings, generateWarnings(parsed)...)
+
+	return summary
+}
+
+// touchedPaths picks the arguments that refer to files: redirection targets,
+// path-like tokens and names that exist in the working directory.
+func touchedPaths(args []string) []string {
+	var paths []string
+	seen := make(map[string]bool)
+	add := func(p string) {
+		p = strings.Trim(p, `"'`)
+		if p == "" || seen[p] {
+			return
+		}
+		seen[p] = true
+		paths = append(paths, p)
+	}
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == ">" || arg == ">>" || arg == "<" || arg == "2>":
+			if i+1 < len(args) {
+				add(args[i+1])
+				i++
+			}
+		case strings.HasPrefix(arg, ">") || strings.HasPrefix(arg, "<"):
+			add(strings.TrimLeft(arg, "<>"))
+		case strings.ContainsAny(arg, "/\\*") || strings.HasPrefix(arg, ".") || strings.HasPrefix(arg, "~"):
+			if !strings.Contains(arg, "://") {
+				add(arg)
+			}
+		default:
+			if _, err := os.Stat(arg); err == nil {
+				add(arg)
+			}
+		}
+	}
+
+	return paths
+}
+
+// renderExecSummary renders the one-screen summary shown before execution
+// and by `wut explain --verbose`.
+func renderExecSummary(s *execSummary) string {
+	labelStyle := lipgloss.NewStyle().Foreground(ui.ColorSubtle).Width(10)
+
+	var b strings.Builder
+	b.WriteString(labelStyle.Render("Command") + ui.Primary(s.Command) + "\n")
+	if s.Base != "" {
+		b.WriteString(labelStyle.Render("Runs") + ui.Success(s.Base) + "\n")
+	}
+
+	if len(s.Flags) > 0 {
+		b.WriteString(labelStyle.Render("Flags") + "\n")
+		for _, f := range s.Flags {
+			meaning := f.Meaning
+			if !f.Known {
+				meaning = ui.Muted("unrecognized")
+			}
+			b.WriteString(fmt.Sprintf("  %s %s\n", ui.Warning(fmt.Sprintf("%-14s", f.Flag)), meaning))
+		}
+	}
+
+	if len(s.Paths) > 0 {
+		b.WriteString(labelStyle.Render("Touches") + strings.Join(s.Paths, ", ") + "\n")
+	}
+
+	if s.Dangerous || len(s.Warnings) > 0 {
+		if s.Dangerous {
+			b.WriteString(ui.Error("⚠  This command is flagged as dangerous") + "\n")
+		}
+		for _, w := range s.Warnings {
+			b.WriteString("  " + ui.Warning("• "+w) + "\n")
+		}
+	}
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(ui.ColorBrand).
+		Padding(0, 1).
+		Render(strings.TrimRight(b.String(), "\n"))
+}
+
+// confirmExecution shows the dry-run summary and asks before running a
+// command picked in a TUI. It is skipped with --force, and when
+// ui.confirm_before_exec is disabled unless the command is dangerous and
+// ui.confirm_dangerous is enabled.
+func confirmExecution(command string) bool {
+	if execForce {
+		return true
+	}
+
+	// Dangerous commands are confirmed even when the summary is turned off
+	summary := buildExecSummary(command)
+	if !config.Get().UI.ConfirmBeforeExec && !(summary.Dangerous && config.Get().UI.ConfirmDangerous) {
+		return true
+	}
+
+	fmt.Println()
+	fmt.Println(renderExecSummary(summary))
+	fmt.Println()
+
+	prompt := "Run this command? [y/N]"
+	if summary.Dangerous {
+		prompt = "This command is dangerous. Run it anyway? [y/N]"
+	}
+	if !askYN(prompt, false) {
+		fmt.Println(ui.Muted("Cancelled - nothing was executed."))
+		return false
+	}
+	return true
+}
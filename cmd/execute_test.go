@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"wut/internal/config"
+)
+
+// feedStdin makes input the answer to the prompts read from stdin
+func feedStdin(t *testing.T, input string) {
+	t.Helper()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("pipe: %v", err)
+	}
+	if _, err := w.WriteString(input); err != nil {
+		t.Fatalf("write stdin: %v", err)
+	}
+	_ = w.Close()
+
+	orig := os.Stdin
+	os.Stdin = r
+	t.Cleanup(func() {
+		os.Stdin = orig
+		_ = r.Close()
+	})
+}
+
+func TestConfirmExecution(t *testing.T) {
+	t.Cleanup(func() {
+		config.Set(&config.Config{})
+		execForce = false
+	})
+
+	tests := []struct {
+		name             string
+		command          string
+		confirmAll       bool
+		confirmDangerous bool
+		force            bool
+		input            string
+		want             bool
+		wantPrompt       string // empty when no summary may be shown
+	}{
+		{"confirmed", "ls -la", true, false, false, "y\n", true, "Run this command?"},
+		{"declined", "ls -la", true, false, false, "n\n", false, "Run this command?"},
+		{"default is no", "ls -la", true, false, false, "\n", false, "Run this command?"},
+		{"summary disabled", "ls -la", false, true, false, "n\n", true, ""},
+		{"dangerous still confirmed", "rm -rf /", false, true, false, "n\n", false, "dangerous. Run it anyway?"},
+		{"dangerous unconfirmed", "rm -rf /", false, false, false, "n\n", true, ""},
+		{"forced", "rm -rf /", true, true, true, "n\n", true, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{}
+			cfg.UI.ConfirmBeforeExec = tt.confirmAll
+			cfg.UI.ConfirmDangerous = tt.confirmDangerous
+			config.Set(cfg)
+			execForce = tt.force
+			feedStdin(t, tt.input)
+
+			var got bool
+			out := captureStdout(t, func() { got = confirmExecution(tt.command) })
+			if got != tt.want {
+				t.Errorf("confirmExecution(%q) = %v, want %v", tt.command, got, tt.want)
+			}
+			switch {
+			case tt.wantPrompt == "" && strings.TrimSpace(out) != "":
+				t.Errorf("confirmExecution(%q) asked without a reason:\n%s", tt.command, out)
+			case tt.wantPrompt != "" && (!strings.Contains(out, tt.wantPrompt) || !strings.Contains(out, tt.command)):
+				t.Errorf("confirmExecution(%q) summary lacks %q or the command:\n%s", tt.command, tt.wantPrompt, out)
+			}
+			if !got && tt.wantPrompt != "" && !strings.Contains(out, "nothing was executed") {
+				t.Errorf("declining did not say nothing ran:\n%s", out)
+			}
+		})
+	}
+}
+
+func TestRunPickedCommandDeclined(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.UI.ConfirmBeforeExec = true
+	config.Set(cfg)
+	t.Cleanup(func() { config.Set(&config.Config{}) })
+	feedStdin(t, "n\n")
+
+	// Running "false" would fail, so a nil error means nothing was executed
+	var err error
+	captureStdout(t, func() { err = runPickedCommand(context.Background(), "false") })
+	if err != nil {
+		t.Errorf("runPickedCommand() after declining = %v, want nothing executed", err)
+	}
+}
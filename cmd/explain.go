@@ -4,11 +4,15 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
 	"wut/internal/config"
+	"wut/internal/corrector"
+	"wut/internal/db"
 	"wut/internal/logger"
 	"wut/internal/metrics"
 	"wut/internal/ui"
@@ -16,12 +20,24 @@ import (
 
 // explainCmd represents the explain command
 var explainCmd = &cobra.Command{
-	Use:   "explain [command]",
+	Use:   "explain [command | -]",
 	Short: "Explain a command",
-	Long:  `Get a detailed explanation of what a command does, its flags, and potential risks.`,
+	Long: `Get a detailed explanation of what a command does, its flags, and potential risks.
+
+Each flag is explained on its own, so explain doubles as a quick reference
+for what a flag does; unknown flags point at the closest known one. Flags
+after the command belong to it, so put explain's own flags first, or quote
+the command.
+
+With "-", or when input is piped and no command is given, the commands are
+read from standard input, one per line, and explained in turn; --json then
+prints an array.`,
 	Example: `  wut explain "git rebase -i"
+  wut explain docker run --rm -it
   wut explain "docker-compose up -d"
-  wut explain "rm -rf /"`,
+  wut explain "rm -rf /"
+  wut explain "tar -xzf a.tgz" --json
+  wut explain --json - < commands.txt`,
 	RunE: runExplain,
 }
 
@@ -30,45 +46,102 @@ var (
 	explainDangerous bool
 )
 
+// explainDBTimeout bounds the wait for the TLDR cache; descriptions are
+// optional, so a locked database is skipped
+const explainDBTimeout = 100 * time.Millisecond
+
 func init() {
 	rootCmd.AddCommand(explainCmd)
 
+	// Flags after the command are its own, e.g. the --rm of docker run --rm
+	explainCmd.Flags().SetInterspersed(false)
 	explainCmd.Flags().BoolVarP(&explainVerbose, "verbose", "v", false, "show detailed explanation")
-	explainCmd.Flags().BoolVarP(&explainDangerous, "dangerous", "d", false, "show dangerous command warnings")
+	explainCmd.Flags().BoolVar(&explainDangerous, "dangerous", false, "show dangerous command warnings")
+	explainCmd.Flags().BoolVar(&outputJSON, "json", false, "print the explanation as JSON")
 }
 
 func runExplain(cmd *cobra.Command, args []string) error {
 	ctx := cmd.Context()
-	log := logger.With("explain")
+	cfg := config.Get()
+
+	commands, fromStdin, err := stdinCommands(cmd, args)
+	if err != nil {
+		return err
+	}
+	if fromStdin && (len(commands) > 1 || outputJSON) {
+		return explainCommands(ctx, commands, cfg)
+	}
+	if fromStdin {
+		args = commands
+	}
 
 	if len(args) == 0 {
 		return fmt.Errorf("please provide a command to explain")
 	}
 
-	command := strings.Join(args, " ")
-	log.Debug("explaining command", "command", command)
+	// A quoted command keeps explain's flags after it working
+	if !fromStdin && len(args) > 1 && strings.ContainsAny(args[0], " \t") {
+		if err := cmd.Flags().Parse(args[1:]); err != nil {
+			return err
+		}
+		args = append(args[:1], cmd.Flags().Args()...)
+	}
 
-	cfg := config.Get()
+	parsed, explanation, err := explainCommand(ctx, strings.Join(args, " "), cfg)
+	if err != nil {
+		return err
+	}
+
+	// Display explanation
+	if outputJSON {
+		return writeJSON(newExplainJSON(parsed, explanation))
+	}
+	return displayExplanation(explanation, cfg)
+}
+
+// explainCommands explains each of commands in turn, as read from standard
+// input. With --json the explanations are printed as one array.
+func explainCommands(ctx context.Context, commands []string, cfg *config.Config) error {
+	docs := make([]*explainJSON, 0, len(commands))
+	for i, command := range commands {
+		parsed, explanation, err := explainCommand(ctx, command, cfg)
+		if err != nil {
+			return err
+		}
+		if outputJSON {
+			docs = append(docs, newExplainJSON(parsed, explanation))
+			continue
+		}
+		printCommandHeader(i, len(commands), command)
+		if err := displayExplanation(explanation, cfg); err != nil {
+			return err
+		}
+	}
+	if outputJSON {
+		return writeJSON(docs)
+	}
+	return nil
+}
+
+// explainCommand parses and explains command
+func explainCommand(ctx context.Context, command string, cfg *config.Config) (*ParsedCommand, *Explanation, error) {
+	log := logger.With("explain")
+	log.Debug("explaining command", "command", command)
 
 	// Parse the command
-	parsed := parseCommand(command)
+	parsed := parseFirstCommand(command)
 
 	// Generate explanation
 	explanation, err := generateExplanation(ctx, parsed, cfg)
 	if err != nil {
 		log.Error("failed to generate explanation", "error", err)
-		return fmt.Errorf("failed to explain command: %w", err)
-	}
-
-	// Display explanation
-	if err := displayExplanation(explanation, cfg); err != nil {
-		return err
+		return nil, nil, fmt.Errorf("failed to explain command: %w", err)
 	}
 
 	// Record metrics
 	metrics.RecordCommandExplained()
 
-	return nil
+	return parsed, explanation, nil
 }
 
 // Explanation holds command explanation
@@ -76,6 +149,7 @@ type Explanation struct {
 	Command      string
 	Summary      string
 	Description  string
+	Segments     []Segment
 	Arguments    []Argument
 	Flags        []Flag
 	Examples     []Example
@@ -101,6 +175,8 @@ type Flag struct {
 	Description string
 	HasValue    bool
 	IsShort     bool
+	Known       bool
+	DidYouMean  string // closest known flag when this one is unknown
 }
 
 // Example represents a usage example
@@ -111,10 +187,22 @@ type Example struct {
 
 // ParsedCommand represents a parsed command
 type ParsedCommand struct {
-	Command string
-	Args    []string
-	Flags   []ParsedFlag
-	Raw     string
+	Command  string
+	Args     []string
+	Flags    []ParsedFlag
+	EnvVars  map[string]string // VAR=value assignments before the command
+	Operator string            // how a stage joins the previous one: &&, || or ;
+	Sequence []ParsedCommand   // every stage of a chain like "a && b", this one first
+	Raw      string
+}
+
+// Stages returns the stages of a chain, or the command itself when it is not
+// one
+func (p *ParsedCommand) Stages() []ParsedCommand {
+	if len(p.Sequence) > 0 {
+		return p.Sequence
+	}
+	return []ParsedCommand{*p}
 }
 
 // ParsedFlag represents a parsed flag
@@ -125,13 +213,12 @@ type ParsedFlag struct {
 }
 
 func generateExplanation(ctx context.Context, parsed *ParsedCommand, cfg *config.Config) (*Explanation, error) {
-	// This is a simplified implementation
-	// In production, this would use a comprehensive command database
+	descriptions := newCommandDescriber()
+	defer descriptions.Close()
 
 	explanation := &Explanation{
 		Command:      parsed.Raw,
-		Summary:      generateSummary(parsed),
-		Description:  generateDescription(parsed),
+		Segments:     splitSegments(parsed.Raw, descriptions.Describe),
 		Arguments:    extractArguments(parsed),
 		Flags:        extractFlagsV2(parsed),
 		Examples:     generateExamples(parsed),
@@ -141,10 +228,82 @@ func generateExplanation(ctx context.Context, parsed *ParsedCommand, cfg *config
 		DangerLevel:  calculateDangerLevel(parsed),
 		Alternatives: generateAlternatives(parsed),
 	}
+	explanation.Summary = generateSummary(explanation.Segments)
+	explanation.Description = generateDescription(explanation.Segments)
+
+	// Ask the corrector about the whole line and each of its commands
+	checker := corrector.New()
+	checked := []string{parsed.Raw}
+	for _, seg := range explanation.Segments {
+		checked = append(checked, seg.Raw)
+	}
+	for _, command := range checked {
+		danger := checker.AssessDanger(command)
+		if danger == nil {
+			continue
+		}
+		explanation.IsDangerous = true
+		if explanation.DangerLevel == "safe" {
+			explanation.DangerLevel = "high"
+		}
+		warning := strings.TrimSpace(strings.TrimPrefix(danger.Explanation, "⚠️"))
+		if !slices.Contains(explanation.Warnings, warning) {
+			explanation.Warnings = append(explanation.Warnings, warning)
+		}
+	}
 
 	return explanation, nil
 }
 
+// commandDescriber describes commands from the cached TLDR pages, falling
+// back to the corrector's built-in descriptions
+type commandDescriber struct {
+	pages *db.Storage
+}
+
+func newCommandDescriber() *commandDescriber {
+	pages, err := db.OpenReadOnly(config.GetTLDRDatabasePath(), explainDBTimeout)
+	if err != nil {
+		pages = nil
+	}
+	return &commandDescriber{pages: pages}
+}
+
+// Describe returns a one-line description of command, or of its subcommand
+// when a page exists for it
+func (d *commandDescriber) Describe(command, subcommand string) string {
+	if subcommand != "" {
+		if desc := d.page(command + "-" + subcommand); desc != "" {
+			return desc
+		}
+	}
+	if desc := d.page(command); desc != "" {
+		return desc
+	}
+	if desc, ok := corrector.DescribeCommand(command); ok {
+		return desc
+	}
+	return ""
+}
+
+func (d *commandDescriber) page(name string) string {
+	if d.pages == nil {
+		return ""
+	}
+	page, err := d.pages.GetPageAnyPlatform(name, "")
+	if err != nil {
+		return ""
+	}
+	desc, _, _ := strings.Cut(strings.TrimSpace(page.Description), "\n")
+	return strings.TrimSuffix(strings.TrimSpace(desc), ".")
+}
+
+func (d *commandDescriber) Close() {
+	if d.pages != nil {
+		d.pages.Close()
+	}
+}
+
 func displayExplanation(exp *Explanation, cfg *config.Config) error {
 	// Use UI package for styled output
 	uiRenderer := ui.NewRenderer(cfg.UI)
@@ -175,41 +334,10 @@ func displayExplanation(exp *Explanation, cfg *config.Config) error {
 		fmt.Println()
 	}
 
-	// Print arguments
-	if len(exp.Arguments) > 0 {
-		fmt.Println("Arguments:")
-		for _, arg := range exp.Arguments {
-			required := ""
-			if arg.Required {
-				required = ui.Red(" (required)")
-			}
-			fmt.Printf("  %s%s - %s\n", ui.Yellow(arg.Name), required, arg.Description)
-		}
-		fmt.Println()
-	}
-
-	// Print flags
-	if len(exp.Flags) > 0 {
-		fmt.Println("Flags:")
-		for _, flag := range exp.Flags {
-			flagStr := ""
-			if flag.IsShort {
-				flagStr += ui.Greenf("-%s", flag.Name)
-			} else {
-				flagStr += ui.Greenf("--%s", flag.Name)
-			}
-			fmt.Printf("  %s - %s\n", flagStr, flag.Description)
-		}
-		fmt.Println()
-	}
-
-	// Print examples
-	if len(exp.Examples) > 0 {
-		fmt.Println("Examples:")
-		for _, ex := range exp.Examples {
-			fmt.Printf("  $ %s\n", ui.Cyan(ex.Command))
-			fmt.Printf("    %s\n", ex.Description)
-		}
+	// Print the command tree
+	if len(exp.Segments) > 0 {
+		fmt.Println("Breakdown:")
+		fmt.Print(renderSegments(exp.Segments))
 		fmt.Println()
 	}
 
@@ -231,21 +359,62 @@ func displayExplanation(exp *Explanation, cfg *config.Config) error {
 		fmt.Println()
 	}
 
+	// Print the same dry-run summary shown before executing from the TUIs
+	if explainVerbose {
+		fmt.Println("Dry run:")
+		fmt.Println(renderExecSummary(buildExecSummary(exp.Command)))
+		fmt.Println()
+	}
+
 	return nil
 }
 
 // Helper functions for explanation generation
 
 func parseCommand(command string) *ParsedCommand {
-	parts := strings.Fields(command)
-	if len(parts) == 0 {
-		return &ParsedCommand{Raw: command}
+	return parseSequence(command, func(stage string) *ParsedCommand {
+		return parseWords(stage, strings.Fields(stage))
+	})
+}
+
+// parseSequence parses each stage of a chain joined by &&, || or ; with
+// parse. The result describes the first stage, with the whole line as Raw
+// and all of the stages in Sequence.
+func parseSequence(line string, parse func(string) *ParsedCommand) *ParsedCommand {
+	stages := corrector.SplitSequence(line)
+	if len(stages) < 2 {
+		return parse(line)
 	}
 
-	parsed := &ParsedCommand{
-		Command: parts[0],
-		Raw:     command,
+	sequence := make([]ParsedCommand, len(stages))
+	for i, stage := range stages {
+		sequence[i] = *parse(stage.Command)
+		sequence[i].Operator = stage.Operator
 	}
+	parsed := sequence[0]
+	parsed.Raw = line
+	parsed.Sequence = sequence
+	return &parsed
+}
+
+// parseWords parses the words of a simple command. A single-dash option the
+// command is known to take whole, like find's -name, is not split into a
+// cluster of short flags. Leading VAR=value assignments go to EnvVars and
+// the command is the first word after them.
+func parseWords(raw string, parts []string) *ParsedCommand {
+	parsed := &ParsedCommand{Raw: raw}
+	for len(parts) > 0 && corrector.IsEnvAssignment(parts[0]) {
+		name, value, _ := strings.Cut(parts[0], "=")
+		if parsed.EnvVars == nil {
+			parsed.EnvVars = map[string]string{}
+		}
+		parsed.EnvVars[name] = value
+		parts = parts[1:]
+	}
+	if len(parts) == 0 {
+		return parsed
+	}
+	parsed.Command = parts[0]
 
 	for i := 1; i < len(parts); i++ {
 		part := parts[i]
@@ -262,7 +431,8 @@ func parseCommand(command string) *ParsedCommand {
 			parsed.Flags = append(parsed.Flags, flag)
 		} else if strings.HasPrefix(part, "-") && len(part) > 1 {
 			// Short flag(s)
-			if len(part) > 2 && part[2] != '=' {
+			name, _, _ := strings.Cut(part, "=")
+			if !wholeFlag(parsed.Command, name) && len(part) > 2 && part[2] != '=' {
 				// Multiple short flags like -rf
 				for j := 1; j < len(part); j++ {
 					parsed.Flags = append(parsed.Flags, ParsedFlag{
@@ -291,18 +461,48 @@ func parseCommand(command string) *ParsedCommand {
 	return parsed
 }
 
-func generateSummary(parsed *ParsedCommand) string {
-	if parsed.Command == "" {
+func generateSummary(segments []Segment) string {
+	switch len(segments) {
+	case 0:
 		return "Unknown command"
+	case 1:
+		if segments[0].Description != "" {
+			return segments[0].Description
+		}
+		return fmt.Sprintf("Executes %s", segments[0].Command)
 	}
 
-	// Build summary based on command
-	return fmt.Sprintf("Executes %s", parsed.Command)
+	names := make([]string, 0, len(segments))
+	for _, seg := range segments {
+		if seg.Command != "" {
+			names = append(names, seg.Command)
+		}
+	}
+	return fmt.Sprintf("Runs %d commands: %s", len(names), strings.Join(names, ", "))
 }
 
-func generateDescription(parsed *ParsedCommand) string {
-	// In production, this would look up from a command database
-	return fmt.Sprintf("The %s command is used to perform operations.", parsed.Command)
+// generateDescription describes what the command line does, one sentence
+// per command
+func generateDescription(segments []Segment) string {
+	var lines []string
+	for _, seg := range segments {
+		name := seg.Command
+		if seg.Subcommand != "" {
+			name += " " + seg.Subcommand
+		}
+		if name == "" {
+			continue
+		}
+		line := name
+		if seg.Description != "" {
+			line += ": " + seg.Description
+		}
+		if seg.Operator != "" {
+			line = fmt.Sprintf("%s (%s)", line, operatorDescriptions[seg.Operator])
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
 }
 
 func extractArguments(parsed *ParsedCommand) []Argument {
@@ -317,16 +517,21 @@ func extractArguments(parsed *ParsedCommand) []Argument {
 	return args
 }
 
+// wholeFlag reports whether a single-dash option is one flag rather than a
+// cluster of short ones: a known flag, or a misspelt single-dash long option
+// such as find's -nmae
+func wholeFlag(root, name string) bool {
+	if _, ok := corrector.DescribeFlag(root, name); ok {
+		return true
+	}
+	_, ok := corrector.ClosestFlag(root, name)
+	return ok
+}
+
 func extractFlagsV2(parsed *ParsedCommand) []Flag {
 	var flags []Flag
 	for _, f := range parsed.Flags {
-		flags = append(flags, Flag{
-			Name:        f.Name,
-			Value:       f.Value,
-			Description: "Command flag",
-			HasValue:    f.Value != "",
-			IsShort:     f.IsShort,
-		})
+		flags = append(flags, describeFlagOf(parsed.Command, f))
 	}
 	return flags
 }
@@ -368,16 +573,23 @@ func generateWarnings(parsed *ParsedCommand) []string {
 
 func generateTips(parsed *ParsedCommand) []string {
 	var tips []string
+	add := func(tip string) {
+		if !slices.Contains(tips, tip) {
+			tips = append(tips, tip)
+		}
+	}
 
-	cmd := strings.ToLower(parsed.Command)
+	for _, stage := range parsed.Stages() {
+		cmd := strings.ToLower(stage.Command)
 
-	if cmd == "rm" {
-		tips = append(tips, "Use 'rm -i' for interactive mode to confirm each deletion")
-		tips = append(tips, "Consider using 'trash' command instead for safer deletion")
-	}
+		if cmd == "rm" {
+			add("Use 'rm -i' for interactive mode to confirm each deletion")
+			add("Consider using 'trash' command instead for safer deletion")
+		}
 
-	if cmd == "git" {
-		tips = append(tips, "Use 'git status' before committing to review changes")
+		if cmd == "git" {
+			add("Use 'git status' before committing to review changes")
+		}
 	}
 
 	return tips
@@ -426,8 +638,6 @@ func calculateDangerLevel(parsed *ParsedCommand) string {
 }
 
 func generateAlternatives(parsed *ParsedCommand) []string {
-	cmd := strings.ToLower(parsed.Command)
-
 	alternatives := map[string][]string{
 		"rm": {
 			"Use 'trash' command to move files to trash instead of deleting",
@@ -438,9 +648,23 @@ func generateAlternatives(parsed *ParsedCommand) []string {
 		},
 	}
 
-	if alts, ok := alternatives[cmd]; ok {
-		return alts
+	c := corrector.New()
+	var alts []string
+	add := func(alt string) {
+		if !slices.Contains(alts, alt) {
+			alts = append(alts, alt)
+		}
 	}
-
-	return nil
+	for _, stage := range parsed.Stages() {
+		for _, alt := range alternatives[strings.ToLower(stage.Command)] {
+			add(alt)
+		}
+		for _, alt := range c.SuggestAlternativeDetailed(stage.Command) {
+			add(fmt.Sprintf("Try '%s' instead: %s", alt.Name, alt.Reason))
+		}
+		if alt, ok := c.ClassicEquivalent(stage.Command); ok {
+			add(fmt.Sprintf("'%s' is a modern '%s': %s", alt.Name, alt.Replaces, alt.Reason))
+		}
+	}
+	return alts
 }
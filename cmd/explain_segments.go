@@ -0,0 +1,299 @@
+package cmd
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"wut/internal/corrector"
+	"wut/internal/shellwords"
+	"wut/internal/ui"
+)
+
+// Segment is one simple command of an explained command line, such as a
+// single stage of a pipeline
+type Segment struct {
+	Operator    string // how it joins the previous segment: |, &&, ||, ; or &
+	Raw         string
+	Command     string
+	Subcommand  string
+	Description string
+	EnvVars     map[string]string
+	Args        []string
+	Flags       []Flag
+	Redirects   []Redirect
+}
+
+// Redirect is a redirection attached to a segment
+type Redirect struct {
+	Operator    string
+	Target      string
+	Description string
+}
+
+// subcommandTools take a subcommand as their first argument
+var subcommandTools = map[string]bool{
+	"apt": true, "brew": true, "cargo": true, "docker": true,
+	"docker-compose": true, "git": true, "go": true, "helm": true,
+	"kubectl": true, "npm": true, "pip": true, "pnpm": true,
+	"systemctl": true, "terraform": true, "yarn": true,
+}
+
+// operatorDescriptions explain how a segment relates to the previous one
+var operatorDescriptions = map[string]string{
+	"|":  "receives the output of the previous command",
+	"|&": "receives the output and errors of the previous command",
+	"&&": "runs only if the previous command succeeded",
+	"||": "runs only if the previous command failed",
+	";":  "runs after the previous command",
+	"&":  "runs while the previous command continues in the background",
+}
+
+// parseFirstCommand parses the first simple command of a command line,
+// leaving out redirections. Raw keeps the whole line, and a chain like
+// "a && b" has each of its stages in Sequence.
+func parseFirstCommand(line string) *ParsedCommand {
+	return parseSequence(line, parseSimpleCommand)
+}
+
+// parseSimpleCommand parses the words of line up to its first pipe or other
+// control operator
+func parseSimpleCommand(line string) *ParsedCommand {
+	tokens := shellwords.Lex(line)
+	var words []string
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+		if tok.Kind == shellwords.Control {
+			break
+		}
+		if tok.Kind == shellwords.Redirect {
+			if tok.Target == "" && i+1 < len(tokens) && tokens[i+1].Kind == shellwords.Word {
+				i++
+			}
+			continue
+		}
+		words = append(words, tok.Text)
+	}
+	return parseWords(line, words)
+}
+
+// splitSegments breaks a command line into its simple commands and describes
+// each of them
+func splitSegments(line string, describe func(command, subcommand string) string) []Segment {
+	tokens := shellwords.Lex(line)
+
+	var segments []Segment
+	var words []string
+	current := Segment{}
+	start, end := -1, 0
+
+	finish := func() {
+		if len(words) == 0 && len(current.Redirects) == 0 {
+			return
+		}
+		current.Raw = line[start:end]
+		fillSegment(&current, words, describe)
+		segments = append(segments, current)
+	}
+
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+		if tok.Kind == shellwords.Control {
+			finish()
+			current = Segment{Operator: tok.Text}
+			words = nil
+			start = -1
+			continue
+		}
+		if start < 0 {
+			start = tok.Start
+		}
+		end = tok.End
+
+		switch tok.Kind {
+		case shellwords.Word:
+			words = append(words, tok.Text)
+		case shellwords.Redirect:
+			target := tok.Target
+			if target == "" && i+1 < len(tokens) && tokens[i+1].Kind == shellwords.Word {
+				i++
+				target = tokens[i].Text
+				end = tokens[i].End
+			}
+			current.Redirects = append(current.Redirects, Redirect{
+				Operator:    tok.Text,
+				Target:      target,
+				Description: describeRedirect(tok.Text, target),
+			})
+		}
+	}
+	finish()
+
+	return segments
+}
+
+// fillSegment parses the words of a segment into its command, subcommand,
+// flags and arguments
+func fillSegment(seg *Segment, words []string, describe func(command, subcommand string) string) {
+	parsed := parseWords(seg.Raw, words)
+	seg.Command = parsed.Command
+	seg.EnvVars = parsed.EnvVars
+	seg.Args = parsed.Args
+	for len(words) > 0 && corrector.IsEnvAssignment(words[0]) {
+		words = words[1:]
+	}
+	if subcommandTools[parsed.Command] && len(words) > 1 && !strings.HasPrefix(words[1], "-") && len(parsed.Args) > 0 {
+		seg.Subcommand = parsed.Args[0]
+		seg.Args = parsed.Args[1:]
+	}
+	seg.Flags = extractFlagsV2(parsed)
+	if seg.Command == "" {
+		return
+	}
+	// A segment that is a whole known task says what the task does
+	if desc, ok := corrector.DescribeIntent(strings.Join(words, " ")); ok {
+		seg.Description = desc
+		return
+	}
+	seg.Description = describe(seg.Command, seg.Subcommand)
+}
+
+// describeRedirect says in words what a redirection does
+func describeRedirect(op, target string) string {
+	fd, op := splitDescriptor(op)
+	stream := "output"
+	switch fd {
+	case "2":
+		stream = "errors"
+	case "0":
+		stream = "input"
+	}
+
+	switch op {
+	case ">&":
+		if target == "1" {
+			return "Send " + stream + " to the same place as the output"
+		}
+		if target == "2" {
+			return "Send " + stream + " to the same place as the errors"
+		}
+		if target == "-" {
+			return "Close " + stream
+		}
+		return "Send " + stream + " to file descriptor " + target
+	case "<&":
+		return "Read input from file descriptor " + target
+	case "<":
+		return "Read input from " + target
+	case "<<":
+		return "Read input from the following lines, up to " + target
+	case "<<<":
+		return "Read input from the string " + target
+	case "&>", "&>>":
+		stream = "output and errors"
+	}
+
+	if target == "/dev/null" {
+		return "Discard " + stream
+	}
+	if strings.HasSuffix(op, ">>") {
+		return "Append " + stream + " to " + target
+	}
+	return "Write " + stream + " to " + target + ", replacing its contents"
+}
+
+// splitDescriptor splits "2>>" into "2" and ">>"
+func splitDescriptor(op string) (string, string) {
+	i := 0
+	for i < len(op) && op[i] >= '0' && op[i] <= '9' {
+		i++
+	}
+	return op[:i], op[i:]
+}
+
+// describeFlagOf explains a flag of root, reporting unknown flags as
+// unrecognized instead of guessing and pointing at the closest known flag
+func describeFlagOf(root string, f ParsedFlag) Flag {
+	flag := Flag{
+		Name:     f.Name,
+		Value:    f.Value,
+		HasValue: f.Value != "",
+		IsShort:  f.IsShort,
+	}
+	if meaning, ok := corrector.DescribeFlag(root, flagText(f)); ok {
+		flag.Description = meaning
+		flag.Known = true
+	} else {
+		flag.Description = "unrecognized"
+		flag.DidYouMean, _ = corrector.ClosestFlag(root, flagText(f))
+	}
+	return flag
+}
+
+// flagText is a parsed flag as typed, without its value
+func flagText(f ParsedFlag) string {
+	if f.IsShort {
+		return "-" + f.Name
+	}
+	return "--" + f.Name
+}
+
+// renderSegments draws the segments as a tree: each command with its flags,
+// arguments and redirections below it, joined by what its operator does
+func renderSegments(segments []Segment) string {
+	var b strings.Builder
+	for _, seg := range segments {
+		if seg.Operator != "" {
+			b.WriteString(fmt.Sprintf("  %s %s\n", ui.Yellow(seg.Operator), ui.Muted(operatorDescriptions[seg.Operator])))
+		}
+
+		name := seg.Command
+		if seg.Subcommand != "" {
+			name += " " + seg.Subcommand
+		}
+		b.WriteString("  " + ui.Cyan(name))
+		if seg.Description != "" {
+			b.WriteString("  " + seg.Description)
+		}
+		b.WriteString("\n")
+
+		var rows [][2]string
+		for _, name := range slices.Sorted(maps.Keys(seg.EnvVars)) {
+			rows = append(rows, [2]string{ui.Yellow(name + "=" + seg.EnvVars[name]), ui.Muted("environment variable")})
+		}
+		for _, f := range seg.Flags {
+			flag := flagText(ParsedFlag{Name: f.Name, IsShort: f.IsShort})
+			if f.Value != "" {
+				flag += "=" + f.Value
+			}
+			desc := f.Description
+			if !f.Known {
+				desc = ui.Muted(desc)
+				if f.DidYouMean != "" {
+					desc += ui.Muted(", did you mean ") + ui.Green(f.DidYouMean) + ui.Muted("?")
+				}
+			}
+			rows = append(rows, [2]string{ui.Green(flag), desc})
+		}
+		for _, arg := range seg.Args {
+			rows = append(rows, [2]string{arg, ui.Muted("argument")})
+		}
+		for _, r := range seg.Redirects {
+			redirect := r.Operator + " " + r.Target
+			if strings.HasSuffix(r.Operator, "&") {
+				redirect = r.Operator + r.Target
+			}
+			rows = append(rows, [2]string{ui.Yellow(redirect), r.Description})
+		}
+
+		for i, row := range rows {
+			branch := "├─"
+			if i == len(rows)-1 {
+				branch = "└─"
+			}
+			b.WriteString(fmt.Sprintf("    %s %s  %s\n", branch, row[0], row[1]))
+		}
+	}
+	return b.String()
+}
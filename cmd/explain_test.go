@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitSegments(t *testing.T) {
+	describe := func(command, subcommand string) string { return command + "/" + subcommand }
+	segments := splitSegments(`tar -xzf "my archive.tgz" 2>/dev/null | grep -rn 'a|b' >> out.txt && git push --frobnicate origin 2>&1`, describe)
+
+	if len(segments) != 3 {
+		t.Fatalf("got %d segments, want 3: %+v", len(segments), segments)
+	}
+
+	tar := segments[0]
+	if tar.Operator != "" || tar.Command != "tar" || tar.Description != "tar/" {
+		t.Errorf("tar segment = %+v", tar)
+	}
+	if !reflect.DeepEqual(tar.Args, []string{"my archive.tgz"}) {
+		t.Errorf("tar args = %q, want the quoted name as one argument", tar.Args)
+	}
+	if len(tar.Flags) != 3 || !tar.Flags[0].Known || tar.Flags[0].Name != "x" {
+		t.Errorf("tar flags = %+v, want the -xzf cluster split and recognized", tar.Flags)
+	}
+	if want := []Redirect{{Operator: "2>", Target: "/dev/null", Description: "Discard errors"}}; !reflect.DeepEqual(tar.Redirects, want) {
+		t.Errorf("tar redirects = %+v, want %+v", tar.Redirects, want)
+	}
+
+	grep := segments[1]
+	if grep.Operator != "|" || !reflect.DeepEqual(grep.Args, []string{"a|b"}) {
+		t.Errorf("grep segment = %+v, want a pipe and the quoted pattern kept whole", grep)
+	}
+	if len(grep.Redirects) != 1 || grep.Redirects[0].Description != "Append output to out.txt" {
+		t.Errorf("grep redirects = %+v", grep.Redirects)
+	}
+
+	git := segments[2]
+	if git.Operator != "&&" || git.Subcommand != "push" || git.Description != "git/push" {
+		t.Errorf("git segment = %+v", git)
+	}
+	if len(git.Flags) != 1 || git.Flags[0].Known || git.Flags[0].Description != "unrecognized" {
+		t.Errorf("unknown flag = %+v, want it reported as unrecognized", git.Flags)
+	}
+	if !reflect.DeepEqual(git.Args, []string{"origin"}) {
+		t.Errorf("git args = %q", git.Args)
+	}
+	if want := "Send errors to the same place as the output"; len(git.Redirects) != 1 || git.Redirects[0].Description != want {
+		t.Errorf("git redirects = %+v, want 2>&1", git.Redirects)
+	}
+}
+
+func TestParseWordsKeepsSingleDashOptions(t *testing.T) {
+	parsed := parseFirstCommand("find . -name '*.go' -type f > list.txt")
+	var names []string
+	for _, f := range parsed.Flags {
+		names = append(names, f.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"name", "type"}) {
+		t.Errorf("flags = %q, want find's -name and -type kept whole", names)
+	}
+	if !reflect.DeepEqual(parsed.Args, []string{".", "*.go", "f"}) {
+		t.Errorf("args = %q, want the redirection left out", parsed.Args)
+	}
+}
+
+func TestExplainSuggestsClosestFlag(t *testing.T) {
+	flags := extractFlagsV2(parseFirstCommand("docker run --rm --detatch nginx"))
+	if len(flags) != 2 {
+		t.Fatalf("flags = %+v, want --rm and --detatch", flags)
+	}
+	if !flags[0].Known || flags[0].Description != "Remove the container when it exits" {
+		t.Errorf("--rm = %+v, want its description", flags[0])
+	}
+	if flags[1].Known || flags[1].DidYouMean != "--detach" {
+		t.Errorf("--detatch = %+v, want a pointer to --detach", flags[1])
+	}
+
+	find := extractFlagsV2(parseFirstCommand("find . -nmae x"))
+	if len(find) != 1 || find[0].Name != "nmae" || find[0].DidYouMean != "-name" {
+		t.Errorf("find flags = %+v, want -nmae kept whole and pointed at -name", find)
+	}
+}
+
+func TestParseWordsEnvAssignments(t *testing.T) {
+	parsed := parseCommand("FOO=bar docker run -it alpine")
+	if parsed.Command != "docker" {
+		t.Errorf("command = %q, want docker", parsed.Command)
+	}
+	if !reflect.DeepEqual(parsed.EnvVars, map[string]string{"FOO": "bar"}) {
+		t.Errorf("env = %v, want FOO=bar", parsed.EnvVars)
+	}
+	if !reflect.DeepEqual(parsed.Args, []string{"run", "alpine"}) {
+		t.Errorf("args = %q", parsed.Args)
+	}
+
+	parsed = parseCommand("GOOS=linux GOARCH=arm64 CGO_ENABLED= go build ./...")
+	want := map[string]string{"GOOS": "linux", "GOARCH": "arm64", "CGO_ENABLED": ""}
+	if parsed.Command != "go" || !reflect.DeepEqual(parsed.EnvVars, want) {
+		t.Errorf("parsed = %q with env %v, want go with %v", parsed.Command, parsed.EnvVars, want)
+	}
+
+	// An assignment after the command is an argument
+	if parsed = parseCommand("make CC=clang"); parsed.Command != "make" || parsed.EnvVars != nil {
+		t.Errorf("parsed = %+v, want make with no env", parsed)
+	}
+
+	segments := splitSegments("DEBUG=1 git commit -m wip", func(command, subcommand string) string { return "" })
+	if len(segments) != 1 || segments[0].Command != "git" || segments[0].Subcommand != "commit" || segments[0].EnvVars["DEBUG"] != "1" {
+		t.Errorf("segments = %+v, want git commit with DEBUG=1", segments)
+	}
+}
+
+func TestParseSequence(t *testing.T) {
+	parsed := parseFirstCommand(`git add . && git commit -m "a && b" || echo 'failed; retry'; ls -la | wc -l`)
+
+	type stage struct {
+		operator, command string
+		args              []string
+	}
+	want := []stage{
+		{"", "git", []string{"add", "."}},
+		{"&&", "git", []string{"commit", "a && b"}},
+		{"||", "echo", []string{"failed; retry"}},
+		{";", "ls", nil},
+	}
+	stages := parsed.Stages()
+	if len(stages) != len(want) {
+		t.Fatalf("stages = %+v, want %d", stages, len(want))
+	}
+	for i, w := range want {
+		got := stages[i]
+		if got.Operator != w.operator || got.Command != w.command || !reflect.DeepEqual(got.Args, w.args) {
+			t.Errorf("stage %d = %q %q %q, want %q %q %q", i, got.Operator, got.Command, got.Args, w.operator, w.command, w.args)
+		}
+	}
+	if parsed.Command != "git" || parsed.Raw == stages[0].Raw {
+		t.Errorf("parsed = %q with raw %q, want the first stage with the whole line", parsed.Command, parsed.Raw)
+	}
+
+	// A single command has no sequence, but is its own only stage
+	single := parseFirstCommand("git status")
+	if single.Sequence != nil || len(single.Stages()) != 1 {
+		t.Errorf("single command sequence = %+v", single.Sequence)
+	}
+
+	summary := buildExecSummary("FOO=1 npm ci && npm test -- --watch=false")
+	if summary.Base != "npm && npm" {
+		t.Errorf("summary base = %q, want npm && npm", summary.Base)
+	}
+}
@@ -2,28 +2,54 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
 	"strings"
+	"time"
 
-	"github.com/atotto/clipboard"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 
 	"wut/internal/config"
 	"wut/internal/corrector"
 	"wut/internal/db"
+	"wut/internal/metrics"
+	"wut/internal/shell"
+	"wut/internal/smart"
+	"wut/internal/terminal"
 	"wut/internal/ui"
+	"wut/pkg/wut"
 )
 
 // fixCmd corrects typos in commands
 var fixCmd = &cobra.Command{
-	Use:   "fix [command]",
+	Use:   "fix [command | -]",
 	Short: "Fix typos in your commands",
 	Long: `Correct common typos and suggest the right command.
-WUT will detect typos, dangerous commands, and suggest alternatives.`,
+WUT will detect typos, dangerous commands, and suggest alternatives.
+
+Exit status:
+  0  the command looks correct
+  1  wut fix failed
+  2  a correction or a command for the described task was suggested
+  3  the command is dangerous
+
+With --exec, or when the corrected command is run after asking, the exit
+status is the command's. With --shell, a printed correction exits 0 so shell
+hooks can run it; a command needing no correction exits 1.
+
+With "-", or when input is piped and no command is given, the commands are
+read from standard input, one per line. Several commands are fixed in turn;
+the exit status is the most severe of theirs, and --json prints an array.`,
 	Example: `  wut fix "gti status"
   wut fix "doker ps"
-  wut fix "rm -rf /"`,
+  wut fix "rm -rf /"
+  wut fix --last        # Fix the previous command recorded by the shell hook
+  wut fix "gti status" --json
+  fc -ln -1 | wut fix - # Fix a command from another tool
+  wut fix --reset-learning`,
 	RunE: runFix,
 }
 
@@ -31,16 +57,55 @@ var (
 	fixCopy      bool
 	fixList      bool
 	fixExec      bool
+	fixLast      bool
 	fixShellMode bool
+	fixReset     bool
 )
 
+// Exit statuses of wut fix, so scripts and git hooks can tell the results
+// apart; 1 stays the status of a failure, and of a command that needs no
+// correction under --shell, where there is nothing to print
+const (
+	fixExitCorrect   = 0
+	fixExitUnchanged = 1
+	fixExitCorrected = 2
+	fixExitDangerous = 3
+)
+
+// fixExit ends wut fix with status without printing an error
+func fixExit(cmd *cobra.Command, status int) error {
+	if status == fixExitCorrect {
+		return nil
+	}
+	cmd.SilenceErrors = true
+	return exitStatus(status)
+}
+
+// fixJSONStatus is the exit status of the result in doc
+func fixJSONStatus(doc *fixJSON) int {
+	switch {
+	case doc.Dangerous:
+		return fixExitDangerous
+	case doc.Changed:
+		return fixExitCorrected
+	default:
+		return fixExitCorrect
+	}
+}
+
+// lastCommandMaxAge is how long state files of idle shell sessions are kept
+const lastCommandMaxAge = 7 * 24 * time.Hour
+
 func init() {
 	rootCmd.AddCommand(fixCmd)
 
 	fixCmd.Flags().BoolVarP(&fixCopy, "copy", "c", false, "copy corrected command to clipboard")
 	fixCmd.Flags().BoolVarP(&fixList, "list", "l", false, "list common typos")
 	fixCmd.Flags().BoolVarP(&fixExec, "exec", "e", false, "execute corrected command")
+	fixCmd.Flags().BoolVar(&fixLast, "last", false, "fix the previous command recorded by the shell integration")
 	fixCmd.Flags().BoolVar(&fixShellMode, "shell", false, "output corrected command only for shell integration")
+	fixCmd.Flags().BoolVar(&outputJSON, "json", false, "print the correction as JSON (ignores --copy and --exec)")
+	fixCmd.Flags().BoolVar(&fixReset, "reset-learning", false, "forget which corrections you accepted or rejected")
 	_ = fixCmd.Flags().MarkHidden("shell")
 }
 
@@ -52,27 +117,39 @@ func runFix(cmd *cobra.Command, args []string) error {
 		hydrateHistoryFromShell(context.Background(), store)
 	}
 
-	c := corrector.New()
-
-	// Populate corrector with history for better fuzzy matching
-	if store != nil {
-		if history, err := store.GetHistory(context.Background(), 100); err == nil {
-			var historyCmds []string
-			for _, h := range history {
-				historyCmds = append(historyCmds, h.Command)
-			}
-			c.SetHistoryCommands(historyCmds)
-		}
+	if fixReset {
+		return resetLearning(cmd.Context(), store, err)
 	}
 
+	c := newCorrector(cmd.Context(), store)
+
 	// 2. Handle --list flag
 	if fixList {
 		return listCommonTypos()
 	}
 
-	// 3. Get input: either from args or last history command
+	// 3. Get input: from standard input, args or the last history command
+	if !fixLast {
+		inputs, fromStdin, err := stdinCommands(cmd, args)
+		if err != nil {
+			return err
+		}
+		if fromStdin {
+			return fixCommands(cmd, c, store, inputs)
+		}
+	}
+
 	input := ""
-	if len(args) > 0 {
+	if fixLast {
+		last, err := readLastCommand()
+		if err != nil {
+			return err
+		}
+		input = last.Command
+		if !last.Failed() && !fixShellMode && !outputJSON {
+			fmt.Println(ui.Muted("The last command succeeded; checking it anyway."))
+		}
+	} else if len(args) > 0 {
 		input = strings.Join(args, " ")
 	} else if store != nil {
 		// Fetch last command from history (skipping 'wut' commands)
@@ -95,55 +172,128 @@ func runFix(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("no command provided and no recent history found to fix")
 	}
 
+	return fixCommand(cmd, c, store, input)
+}
+
+// fixCommands fixes each of inputs in turn, as read from standard input. The
+// exit status is the most severe of theirs, and with --json the results are
+// printed as one array.
+func fixCommands(cmd *cobra.Command, c *wut.Corrector, store *db.Storage, inputs []string) error {
+	status := fixExitCorrect
+	if outputJSON {
+		docs := make([]*fixJSON, 0, len(inputs))
+		for _, input := range inputs {
+			doc, err := newFixDoc(cmd.Context(), c, input)
+			if err != nil {
+				return err
+			}
+			docs = append(docs, doc)
+			status = max(status, fixJSONStatus(doc))
+		}
+		if err := writeJSON(docs); err != nil {
+			return err
+		}
+		return fixExit(cmd, status)
+	}
+
+	if len(inputs) == 1 {
+		return fixCommand(cmd, c, store, inputs[0])
+	}
+	for i, input := range inputs {
+		if !fixShellMode {
+			printCommandHeader(i, len(inputs), input)
+		}
+		err := fixCommand(cmd, c, store, input)
+		var s exitStatus
+		switch {
+		case err == nil:
+		case errors.As(err, &s):
+			status = max(status, int(s))
+		default:
+			return err
+		}
+	}
+	return fixExit(cmd, status)
+}
+
+// newFixDoc returns the JSON result of fixing input
+func newFixDoc(ctx context.Context, c *wut.Corrector, input string) (*fixJSON, error) {
+	if looksLikeNaturalLanguage(input) {
+		return semanticFixJSON(ctx, input), nil
+	}
+	correction, err := c.Correct(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	metrics.RecordCorrection(correction != nil && !correction.Dangerous)
+	return newFixJSON(input, correction), nil
+}
+
+// fixCommand fixes input, a command or a described task
+func fixCommand(cmd *cobra.Command, c *wut.Corrector, store *db.Storage, input string) error {
+	if outputJSON {
+		doc, err := newFixDoc(cmd.Context(), c, input)
+		if err != nil {
+			return err
+		}
+		if err := writeJSON(doc); err != nil {
+			return err
+		}
+		return fixExit(cmd, fixJSONStatus(doc))
+	}
+
 	// 4a. Detect if input looks like natural language → run semantic engine
 	if looksLikeNaturalLanguage(input) {
 		if fixShellMode {
-			best, err := bestSemanticMatch(input)
+			best, err := bestSemanticMatch(cmd.Context(), input)
 			if err != nil {
 				return err
 			}
 			fmt.Println(best)
 			return nil
 		}
-		return runSemanticSearch(input)
+		if !runSemanticSearch(cmd.Context(), input) {
+			return nil
+		}
+		return fixExit(cmd, fixExitCorrected)
 	}
 
 	// 4b. Perform typo/flag correction
-	correction, err := c.Correct(input)
+	correction, err := c.Correct(cmd.Context(), input)
 	if err != nil {
 		return err
 	}
+	metrics.RecordCorrection(correction != nil && !correction.Dangerous)
 
 	if correction == nil {
 		if fixShellMode {
-			return fmt.Errorf("no correction needed")
+			return fixExit(cmd, fixExitUnchanged)
 		}
 
 		// No correction needed
 		successStyle := lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#10B981")).
+			Foreground(ui.ColorSuccess).
 			Render("✓")
 		fmt.Printf("%s %s\n", successStyle, "This command looks correct!")
 
 		// Suggest alternatives
-		alternatives := c.SuggestAlternative(input)
+		alternatives := corrector.New().SuggestAlternativeDetailed(input)
 		if len(alternatives) > 0 {
 			fmt.Println()
 			fmt.Println("Modern alternatives:")
 			for _, alt := range alternatives {
-				fmt.Printf("  • %s\n", ui.Cyan(alt))
+				fmt.Printf("  • %s — %s\n", ui.Cyan(alt.Name), alt.Reason)
 			}
 		}
 
 		return nil
 	}
 
-	if correction.IsDangerous {
-		if fixShellMode {
-			return fmt.Errorf("dangerous command")
+	if correction.Dangerous {
+		if !fixShellMode {
+			displayCorrection(correction)
 		}
-		displayCorrection(correction)
-		return nil
+		return fixExit(cmd, fixExitDangerous)
 	}
 
 	if fixShellMode {
@@ -156,22 +306,127 @@ func runFix(cmd *cobra.Command, args []string) error {
 
 	// Copy to clipboard if requested
 	if fixCopy && correction.Corrected != "" {
-		if err := clipboard.WriteAll(correction.Corrected); err != nil {
+		method, err := terminal.Copy(correction.Corrected)
+		if err != nil {
 			return fmt.Errorf("failed to copy to clipboard: %w", err)
 		}
-		fmt.Printf("%s Copied to clipboard\n", ui.Success("✓"))
+		fmt.Printf("%s Copied via %s\n", ui.Success("✓"), method)
 	}
 
 	if fixExec && correction.Corrected != "" {
+		recordFeedback(store, correction, true)
 		fmt.Printf("%s Executing: %s\n", ui.Success("✓"), ui.Green(correction.Corrected))
-		if err := db.ExecuteCommand(correction.Corrected); err != nil {
-			return fmt.Errorf("failed to execute corrected command: %w", err)
+		return executeCommand(cmd.Context(), store, correction.Corrected)
+	}
+
+	// Ask whether the correction was right, so the next one is better
+	if store != nil && !fixCopy && correction.Corrected != "" && term.IsTerminal(int(os.Stdin.Fd())) {
+		run := askYN("Run the corrected command? [Y/n]:", true)
+		recordFeedback(store, correction, run)
+		if run {
+			return executeCommand(cmd.Context(), store, correction.Corrected)
+		}
+		return fixExit(cmd, fixExitCorrected)
+	}
+	if fixCopy {
+		recordFeedback(store, correction, true)
+	}
+
+	return fixExit(cmd, fixExitCorrected)
+}
+
+// newCorrector returns the corrector of the configured settings, which
+// learns from the history, aliases, taught corrections and feedback in store
+// when it is open
+func newCorrector(ctx context.Context, store *db.Storage) *wut.Corrector {
+	cfg := config.Get().Corrector
+	opts := []wut.CorrectorOption{
+		wut.WithMinConfidence(cfg.MinConfidence),
+		wut.WithKeyboardAware(cfg.KeyboardAware),
+	}
+	if store != nil {
+		opts = append(opts,
+			wut.WithFeedback(correctionFeedback(store)),
+			wut.WithAliases(userAliasNames(store)...),
+			wut.WithPersonalCorrections(personalCorrections(store)),
+		)
+		if cfg.UseHistory {
+			opts = append(opts, wut.WithHistory(correctorHistory(ctx, store), cfg.HistoryThreshold))
 		}
 	}
+	return wut.NewCorrector(opts...)
+}
+
+// correctorHistoryScanLimit is how many recent executions are summarized
+// for the corrector's history matching
+const correctorHistoryScanLimit = 2000
 
+// correctorHistory returns the user's past commands, with how often and how
+// lately each ran, for the corrector. WUT's own invocations are left out.
+func correctorHistory(ctx context.Context, store *db.Storage) []wut.HistoryEntry {
+	summaries, err := store.GetHistoryCommandSummaries(ctx, correctorHistoryScanLimit)
+	if err != nil {
+		return nil
+	}
+	entries := make([]wut.HistoryEntry, 0, len(summaries))
+	for _, s := range summaries {
+		if strings.HasPrefix(strings.ToLower(strings.TrimSpace(s.Command)), "wut ") {
+			continue
+		}
+		entries = append(entries, wut.HistoryEntry{Command: s.Command, UsageCount: s.UsageCount, LastUsed: s.LastUsed})
+	}
+	return entries
+}
+
+// correctionFeedback looks up how often a correction was accepted and
+// rejected before
+func correctionFeedback(store *db.Storage) wut.FeedbackFunc {
+	return func(original, corrected string) (int, int) {
+		feedback, err := store.GetCorrectionFeedback(context.Background(), original, corrected)
+		if err != nil {
+			return 0, 0
+		}
+		return feedback.Accepted, feedback.Rejected
+	}
+}
+
+// recordFeedback remembers whether a correction was accepted; failures only
+// cost the learning, so they are not reported
+func recordFeedback(store *db.Storage, correction *wut.Correction, accepted bool) {
+	metrics.RecordCorrectionFeedback(accepted)
+	if store == nil {
+		return
+	}
+	_ = store.RecordCorrectionFeedback(context.Background(), correction.Original, correction.Corrected, accepted)
+}
+
+func resetLearning(ctx context.Context, store *db.Storage, openErr error) error {
+	if store == nil {
+		return fmt.Errorf("failed to open database: %w", openErr)
+	}
+	n, err := store.ResetCorrectionFeedback(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s Forgot feedback on %d correction(s)\n", ui.Success("✓"), n)
 	return nil
 }
 
+// readLastCommand returns the previous command line of the current shell
+// session as recorded by the shell hook
+func readLastCommand() (*shell.LastCommand, error) {
+	shell.PruneLastCommands(lastCommandMaxAge)
+
+	last, err := shell.ReadLastCommand(shell.CurrentLastCommandPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("no previous command recorded; run 'wut install --upgrade' and restart your shell")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read last command: %w", err)
+	}
+	return last, nil
+}
+
 // looksLikeNaturalLanguage returns true when the input appears to be a
 // human-language description rather than a shell command.
 // Heuristic: it contains ≥ 2 "natural" words AND the first word is NOT a
@@ -186,6 +441,7 @@ func looksLikeNaturalLanguage(input string) bool {
 		"check": true, "view": true, "search": true, "compress": true,
 		"extract": true, "kill": true, "count": true, "run": true,
 		"build": true, "print": true, "clean": true, "logs": true,
+		"forward": true,
 	}
 
 	knownCommands := map[string]bool{
@@ -218,71 +474,102 @@ func looksLikeNaturalLanguage(input string) bool {
 }
 
 // runSemanticSearch uses the semantic engine to translate natural language
-// into shell commands and displays ranked results.
-func runSemanticSearch(query string) error {
-	results, err := semanticMatches(query)
+// into shell commands and displays ranked results. It reports whether any
+// command was found.
+func runSemanticSearch(ctx context.Context, query string) bool {
+	results, err := semanticMatches(ctx, query)
 	if err != nil {
 		fmt.Println()
 		fmt.Println(ui.Yellow("🤔 No matching commands found for: ") + lipgloss.NewStyle().Bold(true).Render(query))
 		fmt.Println("Try rephrasing, e.g: \"list running containers\" or \"undo last commit\"")
-		return nil
+		return false
 	}
 
 	fmt.Println()
-	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#7C3AED"))
+	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(ui.ColorBrand)
 	fmt.Println(headerStyle.Render("🧠 Semantic Match: " + "\"" + query + "\""))
 	fmt.Println()
 
+	if wut.Ambiguous(results) {
+		fmt.Printf("🤔 Did you mean %s or %s?\n", ui.Green(results[0].Command), ui.Green(results[1].Command))
+		fmt.Println()
+	}
+
 	for i, match := range results {
-		confColor := "#10B981"
+		confColor := ui.ColorSuccess
 		if match.Confidence < 0.7 {
-			confColor = "#F59E0B"
+			confColor = ui.ColorWarning
 		}
 		if match.Confidence < 0.4 {
-			confColor = "#6B7280"
+			confColor = ui.ColorMuted
 		}
 
-		numStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#8B5CF6")).Bold(true)
-		cmdStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#10B981")).Bold(true)
-		descStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#9CA3AF"))
-		confStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(confColor))
-		catStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#6366F1"))
+		numStyle := lipgloss.NewStyle().Foreground(ui.ColorSecondary).Bold(true)
+		cmdStyle := lipgloss.NewStyle().Foreground(ui.ColorSuccess).Bold(true)
+		descStyle := lipgloss.NewStyle().Foreground(ui.ColorSubtle)
+		confStyle := lipgloss.NewStyle().Foreground(confColor)
+		catStyle := lipgloss.NewStyle().Foreground(ui.ColorSecondary)
 
 		fmt.Printf("  %s  %s\n",
 			numStyle.Render(fmt.Sprintf("[%d]", i+1)),
-			cmdStyle.Render(match.Intent.Command))
-		fmt.Printf("     %s\n", descStyle.Render(match.Intent.Description))
+			cmdStyle.Render(match.Command))
+		fmt.Printf("     %s\n", descStyle.Render(match.Description))
 		fmt.Printf("     %s  %s\n",
-			catStyle.Render("#"+match.Intent.Category),
+			catStyle.Render("#"+match.Category),
 			confStyle.Render(fmt.Sprintf("%.0f%% match", match.Confidence*100)))
 		fmt.Println()
 	}
 
-	return nil
+	return true
 }
 
-func bestSemanticMatch(query string) (string, error) {
-	results, err := semanticMatches(query)
+func bestSemanticMatch(ctx context.Context, query string) (string, error) {
+	results, err := semanticMatches(ctx, query)
 	if err != nil {
 		return "", err
 	}
-	return results[0].Intent.Command, nil
+	return results[0].Command, nil
 }
 
-func semanticMatches(query string) ([]corrector.IntentMatch, error) {
-	results := corrector.QuerySemantic(query, 5)
+// semanticFixJSON reports the best semantic match for a natural language query
+func semanticFixJSON(ctx context.Context, query string) *fixJSON {
+	results, err := semanticMatches(ctx, query)
+	if err != nil {
+		return &fixJSON{
+			SchemaVersion: jsonSchemaVersion,
+			Original:      query,
+			Explanation:   "No matching commands found",
+		}
+	}
+	best := results[0]
+	return &fixJSON{
+		SchemaVersion: jsonSchemaVersion,
+		Original:      query,
+		Corrected:     best.Command,
+		Changed:       true,
+		Confidence:    best.Confidence,
+		Explanation:   best.Description,
+		Dangerous:     corrector.New().CheckDangerous(best.Command) != nil,
+	}
+}
+
+func semanticMatches(ctx context.Context, query string) ([]wut.IntentMatch, error) {
+	results, err := wut.QuerySemantic(ctx, smart.SpellcheckQuery(query), 5)
+	if err != nil {
+		return nil, err
+	}
 	if len(results) == 0 {
 		return nil, fmt.Errorf("no semantic matches found")
 	}
 	return results, nil
 }
 
-func displayCorrection(c *corrector.Correction) {
-	if c.IsDangerous {
+func displayCorrection(c *wut.Correction) {
+	if c.Dangerous {
 		dangerStyle := lipgloss.NewStyle().
 			Bold(true).
-			Foreground(lipgloss.Color("#FFFFFF")).
-			Background(lipgloss.Color("#DC2626")).
+			Foreground(ui.ColorOnAccent).
+			Background(ui.ColorError).
 			Padding(0, 1)
 
 		fmt.Println()
@@ -293,7 +580,7 @@ func displayCorrection(c *corrector.Correction) {
 
 		warningBox := lipgloss.NewStyle().
 			Border(lipgloss.RoundedBorder()).
-			BorderForeground(lipgloss.Color("#F59E0B")).
+			BorderForeground(ui.ColorWarning).
 			Padding(1).
 			Render("Never run this command unless you absolutely know what you're doing!")
 		fmt.Println(warningBox)
@@ -305,7 +592,7 @@ func displayCorrection(c *corrector.Correction) {
 	fmt.Println()
 	headerStyle := lipgloss.NewStyle().
 		Bold(true).
-		Foreground(lipgloss.Color("#7C3AED"))
+		Foreground(ui.ColorBrand)
 	fmt.Println(headerStyle.Render("🤔 Did you mean:"))
 	fmt.Println()
 
@@ -321,21 +608,21 @@ func displayCorrection(c *corrector.Correction) {
 	if c.Explanation != "" {
 		fmt.Println()
 		infoStyle := lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#6B7280"))
+			Foreground(ui.ColorMuted)
 		fmt.Printf("  %s\n", infoStyle.Render(c.Explanation))
 	}
 
 	// Show confidence
 	fmt.Println()
 	confidenceStr := fmt.Sprintf("Confidence: %.0f%%", c.Confidence*100)
-	var confidenceColor lipgloss.Color
+	var confidenceColor lipgloss.AdaptiveColor
 	switch {
 	case c.Confidence >= 0.9:
-		confidenceColor = lipgloss.Color("#10B981")
+		confidenceColor = ui.ColorSuccess
 	case c.Confidence >= 0.7:
-		confidenceColor = lipgloss.Color("#F59E0B")
+		confidenceColor = ui.ColorWarning
 	default:
-		confidenceColor = lipgloss.Color("#6B7280")
+		confidenceColor = ui.ColorMuted
 	}
 	confidenceStyle := lipgloss.NewStyle().
 		Foreground(confidenceColor)
@@ -362,7 +649,7 @@ func listCommonTypos() error {
 
 	headerStyle := lipgloss.NewStyle().
 		Bold(true).
-		Foreground(lipgloss.Color("#7C3AED"))
+		Foreground(ui.ColorBrand)
 
 	fmt.Println()
 	fmt.Println(headerStyle.Render("📋 Core Typo Correction Patterns"))
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/goccy/go-json"
+
+	"github.com/spf13/cobra"
+
+	"wut/pkg/wut"
+)
+
+func TestFixExitStatus(t *testing.T) {
+	tests := []struct {
+		name       string
+		correction *wut.Correction
+		want       int
+	}{
+		{"correct", nil, fixExitCorrect},
+		{"corrected", &wut.Correction{Original: "gti status", Corrected: "git status"}, fixExitCorrected},
+		{"dangerous", &wut.Correction{Original: "rm -rf /", Dangerous: true}, fixExitDangerous},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := "gti status"
+			if tt.correction != nil {
+				input = tt.correction.Original
+			}
+			if got := fixJSONStatus(newFixJSON(input, tt.correction)); got != tt.want {
+				t.Errorf("fixJSONStatus() = %d, want %d", got, tt.want)
+			}
+
+			cmd := &cobra.Command{}
+			err := fixExit(cmd, tt.want)
+			var status exitStatus
+			switch {
+			case tt.want == fixExitCorrect && err != nil:
+				t.Errorf("fixExit(0) = %v, want nil", err)
+			case tt.want != fixExitCorrect && (!errors.As(err, &status) || int(status) != tt.want):
+				t.Errorf("fixExit(%d) = %v, want exit status %d", tt.want, err, tt.want)
+			case tt.want != fixExitCorrect && !cmd.SilenceErrors:
+				t.Error("fixExit() left the error to be printed")
+			}
+		})
+	}
+}
+
+func TestFixCommandsFromStdin(t *testing.T) {
+	origShell, origJSON := fixShellMode, outputJSON
+	t.Cleanup(func() { fixShellMode, outputJSON = origShell, origJSON })
+
+	cmd := &cobra.Command{}
+	cmd.SetContext(context.Background())
+	c := wut.NewCorrector()
+
+	// A line needing no correction does not stop the lines after it, and
+	// its status is still reported
+	fixShellMode, outputJSON = true, false
+	var err error
+	out := captureStdout(t, func() { err = fixCommands(cmd, c, nil, []string{"ls", "gti status"}) })
+	if strings.TrimSpace(out) != "git status" {
+		t.Errorf("fixCommands(--shell) printed %q, want the correction of the second line", out)
+	}
+	var status exitStatus
+	if !errors.As(err, &status) || int(status) != fixExitUnchanged {
+		t.Errorf("fixCommands(--shell) = %v, want exit status %d", err, fixExitUnchanged)
+	}
+
+	// A single line still prints an array with --json
+	fixShellMode, outputJSON = false, true
+	out = captureStdout(t, func() { err = fixCommands(cmd, c, nil, []string{"gti status"}) })
+	var docs []fixJSON
+	if jsonErr := json.Unmarshal([]byte(out), &docs); jsonErr != nil || len(docs) != 1 {
+		t.Fatalf("fixCommands(--json) printed %q, want an array of one result", out)
+	}
+	if docs[0].Corrected != "git status" {
+		t.Errorf("corrected = %q, want %q", docs[0].Corrected, "git status")
+	}
+}
@@ -3,23 +3,28 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"io"
 	"math"
+	"os"
 	"runtime"
 	"sort"
 	"strings"
 	"time"
 
-	"github.com/atotto/clipboard"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/muesli/reflow/truncate"
 	"github.com/spf13/cobra"
 
 	"wut/internal/config"
+	"wut/internal/corrector"
 	"wut/internal/db"
 	"wut/internal/logger"
 	"wut/internal/metrics"
 	"wut/internal/shell"
+	"wut/internal/terminal"
+	"wut/internal/ui"
 )
 
 // historyCmd represents the history command
@@ -31,18 +36,21 @@ var historyCmd = &cobra.Command{
   wut history --limit 50
   wut history --search "docker"
   wut history --stats
-  wut history --import-shell`,
+  wut history --import-shell
+  wut history --raw | grep docker`,
 	RunE: runHistory,
 }
 
 var (
-	historyLimit       int
-	historySearch      string
-	historyStats       bool
-	historyClear       bool
-	historyExport      string
-	historyImport      string
-	historyImportShell bool
+	historyLimit        int
+	historySearch       string
+	historyStats        bool
+	historyClear        bool
+	historyExport       string
+	historyImport       string
+	historyImportShell  bool
+	historyAbsoluteTime bool
+	historyRaw          bool
 )
 
 func init() {
@@ -55,13 +63,22 @@ func init() {
 	historyCmd.Flags().StringVarP(&historyExport, "export", "e", "", "export history to JSON file")
 	historyCmd.Flags().StringVarP(&historyImport, "import", "i", "", "import history from JSON file")
 	historyCmd.Flags().BoolVar(&historyImportShell, "import-shell", false, "import from shell history files")
+	historyCmd.Flags().BoolVar(&historyAbsoluteTime, "absolute-time", false, "show absolute timestamps instead of relative times")
+	historyCmd.Flags().BoolVarP(&historyRaw, "raw", "r", false, "print plain text instead of the interactive view")
+
+	_ = historyCmd.RegisterFlagCompletionFunc("search", completeHistoryCommands)
 }
 
 func runHistory(cmd *cobra.Command, args []string) error {
 	ctx := cmd.Context()
 	log := logger.With("history")
 
-	storage, err := db.NewStorage(config.GetDatabasePath())
+	// Viewing history can share the database with another wut instance
+	openStorage := db.OpenForReading
+	if historyClear || historyImport != "" || historyImportShell {
+		openStorage = db.NewStorage
+	}
+	storage, err := openStorage(config.GetDatabasePath())
 	if err != nil {
 		log.Error("failed to initialize storage", "error", err)
 		return fmt.Errorf("failed to initialize storage: %w", err)
@@ -132,6 +149,17 @@ type historyModel struct {
 	msg      string
 	width    int
 	height   int
+	uncopied string
+
+	absoluteTime bool
+	showPreview  bool
+	usage        map[string]int
+
+	// multi-select export
+	selected      map[int]bool
+	exporting     bool
+	confirmDanger bool
+	exportInput   textinput.Model
 }
 
 func newHistoryModel(entries []db.CommandExecution, total int) historyModel {
@@ -148,6 +176,7 @@ func newHistoryModel(entries []db.CommandExecution, total int) historyModel {
 		numPages: numPages,
 		total:    total,
 		msg:      msg,
+		selected: make(map[int]bool),
 	}
 }
 
@@ -155,6 +184,56 @@ func (m historyModel) Init() tea.Cmd {
 	return nil
 }
 
+// historyTimeWidth is the width of the "[01-02 15:04]" absolute column;
+// relative labels are padded to it so the command column stays aligned.
+const historyTimeWidth = 13
+
+// formatHistoryTime renders ts relative to now ("2h ago", "yesterday"), or in
+// the absolute format when requested. Entries without a timestamp show
+// "unknown".
+func formatHistoryTime(ts, now time.Time, absolute bool) string {
+	var label string
+	switch {
+	case ts.IsZero():
+		label = "unknown"
+	case absolute:
+		label = ts.Local().Format("01-02 15:04")
+	default:
+		label = formatRelativeTime(ts, now)
+	}
+	return label
+}
+
+func formatRelativeTime(ts, now time.Time) string {
+	d := now.Sub(ts)
+	if d < time.Minute {
+		return "just now"
+	}
+
+	tsDay := ts.Local()
+	nowDay := now.Local()
+	y1, m1, d1 := tsDay.Date()
+	y2, m2, d2 := nowDay.AddDate(0, 0, -1).Date()
+	isYesterday := y1 == y2 && m1 == m2 && d1 == d2
+
+	switch {
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 24*time.Hour && !isYesterday:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	case isYesterday:
+		return "yesterday"
+	case d < 7*24*time.Hour:
+		return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+	case d < 30*24*time.Hour:
+		return fmt.Sprintf("%dw ago", int(d.Hours()/(24*7)))
+	case d < 365*24*time.Hour:
+		return fmt.Sprintf("%dmo ago", int(d.Hours()/(24*30)))
+	default:
+		return fmt.Sprintf("%dy ago", int(d.Hours()/(24*365)))
+	}
+}
+
 type clearMsg struct{}
 
 func tickClearMsg() tea.Cmd {
@@ -168,12 +247,41 @@ func (m historyModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
+		m = m.relayout()
 	case clearMsg:
 		m.msg = ""
 	case tea.KeyMsg:
+		if m.exporting {
+			return m.updateExport(msg)
+		}
+
 		switch msg.String() {
 		case "ctrl+c", "q", "esc":
 			return m, tea.Quit
+		case " ":
+			if m.cursor >= 0 && m.cursor < len(m.entries) {
+				if m.selected[m.cursor] {
+					delete(m.selected, m.cursor)
+				} else {
+					m.selected[m.cursor] = true
+				}
+			}
+		case "x":
+			if len(m.selected) == 0 {
+				m.msg = "Select commands with space first"
+				return m, tickClearMsg()
+			}
+			input := textinput.New()
+			input.Placeholder = "history.sh"
+			input.SetValue("wut-history.sh")
+			input.CharLimit = 255
+			input.Focus()
+			m.exportInput = input
+			m.exporting = true
+			return m, textinput.Blink
+		case "p":
+			m.showPreview = !m.showPreview
+			m = m.relayout()
 		case "up", "k":
 			if m.cursor > 0 {
 				m.cursor--
@@ -201,13 +309,15 @@ func (m historyModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "enter", "c", "y": // c for copy, y for yank, enter for copy
 			if m.cursor >= 0 && m.cursor < len(m.entries) {
 				targetCmd := m.entries[m.cursor].Command
-				if err := clipboard.WriteAll(targetCmd); err == nil {
-					m.msg = "📋 Copied to clipboard"
-					return m, tickClearMsg()
-				} else {
-					m.msg = string("❌ Copy failed: " + err.Error())
+				method, err := terminal.Copy(targetCmd)
+				if err != nil {
+					m.uncopied = targetCmd
+					m.msg = "❌ Copy failed - command will be printed on exit"
 					return m, tickClearMsg()
 				}
+				m.uncopied = ""
+				m.msg = "📋 Copied via " + method
+				return m, tickClearMsg()
 			}
 		}
 	}
@@ -249,17 +359,17 @@ func (m historyModel) View() string {
 		innerWidth = 20
 	}
 
-	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#7C3AED"))
+	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(ui.ColorBrand)
 	titleStr := headerStyle.Render("📜 Execution Log (Newest First)")
 
 	var sb strings.Builder
 	if m.msg != "" {
-		alertIcon := lipgloss.NewStyle().Foreground(lipgloss.Color("#10B981")).Bold(true).Render("✔️  ")
-		alertText := lipgloss.NewStyle().Foreground(lipgloss.Color("#E5E7EB")).Bold(true).Render(m.msg)
+		alertIcon := lipgloss.NewStyle().Foreground(ui.ColorSuccess).Bold(true).Render("✔️  ")
+		alertText := lipgloss.NewStyle().Foreground(ui.ColorText).Bold(true).Render(m.msg)
 
 		alertStr := lipgloss.NewStyle().
 			Border(lipgloss.RoundedBorder()).
-			BorderForeground(lipgloss.Color("#10B981")).
+			BorderForeground(ui.ColorSuccess).
 			Padding(0, 2).
 			Render(alertIcon + alertText)
 
@@ -279,15 +389,16 @@ func (m historyModel) View() string {
 		sb.WriteString(titleStr + "\n\n")
 	}
 
-	indexStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#6B7280")).Width(4).Align(lipgloss.Right)
-	metaStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#9CA3AF"))
+	indexStyle := lipgloss.NewStyle().Foreground(ui.ColorMuted).Width(4).Align(lipgloss.Right)
+	metaStyle := lipgloss.NewStyle().Foreground(ui.ColorSubtle)
 
 	// ซ่อน timestamp บนจอแคบ (< 50 col)
 	showTime := w >= 50
 	showSource := w >= 78
 
-	// availWidth: พื้นที่สำหรับ command text
+	// availWidth: พื้นที่สำหรับ command text (หัก select mark 2 ช่องเสมอ)
 	// index(4) + space(1) + time+brackets(13) + spaces(3) + cursor(2) = 23 เมื่อมี time
+	// (relative time ถูก pad ให้กว้าง historyTimeWidth เท่ากับ absolute format)
 	// index(4) + space(1) + cursor(2) = 7 เมื่อไม่มี time
 	var availWidth int
 	if showTime {
@@ -298,6 +409,7 @@ func (m historyModel) View() string {
 	if showSource {
 		availWidth -= 20
 	}
+	availWidth -= 2
 	if availWidth < 10 {
 		availWidth = 10
 	}
@@ -305,11 +417,16 @@ func (m historyModel) View() string {
 	for i := start; i < end; i++ {
 		entry := m.entries[i]
 		cursor := "  "
-		cmdStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#10B981"))
+		cmdStyle := lipgloss.NewStyle().Bold(true).Foreground(ui.ColorSuccess)
 
 		if m.cursor == i {
 			cursor = "👉"
-			cmdStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#FFFFFF")).Background(lipgloss.Color("#3B82F6")).Padding(0, 1)
+			cmdStyle = lipgloss.NewStyle().Bold(true).Foreground(ui.ColorOnAccent).Background(ui.ColorPrimary).Padding(0, 1)
+		}
+		if m.selected[i] {
+			cursor += lipgloss.NewStyle().Foreground(ui.ColorWarning).Render("◉")
+		} else {
+			cursor += " "
 		}
 
 		dispCmd := entry.Command
@@ -318,46 +435,269 @@ func (m historyModel) View() string {
 		}
 
 		if showTime {
-			timeStr := entry.Timestamp.Local().Format("01-02 15:04")
+			timeStr := formatHistoryTime(entry.Timestamp, time.Now(), m.absoluteTime)
 			source := ""
 			if showSource {
 				if label := formatHistorySource(entry); label != "" {
 					source = metaStyle.Render(label) + "  "
 				}
 			}
-			sb.WriteString(fmt.Sprintf("%s %s %s   %s%s\n\n", cursor, indexStyle.Render(fmt.Sprintf("%d.", i+1)), metaStyle.Render("["+timeStr+"]"), source, cmdStyle.Render(dispCmd)))
+			sb.WriteString(fmt.Sprintf("%s %s %s   %s%s\n\n", cursor, indexStyle.Render(fmt.Sprintf("%d.", i+1)), metaStyle.Render(fmt.Sprintf("%-*s", historyTimeWidth, "["+timeStr+"]")), source, cmdStyle.Render(dispCmd)))
 		} else {
 			sb.WriteString(fmt.Sprintf("%s %s %s\n\n", cursor, indexStyle.Render(fmt.Sprintf("%d.", i+1)), cmdStyle.Render(dispCmd)))
 		}
 	}
 
-	sb.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color("#6B7280")).Render(
+	if m.showPreview && m.cursor >= 0 && m.cursor < len(m.entries) {
+		sb.WriteString(m.renderPreview(m.entries[m.cursor], innerWidth))
+		sb.WriteString("\n\n")
+	}
+
+	sb.WriteString(lipgloss.NewStyle().Foreground(ui.ColorMuted).Render(
 		fmt.Sprintf("Showing %d unique executions out of %d total recorded.", len(m.entries), m.total)))
 	sb.WriteString("\n\n")
 
+	if m.exporting {
+		promptStyle := lipgloss.NewStyle().Foreground(ui.ColorHighlight).Bold(true)
+		if m.confirmDanger {
+			sb.WriteString(promptStyle.Render("⚠  Selection contains dangerous commands. Include them? [y] yes  [n] skip them  [esc] cancel"))
+		} else {
+			sb.WriteString(promptStyle.Render(fmt.Sprintf("Export %d commands to: ", len(m.selected))) + m.exportInput.View())
+			sb.WriteString("\n" + lipgloss.NewStyle().Foreground(ui.ColorSubtle).Render("[enter] Save | [esc] Cancel"))
+		}
+		return lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(ui.ColorBrand).
+			Padding(1, boxPadX).
+			Width(boxWidth).
+			Render(strings.TrimRight(sb.String(), "\n"))
+	}
+
 	// ── Footer text (responsive) ──────────────────────────────────────────────
-	footerStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#EAB308")).Bold(true)
+	footerStyle := lipgloss.NewStyle().Foreground(ui.ColorHighlight).Bold(true)
 	sb.WriteString(footerStyle.Render(fmt.Sprintf("Page %d/%d", m.page+1, m.numPages)))
 
 	var footerNav string
 	if w >= 90 {
-		footerNav = " | [↑/↓] Navigate | [←/→] Prev/Next Page | [c/enter] Copy | [q] Quit"
+		footerNav = " | [↑/↓] Navigate | [←/→] Page | [c/enter] Copy | [space] Select | [x] Export | [p] Preview | [q] Quit"
 	} else if w >= 60 {
-		footerNav = " | ↑/↓ nav | ←/→ page | c copy | q quit"
+		footerNav = " | ↑/↓ nav | ←/→ page | c copy | space sel | x export | p preview | q quit"
 	} else {
-		footerNav = " | ↑/↓ | ←/→ | c | q"
+		footerNav = " | ↑/↓ | ←/→ | c | space | x | p | q"
 	}
-	sb.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color("#9CA3AF")).Render(footerNav + "\n"))
+	sb.WriteString(lipgloss.NewStyle().Foreground(ui.ColorSubtle).Render(footerNav + "\n"))
 
 	boxStyle := lipgloss.NewStyle().
 		Border(lipgloss.RoundedBorder()).
-		BorderForeground(lipgloss.Color("#7C3AED")).
+		BorderForeground(ui.ColorBrand).
 		Padding(1, boxPadX).
 		Width(boxWidth)
 
 	return boxStyle.Render(strings.TrimRight(sb.String(), "\n"))
 }
 
+// ── Script export ────────────────────────────────────────────────────────────
+
+// updateExport handles keys while the export filename prompt is open
+func (m historyModel) updateExport(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.confirmDanger {
+		switch msg.String() {
+		case "y", "Y":
+			return m.finishExport(true)
+		case "n", "N":
+			return m.finishExport(false)
+		case "esc", "ctrl+c":
+			m.exporting = false
+			m.confirmDanger = false
+			m.msg = "Export cancelled"
+			return m, tickClearMsg()
+		}
+		return m, nil
+	}
+
+	switch msg.String() {
+	case "esc", "ctrl+c":
+		m.exporting = false
+		m.msg = "Export cancelled"
+		return m, tickClearMsg()
+	case "enter":
+		if strings.TrimSpace(m.exportInput.Value()) == "" {
+			return m, nil
+		}
+		c := corrector.New()
+		for _, entry := range m.selectedEntries() {
+			if c.CheckDangerous(entry.Command) != nil {
+				m.confirmDanger = true
+				return m, nil
+			}
+		}
+		return m.finishExport(false)
+	}
+
+	var cmd tea.Cmd
+	m.exportInput, cmd = m.exportInput.Update(msg)
+	return m, cmd
+}
+
+// finishExport writes the selected commands to the chosen script file
+func (m historyModel) finishExport(includeDangerous bool) (tea.Model, tea.Cmd) {
+	m.exporting = false
+	m.confirmDanger = false
+
+	c := corrector.New()
+	var commands []string
+	skipped := 0
+	for _, entry := range m.selectedEntries() {
+		if !includeDangerous && c.CheckDangerous(entry.Command) != nil {
+			skipped++
+			continue
+		}
+		commands = append(commands, entry.Command)
+	}
+
+	path := strings.TrimSpace(m.exportInput.Value())
+	if !strings.HasSuffix(path, ".sh") {
+		path += ".sh"
+	}
+
+	if err := writeHistoryScript(path, commands); err != nil {
+		m.msg = "❌ Export failed: " + err.Error()
+		return m, tickClearMsg()
+	}
+
+	m.selected = make(map[int]bool)
+	m.msg = fmt.Sprintf("💾 Saved %d commands to %s", len(commands), path)
+	if skipped > 0 {
+		m.msg += fmt.Sprintf(" (%d dangerous skipped)", skipped)
+	}
+	return m, tickClearMsg()
+}
+
+// selectedEntries returns the selected entries in original execution order
+// (oldest first), whereas the list itself is newest first.
+func (m historyModel) selectedEntries() []db.CommandExecution {
+	indexes := make([]int, 0, len(m.selected))
+	for i := range m.selected {
+		indexes = append(indexes, i)
+	}
+	// entries are newest first, so a higher index ran earlier
+	sort.Sort(sort.Reverse(sort.IntSlice(indexes)))
+
+	entries := make([]db.CommandExecution, 0, len(indexes))
+	for _, i := range indexes {
+		entries = append(entries, m.entries[i])
+	}
+	sort.SliceStable(entries, func(a, b int) bool {
+		if entries[a].Timestamp.IsZero() || entries[b].Timestamp.IsZero() {
+			return false
+		}
+		return entries[a].Timestamp.Before(entries[b].Timestamp)
+	})
+	return entries
+}
+
+// writeHistoryScript writes commands to an executable shell script that stops
+// at the first failing command.
+func writeHistoryScript(path string, commands []string) error {
+	var b strings.Builder
+	b.WriteString("#!/usr/bin/env bash\n")
+	b.WriteString("# Exported by wut history on " + time.Now().Format("2006-01-02 15:04") + "\n")
+	b.WriteString("set -e\n\n")
+	for _, command := range commands {
+		b.WriteString(strings.TrimSpace(command) + "\n")
+	}
+
+	return os.WriteFile(path, []byte(b.String()), 0755)
+}
+
+// ── Preview pane ─────────────────────────────────────────────────────────────
+
+const (
+	historyMaxPageSize      = 10
+	historyMinPageSize      = 3
+	historyChromeLines      = 9 // border + padding + title + summary + footer
+	historyPreviewCmdLines  = 3
+	historyPreviewHeight    = historyPreviewCmdLines + 5 // border + meta lines + gap
+	historyPreviewMetaWidth = 12
+)
+
+// relayout sizes the page so the list and the preview pane share the
+// terminal height, keeping the cursor on screen.
+func (m historyModel) relayout() historyModel {
+	pageSize := historyMaxPageSize
+	if m.height > 0 {
+		avail := m.height - historyChromeLines
+		if m.showPreview {
+			avail -= historyPreviewHeight
+		}
+		// each row takes two lines (entry + spacing)
+		pageSize = min(max(avail/2, historyMinPageSize), historyMaxPageSize)
+	}
+
+	m.pageSize = pageSize
+	m.numPages = int(math.Ceil(float64(len(m.entries)) / float64(pageSize)))
+	if m.numPages == 0 {
+		m.numPages = 1
+	}
+	m.page = m.cursor / pageSize
+	return m
+}
+
+// renderPreview shows the full, wrapped command under the cursor along with
+// its timestamp, usage count and origin.
+func (m historyModel) renderPreview(entry db.CommandExecution, width int) string {
+	labelStyle := lipgloss.NewStyle().Foreground(ui.ColorMuted).Width(historyPreviewMetaWidth)
+	valueStyle := lipgloss.NewStyle().Foreground(ui.ColorText)
+
+	boxInner := width - 4
+	if boxInner < 10 {
+		boxInner = 10
+	}
+
+	wrapped := lipgloss.NewStyle().Width(boxInner).Render(entry.Command)
+	lines := strings.Split(wrapped, "\n")
+	if len(lines) > historyPreviewCmdLines {
+		lines = lines[:historyPreviewCmdLines]
+		last := strings.TrimRight(lines[historyPreviewCmdLines-1], " ")
+		lines[historyPreviewCmdLines-1] = truncate.String(last, uint(boxInner-4)) + " ..."
+	}
+	cmdText := lipgloss.NewStyle().Bold(true).Foreground(ui.ColorSuccess).Render(strings.Join(lines, "\n"))
+
+	when := "unknown"
+	if !entry.Timestamp.IsZero() {
+		when = entry.Timestamp.Local().Format("2006-01-02 15:04:05") + " (" + formatRelativeTime(entry.Timestamp, time.Now()) + ")"
+	}
+	meta := labelStyle.Render("Ran") + valueStyle.Render(when)
+
+	usage := ""
+	if count := m.usage[strings.TrimSpace(entry.Command)]; count > 0 {
+		usage = fmt.Sprintf("%d×", count)
+	}
+	origin := formatHistorySource(entry)
+	if entry.Dir != "" {
+		origin = strings.TrimSpace(origin + "  " + entry.Dir)
+	}
+	var detail string
+	switch {
+	case usage != "" && origin != "":
+		detail = labelStyle.Render("Used") + valueStyle.Render(usage+"  ·  "+origin)
+	case usage != "":
+		detail = labelStyle.Render("Used") + valueStyle.Render(usage)
+	default:
+		detail = labelStyle.Render("From") + valueStyle.Render(origin)
+	}
+	if lipgloss.Width(detail) > boxInner {
+		detail = truncate.StringWithTail(detail, uint(boxInner), "...")
+	}
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.NormalBorder()).
+		BorderForeground(ui.ColorBorder).
+		Padding(0, 1).
+		Width(width - 2).
+		Render(cmdText + "\n" + meta + "\n" + detail)
+}
+
 func showHistory(ctx context.Context, storage *db.Storage) error {
 	var entries []db.CommandExecution
 	var err error
@@ -387,16 +727,57 @@ func showHistory(ctx context.Context, storage *db.Storage) error {
 		return nil
 	}
 
+	if historyRaw || !terminal.IsInteractive() {
+		printHistoryPlain(os.Stdout, entries, time.Now(), historyAbsoluteTime)
+		metrics.RecordHistoryView()
+		return nil
+	}
+
 	total := getTotalCount(ctx, storage)
-	p := tea.NewProgram(newHistoryModel(entries, total))
-	if _, err := p.Run(); err != nil {
+	model := newHistoryModel(entries, total)
+	model.absoluteTime = historyAbsoluteTime
+	model.usage = historyUsageCounts(ctx, storage)
+	p := tea.NewProgram(model)
+	finalModel, err := p.Run()
+	if err != nil {
 		return fmt.Errorf("error running history UI: %w", err)
 	}
+	if m, ok := finalModel.(historyModel); ok {
+		printUncopiedCommand(m.uncopied)
+	}
 
 	metrics.RecordHistoryView()
 	return nil
 }
 
+// printHistoryPlain prints up to --limit entries, one per line, for pipes and
+// scripts
+func printHistoryPlain(w io.Writer, entries []db.CommandExecution, now time.Time, absolute bool) {
+	if historyLimit > 0 && len(entries) > historyLimit {
+		entries = entries[:historyLimit]
+	}
+	for _, entry := range entries {
+		timeStr := "[" + formatHistoryTime(entry.Timestamp, now, absolute) + "]"
+		fmt.Fprintf(w, "%-*s %s\n", historyTimeWidth, timeStr, strings.TrimSpace(entry.Command))
+	}
+}
+
+// historyUsageCountScanLimit bounds the scan used for preview usage counts
+const historyUsageCountScanLimit = 5000
+
+func historyUsageCounts(ctx context.Context, storage *db.Storage) map[string]int {
+	summaries, err := storage.GetHistoryCommandSummaries(ctx, historyUsageCountScanLimit)
+	if err != nil {
+		return nil
+	}
+
+	counts := make(map[string]int, len(summaries))
+	for _, summary := range summaries {
+		counts[summary.Command] = summary.UsageCount
+	}
+	return counts
+}
+
 func searchHistoryOptimized(ctx context.Context, storage *db.Storage, query string, limit int) ([]db.CommandExecution, error) {
 	if limit <= 0 {
 		limit = 50
@@ -421,11 +802,11 @@ func showHistoryStats(ctx context.Context, storage *db.Storage) error {
 		return fmt.Errorf("failed to get history statistics: %w", err)
 	}
 
-	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#7C3AED"))
+	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(ui.ColorBrand)
 	fmt.Printf("\n%s\n\n", headerStyle.Render("📊 Execution Log Insights"))
 
 	statStyle := lipgloss.NewStyle().Bold(true)
-	valueStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#10B981"))
+	valueStyle := lipgloss.NewStyle().Foreground(ui.ColorSuccess)
 
 	fmt.Printf("  %s %s\n", statStyle.Render("Total Executions :"), valueStyle.Render(fmt.Sprintf("%d", stats.TotalExecutions)))
 	fmt.Printf("  %s %s\n", statStyle.Render("Unique Commands  :"), valueStyle.Render(fmt.Sprintf("%d", stats.UniqueCommands)))
@@ -435,28 +816,28 @@ func showHistoryStats(ctx context.Context, storage *db.Storage) error {
 	fmt.Println()
 
 	if len(stats.TimeDistribution) > 0 {
-		catStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#3B82F6"))
+		catStyle := lipgloss.NewStyle().Bold(true).Foreground(ui.ColorPrimary)
 		fmt.Printf("%s\n", catStyle.Render("🕒 Time Distribution:"))
 		printSortedDistribution(stats.TimeDistribution)
 		fmt.Println()
 	}
 
 	if len(stats.OSDistribution) > 0 {
-		catStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#8B5CF6"))
+		catStyle := lipgloss.NewStyle().Bold(true).Foreground(ui.ColorSecondary)
 		fmt.Printf("%s\n", catStyle.Render("🖥️ OS Distribution:"))
 		printSortedDistribution(stats.OSDistribution)
 		fmt.Println()
 	}
 
 	if len(stats.ShellDistribution) > 0 {
-		catStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#06B6D4"))
+		catStyle := lipgloss.NewStyle().Bold(true).Foreground(ui.ColorAccent)
 		fmt.Printf("%s\n", catStyle.Render("🐚 Shell Distribution:"))
 		printSortedDistribution(stats.ShellDistribution)
 		fmt.Println()
 	}
 
 	if len(stats.TopCommands) > 0 {
-		topStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#F59E0B"))
+		topStyle := lipgloss.NewStyle().Bold(true).Foreground(ui.ColorWarning)
 		fmt.Printf("%s\n", topStyle.Render("🏆 Most Used Combinations/Commands:"))
 		for i, cmd := range stats.TopCommands {
 			fmt.Printf("  %d. %s (%d times)\n", i+1, cmd.Command, cmd.Count)
@@ -464,10 +845,56 @@ func showHistoryStats(ctx context.Context, storage *db.Storage) error {
 		fmt.Println()
 	}
 
+	printTimingStats(stats)
+
 	metrics.RecordHistoryView()
 	return nil
 }
 
+// printTimingStats shows the slowest commands and failure rates, from the
+// executions recorded with history.track_timing
+func printTimingStats(stats *db.HistoryStats) {
+	if stats.TimedExecutions == 0 {
+		if !config.Get().History.TrackTiming {
+			fmt.Println(ui.Muted("Set history.track_timing to true to see the slowest commands and failure rates."))
+			fmt.Println()
+		}
+		return
+	}
+
+	if len(stats.SlowestCommands) > 0 {
+		slowStyle := lipgloss.NewStyle().Bold(true).Foreground(ui.ColorSecondary)
+		fmt.Printf("%s\n", slowStyle.Render("⏱️ Slowest Commands:"))
+		for i, timing := range stats.SlowestCommands {
+			fmt.Printf("  %d. %s (avg %s, max %s, %s)\n", i+1, timing.Command,
+				formatRunDuration(timing.AvgDuration), formatRunDuration(timing.MaxDuration), pluralize(timing.Runs, "run"))
+		}
+		fmt.Println()
+	}
+
+	failStyle := lipgloss.NewStyle().Bold(true).Foreground(ui.ColorError)
+	fmt.Printf("%s\n", failStyle.Render("❌ Failure Rates:"))
+	fmt.Printf("  %d of %s failed (%.0f%%)\n", stats.FailedExecutions, pluralize(stats.TimedExecutions, "recorded run"),
+		100*float64(stats.FailedExecutions)/float64(stats.TimedExecutions))
+	for i, timing := range stats.FailingCommands {
+		fmt.Printf("  %d. %s (%d of %s failed, %.0f%%)\n", i+1, timing.Command, timing.Failures, pluralize(timing.Runs, "run"), 100*timing.FailureRate())
+	}
+	fmt.Println()
+}
+
+// formatRunDuration formats how long a command ran, to a precision that
+// suits its length
+func formatRunDuration(d time.Duration) string {
+	switch {
+	case d < time.Second:
+		return d.Round(time.Millisecond).String()
+	case d < time.Minute:
+		return d.Round(100 * time.Millisecond).String()
+	default:
+		return d.Round(time.Second).String()
+	}
+}
+
 func formatHistorySource(entry db.CommandExecution) string {
 	sourceOS := strings.TrimSpace(entry.SourceOS)
 	shellName := strings.TrimSpace(entry.Shell)
@@ -529,6 +956,9 @@ func importShellHistory(ctx context.Context, storage *db.Storage) error {
 }
 
 func hydrateHistoryFromShell(ctx context.Context, storage *db.Storage) {
+	if storage.IsReadOnly() {
+		return
+	}
 	stats, err := storage.GetHistoryStats(ctx)
 	if err != nil || stats.TotalExecutions > 0 {
 		return
@@ -617,6 +1047,7 @@ func importShellHistoryEntries(ctx context.Context, storage *db.Storage, limitPe
 				Command:  command,
 				SourceOS: runtime.GOOS,
 				Shell:    source.Shell,
+				Imported: true,
 			})
 		}
 		if limitPerShell == 0 {
@@ -761,3 +1192,11 @@ func minInt(a, b int) int {
 }
 
 const historyImportTailWindow = 16
+
+// pluralize formats a count of noun, as "1 run" or "3 runs"
+func pluralize(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"wut/internal/db"
+)
+
+func TestFormatHistoryTime(t *testing.T) {
+	now := time.Date(2026, 3, 10, 15, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		name     string
+		ts       time.Time
+		absolute bool
+		want     string
+	}{
+		{name: "zero time", ts: time.Time{}, want: "unknown"},
+		{name: "zero time absolute", ts: time.Time{}, absolute: true, want: "unknown"},
+		{name: "seconds ago", ts: now.Add(-20 * time.Second), want: "just now"},
+		{name: "minutes ago", ts: now.Add(-15 * time.Minute), want: "15m ago"},
+		{name: "hours ago", ts: now.Add(-2 * time.Hour), want: "2h ago"},
+		{name: "yesterday", ts: now.Add(-20 * time.Hour), want: "yesterday"},
+		{name: "days ago", ts: now.Add(-3 * 24 * time.Hour), want: "3d ago"},
+		{name: "weeks ago", ts: now.Add(-15 * 24 * time.Hour), want: "2w ago"},
+		{name: "months ago", ts: now.Add(-90 * 24 * time.Hour), want: "3mo ago"},
+		{name: "years ago", ts: now.Add(-800 * 24 * time.Hour), want: "2y ago"},
+		{name: "absolute", ts: now, absolute: true, want: "03-10 15:00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := formatHistoryTime(tt.ts, now, tt.absolute)
+			if len(got)+2 > historyTimeWidth {
+				t.Errorf("formatHistoryTime() = %q overflows the time column", got)
+			}
+			if got != tt.want {
+				t.Errorf("formatHistoryTime() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHistoryExportScript(t *testing.T) {
+	now := time.Now()
+	m := newHistoryModel([]db.CommandExecution{
+		{Command: "git push", Timestamp: now},
+		{Command: "git commit -m wip", Timestamp: now.Add(-time.Minute)},
+		{Command: "git add .", Timestamp: now.Add(-2 * time.Minute)},
+	}, 3)
+	m.selected[0] = true
+	m.selected[2] = true
+
+	var commands []string
+	for _, entry := range m.selectedEntries() {
+		commands = append(commands, entry.Command)
+	}
+
+	path := filepath.Join(t.TempDir(), "flow.sh")
+	if err := writeHistoryScript(path, commands); err != nil {
+		t.Fatalf("writeHistoryScript() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read script: %v", err)
+	}
+	script := string(data)
+
+	if !strings.HasPrefix(script, "#!/usr/bin/env bash\n") || !strings.Contains(script, "\nset -e\n") {
+		t.Errorf("script is missing shebang or set -e:\n%s", script)
+	}
+	if strings.Index(script, "git add .") > strings.Index(script, "git push") {
+		t.Errorf("commands are not in execution order:\n%s", script)
+	}
+	if strings.Contains(script, "git commit") {
+		t.Errorf("unselected command was exported:\n%s", script)
+	}
+}
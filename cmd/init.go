@@ -3,18 +3,23 @@ package cmd
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/signal"
+	"slices"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
 
+	"github.com/charmbracelet/huh"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/spf13/cobra"
 	"golang.org/x/term"
 
 	"wut/internal/config"
+	"wut/internal/db"
 	"wut/internal/logger"
 	"wut/internal/shell"
 	"wut/internal/ui"
@@ -27,19 +32,22 @@ var initCmd = &cobra.Command{
 
 This command will:
   • Create configuration directory structure
-  • Detect your shell and recommend integrations
-  • Set up default preferences
+  • Ask for your theme, database and privacy preferences
+  • Import your shell history
+  • Install shell integration for the detected shell
   • Optionally sync TLDR pages
 
-Run this when you first install WUT or want to reconfigure.`,
+Every step can be skipped. It runs by itself the first time WUT is used in
+a terminal; run it again to reconfigure, starting from your current settings.`,
 	Example: `  wut init              # Interactive setup
-  wut init --quick      # Quick setup with defaults
+  wut init --yes        # Non-interactive setup with the current or default settings
   wut init --shell zsh  # Setup for specific shell`,
 	RunE: runInit,
 }
 
 var (
 	initQuick     bool
+	initYes       bool
 	initShell     string
 	initSkipTLDR  bool
 	initSkipShell bool
@@ -50,6 +58,7 @@ func init() {
 	rootCmd.AddCommand(initCmd)
 
 	initCmd.Flags().BoolVarP(&initQuick, "quick", "q", false, "quick setup with defaults (non-interactive)")
+	initCmd.Flags().BoolVarP(&initYes, "yes", "y", false, "accept the current or default settings without prompting")
 	initCmd.Flags().StringVarP(&initShell, "shell", "s", "", "shell type")
 	initCmd.Flags().BoolVar(&initSkipTLDR, "skip-tldr", false, "skip TLDR pages setup")
 	initCmd.Flags().BoolVar(&initSkipShell, "skip-shell", false, "skip shell integration setup")
@@ -58,14 +67,14 @@ func init() {
 
 // Global UI colors
 var (
-	cBlue     = lipgloss.Color("#8B5CF6") // Changed to Purple/Violet for UI
-	cCyan     = lipgloss.Color("#C4B5FD") // Light Purple
-	cGreen    = lipgloss.Color("#10B981")
-	cAmber    = lipgloss.Color("#F59E0B")
-	cPink     = lipgloss.Color("#EC4899")
-	cGray     = lipgloss.Color("#6B7280")
-	cDarkGray = lipgloss.Color("#374151")
-	cWhite    = lipgloss.Color("#F8F9FA")
+	cBlue     = ui.ColorSecondary // Changed to Purple/Violet for UI
+	cCyan     = ui.ColorBrandSoft // Light Purple
+	cGreen    = ui.ColorSuccess
+	cAmber    = ui.ColorWarning
+	cPink     = ui.ColorPink
+	cGray     = ui.ColorMuted
+	cDarkGray = ui.ColorSurface
+	cWhite    = ui.ColorText
 )
 
 // Helper methods for prompts
@@ -109,9 +118,15 @@ func runInit(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
+	// Nothing can be asked without a terminal
+	if initYes || !term.IsTerminal(int(os.Stdin.Fd())) {
+		initQuick = true
+	}
+
 	// ─── Interrupt handling ────────────────────────────────────────────────────
 	osSig := make(chan os.Signal, 1)
 	signal.Notify(osSig, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(osSig)
 	go func() {
 		<-osSig
 		fmt.Println()
@@ -142,7 +157,7 @@ func runInit(cmd *cobra.Command, args []string) error {
 
 		heroLogo := lipgloss.NewStyle().
 			Bold(true).
-			Foreground(lipgloss.Color("#FFFFFF")).
+			Foreground(ui.ColorOnAccent).
 			Background(cBlue).
 			Padding(0, 2).
 			Render(" 🚀 WUT SETUP ")
@@ -195,6 +210,25 @@ func runInit(cmd *cobra.Command, args []string) error {
 
 	cfg := config.Get()
 
+	var shellTargets []string
+	if !initSkipShell {
+		shellTargets = detectShellsForInit(initShell)
+	}
+
+	// ─── Questions ─────────────────────────────────────────────────────────────
+	answers := newInitAnswers(cfg, len(shellTargets) > 0)
+	if !initQuick {
+		if initNonTUI {
+			askInitAnswers(&answers, shellTargets)
+		} else if err := formInitAnswers(&answers, shellTargets); err != nil {
+			if errors.Is(err, huh.ErrUserAborted) {
+				fmt.Println(lipgloss.NewStyle().Foreground(cAmber).Bold(true).Render("\n  ⚠ Setup cancelled — you can re-run 'wut init' any time.\n"))
+				return nil
+			}
+			return fmt.Errorf("setup wizard failed: %w", err)
+		}
+	}
+
 	// ─── Step 1: Directories ───────────────────────────────────────────────────
 	if !initQuick {
 		printStep("📁", "Directories Setup")
@@ -207,66 +241,30 @@ func runInit(cmd *cobra.Command, args []string) error {
 	}
 
 	// ─── Step 2: Configuration ─────────────────────────────────────────────────
-	if initQuick {
-		cfg.UI.Theme = "auto"
-		cfg.Fuzzy.Enabled = true
-		cfg.History.Enabled = true
-		cfg.Context.Enabled = true
-		if err := config.Save(); err != nil {
-			return fmt.Errorf("failed to save config: %w", err)
-		}
-	} else {
-		printStep("⚙️ ", "Terminal Preferences")
-
-		lbl := lipgloss.NewStyle().Foreground(cGray).Render
-		opt := lipgloss.NewStyle().Foreground(cWhite).Render
-		num := lipgloss.NewStyle().Foreground(cBlue).Bold(true).Render
-
-		themeMenu := lipgloss.NewStyle().
-			Border(lipgloss.NormalBorder(), false, false, false, true).
-			BorderForeground(cDarkGray).
-			PaddingLeft(2).
-			MarginLeft(4).
-			Render(
-				lipgloss.JoinVertical(lipgloss.Left,
-					lbl("Choose your preferred theme:"),
-					fmt.Sprintf(" %s %s", num("1"), opt("Auto-detect (Recommended)")),
-					fmt.Sprintf(" %s %s", num("2"), opt("Dark mode")),
-					fmt.Sprintf(" %s %s", num("3"), opt("Light mode")),
-				),
-			)
-		fmt.Println()
-		fmt.Println(themeMenu)
-
-		fmt.Println()
-		choice := askChoice("Selection [1]:", "1")
-		switch choice {
-		case "2":
-			cfg.UI.Theme = "dark"
-		case "3":
-			cfg.UI.Theme = "light"
-		default:
-			cfg.UI.Theme = "auto"
-		}
+	databaseType := cfg.Database.Type
+	answers.apply(cfg)
+	ui.SetTheme(cfg.UI.Theme)
+	// The history import and TLDR download below create their databases
+	// with the engine just picked
+	db.SetEngine(cfg.Database.Type)
+	if err := config.Save(); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+	if cfg.Database.Type != databaseType {
+		printEngineMigrateHint(cfg.Database.Type)
+	}
+	if !initQuick {
+		printStep("⚙️ ", "Preferences")
 		printOK("Theme profile set to " + valFmt(cfg.UI.Theme))
-		fmt.Println()
-
-		cfg.History.Enabled = askYN("Enable command history productivity tracking? [Y/n]:", true)
 		printOK("History tracking " + boolToEnabled(cfg.History.Enabled))
-		fmt.Println()
-
-		cfg.Context.Enabled = askYN("Enable project context analysis to get smarter suggestions? [Y/n]:", true)
 		printOK("Context analysis " + boolToEnabled(cfg.Context.Enabled))
-
-		if err := config.Save(); err != nil {
-			return fmt.Errorf("failed to save config: %w", err)
-		}
+		printOK("Database engine " + valFmt(cfg.Database.Type) + ", automatic backups " + boolToEnabled(cfg.Database.BackupEnabled))
+		printOK("Local only " + boolToEnabled(cfg.Privacy.LocalOnly) + ", command anonymizing " + boolToEnabled(cfg.Privacy.AnonymizeCommands))
 	}
 
 	// ─── Step 3: Shell Integration (auto-install, merged from 'wut install') ──
 	if !initSkipShell {
 		activeShell := shell.DetectCurrentShell()
-		shellTargets := detectShellsForInit(initShell)
 
 		if !initQuick {
 			printStep("🐚", "Shell Integration")
@@ -274,13 +272,16 @@ func runInit(cmd *cobra.Command, args []string) error {
 			if displayShell == "" && len(shellTargets) > 0 {
 				displayShell = shellTargets[0]
 			}
-			if activeShell != "" {
-				displayShell = activeShell
-			}
 			fmt.Printf("    Detected active shell: %s\n", valFmt(displayShell))
-			if len(shellTargets) > 0 {
-				fmt.Printf("    %s\n", lipgloss.NewStyle().Foreground(cGray).Render("Installing integration for: "+strings.Join(shellTargets, ", ")))
+		}
+
+		if !answers.InstallShell {
+			if !initQuick {
+				printOK("Skipped — run 'wut install' to set it up later")
 			}
+			shellTargets = nil
+		} else if !initQuick {
+			fmt.Printf("    %s\n", lipgloss.NewStyle().Foreground(cGray).Render("Installing integration for: "+strings.Join(shellTargets, ", ")))
 			fmt.Println()
 			fmt.Printf("    %s\n\n", lipgloss.NewStyle().Foreground(cGray).Render("Installing key bindings, command-not-found hooks, and pro-tips..."))
 		}
@@ -324,11 +325,22 @@ func runInit(cmd *cobra.Command, args []string) error {
 	if !initQuick {
 		printStep("🕘", "History Import")
 	}
-	if cfg.History.Enabled {
+	if answers.ImportHistory {
 		importCtx, importCancel := context.WithTimeout(context.Background(), 15*time.Second)
 		defer importCancel()
 
-		summary, err := bootstrapShellHistoryImport(importCtx)
+		var summary *shellHistoryImportSummary
+		importHistory := func() error {
+			var err error
+			summary, err = bootstrapShellHistoryImport(importCtx)
+			return err
+		}
+		var err error
+		if initQuick {
+			err = importHistory()
+		} else {
+			err = ui.RunWithSpinner("Importing shell history...", importHistory)
+		}
 		if err != nil {
 			if initQuick {
 				fmt.Printf("Shell history import skipped: %v\n", err)
@@ -348,7 +360,11 @@ func runInit(cmd *cobra.Command, args []string) error {
 			fmt.Printf("Imported %d shell history entries\n", summary.imported)
 		}
 	} else if !initQuick {
-		printWarn("History tracking disabled; shell history import skipped")
+		if cfg.History.Enabled {
+			printOK("Skipped — run 'wut history --import-shell' to import it later")
+		} else {
+			printWarn("History tracking disabled; shell history import skipped")
+		}
 	}
 
 	// ─── Step 5: TLDR Pages ────────────────────────────────────────────────────
@@ -356,19 +372,7 @@ func runInit(cmd *cobra.Command, args []string) error {
 		if !initQuick {
 			printStep("📚", "Offline Knowledge Base")
 
-			descBox := lipgloss.NewStyle().
-				Border(lipgloss.NormalBorder(), false, false, false, true).
-				BorderForeground(cDarkGray).
-				PaddingLeft(2).
-				MarginLeft(4).
-				Foreground(cGray).
-				Render("TLDR pages provide instant offline cheat sheets\nfor almost any CLI tool on your system.")
-
-			fmt.Println()
-			fmt.Println(descBox)
-			fmt.Println()
-
-			if askYN("Download TLDR database now? (Highly Recommended) [Y/n]:", true) {
+			if answers.SyncTLDR {
 				fmt.Printf("    %s\n", lipgloss.NewStyle().Foreground(cGray).Render("Syncing... please wait a moment."))
 				if err := runDBSync(dbSyncCmd, []string{}); err != nil {
 					printWarn("Sync encountered an issue: " + err.Error())
@@ -422,6 +426,186 @@ func runInit(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// initAnswers are the choices made in the setup wizard
+type initAnswers struct {
+	Theme             string
+	History           bool
+	ImportHistory     bool
+	Context           bool
+	InstallShell      bool
+	SyncTLDR          bool
+	LocalOnly         bool
+	AnonymizeCommands bool
+	DatabaseType      string
+	Backups           bool
+}
+
+// newInitAnswers starts the wizard from the current settings, so running it
+// again keeps them
+func newInitAnswers(cfg *config.Config, canInstallShell bool) initAnswers {
+	theme := cfg.UI.Theme
+	if theme == "" {
+		theme = "auto"
+	}
+	databaseType := cfg.Database.Type
+	if !slices.Contains(databaseEngines, databaseType) {
+		databaseType = databaseEngines[0]
+	}
+	return initAnswers{
+		Theme:             theme,
+		History:           cfg.History.Enabled,
+		ImportHistory:     cfg.History.Enabled,
+		Context:           cfg.Context.Enabled,
+		InstallShell:      canInstallShell,
+		SyncTLDR:          !initSkipTLDR && cfg.TLDR.AutoSync,
+		LocalOnly:         cfg.Privacy.LocalOnly,
+		AnonymizeCommands: cfg.Privacy.AnonymizeCommands,
+		DatabaseType:      databaseType,
+		Backups:           cfg.Database.BackupEnabled,
+	}
+}
+
+// apply stores the answers that are settings in cfg
+func (a *initAnswers) apply(cfg *config.Config) {
+	if !a.History {
+		a.ImportHistory = false
+	}
+	cfg.UI.Theme = a.Theme
+	cfg.History.Enabled = a.History
+	cfg.Context.Enabled = a.Context
+	if !initSkipTLDR {
+		cfg.TLDR.AutoSync = a.SyncTLDR
+	}
+	cfg.Privacy.LocalOnly = a.LocalOnly
+	cfg.Privacy.AnonymizeCommands = a.AnonymizeCommands
+	cfg.Database.Type = a.DatabaseType
+	cfg.Database.BackupEnabled = a.Backups
+}
+
+// formInitAnswers asks the wizard questions in a form; each step is a
+// question that can be answered no
+func formInitAnswers(a *initAnswers, shells []string) error {
+	confirm := func(title, description string, value *bool) *huh.Confirm {
+		return huh.NewConfirm().
+			Title(title).
+			Description(description).
+			Affirmative("  Yes  ").Negative("  No  ").
+			WithButtonAlignment(lipgloss.Left).
+			Value(value)
+	}
+
+	groups := []*huh.Group{
+		huh.NewGroup(
+			huh.NewSelect[string]().
+				Title("Theme").
+				Description("Color scheme for the interface").
+				Options(
+					huh.NewOption("Auto (follow system)", "auto"),
+					huh.NewOption("Dark", "dark"),
+					huh.NewOption("Light", "light"),
+				).
+				Value(&a.Theme),
+		).Title("  Appearance"),
+		huh.NewGroup(
+			confirm("Track Command History", "Remember the commands you run to rank suggestions", &a.History),
+			confirm("Import Shell History Now", "Read your existing shell history into WUT", &a.ImportHistory),
+			confirm("Project Context", "Use the current project to get smarter suggestions", &a.Context),
+		).Title("  History"),
+	}
+	engines := make([]huh.Option[string], len(databaseEngines))
+	for i, engine := range databaseEngines {
+		engines[i] = huh.NewOption(engine, engine)
+	}
+	groups = append(groups, huh.NewGroup(
+		huh.NewSelect[string]().
+			Title("Storage Engine").
+			Description("Where history, bookmarks and TLDR pages are kept").
+			Options(engines...).
+			Value(&a.DatabaseType),
+		confirm("Automatic Backups", "Periodically back up the database", &a.Backups),
+	).Title("  Database"))
+	if len(shells) > 0 {
+		groups = append(groups, huh.NewGroup(
+			confirm("Install Shell Integration", "Key bindings and command-not-found hooks for "+strings.Join(shells, ", "), &a.InstallShell),
+		).Title("  Shell"))
+	}
+	if !initSkipTLDR {
+		groups = append(groups, huh.NewGroup(
+			confirm("Sync TLDR Pages", "Download offline cheat sheets now and keep them up to date", &a.SyncTLDR),
+		).Title("  Offline Knowledge Base"))
+	}
+	groups = append(groups, huh.NewGroup(
+		confirm("Local Only", "Never send any data to external services", &a.LocalOnly),
+		confirm("Anonymize Commands", "Redact tokens and passwords before they are stored", &a.AnonymizeCommands),
+	).Title("  Privacy"))
+
+	return huh.NewForm(groups...).WithTheme(getConfigTheme()).Run()
+}
+
+// askInitAnswers asks the wizard questions as plain text prompts
+func askInitAnswers(a *initAnswers, shells []string) {
+	lbl := lipgloss.NewStyle().Foreground(cGray).Render
+	opt := lipgloss.NewStyle().Foreground(cWhite).Render
+	num := lipgloss.NewStyle().Foreground(cBlue).Bold(true).Render
+
+	themes := []string{"auto", "dark", "light"}
+	current := 1
+	for i, theme := range themes {
+		if theme == a.Theme {
+			current = i + 1
+		}
+	}
+	themeMenu := lipgloss.NewStyle().
+		Border(lipgloss.NormalBorder(), false, false, false, true).
+		BorderForeground(cDarkGray).
+		PaddingLeft(2).
+		MarginLeft(4).
+		Render(
+			lipgloss.JoinVertical(lipgloss.Left,
+				lbl("Choose your preferred theme:"),
+				fmt.Sprintf(" %s %s", num("1"), opt("Auto-detect (Recommended)")),
+				fmt.Sprintf(" %s %s", num("2"), opt("Dark mode")),
+				fmt.Sprintf(" %s %s", num("3"), opt("Light mode")),
+			),
+		)
+	fmt.Println()
+	fmt.Println(themeMenu)
+	fmt.Println()
+	choice := askChoice(fmt.Sprintf("Selection [%d]:", current), strconv.Itoa(current))
+	if n, err := strconv.Atoi(choice); err == nil && n >= 1 && n <= len(themes) {
+		a.Theme = themes[n-1]
+	}
+
+	ask := func(prompt string, value *bool) {
+		hint := "[y/N]"
+		if *value {
+			hint = "[Y/n]"
+		}
+		*value = askYN(prompt+" "+hint+":", *value)
+	}
+	ask("Enable command history productivity tracking?", &a.History)
+	if a.History {
+		ask("Import your shell history now?", &a.ImportHistory)
+	}
+	ask("Enable project context analysis to get smarter suggestions?", &a.Context)
+	if len(databaseEngines) > 1 {
+		fmt.Println()
+		fmt.Println(lipgloss.NewStyle().MarginLeft(4).Render(lbl("Storage engines: " + strings.Join(databaseEngines, ", "))))
+		if engine := askChoice(fmt.Sprintf("Storage engine [%s]:", a.DatabaseType), a.DatabaseType); slices.Contains(databaseEngines, engine) {
+			a.DatabaseType = engine
+		}
+	}
+	ask("Back up the database periodically?", &a.Backups)
+	if len(shells) > 0 {
+		ask("Install shell integration for "+strings.Join(shells, ", ")+"?", &a.InstallShell)
+	}
+	if !initSkipTLDR {
+		ask("Download TLDR database now and keep it synced? (Highly Recommended)", &a.SyncTLDR)
+	}
+	ask("Keep all data local, never sending it to external services?", &a.LocalOnly)
+	ask("Redact tokens and passwords from stored commands?", &a.AnonymizeCommands)
+}
+
 // OS / Shell helpers
 
 func detectShellForInit() string {
@@ -458,7 +642,7 @@ func getShellRcFile(shellType string) string {
 
 func boolToEnabled(b bool) string {
 	if b {
-		return lipgloss.NewStyle().Foreground(lipgloss.Color("#2DC653")).Render("enabled")
+		return lipgloss.NewStyle().Foreground(ui.ColorSuccess).Render("enabled")
 	}
-	return lipgloss.NewStyle().Foreground(lipgloss.Color("#FF70A6")).Render("disabled")
+	return lipgloss.NewStyle().Foreground(ui.ColorPink).Render("disabled")
 }
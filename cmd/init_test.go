@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"wut/internal/config"
+)
+
+func TestInitAnswersKeepCurrentSettings(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.UI.Theme = "light"
+	cfg.History.Enabled = true
+	cfg.TLDR.AutoSync = true
+	cfg.Privacy.AnonymizeCommands = true
+	cfg.Database.Type = "bbolt"
+	cfg.Database.BackupEnabled = true
+
+	answers := newInitAnswers(cfg, true)
+	want := initAnswers{
+		Theme:             "light",
+		History:           true,
+		ImportHistory:     true,
+		InstallShell:      true,
+		SyncTLDR:          true,
+		AnonymizeCommands: true,
+		DatabaseType:      "bbolt",
+		Backups:           true,
+	}
+	if answers != want {
+		t.Fatalf("newInitAnswers() = %+v, want %+v", answers, want)
+	}
+
+	// Accepting every answer changes nothing
+	before := *cfg
+	answers.apply(cfg)
+	if !reflect.DeepEqual(*cfg, before) {
+		t.Errorf("apply() of the current settings changed them to %+v", cfg)
+	}
+
+	answers.History = false
+	answers.Theme = "dark"
+	answers.apply(cfg)
+	if cfg.History.Enabled || answers.ImportHistory || cfg.UI.Theme != "dark" {
+		t.Errorf("apply() = history %v, import %v, theme %q, want history and its import off and a dark theme",
+			cfg.History.Enabled, answers.ImportHistory, cfg.UI.Theme)
+	}
+
+	// A config naming an engine WUT does not have starts from one it has
+	cfg.Database.Type = "mysql"
+	if got := newInitAnswers(cfg, false).DatabaseType; got != "bbolt" {
+		t.Errorf("newInitAnswers() engine = %q for an unknown engine, want bbolt", got)
+	}
+}
@@ -3,6 +3,7 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -22,10 +23,13 @@ This command sets up key bindings for your shell to quickly access WUT:
 - Ctrl+Space: Open WUT TUI
 - Ctrl+G: Open WUT with current command line
 - oops: Retry the last command with WUT's best correction
+- Esc Esc: Put a correction of the last command on the prompt (bash, zsh, fish)
 
 Supports live integration for: bash, zsh, fish, powershell, pwsh, nushell, xonsh, elvish, cmd`,
 	Example: `  wut install           # Install for all detected shells (default)
   wut install --all     # Install for all detected shells
+  wut install --upgrade # Refresh the integration in every installed shell
+  wut install --status  # Show integration state and run setup checks
   wut install --uninstall # Remove shell integration`,
 	RunE: runInstall,
 }
@@ -33,6 +37,8 @@ Supports live integration for: bash, zsh, fish, powershell, pwsh, nushell, xonsh
 var (
 	installAll       bool
 	installUninstall bool
+	installUpgrade   bool
+	installStatus    bool
 	installShell     string
 )
 
@@ -41,13 +47,21 @@ func init() {
 
 	installCmd.Flags().BoolVarP(&installAll, "all", "a", false, "install for all detected shells")
 	installCmd.Flags().BoolVarP(&installUninstall, "uninstall", "u", false, "uninstall shell integration")
+	installCmd.Flags().BoolVar(&installUpgrade, "upgrade", false, "refresh the integration in all installed shells")
+	installCmd.Flags().BoolVar(&installStatus, "status", false, "show the integration state of every shell and run setup checks")
 	installCmd.Flags().StringVarP(&installShell, "shell", "s", "", "target shell")
 }
 
 func runInstall(cmd *cobra.Command, args []string) error {
+	if installStatus {
+		return runInstallStatus(cmd.Context())
+	}
 	if installUninstall {
 		return runUninstall()
 	}
+	if installUpgrade {
+		return runUpgrade()
+	}
 
 	if installShell == "" && !installAll {
 		installAll = true
@@ -87,7 +101,7 @@ func installShellIntegration(sh string) error {
 
 	fmt.Printf("Installing WUT integration for %s...\n", sh)
 	if err := installer.Install(sh); err != nil {
-		if err.Error() == "already installed" {
+		if errors.Is(err, shell.ErrAlreadyInstalled) {
 			fmt.Println("✅ WUT integration is already installed")
 			return nil
 		}
@@ -100,6 +114,7 @@ func installShellIntegration(sh string) error {
 	fmt.Println("  • Ctrl+Space - Open WUT TUI")
 	fmt.Println("  • Ctrl+G     - Open WUT with current command")
 	fmt.Println("  • oops       - Retry the last command with WUT correction")
+	fmt.Println("  • Esc Esc    - Put a fix for the last command on the prompt")
 	fmt.Println()
 	if configFile, err := shell.GetConfigFile(sh); err == nil {
 		if reloadCmd := shell.GetReloadCommand(sh, configFile); reloadCmd != "" {
@@ -131,6 +146,38 @@ func uninstallShellIntegration(sh string) error {
 	return nil
 }
 
+// runUpgrade rewrites the integration block of every shell that has one, so
+// stale fragments from older releases pick up new bindings.
+func runUpgrade() error {
+	installer := shell.NewInstaller()
+
+	found := false
+	for _, sh := range detectAllShells() {
+		if !installer.IsShellInstalled(sh) {
+			continue
+		}
+		found = true
+
+		stale := installer.NeedsUpgrade(sh)
+		err := installer.Install(sh)
+		switch {
+		case errors.Is(err, shell.ErrAlreadyInstalled):
+			fmt.Printf("✓ %s integration is up to date\n", sh)
+		case err != nil:
+			fmt.Printf("⚠️  Failed to upgrade %s: %v\n", sh, err)
+		case stale:
+			fmt.Printf("✅ Upgraded %s integration to version %d\n", sh, shell.IntegrationVersion)
+		default:
+			fmt.Printf("✅ Refreshed %s integration\n", sh)
+		}
+	}
+
+	if !found {
+		fmt.Println("No installed shell integration found. Run 'wut install' first.")
+	}
+	return nil
+}
+
 func installAllShells() error {
 	shells := detectAllShells()
 	if len(shells) == 0 {
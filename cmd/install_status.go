@@ -0,0 +1,274 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"wut/internal/config"
+	"wut/internal/db"
+	"wut/internal/health"
+	"wut/internal/shell"
+	"wut/internal/terminal"
+	"wut/internal/ui"
+)
+
+// runInstallStatus prints the integration state of every supported shell
+// followed by doctor checks. It fails when a critical check fails so setup
+// scripts can rely on its exit status.
+func runInstallStatus(ctx context.Context) error {
+	installer := shell.NewInstaller()
+
+	fmt.Println(ui.Title("Shell integration"))
+	fmt.Println()
+	for _, sh := range shell.IntegrationShells() {
+		printShellStatus(installer.Status(ctx, sh))
+	}
+	fmt.Println()
+
+	fmt.Println(ui.Title("Checks"))
+	fmt.Println()
+	checker := health.NewChecker(Version)
+	for _, check := range doctorChecks(installer) {
+		checker.Register(check)
+	}
+	report := checker.Check(ctx)
+
+	failed := 0
+	for _, result := range report.Checks {
+		printCheckResult(result)
+		if result.Status != "healthy" && result.Critical {
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d check(s) failed", failed)
+	}
+	return nil
+}
+
+// printShellStatus prints one shell's rc file, block version, wut lookup and
+// completion state on a single line
+func printShellStatus(s shell.IntegrationStatus) {
+	name := fmt.Sprintf("  %-11s", s.Shell)
+	if s.Executable == "" {
+		fmt.Println(ui.Muted(name + "shell not found"))
+		return
+	}
+
+	var fields []string
+
+	switch {
+	case s.ConfigFile == "":
+		fields = append(fields, ui.Muted("no rc file"))
+	case s.ConfigExists:
+		fields = append(fields, ui.Success("✓ ")+s.ConfigFile)
+	default:
+		fields = append(fields, ui.Warning("✗ ")+s.ConfigFile+ui.Muted(" (missing)"))
+	}
+
+	switch {
+	case !s.Installed:
+		fields = append(fields, ui.Warning("block: none"))
+	case s.Stale():
+		fields = append(fields, ui.Warning(fmt.Sprintf("block: v%d (run wut install --upgrade)", s.Version)))
+	default:
+		fields = append(fields, ui.Success(fmt.Sprintf("block: v%d", s.Version)))
+	}
+
+	if s.WutPath != "" {
+		fields = append(fields, ui.Success("wut: ")+s.WutPath)
+	} else {
+		fields = append(fields, ui.Error("wut: not on PATH"))
+	}
+
+	switch err := checkCompletion(s.Shell); {
+	case errors.Is(err, errNoCompletion):
+		fields = append(fields, ui.Muted("completion: n/a"))
+	case err != nil:
+		fields = append(fields, ui.Error("completion: "+err.Error()))
+	default:
+		fields = append(fields, ui.Success("completion: ok"))
+	}
+
+	fmt.Println(name + strings.Join(fields, ui.Muted("  │  ")))
+}
+
+// printCheckResult prints a check as pass, warn (non-critical failure) or
+// fail, with its remediation hint
+func printCheckResult(r health.Result) {
+	label := fmt.Sprintf("%-18s", r.Name)
+	switch {
+	case r.Status == "healthy":
+		fmt.Printf("  %s %s\n", ui.Success("✓ pass"), r.Name)
+	case r.Critical:
+		fmt.Printf("  %s %s %s\n", ui.Error("✗ fail"), label, r.Error)
+	default:
+		fmt.Printf("  %s %s %s\n", ui.Warning("⚠ warn"), label, r.Error)
+	}
+	if r.Status != "healthy" && r.Hint != "" {
+		fmt.Printf("         %s\n", ui.Muted("→ "+r.Hint))
+	}
+}
+
+// checkCompletion generates the completion script for a shell the same way
+// `wut completion` does
+func checkCompletion(sh string) error {
+	var buf bytes.Buffer
+	err := genCompletion(&buf, sh)
+	if err == nil && buf.Len() == 0 {
+		err = fmt.Errorf("empty script")
+	}
+	return err
+}
+
+// ── Doctor checks ────────────────────────────────────────────────────────────
+
+func doctorChecks(installer *shell.Installer) []health.Check {
+	return []health.Check{
+		{
+			Name:     "config",
+			Checker:  func(context.Context) error { return checkConfigFile(config.GetConfigPath()) },
+			Critical: true,
+			Hint:     "fix the file by hand or run 'wut config --reset'",
+		},
+		{
+			Name:     "history database",
+			Checker:  func(context.Context) error { return checkDatabase(config.GetDatabasePath()) },
+			Critical: true,
+			Hint:     "close other wut processes; if the file is corrupt, move it aside and run 'wut init'",
+		},
+		{
+			Name:     "history encryption",
+			Checker:  checkEncryption,
+			Critical: false,
+			Hint:     "run 'wut config --set privacy.encrypt_data true', or set " + db.PassphraseEnv,
+		},
+		{
+			Name:     "tldr cache",
+			Checker:  func(context.Context) error { return checkTLDRCache(config.GetTLDRDatabasePath(), tldrMaxAge()) },
+			Critical: false,
+			Hint:     "run 'wut db sync'",
+		},
+		{
+			Name:     "clipboard",
+			Checker:  func(context.Context) error { return checkClipboard() },
+			Critical: false,
+			Hint:     "install wl-clipboard, xclip or xsel, or use a terminal that supports OSC52",
+		},
+		{
+			Name:     "shell integration",
+			Checker:  func(context.Context) error { return checkShellIntegration(installer) },
+			Critical: false,
+			Hint:     "run 'wut install' (or 'wut install --upgrade') and restart your shell",
+		},
+	}
+}
+
+func checkConfigFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("cannot read %s: %w", path, err)
+	}
+	var cfg config.Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return fmt.Errorf("invalid YAML in %s: %w", path, err)
+	}
+	return nil
+}
+
+func checkDatabase(path string) error {
+	storage, err := db.NewStorage(path)
+	if err != nil {
+		return fmt.Errorf("cannot open %s: %w", path, err)
+	}
+	return storage.Close()
+}
+
+// checkEncryption reports history that should be encrypted but is not, or
+// that is encrypted but cannot be unlocked
+func checkEncryption(context.Context) error {
+	path := config.GetDatabasePath()
+	enabled := config.Get().Privacy.EncryptData
+	if _, err := os.Stat(path); err != nil {
+		return nil
+	}
+	storage, err := db.OpenReadOnly(path, time.Second)
+	if err != nil {
+		return nil // reported by the history database check
+	}
+	defer storage.Close()
+
+	if enabled && !storage.IsEncrypted() {
+		return fmt.Errorf("privacy.encrypt_data is on but the history is stored in plaintext")
+	}
+	if storage.IsEncrypted() {
+		if _, err := storage.GetHistory(context.Background(), 1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tldrMaxAge is the cache age after which the TLDR cache counts as stale
+func tldrMaxAge() time.Duration {
+	days := config.Get().TLDR.AutoSyncInterval
+	if days <= 0 {
+		days = 7
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+func checkTLDRCache(path string, maxAge time.Duration) error {
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("no TLDR cache at %s", path)
+	}
+	storage, err := db.NewStorage(path)
+	if err != nil {
+		return fmt.Errorf("cannot open %s: %w", path, err)
+	}
+	defer storage.Close()
+
+	meta, err := storage.GetMetadata()
+	if err != nil || meta == nil || meta.LastSync.IsZero() {
+		return fmt.Errorf("the TLDR cache has never been synced")
+	}
+	if age := time.Since(meta.LastSync); age > maxAge {
+		return fmt.Errorf("last synced %d days ago", int(age.Hours()/24))
+	}
+	return nil
+}
+
+func checkClipboard() error {
+	if terminal.ClipboardBackend() == "" {
+		return fmt.Errorf("no clipboard backend found")
+	}
+	return nil
+}
+
+func checkShellIntegration(installer *shell.Installer) error {
+	var installed, stale []string
+	for _, sh := range installer.GetDetectedShells() {
+		if !installer.IsShellInstalled(sh) {
+			continue
+		}
+		installed = append(installed, sh)
+		if installer.NeedsUpgrade(sh) {
+			stale = append(stale, sh)
+		}
+	}
+	switch {
+	case len(installed) == 0:
+		return fmt.Errorf("not installed in any shell")
+	case len(stale) > 0:
+		return fmt.Errorf("outdated in %s", strings.Join(stale, ", "))
+	}
+	return nil
+}
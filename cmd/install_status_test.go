@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"wut/internal/db"
+)
+
+func TestDoctorChecks(t *testing.T) {
+	dir := t.TempDir()
+
+	valid := filepath.Join(dir, "valid.yaml")
+	invalid := filepath.Join(dir, "invalid.yaml")
+	_ = os.WriteFile(valid, []byte("app:\n  name: wut\n"), 0644)
+	_ = os.WriteFile(invalid, []byte("app: [unclosed\n"), 0644)
+
+	if err := checkConfigFile(valid); err != nil {
+		t.Errorf("valid config: %v", err)
+	}
+	if err := checkConfigFile(invalid); err == nil {
+		t.Errorf("invalid config passed")
+	}
+	if err := checkConfigFile(filepath.Join(dir, "missing.yaml")); err == nil {
+		t.Errorf("missing config passed")
+	}
+
+	tldrPath := filepath.Join(dir, "tldr.db")
+	if err := checkTLDRCache(tldrPath, time.Hour); err == nil {
+		t.Errorf("missing TLDR cache passed")
+	}
+
+	storage, err := db.NewStorage(tldrPath)
+	if err != nil {
+		t.Fatalf("NewStorage() error = %v", err)
+	}
+	if err := storage.SaveMetadata(&db.Metadata{LastSync: time.Now().Add(-48 * time.Hour)}); err != nil {
+		t.Fatalf("SaveMetadata() error = %v", err)
+	}
+	storage.Close()
+
+	if err := checkTLDRCache(tldrPath, 24*time.Hour); err == nil {
+		t.Errorf("stale TLDR cache passed")
+	}
+	if err := checkTLDRCache(tldrPath, 72*time.Hour); err != nil {
+		t.Errorf("fresh TLDR cache: %v", err)
+	}
+	if err := checkDatabase(tldrPath); err != nil {
+		t.Errorf("checkDatabase() error = %v", err)
+	}
+}
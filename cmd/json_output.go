@@ -0,0 +1,458 @@
+package cmd
+
+import (
+	"io"
+	"os"
+	"time"
+
+	"github.com/goccy/go-json"
+
+	"wut/internal/config"
+	"wut/internal/corrector"
+	"wut/internal/db"
+	"wut/internal/health"
+	"wut/internal/metrics"
+	"wut/internal/performance"
+	"wut/internal/shell"
+	"wut/internal/terminal"
+	"wut/pkg/wut"
+)
+
+// jsonSchemaVersion is reported as "schema_version" in every --json document.
+// It is bumped when a field is renamed, removed or changes meaning; adding
+// fields does not change the version.
+//
+// Version 1:
+//
+//	suggest: {schema_version, query, suggestions: [{command, description, score, source, dangerous, matches: [{start, end}]}]}
+//	fix:     {schema_version, original, corrected, changed, confidence, explanation, dangerous}
+//	explain: {schema_version, command, base, summary, description, args, flags: [{flag, value, description, recognized, did_you_mean}], segments, warnings, dangerous, danger_level}
+//	         segments: [{operator, command, subcommand, description, args, flags, redirects: [{operator, target, description}]}]
+//	stats:   {schema_version, history: {total_executions, unique_commands, top_commands: [{command, count}], time_distribution, os_distribution, shell_distribution},
+//	         usage: {since, invocations, suggest_latency_ms: {mean, count}, search_latency_ms, correction_hit_rate, correction_acceptance_rate, cache_hit_ratios}}
+//	doctor:  {schema_version, version, status, checks: [{name, status, critical, error, hint}], skipped: [{name, reason}],
+//	         shells: [{shell, executable, config_file, installed, integration_version, outdated, wut_path}], terminal: {tty, color, ..., width, height}}
+//	config --diff: {schema_version, changes: [{key, default, current}]}
+//
+// A suggestion's matches are the byte ranges of its command that the words of
+// the query matched, end exclusive, for highlighting.
+//
+// A doctor check's status is "pass", "warn" (an optional check failed) or
+// "fail"; the document's status is "fail" when any check failed.
+//
+// score, confidence and the rates and ratios are in the range 0..1; a rate
+// is null until something was recorded for it.
+const jsonSchemaVersion = 1
+
+// outputJSON is set by the --json flag of suggest, fix, explain, stats,
+// doctor and config
+var outputJSON bool
+
+// suggestJSON is the --json document printed by `wut suggest`
+type suggestJSON struct {
+	SchemaVersion int              `json:"schema_version"`
+	Query         string           `json:"query"`
+	Suggestions   []suggestionJSON `json:"suggestions"`
+}
+
+// suggestionJSON is a single suggested command
+type suggestionJSON struct {
+	Command     string  `json:"command"`
+	Description string  `json:"description"`
+	Score       float64 `json:"score"`
+	Source      string  `json:"source"`
+	Dangerous   bool    `json:"dangerous"`
+	// Matches are the parts of Command the query matched
+	Matches []performance.Span `json:"matches"`
+}
+
+// configDiffJSON is the --json document printed by `wut config --diff`
+type configDiffJSON struct {
+	SchemaVersion int            `json:"schema_version"`
+	Changes       []configChange `json:"changes"`
+}
+
+// fixJSON is the --json document printed by `wut fix`
+type fixJSON struct {
+	SchemaVersion int     `json:"schema_version"`
+	Original      string  `json:"original"`
+	Corrected     string  `json:"corrected"`
+	Changed       bool    `json:"changed"`
+	Confidence    float64 `json:"confidence"`
+	Explanation   string  `json:"explanation"`
+	Dangerous     bool    `json:"dangerous"`
+}
+
+// explainJSON is the --json document printed by `wut explain`
+type explainJSON struct {
+	SchemaVersion int               `json:"schema_version"`
+	Command       string            `json:"command"`
+	Base          string            `json:"base"`
+	Summary       string            `json:"summary"`
+	Description   string            `json:"description"`
+	Args          []string          `json:"args"`
+	Flags         []explainFlagJSON `json:"flags"`
+	Segments      []segmentJSON     `json:"segments"`
+	Warnings      []string          `json:"warnings"`
+	Dangerous     bool              `json:"dangerous"`
+	DangerLevel   string            `json:"danger_level"`
+}
+
+// explainFlagJSON is a single flag of an explained command
+type explainFlagJSON struct {
+	Flag        string `json:"flag"`
+	Value       string `json:"value"`
+	Description string `json:"description"`
+	Recognized  bool   `json:"recognized"`
+	DidYouMean  string `json:"did_you_mean,omitempty"`
+}
+
+// segmentJSON is one simple command of an explained command line
+type segmentJSON struct {
+	Operator    string            `json:"operator"`
+	Command     string            `json:"command"`
+	Subcommand  string            `json:"subcommand"`
+	Description string            `json:"description"`
+	Env         map[string]string `json:"env,omitempty"`
+	Args        []string          `json:"args"`
+	Flags       []explainFlagJSON `json:"flags"`
+	Redirects   []redirectJSON    `json:"redirects"`
+}
+
+// redirectJSON is a redirection of a segment
+type redirectJSON struct {
+	Operator    string `json:"operator"`
+	Target      string `json:"target"`
+	Description string `json:"description"`
+}
+
+// statsJSON is the --json document printed by `wut stats`
+type statsJSON struct {
+	SchemaVersion int              `json:"schema_version"`
+	History       historyStatsJSON `json:"history"`
+	Usage         usageJSON        `json:"usage"`
+}
+
+// historyStatsJSON summarizes the command history
+type historyStatsJSON struct {
+	TotalExecutions   int                `json:"total_executions"`
+	UniqueCommands    int                `json:"unique_commands"`
+	TopCommands       []commandCountJSON `json:"top_commands"`
+	TimeDistribution  map[string]int     `json:"time_distribution"`
+	OSDistribution    map[string]int     `json:"os_distribution"`
+	ShellDistribution map[string]int     `json:"shell_distribution"`
+}
+
+// commandCountJSON is how often a command was run
+type commandCountJSON struct {
+	Command string `json:"command"`
+	Count   int    `json:"count"`
+}
+
+// usageJSON is how WUT itself was used, as recorded locally
+type usageJSON struct {
+	Since                    *time.Time         `json:"since"`
+	Invocations              map[string]int64   `json:"invocations"`
+	SuggestLatency           latencyJSON        `json:"suggest_latency_ms"`
+	SearchLatency            latencyJSON        `json:"search_latency_ms"`
+	CorrectionHitRate        *float64           `json:"correction_hit_rate"`
+	CorrectionAcceptanceRate *float64           `json:"correction_acceptance_rate"`
+	CacheHitRatios           map[string]float64 `json:"cache_hit_ratios"`
+}
+
+// latencyJSON is the mean of a latency histogram
+type latencyJSON struct {
+	Mean  float64 `json:"mean"`
+	Count int64   `json:"count"`
+}
+
+// newStatsJSON builds the stats document
+func newStatsJSON(stats *db.HistoryStats, usage *metrics.Usage) *statsJSON {
+	rate := func(r float64) *float64 {
+		if r < 0 {
+			return nil
+		}
+		return &r
+	}
+	latency := func(name string) latencyJSON {
+		h := usage.Histograms[name]
+		return latencyJSON{Mean: h.Mean(), Count: h.Count}
+	}
+
+	doc := &statsJSON{
+		SchemaVersion: jsonSchemaVersion,
+		History: historyStatsJSON{
+			TotalExecutions:   stats.TotalExecutions,
+			UniqueCommands:    stats.UniqueCommands,
+			TopCommands:       []commandCountJSON{},
+			TimeDistribution:  stats.TimeDistribution,
+			OSDistribution:    stats.OSDistribution,
+			ShellDistribution: stats.ShellDistribution,
+		},
+		Usage: usageJSON{
+			Invocations:              usage.Invocations(),
+			SuggestLatency:           latency(metrics.LatencySuggest),
+			SearchLatency:            latency(metrics.LatencySearch),
+			CorrectionHitRate:        rate(usage.Ratio(metrics.CorrectionsHit, metrics.CorrectionsMiss)),
+			CorrectionAcceptanceRate: rate(usage.Ratio(metrics.CorrectionsAccepted, metrics.CorrectionsRejected)),
+			CacheHitRatios:           usage.CacheHitRatios(),
+		},
+	}
+	for _, c := range stats.TopCommands {
+		doc.History.TopCommands = append(doc.History.TopCommands, commandCountJSON{Command: c.Command, Count: c.Count})
+	}
+	if !usage.Since.IsZero() {
+		doc.Usage.Since = &usage.Since
+	}
+	return doc
+}
+
+// writeJSON prints v as indented JSON on stdout
+func writeJSON(v any) error {
+	return encodeJSON(os.Stdout, v)
+}
+
+func encodeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// rankScore turns a position in a ranked list of n results into a score
+// between 0 and 1, best first.
+func rankScore(i, n int) float64 {
+	if n <= 0 {
+		return 0
+	}
+	return 1 - float64(i)/float64(n)
+}
+
+// newSuggestJSON builds the suggest document from a TLDR page, or from the
+// closest command names when no page was found.
+func newSuggestJSON(query string, page *db.Page, matches []string, limit int) *suggestJSON {
+	doc := &suggestJSON{
+		SchemaVersion: jsonSchemaVersion,
+		Query:         query,
+		Suggestions:   []suggestionJSON{},
+	}
+	c := corrector.New()
+	matcher := newMatchHighlighter()
+
+	if page != nil {
+		examples := page.Examples
+		if limit > 0 && len(examples) > limit {
+			examples = examples[:limit]
+		}
+		for _, ex := range examples {
+			doc.Suggestions = append(doc.Suggestions, suggestionJSON{
+				Command:     ex.Command,
+				Description: ex.Description,
+				Score:       1,
+				Source:      "tldr/" + page.Platform,
+				Dangerous:   c.AssessDanger(ex.Command) != nil,
+				Matches:     matchSpans(matcher, query, ex.Command),
+			})
+		}
+		return doc
+	}
+
+	for i, name := range matches {
+		doc.Suggestions = append(doc.Suggestions, suggestionJSON{
+			Command:     name,
+			Description: "TLDR page for " + name,
+			Score:       rankScore(i, len(matches)),
+			Source:      "tldr-index",
+			Matches:     matchSpans(matcher, query, name),
+		})
+	}
+	return doc
+}
+
+// newMatchHighlighter returns a matcher that finds the same spans as the
+// smart engine
+func newMatchHighlighter() *performance.FastMatcher {
+	matcher := performance.NewFastMatcher(false, 0.3, 3)
+	matcher.SetAlgorithm(performance.Algorithm(config.Get().Fuzzy.Algorithm))
+	return matcher
+}
+
+// matchSpans returns the parts of command the words of query matched, never
+// nil so the document always has a list
+func matchSpans(matcher *performance.FastMatcher, query, command string) []performance.Span {
+	spans := matcher.MatchSpans(query, command)
+	if spans == nil {
+		return []performance.Span{}
+	}
+	return spans
+}
+
+// newFixJSON builds the fix document. A nil correction means the command
+// already looks correct.
+func newFixJSON(input string, correction *wut.Correction) *fixJSON {
+	if correction == nil {
+		return &fixJSON{
+			SchemaVersion: jsonSchemaVersion,
+			Original:      input,
+			Corrected:     input,
+			Confidence:    1,
+			Explanation:   "This command looks correct",
+		}
+	}
+	return &fixJSON{
+		SchemaVersion: jsonSchemaVersion,
+		Original:      input,
+		Corrected:     correction.Corrected,
+		Changed:       correction.Corrected != "" && correction.Corrected != input,
+		Confidence:    correction.Confidence,
+		Explanation:   correction.Explanation,
+		Dangerous:     correction.Dangerous,
+	}
+}
+
+// newExplainJSON builds the explain document from a parsed command and its
+// explanation
+func newExplainJSON(parsed *ParsedCommand, exp *Explanation) *explainJSON {
+	doc := &explainJSON{
+		SchemaVersion: jsonSchemaVersion,
+		Command:       exp.Command,
+		Base:          parsed.Command,
+		Summary:       exp.Summary,
+		Description:   exp.Description,
+		Args:          append([]string{}, parsed.Args...),
+		Flags:         explainFlagsJSON(exp.Flags),
+		Segments:      []segmentJSON{},
+		Warnings:      append([]string{}, exp.Warnings...),
+		Dangerous:     exp.IsDangerous,
+		DangerLevel:   exp.DangerLevel,
+	}
+	for _, seg := range exp.Segments {
+		segment := segmentJSON{
+			Operator:    seg.Operator,
+			Command:     seg.Command,
+			Subcommand:  seg.Subcommand,
+			Description: seg.Description,
+			Env:         seg.EnvVars,
+			Args:        append([]string{}, seg.Args...),
+			Flags:       explainFlagsJSON(seg.Flags),
+			Redirects:   []redirectJSON{},
+		}
+		for _, r := range seg.Redirects {
+			segment.Redirects = append(segment.Redirects, redirectJSON(r))
+		}
+		doc.Segments = append(doc.Segments, segment)
+	}
+	return doc
+}
+
+func explainFlagsJSON(flags []Flag) []explainFlagJSON {
+	out := []explainFlagJSON{}
+	for _, f := range flags {
+		out = append(out, explainFlagJSON{
+			Flag:        flagText(ParsedFlag{Name: f.Name, IsShort: f.IsShort}),
+			Value:       f.Value,
+			Description: f.Description,
+			Recognized:  f.Known,
+			DidYouMean:  f.DidYouMean,
+		})
+	}
+	return out
+}
+
+// doctorJSON is the --json document printed by `wut doctor`
+type doctorJSON struct {
+	SchemaVersion int               `json:"schema_version"`
+	Version       string            `json:"version"`
+	Status        string            `json:"status"`
+	Checks        []doctorCheckJSON `json:"checks"`
+	Skipped       []doctorSkipJSON  `json:"skipped"`
+	Shells        []doctorShellJSON `json:"shells"`
+	Terminal      terminalJSON      `json:"terminal"`
+}
+
+// doctorCheckJSON is the result of one doctor check
+type doctorCheckJSON struct {
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	Critical bool   `json:"critical"`
+	Error    string `json:"error,omitempty"`
+	Hint     string `json:"hint,omitempty"`
+}
+
+// doctorSkipJSON is a check doctor did not run
+type doctorSkipJSON struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// doctorShellJSON is the integration state of an installed shell
+type doctorShellJSON struct {
+	Shell              string `json:"shell"`
+	Executable         string `json:"executable"`
+	ConfigFile         string `json:"config_file"`
+	Installed          bool   `json:"installed"`
+	IntegrationVersion int    `json:"integration_version"`
+	Outdated           bool   `json:"outdated"`
+	WutPath            string `json:"wut_path"`
+}
+
+// terminalJSON is what the terminal can display
+type terminalJSON struct {
+	TTY        bool `json:"tty"`
+	Color      bool `json:"color"`
+	Colors256  bool `json:"colors_256"`
+	TrueColor  bool `json:"true_color"`
+	Emoji      bool `json:"emoji"`
+	Hyperlinks bool `json:"hyperlinks"`
+	Width      int  `json:"width"`
+	Height     int  `json:"height"`
+}
+
+// newDoctorJSON builds the doctor document
+func newDoctorJSON(report health.Health, shells []shell.IntegrationStatus, caps terminal.Capabilities, skipped string) *doctorJSON {
+	doc := &doctorJSON{
+		SchemaVersion: jsonSchemaVersion,
+		Version:       report.Version,
+		Status:        "pass",
+		Checks:        []doctorCheckJSON{},
+		Skipped:       []doctorSkipJSON{},
+		Shells:        []doctorShellJSON{},
+		Terminal: terminalJSON{
+			TTY:        caps.IsTTY,
+			Color:      caps.Color,
+			Colors256:  caps.Supports256Colors,
+			TrueColor:  caps.SupportsTrueColor,
+			Emoji:      caps.SupportsEmoji,
+			Hyperlinks: caps.SupportsHyperlinks,
+			Width:      caps.Width,
+			Height:     caps.Height,
+		},
+	}
+	for _, r := range report.Checks {
+		check := doctorCheckJSON{Name: r.Name, Status: "pass", Critical: r.Critical}
+		if r.Status != "healthy" {
+			check.Status, check.Error, check.Hint = "warn", r.Error, r.Hint
+			if r.Critical {
+				check.Status, doc.Status = "fail", "fail"
+			}
+		}
+		doc.Checks = append(doc.Checks, check)
+	}
+	if skipped != "" {
+		doc.Skipped = append(doc.Skipped, doctorSkipJSON{Name: "tldr network", Reason: skipped})
+	}
+	for _, s := range shells {
+		if s.Executable == "" {
+			continue
+		}
+		doc.Shells = append(doc.Shells, doctorShellJSON{
+			Shell:              s.Shell,
+			Executable:         s.Executable,
+			ConfigFile:         s.ConfigFile,
+			Installed:          s.Installed,
+			IntegrationVersion: s.Version,
+			Outdated:           s.Stale(),
+			WutPath:            s.WutPath,
+		})
+	}
+	return doc
+}
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/goccy/go-json"
+
+	"wut/internal/db"
+	"wut/internal/performance"
+	"wut/pkg/wut"
+)
+
+func TestJSONOutputSchema(t *testing.T) {
+	page := &db.Page{
+		Name:     "rm",
+		Platform: db.PlatformCommon,
+		Examples: []db.Example{
+			{Description: "Remove a file", Command: "rm <path/to/file>"},
+			{Description: "Remove everything", Command: "rm -rf /"},
+		},
+	}
+	correction := &wut.Correction{Original: "gti status", Corrected: "git status", Confidence: 0.9, Explanation: "Fixed: 'gti'→'git'"}
+	parsed := parseCommand("tar -xzf a.tgz")
+
+	docs := map[string]any{
+		"suggest": newSuggestJSON("rm", page, nil, 10),
+		"fix":     newFixJSON("gti status", correction),
+		"explain": newExplainJSON(parsed, &Explanation{Command: parsed.Raw, Flags: extractFlagsV2(parsed)}),
+	}
+
+	for name, doc := range docs {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := encodeJSON(&buf, doc); err != nil {
+				t.Fatalf("encodeJSON() error = %v", err)
+			}
+			if strings.Contains(buf.String(), "\x1b") {
+				t.Errorf("JSON output contains escape sequences:\n%s", buf.String())
+			}
+
+			var decoded map[string]any
+			if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+				t.Fatalf("output is not valid JSON: %v", err)
+			}
+			if v, ok := decoded["schema_version"].(float64); !ok || int(v) != jsonSchemaVersion {
+				t.Errorf("schema_version = %v, want %d", decoded["schema_version"], jsonSchemaVersion)
+			}
+		})
+	}
+
+	suggest := docs["suggest"].(*suggestJSON)
+	if suggest.Suggestions[0].Dangerous || !suggest.Suggestions[1].Dangerous {
+		t.Errorf("dangerous flags = %v, %v; want false, true", suggest.Suggestions[0].Dangerous, suggest.Suggestions[1].Dangerous)
+	}
+	if want := []performance.Span{{Start: 0, End: 2}}; !reflect.DeepEqual(suggest.Suggestions[0].Matches, want) {
+		t.Errorf("matches = %v, want %v", suggest.Suggestions[0].Matches, want)
+	}
+
+	// Every word of the query is matched, and the scattered letters of a
+	// fuzzy match each get a span
+	index := newSuggestJSON("git stat", nil, []string{"git-status", "tar"}, 10)
+	if want := []performance.Span{{Start: 0, End: 3}, {Start: 4, End: 8}}; !reflect.DeepEqual(index.Suggestions[0].Matches, want) {
+		t.Errorf("git-status matches = %v, want %v", index.Suggestions[0].Matches, want)
+	}
+	fuzzy := newSuggestJSON("gst", nil, []string{"git-status"}, 10)
+	if want := []performance.Span{{Start: 0, End: 1}, {Start: 4, End: 6}}; !reflect.DeepEqual(fuzzy.Suggestions[0].Matches, want) {
+		t.Errorf("fuzzy matches = %v, want %v", fuzzy.Suggestions[0].Matches, want)
+	}
+	if matches := index.Suggestions[1].Matches; matches == nil || len(matches) != 0 {
+		t.Errorf("tar matches = %#v, want an empty list", matches)
+	}
+}
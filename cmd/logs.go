@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"wut/internal/config"
+	"wut/internal/logger"
+	"wut/internal/ui"
+
+	"github.com/spf13/cobra"
+)
+
+var logsCmd = &cobra.Command{
+	Use:   "logs",
+	Short: "Show the end of the WUT log file",
+	Long: `Print the last lines of the log file set by logging.file, colored by
+level, and how much disk space it and its rotated backups take.
+
+The log file gets logging.level and up. Run any command with --verbose to
+also see its log on stderr, or with --debug to include details and how long
+opening the database, searching, suggesting and fetching pages took.`,
+	Example: `  wut logs
+  wut logs --tail 200
+  wut suggest git --debug`,
+	RunE: runLogs,
+}
+
+var logsTail int
+
+func init() {
+	rootCmd.AddCommand(logsCmd)
+
+	logsCmd.Flags().IntVarP(&logsTail, "tail", "n", 50, "number of lines to show (0 for all)")
+}
+
+func runLogs(cmd *cobra.Command, args []string) error {
+	path := config.Get().Logging.File
+	if path == "" {
+		return fmt.Errorf("logging.file is not set; set it with 'wut config --set logging.file <path>'")
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		fmt.Println(ui.Muted("No log file yet at " + path))
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read log file: %w", err)
+	}
+
+	level := ""
+	for _, line := range tailLines(string(data), logsTail) {
+		// Lines without a level continue a multi-line value of the entry above
+		if l := logLineLevel(line); l != "" {
+			level = l
+		}
+		fmt.Println(colorLogLine(line, level))
+	}
+
+	if size, files, err := logger.DiskUsage(path); err == nil {
+		fmt.Println()
+		fmt.Println(ui.Muted(logUsageSummary(size, files, config.Get().Logging)))
+	}
+	return nil
+}
+
+// logUsageSummary describes the disk space taken by the log file and its
+// rotated backups
+func logUsageSummary(size int64, files int, cfg config.LoggingConfig) string {
+	summary := fmt.Sprintf("%s in %d log files", formatBytes(size), files)
+	if files == 1 {
+		summary = fmt.Sprintf("%s in 1 log file", formatBytes(size))
+	}
+	if cfg.MaxSize > 0 {
+		summary += fmt.Sprintf("; rotated at %d MB", cfg.MaxSize)
+		if cfg.MaxBackups > 0 {
+			summary += fmt.Sprintf(", keeping %d backups", cfg.MaxBackups)
+		}
+	}
+	return summary
+}
+
+// tailLines returns the last n lines of text; n of 0 or less returns them all
+func tailLines(text string, n int) []string {
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	if len(lines) == 1 && lines[0] == "" {
+		return nil
+	}
+	if n > 0 && len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return lines
+}
+
+// logLineLevel returns the level of a text or JSON log line, as debug, info,
+// warn, error or fatal, or "" when the line has none
+func logLineLevel(line string) string {
+	if strings.HasPrefix(line, "{") {
+		var entry struct {
+			Level string `json:"level"`
+		}
+		if json.Unmarshal([]byte(line), &entry) != nil {
+			return ""
+		}
+		return strings.ToLower(entry.Level)
+	}
+
+	fields := strings.Fields(line)
+	if len(fields) < 2 {
+		return ""
+	}
+	switch fields[1] {
+	case "DEBU":
+		return "debug"
+	case "INFO":
+		return "info"
+	case "WARN":
+		return "warn"
+	case "ERRO":
+		return "error"
+	case "FATA":
+		return "fatal"
+	default:
+		return ""
+	}
+}
+
+// colorLogLine colors a log line by its level
+func colorLogLine(line, level string) string {
+	switch level {
+	case "debug":
+		return ui.Muted(line)
+	case "warn":
+		return ui.Warning(line)
+	case "error", "fatal":
+		return ui.Error(line)
+	default:
+		return line
+	}
+}
@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"slices"
+	"testing"
+
+	"wut/internal/config"
+)
+
+func TestTailLines(t *testing.T) {
+	text := "one\ntwo\nthree\n"
+	if got := tailLines(text, 2); !slices.Equal(got, []string{"two", "three"}) {
+		t.Errorf("tailLines(2) = %q", got)
+	}
+	if got := tailLines(text, 0); len(got) != 3 {
+		t.Errorf("tailLines(0) = %q, want every line", got)
+	}
+	if got := tailLines("", 5); got != nil {
+		t.Errorf("tailLines() of an empty log = %q, want none", got)
+	}
+}
+
+func TestLogLineLevel(t *testing.T) {
+	tests := map[string]string{
+		"2026-01-02T15:04:05Z WARN db: pruned entries=3":                 "warn",
+		"2026-01-02T15:04:05Z ERRO command execution failed":             "error",
+		"2026-01-02T15:04:05Z DEBU db: open storage took=49µs":           "debug",
+		`{"time":"2026-01-02T15:04:05Z","level":"info","msg":"started"}`: "info",
+		"  │ Use 'wut config --list' to see available keys":              "",
+		`{"broken"`: "",
+	}
+	for line, want := range tests {
+		if got := logLineLevel(line); got != want {
+			t.Errorf("logLineLevel(%q) = %q, want %q", line, got, want)
+		}
+	}
+}
+
+func TestLogUsageSummary(t *testing.T) {
+	cfg := config.LoggingConfig{MaxSize: 10, MaxBackups: 5}
+	if got, want := logUsageSummary(3*1024*1024, 4, cfg), "3.0 MB in 4 log files; rotated at 10 MB, keeping 5 backups"; got != want {
+		t.Errorf("logUsageSummary() = %q, want %q", got, want)
+	}
+	if got, want := logUsageSummary(512, 1, config.LoggingConfig{}), "512 B in 1 log file"; got != want {
+		t.Errorf("logUsageSummary() without rotation = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+
+	"wut/internal/config"
+	"wut/internal/db"
+	"wut/internal/ui"
+)
+
+func TestPipedOutputHasNoANSI(t *testing.T) {
+	origProfile := lipgloss.ColorProfile()
+	t.Cleanup(func() { lipgloss.SetColorProfile(origProfile) })
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("NO_COLOR", "")
+
+	config.Set(&config.Config{})
+
+	storage, err := db.NewStorage(filepath.Join(t.TempDir(), "wut.db"))
+	if err != nil {
+		t.Fatalf("NewStorage() error = %v", err)
+	}
+	defer storage.Close()
+	for _, command := range []string{"git status", "docker ps"} {
+		if err := storage.AddHistory(context.Background(), command); err != nil {
+			t.Fatalf("AddHistory() error = %v", err)
+		}
+	}
+
+	commands := map[string]func() error{
+		"suggest": func() error {
+			suggestOffline = true
+			defer func() { suggestOffline = false }()
+			return runSuggest(suggestCmd, []string{"git"})
+		},
+		"history": func() error { return showHistory(context.Background(), storage) },
+		"config":  func() error { return runConfig(configCmd, nil) },
+		"db":      func() error { return runDBStatus(dbStatusCmd, nil) },
+	}
+
+	for name, run := range commands {
+		t.Run(name, func(t *testing.T) {
+			// Pretend the terminal supports color; stdout is a pipe inside
+			// captureStdout, so the color mode must fall back to plain.
+			lipgloss.SetColorProfile(termenv.TrueColor)
+
+			out := captureStdout(t, func() {
+				ui.ApplyColorMode()
+				if err := run(); err != nil {
+					t.Errorf("%s error = %v", name, err)
+				}
+			})
+			if strings.TrimSpace(out) == "" {
+				t.Fatalf("%s printed nothing", name)
+			}
+			if strings.Contains(out, "\x1b") {
+				t.Errorf("%s emitted escape sequences into a pipe:\n%q", name, out)
+			}
+		})
+	}
+}
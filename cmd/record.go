@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+
+	"wut/internal/config"
+	"wut/internal/db"
+	"wut/internal/ui"
+)
+
+// recordCmd records a command the shell just ran. The shell integration
+// calls it from its prompt hook, so history has every command with the time
+// it ran rather than only what a history file import finds.
+var recordCmd = &cobra.Command{
+	Use:   "__record [--status code --duration ms] [--] <command>",
+	Short: "Record a command run in the shell",
+	Long: `Record a command run in the shell in the history, and show a tip when
+it is a long command run often. The shell integration calls this after each
+command; WUT's own commands are not recorded.
+
+With history.track_timing, --status and --duration record how the command
+exited and how long it ran, in milliseconds.
+
+Set WUT_HISTORY_HOOK=0 in a shell to stop recording its commands.`,
+	// pro-tip is the name used by integrations installed before version 5
+	Aliases: []string{"pro-tip"},
+	Hidden:  true,
+	RunE:    runRecord,
+}
+
+// historyHookEnv turns recording by the shell integration off when false
+const historyHookEnv = "WUT_HISTORY_HOOK"
+
+// tipMinLength and tipMinCount are how long and how often run a command
+// must be before a shortcut is suggested for it
+const (
+	tipMinLength = 15
+	tipMinCount  = 5
+)
+
+var (
+	recordStatus   int
+	recordDuration int64
+)
+
+func init() {
+	rootCmd.AddCommand(recordCmd)
+
+	recordCmd.Flags().IntVar(&recordStatus, "status", 0, "exit status of the command")
+	recordCmd.Flags().Int64Var(&recordDuration, "duration", 0, "how long the command ran, in milliseconds")
+}
+
+func runRecord(cmd *cobra.Command, args []string) error {
+	command := strings.TrimSpace(strings.Join(args, " "))
+	if command == "" || isWutCommand(command) || !historyHookEnabled() {
+		return nil
+	}
+
+	cfg, ok := loadRecordConfig()
+	if !ok || !cfg.History.Enabled {
+		return nil
+	}
+
+	// Recording must never disturb the prompt, so failures are ignored
+	storage, err := db.NewStorage(config.GetDatabasePath())
+	if err != nil {
+		return nil
+	}
+	defer storage.Close()
+
+	ctx := context.Background()
+	entry := db.CommandExecution{Command: command}
+	if cmd.Flags().Changed("status") && cmd.Flags().Changed("duration") {
+		entry = timedExecution(command, recordStatus, recordDuration, time.Now())
+	}
+	if _, err := storage.AddHistoryBatch(ctx, []db.CommandExecution{entry}); err != nil {
+		return nil
+	}
+	if cfg.History.MaxEntries > 0 {
+		_ = storage.TrimHistory(ctx, cfg.History.MaxEntries)
+	}
+
+	showShortcutTip(ctx, storage, command)
+	return nil
+}
+
+// loadRecordConfig loads the configuration in place of the initialization
+// __record skips, which runs on every prompt and so must stay quiet and fast.
+// Nothing is recorded before WUT is set up, and only the settings recording
+// and its tips depend on are applied.
+func loadRecordConfig() (*config.Config, bool) {
+	if cfgFile != "" {
+		config.SetConfigPath(cfgFile)
+	}
+	if _, err := os.Stat(config.GetConfigPath()); err != nil {
+		return nil, false
+	}
+	cfg, err := config.Load("")
+	if err != nil || !cfg.App.Initialized {
+		return nil, false
+	}
+
+	db.SetEngine(cfg.Database.Type)
+	db.SetMaxSize(int64(cfg.Database.MaxSize) * 1024 * 1024)
+	db.SetAnonymize(cfg.Privacy.AnonymizeCommands)
+	db.SetTrackTiming(cfg.History.TrackTiming)
+	ui.SetTheme(cfg.UI.Theme)
+	return cfg, true
+}
+
+// timedExecution is the execution of command that ended at end after
+// running for duration milliseconds with status
+func timedExecution(command string, status int, duration int64, end time.Time) db.CommandExecution {
+	duration = max(duration, 0)
+	return db.CommandExecution{
+		Command:   command,
+		Timestamp: end.Add(-time.Duration(duration) * time.Millisecond),
+		ExitCode:  status,
+		Duration:  duration,
+		Timed:     true,
+	}
+}
+
+// historyHookEnabled reports whether WUT_HISTORY_HOOK leaves recording on
+func historyHookEnabled() bool {
+	enabled, err := strconv.ParseBool(os.Getenv(historyHookEnv))
+	return err != nil || enabled
+}
+
+// wutAliases are the shell functions the integration defines around wut
+var wutAliases = map[string]bool{
+	"oops":    true,
+	"again":   true,
+	"fixlast": true,
+}
+
+// isWutCommand reports whether command runs WUT itself, directly or through
+// one of the integration's shell functions
+func isWutCommand(command string) bool {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return false
+	}
+	name := strings.TrimSuffix(filepath.Base(fields[0]), ".exe")
+	return name == "wut" || wutAliases[name] || strings.HasPrefix(name, "__wut")
+}
+
+// showShortcutTip suggests an alias for command when it is long and run often
+func showShortcutTip(ctx context.Context, storage *db.Storage, command string) {
+	if len(command) < tipMinLength {
+		return
+	}
+	count, err := storage.GetCommandUsageCount(ctx, command, tipMinCount)
+	if err != nil || count < tipMinCount {
+		return
+	}
+
+	tipStyle := lipgloss.NewStyle().Foreground(ui.ColorHighlight).Bold(true)
+	cmdStyle := lipgloss.NewStyle().Foreground(ui.ColorPrimary)
+
+	fmt.Printf("\n  💡 %s\n  %s\n",
+		tipStyle.Render("Tip: You run this long command frequently! Want a shortcut?"),
+		lipgloss.NewStyle().Foreground(ui.ColorSubtle).Render(fmt.Sprintf("Run: wut a --add myalias \"%s\"", cmdStyle.Render(command))),
+	)
+}
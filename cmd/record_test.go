@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"wut/internal/config"
+)
+
+func TestIsWutCommand(t *testing.T) {
+	tests := []struct {
+		command string
+		want    bool
+	}{
+		{"wut fix gti", true},
+		{"wut", true},
+		{"/usr/local/bin/wut suggest", true},
+		{"oops", true},
+		{"again --exec", true},
+		{"fixlast", true},
+		{"__wut_fix_last", true},
+		{"wutang", false},
+		{"git status", false},
+		{"echo wut", false},
+	}
+	for _, tt := range tests {
+		if got := isWutCommand(tt.command); got != tt.want {
+			t.Errorf("isWutCommand(%q) = %v, want %v", tt.command, got, tt.want)
+		}
+	}
+}
+
+func TestHistoryHookEnabled(t *testing.T) {
+	for value, want := range map[string]bool{"": true, "1": true, "true": true, "0": false, "false": false, "yes": true} {
+		t.Setenv(historyHookEnv, value)
+		if got := historyHookEnabled(); got != want {
+			t.Errorf("historyHookEnabled() with %s=%q = %v, want %v", historyHookEnv, value, got, want)
+		}
+	}
+}
+
+func TestTimedExecution(t *testing.T) {
+	end := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	entry := timedExecution("make test", 2, 1500, end)
+	if !entry.Timed || entry.ExitCode != 2 || entry.Duration != 1500 || !entry.Timestamp.Equal(end.Add(-1500*time.Millisecond)) {
+		t.Errorf("timedExecution() = %+v, want a 1.5s failed run started before end", entry)
+	}
+	// A clock that went backwards gives a negative duration
+	if entry := timedExecution("ls", 0, -20, end); entry.Duration != 0 || !entry.Timestamp.Equal(end) {
+		t.Errorf("timedExecution() with a negative duration = %+v", entry)
+	}
+}
+
+func TestRecordSkipsInitialization(t *testing.T) {
+	if !shouldSkipInitialization(recordCmd) {
+		t.Error("__record goes through the full initialization")
+	}
+
+	// Without a configuration nothing is recorded, and none is created
+	origCfgFile := cfgFile
+	t.Cleanup(func() {
+		cfgFile = origCfgFile
+		config.SetConfigPath("")
+	})
+	cfgFile = filepath.Join(t.TempDir(), "config.yaml")
+	if _, ok := loadRecordConfig(); ok {
+		t.Error("loadRecordConfig() = true without a configuration file")
+	}
+	if _, err := os.Stat(cfgFile); !os.IsNotExist(err) {
+		t.Errorf("loadRecordConfig() created %s", cfgFile)
+	}
+}
@@ -3,6 +3,7 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/signal"
@@ -10,9 +11,13 @@ import (
 	"syscall"
 
 	"wut/internal/config"
+	"wut/internal/corrector"
+	"wut/internal/db"
 	"wut/internal/health"
 	"wut/internal/logger"
 	"wut/internal/metrics"
+	"wut/internal/smart"
+	"wut/internal/terminal"
 	"wut/internal/ui"
 
 	"github.com/charmbracelet/lipgloss"
@@ -31,8 +36,14 @@ var (
 
 	cfgFile       string
 	debug         bool
+	verbose       bool
+	noColor       bool
+	metricsAddr   string
 	didInitialize bool
 
+	// stopMetrics shuts down the --metrics-addr server
+	stopMetrics func()
+
 	// rootCmd represents the base command
 	rootCmd = &cobra.Command{
 		Use:   "wut",
@@ -40,6 +51,12 @@ var (
 		Long: `The Smart Command Line Assistant That Actually Understands You
 `,
 		Version: "", // Will be set in init()
+		// Unknown commands reach RunE so they can be corrected
+		Args: cobra.ArbitraryArgs,
+		RunE: runRoot,
+		// Runtime failures (including a failing executed command) should not
+		// dump the usage text
+		SilenceUsage: true,
 		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 			if shouldSkipInitialization(cmd) {
 				return nil
@@ -49,27 +66,39 @@ var (
 				return err
 			}
 
-			// Check if WUT has been initialized
+			// Set WUT up on first use, or ask for it to be set up when there
+			// is no terminal to run the wizard in
+			if !config.IsInitialized() && terminal.IsInteractive() {
+				if err := runInit(initCmd, nil); err != nil {
+					return err
+				}
+				if !config.IsInitialized() {
+					os.Exit(1)
+				}
+			}
 			if !config.IsInitialized() {
 				fmt.Println()
 				banner := lipgloss.NewStyle().
 					Bold(true).
-					Foreground(lipgloss.Color("#FFFFFF")).
-					Background(lipgloss.Color("#EF4444")).
+					Foreground(ui.ColorOnAccent).
+					Background(ui.ColorError).
 					Padding(0, 2).
 					Render("⚠  WUT has not been initialized yet!")
 				fmt.Println(banner)
 				fmt.Println()
-				fmt.Println(lipgloss.NewStyle().Foreground(lipgloss.Color("#9CA3AF")).Render("  Please run the setup wizard first:"))
+				fmt.Println(lipgloss.NewStyle().Foreground(ui.ColorSubtle).Render("  Please run the setup wizard first:"))
 				fmt.Println()
-				fmt.Println(lipgloss.NewStyle().Foreground(lipgloss.Color("#10B981")).Bold(true).Render("    wut init"))
+				fmt.Println(lipgloss.NewStyle().Foreground(ui.ColorSuccess).Bold(true).Render("    wut init"))
 				fmt.Println()
-				fmt.Println(lipgloss.NewStyle().Foreground(lipgloss.Color("#6B7280")).Render("  This will configure your settings, install shell integration,"))
-				fmt.Println(lipgloss.NewStyle().Foreground(lipgloss.Color("#6B7280")).Render("  and download the command database — all in one step."))
+				fmt.Println(lipgloss.NewStyle().Foreground(ui.ColorMuted).Render("  This will configure your settings, install shell integration,"))
+				fmt.Println(lipgloss.NewStyle().Foreground(ui.ColorMuted).Render("  and download the command database — all in one step."))
 				fmt.Println()
 				os.Exit(1)
 			}
 
+			metrics.RecordInvocation(strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" "))
+			autoBackup(cmd.Context(), cmd)
+			autoSyncTLDR(cmd)
 			return nil
 		},
 		PersistentPostRun: func(cmd *cobra.Command, args []string) {
@@ -86,17 +115,145 @@ func applyPremiumHelpRecursively(c *cobra.Command) {
 	}
 }
 
+// exitStatus is returned by a command whose result is its exit status
+// rather than a failure, such as wut fix finding a typo. Execute exits with
+// it and prints nothing.
+type exitStatus int
+
+func (s exitStatus) Error() string {
+	return fmt.Sprintf("exit status %d", int(s))
+}
+
+// errUnknownCommand is returned for a mistyped command; the message already
+// says what to run instead, so it is not logged again
+var errUnknownCommand = errors.New("unknown command")
+
+// runRoot shows the help, or explains an unknown command
+func runRoot(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		return cmd.Help()
+	}
+	return unknownCommandError(cmd, args)
+}
+
+// implicitSuggestArgs routes free text given without a command to suggest,
+// so 'wut how do i see open ports --limit 5' runs as 'wut suggest how do i
+// see open ports --limit 5'. Text that looks like a typo of a command is left
+// for runRoot to correct.
+func implicitSuggestArgs(root *cobra.Command, args []string) []string {
+	if len(args) == 0 || args[0] == cobra.ShellCompRequestCmd || args[0] == cobra.ShellCompNoDescRequestCmd {
+		return args
+	}
+	root.InitDefaultHelpCmd()
+	root.InitDefaultCompletionCmd()
+	if cmd, _, err := root.Find(args); err != nil || cmd != root {
+		return args
+	}
+
+	words := positionalArgs(root, args)
+	if len(words) == 0 || closestCommand(root, words) != "" {
+		return args
+	}
+	return append([]string{"suggest"}, args...)
+}
+
+// positionalArgs returns args without flags and the values of root flags
+// that take one
+func positionalArgs(root *cobra.Command, args []string) []string {
+	var words []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			return append(words, args[i+1:]...)
+		}
+		if len(arg) < 2 || arg[0] != '-' {
+			words = append(words, arg)
+			continue
+		}
+		if strings.Contains(arg, "=") {
+			continue
+		}
+
+		var flag *pflag.Flag
+		if name, ok := strings.CutPrefix(arg, "--"); ok {
+			flag = root.PersistentFlags().Lookup(name)
+		} else if len(arg) == 2 {
+			flag = root.PersistentFlags().ShorthandLookup(arg[1:])
+		}
+		if flag != nil && flag.NoOptDefVal == "" {
+			i++
+		}
+	}
+	return words
+}
+
+// unknownCommandError reports an unknown top-level command with the command
+// it is most likely a typo of
+func unknownCommandError(root *cobra.Command, args []string) error {
+	typed := args[0]
+	match := closestCommand(root, args)
+	if match == "" {
+		return fmt.Errorf("%w %q for %q\nRun '%s --help' for usage.", errUnknownCommand, typed, root.CommandPath(), root.CommandPath())
+	}
+	run := strings.Join(append([]string{root.CommandPath(), match}, args[1:]...), " ")
+	return fmt.Errorf("%w %q for %q\n\nDid you mean '%s'?\nRun '%s' to run it.", errUnknownCommand, typed, root.CommandPath(), match, run)
+}
+
+// closestCommand returns the command the first of words is most likely a
+// typo of, found by the corrector among the command names and aliases, or
+// failing that, for a single word, the command it is a prefix of. A shell
+// command such as docker, or text that reads as a question, is no typo.
+func closestCommand(root *cobra.Command, words []string) string {
+	typed := words[0]
+	if corrector.IsKnownCommand(typed) || smart.IsNaturalLanguage(strings.Join(words, " ")) {
+		return ""
+	}
+
+	names := make(map[string]string)
+	var corpus []string
+	for _, c := range root.Commands() {
+		if !c.IsAvailableCommand() {
+			continue
+		}
+		for _, name := range append([]string{c.Name()}, c.Aliases...) {
+			name = strings.ToLower(name)
+			names[name] = c.Name()
+			corpus = append(corpus, name)
+		}
+	}
+
+	if match := names[corrector.New().Closest(typed, corrector.NewCorpus(corpus))]; match != "" {
+		return match
+	}
+	if len(words) == 1 {
+		if prefixed := root.SuggestionsFor(typed); len(prefixed) > 0 {
+			return prefixed[0]
+		}
+	}
+	return ""
+}
+
 func shouldSkipInitialization(cmd *cobra.Command) bool {
 	if cmd == nil {
 		return false
 	}
 
+	// The root command only shows help or reports an unknown command
+	if !cmd.HasParent() {
+		return true
+	}
+
 	if help, err := cmd.Flags().GetBool("help"); err == nil && help {
 		return true
 	}
 
+	if completionCommandNames[cmd.Name()] {
+		return true
+	}
+
 	switch cmd.Name() {
-	case "init", "help", "version", "bug-report":
+	// __record loads only what it needs, as it runs on every prompt
+	case "init", "help", "version", "bug-report", "doctor", recordCmd.Name():
 		return true
 	default:
 		return false
@@ -126,17 +283,40 @@ func Execute() {
 	// Apply modern UI scheme to all registered commands
 	applyPremiumHelpRecursively(rootCmd)
 
+	rootCmd.SetArgs(implicitSuggestArgs(rootCmd, os.Args[1:]))
 	if err := rootCmd.Execute(); err != nil {
-		logger.Error("command execution failed", "error", err)
-		os.Exit(1)
+		code, report := exitCode(err)
+		if report {
+			logger.Error("command execution failed", "error", err)
+		}
+		os.Exit(code)
+	}
+}
+
+// exitCode is the status WUT exits with after err, and whether err still has
+// to be reported. A command WUT executed on the user's behalf has its exit
+// status mirrored.
+func exitCode(err error) (int, bool) {
+	var exitErr *db.ExitError
+	if errors.As(err, &exitErr) && exitErr.Result.ExitCode > 0 {
+		return exitErr.Result.ExitCode, false
 	}
+	var status exitStatus
+	if errors.As(err, &status) {
+		return int(status), false
+	}
+	return 1, !errors.Is(err, errUnknownCommand)
 }
 
 func init() {
 	cobra.OnInitialize(initConfig)
 
-	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.config/wut/config.yaml)")
-	rootCmd.PersistentFlags().BoolVarP(&debug, "debug", "d", false, "enable debug mode")
+	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (also honors "+configFileEnv+"; default is $HOME/.config/wut/config.yaml)")
+	rootCmd.PersistentFlags().BoolVarP(&debug, "debug", "d", false, "enable debug mode, logging details and timings to stderr")
+	rootCmd.PersistentFlags().BoolVar(&verbose, "verbose", false, "log what WUT does to stderr")
+	rootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false, "disable colored output (also honors NO_COLOR)")
+	rootCmd.PersistentFlags().StringVar(&metricsAddr, "metrics-addr", "", "serve metrics and pprof on this address, e.g. :6060 (also honors "+metricsAddrEnv+")")
+	_ = rootCmd.PersistentFlags().MarkHidden("metrics-addr")
 }
 
 func setupPremiumHelp(cmd *cobra.Command) {
@@ -154,11 +334,11 @@ func setupPremiumHelp(cmd *cobra.Command) {
 
 			bannerStyle := lipgloss.NewStyle().
 				Bold(true).
-				Foreground(lipgloss.Color("#FFFFFF")).
-				Background(lipgloss.Color("#8B5CF6")). // Electric Blue background
-				Padding(1, padX).                      // Dynamic left/right padding
+				Foreground(ui.ColorOnAccent).
+				Background(ui.ColorSecondary). // Electric Blue background
+				Padding(1, padX).              // Dynamic left/right padding
 				Border(lipgloss.NormalBorder()).
-				BorderForeground(lipgloss.Color("#8B5CF6")). // Violet border
+				BorderForeground(ui.ColorSecondary). // Violet border
 				MarginBottom(1)
 
 			if termWidth < 70 {
@@ -288,9 +468,41 @@ func SetVersionInfo() {
 	rootCmd.Version = Version
 }
 
+// configFileEnv sets --config from the environment
+const configFileEnv = "WUT_CONFIG"
+
 // initConfig reads in config file and ENV variables if set.
 func initConfig() {
-	// Configuration will be loaded in initialize()
+	// Configuration will be loaded in initialize(), but every command,
+	// including those that skip it, should see the same file
+	cfgFile = resolveConfigFile(cfgFile)
+	config.SetConfigPath(cfgFile)
+
+	// Color must be settled before anything renders, including help and init
+	terminal.SetNoColor(noColor)
+	ui.ApplyColorMode()
+}
+
+// resolveConfigFile returns the config file to use: the --config flag, then
+// WUT_CONFIG. Empty means the default file.
+func resolveConfigFile(flag string) string {
+	if flag != "" {
+		return flag
+	}
+	return os.Getenv(configFileEnv)
+}
+
+// logLevelOverride is the log level --debug or --verbose set for this run,
+// for both the log file and stderr
+func logLevelOverride() string {
+	switch {
+	case debug:
+		return "debug"
+	case verbose:
+		return "info"
+	default:
+		return ""
+	}
 }
 
 // initialize performs initialization before command execution
@@ -299,8 +511,10 @@ func initialize(ctx context.Context) error {
 
 	// Initialize logger first
 	logCfg := logger.DefaultConfig()
-	if debug {
-		logCfg.Level = "debug"
+	override := logLevelOverride()
+	if override != "" {
+		logCfg.Level = override
+		logCfg.ConsoleLevel = override
 	}
 
 	if err := logger.Initialize(logCfg); err != nil {
@@ -322,14 +536,36 @@ func initialize(ctx context.Context) error {
 		cfg.App.Debug = true
 	}
 
+	ui.SetTheme(cfg.UI.Theme)
+
+	// Switch to the configured log file and format
+	logCfg.Format = cfg.Logging.Format
+	logCfg.File = cfg.Logging.File
+	logCfg.MaxSize = cfg.Logging.MaxSize
+	logCfg.MaxBackups = cfg.Logging.MaxBackups
+	logCfg.MaxAge = cfg.Logging.MaxAge
+	if override == "" && cfg.Logging.Level != "" {
+		logCfg.Level = cfg.Logging.Level
+	}
+	if err := logger.Configure(logCfg); err != nil {
+		log.Warn("failed to apply logging configuration", "error", err)
+	}
+	log = logger.With("init")
+
 	// Ensure directories exist
 	if err := config.EnsureDirs(); err != nil {
 		log.Error("failed to create directories", "error", err)
 		return fmt.Errorf("failed to create directories: %w", err)
 	}
 
+	db.SetEngine(cfg.Database.Type)
+	db.SetMaxSize(int64(cfg.Database.MaxSize) * 1024 * 1024)
+	db.SetAnonymize(cfg.Privacy.AnonymizeCommands)
+	db.SetTrackTiming(cfg.History.TrackTiming)
+
 	// Initialize metrics
 	metrics.Initialize(Version, Commit)
+	startMetricsServer(log)
 
 	// Initialize health checker
 	healthChecker := health.NewChecker(Version)
@@ -337,7 +573,7 @@ func initialize(ctx context.Context) error {
 
 	// Log startup information
 	log.Info("initialization complete",
-		"config_file", cfgFile,
+		"config_file", config.GetConfigPath(),
 		"debug", cfg.App.Debug,
 	)
 
@@ -355,6 +591,16 @@ func cleanup() {
 	log := logger.With("cleanup")
 	log.Info("performing cleanup")
 
+	if stopMetrics != nil {
+		stopMetrics()
+		stopMetrics = nil
+	}
+
+	// Usage stays on this machine; privacy.share_analytics does not change that
+	if err := db.FlushUsage(context.Background(), config.GetDatabasePath(), metrics.TakeUsage()); err != nil {
+		log.Debug("failed to record usage", "error", err)
+	}
+
 	// Flush logger
 	if err := logger.Get().Sync(); err != nil {
 		// Ignore sync errors
@@ -363,3 +609,29 @@ func cleanup() {
 
 	log.Info("cleanup complete")
 }
+
+// metricsAddrEnv sets --metrics-addr from the environment
+const metricsAddrEnv = "WUT_METRICS_ADDR"
+
+// startMetricsServer serves the metrics as JSON and the net/http/pprof
+// profiles when --metrics-addr or WUT_METRICS_ADDR is set. An address
+// without a host binds to localhost only.
+func startMetricsServer(log *logger.Logger) {
+	addr := metricsAddr
+	if addr == "" {
+		addr = os.Getenv(metricsAddrEnv)
+	}
+	if addr == "" || stopMetrics != nil {
+		return
+	}
+
+	bound, stop, err := metrics.Get().Serve(addr)
+	if err != nil {
+		log.Warn("failed to start metrics server", "addr", addr, "error", err)
+		fmt.Fprintf(os.Stderr, "wut: metrics server: %v\n", err)
+		return
+	}
+	stopMetrics = stop
+	log.Info("serving metrics and pprof", "addr", bound.String())
+	fmt.Fprintf(os.Stderr, "wut: metrics on http://%s/metrics, profiles on http://%s/debug/pprof/\n", bound, bound)
+}
@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	"wut/internal/config"
+)
+
+func TestUnknownCommandError(t *testing.T) {
+	root := &cobra.Command{Use: "wut"}
+	run := func(*cobra.Command, []string) {}
+	root.AddCommand(
+		&cobra.Command{Use: "suggest", Run: run},
+		&cobra.Command{Use: "history", Aliases: []string{"hist"}, Run: run},
+		&cobra.Command{Use: "explain", Run: run},
+		&cobra.Command{Use: "secret", Hidden: true, Run: run},
+	)
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"suggets"}, "Did you mean 'suggest'?\nRun 'wut suggest' to run it."},
+		{[]string{"hsit", "git"}, "Did you mean 'history'?\nRun 'wut history git' to run it."},
+		{[]string{"expl"}, "Did you mean 'explain'?\nRun 'wut explain' to run it."},
+		{[]string{"secert"}, "Run 'wut --help' for usage."},
+		{[]string{"zzzzz"}, "Run 'wut --help' for usage."},
+	}
+	for _, tt := range tests {
+		err := unknownCommandError(root, tt.args)
+		if !errors.Is(err, errUnknownCommand) {
+			t.Fatalf("unknownCommandError(%q) = %v, want errUnknownCommand", tt.args, err)
+		}
+		if !strings.HasPrefix(err.Error(), `unknown command "`+tt.args[0]+`" for "wut"`) || !strings.HasSuffix(err.Error(), tt.want) {
+			t.Errorf("unknownCommandError(%q) = %q, want it to end in %q", tt.args, err, tt.want)
+		}
+	}
+}
+
+func TestImplicitSuggestArgs(t *testing.T) {
+	root := &cobra.Command{Use: "wut", Args: cobra.ArbitraryArgs, Run: func(*cobra.Command, []string) {}}
+	root.PersistentFlags().String("config", "", "")
+	root.PersistentFlags().BoolP("debug", "d", false, "")
+	run := func(*cobra.Command, []string) {}
+	root.AddCommand(
+		&cobra.Command{Use: "suggest", Run: run},
+		&cobra.Command{Use: "history", Run: run},
+		&cobra.Command{Use: "doctor", Run: run},
+	)
+
+	tests := []struct {
+		args    []string
+		suggest bool
+	}{
+		{[]string{"how", "do", "i", "see", "open", "ports", "--limit", "5"}, true},
+		{[]string{"--config", "wut.yaml", "-d", "list", "files", "--json"}, true},
+		{[]string{"docker"}, true},
+		{[]string{"hstory"}, false},
+		{[]string{"history", "git"}, false},
+		{[]string{"--debug"}, false},
+		{[]string{cobra.ShellCompRequestCmd, "how"}, false},
+	}
+	for _, tt := range tests {
+		got := implicitSuggestArgs(root, tt.args)
+		want := tt.args
+		if tt.suggest {
+			want = append([]string{"suggest"}, tt.args...)
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("implicitSuggestArgs(%q) = %q, want %q", tt.args, got, want)
+		}
+	}
+}
+
+func TestResolveConfigFile(t *testing.T) {
+	t.Setenv(configFileEnv, "")
+	if got := resolveConfigFile(""); got != "" {
+		t.Errorf("resolveConfigFile() with nothing set = %q, want the default", got)
+	}
+
+	t.Setenv(configFileEnv, "/env/wut.yaml")
+	if got := resolveConfigFile(""); got != "/env/wut.yaml" {
+		t.Errorf("resolveConfigFile() = %q, want %s", got, configFileEnv)
+	}
+	if got := resolveConfigFile("/flag/wut.yaml"); got != "/flag/wut.yaml" {
+		t.Errorf("resolveConfigFile(--config) = %q, want the flag over %s", got, configFileEnv)
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	config.Set(&config.Config{})
+	t.Cleanup(func() { config.Set(&config.Config{}) })
+
+	// A failing command WUT runs makes WUT exit with the same status
+	err := executeCommand(context.Background(), nil, "exit 3")
+	if code, report := exitCode(err); code != 3 || report {
+		t.Errorf("exitCode(%v) = %d, %v; want 3 without a report", err, code, report)
+	}
+
+	tests := []struct {
+		err        error
+		wantCode   int
+		wantReport bool
+	}{
+		{fmt.Errorf("fix: %w", exitStatus(2)), 2, false},
+		{errUnknownCommand, 1, false},
+		{errors.New("boom"), 1, true},
+	}
+	for _, tt := range tests {
+		if code, report := exitCode(tt.err); code != tt.wantCode || report != tt.wantReport {
+			t.Errorf("exitCode(%v) = %d, %v; want %d, %v", tt.err, code, report, tt.wantCode, tt.wantReport)
+		}
+	}
+}
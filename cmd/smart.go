@@ -4,19 +4,23 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"os"
 	"strings"
 	"time"
 
+	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/spf13/cobra"
 
 	"wut/internal/config"
 	appctx "wut/internal/context"
-	"wut/internal/corrector"
 	"wut/internal/db"
 	"wut/internal/logger"
+	"wut/internal/metrics"
 	"wut/internal/smart"
+	"wut/internal/terminal"
 	"wut/internal/ui"
+	"wut/pkg/wut"
 )
 
 // smartCmd provides intelligent, context-aware command suggestions
@@ -25,17 +29,27 @@ var smartCmd = &cobra.Command{
 	Short: "Smart command suggestions based on context",
 	Long: `Get intelligent command suggestions based on your project context,
 command history, and current directory. WUT will detect your project type
-and suggest the most relevant commands.`,
+and suggest the most relevant commands.
+
+Without a query, wut smart opens a dashboard: the detected project types,
+git branch and status and Compose project at the top, ranked suggestions
+below them, and a bar of quick actions such as committing everything,
+pushing, running the tests or starting the Compose services. The dashboard
+follows the directory as it changes. Press enter, or the number of a quick
+action, to run a command after confirming it. Without a terminal the same
+information is printed as text.`,
 	Example: `  wut smart
   wut smart git
-  wut smart "docker build"`,
+  wut smart "docker build"
+  wut smart git --explain-ranking`,
 	RunE: runSmart,
 }
 
 var (
-	smartLimit   int
-	smartExec    bool
-	smartCorrect bool
+	smartLimit          int
+	smartExec           bool
+	smartCorrect        bool
+	smartExplainRanking bool
 )
 
 func init() {
@@ -44,6 +58,7 @@ func init() {
 	smartCmd.Flags().IntVarP(&smartLimit, "limit", "l", 0, "maximum suggestions to show (0 = unlimited)")
 	smartCmd.Flags().BoolVarP(&smartExec, "exec", "e", false, "execute selected command")
 	smartCmd.Flags().BoolVarP(&smartCorrect, "correct", "c", true, "auto-correct typos")
+	smartCmd.Flags().BoolVar(&smartExplainRanking, "explain-ranking", false, "show how each suggestion's score was computed")
 }
 
 func runSmart(cmd *cobra.Command, args []string) error {
@@ -59,9 +74,17 @@ func runSmart(cmd *cobra.Command, args []string) error {
 		query = strings.Join(args, " ")
 	}
 
+	// Initialize storage once and reuse it for correction, ranking and history.
+	storage := openSmartStorage(log)
+	if storage != nil {
+		defer storage.Close()
+		hydrateHistoryFromShell(context.Background(), storage)
+	}
+	engine := smart.NewEngine(storage)
+	engine.SetExplainRanking(smartExplainRanking)
+
 	// Detect context with timeout
-	analyzer := appctx.NewAnalyzer()
-	appCtx, err := analyzer.Analyze(ctx)
+	appCtx, err := engine.Context(ctx)
 	if err != nil {
 		log.Warn("failed to detect context", "error", err)
 		appCtx = &appctx.Context{
@@ -70,33 +93,14 @@ func runSmart(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	// Initialize storage once and reuse it for correction, ranking and history.
-	storage := openSmartStorage(log)
-	if storage != nil {
-		defer storage.Close()
-		hydrateHistoryFromShell(context.Background(), storage)
-	}
-
 	// Check for typos if enabled
 	if smartCorrect && query != "" {
-		c := corrector.New()
-
-		// Optional: supply history to corrector for better matching
-		if storage != nil {
-			if history, err := storage.GetHistory(context.Background(), 100); err == nil {
-				historyCmds := make([]string, 0, len(history))
-				for _, h := range history {
-					if strings.HasPrefix(strings.ToLower(strings.TrimSpace(h.Command)), "wut ") {
-						continue
-					}
-					historyCmds = append(historyCmds, h.Command)
-				}
-				c.SetHistoryCommands(historyCmds)
-			}
+		correction, err := newCorrector(ctx, storage).Correct(ctx, query)
+		if err == nil {
+			metrics.RecordCorrection(correction != nil && !correction.Dangerous)
 		}
-
-		if correction, err := c.Correct(query); err == nil && correction != nil {
-			if correction.IsDangerous {
+		if err == nil && correction != nil {
+			if correction.Dangerous {
 				printCorrection(correction)
 				return nil // Don't proceed with dangerous commands
 			}
@@ -107,8 +111,10 @@ func runSmart(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	// Create smart engine
-	engine := smart.NewEngine(storage)
+	if query == "" && !smartExplainRanking && terminal.IsInteractive() {
+		return runSmartDashboard(cmd.Context(), engine, appCtx, storage)
+	}
+
 	fetchLimit := smartLimit
 	if fetchLimit > 0 && fetchLimit < 120 {
 		fetchLimit = 120
@@ -157,7 +163,44 @@ func runSmart(cmd *cobra.Command, args []string) error {
 		suggestions = engine.GetFallbackSuggestions(appCtx, smartLimit)
 	}
 
-	return showSmartSuggestions(query, appCtx, suggestions)
+	if smartExplainRanking {
+		if smartLimit > 0 && len(suggestions) > smartLimit {
+			suggestions = suggestions[:smartLimit]
+		}
+		printRankingExplanation(os.Stdout, query, suggestions)
+		return nil
+	}
+
+	if query == "" {
+		// Without a terminal the dashboard is printed instead
+		if smartLimit > 0 && len(suggestions) > smartLimit {
+			suggestions = suggestions[:smartLimit]
+		}
+		printSmartDashboard(os.Stdout, appCtx, suggestions, engine.QuickActions(appCtx))
+		return nil
+	}
+
+	return showSmartSuggestions(query, appCtx, suggestions, storage)
+}
+
+// runSmartDashboard shows the dashboard and runs the command picked in it
+func runSmartDashboard(ctx context.Context, engine *smart.Engine, appCtx *appctx.Context, storage *db.Storage) error {
+	engine.Preload(context.Background(), appCtx)
+
+	finalModel, err := tea.NewProgram(newSmartDashboardModel(engine, appCtx, smartLimit), tea.WithAltScreen()).Run()
+	if storage != nil {
+		// Close before running a picked command so its history can be recorded
+		storage.Close()
+	}
+	if err != nil {
+		return fmt.Errorf("error running smart dashboard: %w", err)
+	}
+	if m, ok := finalModel.(smartDashboardModel); ok && m.picked != "" {
+		err := runPickedCommand(ctx, m.picked)
+		engine.CommandExecuted(appCtx.WorkingDir)
+		return err
+	}
+	return nil
 }
 
 func openSmartStorage(log *logger.Logger) *db.Storage {
@@ -185,12 +228,12 @@ func openSmartStorage(log *logger.Logger) *db.Storage {
 	return nil
 }
 
-func printCorrection(c *corrector.Correction) {
-	if c.IsDangerous {
+func printCorrection(c *wut.Correction) {
+	if c.Dangerous {
 		warningStyle := lipgloss.NewStyle().
 			Bold(true).
-			Foreground(lipgloss.Color("#EF4444")).
-			Background(lipgloss.Color("#FEE2E2"))
+			Foreground(ui.ColorError).
+			Background(ui.ColorErrorBg)
 		fmt.Println(warningStyle.Render(" " + c.Explanation + " "))
 		fmt.Println()
 		return
@@ -198,7 +241,7 @@ func printCorrection(c *corrector.Correction) {
 
 	if c.Corrected != "" && c.Corrected != c.Original {
 		correctionStyle := lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#F59E0B"))
+			Foreground(ui.ColorWarning)
 		fmt.Printf("%s %s → %s\n\n",
 			correctionStyle.Render("🤔 Did you mean:"),
 			c.Original,
@@ -206,7 +249,7 @@ func printCorrection(c *corrector.Correction) {
 	}
 }
 
-func shouldApplySmartCorrection(original string, correction *corrector.Correction) bool {
+func shouldApplySmartCorrection(original string, correction *wut.Correction) bool {
 	if correction == nil {
 		return false
 	}
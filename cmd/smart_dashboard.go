@@ -0,0 +1,416 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/reflow/truncate"
+
+	appctx "wut/internal/context"
+	"wut/internal/smart"
+	"wut/internal/ui"
+)
+
+// smartDashboardRefresh is how often the dashboard checks whether the
+// context changed. Engine.Context only re-analyses the directory when its
+// stamp changed, so a check is cheap.
+const smartDashboardRefresh = 2 * time.Second
+
+// smartDashboardLimit is the number of suggestions shown without --limit
+const smartDashboardLimit = 10
+
+// dashboardFocus is the dashboard section the arrow keys move in
+type dashboardFocus int
+
+const (
+	focusSuggestions dashboardFocus = iota
+	focusActions
+)
+
+type smartDashboardModel struct {
+	engine       *smart.Engine
+	context      *appctx.Context
+	suggestions  []smart.Suggestion
+	actions      []smart.Suggestion
+	limit        int
+	focus        dashboardFocus
+	cursor       int
+	actionCursor int
+	loading      bool
+	width        int
+	height       int
+	picked       string // command to run after the dashboard quits
+}
+
+// smartDashboardMsg carries a context and the suggestions and quick actions
+// for it. A nil context means it has not changed since the last load.
+type smartDashboardMsg struct {
+	context     *appctx.Context
+	suggestions []smart.Suggestion
+	actions     []smart.Suggestion
+}
+
+type smartDashboardTickMsg struct{}
+
+// newSmartDashboardModel starts the dashboard on the fallback suggestions
+// for ctx, which need no history lookups, until the ranked ones load
+func newSmartDashboardModel(engine *smart.Engine, ctx *appctx.Context, limit int) smartDashboardModel {
+	if limit <= 0 {
+		limit = smartDashboardLimit
+	}
+	return smartDashboardModel{
+		engine:      engine,
+		context:     ctx,
+		suggestions: engine.GetFallbackSuggestions(ctx, limit),
+		actions:     engine.QuickActions(ctx),
+		limit:       limit,
+		loading:     true,
+	}
+}
+
+// loadSmartDashboard reads the current context and, when it is not current,
+// ranks the suggestions for it. A nil current always loads.
+func loadSmartDashboard(engine *smart.Engine, current *appctx.Context, limit int) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+
+		data, err := engine.Context(ctx)
+		if err != nil || data == current {
+			return smartDashboardMsg{}
+		}
+		if current != nil {
+			// Cached suggestions were ranked for the old git status
+			engine.ClearSuggestions()
+		}
+		suggestions, err := engine.Suggest(ctx, "", data, limit)
+		if err != nil || len(suggestions) == 0 {
+			suggestions = engine.GetFallbackSuggestions(data, limit)
+		}
+		return smartDashboardMsg{
+			context:     data,
+			suggestions: suggestions,
+			actions:     engine.QuickActions(data),
+		}
+	}
+}
+
+func tickSmartDashboard() tea.Cmd {
+	return tea.Tick(smartDashboardRefresh, func(_ time.Time) tea.Msg {
+		return smartDashboardTickMsg{}
+	})
+}
+
+func (m smartDashboardModel) Init() tea.Cmd {
+	return tea.Batch(loadSmartDashboard(m.engine, nil, m.limit), tickSmartDashboard())
+}
+
+func (m smartDashboardModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+	case smartDashboardMsg:
+		m.loading = false
+		if msg.context != nil {
+			m.context = msg.context
+			m.suggestions = msg.suggestions
+			m.actions = msg.actions
+			m.cursor = min(m.cursor, max(len(m.suggestions)-1, 0))
+			m.actionCursor = min(m.actionCursor, max(len(m.actions)-1, 0))
+			if len(m.actions) == 0 {
+				m.focus = focusSuggestions
+			}
+		}
+	case smartDashboardTickMsg:
+		if m.loading {
+			return m, tickSmartDashboard()
+		}
+		m.loading = true
+		return m, tea.Batch(loadSmartDashboard(m.engine, m.context, m.limit), tickSmartDashboard())
+	case tea.KeyMsg:
+		switch key := msg.String(); key {
+		case "ctrl+c", "q", "esc":
+			return m, tea.Quit
+		case "tab", "shift+tab":
+			if m.focus == focusSuggestions && len(m.actions) > 0 {
+				m.focus = focusActions
+			} else {
+				m.focus = focusSuggestions
+			}
+		case "up", "k":
+			if m.focus == focusSuggestions && m.cursor > 0 {
+				m.cursor--
+			}
+		case "down", "j":
+			if m.focus == focusSuggestions && m.cursor < len(m.suggestions)-1 {
+				m.cursor++
+			}
+		case "left", "h":
+			if m.focus == focusActions && m.actionCursor > 0 {
+				m.actionCursor--
+			}
+		case "right", "l":
+			if m.focus == focusActions && m.actionCursor < len(m.actions)-1 {
+				m.actionCursor++
+			}
+		case "r":
+			if m.loading {
+				break
+			}
+			if m.context != nil {
+				m.engine.InvalidateContext(m.context.WorkingDir)
+			}
+			m.loading = true
+			return m, loadSmartDashboard(m.engine, nil, m.limit)
+		case "enter":
+			if m.focus == focusActions && m.actionCursor < len(m.actions) {
+				m.picked = m.actions[m.actionCursor].Command
+				return m, tea.Quit
+			}
+			if m.focus == focusSuggestions && m.cursor < len(m.suggestions) {
+				m.picked = m.suggestions[m.cursor].Command
+				return m, tea.Quit
+			}
+		default:
+			// Digits run the quick action with that number
+			if len(key) == 1 && key[0] >= '1' && key[0] <= '9' {
+				if i := int(key[0] - '1'); i < len(m.actions) {
+					m.picked = m.actions[i].Command
+					return m, tea.Quit
+				}
+			}
+		}
+	}
+	return m, nil
+}
+
+func (m smartDashboardModel) View() string {
+	w := m.width
+	if w <= 0 {
+		w = 100
+	}
+	boxPadX := 2
+	if w < 60 {
+		boxPadX = 1
+	}
+	boxWidth := max(w-2, 30)
+	innerWidth := max(boxWidth-2-boxPadX*2, 24)
+
+	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(ui.ColorBrand)
+	sectionStyle := lipgloss.NewStyle().Bold(true).Foreground(ui.ColorPrimary)
+	metaStyle := lipgloss.NewStyle().Foreground(ui.ColorSubtle)
+	sourceStyle := lipgloss.NewStyle().Foreground(ui.ColorBrandSoft)
+	descStyle := lipgloss.NewStyle().Foreground(ui.ColorMuted)
+
+	var sb strings.Builder
+	title := headerStyle.Render("🧭 Smart Dashboard")
+	if m.loading {
+		title += "  " + descStyle.Render("refreshing…")
+	}
+	sb.WriteString(title + "\n\n")
+
+	sb.WriteString(sectionStyle.Render("Context") + "\n")
+	for _, line := range smartDashboardContext(m.context) {
+		sb.WriteString("  " + metaStyle.Render(truncate.StringWithTail(line, uint(innerWidth-2), "...")) + "\n")
+	}
+	sb.WriteString("\n")
+
+	sb.WriteString(sectionStyle.Render("Suggestions") + "\n")
+	// Leave room for the context, the quick actions and the footer
+	rows := len(m.suggestions)
+	if m.height > 0 {
+		rows = min(rows, max(m.height-20, 3))
+	}
+	start := max(m.cursor-rows+1, 0)
+	showSource := w >= 65
+	showDesc := w >= 80
+	for i := start; i < start+rows && i < len(m.suggestions); i++ {
+		suggestion := m.suggestions[i]
+		cursor := "  "
+		cmdStyle := lipgloss.NewStyle().Bold(true).Foreground(ui.ColorSuccess)
+		if m.focus == focusSuggestions && m.cursor == i {
+			cursor = "👉"
+			cmdStyle = cmdStyle.Foreground(ui.ColorOnAccent).Background(ui.ColorPrimary).Padding(0, 1)
+		}
+		line := cursor + " "
+		if showSource {
+			line += sourceStyle.Render(fmt.Sprintf("%-11s", "["+compactSuggestionSource(suggestion.Source)+"]")) + " "
+		}
+		avail := max(innerWidth-lipgloss.Width(line)-2, 12)
+		line += cmdStyle.Render(truncate.StringWithTail(suggestion.Command, uint(avail), "..."))
+		if showDesc && suggestion.Description != "" {
+			if room := innerWidth - lipgloss.Width(line) - 3; room > 10 {
+				line += "  " + descStyle.Render(truncate.StringWithTail(suggestion.Description, uint(room), "..."))
+			}
+		}
+		sb.WriteString(line + "\n")
+	}
+	if len(m.suggestions) == 0 {
+		sb.WriteString("  " + descStyle.Render("No suggestions for this directory") + "\n")
+	}
+	sb.WriteString("\n")
+
+	sb.WriteString(sectionStyle.Render("Quick actions") + "\n")
+	if len(m.actions) == 0 {
+		sb.WriteString("  " + descStyle.Render("Nothing to do right now") + "\n")
+	}
+	var bar []string
+	for i, action := range m.actions {
+		style := lipgloss.NewStyle().Foreground(ui.ColorText).Background(ui.ColorSurface).Padding(0, 1)
+		if m.focus == focusActions && m.actionCursor == i {
+			style = style.Bold(true).Foreground(ui.ColorOnAccent).Background(ui.ColorPrimary)
+		}
+		label := action.Description
+		if i < 9 {
+			label = fmt.Sprintf("%d %s %s", i+1, action.Icon, label)
+		}
+		bar = append(bar, style.Render(label))
+	}
+	if len(bar) > 0 {
+		sb.WriteString("  " + wrapDashboardBar(bar, innerWidth-2) + "\n")
+		if m.focus == focusActions && m.actionCursor < len(m.actions) {
+			sb.WriteString("  " + descStyle.Render("$ "+m.actions[m.actionCursor].Command) + "\n")
+		}
+	}
+	sb.WriteString("\n")
+
+	footerStyle := lipgloss.NewStyle().Foreground(ui.ColorHighlight).Bold(true)
+	var footer string
+	if w >= 110 {
+		footer = "[↑/↓] Navigate | [tab] Switch section | [1-9] Quick action | [enter] Run | [r] Refresh | [q] Quit"
+	} else if w >= 60 {
+		footer = "↑/↓ nav | tab section | 1-9 action | enter run | r refresh | q quit"
+	} else {
+		footer = "↑/↓ | tab | 1-9 | enter | r | q"
+	}
+	sb.WriteString(footerStyle.Render(footer))
+
+	boxStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(ui.ColorBrand).
+		Padding(1, boxPadX).
+		Width(boxWidth)
+	return boxStyle.Render(sb.String())
+}
+
+// wrapDashboardBar lays out the quick-action buttons in rows no wider than
+// width
+func wrapDashboardBar(buttons []string, width int) string {
+	var rows []string
+	row := ""
+	for _, button := range buttons {
+		if row != "" && lipgloss.Width(row)+1+lipgloss.Width(button) > width {
+			rows = append(rows, row)
+			row = ""
+		}
+		if row != "" {
+			row += " "
+		}
+		row += button
+	}
+	rows = append(rows, row)
+	return strings.Join(rows, "\n  ")
+}
+
+// smartDashboardContext describes the project types, git state and Compose
+// project of ctx, one line each
+func smartDashboardContext(ctx *appctx.Context) []string {
+	if ctx == nil {
+		return []string{"No context available"}
+	}
+
+	workspace := filepath.Base(ctx.WorkingDir)
+	if workspace == "." || workspace == "/" || workspace == "\\" || workspace == "" {
+		workspace = ctx.WorkingDir
+	}
+	project := "📁 " + workspace
+	var types []string
+	for _, p := range ctx.Projects {
+		types = append(types, p.Type)
+	}
+	if len(types) == 0 && ctx.ProjectType != "" && ctx.ProjectType != "unknown" {
+		types = append([]string{ctx.ProjectType}, ctx.SecondaryTypes...)
+	}
+	if len(types) > 0 {
+		project += "  ·  " + strings.Join(types, ", ")
+	} else {
+		project += "  ·  no project detected"
+	}
+	lines := []string{project}
+
+	if !ctx.IsGitRepo {
+		lines = append(lines, "🌿 Not a git repository")
+	} else {
+		status := ctx.GitStatus
+		branch := ctx.GitBranch
+		if branch == "" {
+			branch = "detached HEAD"
+		}
+		parts := []string{"🌿 " + branch}
+		if status.Upstream != "" {
+			parts[0] += " → " + status.Upstream
+		}
+		if status.Operation != "" {
+			parts = append(parts, status.Operation+" in progress")
+		}
+		for _, count := range []struct {
+			n    int
+			noun string
+		}{
+			{len(status.ConflictedFiles), "conflicted"},
+			{len(status.StagedFiles), "staged"},
+			{len(status.ModifiedFiles), "modified"},
+			{len(status.UntrackedFiles), "untracked"},
+		} {
+			if count.n > 0 {
+				parts = append(parts, fmt.Sprintf("%d %s", count.n, count.noun))
+			}
+		}
+		if status.Ahead > 0 {
+			parts = append(parts, fmt.Sprintf("↑%d", status.Ahead))
+		}
+		if status.Behind > 0 {
+			parts = append(parts, fmt.Sprintf("↓%d", status.Behind))
+		}
+		if len(parts) == 1 && status.IsClean {
+			parts = append(parts, "clean")
+		}
+		lines = append(lines, strings.Join(parts, "  ·  "))
+	}
+
+	switch {
+	case ctx.ComposeCommand != "" && len(ctx.ComposeServices) > 0:
+		lines = append(lines, fmt.Sprintf("🐳 %s  ·  %s", ctx.ComposeCommand, strings.Join(ctx.ComposeServices, ", ")))
+	case ctx.ComposeCommand != "":
+		lines = append(lines, "🐳 "+ctx.ComposeCommand+"  ·  no services")
+	default:
+		lines = append(lines, "🐳 No Compose file")
+	}
+	return lines
+}
+
+// printSmartDashboard prints the dashboard as plain text for terminals that
+// cannot host it
+func printSmartDashboard(w io.Writer, ctx *appctx.Context, suggestions, actions []smart.Suggestion) {
+	fmt.Fprintln(w, "Context:")
+	for _, line := range smartDashboardContext(ctx) {
+		fmt.Fprintln(w, "  "+line)
+	}
+	fmt.Fprintln(w)
+	SimpleOutput(w, "", suggestions)
+	if len(actions) == 0 {
+		return
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Quick actions:")
+	for i, action := range actions {
+		fmt.Fprintf(w, "  %d. %s  # %s\n", i+1, action.Command, action.Description)
+	}
+}
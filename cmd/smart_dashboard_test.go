@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"wut/internal/config"
+	appctx "wut/internal/context"
+	"wut/internal/smart"
+)
+
+func dashboardContext() *appctx.Context {
+	return &appctx.Context{
+		WorkingDir:      "/src/shop",
+		IsGitRepo:       true,
+		GitBranch:       "main",
+		ProjectType:     "go",
+		Projects:        []appctx.Project{{Type: "go"}, {Type: "docker"}},
+		ComposeCommand:  "docker compose",
+		ComposeServices: []string{"web", "db"},
+		GitStatus: appctx.GitStatus{
+			Branch:        "main",
+			Upstream:      "origin/main",
+			ModifiedFiles: []string{"main.go"},
+			Ahead:         1,
+		},
+	}
+}
+
+func TestSmartDashboardModel(t *testing.T) {
+	config.Set(&config.Config{})
+	engine := smart.NewEngine(nil)
+
+	key := func(m smartDashboardModel, k string) (smartDashboardModel, tea.Cmd) {
+		msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+		switch k {
+		case "enter":
+			msg = tea.KeyMsg{Type: tea.KeyEnter}
+		case "tab":
+			msg = tea.KeyMsg{Type: tea.KeyTab}
+		}
+		next, cmd := m.Update(msg)
+		return next.(smartDashboardModel), cmd
+	}
+
+	m := newSmartDashboardModel(engine, dashboardContext(), 0)
+	if len(m.suggestions) == 0 || len(m.actions) == 0 {
+		t.Fatalf("new dashboard has %d suggestions and %d actions", len(m.suggestions), len(m.actions))
+	}
+	view := m.View()
+	for _, want := range []string{"main → origin/main", "1 modified", "↑1", "docker compose  ·  web, db", "Run the tests"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("View() is missing %q:\n%s", want, view)
+		}
+	}
+
+	// A load that found the context unchanged keeps what is shown
+	next, _ := m.Update(smartDashboardMsg{})
+	if got := next.(smartDashboardModel); got.loading || len(got.suggestions) != len(m.suggestions) {
+		t.Errorf("unchanged load: loading = %v, %d suggestions", got.loading, len(got.suggestions))
+	}
+
+	picked, cmd := key(m, "j")
+	picked, _ = key(picked, "enter")
+	if picked.picked != m.suggestions[1].Command {
+		t.Errorf("enter picked %q, want the second suggestion %q", picked.picked, m.suggestions[1].Command)
+	}
+	if cmd != nil {
+		t.Error("moving the cursor returned a command")
+	}
+
+	picked, _ = key(m, "tab")
+	picked, _ = key(picked, "l")
+	picked, cmd = key(picked, "enter")
+	if picked.picked != m.actions[1].Command || cmd == nil {
+		t.Errorf("enter on the second quick action picked %q", picked.picked)
+	}
+
+	picked, _ = key(m, "3")
+	if picked.picked != m.actions[2].Command {
+		t.Errorf("3 picked %q, want %q", picked.picked, m.actions[2].Command)
+	}
+}
+
+func TestPrintSmartDashboard(t *testing.T) {
+	config.Set(&config.Config{})
+	engine := smart.NewEngine(nil)
+	ctx := dashboardContext()
+
+	var b bytes.Buffer
+	printSmartDashboard(&b, ctx, engine.GetFallbackSuggestions(ctx, 3), engine.QuickActions(ctx))
+	out := b.String()
+	for _, want := range []string{"📁 shop  ·  go, docker", "🌿 main → origin/main", "1. git status", "Quick actions:", "go test ./...  # Run the tests"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("printSmartDashboard() is missing %q:\n%s", want, out)
+		}
+	}
+
+	b.Reset()
+	printSmartDashboard(&b, &appctx.Context{WorkingDir: "/tmp"}, nil, nil)
+	for _, want := range []string{"no project detected", "Not a git repository", "No Compose file"} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("printSmartDashboard() outside a project is missing %q:\n%s", want, b.String())
+		}
+	}
+}
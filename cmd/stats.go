@@ -5,11 +5,14 @@ import (
 	"fmt"
 	"math"
 	"os"
+	"sort"
 	"strings"
 
 	"wut/internal/config"
 	"wut/internal/db"
 	"wut/internal/logger"
+	"wut/internal/metrics"
+	"wut/internal/ui"
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/spf13/cobra"
@@ -21,30 +24,39 @@ var statsCmd = &cobra.Command{
 	Aliases: []string{"stat", "metrics", "analytics"},
 	Short:   "View WUT usage statistics and productivity metrics",
 	Long: `Display detailed productivity analytics including command usage,
-time-of-day heatmaps, top command leaderboard, and a productivity score.`,
+time-of-day heatmaps, top command leaderboard, and a productivity score.
+
+It also shows how you use WUT itself: how often each command runs, how fast
+suggestions and searches are, how often corrections are found and taken, and
+how well the caches work. These metrics are kept in the local database and
+never leave your machine.`,
+	Example: `  wut stats
+  wut stats --json`,
 	RunE: runStats,
 }
 
 func init() {
 	rootCmd.AddCommand(statsCmd)
+
+	statsCmd.Flags().BoolVar(&outputJSON, "json", false, "print the statistics as JSON")
 }
 
 // statsColors — palette used throughout the stats dashboard
 var (
-	sColPurple = lipgloss.Color("#7C3AED")
-	sColViolet = lipgloss.Color("#8B5CF6")
-	sColBlue   = lipgloss.Color("#3B82F6")
-	sColCyan   = lipgloss.Color("#06B6D4")
-	sColGreen  = lipgloss.Color("#10B981")
-	sColAmber  = lipgloss.Color("#F59E0B")
-	sColPink   = lipgloss.Color("#EC4899")
-	sColYellow = lipgloss.Color("#FCD34D")
-	sColGray   = lipgloss.Color("#6B7280")
-	sColLtGray = lipgloss.Color("#D1D5DB")
+	sColPurple = ui.ColorBrand
+	sColViolet = ui.ColorSecondary
+	sColBlue   = ui.ColorPrimary
+	sColCyan   = ui.ColorAccent
+	sColGreen  = ui.ColorSuccess
+	sColAmber  = ui.ColorWarning
+	sColPink   = ui.ColorPink
+	sColYellow = ui.ColorHighlight
+	sColGray   = ui.ColorMuted
+	sColLtGray = ui.ColorText
 )
 
 func runStats(cmd *cobra.Command, args []string) error {
-	logger.Info("generating usage stats")
+	logger.With("stats").Info("generating usage stats")
 
 	store, err := db.NewStorage(config.GetDatabasePath())
 	if err != nil {
@@ -56,6 +68,14 @@ func runStats(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("failed to get stats: %w", err)
 	}
+	usage, err := store.GetUsage(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to get usage: %w", err)
+	}
+
+	if outputJSON {
+		return writeJSON(newStatsJSON(stats, usage))
+	}
 
 	if stats.TotalExecutions == 0 {
 		emptyBox := lipgloss.NewStyle().
@@ -71,6 +91,10 @@ func runStats(cmd *cobra.Command, args []string) error {
 			)
 		fmt.Println()
 		fmt.Println(emptyBox)
+		if !usage.Empty() {
+			fmt.Println()
+			fmt.Println(renderUsagePanel(usage, 86, 36))
+		}
 		return nil
 	}
 
@@ -94,7 +118,7 @@ func runStats(cmd *cobra.Command, args []string) error {
 	// ─── Header Banner ────────────────────────────────────────────────────────
 	banner := lipgloss.NewStyle().
 		Bold(true).
-		Foreground(lipgloss.Color("#FFFFFF")).
+		Foreground(ui.ColorOnAccent).
 		Background(sColPurple).
 		Padding(0, 3).
 		Render("  📊  WUT Productivity Dashboard  ")
@@ -104,7 +128,7 @@ func runStats(cmd *cobra.Command, args []string) error {
 	fmt.Println()
 
 	// ─── Summary Cards ────────────────────────────────────────────────────────
-	cardStyle := func(bg lipgloss.Color) lipgloss.Style {
+	cardStyle := func(bg lipgloss.AdaptiveColor) lipgloss.Style {
 		return lipgloss.NewStyle().
 			Border(lipgloss.RoundedBorder()).
 			BorderForeground(bg).
@@ -198,7 +222,7 @@ func runStats(cmd *cobra.Command, args []string) error {
 	}
 
 	medals := []string{"🥇", "🥈", "🥉", " 4", " 5", " 6", " 7"}
-	barColors := []lipgloss.Color{sColPink, sColViolet, sColBlue, sColCyan, sColGreen, sColAmber, sColGray}
+	barColors := []lipgloss.AdaptiveColor{sColPink, sColViolet, sColBlue, sColCyan, sColGreen, sColAmber, sColGray}
 
 	var lbLines []string
 	lbLines = append(lbLines, sectionTitle("🏆", "Top Command Leaderboard"))
@@ -251,7 +275,7 @@ func runStats(cmd *cobra.Command, args []string) error {
 		"Night (00:00-06:00)",
 	}
 	timeIcons := []string{"🌅", "☀️ ", "🌆", "🌙"}
-	timeColors := []lipgloss.Color{sColAmber, sColCyan, sColViolet, sColBlue}
+	timeColors := []lipgloss.AdaptiveColor{sColAmber, sColCyan, sColViolet, sColBlue}
 
 	timeMax := 0
 	for _, k := range timeKeys {
@@ -299,11 +323,100 @@ func runStats(cmd *cobra.Command, args []string) error {
 	hmBox := panelBorder.Width(boxLayoutWidth).Render(strings.Join(hmLines, "\n"))
 	fmt.Println(hmBox)
 
+	if !usage.Empty() {
+		fmt.Println()
+		fmt.Println(renderUsagePanel(usage, boxLayoutWidth, maxBarWidth))
+	}
+
 	// ─── Footer ───────────────────────────────────────────────────────────────
 	fmt.Println()
 	fmt.Println(muted("  💡 Tip: Use ") +
-		lipgloss.NewStyle().Foreground(sColCyan).Render("wut bookmark add \"cmd\" -l label") +
+		lipgloss.NewStyle().Foreground(sColCyan).Render("wut bookmark add \"cmd\" -t tag") +
 		muted(" to save your favourite commands."))
 	fmt.Println()
 	return nil
 }
+
+// renderUsagePanel shows how WUT itself is used: runs per command, latency,
+// corrections and cache hit ratios
+func renderUsagePanel(usage *metrics.Usage, width, maxBarWidth int) string {
+	title := lipgloss.NewStyle().Bold(true).Foreground(sColViolet).Render("⚙️  How You Use WUT")
+	label := func(s string) string {
+		return lipgloss.NewStyle().Foreground(sColLtGray).Render(fmt.Sprintf("%-22s", s))
+	}
+	value := func(s string) string {
+		return lipgloss.NewStyle().Bold(true).Foreground(sColYellow).Render(s)
+	}
+	muted := func(s string) string {
+		return lipgloss.NewStyle().Foreground(sColGray).Render(s)
+	}
+	percent := func(r float64) string {
+		if r < 0 {
+			return muted("–")
+		}
+		return value(fmt.Sprintf("%.0f%%", r*100))
+	}
+
+	lines := []string{title, ""}
+
+	// Runs per command, most used first
+	invocations := usage.Invocations()
+	names := make([]string, 0, len(invocations))
+	var most int64
+	for name, n := range invocations {
+		names = append(names, name)
+		most = max(most, n)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if invocations[names[i]] != invocations[names[j]] {
+			return invocations[names[i]] > invocations[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	if len(names) > 7 {
+		names = names[:7]
+	}
+	for _, name := range names {
+		n := invocations[name]
+		w := max(1, int(math.Round(float64(n)/float64(most)*float64(maxBarWidth))))
+		bar := lipgloss.NewStyle().Foreground(sColCyan).Render(strings.Repeat("█", w)) + strings.Repeat(" ", maxBarWidth-w)
+		lines = append(lines, fmt.Sprintf("  %s %s  %s", label("wut "+name), bar, value(fmt.Sprintf("%5d", n))))
+	}
+	if len(names) > 0 {
+		lines = append(lines, "")
+	}
+
+	latency := func(name, text string) {
+		if h, ok := usage.Histograms[name]; ok && h.Count > 0 {
+			lines = append(lines, fmt.Sprintf("  %s %s %s", label(text), value(fmt.Sprintf("%.0f ms", h.Mean())), muted(fmt.Sprintf("avg over %d", h.Count))))
+		}
+	}
+	latency(metrics.LatencySuggest, "Suggest latency")
+	latency(metrics.LatencySearch, "Search latency")
+
+	lines = append(lines,
+		fmt.Sprintf("  %s %s", label("Corrections found"), percent(usage.Ratio(metrics.CorrectionsHit, metrics.CorrectionsMiss))),
+		fmt.Sprintf("  %s %s", label("Corrections accepted"), percent(usage.Ratio(metrics.CorrectionsAccepted, metrics.CorrectionsRejected))),
+	)
+
+	ratios := usage.CacheHitRatios()
+	caches := make([]string, 0, len(ratios))
+	for name := range ratios {
+		caches = append(caches, name)
+	}
+	sort.Strings(caches)
+	for _, name := range caches {
+		lines = append(lines, fmt.Sprintf("  %s %s", label("Cache: "+name), percent(ratios[name])))
+	}
+
+	if !usage.Since.IsZero() {
+		lines = append(lines, "", muted("  Recorded locally since "+usage.Since.Format("2006-01-02")))
+	}
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(sColViolet).
+		Padding(0, 1).
+		Width(width).
+		Render(strings.Join(lines, "\n"))
+}
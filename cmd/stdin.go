@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"wut/internal/ui"
+)
+
+// stdinArg is the argument that makes wut fix and wut explain read their
+// commands from standard input
+const stdinArg = "-"
+
+// stdinCommands returns the commands to read from standard input, one per
+// non-blank line, and whether they were read from it. Standard input is read
+// when args is just "-", or when there are no args and it is piped.
+func stdinCommands(cmd *cobra.Command, args []string) ([]string, bool, error) {
+	in := cmd.InOrStdin()
+	switch {
+	case len(args) == 1 && args[0] == stdinArg:
+	case len(args) == 0 && isPiped(in):
+	default:
+		return nil, false, nil
+	}
+
+	data, err := io.ReadAll(in)
+	if err != nil {
+		return nil, true, fmt.Errorf("failed to read standard input: %w", err)
+	}
+	commands := splitCommands(string(data))
+	if len(commands) == 0 {
+		return nil, true, errors.New("no command on standard input")
+	}
+	return commands, true, nil
+}
+
+// isPiped reports whether in is a pipe or a redirected file, rather than a
+// terminal or /dev/null
+func isPiped(in io.Reader) bool {
+	f, ok := in.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeNamedPipe != 0 || info.Mode().IsRegular()
+}
+
+// splitCommands returns each non-blank line of input as a command, trimmed
+// like a shell argument would be
+func splitCommands(input string) []string {
+	var commands []string
+	for line := range strings.Lines(input) {
+		if line = strings.TrimSpace(line); line != "" {
+			commands = append(commands, line)
+		}
+	}
+	return commands
+}
+
+// printCommandHeader separates the output for the i-th of n commands read
+// from standard input
+func printCommandHeader(i, n int, command string) {
+	if i > 0 {
+		fmt.Println()
+	}
+	fmt.Println(ui.Muted(fmt.Sprintf("── [%d/%d] %s", i+1, n, command)))
+}
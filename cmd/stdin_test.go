@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestStdinCommands(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		input     string
+		want      []string
+		fromStdin bool
+		wantErr   bool
+	}{
+		{"dash", []string{"-"}, "gti status\n", []string{"gti status"}, true, false},
+		{"lines", []string{"-"}, "gti status\r\n\n  doker ps  \n\n", []string{"gti status", "doker ps"}, true, false},
+		{"no trailing newline", []string{"-"}, "ls -la", []string{"ls -la"}, true, false},
+		{"empty", []string{"-"}, "\n \n", nil, true, true},
+		{"args", []string{"gti", "status"}, "doker ps\n", nil, false, false},
+		// A reader that is not a pipe, like a terminal, is not read without "-"
+		{"not piped", nil, "doker ps\n", nil, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := &cobra.Command{}
+			cmd.SetIn(strings.NewReader(tt.input))
+			got, fromStdin, err := stdinCommands(cmd, tt.args)
+			if (err != nil) != tt.wantErr || fromStdin != tt.fromStdin || !slices.Equal(got, tt.want) {
+				t.Errorf("stdinCommands(%q) = %q, %v, %v; want %q, %v, error %v",
+					tt.args, got, fromStdin, err, tt.want, tt.fromStdin, tt.wantErr)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
@@ -12,8 +13,14 @@ import (
 	"github.com/spf13/cobra"
 
 	"wut/internal/config"
+	appctx "wut/internal/context"
+	"wut/internal/corrector"
+	"wut/internal/daemon"
 	"wut/internal/db"
 	"wut/internal/logger"
+	"wut/internal/smart"
+	"wut/internal/terminal"
+	"wut/internal/ui"
 )
 
 // suggestCmd represents the suggest command
@@ -25,24 +32,37 @@ command line help database. Provides concise, practical examples
 for thousands of commands.
 
 If no command is provided, enters interactive mode with live search.
+Describe a task in words ("free up docker disk space") to get matching
+commands instead of a cheat sheet. When the words fit several tools, name
+the one you mean with --category, e.g. --category docker.
 
 Uses local database if available, otherwise fetches from online.
-Auto-detects offline mode when no internet connection.`,
+Auto-detects offline mode when no internet connection.
+
+When 'wut daemon' is running, task descriptions are answered by it, which
+skips loading the suggestion engine; --no-daemon answers in-process.`,
 	Example: `  wut suggest git
   wut suggest docker
   wut suggest              # Interactive mode
   wut suggest npm --raw    # Plain text output
   wut suggest git --offline # Force offline mode
-  wut suggest git --exec   # Execute selected command`,
+  wut suggest git --exec   # Execute selected command
+  wut suggest tar --copy   # Copy selected command to the clipboard
+  wut suggest git --json   # Machine-readable output
+  wut suggest "free up docker disk space"
+  wut suggest "show logs" --category docker`,
 	RunE: runSuggest,
 }
 
 var (
-	suggestRaw     bool
-	suggestQuiet   bool
-	suggestLimit   int
-	suggestOffline bool
-	suggestExec    bool
+	suggestRaw      bool
+	suggestQuiet    bool
+	suggestLimit    int
+	suggestOffline  bool
+	suggestExec     bool
+	suggestCopy     bool
+	suggestCategory string
+	suggestNoDaemon bool
 )
 
 func init() {
@@ -53,6 +73,14 @@ func init() {
 	suggestCmd.Flags().IntVarP(&suggestLimit, "limit", "l", 10, "maximum number of examples to show")
 	suggestCmd.Flags().BoolVarP(&suggestOffline, "offline", "o", false, "force offline mode (use local database only)")
 	suggestCmd.Flags().BoolVarP(&suggestExec, "exec", "e", false, "execute the selected command after TUI closes")
+	suggestCmd.Flags().BoolVarP(&suggestCopy, "copy", "c", false, "copy the selected command to the clipboard")
+	suggestCmd.Flags().BoolVar(&execForce, "force", false, "skip the confirmation summary before executing")
+	suggestCmd.Flags().BoolVar(&outputJSON, "json", false, "print suggestions as JSON")
+	suggestCmd.Flags().StringVar(&suggestCategory, "category", "", "only match task descriptions to commands of this tool (docker, git, ...)")
+	suggestCmd.Flags().BoolVar(&suggestNoDaemon, "no-daemon", false, "answer in-process even when wut daemon is running")
+
+	suggestCmd.ValidArgsFunction = completePageNames
+	_ = suggestCmd.RegisterFlagCompletionFunc("category", completeIntentCategories)
 }
 
 func runSuggest(cmd *cobra.Command, args []string) error {
@@ -68,6 +96,17 @@ func runSuggest(cmd *cobra.Command, args []string) error {
 
 	log.Debug("processing suggest request", "query", query, "raw", suggestRaw, "offline", suggestOffline)
 
+	// A category always describes a task
+	if suggestCategory != "" {
+		if err := validateIntentCategory(suggestCategory); err != nil {
+			return err
+		}
+		if query == "" {
+			return fmt.Errorf("--category needs a task to describe, e.g. wut suggest \"show logs\" --category %s", suggestCategory)
+		}
+		return runNaturalLanguageMode(query)
+	}
+
 	// Get database path
 	dbPath := getDBPathForSuggest()
 
@@ -75,7 +114,7 @@ func runSuggest(cmd *cobra.Command, args []string) error {
 	var storage *db.Storage
 	var err error
 	if _, statErr := os.Stat(dbPath); statErr == nil {
-		storage, err = db.NewStorage(dbPath)
+		storage, err = db.OpenForReading(dbPath)
 		if err != nil {
 			log.Warn("failed to open local storage", "error", err)
 		}
@@ -97,30 +136,192 @@ func runSuggest(cmd *cobra.Command, args []string) error {
 
 	client := db.NewClient(clientOpts...)
 
+	if outputJSON {
+		return runJSONMode(cmd.Context(), client, query)
+	}
+
+	// A WUT alias expands to its command
+	if words := strings.Fields(query); len(words) > 0 {
+		if a := findUserAlias(words[0]); a != nil {
+			return runAliasMode(cmd.Context(), a, words[1:])
+		}
+	}
+
+	// A task described in words gets ranked commands instead of a page
+	if isTaskDescription(storage, query) {
+		return runNaturalLanguageMode(query)
+	}
+
+	// Plain output when asked for, or when the TUI cannot run (e.g. piped)
+	plain := suggestRaw || suggestQuiet || !terminal.IsInteractive()
+
 	// Interactive mode - launch TUI
 	if query == "" {
-		if suggestRaw || suggestQuiet {
+		if plain {
 			return runCommandIndexMode(client)
 		}
-		return runInteractiveMode(client, storage)
+		return runInteractiveMode(cmd.Context(), client, storage)
 	}
 
 	// If raw mode or quiet mode with query
-	if suggestRaw || suggestQuiet {
+	if plain {
 		return runRawMode(client, query)
 	}
 
 	// Normal mode with TUI for specific command
-	return runCommandMode(client, storage, query)
+	return runCommandMode(cmd.Context(), client, storage, query)
+}
+
+// isTaskDescription reports whether query describes what to do rather than
+// naming a command. A first word with a local page is always a command, and
+// a single word goes to the page lookup.
+func isTaskDescription(storage *db.Storage, query string) bool {
+	if !smart.IsTaskQuery(query) {
+		return false
+	}
+	if storage != nil {
+		if _, err := storage.GetPageAnyPlatform(strings.Fields(query)[0], ""); err == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// validateIntentCategory reports an error naming the known categories when
+// category is not one of them
+func validateIntentCategory(category string) error {
+	categories := corrector.IntentCategories()
+	for _, known := range categories {
+		if strings.EqualFold(known, category) {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown category %q; use one of: %s", category, strings.Join(categories, ", "))
+}
+
+// runNaturalLanguageMode answers a query like "free up docker disk space"
+// with the smart engine, which merges semantic matches with history and
+// context suggestions
+func runNaturalLanguageMode(query string) error {
+	log := logger.With("suggest")
+	if appCtx, suggestions, ok := daemonSuggestions(query); ok {
+		var storage *db.Storage
+		// The interactive list bookmarks and ranks in the database
+		if !suggestQuiet && !suggestRaw {
+			if storage = openSmartStorage(log); storage != nil {
+				defer storage.Close()
+			}
+		}
+		return printNaturalLanguageSuggestions(query, appCtx, suggestions, storage)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	storage := openSmartStorage(log)
+	if storage != nil {
+		defer storage.Close()
+	}
+	engine := smart.NewEngine(storage)
+	engine.SetIntentCategory(suggestCategory)
+
+	appCtx, err := engine.Context(ctx)
+	if err != nil {
+		log.Warn("failed to detect context", "error", err)
+		appCtx = &appctx.Context{WorkingDir: ".", ProjectType: "unknown"}
+	}
+
+	suggestions, err := engine.Suggest(ctx, query, appCtx, suggestLimit)
+	if err != nil {
+		return fmt.Errorf("failed to get suggestions: %w", err)
+	}
+	return printNaturalLanguageSuggestions(query, appCtx, suggestions, storage)
+}
+
+// daemonSuggestions asks wut daemon for the suggestions for query; ok is
+// false when it is not running or did not answer, or --no-daemon was given
+func daemonSuggestions(query string) (appCtx *appctx.Context, suggestions []smart.Suggestion, ok bool) {
+	if suggestNoDaemon {
+		return nil, nil, false
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		return nil, nil, false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	resp, err := daemon.Call(ctx, config.GetDaemonSocketPath(), daemon.Request{
+		Op:       daemon.OpSuggest,
+		Query:    query,
+		Dir:      dir,
+		Limit:    suggestLimit,
+		Category: suggestCategory,
+	})
+	if err != nil {
+		if !errors.Is(err, daemon.ErrNotRunning) {
+			logger.With("suggest").Warn("daemon did not answer, answering in-process", "error", err)
+		}
+		return nil, nil, false
+	}
+	if resp.Context == nil {
+		resp.Context = &appctx.Context{WorkingDir: dir, ProjectType: "unknown"}
+	}
+	return resp.Context, resp.Suggestions, true
+}
+
+// printNaturalLanguageSuggestions shows the suggestions for a task
+// description the way --quiet and --raw ask for, or in the interactive list
+func printNaturalLanguageSuggestions(query string, appCtx *appctx.Context, suggestions []smart.Suggestion, storage *db.Storage) error {
+	switch {
+	case suggestQuiet:
+		for _, suggestion := range suggestions {
+			fmt.Println(suggestion.Command)
+		}
+	case suggestRaw:
+		SimpleOutput(os.Stdout, query, suggestions)
+	default:
+		return showSmartSuggestions(query, appCtx, suggestions, storage)
+	}
+	return nil
+}
+
+// runAliasMode shows what a WUT alias expands to with the given arguments,
+// or copies or runs the expansion with --copy and --exec. Without arguments
+// the alias parameters are asked for before running.
+func runAliasMode(ctx context.Context, a *db.UserAlias, args []string) error {
+	command := a.Command
+	if len(args) > 0 {
+		command = db.ExpandAlias(a.Command, args)
+	}
+
+	switch {
+	case suggestExec || suggestCopy:
+		if len(args) == 0 {
+			command = promptAliasArgs(command)
+		}
+		return handlePickedCommand(ctx, command)
+	case suggestQuiet:
+		fmt.Println(command)
+	default:
+		fmt.Printf("%s %s %s\n", ui.Cyan(a.Name), ui.Muted("→"), ui.Success(command))
+		if a.Description != "" {
+			fmt.Printf("  %s\n", a.Description)
+		}
+		if params := db.AliasParams(command); len(params) > 0 {
+			fmt.Printf("  %s\n", ui.Muted("Takes "+strings.Join(params, " ")))
+		}
+		fmt.Println(ui.Muted("  Run it with: wut suggest " + strings.Join(append([]string{a.Name}, args...), " ") + " --exec"))
+	}
+	return nil
 }
 
 // runInteractiveMode runs the interactive TUI mode
-func runInteractiveMode(client *db.Client, storage *db.Storage) error {
+func runInteractiveMode(ctx context.Context, client *db.Client, storage *db.Storage) error {
 	log := logger.With("suggest")
 	log.Debug("entering interactive mode")
 
 	// Check if online
-	ctx := context.Background()
 	online := client.IsOnline(ctx)
 	if !online && !client.IsOfflineMode() {
 		fmt.Println("📴 Offline mode - using local database")
@@ -130,6 +331,11 @@ func runInteractiveMode(client *db.Client, storage *db.Storage) error {
 
 	// Create and run TUI
 	model := db.NewModel()
+	model.SetPreviewEnabled(config.Get().UI.ShowPreview)
+	model.SetConfirmDangerous(config.Get().UI.ConfirmDangerous)
+	model.SetBookmarker(bookmarkCommand)
+	model.SetRanker(rankCommand)
+	model.SetBookmarkedCommands(bookmarkedCommands())
 
 	// Set storage if available
 	if storage != nil {
@@ -145,13 +351,11 @@ func runInteractiveMode(client *db.Client, storage *db.Storage) error {
 
 	// Get selected command or executed command
 	if m, ok := finalModel.(*db.Model); ok {
+		printUncopiedCommand(m.GetUncopiedCommand())
+
 		// Check if a command should be executed
 		if cmd := m.GetExecutedCommand(); cmd != "" {
-			fmt.Printf("\n⚡ Executing: %s\n\n", cmd)
-			if err := db.ExecuteCommand(cmd); err != nil {
-				return fmt.Errorf("execution failed: %w", err)
-			}
-			return nil
+			return handlePickedCommand(ctx, cmd)
 		}
 
 		selected := m.Selected()
@@ -210,9 +414,30 @@ func runRawMode(client *db.Client, query string) error {
 		}
 	}
 
+	// Without a TUI to pick from, --copy takes the first example
+	if suggestCopy && len(page.Examples) > 0 {
+		copyPickedCommand(page.Examples[0].Command)
+	}
+
 	return nil
 }
 
+// runJSONMode prints the examples for query, or the closest matching
+// commands when there is no page, as a JSON document
+func runJSONMode(ctx context.Context, client *db.Client, query string) error {
+	if query != "" {
+		if page, err := client.GetPageAnyPlatform(ctx, query); err == nil {
+			return writeJSON(newSuggestJSON(query, page, nil, suggestLimit))
+		}
+	}
+
+	matches, err := client.FindCommandMatches(ctx, query, suggestLimit)
+	if err != nil {
+		return fmt.Errorf("failed to find commands: %w", err)
+	}
+	return writeJSON(newSuggestJSON(query, nil, matches, suggestLimit))
+}
+
 func runCommandIndexMode(client *db.Client) error {
 	ctx := context.Background()
 	commands, err := client.FindCommandMatches(ctx, "", suggestLimit)
@@ -237,9 +462,7 @@ func runCommandIndexMode(client *db.Client) error {
 }
 
 // runCommandMode runs with TUI for a specific command
-func runCommandMode(client *db.Client, storage *db.Storage, query string) error {
-	ctx := context.Background()
-
+func runCommandMode(ctx context.Context, client *db.Client, storage *db.Storage, query string) error {
 	page, err := client.GetPageAnyPlatform(ctx, query)
 	if err != nil {
 		fmt.Printf("Command not found: %s\n", query)
@@ -255,8 +478,8 @@ func runCommandMode(client *db.Client, storage *db.Storage, query string) error
 		return nil
 	}
 
-	if suggestExec {
-		return runDetailMode(client, storage, page)
+	if suggestExec || suggestCopy {
+		return runDetailMode(ctx, client, storage, page)
 	}
 
 	// Render with lipgloss
@@ -266,8 +489,11 @@ func runCommandMode(client *db.Client, storage *db.Storage, query string) error
 	return nil
 }
 
-func runDetailMode(client *db.Client, storage *db.Storage, page *db.Page) error {
+func runDetailMode(ctx context.Context, client *db.Client, storage *db.Storage, page *db.Page) error {
 	model := db.NewModel()
+	model.SetConfirmDangerous(config.Get().UI.ConfirmDangerous)
+	model.SetBookmarker(bookmarkCommand)
+	model.SetRanker(rankCommand)
 	if storage != nil {
 		model.SetStorage(storage)
 	}
@@ -280,17 +506,55 @@ func runDetailMode(client *db.Client, storage *db.Storage, page *db.Page) error
 	}
 
 	if m, ok := finalModel.(*db.Model); ok {
+		printUncopiedCommand(m.GetUncopiedCommand())
+
 		if cmd := m.GetExecutedCommand(); cmd != "" {
-			fmt.Printf("\n⚡ Executing: %s\n\n", cmd)
-			if err := db.ExecuteCommand(cmd); err != nil {
-				return fmt.Errorf("execution failed: %w", err)
-			}
+			return handlePickedCommand(ctx, cmd)
 		}
 	}
 
 	return nil
 }
 
+// handlePickedCommand copies and/or runs the example picked in the TUI.
+// --copy alone only copies it.
+func handlePickedCommand(ctx context.Context, command string) error {
+	if suggestCopy {
+		copyPickedCommand(command)
+		if !suggestExec {
+			return nil
+		}
+	}
+	return runPickedCommand(ctx, command)
+}
+
+// copyToClipboard is the clipboard backend used by --copy; tests replace it
+var copyToClipboard = terminal.Copy
+
+// copyPickedCommand puts a picked example on the clipboard with its
+// placeholders stripped, like the TUI's copy key. When no clipboard backend
+// works the command is printed instead.
+func copyPickedCommand(command string) {
+	command = db.FillPlaceholders(command, nil)
+	method, err := copyToClipboard(command)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, ui.Warning("⚠️  Clipboard unavailable - copy the command manually:"))
+		fmt.Println(command)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "%s Copied via %s: %s\n", ui.Success("✓"), method, command)
+}
+
+// printUncopiedCommand prints a command that no clipboard backend accepted so
+// it can still be selected by hand once the TUI has exited.
+func printUncopiedCommand(command string) {
+	if command == "" {
+		return
+	}
+	fmt.Println("📋 Clipboard unavailable - copy the command manually:")
+	fmt.Printf("   %s\n", command)
+}
+
 // getDBPathForSuggest returns the path to the database
 func getDBPathForSuggest() string {
 	return config.GetTLDRDatabasePath()
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"wut/internal/config"
+	"wut/internal/db"
+)
+
+// stubClipboard replaces the clipboard for the test and returns the texts
+// copied to it. A non-nil err makes every copy fail.
+func stubClipboard(t *testing.T, err error) *[]string {
+	t.Helper()
+
+	var copied []string
+	orig := copyToClipboard
+	copyToClipboard = func(text string) (string, error) {
+		if err != nil {
+			return "", err
+		}
+		copied = append(copied, text)
+		return "stub", nil
+	}
+	t.Cleanup(func() { copyToClipboard = orig })
+	return &copied
+}
+
+func TestSuggestCopyRawMode(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	cfg := &config.Config{}
+	cfg.Database.Path = filepath.Join(dir, "wut.db")
+	config.Set(cfg)
+	t.Cleanup(func() { config.Set(&config.Config{}) })
+
+	tldr, err := db.NewStorage(config.GetTLDRDatabasePath())
+	if err != nil {
+		t.Fatalf("NewStorage() error = %v", err)
+	}
+	if err := tldr.SavePage(&db.Page{
+		Name:     "tar",
+		Platform: "common",
+		Language: "en",
+		Examples: []db.Example{
+			{Description: "Extract an archive", Command: "tar xf <path/to/file.tar>"},
+			{Description: "List an archive", Command: "tar tf <path/to/file.tar>"},
+		},
+	}); err != nil {
+		t.Fatalf("SavePage() error = %v", err)
+	}
+	tldr.Close()
+
+	origRaw, origCopy, origOffline := suggestRaw, suggestCopy, suggestOffline
+	t.Cleanup(func() { suggestRaw, suggestCopy, suggestOffline = origRaw, origCopy, origOffline })
+	suggestRaw, suggestCopy, suggestOffline = true, true, true
+
+	t.Run("copies the first example", func(t *testing.T) {
+		copied := stubClipboard(t, nil)
+		out := captureStdout(t, func() {
+			if err := runSuggest(suggestCmd, []string{"tar"}); err != nil {
+				t.Errorf("runSuggest() error = %v", err)
+			}
+		})
+		if len(*copied) != 1 || (*copied)[0] != "tar xf" {
+			t.Errorf("copied %q, want the first example without placeholders", *copied)
+		}
+		if !strings.Contains(out, "List an archive") {
+			t.Errorf("raw output is missing the page:\n%s", out)
+		}
+	})
+
+	t.Run("prints the command without a clipboard", func(t *testing.T) {
+		stubClipboard(t, errors.New("no clipboard"))
+		out := captureStdout(t, func() {
+			if err := runSuggest(suggestCmd, []string{"tar"}); err != nil {
+				t.Errorf("runSuggest() error = %v", err)
+			}
+		})
+		if !strings.HasSuffix(out, "\ntar xf\n") {
+			t.Errorf("output does not end with the uncopied command:\n%s", out)
+		}
+	})
+}
+
+func TestHandlePickedCommandCopyOnly(t *testing.T) {
+	config.Set(&config.Config{})
+	t.Cleanup(func() { config.Set(&config.Config{}) })
+
+	origCopy, origExec := suggestCopy, suggestExec
+	t.Cleanup(func() { suggestCopy, suggestExec = origCopy, origExec })
+	suggestCopy, suggestExec = true, false
+
+	copied := stubClipboard(t, nil)
+
+	// Running "false" would fail, so a nil error means nothing was executed
+	if err := handlePickedCommand(context.Background(), "false <ignored>"); err != nil {
+		t.Fatalf("handlePickedCommand() error = %v, want the command only copied", err)
+	}
+	if len(*copied) != 1 || (*copied)[0] != "false" {
+		t.Errorf("copied %q, want [false]", *copied)
+	}
+}
@@ -2,20 +2,29 @@ package cmd
 
 import (
 	"fmt"
+	"io"
 	"math"
+	"os"
 	"path/filepath"
 	"strings"
+	"unicode/utf8"
 
-	"github.com/atotto/clipboard"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/muesli/reflow/truncate"
 
 	appctx "wut/internal/context"
+	"wut/internal/db"
 	"wut/internal/metrics"
+	"wut/internal/performance"
 	"wut/internal/smart"
+	"wut/internal/terminal"
+	"wut/internal/ui"
 )
 
+// smartMetaMaxLines caps the wrapped description shown under each suggestion
+const smartMetaMaxLines = 3
+
 type smartListModel struct {
 	query       string
 	context     *appctx.Context
@@ -27,24 +36,100 @@ type smartListModel struct {
 	msg         string
 	width       int
 	height      int
+	uncopied    string
+	store       *db.Storage // for the bookmark key, may be nil
 }
 
-func showSmartSuggestions(query string, ctx *appctx.Context, suggestions []smart.Suggestion) error {
+// showSmartSuggestions shows suggestions in the interactive list. store is
+// used to bookmark the highlighted suggestion and may be nil.
+func showSmartSuggestions(query string, ctx *appctx.Context, suggestions []smart.Suggestion, store *db.Storage) error {
 	if len(suggestions) == 0 {
 		fmt.Println("No smart suggestions found.")
 		return nil
 	}
 
+	if !terminal.IsInteractive() {
+		SimpleOutput(os.Stdout, query, suggestions)
+		return nil
+	}
+
 	model := newSmartListModel(query, ctx, suggestions)
+	model.store = store
 	p := tea.NewProgram(model)
-	if _, err := p.Run(); err != nil {
+	finalModel, err := p.Run()
+	if err != nil {
 		return fmt.Errorf("error running smart UI: %w", err)
 	}
+	if m, ok := finalModel.(smartListModel); ok {
+		printUncopiedCommand(m.uncopied)
+	}
 
 	metrics.RecordHistoryView()
 	return nil
 }
 
+// SimpleOutput prints suggestions as a plain numbered list for terminals that
+// cannot host the interactive UI.
+func SimpleOutput(w io.Writer, query string, suggestions []smart.Suggestion) {
+	if query != "" {
+		fmt.Fprintf(w, "%s %s\n\n", ui.Title("Suggestions for"), ui.Primary(query))
+	}
+
+	for i, suggestion := range suggestions {
+		fmt.Fprintf(w, "%2d. %s\n", i+1, renderMatched(suggestion.Command, suggestion.MatchSpans, ui.StyleSuccess))
+		if meta := smartSuggestionMeta(suggestion); meta != "" {
+			fmt.Fprintf(w, "    %s\n", ui.Muted(meta))
+		}
+	}
+}
+
+// printRankingExplanation prints suggestions with the contribution of each
+// scoring term, for `wut smart --explain-ranking`
+func printRankingExplanation(w io.Writer, query string, suggestions []smart.Suggestion) {
+	if query != "" {
+		fmt.Fprintf(w, "%s %s\n\n", ui.Title("Ranking for"), ui.Primary(query))
+	}
+
+	for i, suggestion := range suggestions {
+		fmt.Fprintf(w, "%2d. %s  %s\n", i+1, ui.Success(suggestion.Command), ui.Muted(suggestion.Source))
+		fmt.Fprintf(w, "    %s\n", ui.Muted(formatScoreBreakdown(suggestion)))
+	}
+}
+
+// formatScoreBreakdown shows a suggestion's score as the sum of its non-zero
+// contributions
+func formatScoreBreakdown(suggestion smart.Suggestion) string {
+	b := suggestion.Breakdown
+	if b == nil {
+		return fmt.Sprintf("score %.2f (no breakdown)", suggestion.Score)
+	}
+
+	terms := []struct {
+		name  string
+		value float64
+	}{
+		{"source", b.Source},
+		{"exact", b.Exact},
+		{"prefix", b.Prefix},
+		{"contains", b.Contains},
+		{"fuzzy", b.Fuzzy},
+		{"history", b.History},
+		{"recency", b.Recency},
+		{"context", b.Context},
+		{"bias", b.Bias},
+	}
+	parts := make([]string, 0, len(terms))
+	for _, term := range terms {
+		if term.value != 0 {
+			parts = append(parts, fmt.Sprintf("%s %.2f", term.name, term.value))
+		}
+	}
+	if len(parts) == 0 {
+		parts = append(parts, "0")
+	}
+	return fmt.Sprintf("score %.2f = %s", suggestion.Score, strings.Join(parts, " + "))
+}
+
 func newSmartListModel(query string, ctx *appctx.Context, suggestions []smart.Suggestion) smartListModel {
 	pageSize := 12
 	numPages := int(math.Ceil(float64(len(suggestions)) / float64(pageSize)))
@@ -103,11 +188,41 @@ func (m smartListModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "enter", "c", "y":
 			if m.cursor >= 0 && m.cursor < len(m.suggestions) {
 				targetCmd := m.suggestions[m.cursor].Command
-				if err := clipboard.WriteAll(targetCmd); err == nil {
-					m.msg = "📋 Copied to clipboard"
+				method, err := terminal.Copy(targetCmd)
+				if err != nil {
+					m.uncopied = targetCmd
+					m.msg = "❌ Copy failed - command will be printed on exit"
 					return m, tickClearMsg()
 				}
-				m.msg = "❌ Copy failed"
+				m.uncopied = ""
+				m.msg = "📋 Copied via " + method
+				return m, tickClearMsg()
+			}
+		case "b":
+			if m.cursor >= 0 && m.cursor < len(m.suggestions) {
+				suggestion := &m.suggestions[m.cursor]
+				if err := saveBookmark(m.store, suggestion.Command); err != nil {
+					m.msg = "❌ Bookmark failed: " + err.Error()
+					return m, tickClearMsg()
+				}
+				if !strings.Contains(suggestion.Source, "Bookmark") {
+					suggestion.Source += " + ⭐ Bookmark"
+				}
+				m.msg = "⭐ Bookmarked"
+				return m, tickClearMsg()
+			}
+		case "+", "=", "-":
+			if m.cursor >= 0 && m.cursor < len(m.suggestions) {
+				delta := 1
+				if msg.String() == "-" {
+					delta = -1
+				}
+				level, err := trainSuggestion(m.store, m.suggestions[m.cursor].Command, delta)
+				if err != nil {
+					m.msg = "❌ Ranking failed: " + err.Error()
+					return m, tickClearMsg()
+				}
+				m.msg = "🎯 Now " + describeBias(level)
 				return m, tickClearMsg()
 			}
 		}
@@ -146,11 +261,11 @@ func (m smartListModel) View() string {
 		innerWidth = 24
 	}
 
-	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#7C3AED"))
-	queryStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#3B82F6")).Bold(true)
-	metaStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#9CA3AF"))
-	sourceStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#A78BFA"))
-	descStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#6B7280"))
+	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(ui.ColorBrand)
+	queryStyle := lipgloss.NewStyle().Foreground(ui.ColorPrimary).Bold(true)
+	metaStyle := lipgloss.NewStyle().Foreground(ui.ColorSubtle)
+	sourceStyle := lipgloss.NewStyle().Foreground(ui.ColorBrandSoft)
+	descStyle := lipgloss.NewStyle().Foreground(ui.ColorMuted)
 
 	title := "💡 Smart Suggestions"
 	if strings.TrimSpace(m.query) != "" {
@@ -159,10 +274,10 @@ func (m smartListModel) View() string {
 
 	var sb strings.Builder
 	if m.msg != "" {
-		alertText := lipgloss.NewStyle().Foreground(lipgloss.Color("#E5E7EB")).Bold(true).Render(m.msg)
+		alertText := lipgloss.NewStyle().Foreground(ui.ColorText).Bold(true).Render(m.msg)
 		alertStr := lipgloss.NewStyle().
 			Border(lipgloss.RoundedBorder()).
-			BorderForeground(lipgloss.Color("#10B981")).
+			BorderForeground(ui.ColorSuccess).
 			Padding(0, 1).
 			Render(alertText)
 
@@ -191,7 +306,7 @@ func (m smartListModel) View() string {
 		sb.WriteString("\n\n")
 	}
 
-	indexStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#6B7280")).Width(4).Align(lipgloss.Right)
+	indexStyle := lipgloss.NewStyle().Foreground(ui.ColorMuted).Width(4).Align(lipgloss.Right)
 	showDesc := w >= 80
 	showSource := w >= 65
 
@@ -206,31 +321,39 @@ func (m smartListModel) View() string {
 	for i := start; i < end; i++ {
 		suggestion := m.suggestions[i]
 		cursor := "  "
-		cmdStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#10B981"))
+		cmdStyle := lipgloss.NewStyle().Bold(true).Foreground(ui.ColorSuccess)
+		pad := ""
 		if m.cursor == i {
 			cursor = "👉"
 			cmdStyle = lipgloss.NewStyle().
 				Bold(true).
-				Foreground(lipgloss.Color("#FFFFFF")).
-				Background(lipgloss.Color("#3B82F6")).
-				Padding(0, 1)
+				Foreground(ui.ColorOnAccent).
+				Background(ui.ColorPrimary)
+			// The command is rendered in pieces, so pad it by hand
+			pad = cmdStyle.Render(" ")
 		}
 
-		command := suggestion.Command
+		command, spans := suggestion.Command, suggestion.MatchSpans
 		if lipgloss.Width(command) > availWidth {
 			command = truncate.StringWithTail(command, uint(availWidth), "...")
+			spans = clipSpans(spans, len(strings.TrimSuffix(command, "...")))
 		}
+		renderedCommand := pad + renderMatched(command, spans, cmdStyle) + pad
 
 		sourceLabel := ""
 		if showSource {
-			sourceLabel = sourceStyle.Render("["+compactSuggestionSource(suggestion.Source)+"]") + "  "
+			label := compactSuggestionSource(suggestion.Source)
+			if label == "semantic" {
+				label = suggestion.Icon + " " + label
+			}
+			sourceLabel = sourceStyle.Render("["+label+"]") + "  "
 		}
 
-		sb.WriteString(fmt.Sprintf("%s %s %s%s\n", cursor, indexStyle.Render(fmt.Sprintf("%d.", i+1)), sourceLabel, cmdStyle.Render(command)))
+		sb.WriteString(fmt.Sprintf("%s %s %s%s\n", cursor, indexStyle.Render(fmt.Sprintf("%d.", i+1)), sourceLabel, renderedCommand))
 
 		if showDesc {
-			if extra := smartSuggestionMeta(suggestion, innerWidth-6); extra != "" {
-				sb.WriteString("      " + descStyle.Render(extra) + "\n")
+			for _, line := range ui.Wrap(smartSuggestionMeta(suggestion), innerWidth-6, smartMetaMaxLines) {
+				sb.WriteString("      " + descStyle.Render(line) + "\n")
 			}
 		}
 		sb.WriteString("\n")
@@ -239,28 +362,64 @@ func (m smartListModel) View() string {
 	sb.WriteString(metaStyle.Render(fmt.Sprintf("Showing %d suggestions total.", len(m.suggestions))))
 	sb.WriteString("\n\n")
 
-	footerStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#EAB308")).Bold(true)
+	footerStyle := lipgloss.NewStyle().Foreground(ui.ColorHighlight).Bold(true)
 	sb.WriteString(footerStyle.Render(fmt.Sprintf("Page %d/%d", m.page+1, m.numPages)))
 
 	var footerNav string
 	if w >= 90 {
-		footerNav = " | [↑/↓] Navigate | [←/→] Prev/Next Page | [c/enter] Copy | [q] Quit"
+		footerNav = " | [↑/↓] Navigate | [←/→] Prev/Next Page | [c/enter] Copy | [b] Bookmark | [+/-] Rank | [q] Quit"
 	} else if w >= 60 {
-		footerNav = " | ↑/↓ nav | ←/→ page | c copy | q quit"
+		footerNav = " | ↑/↓ nav | ←/→ page | c copy | b bookmark | +/- rank | q quit"
 	} else {
-		footerNav = " | ↑/↓ | ←/→ | c | q"
+		footerNav = " | ↑/↓ | ←/→ | c | b | q"
 	}
 	sb.WriteString(metaStyle.Render(footerNav + "\n"))
 
 	boxStyle := lipgloss.NewStyle().
 		Border(lipgloss.RoundedBorder()).
-		BorderForeground(lipgloss.Color("#7C3AED")).
+		BorderForeground(ui.ColorBrand).
 		Padding(1, boxPadX).
 		Width(boxWidth)
 
 	return boxStyle.Render(strings.TrimRight(sb.String(), "\n"))
 }
 
+// renderMatched renders text in style with the spans a query matched
+// underlined, so a list shows why each command came up
+func renderMatched(text string, spans []performance.Span, style lipgloss.Style) string {
+	matched := style.Underline(true)
+	var b strings.Builder
+	pos := 0
+	for _, span := range spans {
+		start, end := max(span.Start, pos), min(span.End, len(text))
+		if start >= end || !utf8.RuneStart(text[start]) || (end < len(text) && !utf8.RuneStart(text[end])) {
+			continue
+		}
+		if start > pos {
+			b.WriteString(style.Render(text[pos:start]))
+		}
+		b.WriteString(matched.Render(text[start:end]))
+		pos = end
+	}
+	if pos < len(text) || pos == 0 {
+		b.WriteString(style.Render(text[pos:]))
+	}
+	return b.String()
+}
+
+// clipSpans cuts spans off at limit, for a text truncated there
+func clipSpans(spans []performance.Span, limit int) []performance.Span {
+	var clipped []performance.Span
+	for _, span := range spans {
+		if span.Start >= limit {
+			break
+		}
+		span.End = min(span.End, limit)
+		clipped = append(clipped, span)
+	}
+	return clipped
+}
+
 func smartContextSummary(ctx *appctx.Context) string {
 	if ctx == nil {
 		return "No context available"
@@ -275,7 +434,7 @@ func smartContextSummary(ctx *appctx.Context) string {
 		parts = append(parts, "Project: "+workspace)
 	}
 	if ctx.ProjectType != "" && ctx.ProjectType != "unknown" {
-		parts = append(parts, "Type: "+ctx.ProjectType)
+		parts = append(parts, "Type: "+strings.Join(append([]string{ctx.ProjectType}, ctx.SecondaryTypes...), " + "))
 	}
 	if ctx.Shell != "" {
 		parts = append(parts, "Shell: "+strings.TrimSuffix(strings.TrimSuffix(strings.TrimSuffix(ctx.Shell, ".exe"), ".cmd"), ".bat"))
@@ -299,6 +458,8 @@ func smartContextSummary(ctx *appctx.Context) string {
 func compactSuggestionSource(source string) string {
 	source = strings.TrimSpace(source)
 	switch {
+	case strings.Contains(source, "Bookmark"):
+		return "bookmark"
 	case strings.Contains(source, "Smart History"):
 		return "history"
 	case strings.Contains(source, "Context"):
@@ -309,12 +470,16 @@ func compactSuggestionSource(source string) string {
 		return "reference"
 	case strings.Contains(source, "Fuzzy"):
 		return "fuzzy"
+	case strings.Contains(source, "Semantic"):
+		return "semantic"
+	case strings.Contains(source, "Alias"):
+		return "alias"
 	default:
 		return strings.ToLower(source)
 	}
 }
 
-func smartSuggestionMeta(suggestion smart.Suggestion, width int) string {
+func smartSuggestionMeta(suggestion smart.Suggestion) string {
 	parts := make([]string, 0, 4)
 	if suggestion.Description != "" {
 		parts = append(parts, suggestion.Description)
@@ -325,19 +490,16 @@ func smartSuggestionMeta(suggestion smart.Suggestion, width int) string {
 	if suggestion.IsPerfectMatch {
 		parts = append(parts, "exact")
 	}
+	if suggestion.Confidence > 0 {
+		parts = append(parts, fmt.Sprintf("%.0f%% confident", suggestion.Confidence*100))
+	}
 	if suggestion.ContextMatch >= 0.3 {
 		parts = append(parts, "local context")
 	}
 	if suggestion.UsageCount > 1 {
 		parts = append(parts, fmt.Sprintf("used %d times", suggestion.UsageCount))
 	}
-	if meta := strings.Join(parts, "  ·  "); meta != "" {
-		if width > 0 && lipgloss.Width(meta) > width {
-			return truncate.StringWithTail(meta, uint(width), "...")
-		}
-		return meta
-	}
-	return ""
+	return strings.Join(parts, "  ·  ")
 }
 
 func smartDifferenceSummary(suggestions []smart.Suggestion, width int) string {
@@ -349,6 +511,8 @@ func smartDifferenceSummary(suggestions []smart.Suggestion, width int) string {
 	contextCount := 0
 	referenceCount := 0
 	exploreCount := 0
+	semanticCount := 0
+	bookmarkCount := 0
 	var bestNonHistory string
 
 	for _, suggestion := range suggestions {
@@ -370,6 +534,13 @@ func smartDifferenceSummary(suggestions []smart.Suggestion, width int) string {
 			if bestNonHistory == "" {
 				bestNonHistory = suggestion.Command
 			}
+		case "semantic":
+			semanticCount++
+			if bestNonHistory == "" {
+				bestNonHistory = suggestion.Command
+			}
+		case "bookmark":
+			bookmarkCount++
 		default:
 			if bestNonHistory == "" && !strings.Contains(strings.ToLower(suggestion.Source), "history") {
 				bestNonHistory = suggestion.Command
@@ -389,6 +560,12 @@ func smartDifferenceSummary(suggestions []smart.Suggestion, width int) string {
 	if exploreCount > 0 {
 		parts = append(parts, fmt.Sprintf("%d explore", exploreCount))
 	}
+	if semanticCount > 0 {
+		parts = append(parts, fmt.Sprintf("%d semantic", semanticCount))
+	}
+	if bookmarkCount > 0 {
+		parts = append(parts, fmt.Sprintf("%d bookmarked", bookmarkCount))
+	}
 	if bestNonHistory != "" {
 		parts = append(parts, "best new idea: "+bestNonHistory)
 	}
@@ -410,7 +587,15 @@ func smartSuggestionHint(suggestion smart.Suggestion) string {
 		return "not required in your history"
 	case "fuzzy":
 		return "discovery match"
+	case "semantic":
+		return "matches what you described"
+	case "bookmark":
+		return "bookmarked"
 	default:
+		// External sources are labelled with their name
+		if name, ok := strings.CutPrefix(suggestion.Source, "🔌 "); ok {
+			return "from " + name
+		}
 		return ""
 	}
 }
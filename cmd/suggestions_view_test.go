@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+
+	"wut/internal/performance"
+)
+
+func TestRenderMatched(t *testing.T) {
+	origProfile := lipgloss.ColorProfile()
+	t.Cleanup(func() { lipgloss.SetColorProfile(origProfile) })
+	lipgloss.SetColorProfile(termenv.TrueColor)
+
+	style := lipgloss.NewStyle()
+	underlined := style.Underline(true)
+	spans := []performance.Span{{Start: 0, End: 1}, {Start: 4, End: 6}}
+
+	got := renderMatched("git status", spans, style)
+	want := underlined.Render("g") + style.Render("it ") + underlined.Render("st") + style.Render("atus")
+	if got != want {
+		t.Errorf("renderMatched() = %q, want %q", got, want)
+	}
+
+	// Without spans, or with spans that do not fit, the text is unchanged
+	if got := renderMatched("git", nil, style); got != style.Render("git") {
+		t.Errorf("renderMatched() without spans = %q", got)
+	}
+	if got := renderMatched("git", []performance.Span{{Start: 5, End: 9}}, style); got != style.Render("git") {
+		t.Errorf("renderMatched() with a span past the end = %q", got)
+	}
+
+	// A truncated command keeps the highlight on what is still shown
+	clipped := clipSpans([]performance.Span{{Start: 0, End: 3}, {Start: 8, End: 12}, {Start: 20, End: 22}}, 10)
+	if len(clipped) != 2 || clipped[1] != (performance.Span{Start: 8, End: 10}) {
+		t.Errorf("clipSpans() = %v", clipped)
+	}
+}
@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+
+	"wut/internal/config"
+	"wut/internal/db"
+	"wut/internal/smart"
+	"wut/internal/terminal"
+	"wut/internal/ui"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// themeViews renders a few representative views for the theme snapshots
+func themeViews() string {
+	config.Set(&config.Config{})
+
+	var b bytes.Buffer
+	SimpleOutput(&b, "git", []smart.Suggestion{
+		{Command: "git status", Description: "Show the working tree status", Source: "Smart History", UsageCount: 3},
+		{Command: "git stash", Description: "Stash local changes", Source: "Context"},
+	})
+
+	ts := time.Date(2026, 3, 14, 9, 26, 0, 0, time.Local)
+	history := newHistoryModel([]db.CommandExecution{
+		{ID: "1", Command: "go test ./...", Timestamp: ts},
+		{ID: "2", Command: "git push", Timestamp: ts.Add(time.Minute), ExitCode: 1},
+	}, 2)
+	history.absoluteTime = true
+	history.width = 80
+	b.WriteString(history.View())
+
+	bookmarks := bookmarkModel{
+		bookmarks: []db.Bookmark{
+			{ID: "abc123", Command: "docker compose up -d", Tags: []string{"docker"}, Notes: "start the stack", CreatedAt: ts},
+		},
+		pageSize: 8,
+		width:    80,
+	}.repaginate()
+	b.WriteString(bookmarks.View())
+
+	return b.String()
+}
+
+func TestThemeSnapshots(t *testing.T) {
+	origProfile := lipgloss.ColorProfile()
+	origDark := lipgloss.HasDarkBackground()
+	t.Cleanup(func() {
+		lipgloss.SetColorProfile(origProfile)
+		lipgloss.SetHasDarkBackground(origDark)
+		terminal.SetNoColor(false)
+	})
+
+	tests := []struct {
+		golden  string
+		theme   string
+		noColor bool
+	}{
+		{"theme-dark.golden", "dark", false},
+		{"theme-light.golden", "light", false},
+		{"theme-nocolor.golden", "dark", true},
+	}
+
+	got := make(map[string]string)
+	for _, tt := range tests {
+		t.Run(tt.golden, func(t *testing.T) {
+			// The test binary is not attached to a terminal, so force
+			// colors unless the case turns them off
+			terminal.SetNoColor(tt.noColor)
+			lipgloss.SetColorProfile(termenv.TrueColor)
+			if tt.noColor {
+				ui.ApplyColorMode()
+			}
+			ui.SetTheme(tt.theme)
+
+			out := themeViews()
+			got[tt.theme+"/"+tt.golden] = out
+
+			path := filepath.Join("testdata", tt.golden)
+			if *updateGolden {
+				if err := os.MkdirAll("testdata", 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, []byte(out), 0644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if out != string(want) {
+				t.Errorf("%s differs from the rendered views; run go test -update if the change is intended\ngot:\n%s", tt.golden, out)
+			}
+		})
+	}
+
+	if got["dark/theme-dark.golden"] == got["light/theme-light.golden"] {
+		t.Error("the dark and light themes render the same colors")
+	}
+	if bytes.Contains([]byte(got["dark/theme-nocolor.golden"]), []byte("\x1b[")) {
+		t.Error("views emitted ANSI escapes with color disabled")
+	}
+}
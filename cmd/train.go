@@ -0,0 +1,231 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"wut/internal/db"
+	"wut/internal/ui"
+)
+
+// trainCmd teaches WUT personal corrections and which suggestions to prefer
+var trainCmd = &cobra.Command{
+	Use:   "train",
+	Short: "Teach WUT your corrections and favorite commands",
+	Long: `Teach WUT corrections of your own and which commands to rank higher or
+lower.
+
+A correction taught with 'wut train correct' is applied by wut fix and wut
+smart before any built-in one. A command boosted with 'wut train boost' ranks
+higher in wut smart and history search, and one buried with 'wut train bury'
+lower; each boost or bury multiplies its score by 1.5 or divides it by 1.5,
+up to three times. In the suggestion lists, + and - boost and bury the
+highlighted command.
+
+Everything is stored in the WUT database on this machine.`,
+	Example: `  wut train correct gti git
+  wut train correct "dc up" "docker compose up -d"
+  wut train boost "git status -sb"
+  wut train bury "git status"
+  wut train list
+  wut train rm gti`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return cmd.Help()
+	},
+}
+
+var trainCorrectCmd = &cobra.Command{
+	Use:   "correct <typo> <command>",
+	Short: "Always correct a typo to a command",
+	Args:  cobra.MinimumNArgs(2),
+	RunE:  runTrainCorrect,
+}
+
+var trainBoostCmd = &cobra.Command{
+	Use:   "boost <command>",
+	Short: "Rank a command higher",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runTrainBias(cmd.Context(), strings.Join(args, " "), 1)
+	},
+}
+
+var trainBuryCmd = &cobra.Command{
+	Use:   "bury <command>",
+	Short: "Rank a command lower",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runTrainBias(cmd.Context(), strings.Join(args, " "), -1)
+	},
+}
+
+var trainListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List taught corrections and boosted or buried commands",
+	Args:    cobra.NoArgs,
+	RunE:    runTrainList,
+}
+
+var trainRemoveCmd = &cobra.Command{
+	Use:     "remove <typo|command>",
+	Aliases: []string{"rm", "delete"},
+	Short:   "Forget a taught correction or a command's boost or bury",
+	Args:    cobra.MinimumNArgs(1),
+	RunE:    runTrainRemove,
+}
+
+func init() {
+	rootCmd.AddCommand(trainCmd)
+	trainCmd.AddCommand(trainCorrectCmd, trainBoostCmd, trainBuryCmd, trainListCmd, trainRemoveCmd)
+}
+
+func runTrainCorrect(cmd *cobra.Command, args []string) error {
+	store, err := getDB()
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer store.Close()
+
+	typo, command := args[0], strings.Join(args[1:], " ")
+	if err := store.SavePersonalCorrection(cmd.Context(), typo, command); err != nil {
+		return fmt.Errorf("failed to save correction: %w", err)
+	}
+	fmt.Printf("%s %s → %s\n", ui.Green("✓"), ui.Cyan(typo), command)
+	return nil
+}
+
+func runTrainBias(ctx context.Context, command string, delta int) error {
+	store, err := getDB()
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer store.Close()
+
+	bias, err := store.AdjustCommandBias(ctx, command, delta)
+	if err != nil {
+		return fmt.Errorf("failed to save bias: %w", err)
+	}
+	fmt.Printf("%s %s %s\n", ui.Green("✓"), ui.Cyan(bias.Command), ui.Muted(describeBias(bias.Level)))
+	return nil
+}
+
+// describeBias says how a bias level ranks a command
+func describeBias(level int) string {
+	switch {
+	case level > 0:
+		return fmt.Sprintf("boosted %d/%d", level, db.MaxBiasLevel)
+	case level < 0:
+		return fmt.Sprintf("buried %d/%d", -level, db.MaxBiasLevel)
+	default:
+		return "ranked normally"
+	}
+}
+
+func runTrainList(cmd *cobra.Command, args []string) error {
+	store, err := getDB()
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer store.Close()
+
+	corrections, err := store.ListPersonalCorrections(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to list corrections: %w", err)
+	}
+	biases, err := store.ListCommandBiases(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to list biases: %w", err)
+	}
+	printTraining(os.Stdout, corrections, biases)
+	return nil
+}
+
+// printTraining lists taught corrections and command biases
+func printTraining(w io.Writer, corrections []db.PersonalCorrection, biases []db.CommandBias) {
+	if len(corrections) == 0 && len(biases) == 0 {
+		fmt.Fprintln(w, "Nothing taught yet. Try: wut train correct gti git")
+		return
+	}
+	if len(corrections) > 0 {
+		fmt.Fprintln(w, ui.Title("Corrections"))
+		for _, c := range corrections {
+			fmt.Fprintf(w, "  %s → %s\n", ui.Cyan(c.Typo), c.Command)
+		}
+	}
+	if len(biases) > 0 {
+		if len(corrections) > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintln(w, ui.Title("Ranking"))
+		for _, b := range biases {
+			fmt.Fprintf(w, "  %s  %s\n", ui.Green(b.Command), ui.Muted(describeBias(b.Level)))
+		}
+	}
+}
+
+func runTrainRemove(cmd *cobra.Command, args []string) error {
+	store, err := getDB()
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer store.Close()
+
+	// A taught typo is removed first; otherwise the argument is a command
+	target := strings.Join(args, " ")
+	err = store.DeletePersonalCorrection(cmd.Context(), target)
+	if errors.Is(err, db.ErrCorrectionNotFound) {
+		err = store.DeleteCommandBias(cmd.Context(), target)
+	}
+	switch {
+	case errors.Is(err, db.ErrBiasNotFound):
+		return fmt.Errorf("nothing taught for %q; see wut train list", target)
+	case err != nil:
+		return fmt.Errorf("failed to remove %q: %w", target, err)
+	}
+	fmt.Printf("%s Forgot %s\n", ui.Green("✓"), ui.Cyan(target))
+	return nil
+}
+
+// personalCorrections returns the corrections taught in store by typo, for
+// the corrector
+func personalCorrections(store *db.Storage) map[string]string {
+	corrections, err := store.ListPersonalCorrections(context.Background())
+	if err != nil {
+		return nil
+	}
+	byTypo := make(map[string]string, len(corrections))
+	for _, c := range corrections {
+		byTypo[c.Typo] = c.Command
+	}
+	return byTypo
+}
+
+// trainSuggestion boosts a command in store by delta levels, or buries it
+// for a negative delta, and returns its new level. store may be nil.
+func trainSuggestion(store *db.Storage, command string, delta int) (int, error) {
+	if store == nil {
+		return 0, fmt.Errorf("database unavailable")
+	}
+	bias, err := store.AdjustCommandBias(context.Background(), command, delta)
+	return bias.Level, err
+}
+
+// rankCommand boosts or buries a command with a short-lived database handle,
+// for views that do not hold the WUT database open
+func rankCommand(command string, delta int) error {
+	store, err := getDB()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	_, err = trainSuggestion(store, command, delta)
+	return err
+}
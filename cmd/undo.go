@@ -3,225 +3,594 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"slices"
+	"strconv"
 	"strings"
 
 	"wut/internal/config"
+	"wut/internal/corrector"
 	"wut/internal/db"
 	"wut/internal/logger"
+	"wut/internal/shellwords"
+	"wut/internal/terminal"
 	"wut/internal/ui"
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/spf13/cobra"
 )
 
+var (
+	undoCommand string
+	undoRun     bool
+)
+
 var undoCmd = &cobra.Command{
 	Use:   "undo [command]",
 	Short: "Suggest how to undo a specific command or your last action",
-	Long: `Accidentally ran a command and want to revert it? 
-'wut undo' analyzes the command (or your recent history) and provides the exact command needed to undo the changes.`,
+	Long: `Accidentally ran a command and want to revert it?
+'wut undo' analyzes the command (or your recent history) and suggests the command that reverts it.
+Nothing is run unless you pass --run, and then only after the usual confirmation.`,
 	Example: `  wut undo "git add ."
+  wut undo --command "mv notes.txt docs/notes.txt"
   wut undo "tar -xf archive.tar"
-  wut undo  // Automatically finds and suggests undo for your last command`,
+  wut undo --run  # Suggest an undo for your last command and offer to run it`,
 	RunE: runUndo,
 }
 
 func init() {
 	rootCmd.AddCommand(undoCmd)
+
+	undoCmd.Flags().StringVar(&undoCommand, "command", "", "command to undo instead of the last one in history")
+	undoCmd.Flags().BoolVarP(&undoRun, "run", "r", false, "run the undo command after confirmation")
 }
 
-// undoRule represents a pattern and its corresponding undo suggestion
+// undoRule is the inverse of one kind of command. Rules are matched in order,
+// so more specific ones go first. To teach undo a new command, add a rule.
+//
+// Undo is a template filled from the original command:
+//
+//	{args}       the arguments after the subcommand
+//	{1}, {2}...  a single argument
+//	{last}       the last argument
+//	{flag:f,file} the value given to -f or --file
+//
+// A value may name a fallback after |, which is used when the original
+// command has no such value. Fallbacks like <container> are asked for before
+// the command runs. A rule without Undo marks a command that cannot be
+// undone.
 type undoRule struct {
-	Prefix      string
+	Command     string   // program name
+	Subcommand  string   // first argument, for tools like git and docker
+	Flags       []string // the command must use one of these flags
+	Args        int      // exact number of arguments, 0 for any, -1 for none
 	Description string
-	UndoCmd     func(args string) string
+	Undo        string
 	Warning     string
 }
 
-// predefined undo rules
 var undoRules = []undoRule{
+	// Commands that cannot be undone
+	{
+		Command:     "rm",
+		Description: "Deleted files do not go to a trash can",
+		Warning:     "rm cannot be undone. Restore from a backup, or stop writing to the disk and try a recovery tool like testdisk.",
+	},
+	{
+		Command:     "dd",
+		Description: "The overwritten data is gone",
+		Warning:     "dd cannot be undone. Restore the target from a backup.",
+	},
+	{
+		Command:     "shred",
+		Description: "Shredded files are overwritten on purpose",
+		Warning:     "shred cannot be undone.",
+	},
 	{
-		Prefix:      "git add",
+		Command:     "git",
+		Subcommand:  "clean",
+		Description: "Untracked files removed by git clean are not kept anywhere",
+		Warning:     "git clean cannot be undone.",
+	},
+
+	// git
+	{
+		Command:     "git",
+		Subcommand:  "reset",
+		Flags:       []string{"hard"},
+		Description: "Move the branch back to where it was before the reset",
+		Undo:        "git reset --hard ORIG_HEAD",
+		Warning:     "Uncommitted changes discarded by the reset cannot be recovered.",
+	},
+	{
+		Command:     "git",
+		Subcommand:  "add",
 		Description: "Unstage files from the index",
-		UndoCmd:     func(args string) string { return "git restore --staged " + args },
-		Warning:     "",
+		Undo:        "git restore --staged {args|.}",
 	},
 	{
-		Prefix:      "git commit",
+		Command:     "git",
+		Subcommand:  "commit",
 		Description: "Undo the last commit while keeping your working changes",
-		UndoCmd:     func(args string) string { return "git reset --soft HEAD~1" },
+		Undo:        "git reset --soft HEAD~1",
 		Warning:     "If you already pushed this commit, you'll need to force push (not recommended for shared branches).",
 	},
 	{
-		Prefix:      "git push",
+		Command:     "git",
+		Subcommand:  "push",
 		Description: "Revert the pushed commits safely without rewriting history",
-		UndoCmd:     func(args string) string { return "git revert HEAD" },
+		Undo:        "git revert HEAD",
 		Warning:     "To completely remove it from remote history, use 'git push -f' after 'git reset HEAD~1'.",
 	},
 	{
-		Prefix:      "git merge",
-		Description: "Abort a merge in progress, or revert a completed merge",
-		UndoCmd: func(args string) string {
-			return "git merge --abort    # (if in progress)\ngit reset --merge HEAD~1  # (if completed)"
-		},
-		Warning: "",
+		Command:     "git",
+		Subcommand:  "merge",
+		Description: "Undo a completed merge",
+		Undo:        "git reset --merge ORIG_HEAD",
+		Warning:     "If the merge stopped on conflicts, use 'git merge --abort' instead.",
 	},
 	{
-		Prefix:      "git rebase",
+		Command:     "git",
+		Subcommand:  "rebase",
 		Description: "Abort an ongoing rebase",
-		UndoCmd:     func(args string) string { return "git rebase --abort" },
-		Warning:     "",
+		Undo:        "git rebase --abort",
+		Warning:     "If the rebase already finished, use 'git reset --hard ORIG_HEAD' instead.",
 	},
 	{
-		Prefix:      "git checkout",
+		Command:     "git",
+		Subcommand:  "checkout",
 		Description: "Go back to the previous branch you were on",
-		UndoCmd:     func(args string) string { return "git checkout -" },
-		Warning:     "",
+		Undo:        "git checkout -",
 	},
 	{
-		Prefix:      "tar -xf",
-		Description: "Remove all files that were just extracted (requires knowing the contents)",
-		UndoCmd:     func(args string) string { return "tar -tf " + args + " | xargs rm -rf" },
-		Warning:     "Be extremely careful. This will forcefully delete all files listed in the archive.",
+		Command:     "git",
+		Subcommand:  "switch",
+		Description: "Go back to the previous branch you were on",
+		Undo:        "git switch -",
 	},
 	{
-		Prefix:      "tar -xzf",
-		Description: "Remove all files that were just extracted",
-		UndoCmd:     func(args string) string { return "tar -tzf " + args + " | xargs rm -rf" },
-		Warning:     "Be extremely careful. This will forcefully delete all files listed in the archive.",
+		Command:     "git",
+		Subcommand:  "stash",
+		Args:        -1,
+		Description: "Put the stashed changes back",
+		Undo:        "git stash pop",
 	},
+
+	// Files
 	{
-		Prefix:      "mkdir",
+		Command:     "mv",
+		Args:        2,
+		Description: "Move the file back",
+		Undo:        "mv {2} {1}",
+		Warning:     "If the destination already existed it was overwritten. If it is a directory, the file is now inside it.",
+	},
+	{
+		Command:     "tar",
+		Flags:       []string{"x", "extract", "get"},
+		Description: "List the paths the archive created so you can review and remove them",
+		Undo:        "tar -tf {flag:f,file|<archive>}",
+		Warning:     "Files the archive overwrote cannot be restored.",
+	},
+	{
+		Command:     "mkdir",
 		Description: "Remove the created directory",
-		UndoCmd:     func(args string) string { return "rmdir " + args + "\n# or 'rm -rf " + args + "' if it isn't empty" },
-		Warning:     "",
+		Undo:        "rmdir {args}",
+		Warning:     "rmdir only removes empty directories.",
 	},
 	{
-		Prefix:      "touch",
+		Command:     "touch",
 		Description: "Delete the created file",
-		UndoCmd:     func(args string) string { return "rm " + args },
-		Warning:     "",
+		Undo:        "rm {args}",
+		Warning:     "Only do this if the file did not exist before; touch on an existing file just updates its timestamp.",
+	},
+	{
+		Command:     "ln",
+		Description: "Remove the created link",
+		Undo:        "rm {last}",
+	},
+	{
+		Command:     "chown",
+		Description: "Give the files back to their previous owner",
+		Undo:        "chown <owner> {last}",
+		Warning:     "The previous owner is not recorded anywhere, so you have to supply it.",
+	},
+	{
+		Command:     "chmod",
+		Description: "Restore the previous permissions",
+		Undo:        "chmod <mode> {last}",
+		Warning:     "The previous permissions are not recorded anywhere, so you have to supply them.",
 	},
+
+	// Services
 	{
-		Prefix:      "systemctl start",
+		Command:     "systemctl",
+		Subcommand:  "start",
 		Description: "Stop the started systemd service",
-		UndoCmd:     func(args string) string { return "sudo systemctl stop " + args },
-		Warning:     "",
+		Undo:        "systemctl stop {args}",
 	},
 	{
-		Prefix:      "systemctl stop",
+		Command:     "systemctl",
+		Subcommand:  "stop",
 		Description: "Start the stopped systemd service",
-		UndoCmd:     func(args string) string { return "sudo systemctl start " + args },
-		Warning:     "",
+		Undo:        "systemctl start {args}",
+	},
+	{
+		Command:     "systemctl",
+		Subcommand:  "enable",
+		Description: "Stop the service from starting at boot",
+		Undo:        "systemctl disable {args}",
+	},
+	{
+		Command:     "systemctl",
+		Subcommand:  "disable",
+		Description: "Start the service at boot again",
+		Undo:        "systemctl enable {args}",
+	},
+
+	// Containers
+	{
+		Command:     "docker",
+		Subcommand:  "run",
+		Description: "Stop and remove the container",
+		Undo:        "docker rm -f {flag:name|<container>}",
+		Warning:     "Anything written inside the container is lost with it.",
 	},
 	{
-		Prefix:      "chown",
-		Description: "Revert ownership recursively to the standard fallback (root) or previous owner",
-		UndoCmd: func(args string) string {
-			return "sudo chown root:root " + args + "   # (adjust to actual previous owner)"
-		},
-		Warning: "System cannot know the previous owner automatically.",
+		Command:     "docker",
+		Subcommand:  "start",
+		Description: "Stop the started containers",
+		Undo:        "docker stop {args}",
 	},
 	{
-		Prefix:      "chmod",
-		Description: "Restore permissions (No universal undo)",
-		UndoCmd: func(args string) string {
-			return "chmod 644 " + args + "   # for files\nchmod 755 " + args + "   # for directories"
-		},
-		Warning: "Actual previous permissions are lost.",
+		Command:     "docker",
+		Subcommand:  "stop",
+		Description: "Start the stopped containers",
+		Undo:        "docker start {args}",
 	},
 	{
-		Prefix:      "rm",
-		Description: "Attempt to undelete files",
-		UndoCmd: func(args string) string {
-			return "# No direct CLI undo. You must rely on undelete utilities (e.g. extundelete/testdisk) or backups."
-		},
-		Warning: "The 'rm' command is usually permanent in Unix-like systems. Act quickly and unmount the drive if it is critical.",
+		Command:     "kubectl",
+		Subcommand:  "apply",
+		Description: "Delete the resources the manifest describes",
+		Undo:        "kubectl delete -f {flag:f,filename|<manifest>}",
+		Warning:     "This also deletes resources that existed before the apply. To roll back a change instead, use 'kubectl rollout undo'.",
 	},
 	{
-		Prefix:      "npm install",
+		Command:     "kubectl",
+		Subcommand:  "create",
+		Description: "Delete the created resources",
+		Undo:        "kubectl delete -f {flag:f,filename|<manifest>}",
+	},
+
+	// Packages
+	{
+		Command:     "npm",
+		Subcommand:  "install",
 		Description: "Uninstall the added npm packages",
-		UndoCmd:     func(args string) string { return "npm uninstall " + args },
-		Warning:     "",
+		Undo:        "npm uninstall {args|<package>}",
 	},
 	{
-		Prefix:      "docker run",
-		Description: "Stop and remove the container",
-		UndoCmd:     func(args string) string { return "docker stop <container_id> && docker rm <container_id>" },
-		Warning:     "You need the ID or Name returned by the run command.",
+		Command:     "pip",
+		Subcommand:  "install",
+		Description: "Uninstall the added Python packages",
+		Undo:        "pip uninstall {args|<package>}",
+	},
+	{
+		Command:     "brew",
+		Subcommand:  "install",
+		Description: "Uninstall the added formulae",
+		Undo:        "brew uninstall {args|<formula>}",
+	},
+	{
+		Command:     "apt",
+		Subcommand:  "install",
+		Description: "Remove the added packages",
+		Undo:        "apt remove {args|<package>}",
 	},
 }
 
-func runUndo(cmd *cobra.Command, args []string) error {
-	var targetCmd string
+// undoStep is the suggestion for one command of the line being undone
+type undoStep struct {
+	Original    string
+	Description string
+	Undo        string // empty when the command cannot be undone
+	Warning     string
+}
+
+// undoTarget is a simple command ready to be matched against undoRules
+type undoTarget struct {
+	raw        string
+	sudo       bool
+	words      []string // words after any sudo and VAR=value prefix
+	parsed     *ParsedCommand
+	subcommand string
+	args       []string // arguments after the subcommand
+}
 
-	// 1. If arguments are provided, use them as the target command
-	if len(args) > 0 {
+func runUndo(cmd *cobra.Command, args []string) error {
+	targetCmd := undoCommand
+	if targetCmd == "" && len(args) > 0 {
 		targetCmd = strings.Join(args, " ")
-	} else {
-		// 2. Otherwise, fetch the last executed command from DB history
-		store, err := db.NewStorage(config.GetDatabasePath())
-		if err == nil {
-			defer store.Close()
-			hydrateHistoryFromShell(context.Background(), store)
-			// Fetch a bit more just in case the latest are 'wut' commands
-			history, err := store.GetHistory(context.Background(), 10)
-			if err == nil && len(history) > 0 {
-				for _, entry := range history {
-					entryCmd := strings.TrimSpace(entry.Command)
-					// Skip any wut commands in the history
-					if entryCmd != "" && !strings.HasPrefix(entryCmd, "wut") {
-						targetCmd = entryCmd
-						break
-					}
-				}
-			}
-		}
+	}
+	if targetCmd == "" {
+		targetCmd = lastUndoableCommand(cmd.Context())
 	}
 
+	targetCmd = strings.TrimSpace(targetCmd)
 	if targetCmd == "" {
 		fmt.Println("No recent command found to undo. Please explicitly provide a command: wut undo \"git add .\"")
 		return nil
 	}
-
-	targetCmd = strings.TrimSpace(targetCmd)
-	logger.Info("Attempting to undo command", "command", targetCmd)
+	logger.With("undo").Info("attempting to undo command", "command", targetCmd)
 
 	// Display header
 	headerStyle := lipgloss.NewStyle().
 		Bold(true).
-		Foreground(lipgloss.Color("#7C3AED"))
+		Foreground(ui.ColorBrand)
 	fmt.Println()
 	fmt.Println(headerStyle.Render("⏪ Undo Assistant"))
 	fmt.Println()
 	fmt.Printf("Command: %s\n\n", ui.Cyan(targetCmd))
 
-	// Find matching rule
-	for _, rule := range undoRules {
-		if strings.HasPrefix(targetCmd, rule.Prefix) {
+	steps := planUndo(targetCmd)
+	if len(steps) == 0 {
+		fmt.Println(ui.Muted("🤷 I do not have a specific undo rule for this command."))
+		fmt.Println(ui.Muted("Tip: Depending on the program, check its man page or undo feature."))
+		fmt.Println("\n" + ui.Mascot())
+		fmt.Println()
+		return nil
+	}
 
-			// Extract arguments passed to the command (if any)
-			cmdArgs := strings.TrimSpace(strings.TrimPrefix(targetCmd, rule.Prefix))
+	actionStyle := lipgloss.NewStyle().Foreground(ui.ColorSuccess).Bold(true)
+	warningStyle := lipgloss.NewStyle().Foreground(ui.ColorError) // Red
+	for _, step := range steps {
+		if len(steps) > 1 {
+			fmt.Println(ui.Muted(step.Original))
+		}
+		fmt.Printf("Action: %s\n", actionStyle.Render(step.Description))
+		if step.Undo != "" {
+			fmt.Println()
+			fmt.Println(ui.Accent(step.Undo))
+		}
+		if step.Warning != "" {
+			fmt.Println()
+			fmt.Printf("⚠️  %s\n", warningStyle.Render(step.Warning))
+		}
+		fmt.Println()
+	}
 
-			actionStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#10B981")).Bold(true)
-			fmt.Printf("Action: %s\n", actionStyle.Render(rule.Description))
+	undo := undoCommandLine(steps)
+	if undo == "" {
+		if undoRun {
+			fmt.Println(ui.Muted("There is no undo command to run for this."))
+		}
+		return nil
+	}
+	if !undoRun {
+		if terminal.IsInteractive() {
+			fmt.Println(ui.Muted("Nothing was run. Use 'wut undo --run' to run the undo command after confirming it."))
 			fmt.Println()
-			fmt.Println(ui.Accent(rule.UndoCmd(cmdArgs)))
+		}
+		return nil
+	}
+	return runPickedCommand(cmd.Context(), undo)
+}
+
+// lastUndoableCommand returns the most recent command in WUT history that is
+// not a wut command itself
+func lastUndoableCommand(ctx context.Context) string {
+	store, err := db.NewStorage(config.GetDatabasePath())
+	if err != nil {
+		return ""
+	}
+	defer store.Close()
+
+	hydrateHistoryFromShell(ctx, store)
+	// Fetch a bit more just in case the latest are 'wut' commands
+	history, err := store.GetHistory(ctx, 10)
+	if err != nil {
+		return ""
+	}
+	for _, entry := range history {
+		entryCmd := strings.TrimSpace(entry.Command)
+		// Skip any wut commands in the history
+		if entryCmd != "" && !strings.HasPrefix(entryCmd, "wut") {
+			return entryCmd
+		}
+	}
+	return ""
+}
+
+// planUndo suggests an undo for each command of line that has a rule, latest
+// command first, since that is the order they have to be undone in
+func planUndo(line string) []undoStep {
+	var steps []undoStep
+	targets := undoTargets(line)
+	for i := len(targets) - 1; i >= 0; i-- {
+		if step, ok := targets[i].plan(); ok {
+			steps = append(steps, step)
+		}
+	}
+	return steps
+}
+
+// undoCommandLine joins the undo commands of steps into one command line. It
+// is empty when a step has nothing to run, so a partial undo is never offered.
+func undoCommandLine(steps []undoStep) string {
+	commands := make([]string, 0, len(steps))
+	for _, step := range steps {
+		if step.Undo == "" {
+			return ""
+		}
+		commands = append(commands, step.Undo)
+	}
+	return strings.Join(commands, " && ")
+}
+
+// undoTargets splits a command line into its simple commands
+func undoTargets(line string) []undoTarget {
+	var targets []undoTarget
+	var words []string
+	start, end := -1, 0
+
+	finish := func() {
+		if len(words) > 0 {
+			targets = append(targets, newUndoTarget(line[start:end], words))
+		}
+		words = nil
+		start = -1
+	}
+	for _, tok := range shellwords.Lex(line) {
+		if tok.Kind == shellwords.Control {
+			finish()
+			continue
+		}
+		if start < 0 {
+			start = tok.Start
+		}
+		end = tok.End
+		if tok.Kind == shellwords.Word {
+			words = append(words, tok.Text)
+		}
+	}
+	finish()
+	return targets
+}
+
+func newUndoTarget(raw string, words []string) undoTarget {
+	t := undoTarget{raw: raw}
+	for len(words) > 0 && corrector.IsEnvAssignment(words[0]) {
+		words = words[1:]
+	}
+	if len(words) > 0 && words[0] == "sudo" {
+		t.sudo = true
+		words = words[1:]
+	}
+	t.words = words
+	t.parsed = parseWords(raw, words)
+	t.args = t.parsed.Args
+	if subcommandTools[t.parsed.Command] && len(t.args) > 0 && len(words) > 1 && !strings.HasPrefix(words[1], "-") {
+		t.subcommand = t.args[0]
+		t.args = t.args[1:]
+	}
+	return t
+}
 
-			if rule.Warning != "" {
-				fmt.Println()
-				warningStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#EF4444")) // Red
-				fmt.Printf("⚠️  %s\n", warningStyle.Render(rule.Warning))
+// plan finds the first rule matching the command and fills in its undo
+func (t undoTarget) plan() (undoStep, bool) {
+	for _, rule := range undoRules {
+		if !t.matches(rule) {
+			continue
+		}
+		step := undoStep{
+			Original:    t.raw,
+			Description: rule.Description,
+			Warning:     rule.Warning,
+		}
+		if rule.Undo != "" {
+			step.Undo = t.fill(rule.Undo)
+			if t.sudo {
+				step.Undo = "sudo " + step.Undo
 			}
-			fmt.Println()
-			return nil
 		}
+		return step, true
 	}
+	return undoStep{}, false
+}
 
-	// No rule matched
-	fmt.Println(ui.Muted("🤷 I do not have a specific undo rule for this command."))
-	fmt.Println(ui.Muted("Tip: Depending on the program, check its man page or undo feature."))
-	fmt.Println("\n" + ui.Mascot())
-	fmt.Println()
+func (t undoTarget) matches(rule undoRule) bool {
+	if t.parsed.Command != rule.Command || t.subcommand != rule.Subcommand {
+		return false
+	}
+	switch {
+	case rule.Args > 0 && len(t.args) != rule.Args:
+		return false
+	case rule.Args < 0 && len(t.args) > 0:
+		// git stash with a subcommand like list or pop is not a plain stash
+		return false
+	}
+	if len(rule.Flags) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(t.parsed.Flags, func(f ParsedFlag) bool {
+		return slices.Contains(rule.Flags, f.Name)
+	})
+}
 
-	return nil
+// fill expands the {...} values of an undo template
+func (t undoTarget) fill(template string) string {
+	var b strings.Builder
+	for {
+		open := strings.IndexByte(template, '{')
+		closing := -1
+		if open >= 0 {
+			closing = strings.IndexByte(template[open+1:], '}')
+		}
+		if closing < 0 {
+			b.WriteString(template)
+			return b.String()
+		}
+		b.WriteString(template[:open])
+		key, fallback, _ := strings.Cut(template[open+1:open+1+closing], "|")
+		if value := t.value(key); value != "" {
+			b.WriteString(value)
+		} else {
+			b.WriteString(fallback)
+		}
+		template = template[open+closing+2:]
+	}
+}
+
+// value returns a template value, quoted for the shell
+func (t undoTarget) value(key string) string {
+	if names, ok := strings.CutPrefix(key, "flag:"); ok {
+		return quoteUndoArg(undoFlagValue(t.words, strings.Split(names, ",")))
+	}
+	switch key {
+	case "args":
+		quoted := make([]string, len(t.args))
+		for i, arg := range t.args {
+			quoted[i] = quoteUndoArg(arg)
+		}
+		return strings.Join(quoted, " ")
+	case "last":
+		if len(t.args) == 0 {
+			return ""
+		}
+		return quoteUndoArg(t.args[len(t.args)-1])
+	}
+	if n, err := strconv.Atoi(key); err == nil && n >= 1 && n <= len(t.args) {
+		return quoteUndoArg(t.args[n-1])
+	}
+	return ""
+}
+
+// undoFlagValue returns the value given to any of the named flags, written as
+// -f value, -fvalue, --file value or --file=value
+func undoFlagValue(words, names []string) string {
+	for i, word := range words {
+		for _, name := range names {
+			flag := "--" + name
+			if len(name) == 1 {
+				flag = "-" + name
+			}
+			switch {
+			case word == flag && i+1 < len(words):
+				return words[i+1]
+			case strings.HasPrefix(word, flag+"="):
+				return strings.TrimPrefix(word, flag+"=")
+			case len(name) == 1 && strings.HasPrefix(word, "-") && !strings.HasPrefix(word, "--") && strings.HasSuffix(word, name) && i+1 < len(words):
+				// The value of the last flag in a cluster like tar's -xzf
+				return words[i+1]
+			}
+		}
+	}
+	return ""
+}
+
+// quoteUndoArg single-quotes an argument that the shell would otherwise split
+// or expand
+func quoteUndoArg(arg string) string {
+	if arg == "" || !strings.ContainsAny(arg, " \t\n'\"\\$`*?[]{}()<>|&;#~!") {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
 }
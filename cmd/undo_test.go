@@ -0,0 +1,55 @@
+package cmd
+
+import "testing"
+
+func TestPlanUndo(t *testing.T) {
+	tests := []struct {
+		command string
+		undo    string
+	}{
+		{"git add .", "git restore --staged ."},
+		{"git add -A", "git restore --staged ."},
+		{"git add 'my file.txt' main.go", "git restore --staged 'my file.txt' main.go"},
+		{"git commit -m 'wip'", "git reset --soft HEAD~1"},
+		{"git reset --hard HEAD~3", "git reset --hard ORIG_HEAD"},
+		{"git stash", "git stash pop"},
+		{"docker run -d --name web -p 80:80 nginx", "docker rm -f web"},
+		{"docker run --name=db postgres", "docker rm -f db"},
+		{"docker run -it alpine sh", "docker rm -f <container>"},
+		{"mv notes.txt docs/notes.md", "mv docs/notes.md notes.txt"},
+		{"tar -xzf release.tar.gz -C /opt", "tar -tf release.tar.gz"},
+		{"tar --extract --file=backup.tar", "tar -tf backup.tar"},
+		{"kubectl apply -f deploy.yaml", "kubectl delete -f deploy.yaml"},
+		{"kubectl apply --filename k8s/", "kubectl delete -f k8s/"},
+		{"sudo systemctl start nginx", "sudo systemctl stop nginx"},
+		{"FOO=bar npm install left-pad", "npm uninstall left-pad"},
+		// Later commands are undone first
+		{"git add . && git commit -m wip", "git reset --soft HEAD~1 && git restore --staged ."},
+	}
+	for _, tt := range tests {
+		steps := planUndo(tt.command)
+		if got := undoCommandLine(steps); got != tt.undo {
+			t.Errorf("planUndo(%q) = %q, want %q", tt.command, got, tt.undo)
+		}
+	}
+}
+
+func TestPlanUndoIrreversible(t *testing.T) {
+	for _, command := range []string{"rm -rf build", "dd if=/dev/zero of=/dev/sdb", "mkdir out && rm -r tmp"} {
+		steps := planUndo(command)
+		if len(steps) == 0 || steps[0].Warning == "" {
+			t.Errorf("planUndo(%q) = %+v, want a warning", command, steps)
+		}
+		if got := undoCommandLine(steps); got != "" {
+			t.Errorf("planUndo(%q) offered %q to run", command, got)
+		}
+	}
+
+	// Commands without a rule get no plan, and mv with several sources is
+	// not simply reversible
+	for _, command := range []string{"ls -la", "mv a b dir/", "git stash list"} {
+		if steps := planUndo(command); len(steps) != 0 {
+			t.Errorf("planUndo(%q) = %+v, want no steps", command, steps)
+		}
+	}
+}
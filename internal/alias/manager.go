@@ -3,6 +3,7 @@ package alias
 
 import (
 	"bufio"
+	"encoding/json"
 	"fmt"
 	"maps"
 	"os"
@@ -384,7 +385,15 @@ func (m *Manager) loadFromShell() (map[string]string, error) {
 	return aliases, scanner.Err()
 }
 
-// loadFromWut loads wut-specific aliases
+// wutAlias is how an alias is kept in aliases.json
+type wutAlias struct {
+	Command     string `json:"command"`
+	Description string `json:"description"`
+	Category    string `json:"category"`
+	AutoGen     bool   `json:"auto_gen"`
+}
+
+// loadFromWut loads the aliases added with Add from aliases.json
 func (m *Manager) loadFromWut() (map[string]*Alias, error) {
 	aliases := make(map[string]*Alias)
 
@@ -394,48 +403,48 @@ func (m *Manager) loadFromWut() (map[string]*Alias, error) {
 		return aliases, err
 	}
 
-	// Simple parsing (in real implementation, use JSON)
-	_ = data
+	var stored map[string]wutAlias
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return aliases, fmt.Errorf("failed to parse %s: %w", aliasFile, err)
+	}
+	for name, a := range stored {
+		aliases[name] = &Alias{
+			Name:        name,
+			Command:     a.Command,
+			Description: a.Description,
+			Category:    a.Category,
+			AutoGen:     a.AutoGen,
+		}
+	}
 	return aliases, nil
 }
 
-// save saves aliases to disk
+// save saves aliases to disk. Aliases read from the shell config stay
+// there and are not copied.
 func (m *Manager) save() error {
 	if err := os.MkdirAll(m.configDir, 0755); err != nil {
 		return err
 	}
 
-	// Save to wut aliases file
-	aliasFile := filepath.Join(m.configDir, "aliases.json")
-
-	// Build JSON manually for now
-	var sb strings.Builder
-	sb.WriteString("{\n")
-
-	// Get sorted list of names
-	var names []string
-	for name := range m.aliases {
-		names = append(names, name)
-	}
-	sort.Strings(names)
-
-	first := true
-	for _, name := range names {
-		alias := m.aliases[name]
-		if !first {
-			sb.WriteString(",\n")
+	stored := make(map[string]wutAlias)
+	for name, alias := range m.aliases {
+		if alias.Category == "shell" {
+			continue
+		}
+		stored[name] = wutAlias{
+			Command:     alias.Command,
+			Description: alias.Description,
+			Category:    alias.Category,
+			AutoGen:     alias.AutoGen,
 		}
-		first = false
-		sb.WriteString(fmt.Sprintf(`  "%s": {
-    "command": "%s",
-    "description": "%s",
-    "category": "%s",
-    "auto_gen": %t
-  }`, alias.Name, alias.Command, alias.Description, alias.Category, alias.AutoGen))
 	}
-	sb.WriteString("\n}\n")
+	data, err := json.MarshalIndent(stored, "", "  ")
+	if err != nil {
+		return err
+	}
 
-	return os.WriteFile(aliasFile, []byte(sb.String()), 0644)
+	aliasFile := filepath.Join(m.configDir, "aliases.json")
+	return os.WriteFile(aliasFile, append(data, '\n'), 0644)
 }
 
 // GetPopularAliases returns commonly useful aliases
@@ -6,6 +6,8 @@ import (
 	"unicode"
 
 	"wut/internal/performance"
+
+	"github.com/hbollon/go-edlib"
 )
 
 // Profile captures normalized command intent for matching and ranking.
@@ -119,12 +121,12 @@ func Score(query Query, profile Profile, matcher *performance.FastMatcher) (floa
 		score += 180
 	}
 
-	if coverage, ordered := tokenCoverage(query.Tokens, profile.Tokens); coverage > 0 {
+	if coverage, ordered, all := tokenCoverage(query.Tokens, profile.Tokens); coverage > 0 {
 		score += coverage * 460
 		if ordered {
 			score += 120
 		}
-		if coverage == 1 {
+		if all {
 			score += 140
 		}
 	}
@@ -194,7 +196,7 @@ func HasAnchor(query Query, profile Profile, matcher *performance.FastMatcher) b
 		}
 	}
 
-	if coverage, _ := tokenCoverage(query.Tokens, profile.Tokens); coverage >= 0.5 {
+	if coverage, _, _ := tokenCoverage(query.Tokens, profile.Tokens); coverage >= 0.5 {
 		return true
 	}
 
@@ -396,27 +398,49 @@ func detectIntent(executable string, remainder []string) (string, string, []stri
 	return subcommand, strings.Join(intentParts, " "), nil
 }
 
-func tokenCoverage(queryTokens, targetTokens []string) (float64, bool) {
+// Coverage reports how much of the query the profile's tokens cover, from 0
+// to 1. Each query token counts for its closest profile token, in any
+// order, and a misspelt one for part, so "kubctl get pod" covers
+// "kubectl get pods" almost fully.
+func Coverage(query Query, profile Profile) float64 {
+	coverage, _, _ := tokenCoverage(query.Tokens, profile.Tokens)
+	return coverage
+}
+
+// SameToken reports whether a typed token names target, exactly or with a
+// typo
+func SameToken(token, target string) bool {
+	return token == target || typoSimilarity(token, target) > 0
+}
+
+// tokenCoverage scores each query token by its closest target token and
+// returns the average, whether the matched tokens appear in query order, and
+// whether every query token matched something
+func tokenCoverage(queryTokens, targetTokens []string) (float64, bool, bool) {
 	if len(queryTokens) == 0 || len(targetTokens) == 0 {
-		return 0, false
+		return 0, false, false
 	}
 
+	total := 0.0
 	matched := 0
 	lastIdx := -1
 	ordered := true
 
 	for _, queryToken := range queryTokens {
-		foundIdx := -1
+		foundIdx, quality := -1, 0.0
 		for i, targetToken := range targetTokens {
-			if !tokenMatches(queryToken, targetToken) {
-				continue
+			if tokenMatches(queryToken, targetToken) {
+				foundIdx, quality = i, 1
+				break
+			}
+			if q := typoSimilarity(queryToken, targetToken); q > quality {
+				foundIdx, quality = i, q
 			}
-			foundIdx = i
-			break
 		}
 		if foundIdx == -1 {
 			continue
 		}
+		total += quality
 		matched++
 		if lastIdx > foundIdx {
 			ordered = false
@@ -426,7 +450,32 @@ func tokenCoverage(queryTokens, targetTokens []string) (float64, bool) {
 		}
 	}
 
-	return float64(matched) / float64(len(queryTokens)), ordered && matched > 1
+	return total / float64(len(queryTokens)), ordered && matched > 1, matched == len(queryTokens)
+}
+
+// typoWeight is what a token with a typo counts for next to an exact one,
+// before its edits are taken off
+const typoWeight = 0.9
+
+// typoSimilarity scores a query token that is a typo of target, from 0 for
+// none to typoWeight. Tokens of three or four letters may be one edit away
+// and longer ones two, using the same edit distance as the corrector.
+func typoSimilarity(query, target string) float64 {
+	if len(query) < 3 || len(target) < 3 {
+		return 0
+	}
+	maxEdits := 1
+	if len(query) >= 5 {
+		maxEdits = 2
+	}
+	if diff := len(query) - len(target); diff < -maxEdits || diff > maxEdits {
+		return 0
+	}
+	d := edlib.OSADamerauLevenshteinDistance(query, target)
+	if d == 0 || d > maxEdits {
+		return 0
+	}
+	return typoWeight * (1 - float64(d)/float64(max(len(query), len(target))))
 }
 
 func tokenMatches(queryToken, targetToken string) bool {
@@ -0,0 +1,102 @@
+package commandsearch
+
+import (
+	"sort"
+	"testing"
+
+	"wut/internal/performance"
+)
+
+var candidateCommands = []string{
+	"git commit --amend", "git commit -m wip", "git status", "git log --oneline", "git push origin main",
+	"git checkout main", "git stash pop", "git rebase -i HEAD~3",
+	"kubectl get pods", "kubectl get svc", "kubectl describe pod web", "kubectl logs -f web",
+	"docker compose up -d", "docker compose down", "docker ps", "docker build -t app .",
+	"npm run build", "npm install", "go test ./...", "terraform plan",
+}
+
+// rank returns the candidates matching query, best first
+func rank(query string) []string {
+	matcher := performance.NewFastMatcher(false, 0.25, 3)
+	q := ParseQuery(query)
+	type scored struct {
+		command string
+		score   float64
+	}
+	var results []scored
+	for _, command := range candidateCommands {
+		profile := BuildProfile(command)
+		if !HasAnchor(q, profile, matcher) {
+			continue
+		}
+		if score, ok := Score(q, profile, matcher); ok {
+			results = append(results, scored{command, score})
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool { return results[i].score > results[j].score })
+
+	ranked := make([]string, len(results))
+	for i, r := range results {
+		ranked[i] = r.command
+	}
+	return ranked
+}
+
+func TestTypoTolerantRanking(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"git comit amend", "git commit --amend"},
+		{"kubctl get pod", "kubectl get pods"},
+		{"dockr comps up", "docker compose up -d"},
+		{"amend comit git", "git commit --amend"},
+		{"get pods kubectl", "kubectl get pods"},
+		{"terrafrom plan", "terraform plan"},
+		{"git status", "git status"},
+	}
+	for _, tt := range tests {
+		ranked := rank(tt.query)
+		if len(ranked) == 0 || ranked[0] != tt.want {
+			t.Errorf("rank(%q) = %q, want %q first", tt.query, ranked, tt.want)
+		}
+	}
+}
+
+func TestCoverage(t *testing.T) {
+	tests := []struct {
+		query, command string
+		min, max       float64
+	}{
+		{"kubectl get pods", "kubectl get pods", 1, 1},
+		{"kubctl get pod", "kubectl get pods", 0.9, 0.99},
+		{"get pod", "kubectl get pods", 1, 1},
+		{"kubctl get pod", "docker ps", 0, 0},
+		{"zzz", "git status", 0, 0},
+	}
+	for _, tt := range tests {
+		got := Coverage(ParseQuery(tt.query), BuildProfile(tt.command))
+		if got < tt.min || got > tt.max {
+			t.Errorf("Coverage(%q, %q) = %.3f, want %.2f to %.2f", tt.query, tt.command, got, tt.min, tt.max)
+		}
+	}
+}
+
+func TestSameToken(t *testing.T) {
+	tests := []struct {
+		token, target string
+		want          bool
+	}{
+		{"git", "git", true},
+		{"kubctl", "kubectl", true},
+		{"comps", "compose", true},
+		{"gti", "git", true},
+		{"ls", "sl", false},
+		{"docker", "podman", false},
+	}
+	for _, tt := range tests {
+		if got := SameToken(tt.token, tt.target); got != tt.want {
+			t.Errorf("SameToken(%q, %q) = %v, want %v", tt.token, tt.target, got, tt.want)
+		}
+	}
+}
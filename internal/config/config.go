@@ -7,6 +7,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -17,16 +18,18 @@ import (
 
 // Config holds all configuration for the application
 type Config struct {
-	App      AppConfig      `mapstructure:"app" yaml:"app"`
-	Fuzzy    FuzzyConfig    `mapstructure:"fuzzy" yaml:"fuzzy"`
-	UI       UIConfig       `mapstructure:"ui" yaml:"ui"`
-	Database DatabaseConfig `mapstructure:"database" yaml:"database"`
-	History  HistoryConfig  `mapstructure:"history" yaml:"history"`
-	Context  ContextConfig  `mapstructure:"context" yaml:"context"`
-	Shell    ShellConfig    `mapstructure:"shell" yaml:"shell"`
-	Privacy  PrivacyConfig  `mapstructure:"privacy" yaml:"privacy"`
-	Logging  LoggingConfig  `mapstructure:"logging" yaml:"logging"`
-	TLDR     TLDRConfig     `mapstructure:"tldr" yaml:"tldr"`
+	App       AppConfig       `mapstructure:"app" yaml:"app"`
+	Fuzzy     FuzzyConfig     `mapstructure:"fuzzy" yaml:"fuzzy"`
+	UI        UIConfig        `mapstructure:"ui" yaml:"ui"`
+	Database  DatabaseConfig  `mapstructure:"database" yaml:"database"`
+	History   HistoryConfig   `mapstructure:"history" yaml:"history"`
+	Context   ContextConfig   `mapstructure:"context" yaml:"context"`
+	Shell     ShellConfig     `mapstructure:"shell" yaml:"shell"`
+	Privacy   PrivacyConfig   `mapstructure:"privacy" yaml:"privacy"`
+	Logging   LoggingConfig   `mapstructure:"logging" yaml:"logging"`
+	TLDR      TLDRConfig      `mapstructure:"tldr" yaml:"tldr"`
+	Smart     SmartConfig     `mapstructure:"smart" yaml:"smart"`
+	Corrector CorrectorConfig `mapstructure:"corrector" yaml:"corrector"`
 }
 
 // AppConfig holds application settings
@@ -49,6 +52,8 @@ type FuzzyConfig struct {
 	CaseSensitive bool    `mapstructure:"case_sensitive" yaml:"case_sensitive"`
 	MaxDistance   int     `mapstructure:"max_distance" yaml:"max_distance"`
 	Threshold     float64 `mapstructure:"threshold" yaml:"threshold"`
+	// Algorithm scores typos: hybrid, levenshtein, damerau or jaro_winkler
+	Algorithm string `mapstructure:"algorithm" yaml:"algorithm"`
 }
 
 // UIConfig holds UI settings
@@ -59,6 +64,9 @@ type UIConfig struct {
 	SyntaxHighlighting bool              `mapstructure:"syntax_highlighting" yaml:"syntax_highlighting"`
 	Pagination         int               `mapstructure:"pagination" yaml:"pagination"`
 	Colors             map[string]string `mapstructure:"colors" yaml:"colors"`
+	ConfirmBeforeExec  bool              `mapstructure:"confirm_before_exec" yaml:"confirm_before_exec"`
+	ShowPreview        bool              `mapstructure:"show_preview" yaml:"show_preview"`
+	ConfirmDangerous   bool              `mapstructure:"confirm_dangerous" yaml:"confirm_dangerous"`
 }
 
 // DatabaseConfig holds database settings
@@ -68,6 +76,7 @@ type DatabaseConfig struct {
 	MaxSize        int    `mapstructure:"max_size" yaml:"max_size"`
 	BackupEnabled  bool   `mapstructure:"backup_enabled" yaml:"backup_enabled"`
 	BackupInterval int    `mapstructure:"backup_interval" yaml:"backup_interval"`
+	MaxBackups     int    `mapstructure:"max_backups" yaml:"max_backups"`
 }
 
 // HistoryConfig holds history settings
@@ -109,6 +118,7 @@ type LoggingConfig struct {
 	MaxSize    int    `mapstructure:"max_size" yaml:"max_size"`
 	MaxBackups int    `mapstructure:"max_backups" yaml:"max_backups"`
 	MaxAge     int    `mapstructure:"max_age" yaml:"max_age"`
+	Format     string `mapstructure:"format" yaml:"format"` // text or json
 }
 
 // TLDRConfig holds TLDR pages settings
@@ -122,6 +132,47 @@ type TLDRConfig struct {
 	DefaultPlatform  string `mapstructure:"default_platform" yaml:"default_platform"`
 }
 
+// CorrectorConfig holds typo correction settings
+type CorrectorConfig struct {
+	MinConfidence    float64 `mapstructure:"min_confidence" yaml:"min_confidence"`
+	KeyboardAware    bool    `mapstructure:"keyboard_aware" yaml:"keyboard_aware"`
+	HistoryThreshold float64 `mapstructure:"history_threshold" yaml:"history_threshold"`
+	UseHistory       bool    `mapstructure:"use_history" yaml:"use_history"`
+	// SemanticSpellcheck corrects misspelled words of natural-language
+	// queries to intent keywords before matching them
+	SemanticSpellcheck bool `mapstructure:"semantic_spellcheck" yaml:"semantic_spellcheck"`
+}
+
+// SmartConfig holds smart suggestion settings
+type SmartConfig struct {
+	Weights ScoringWeightsConfig `mapstructure:"weights" yaml:"weights"`
+	// Sources are external suggestion sources declared by name and command,
+	// run along with the executables in the sources directory
+	Sources []SourceConfig `mapstructure:"sources" yaml:"sources,omitempty"`
+	// DisabledSources names the external sources that are not run
+	DisabledSources []string `mapstructure:"disabled_sources" yaml:"disabled_sources,omitempty"`
+	SourceTimeout   int      `mapstructure:"source_timeout" yaml:"source_timeout"` // milliseconds
+}
+
+// SourceConfig declares an external suggestion source: a command that is
+// given the query and prints suggestions as JSON lines
+type SourceConfig struct {
+	Name    string `mapstructure:"name" yaml:"name"`
+	Command string `mapstructure:"command" yaml:"command"`
+}
+
+// ScoringWeightsConfig overrides the smart engine's ranking weights. Unset
+// weights keep their defaults.
+type ScoringWeightsConfig struct {
+	ExactMatch       *float64 `mapstructure:"exact_match" yaml:"exact_match,omitempty"`
+	PrefixMatch      *float64 `mapstructure:"prefix_match" yaml:"prefix_match,omitempty"`
+	ContainsMatch    *float64 `mapstructure:"contains_match" yaml:"contains_match,omitempty"`
+	FuzzyMatch       *float64 `mapstructure:"fuzzy_match" yaml:"fuzzy_match,omitempty"`
+	HistoryFreq      *float64 `mapstructure:"history_freq" yaml:"history_freq,omitempty"`
+	Recency          *float64 `mapstructure:"recency" yaml:"recency,omitempty"`
+	ContextRelevance *float64 `mapstructure:"context_relevance" yaml:"context_relevance,omitempty"`
+}
+
 var (
 	// globalConfig holds the global configuration instance
 	globalConfig *Config
@@ -131,18 +182,19 @@ var (
 	configPath string
 )
 
-// Load loads the configuration from file and environment variables
+// Load loads the configuration from file and environment variables. An
+// empty path loads the file set with SetConfigPath, or the default one.
 func Load(path string) (*Config, error) {
-	if path == "" {
-		path = getDefaultConfigPath()
+	if path != "" {
+		SetConfigPath(path)
 	}
-	configPath = path
+	path = GetConfigPath()
 
 	viper.SetConfigFile(path)
 	viper.SetConfigType("yaml")
 
 	// Set default values
-	setDefaults()
+	setDefaults(viper.GetViper())
 
 	// Read environment variables
 	viper.SetEnvPrefix("WUT")
@@ -237,56 +289,75 @@ func Save() error {
 	return nil
 }
 
-// setDefaults sets default configuration values
-func setDefaults() {
-	viper.SetDefault("app.name", "wut")
-	viper.SetDefault("app.version", "0.3.0")
-	viper.SetDefault("app.debug", false)
-	viper.SetDefault("app.initialized", false)
-
-	viper.SetDefault("fuzzy.enabled", true)
-	viper.SetDefault("fuzzy.case_sensitive", false)
-	viper.SetDefault("fuzzy.max_distance", 3)
-	viper.SetDefault("fuzzy.threshold", 0.6)
-
-	viper.SetDefault("ui.theme", "auto")
-	viper.SetDefault("ui.show_confidence", true)
-	viper.SetDefault("ui.show_explanations", true)
-	viper.SetDefault("ui.pagination", 10)
-
-	viper.SetDefault("database.type", "bbolt")
-	viper.SetDefault("database.path", getDefaultDatabasePath())
-	viper.SetDefault("database.max_size", 100)
-
-	viper.SetDefault("history.enabled", true)
-	viper.SetDefault("history.max_entries", 10000)
-	viper.SetDefault("shell.enabled", true)
-	viper.SetDefault("shell.hooks.bash", true)
-	viper.SetDefault("shell.hooks.zsh", true)
-	viper.SetDefault("shell.hooks.fish", true)
-	viper.SetDefault("shell.hooks.powershell", true)
-	viper.SetDefault("shell.hooks.pwsh", true)
-	viper.SetDefault("shell.hooks.cmd", true)
-	viper.SetDefault("shell.hooks.nushell", true)
-	viper.SetDefault("shell.hooks.xonsh", true)
-	viper.SetDefault("shell.hooks.elvish", true)
-
-	viper.SetDefault("logging.level", "info")
-	viper.SetDefault("logging.file", getDefaultLogPath())
+// setDefaults sets default configuration values on v
+func setDefaults(v *viper.Viper) {
+	v.SetDefault("app.name", "wut")
+	v.SetDefault("app.version", "0.3.0")
+	v.SetDefault("app.debug", false)
+	v.SetDefault("app.initialized", false)
+
+	v.SetDefault("fuzzy.enabled", true)
+	v.SetDefault("fuzzy.case_sensitive", false)
+	v.SetDefault("fuzzy.max_distance", 3)
+	v.SetDefault("fuzzy.threshold", 0.6)
+	v.SetDefault("fuzzy.algorithm", "hybrid")
+
+	v.SetDefault("ui.theme", "auto")
+	v.SetDefault("ui.show_confidence", true)
+	v.SetDefault("ui.show_explanations", true)
+	v.SetDefault("ui.pagination", 10)
+	v.SetDefault("ui.confirm_before_exec", true)
+	v.SetDefault("ui.show_preview", true)
+	v.SetDefault("ui.confirm_dangerous", true)
+
+	v.SetDefault("database.type", "bbolt")
+	v.SetDefault("database.path", getDefaultDatabasePath())
+	v.SetDefault("database.max_size", 100)
+	v.SetDefault("database.backup_enabled", true)
+	v.SetDefault("database.backup_interval", 24)
+	v.SetDefault("database.max_backups", 5)
+
+	v.SetDefault("history.enabled", true)
+	v.SetDefault("history.max_entries", 10000)
+	v.SetDefault("history.track_timing", false)
+	v.SetDefault("shell.enabled", true)
+	v.SetDefault("shell.hooks.bash", true)
+	v.SetDefault("shell.hooks.zsh", true)
+	v.SetDefault("shell.hooks.fish", true)
+	v.SetDefault("shell.hooks.powershell", true)
+	v.SetDefault("shell.hooks.pwsh", true)
+	v.SetDefault("shell.hooks.cmd", true)
+	v.SetDefault("shell.hooks.nushell", true)
+	v.SetDefault("shell.hooks.xonsh", true)
+	v.SetDefault("shell.hooks.elvish", true)
+
+	v.SetDefault("logging.level", "info")
+	v.SetDefault("logging.file", getDefaultLogPath())
+	v.SetDefault("logging.max_size", 10)
+	v.SetDefault("logging.max_backups", 5)
+	v.SetDefault("logging.max_age", 30)
+	v.SetDefault("logging.format", "text")
 
 	// TLDR defaults
-	viper.SetDefault("tldr.enabled", true)
-	viper.SetDefault("tldr.auto_sync", true)
-	viper.SetDefault("tldr.auto_sync_interval", 7) // 7 days
-	viper.SetDefault("tldr.offline_mode", false)
-	viper.SetDefault("tldr.auto_detect_online", true)
-	viper.SetDefault("tldr.max_cache_age", 30) // 30 days
-	viper.SetDefault("tldr.default_platform", "common")
+	v.SetDefault("tldr.enabled", true)
+	v.SetDefault("tldr.auto_sync", true)
+	v.SetDefault("tldr.auto_sync_interval", 7) // 7 days
+	v.SetDefault("tldr.offline_mode", false)
+	v.SetDefault("tldr.auto_detect_online", true)
+	v.SetDefault("tldr.max_cache_age", 30) // 30 days
+	v.SetDefault("tldr.default_platform", "common")
+
+	v.SetDefault("smart.source_timeout", 1000)
+
+	v.SetDefault("corrector.min_confidence", 0.4)
+	v.SetDefault("corrector.keyboard_aware", false)
+	v.SetDefault("corrector.history_threshold", 0.5)
+	v.SetDefault("corrector.use_history", true)
+	v.SetDefault("corrector.semantic_spellcheck", false)
 }
 
-// createDefaultConfig creates a default configuration file
-func createDefaultConfig(path string) error {
-	defaultConfig := `# WUT - Command Helper
+// defaultConfigYAML is the config file written for a new or reset config
+const defaultConfigYAML = `# WUT - Command Helper
 # Default Configuration File
 
 app:
@@ -300,6 +371,8 @@ fuzzy:
   case_sensitive: false
   max_distance: 3
   threshold: 0.6
+  # How typos are scored: hybrid, levenshtein, damerau or jaro_winkler
+  algorithm: hybrid
 
 ui:
   theme: "auto"
@@ -313,6 +386,9 @@ ui:
     warning: "#F59E0B"
     error: "#EF4444"
     info: "#3B82F6"
+  confirm_before_exec: true
+  show_preview: true
+  confirm_dangerous: true
 
 database:
   type: "bbolt"
@@ -320,13 +396,15 @@ database:
   max_size: 100
   backup_enabled: true
   backup_interval: 24
+  max_backups: 5
 
 history:
   enabled: true
   max_entries: 10000
   track_frequency: true
   track_context: true
-  track_timing: true
+  # Record the exit status and duration of each command run in the shell
+  track_timing: false
 
 context:
   enabled: true
@@ -350,7 +428,7 @@ shell:
 
 privacy:
   local_only: true
-  encrypt_data: true
+  encrypt_data: false
   anonymize_commands: false
   share_analytics: false
 
@@ -360,10 +438,66 @@ logging:
   max_size: 10
   max_backups: 5
   max_age: 30
+  # text, or json for one JSON object per line
+  format: "text"
+
+smart:
+  # Ranking weights; unset weights use the built-in defaults
+  weights: {}
+  #   exact_match: 1.0
+  #   prefix_match: 0.9
+  #   contains_match: 0.7
+  #   fuzzy_match: 0.5
+  #   history_freq: 0.3
+  #   recency: 0.2
+  #   context_relevance: 0.4
+  # External suggestion sources, besides the executables in
+  # ~/.config/wut/sources; each is given the query and prints JSON lines
+  sources: []
+  #   - name: runbooks
+  #     command: "runbook-search --wut"
+  # Sources that are not run, by name
+  disabled_sources: []
+  # How long a source may take before its suggestions are left out, in ms
+  source_timeout: 1000
+
+corrector:
+  # Corrections less confident than this (0-1) are not suggested
+  min_confidence: 0.4
+  # Treat hitting a neighbouring QWERTY key as half a typo
+  keyboard_aware: false
+  # Past commands scoring below this (0-1), by closeness, use and recency,
+  # are not proposed
+  history_threshold: 0.5
+  # Propose similar past commands as corrections
+  use_history: true
+  # Correct misspelled words of natural-language queries, such as
+  # "lst runing containrs", before matching them to commands
+  semantic_spellcheck: false
 
 `
 
-	return os.WriteFile(path, []byte(defaultConfig), 0644)
+// Defaults returns the configuration Reset restores, without touching the
+// config file
+func Defaults() (*Config, error) {
+	v := viper.New()
+	v.SetConfigType("yaml")
+	setDefaults(v)
+	if err := v.ReadConfig(strings.NewReader(defaultConfigYAML)); err != nil {
+		return nil, fmt.Errorf("failed to read default config: %w", err)
+	}
+
+	var cfg Config
+	if err := v.Unmarshal(&cfg); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal default config: %w", err)
+	}
+	expandPaths(&cfg)
+	return &cfg, nil
+}
+
+// createDefaultConfig creates a default configuration file
+func createDefaultConfig(path string) error {
+	return os.WriteFile(path, []byte(defaultConfigYAML), 0644)
 }
 
 // expandPaths expands environment variables and home directory in paths
@@ -399,6 +533,12 @@ func GetDataDir() string {
 	return filepath.Dir(GetDatabasePath())
 }
 
+// GetSourcesDir returns the directory whose executables are run as external
+// suggestion sources
+func GetSourcesDir() string {
+	return filepath.Join(getDefaultAppDir(), "sources")
+}
+
 // EnsureDirs ensures all necessary directories exist
 func EnsureDirs() error {
 	homeDir, _ := os.UserHomeDir()
@@ -432,6 +572,12 @@ func GetDatabasePath() string {
 	return ResolveDatabasePath(Get().Database.Path)
 }
 
+// GetDaemonSocketPath returns the unix socket wut daemon listens on, in a
+// directory of its own that only the user can enter
+func GetDaemonSocketPath() string {
+	return filepath.Join(GetDataDir(), "daemon", "wut.sock")
+}
+
 // GetTLDRDatabasePath returns the canonical path to the TLDR cache database file.
 func GetTLDRDatabasePath() string {
 	return filepath.Join(filepath.Dir(GetDatabasePath()), "tldr.db")
@@ -458,7 +604,7 @@ func ResolveDatabasePath(path string) string {
 	}
 
 	switch strings.ToLower(filepath.Ext(cleaned)) {
-	case ".db", ".bolt", ".bbolt":
+	case ".db", ".bolt", ".bbolt", ".sqlite", ".sqlite3":
 		return cleaned
 	default:
 		return filepath.Join(cleaned, "wut.db")
@@ -486,11 +632,31 @@ func getDefaultLogPath() string {
 	return filepath.Join(getDefaultAppDir(), "logs", "wut.log")
 }
 
-// GetConfigPath returns the current configuration file path
+// SetConfigPath points Load, Save and GetConfigPath at path instead of the
+// active profile's config file. A leading ~ and environment variables are expanded
+// and a relative path is made absolute; an empty path restores the default.
+func SetConfigPath(path string) {
+	if path == "" {
+		configPath = ""
+		return
+	}
+	homeDir, _ := os.UserHomeDir()
+	path = expandPath(path, homeDir)
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	configPath = path
+}
+
+// GetConfigPath returns the current configuration file path: the one set
+// with SetConfigPath, or else the active profile's
 func GetConfigPath() string {
 	if configPath != "" {
 		return configPath
 	}
+	if path, err := ProfilePath(ActiveProfile()); err == nil {
+		return path
+	}
 	return getDefaultConfigPath()
 }
 
@@ -507,7 +673,7 @@ func Reset() error {
 	viper.Reset()
 
 	// Recreate default config
-	setDefaults()
+	setDefaults(viper.GetViper())
 
 	// Create new config file
 	if err := createDefaultConfig(path); err != nil {
@@ -524,8 +690,46 @@ func Reset() error {
 	return nil
 }
 
-// Edit opens the config file in the default editor
+// Edit opens the config file in the default editor, keeping a copy of it
+// as it was in a .bak file next to it
 func Edit() error {
+	if _, err := BackupForEdit(); err != nil {
+		return err
+	}
+	return OpenEditor()
+}
+
+// BackupForEdit creates the config file if needed and copies it to a .bak
+// file next to it, so an edit that breaks it can be undone with
+// RestoreBackup. It returns the backup's path.
+func BackupForEdit() (string, error) {
+	path := GetConfigPath()
+
+	// Ensure file exists
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		if err := createDefaultConfig(path); err != nil {
+			return "", fmt.Errorf("failed to create config file: %w", err)
+		}
+	}
+
+	backup := path + ".bak"
+	if err := copyFile(path, backup); err != nil {
+		return "", fmt.Errorf("failed to back up config: %w", err)
+	}
+	return backup, nil
+}
+
+// RestoreBackup puts the copy made by BackupForEdit back in place
+func RestoreBackup(backup string) error {
+	if err := copyFile(backup, GetConfigPath()); err != nil {
+		return fmt.Errorf("failed to restore config: %w", err)
+	}
+	return nil
+}
+
+// OpenEditor opens the config file in $EDITOR, or the first common editor
+// found, and waits for it to exit
+func OpenEditor() error {
 	editor := os.Getenv("EDITOR")
 	if editor == "" {
 		// Try common editors
@@ -542,16 +746,7 @@ func Edit() error {
 		return fmt.Errorf("no editor found. Set EDITOR environment variable")
 	}
 
-	path := GetConfigPath()
-
-	// Ensure file exists
-	if _, err := os.Stat(path); os.IsNotExist(err) {
-		if err := createDefaultConfig(path); err != nil {
-			return fmt.Errorf("failed to create config file: %w", err)
-		}
-	}
-
-	cmd := exec.Command(editor, path)
+	cmd := exec.Command(editor, GetConfigPath())
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
@@ -559,6 +754,24 @@ func Edit() error {
 	return cmd.Run()
 }
 
+// ValidateFile reports whether the file at path parses as a config: valid
+// YAML whose values have the types of the Config fields
+func ValidateFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+	return validateConfig(data)
+}
+
+func validateConfig(data []byte) error {
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return fmt.Errorf("invalid config file: %w", err)
+	}
+	return nil
+}
+
 // Import imports configuration from a file
 func Import(path string) error {
 	// Read source file
@@ -568,18 +781,15 @@ func Import(path string) error {
 	}
 
 	// Validate YAML
-	var importedCfg Config
-	if err := yaml.Unmarshal(data, &importedCfg); err != nil {
-		return fmt.Errorf("invalid config file: %w", err)
+	if err := validateConfig(data); err != nil {
+		return err
 	}
 
 	// Backup current config
 	currentPath := GetConfigPath()
-	backupPath := currentPath + ".backup." + time.Now().Format("20060102-150405")
-	if _, err := os.Stat(currentPath); err == nil {
-		if err := copyFile(currentPath, backupPath); err != nil {
-			return fmt.Errorf("failed to create backup: %w", err)
-		}
+	backupPath, err := backupConfig()
+	if err != nil {
+		return err
 	}
 
 	// Copy new config
@@ -601,6 +811,92 @@ func Import(path string) error {
 	return nil
 }
 
+// Merge imports the settings in a file over the current configuration,
+// leaving the ones it does not mention as they are. set stores one setting,
+// given by its dot-notation key, in cfg and rejects invalid values; nothing
+// is saved unless every setting is accepted.
+func Merge(path string, set func(cfg *Config, key, value string) error) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read import file: %w", err)
+	}
+	if err := validateConfig(data); err != nil {
+		return err
+	}
+
+	var tree map[string]any
+	if err := yaml.Unmarshal(data, &tree); err != nil {
+		return fmt.Errorf("invalid config file: %w", err)
+	}
+	values := make(map[string]string)
+	flattenSettings("", tree, values)
+
+	cfg, err := Get().clone()
+	if err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if err := set(cfg, key, values[key]); err != nil {
+			return err
+		}
+	}
+
+	if _, err := backupConfig(); err != nil {
+		return err
+	}
+	Set(cfg)
+	return Save()
+}
+
+// flattenSettings collects the settings of a parsed config file under their
+// dot-notation keys, e.g. ui.theme
+func flattenSettings(prefix string, tree map[string]any, values map[string]string) {
+	for name, value := range tree {
+		key := name
+		if prefix != "" {
+			key = prefix + "." + name
+		}
+		switch v := value.(type) {
+		case map[string]any:
+			flattenSettings(key, v, values)
+		case nil:
+		default:
+			values[key] = fmt.Sprint(v)
+		}
+	}
+}
+
+// clone returns a deep copy of c
+func (c *Config) clone() (*Config, error) {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy config: %w", err)
+	}
+	var out Config
+	if err := yaml.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("failed to copy config: %w", err)
+	}
+	return &out, nil
+}
+
+// backupConfig copies the config file to a timestamped backup next to it and
+// returns the backup's path. Without a config file there is nothing to copy.
+func backupConfig() (string, error) {
+	currentPath := GetConfigPath()
+	backupPath := currentPath + ".backup." + time.Now().Format("20060102-150405")
+	if _, err := os.Stat(currentPath); err == nil {
+		if err := copyFile(currentPath, backupPath); err != nil {
+			return "", fmt.Errorf("failed to create backup: %w", err)
+		}
+	}
+	return backupPath, nil
+}
+
 // Export exports configuration to a file
 func Export(path string) error {
 	return copyFile(GetConfigPath(), path)
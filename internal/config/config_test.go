@@ -0,0 +1,151 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestValidateFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"valid", "ui:\n  theme: dark\nfuzzy:\n  max_distance: 3\n", ""},
+		{"empty", "", ""},
+		{"malformed", "ui:\n  theme: [dark\n", "invalid config file: yaml: "},
+		{"wrong type", "fuzzy:\n  max_distance: three\n", "invalid config file: yaml: unmarshal errors"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			err := ValidateFile(path)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("ValidateFile() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.HasPrefix(err.Error(), tt.wantErr) {
+				t.Errorf("ValidateFile() error = %v, want it to start with %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBackupForEdit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	SetConfigPath(path)
+	t.Cleanup(func() { SetConfigPath("") })
+
+	// A missing config is created before it is backed up
+	backup, err := BackupForEdit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if backup != path+".bak" {
+		t.Errorf("BackupForEdit() = %q, want %q", backup, path+".bak")
+	}
+	original, err := os.ReadFile(path)
+	if err != nil || len(original) == 0 {
+		t.Fatalf("config not created: %v", err)
+	}
+
+	if err := os.WriteFile(path, []byte("ui: [broken\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateFile(path); err == nil {
+		t.Fatal("ValidateFile() of a broken edit succeeded")
+	}
+	if err := RestoreBackup(backup); err != nil {
+		t.Fatal(err)
+	}
+	if restored, _ := os.ReadFile(path); string(restored) != string(original) {
+		t.Error("RestoreBackup() did not bring back the config from before the edit")
+	}
+}
+
+func TestDefaultsMatchReset(t *testing.T) {
+	SetConfigPath(filepath.Join(t.TempDir(), "config.yaml"))
+	t.Cleanup(func() { SetConfigPath("") })
+
+	if err := Reset(); err != nil {
+		t.Fatal(err)
+	}
+	defaults, err := Defaults()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := Get(); !reflect.DeepEqual(defaults, got) {
+		t.Errorf("Defaults() = %+v\nwant the config after Reset() %+v", defaults, got)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	dir := t.TempDir()
+	SetConfigPath(filepath.Join(dir, "config.yaml"))
+	t.Cleanup(func() { SetConfigPath("") })
+	if err := Reset(); err != nil {
+		t.Fatal(err)
+	}
+
+	override := filepath.Join(dir, "override.yaml")
+	if err := os.WriteFile(override, []byte("ui:\n  theme: dark\nfuzzy:\n  threshold: 0.8\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var keys []string
+	set := func(cfg *Config, key, value string) error {
+		keys = append(keys, key+"="+value)
+		switch key {
+		case "ui.theme":
+			cfg.UI.Theme = value
+		case "fuzzy.threshold":
+			threshold, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return err
+			}
+			cfg.Fuzzy.Threshold = threshold
+		default:
+			return fmt.Errorf("unknown config key: %s", key)
+		}
+		return nil
+	}
+	if err := Merge(override, set); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"fuzzy.threshold=0.8", "ui.theme=dark"}; !slices.Equal(keys, want) {
+		t.Errorf("Merge() set %q, want %q", keys, want)
+	}
+
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.UI.Theme != "dark" || cfg.Fuzzy.Threshold != 0.8 {
+		t.Errorf("merged theme = %q, threshold = %v, want dark and 0.8", cfg.UI.Theme, cfg.Fuzzy.Threshold)
+	}
+	if !cfg.Fuzzy.Enabled || cfg.Fuzzy.MaxDistance != 3 || cfg.UI.Pagination != 10 {
+		t.Errorf("Merge() changed settings missing from the file: %+v %+v", cfg.Fuzzy, cfg.UI)
+	}
+
+	// A rejected setting leaves the config as it was
+	if err := os.WriteFile(override, []byte("ui:\n  theme: light\n  nope: 1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Merge(override, set); err == nil {
+		t.Fatal("Merge() with an unknown key succeeded")
+	}
+	if cfg, _ := Load(""); cfg.UI.Theme != "dark" {
+		t.Errorf("theme after a failed Merge() = %q, want dark", cfg.UI.Theme)
+	}
+}
@@ -0,0 +1,126 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// DefaultProfile is the profile kept in config.yaml
+const DefaultProfile = "default"
+
+// profileNameRe is what a profile name may look like, so it is safe as a
+// file name
+var profileNameRe = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+
+// getProfilesDir returns the directory holding the named profiles
+func getProfilesDir() string {
+	return filepath.Join(getDefaultAppDir(), "profiles")
+}
+
+// getActiveProfileFile returns the file remembering the active profile
+func getActiveProfileFile() string {
+	return filepath.Join(getDefaultAppDir(), "profile")
+}
+
+// ProfilePath returns the config file of the named profile. The default
+// profile is config.yaml; the others live in the profiles directory.
+func ProfilePath(name string) (string, error) {
+	if name == "" || name == DefaultProfile {
+		return getDefaultConfigPath(), nil
+	}
+	if !profileNameRe.MatchString(name) {
+		return "", fmt.Errorf("invalid profile name %q: use letters, digits, - and _", name)
+	}
+	return filepath.Join(getProfilesDir(), name+".yaml"), nil
+}
+
+// ActiveProfile returns the profile commands use when no config file is
+// given. A profile whose file is gone falls back to the default.
+func ActiveProfile() string {
+	data, err := os.ReadFile(getActiveProfileFile())
+	if err != nil {
+		return DefaultProfile
+	}
+	name := strings.TrimSpace(string(data))
+	path, err := ProfilePath(name)
+	if err != nil || name == DefaultProfile {
+		return DefaultProfile
+	}
+	if _, err := os.Stat(path); err != nil {
+		return DefaultProfile
+	}
+	return name
+}
+
+// SetActiveProfile makes the named profile the one later commands load.
+// The profile must exist.
+func SetActiveProfile(name string) error {
+	path, err := ProfilePath(name)
+	if err != nil {
+		return err
+	}
+	if name != DefaultProfile {
+		if _, err := os.Stat(path); err != nil {
+			return fmt.Errorf("profile %q does not exist; create it with 'wut config --new-profile %s'", name, name)
+		}
+	}
+
+	if err := os.MkdirAll(getDefaultAppDir(), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if err := os.WriteFile(getActiveProfileFile(), []byte(name+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to save active profile: %w", err)
+	}
+	return nil
+}
+
+// ListProfiles returns the default profile followed by the named ones, in
+// name order
+func ListProfiles() ([]string, error) {
+	profiles := []string{DefaultProfile}
+	entries, err := os.ReadDir(getProfilesDir())
+	if os.IsNotExist(err) {
+		return profiles, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read profiles: %w", err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".yaml")
+		if !ok || entry.IsDir() || !profileNameRe.MatchString(name) || name == DefaultProfile {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return append(profiles, names...), nil
+}
+
+// NewProfile creates the named profile as a copy of the current config file
+// and returns its path
+func NewProfile(name string) (string, error) {
+	if name == DefaultProfile {
+		return "", fmt.Errorf("profile %q already exists", name)
+	}
+	path, err := ProfilePath(name)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(path); err == nil {
+		return "", fmt.Errorf("profile %q already exists", name)
+	}
+
+	if err := os.MkdirAll(getProfilesDir(), 0755); err != nil {
+		return "", fmt.Errorf("failed to create profiles directory: %w", err)
+	}
+	if err := copyFile(GetConfigPath(), path); err != nil {
+		return "", fmt.Errorf("failed to create profile: %w", err)
+	}
+	return path, nil
+}
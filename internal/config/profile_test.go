@@ -0,0 +1,75 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestProfiles(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	SetConfigPath("")
+	t.Cleanup(func() { SetConfigPath("") })
+
+	appDir := filepath.Join(dir, "wut")
+	if err := os.MkdirAll(appDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(appDir, "config.yaml"), []byte("ui:\n  theme: dark\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := ActiveProfile(); got != DefaultProfile {
+		t.Errorf("ActiveProfile() = %q, want %q", got, DefaultProfile)
+	}
+	if err := SetActiveProfile("work"); err == nil {
+		t.Error("SetActiveProfile() of a missing profile succeeded")
+	}
+
+	path, err := NewProfile("work")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != "ui:\n  theme: dark\n" {
+		t.Errorf("NewProfile() wrote %q, %v, want a copy of config.yaml", data, err)
+	}
+	if _, err := NewProfile("work"); err == nil {
+		t.Error("NewProfile() of an existing profile succeeded")
+	}
+	for _, name := range []string{"../work", "a/b", ".hidden", "default"} {
+		if _, err := NewProfile(name); err == nil {
+			t.Errorf("NewProfile(%q) succeeded", name)
+		}
+	}
+	if _, err := NewProfile("home"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := SetActiveProfile("work"); err != nil {
+		t.Fatal(err)
+	}
+	if got := ActiveProfile(); got != "work" {
+		t.Errorf("ActiveProfile() = %q, want work", got)
+	}
+	if got := GetConfigPath(); got != path {
+		t.Errorf("GetConfigPath() = %q, want the work profile %q", got, path)
+	}
+
+	profiles, err := ListProfiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"default", "home", "work"}; !slices.Equal(profiles, want) {
+		t.Errorf("ListProfiles() = %q, want %q", profiles, want)
+	}
+
+	// A removed profile falls back to the default
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if got := GetConfigPath(); got != filepath.Join(appDir, "config.yaml") {
+		t.Errorf("GetConfigPath() after removing the profile = %q, want config.yaml", got)
+	}
+}
@@ -4,6 +4,7 @@ package context
 import (
 	"context"
 	"fmt"
+	"math"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -14,16 +15,28 @@ import (
 
 // Context holds information about the current environment
 type Context struct {
-	WorkingDir   string
-	HomeDir      string
-	IsGitRepo    bool
-	GitBranch    string
-	GitStatus    GitStatus
-	ProjectType  string
+	WorkingDir     string
+	HomeDir        string
+	IsGitRepo      bool
+	GitRoot        string
+	GitBranch      string
+	GitStatus      GitStatus
+	ProjectType    string
+	SecondaryTypes []string // further project types found next to ProjectType
+	// Projects are the project types found in the working directory, its
+	// parents up to the git root and the rest of the repository, nearest
+	// first, one per type
+	Projects     []Project
 	ProjectFiles []string
-	Environment  map[string]string
-	Shell        string
-	OS           string
+	// ComposeServices and ComposeProfiles come from the Compose file of the
+	// nearest docker project; ComposeCommand is "docker compose" or
+	// "docker-compose", whichever is installed
+	ComposeServices []string
+	ComposeProfiles []string
+	ComposeCommand  string
+	Environment     map[string]string
+	Shell           string
+	OS              string
 }
 
 // GitStatus represents git repository status
@@ -34,6 +47,41 @@ type GitStatus struct {
 	UntrackedFiles []string
 	Ahead          int
 	Behind         int
+	// ConflictedFiles are unmerged paths left by a merge, rebase or stash pop
+	ConflictedFiles []string
+	HasConflicts    bool
+	// PullBlockingFiles are local changes to files the upstream also changed,
+	// which make git refuse to pull until they are stashed or committed
+	PullBlockingFiles []string
+	// Branch is the checked out branch, or the branch being rebased; it is
+	// empty on a detached HEAD
+	Branch string
+	// Upstream is the branch's upstream, such as "origin/main", and Remote
+	// the remote a branch without one would be pushed to
+	Upstream string
+	Remote   string
+	// Operation is the merge, rebase, cherry-pick or revert in progress
+	Operation string
+}
+
+// Git operations that stop for the user to continue or abort them
+const (
+	GitMerge      = "merge"
+	GitRebase     = "rebase"
+	GitCherryPick = "cherry-pick"
+	GitRevert     = "revert"
+)
+
+// Project is a project type found by its marker file
+type Project struct {
+	Type string
+	Dir  string
+	// Evidence is the marker file, relative to the git root, such as
+	// "api/go.mod"
+	Evidence string
+	// Distance counts the directories between the working directory and
+	// Dir: 0 for the working directory, 1 for its parent or a child
+	Distance int
 }
 
 // Analyzer analyzes the current context
@@ -57,10 +105,16 @@ func (a *Analyzer) Analyze(ctx context.Context) (*Context, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to get working directory: %w", err)
 	}
-	if wd == "" {
+	return a.AnalyzeDir(ctx, wd)
+}
+
+// AnalyzeDir analyzes the context of dir as if it were the working
+// directory, for a process answering for another one
+func (a *Analyzer) AnalyzeDir(ctx context.Context, dir string) (*Context, error) {
+	if dir == "" {
 		return nil, fmt.Errorf("working directory is empty")
 	}
-	a.context.WorkingDir = wd
+	a.context.WorkingDir = dir
 
 	// Get home directory
 	home, err := os.UserHomeDir()
@@ -80,6 +134,7 @@ func (a *Analyzer) Analyze(ctx context.Context) (*Context, error) {
 
 	// Detect project type
 	a.detectProjectType()
+	a.detectCompose()
 
 	// Get environment variables
 	a.getEnvironment()
@@ -87,6 +142,13 @@ func (a *Analyzer) Analyze(ctx context.Context) (*Context, error) {
 	return a.context, nil
 }
 
+// git returns a git command run in the working directory
+func (a *Analyzer) git(ctx context.Context, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = a.context.WorkingDir
+	return cmd
+}
+
 // analyzeGit analyzes git repository context
 func (a *Analyzer) analyzeGit(ctx context.Context) {
 	// Check if in a git repository
@@ -97,9 +159,10 @@ func (a *Analyzer) analyzeGit(ctx context.Context) {
 	}
 
 	a.context.IsGitRepo = true
+	a.context.GitRoot = filepath.Dir(gitDir)
 
 	// Get current branch
-	if branch, err := exec.CommandContext(ctx, "git", "rev-parse", "--abbrev-ref", "HEAD").Output(); err == nil {
+	if branch, err := a.git(ctx, "rev-parse", "--abbrev-ref", "HEAD").Output(); err == nil {
 		a.context.GitBranch = strings.TrimSpace(string(branch))
 	}
 
@@ -112,7 +175,7 @@ func (a *Analyzer) getGitStatus(ctx context.Context) GitStatus {
 	status := GitStatus{}
 
 	// Check if clean
-	if output, err := exec.CommandContext(ctx, "git", "status", "--porcelain").Output(); err == nil {
+	if output, err := a.git(ctx, "status", "--porcelain").Output(); err == nil {
 		lines := strings.Split(strings.TrimSpace(string(output)), "\n")
 		status.IsClean = len(lines) == 0 || (len(lines) == 1 && lines[0] == "")
 
@@ -142,6 +205,11 @@ func (a *Analyzer) getGitStatus(ctx context.Context) GitStatus {
 				}
 			}
 
+			if isUnmerged(indexStatus, byte(workTreeStatus)) {
+				status.ConflictedFiles = append(status.ConflictedFiles, filename)
+				continue
+			}
+
 			switch indexStatus {
 			case 'M', 'A', 'D', 'R', 'C':
 				status.StagedFiles = append(status.StagedFiles, filename)
@@ -157,7 +225,7 @@ func (a *Analyzer) getGitStatus(ctx context.Context) GitStatus {
 	}
 
 	// Get ahead/behind
-	if output, err := exec.CommandContext(ctx, "git", "rev-list", "--left-right", "--count", "HEAD...@{u}").Output(); err == nil {
+	if output, err := a.git(ctx, "rev-list", "--left-right", "--count", "HEAD...@{u}").Output(); err == nil {
 		var ahead, behind int
 		if _, err := fmt.Sscanf(string(output), "%d\t%d", &ahead, &behind); err == nil {
 			status.Ahead = ahead
@@ -165,10 +233,130 @@ func (a *Analyzer) getGitStatus(ctx context.Context) GitStatus {
 		}
 	}
 
+	status.HasConflicts = len(status.ConflictedFiles) > 0
+	if output, err := a.git(ctx, "rev-parse", "--absolute-git-dir").Output(); err == nil {
+		gitDir := strings.TrimSpace(string(output))
+		status.Operation = gitOperation(gitDir)
+		if status.Operation == GitRebase {
+			status.Branch = rebaseBranch(gitDir)
+		}
+	}
+	if status.Branch == "" {
+		if output, err := a.git(ctx, "symbolic-ref", "--short", "-q", "HEAD").Output(); err == nil {
+			status.Branch = strings.TrimSpace(string(output))
+		}
+	}
+	if output, err := a.git(ctx, "rev-parse", "--abbrev-ref", "--symbolic-full-name", "@{u}").Output(); err == nil {
+		status.Upstream = strings.TrimSpace(string(output))
+	}
+	if status.Upstream == "" {
+		if output, err := a.git(ctx, "remote").Output(); err == nil {
+			status.Remote = pushRemote(strings.Fields(string(output)))
+		}
+	}
+
+	if status.Behind > 0 && (len(status.ModifiedFiles) > 0 || len(status.StagedFiles) > 0) {
+		status.PullBlockingFiles = a.pullBlockingFiles(ctx, status)
+	}
+
 	return status
 }
 
-// detectProjectType detects the project type based on files
+// gitOperation returns the operation in progress in gitDir, read from the
+// files git keeps until it is continued or aborted
+func gitOperation(gitDir string) string {
+	switch {
+	case fileExists(filepath.Join(gitDir, "rebase-merge")), fileExists(filepath.Join(gitDir, "rebase-apply")):
+		return GitRebase
+	case fileExists(filepath.Join(gitDir, "MERGE_HEAD")):
+		return GitMerge
+	case fileExists(filepath.Join(gitDir, "CHERRY_PICK_HEAD")):
+		return GitCherryPick
+	case fileExists(filepath.Join(gitDir, "REVERT_HEAD")):
+		return GitRevert
+	}
+	return ""
+}
+
+// rebaseBranch returns the branch being rebased, since HEAD is detached
+// while a rebase runs
+func rebaseBranch(gitDir string) string {
+	for _, dir := range []string{"rebase-merge", "rebase-apply"} {
+		if data, err := os.ReadFile(filepath.Join(gitDir, dir, "head-name")); err == nil {
+			return strings.TrimPrefix(strings.TrimSpace(string(data)), "refs/heads/")
+		}
+	}
+	return ""
+}
+
+// pushRemote picks the remote to publish a new branch to: origin when there
+// is one, otherwise the only remote
+func pushRemote(remotes []string) string {
+	if slices.Contains(remotes, "origin") {
+		return "origin"
+	}
+	if len(remotes) == 1 {
+		return remotes[0]
+	}
+	return ""
+}
+
+// isUnmerged reports whether a porcelain status pair marks a conflict:
+// either side is U, or both sides added or deleted the path
+func isUnmerged(index, workTree byte) bool {
+	return index == 'U' || workTree == 'U' ||
+		(index == 'A' && workTree == 'A') || (index == 'D' && workTree == 'D')
+}
+
+// pullBlockingFiles returns the locally changed files that the upstream also
+// changed since the branches diverged
+func (a *Analyzer) pullBlockingFiles(ctx context.Context, status GitStatus) []string {
+	output, err := a.git(ctx, "diff", "--name-only", "HEAD...@{u}").Output()
+	if err != nil {
+		return nil
+	}
+	incoming := make(map[string]bool)
+	for _, name := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		if name != "" {
+			incoming[name] = true
+		}
+	}
+
+	var blocking []string
+	for _, name := range append(status.StagedFiles, status.ModifiedFiles...) {
+		if incoming[name] && !slices.Contains(blocking, name) {
+			blocking = append(blocking, name)
+		}
+	}
+	return blocking
+}
+
+// projectMarkers maps project types to the files that identify them, in
+// priority order: the first type found becomes the primary one
+var projectMarkers = []struct {
+	projectType string
+	patterns    []string
+}{
+	{"go", []string{"go.mod"}},
+	{"rust", []string{"Cargo.toml"}},
+	{"nodejs", []string{"package.json"}},
+	{"python", []string{"requirements.txt", "setup.py", "pyproject.toml", "Pipfile"}},
+	{"maven", []string{"pom.xml"}},
+	{"gradle", []string{"build.gradle", "build.gradle.kts", "settings.gradle", "settings.gradle.kts"}},
+	{"php", []string{"composer.json"}},
+	{"ruby", []string{"Gemfile"}},
+	{"dotnet", []string{"*.csproj", "*.fsproj", "*.vbproj", "*.sln"}},
+	{"terraform", []string{"*.tf", "*.tfvars"}},
+	{"docker", []string{"Dockerfile", "docker-compose.yml", "docker-compose.yaml", "compose.yml", "compose.yaml", ".dockerignore"}},
+	{"ansible", []string{"ansible.cfg", "playbook.yml", "playbook.yaml"}},
+	{"kubernetes", []string{"kustomization.yaml", "kustomization.yml", "Chart.yaml", "skaffold.yaml"}},
+}
+
+// detectProjectType detects the project type based on files. A directory
+// can hold several projects (a Go service with a Dockerfile); the first
+// match becomes ProjectType and the others SecondaryTypes. When the working
+// directory has none, the nearest parent up to the git root that has one
+// decides.
 func (a *Analyzer) detectProjectType() {
 	files, err := os.ReadDir(a.context.WorkingDir)
 	if err != nil {
@@ -183,52 +371,223 @@ func (a *Analyzer) detectProjectType() {
 	}
 	a.context.ProjectFiles = projectFiles
 
-	// Detect project type based on files (priority order)
-	// Check primary project files first
-	primaryPatterns := map[string][]string{
-		"go":     {"go.mod"},
-		"nodejs": {"package.json"},
-		"python": {"requirements.txt", "setup.py", "pyproject.toml", "Pipfile"},
-		"rust":   {"Cargo.toml"},
-		"ruby":   {"Gemfile"},
-		"java":   {"pom.xml", "build.gradle", "build.gradle.kts"},
-		"dotnet": {"*.csproj", "*.sln"},
-	}
-
-	// Check primary patterns first
-	for projectType, patterns := range primaryPatterns {
-		for _, pattern := range patterns {
-			if matchPattern(projectFiles, pattern) {
-				a.context.ProjectType = projectType
-				return
+	a.context.Projects = DetectProjects(a.context.WorkingDir, a.context.GitRoot)
+	for _, project := range a.context.Projects {
+		if !isAncestor(project.Dir, a.context.WorkingDir) {
+			continue
+		}
+		if a.context.ProjectType == "" {
+			a.context.ProjectType = project.Type
+		} else if project.Dir == a.projectDir() {
+			a.context.SecondaryTypes = append(a.context.SecondaryTypes, project.Type)
+		}
+	}
+	if a.context.ProjectType != "" {
+		return
+	}
+
+	// Check for git repo last
+	if a.context.IsGitRepo {
+		a.context.ProjectType = "git"
+		return
+	}
+
+	a.context.ProjectType = "unknown"
+}
+
+// detectCompose reads the services of the nearest docker project's Compose
+// file. A file that cannot be parsed leaves them empty.
+func (a *Analyzer) detectCompose() {
+	for _, project := range a.context.Projects {
+		if project.Type != "docker" {
+			continue
+		}
+		compose, err := ReadCompose(project.Dir)
+		if err != nil || compose == nil {
+			return
+		}
+		a.context.ComposeServices = compose.Services
+		a.context.ComposeProfiles = compose.Profiles
+		a.context.ComposeCommand = ComposeCommand()
+		return
+	}
+}
+
+// projectDir returns the directory ProjectType was found in
+func (a *Analyzer) projectDir() string {
+	for _, project := range a.context.Projects {
+		if project.Type == a.context.ProjectType {
+			return project.Dir
+		}
+	}
+	return ""
+}
+
+// DetectProjectTypes returns every project type whose marker files are among
+// files, most specific first
+func DetectProjectTypes(files []string) []string {
+	var types []string
+	for _, marker := range detectMarkers(files) {
+		types = append(types, marker.projectType)
+	}
+	return types
+}
+
+// foundMarker is a project type and the file that identified it
+type foundMarker struct {
+	projectType string
+	file        string
+}
+
+// detectMarkers returns the project types whose marker files are among
+// files, most specific first
+func detectMarkers(files []string) []foundMarker {
+	var found []foundMarker
+	for _, marker := range projectMarkers {
+		for _, pattern := range marker.patterns {
+			if file, ok := matchFile(files, pattern); ok {
+				found = append(found, foundMarker{marker.projectType, file})
+				break
 			}
 		}
 	}
+	return found
+}
+
+const (
+	// maxProjectDepth is how deep below the git root sub-projects are
+	// looked for
+	maxProjectDepth = 3
+
+	// maxProjectDirs caps the directories read, so huge repositories
+	// stay fast
+	maxProjectDirs = 300
+)
 
-	// Check secondary patterns (docker, terraform, etc.)
-	secondaryPatterns := map[string][]string{
-		"docker":     {"Dockerfile", "docker-compose.yml", "docker-compose.yaml", ".dockerignore"},
-		"terraform":  {"*.tf", "*.tfvars", "main.tf"},
-		"ansible":    {"ansible.cfg", "inventory", "playbook.yml", "playbook.yaml"},
-		"kubernetes": {"*.yaml", "*.yml", "k8s", "manifests"},
+// skipProjectDirs are never searched for sub-projects
+var skipProjectDirs = map[string]bool{
+	"node_modules": true, "vendor": true, "target": true, "dist": true,
+	"build": true, "venv": true, "__pycache__": true, "testdata": true,
+}
+
+// DetectProjects finds the projects around workingDir: in it, in its
+// parents up to gitRoot and, inside a git repository, in the directories
+// below gitRoot, as monorepos keep a Go backend in ./api and a Node
+// frontend in ./web. Each type is reported once, for its nearest directory,
+// nearest first. At the same distance parents come before other
+// directories, which follow in name order.
+func DetectProjects(workingDir, gitRoot string) []Project {
+	root := gitRoot
+	if root == "" || !isAncestor(root, workingDir) {
+		root = workingDir
 	}
 
-	for projectType, patterns := range secondaryPatterns {
-		for _, pattern := range patterns {
-			if matchPattern(projectFiles, pattern) {
-				a.context.ProjectType = projectType
-				return
+	// Parents first, so they win ties against children
+	dirs := []string{workingDir}
+	for dir := workingDir; dir != root; {
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+		dirs = append(dirs, dir)
+	}
+	if gitRoot != "" {
+		dirs = append(dirs, subdirectories(root)...)
+	}
+
+	var projects []Project
+	seen := make(map[string]bool)
+	for _, dir := range dirs {
+		if seen[dir] {
+			continue
+		}
+		seen[dir] = true
+
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		var files []string
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				files = append(files, entry.Name())
+			}
+		}
+		for _, marker := range detectMarkers(files) {
+			evidence, err := filepath.Rel(root, filepath.Join(dir, marker.file))
+			if err != nil {
+				evidence = marker.file
 			}
+			projects = append(projects, Project{
+				Type:     marker.projectType,
+				Dir:      dir,
+				Evidence: filepath.ToSlash(evidence),
+				Distance: dirDistance(workingDir, dir),
+			})
 		}
 	}
 
-	// Check for git repo last
-	if a.context.IsGitRepo {
-		a.context.ProjectType = "git"
-		return
+	slices.SortStableFunc(projects, func(a, b Project) int {
+		return a.Distance - b.Distance
+	})
+	nearest := projects[:0]
+	types := make(map[string]bool)
+	for _, project := range projects {
+		if !types[project.Type] {
+			types[project.Type] = true
+			nearest = append(nearest, project)
+		}
 	}
+	return nearest
+}
 
-	a.context.ProjectType = "unknown"
+// subdirectories lists the directories below root, breadth first, skipping
+// hidden and dependency directories
+func subdirectories(root string) []string {
+	var dirs []string
+	level := []string{root}
+	for depth := 0; depth < maxProjectDepth && len(level) > 0; depth++ {
+		var next []string
+		for _, dir := range level {
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				continue
+			}
+			for _, entry := range entries {
+				name := entry.Name()
+				if !entry.IsDir() || strings.HasPrefix(name, ".") || skipProjectDirs[name] {
+					continue
+				}
+				if len(dirs) >= maxProjectDirs {
+					return dirs
+				}
+				sub := filepath.Join(dir, name)
+				dirs = append(dirs, sub)
+				next = append(next, sub)
+			}
+		}
+		level = next
+	}
+	return dirs
+}
+
+// dirDistance counts the steps from one directory to another, up and down
+func dirDistance(from, to string) int {
+	rel, err := filepath.Rel(from, to)
+	if err != nil {
+		return math.MaxInt32
+	}
+	if rel == "." {
+		return 0
+	}
+	return len(strings.Split(rel, string(filepath.Separator)))
+}
+
+// isAncestor reports whether dir is path or one of its parents
+func isAncestor(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
 }
 
 // getEnvironment gets relevant environment variables
@@ -265,7 +624,15 @@ func (a *Analyzer) GetRelevantCommands() []string {
 	}
 
 	// Project type specific commands
-	commands = append(commands, a.getProjectCommands()...)
+	commands = append(commands, projectCommands(a.context.ProjectType)...)
+	for _, projectType := range a.context.SecondaryTypes {
+		commands = append(commands, projectCommands(projectType)...)
+	}
+	for _, project := range a.context.Projects {
+		if project.Type != a.context.ProjectType && !slices.Contains(a.context.SecondaryTypes, project.Type) {
+			commands = append(commands, projectCommands(project.Type)...)
+		}
+	}
 
 	return commands
 }
@@ -275,6 +642,9 @@ func (a *Analyzer) getGitCommands() []string {
 	var commands []string
 
 	// Based on git status
+	if a.context.GitStatus.HasConflicts {
+		commands = append(commands, "git diff --name-only --diff-filter=U")
+	}
 	if !a.context.GitStatus.IsClean {
 		if len(a.context.GitStatus.StagedFiles) > 0 {
 			commands = append(commands, "git commit -m 'message'")
@@ -288,11 +658,22 @@ func (a *Analyzer) getGitCommands() []string {
 	}
 
 	// Based on branch status
-	if a.context.GitStatus.Ahead > 0 {
-		commands = append(commands, "git push")
+	status := a.context.GitStatus
+	if status.Operation != "" {
+		commands = append(commands, "git "+status.Operation+" --continue", "git "+status.Operation+" --abort")
+	} else if status.Upstream == "" && status.Branch != "" && status.Remote != "" {
+		commands = append(commands, "git push -u "+status.Remote+" "+status.Branch)
+	}
+	if len(status.PullBlockingFiles) > 0 {
+		commands = append(commands, "git stash")
 	}
-	if a.context.GitStatus.Behind > 0 {
-		commands = append(commands, "git pull")
+	switch {
+	case status.Ahead > 0 && status.Behind > 0:
+		commands = append(commands, "git pull --rebase", "git pull --no-rebase")
+	case status.Ahead > 0:
+		commands = append(commands, "git push")
+	case status.Behind > 0:
+		commands = append(commands, "git pull --rebase")
 	}
 
 	// Always relevant
@@ -301,9 +682,9 @@ func (a *Analyzer) getGitCommands() []string {
 	return commands
 }
 
-// getProjectCommands returns commands based on project type
-func (a *Analyzer) getProjectCommands() []string {
-	switch a.context.ProjectType {
+// projectCommands returns commands based on project type
+func projectCommands(projectType string) []string {
+	switch projectType {
 	case "nodejs":
 		return []string{
 			"npm install",
@@ -343,6 +724,49 @@ func (a *Analyzer) getProjectCommands() []string {
 			"kubectl get svc",
 			"kubectl logs -f <pod>",
 		}
+	case "maven":
+		return []string{
+			"mvn clean install",
+			"mvn test",
+			"mvn package",
+			"mvn dependency:tree",
+		}
+	case "gradle":
+		return []string{
+			"./gradlew build",
+			"./gradlew test",
+			"./gradlew clean",
+			"./gradlew dependencies",
+		}
+	case "php":
+		return []string{
+			"composer install",
+			"composer update",
+			"composer dump-autoload",
+			"vendor/bin/phpunit",
+		}
+	case "ruby":
+		return []string{
+			"bundle install",
+			"bundle exec rake",
+			"bundle exec rspec",
+			"bundle update",
+		}
+	case "dotnet":
+		return []string{
+			"dotnet restore",
+			"dotnet build",
+			"dotnet test",
+			"dotnet run",
+		}
+	case "terraform":
+		return []string{
+			"terraform init",
+			"terraform plan",
+			"terraform apply",
+			"terraform fmt",
+			"terraform validate",
+		}
 	default:
 		return nil
 	}
@@ -368,6 +792,36 @@ func findGitDir(startPath string) string {
 	return ""
 }
 
+// GitHead returns the branch and commit HEAD points to in the repository at
+// gitRoot, read from .git without running git. It changes on checkout and
+// commit and is empty when HEAD cannot be read.
+func GitHead(gitRoot string) string {
+	gitDir := filepath.Join(gitRoot, ".git")
+	data, err := os.ReadFile(filepath.Join(gitDir, "HEAD"))
+	if err != nil {
+		return ""
+	}
+	head := strings.TrimSpace(string(data))
+	ref, ok := strings.CutPrefix(head, "ref: ")
+	if !ok {
+		// Detached HEAD holds the commit itself
+		return head
+	}
+
+	if commit, err := os.ReadFile(filepath.Join(gitDir, filepath.FromSlash(ref))); err == nil {
+		return ref + "@" + strings.TrimSpace(string(commit))
+	}
+	// The ref may only exist in packed-refs
+	if packed, err := os.ReadFile(filepath.Join(gitDir, "packed-refs")); err == nil {
+		for line := range strings.SplitSeq(string(packed), "\n") {
+			if commit, name, ok := strings.Cut(strings.TrimSpace(line), " "); ok && name == ref {
+				return ref + "@" + commit
+			}
+		}
+	}
+	return ref
+}
+
 func detectOS() string {
 	// Use runtime.GOOS for reliable OS detection at compile time
 	return strings.ToLower(runtime.GOOS)
@@ -400,14 +854,20 @@ func detectShell() string {
 }
 
 func matchPattern(files []string, pattern string) bool {
+	_, ok := matchFile(files, pattern)
+	return ok
+}
+
+// matchFile returns the first of files that matches pattern
+func matchFile(files []string, pattern string) (string, bool) {
 	// Fast prefix search if no wildcard
 	if !strings.Contains(pattern, "*") {
-		return slices.Contains(files, pattern)
+		return pattern, slices.Contains(files, pattern)
 	}
 	for _, f := range files {
 		if matched, _ := filepath.Match(pattern, f); matched {
-			return true
+			return f, true
 		}
 	}
-	return false
+	return "", false
 }
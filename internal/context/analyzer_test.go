@@ -0,0 +1,252 @@
+package context
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestDetectProjectType(t *testing.T) {
+	tests := []struct {
+		name      string
+		files     []string
+		primary   string
+		secondary []string
+	}{
+		{name: "maven", files: []string{"pom.xml"}, primary: "maven"},
+		{name: "gradle kotlin dsl", files: []string{"build.gradle.kts", "gradlew"}, primary: "gradle"},
+		{name: "composer", files: []string{"composer.json", "composer.lock"}, primary: "php"},
+		{name: "bundler", files: []string{"Gemfile", "Gemfile.lock"}, primary: "ruby"},
+		{name: "dotnet project", files: []string{"App.csproj", "Program.cs"}, primary: "dotnet"},
+		{name: "terraform", files: []string{"main.tf", "variables.tf"}, primary: "terraform"},
+		{
+			name:      "go service with docker and terraform",
+			files:     []string{"Dockerfile", "go.mod", "infra.tf"},
+			primary:   "go",
+			secondary: []string{"terraform", "docker"},
+		},
+		{
+			name:      "node frontend with composer backend",
+			files:     []string{"package.json", "composer.json"},
+			primary:   "nodejs",
+			secondary: []string{"php"},
+		},
+		{name: "stray yaml is not kubernetes", files: []string{".golangci.yml"}, primary: "unknown"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, name := range tt.files {
+				if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			a := NewAnalyzer()
+			a.context.WorkingDir = dir
+			a.detectProjectType()
+
+			if a.context.ProjectType != tt.primary {
+				t.Errorf("ProjectType = %q, want %q", a.context.ProjectType, tt.primary)
+			}
+			if !slices.Equal(a.context.SecondaryTypes, tt.secondary) {
+				t.Errorf("SecondaryTypes = %v, want %v", a.context.SecondaryTypes, tt.secondary)
+			}
+		})
+	}
+}
+
+func TestDetectProjectsInMonorepo(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{".git/HEAD", "Dockerfile", "api/go.mod", "api/cmd/server/main.go", "web/package.json", "infra/main.tf", "web/node_modules/left-pad/package.json", ".github/go.mod"} {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	wd := filepath.Join(root, "api", "cmd", "server")
+	var got []string
+	for _, p := range DetectProjects(wd, root) {
+		got = append(got, fmt.Sprintf("%s %s %d", p.Type, p.Evidence, p.Distance))
+	}
+	want := []string{"go api/go.mod 2", "docker Dockerfile 3", "terraform infra/main.tf 4", "nodejs web/package.json 4"}
+	if !slices.Equal(got, want) {
+		t.Errorf("DetectProjects() = %q, want %q", got, want)
+	}
+
+	// The nearest parent's type wins over the repository root's
+	a := NewAnalyzer()
+	a.context.WorkingDir = wd
+	a.context.GitRoot = root
+	a.context.IsGitRepo = true
+	a.detectProjectType()
+	if a.context.ProjectType != "go" || len(a.context.SecondaryTypes) != 0 {
+		t.Errorf("ProjectType, SecondaryTypes = %q, %v, want go alone", a.context.ProjectType, a.context.SecondaryTypes)
+	}
+
+	// Outside a git repository sub-directories are not searched
+	if projects := DetectProjects(root, ""); len(projects) != 1 || projects[0].Type != "docker" {
+		t.Errorf("DetectProjects() without git = %+v, want docker only", projects)
+	}
+}
+
+func TestGetGitStatusUpstream(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+
+	root := t.TempDir()
+	origin := filepath.Join(root, "origin.git")
+	local := filepath.Join(root, "local")
+	other := filepath.Join(root, "other")
+
+	git := func(dir string, args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=wut", "-c", "user.email=wut@example.com"}, args...)...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	write := func(path, content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	git(root, "init", "-q", "--bare", origin)
+	git(root, "clone", "-q", origin, local)
+	write(filepath.Join(local, "main.go"), "package main\n")
+	write(filepath.Join(local, "README"), "wut\n")
+	git(local, "add", ".")
+	git(local, "commit", "-q", "-m", "initial")
+	git(local, "push", "-q", "origin", "HEAD")
+
+	// Another clone changes main.go upstream
+	git(root, "clone", "-q", origin, other)
+	write(filepath.Join(other, "main.go"), "package main\n\nfunc main() {}\n")
+	git(other, "commit", "-q", "-am", "add main")
+	git(other, "push", "-q")
+	git(local, "fetch", "-q")
+
+	// Local edits to main.go block the pull, edits to README do not
+	write(filepath.Join(local, "main.go"), "package main // local\n")
+	write(filepath.Join(local, "README"), "wut local\n")
+	t.Chdir(local)
+
+	a := NewAnalyzer()
+	status := a.getGitStatus(t.Context())
+	if status.Ahead != 0 || status.Behind != 1 {
+		t.Errorf("Ahead, Behind = %d, %d, want 0, 1", status.Ahead, status.Behind)
+	}
+	if !slices.Equal(status.PullBlockingFiles, []string{"main.go"}) {
+		t.Errorf("PullBlockingFiles = %v, want [main.go]", status.PullBlockingFiles)
+	}
+	if status.HasConflicts {
+		t.Errorf("HasConflicts = true without a merge")
+	}
+	if status.Branch == "" || status.Upstream != "origin/"+status.Branch || status.Remote != "" {
+		t.Errorf("Branch, Upstream, Remote = %q, %q, %q, want the branch tracking origin", status.Branch, status.Upstream, status.Remote)
+	}
+
+	// Committing diverges the branch and merging leaves main.go conflicted
+	git(local, "commit", "-q", "-am", "local edits")
+	status = a.getGitStatus(t.Context())
+	if status.Ahead != 1 || status.Behind != 1 {
+		t.Errorf("Ahead, Behind = %d, %d, want 1, 1", status.Ahead, status.Behind)
+	}
+	cmd := exec.Command("git", "-c", "user.name=wut", "-c", "user.email=wut@example.com", "merge", "-q", "@{u}")
+	cmd.Dir = local
+	if err := cmd.Run(); err == nil {
+		t.Fatal("merge succeeded, want a conflict")
+	}
+	status = a.getGitStatus(t.Context())
+	if !status.HasConflicts || !slices.Equal(status.ConflictedFiles, []string{"main.go"}) {
+		t.Errorf("ConflictedFiles = %v, want [main.go]", status.ConflictedFiles)
+	}
+	if slices.Contains(status.ModifiedFiles, "main.go") || slices.Contains(status.StagedFiles, "main.go") {
+		t.Errorf("conflicted file also listed as modified or staged: %+v", status)
+	}
+	if status.Operation != GitMerge {
+		t.Errorf("Operation = %q, want %q", status.Operation, GitMerge)
+	}
+}
+
+func TestGetGitStatusRebase(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+
+	root := t.TempDir()
+	origin := filepath.Join(root, "origin.git")
+	local := filepath.Join(root, "local")
+	git := func(args ...string) error {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=wut", "-c", "user.email=wut@example.com"}, args...)...)
+		cmd.Dir = local
+		return cmd.Run()
+	}
+	must := func(args ...string) {
+		t.Helper()
+		if err := git(args...); err != nil {
+			t.Fatalf("git %v: %v", args, err)
+		}
+	}
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(local, "main.go"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := os.MkdirAll(local, 0755); err != nil {
+		t.Fatal(err)
+	}
+	must("init", "-q", "--bare", origin)
+	must("init", "-q", "-b", "main")
+	must("remote", "add", "origin", origin)
+	write("package main\n")
+	must("add", ".")
+	must("commit", "-q", "-m", "initial")
+	must("checkout", "-q", "-b", "feature")
+	t.Chdir(local)
+
+	// A new branch has no upstream yet
+	a := NewAnalyzer()
+	status := a.getGitStatus(t.Context())
+	if status.Branch != "feature" || status.Upstream != "" || status.Remote != "origin" || status.Operation != "" {
+		t.Errorf("Branch, Upstream, Remote, Operation = %q, %q, %q, %q, want feature without upstream on origin",
+			status.Branch, status.Upstream, status.Remote, status.Operation)
+	}
+
+	// Both branches change main.go, so rebasing stops on a conflict
+	write("package main // feature\n")
+	must("commit", "-q", "-am", "feature")
+	must("checkout", "-q", "main")
+	write("package main // main\n")
+	must("commit", "-q", "-am", "main")
+	must("checkout", "-q", "feature")
+	if err := git("rebase", "main"); err == nil {
+		t.Fatal("rebase succeeded, want a conflict")
+	}
+
+	status = a.getGitStatus(t.Context())
+	if status.Operation != GitRebase || status.Branch != "feature" {
+		t.Errorf("Operation, Branch = %q, %q, want rebase of feature", status.Operation, status.Branch)
+	}
+	if !status.HasConflicts {
+		t.Error("HasConflicts = false during a conflicted rebase")
+	}
+}
@@ -0,0 +1,159 @@
+package context
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// composeFiles are the Compose file names, in the order docker compose
+// looks for them
+var composeFiles = []string{"compose.yaml", "compose.yml", "docker-compose.yaml", "docker-compose.yml"}
+
+// Compose is what a Compose file in the project defines
+type Compose struct {
+	File     string
+	Services []string
+	Profiles []string
+}
+
+var (
+	composeCacheMu sync.Mutex
+	composeCache   = make(map[string]cachedCompose)
+)
+
+// cachedCompose is a parsed Compose file, valid while it is unchanged
+type cachedCompose struct {
+	modTime time.Time
+	size    int64
+	compose *Compose
+	err     error
+}
+
+// ReadCompose parses the Compose file in dir, if there is one. Files are
+// only parsed again after they change. YAML anchors and merge keys are
+// followed; ${VAR} interpolation is left as written.
+func ReadCompose(dir string) (*Compose, error) {
+	for _, name := range composeFiles {
+		path := filepath.Join(dir, name)
+		info, err := os.Stat(path)
+		if err != nil || info.IsDir() {
+			continue
+		}
+
+		composeCacheMu.Lock()
+		cached, ok := composeCache[path]
+		composeCacheMu.Unlock()
+		if !ok || !cached.modTime.Equal(info.ModTime()) || cached.size != info.Size() {
+			cached = cachedCompose{modTime: info.ModTime(), size: info.Size()}
+			data, err := os.ReadFile(path)
+			if err == nil {
+				cached.compose, err = parseCompose(data)
+			}
+			if cached.compose != nil {
+				cached.compose.File = name
+			}
+			cached.err = err
+			composeCacheMu.Lock()
+			composeCache[path] = cached
+			composeCacheMu.Unlock()
+		}
+		return cached.compose, cached.err
+	}
+	return nil, nil
+}
+
+// parseCompose reads the services, in file order, and the profiles they use
+func parseCompose(data []byte) (*Compose, error) {
+	var doc struct {
+		Services yaml.Node `yaml:"services"`
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid Compose file: %w", err)
+	}
+	services := &doc.Services
+	if services.Kind == yaml.AliasNode {
+		services = services.Alias
+	}
+	if services.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("invalid Compose file: no services")
+	}
+
+	compose := &Compose{}
+	seenProfiles := make(map[string]bool)
+	for i := 0; i+1 < len(services.Content); i += 2 {
+		name := services.Content[i].Value
+		if name == "<<" {
+			continue
+		}
+		compose.Services = append(compose.Services, name)
+
+		var service struct {
+			Profiles []string `yaml:"profiles"`
+		}
+		// A service that does not decode still counts, without profiles
+		if err := services.Content[i+1].Decode(&service); err != nil {
+			continue
+		}
+		for _, profile := range service.Profiles {
+			if !seenProfiles[profile] {
+				seenProfiles[profile] = true
+				compose.Profiles = append(compose.Profiles, profile)
+			}
+		}
+	}
+	return compose, nil
+}
+
+var (
+	composeCommandOnce sync.Once
+	composeCommand     string
+)
+
+// ComposeCommand returns how Compose is run here: "docker compose" when the
+// v2 plugin is installed or nothing is, "docker-compose" when only the
+// legacy binary is. The answer is worked out once per process.
+func ComposeCommand() string {
+	composeCommandOnce.Do(func() {
+		composeCommand = "docker compose"
+		if hasComposePlugin() {
+			return
+		}
+		if _, err := exec.LookPath("docker-compose"); err == nil {
+			composeCommand = "docker-compose"
+		}
+	})
+	return composeCommand
+}
+
+// hasComposePlugin looks for the docker compose v2 plugin where the docker
+// CLI looks for it
+func hasComposePlugin() bool {
+	var dirs []string
+	if config := os.Getenv("DOCKER_CONFIG"); config != "" {
+		dirs = append(dirs, filepath.Join(config, "cli-plugins"))
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs, filepath.Join(home, ".docker", "cli-plugins"))
+	}
+	dirs = append(dirs,
+		"/usr/local/lib/docker/cli-plugins",
+		"/usr/local/libexec/docker/cli-plugins",
+		"/usr/lib/docker/cli-plugins",
+		"/usr/libexec/docker/cli-plugins",
+		`C:\Program Files\Docker\cli-plugins`,
+	)
+	for _, dir := range dirs {
+		for _, name := range []string{"docker-compose", "docker-compose.exe"} {
+			if fileExists(filepath.Join(dir, name)) {
+				return true
+			}
+		}
+	}
+	return false
+}
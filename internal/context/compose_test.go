@@ -0,0 +1,74 @@
+package context
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestReadCompose(t *testing.T) {
+	dir := t.TempDir()
+	compose := `x-common: &common
+  restart: unless-stopped
+  environment:
+    DATABASE_URL: ${DATABASE_URL:-postgres://localhost/app}
+
+services:
+  api:
+    <<: *common
+    image: "app:${TAG:-latest}"
+  db:
+    image: postgres:16
+  debug:
+    <<: *common
+    image: busybox
+    profiles: [tools, debug]
+  docs:
+    image: nginx
+    profiles: [tools]
+`
+	if err := os.WriteFile(filepath.Join(dir, "docker-compose.yml"), []byte(compose), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ReadCompose(dir)
+	if err != nil {
+		t.Fatalf("ReadCompose() error = %v", err)
+	}
+	if got.File != "docker-compose.yml" {
+		t.Errorf("File = %q", got.File)
+	}
+	if want := []string{"api", "db", "debug", "docs"}; !slices.Equal(got.Services, want) {
+		t.Errorf("Services = %v, want %v", got.Services, want)
+	}
+	if want := []string{"tools", "debug"}; !slices.Equal(got.Profiles, want) {
+		t.Errorf("Profiles = %v, want %v", got.Profiles, want)
+	}
+
+	// compose.yaml is preferred, and a broken file gives an error
+	if err := os.WriteFile(filepath.Join(dir, "compose.yaml"), []byte("services: [unclosed\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := ReadCompose(dir); err == nil || got != nil {
+		t.Errorf("ReadCompose() of a broken file = %+v, %v", got, err)
+	}
+
+	if got, err := ReadCompose(t.TempDir()); got != nil || err != nil {
+		t.Errorf("ReadCompose() without a file = %+v, %v", got, err)
+	}
+}
+
+func TestAnalyzerReadsCompose(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "compose.yaml"), []byte("services:\n  web:\n    image: nginx\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	a := NewAnalyzer()
+	a.context.WorkingDir = dir
+	a.detectProjectType()
+	a.detectCompose()
+	if !slices.Equal(a.context.ComposeServices, []string{"web"}) || a.context.ComposeCommand == "" {
+		t.Errorf("ComposeServices, ComposeCommand = %v, %q", a.context.ComposeServices, a.context.ComposeCommand)
+	}
+}
@@ -0,0 +1,303 @@
+package context
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goccy/go-json"
+	"gopkg.in/yaml.v3"
+)
+
+// Task is a script, target, task or recipe a project defines for itself
+type Task struct {
+	Command     string
+	Description string
+	// File is the file that defines the task, such as "Makefile"
+	File string
+}
+
+// maxTaskDescription caps how much of a task's body is shown
+const maxTaskDescription = 80
+
+// taskFiles are the files read for tasks, with the function that reads each
+var taskFiles = []struct {
+	name  string
+	parse func(dir string, data []byte) ([]Task, error)
+}{
+	{"package.json", parsePackageScripts},
+	{"Makefile", parseMakefile},
+	{"makefile", parseMakefile},
+	{"GNUmakefile", parseMakefile},
+	{"Taskfile.yml", parseTaskfile},
+	{"Taskfile.yaml", parseTaskfile},
+	{"justfile", parseJustfile},
+	{"Justfile", parseJustfile},
+	{".justfile", parseJustfile},
+}
+
+// cachedTasks are the tasks read from a file, valid while it is unchanged
+type cachedTasks struct {
+	modTime time.Time
+	size    int64
+	tasks   []Task
+	err     error
+}
+
+var (
+	taskCacheMu sync.Mutex
+	taskCache   = make(map[string]cachedTasks)
+)
+
+// ProjectTasks returns the package.json scripts, Makefile targets, Taskfile
+// tasks and justfile recipes defined in dir, as the commands that run them.
+// Each file is only parsed again after it changes. A file that cannot be
+// parsed contributes nothing; its error is in errs, keyed by file name.
+func ProjectTasks(dir string) (tasks []Task, errs map[string]error) {
+	for _, file := range taskFiles {
+		path := filepath.Join(dir, file.name)
+		info, err := os.Stat(path)
+		if err != nil || info.IsDir() {
+			continue
+		}
+
+		taskCacheMu.Lock()
+		cached, ok := taskCache[path]
+		taskCacheMu.Unlock()
+		if !ok || !cached.modTime.Equal(info.ModTime()) || cached.size != info.Size() {
+			cached = cachedTasks{modTime: info.ModTime(), size: info.Size()}
+			data, err := os.ReadFile(path)
+			if err == nil {
+				cached.tasks, err = file.parse(dir, data)
+			}
+			cached.err = err
+			for i := range cached.tasks {
+				cached.tasks[i].File = file.name
+			}
+			taskCacheMu.Lock()
+			taskCache[path] = cached
+			taskCacheMu.Unlock()
+		}
+
+		if cached.err != nil {
+			if errs == nil {
+				errs = make(map[string]error)
+			}
+			errs[file.name] = cached.err
+			continue
+		}
+		tasks = append(tasks, cached.tasks...)
+	}
+	return tasks, errs
+}
+
+// parsePackageScripts reads the "scripts" of package.json in file order,
+// run with the package manager whose lock file is in dir
+func parsePackageScripts(dir string, data []byte) ([]Task, error) {
+	var pkg struct {
+		Scripts json.RawMessage `json:"scripts"`
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return nil, fmt.Errorf("invalid package.json: %w", err)
+	}
+	if len(pkg.Scripts) == 0 {
+		return nil, nil
+	}
+
+	// Decode token by token, since a map would lose the order
+	dec := json.NewDecoder(bytes.NewReader(pkg.Scripts))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, fmt.Errorf("invalid package.json: scripts is not an object")
+	}
+	run := packageRunner(dir)
+	var tasks []Task
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("invalid package.json: %w", err)
+		}
+		name, _ := tok.(string)
+		var body string
+		if err := dec.Decode(&body); err != nil {
+			return nil, fmt.Errorf("invalid package.json: script %q: %w", name, err)
+		}
+		tasks = append(tasks, Task{Command: run + name, Description: describeTask(body)})
+	}
+	return tasks, nil
+}
+
+// packageRunner returns the command prefix that runs a package.json script,
+// following the lock file in dir
+func packageRunner(dir string) string {
+	switch {
+	case fileExists(filepath.Join(dir, "pnpm-lock.yaml")):
+		return "pnpm run "
+	case fileExists(filepath.Join(dir, "yarn.lock")):
+		return "yarn "
+	case fileExists(filepath.Join(dir, "bun.lockb")), fileExists(filepath.Join(dir, "bun.lock")):
+		return "bun run "
+	default:
+		return "npm run "
+	}
+}
+
+// makeTargetPattern matches a rule's target, leaving out variable
+// assignments such as NAME:=value and special targets such as .PHONY
+var makeTargetPattern = regexp.MustCompile(`^([a-zA-Z0-9_-]+)\s*:([^=]|$)`)
+
+// parseMakefile reads the targets of a Makefile with the first line of
+// their recipe, or the "## description" after the target when there is one
+func parseMakefile(_ string, data []byte) ([]Task, error) {
+	var tasks []Task
+	seen := make(map[string]bool)
+	current := -1
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "\t") {
+			if current >= 0 && tasks[current].Description == "" {
+				tasks[current].Description = describeTask(strings.TrimLeft(line, "\t@-"))
+			}
+			continue
+		}
+		current = -1
+		m := makeTargetPattern.FindStringSubmatch(line)
+		if m == nil || seen[m[1]] {
+			continue
+		}
+		seen[m[1]] = true
+		task := Task{Command: "make " + m[1]}
+		if _, comment, ok := strings.Cut(line, "##"); ok {
+			task.Description = describeTask(comment)
+		}
+		tasks = append(tasks, task)
+		current = len(tasks) - 1
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("invalid Makefile: %w", err)
+	}
+	return tasks, nil
+}
+
+// parseTaskfile reads the tasks of a Taskfile.yml in file order, described
+// by their desc or their first command
+func parseTaskfile(_ string, data []byte) ([]Task, error) {
+	var doc struct {
+		Tasks yaml.Node `yaml:"tasks"`
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid Taskfile: %w", err)
+	}
+	if doc.Tasks.Kind == 0 {
+		return nil, nil
+	}
+	if doc.Tasks.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("invalid Taskfile: tasks is not a map")
+	}
+
+	var tasks []Task
+	for i := 0; i+1 < len(doc.Tasks.Content); i += 2 {
+		name := doc.Tasks.Content[i].Value
+		var task struct {
+			Desc     string `yaml:"desc"`
+			Internal bool   `yaml:"internal"`
+			Cmds     []any  `yaml:"cmds"`
+			Cmd      string `yaml:"cmd"`
+		}
+		body := doc.Tasks.Content[i+1]
+		switch body.Kind {
+		case yaml.ScalarNode:
+			task.Cmd = body.Value
+		case yaml.SequenceNode:
+			if err := body.Decode(&task.Cmds); err != nil {
+				return nil, fmt.Errorf("invalid Taskfile: task %q: %w", name, err)
+			}
+		default:
+			if err := body.Decode(&task); err != nil {
+				return nil, fmt.Errorf("invalid Taskfile: task %q: %w", name, err)
+			}
+		}
+		if task.Internal {
+			continue
+		}
+
+		description := task.Desc
+		if description == "" && task.Cmd != "" {
+			description = task.Cmd
+		}
+		if description == "" && len(task.Cmds) > 0 {
+			switch cmd := task.Cmds[0].(type) {
+			case string:
+				description = cmd
+			case map[string]any:
+				description = fmt.Sprint(cmd["cmd"])
+			}
+		}
+		tasks = append(tasks, Task{Command: "task " + name, Description: describeTask(description)})
+	}
+	return tasks, nil
+}
+
+// justRecipePattern matches a recipe header, leaving out NAME := value
+var justRecipePattern = regexp.MustCompile(`^@?([a-zA-Z_][a-zA-Z0-9_-]*)([^:]*):([^=]|$)`)
+
+// parseJustfile reads the recipes of a justfile, described by the comment
+// above them or the first line of their body. Private recipes are left out.
+func parseJustfile(_ string, data []byte) ([]Task, error) {
+	var tasks []Task
+	var comment string
+	private := false
+	current := -1
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+			current = -1
+		case line != trimmed && (line[0] == ' ' || line[0] == '\t'):
+			if current >= 0 && tasks[current].Description == "" {
+				tasks[current].Description = describeTask(strings.TrimLeft(trimmed, "@-"))
+			}
+		case strings.HasPrefix(trimmed, "#"):
+			comment = strings.TrimSpace(strings.TrimPrefix(trimmed, "#"))
+			continue
+		case strings.HasPrefix(trimmed, "["):
+			private = private || strings.Contains(trimmed, "private")
+			continue
+		default:
+			current = -1
+			m := justRecipePattern.FindStringSubmatch(line)
+			if m != nil && !private && !strings.HasPrefix(m[1], "_") {
+				tasks = append(tasks, Task{Command: "just " + m[1], Description: describeTask(comment)})
+				current = len(tasks) - 1
+			}
+		}
+		comment = ""
+		private = false
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("invalid justfile: %w", err)
+	}
+	return tasks, nil
+}
+
+// describeTask shortens a task body to one line
+func describeTask(body string) string {
+	body = strings.Join(strings.Fields(body), " ")
+	if runes := []rune(body); len(runes) > maxTaskDescription {
+		body = string(runes[:maxTaskDescription-1]) + "…"
+	}
+	return body
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
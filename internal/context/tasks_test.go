@@ -0,0 +1,132 @@
+package context
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestProjectTasks(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("package.json", `{"name": "web", "scripts": {"storybook": "storybook dev -p 6006", "build": "vite build"}}`)
+	write("yarn.lock", "")
+	write("Makefile", `.PHONY: proto lint
+GO ?= go
+VERSION:=1.0
+
+proto: ## Generate protobuf code
+	protoc --go_out=. api.proto
+
+lint: proto
+	@golangci-lint run
+`)
+	write("Taskfile.yml", `version: "3"
+tasks:
+  deploy:staging:
+    desc: Deploy to staging
+    cmds:
+      - ./deploy.sh staging
+  fmt: gofmt -w .
+  helper:
+    internal: true
+    cmds: [echo hi]
+`)
+	write("justfile", `set shell := ["bash", "-c"]
+version := "1.0"
+
+# Run the test suite
+test filter="":
+    go test ./... -run {{filter}}
+
+_hidden:
+    echo private
+
+[private]
+secret:
+    echo private
+
+serve port:
+    go run . --port {{port}}
+`)
+
+	tasks, errs := ProjectTasks(dir)
+	if len(errs) != 0 {
+		t.Fatalf("ProjectTasks() errors = %v", errs)
+	}
+	var got []string
+	for _, task := range tasks {
+		got = append(got, fmt.Sprintf("%s | %s | %s", task.Command, task.Description, task.File))
+	}
+	want := []string{
+		"yarn storybook | storybook dev -p 6006 | package.json",
+		"yarn build | vite build | package.json",
+		"make proto | Generate protobuf code | Makefile",
+		"make lint | golangci-lint run | Makefile",
+		"task deploy:staging | Deploy to staging | Taskfile.yml",
+		"task fmt | gofmt -w . | Taskfile.yml",
+		"just test | Run the test suite | justfile",
+		"just serve | go run . --port {{port}} | justfile",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("ProjectTasks() =\n%q\nwant\n%q", got, want)
+	}
+
+	// A broken file contributes nothing, once it changes
+	write("package.json", `{"scripts": {"build": `)
+	later := time.Now().Add(time.Second)
+	if err := os.Chtimes(filepath.Join(dir, "package.json"), later, later); err != nil {
+		t.Fatal(err)
+	}
+	tasks, errs = ProjectTasks(dir)
+	if errs["package.json"] == nil {
+		t.Error("malformed package.json was not reported")
+	}
+	for _, task := range tasks {
+		if task.File == "package.json" {
+			t.Errorf("malformed package.json still gave %q", task.Command)
+		}
+	}
+}
+
+func TestProjectTasksCache(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "Makefile")
+	if err := os.WriteFile(path, []byte("build:\n\tgo build\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	stamp := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(path, stamp, stamp); err != nil {
+		t.Fatal(err)
+	}
+	if tasks, _ := ProjectTasks(dir); len(tasks) != 1 {
+		t.Fatalf("ProjectTasks() = %v, want one target", tasks)
+	}
+
+	// Same size and time: the cached targets are returned
+	if err := os.WriteFile(path, []byte("check:\n\tgo check\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, stamp, stamp); err != nil {
+		t.Fatal(err)
+	}
+	if tasks, _ := ProjectTasks(dir); tasks[0].Command != "make build" {
+		t.Errorf("unchanged Makefile was parsed again: %v", tasks)
+	}
+
+	// A new modification time reads it again
+	if err := os.Chtimes(path, time.Now(), time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if tasks, _ := ProjectTasks(dir); tasks[0].Command != "make check" {
+		t.Errorf("changed Makefile was not parsed again: %v", tasks)
+	}
+}
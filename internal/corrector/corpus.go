@@ -0,0 +1,125 @@
+package corrector
+
+// Corpus is a word list indexed for bestMatch. Words are bucketed by length
+// so only those within maxDist of a token's length are looked at, and each
+// word's letter counts give a lower bound on its edit distance that skips
+// most of them without running the DP. Build it once per word list.
+type Corpus struct {
+	words []string
+	exact map[string]struct{}
+	// byLen[n] holds the indices of the words n bytes long, in word order
+	byLen [][]int
+	bags  []letterBag
+}
+
+// NewCorpus indexes the words of lists, in order
+func NewCorpus(lists ...[]string) *Corpus {
+	c := &Corpus{exact: make(map[string]struct{})}
+	for _, list := range lists {
+		for _, word := range list {
+			i := len(c.words)
+			c.words = append(c.words, word)
+			c.exact[word] = struct{}{}
+			for len(c.byLen) <= len(word) {
+				c.byLen = append(c.byLen, nil)
+			}
+			c.byLen[len(word)] = append(c.byLen[len(word)], i)
+			c.bags = append(c.bags, newLetterBag(word))
+		}
+	}
+	return c
+}
+
+// Words returns the words in order
+func (c *Corpus) Words() []string {
+	if c == nil {
+		return nil
+	}
+	return c.words
+}
+
+// Len returns the number of words
+func (c *Corpus) Len() int {
+	if c == nil {
+		return 0
+	}
+	return len(c.words)
+}
+
+// Contains reports whether word is in the corpus
+func (c *Corpus) Contains(word string) bool {
+	if c == nil {
+		return false
+	}
+	_, ok := c.exact[word]
+	return ok
+}
+
+// letterBagBins is how many counters a letterBag keeps; letters get one
+// each and the other bytes share the rest
+const letterBagBins = 32
+
+// letterBag counts a word's bytes by bin. It is only kept for short ASCII
+// words, where one edit changes one byte; ok is false otherwise.
+type letterBag struct {
+	counts [letterBagBins]uint8
+	ok     bool
+}
+
+func newLetterBag(word string) letterBag {
+	var bag letterBag
+	if len(word) > 255 {
+		return bag
+	}
+	for i := 0; i < len(word); i++ {
+		ch := word[i]
+		if ch >= 0x80 {
+			return letterBag{}
+		}
+		bag.counts[letterBin(ch)]++
+	}
+	bag.ok = true
+	return bag
+}
+
+// letterBin maps a byte to its counter. Sharing a counter only loosens the
+// bound, so the bins just need to keep common letters apart.
+func letterBin(ch byte) int {
+	switch {
+	case ch >= 'a' && ch <= 'z':
+		return int(ch - 'a')
+	case ch >= 'A' && ch <= 'Z':
+		return int(ch - 'A')
+	case ch >= '0' && ch <= '9':
+		return 26
+	case ch == '-':
+		return 27
+	case ch == '_':
+		return 28
+	case ch == '.':
+		return 29
+	case ch == '/':
+		return 30
+	default:
+		return 31
+	}
+}
+
+// bagDistance is a lower bound on the edit distance between the words of
+// two bags: an insertion, deletion or substitution changes the counts by at
+// most one on each side and a transposition not at all. It is 0 when either
+// bag could not be built.
+func bagDistance(a, b *letterBag) int {
+	if !a.ok || !b.ok {
+		return 0
+	}
+	extraA, extraB := 0, 0
+	for i := range a.counts {
+		if d := int(a.counts[i]) - int(b.counts[i]); d > 0 {
+			extraA += d
+		} else {
+			extraB -= d
+		}
+	}
+	return max(extraA, extraB)
+}
@@ -0,0 +1,174 @@
+package corrector
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// goldenCommands are typed commands whose corrections are pinned in
+// testdata/corrections.golden
+var goldenCommands = []string{
+	"gti status", "git stauts", "git comit -m fix", "git psuh origin main", "git chekcout -b feature",
+	"git rebsae main", "git cherry-pik abc123", "git stash pop", "git log --onelin", "git commit --amnd",
+	"dcoker ps", "docker biuld -t app .", "docker imgaes", "docker compose up --detatch", "docker run --rm -it ubuntu",
+	"kubeclt get pods", "kubectl aplly -f deploy.yaml", "kubectl descibe pod web", "kubectl logs --folow web",
+	"npm isntall", "npm run biuld", "npm tset", "yran add react", "pnmp install",
+	"pytohn main.py", "pip isntall requests", "pip3 freze", "go biuld ./...", "go tets ./...", "cargo buidl --relase",
+	"terrafrom plan", "terraform aplly", "ansbile all -m ping", "helm instal web ./chart", "brew isntall jq",
+	"sl -la", "lss", "cta README.md", "grpe -r TODO .", "mkdri build", "chmdo +x run.sh", "tial -f app.log",
+	"systemclt restart nginx", "sudo apt updat", "apt-get isntall curl", "journalclt -u nginx", "sss -tlnp",
+	"curll https://example.com", "wegt https://example.com", "shh user@host", "rsycn -av src dst",
+	"vmi main.go", "nvmi main.go", "mkae build", "cmkae ..", "tmxu attach", "opnessl version",
+	"wut sugest", "wut explian tar", "wut hisotry", "aws s33 ls", "gcloud comptue instances list",
+	"ls", "git status", "docker ps", "echo hello", "./configure --prefix=/usr", "git commit -m \"fix typo\"",
+	"DOCKER PS", "git STAUTS", "tar xvf archive.tar", "kubectl get pods -n kube-system",
+}
+
+func TestCorrectionsGolden(t *testing.T) {
+	var b strings.Builder
+	for _, keyboardAware := range []bool{false, true} {
+		c := New()
+		c.SetKeyboardAware(keyboardAware)
+		c.SetAliases([]string{"gst", "kctx"})
+		for _, command := range goldenCommands {
+			corrected, confidence, explanation := "-", 0.0, ""
+			fix, err := c.correctSentence(context.Background(), command)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fix != nil {
+				corrected, confidence, explanation = fix.Corrected, fix.Confidence, fix.Explanation
+			}
+			fmt.Fprintf(&b, "%v\t%s\t%s\t%.4f\t%s\n", keyboardAware, command, corrected, confidence, explanation)
+		}
+	}
+	got := b.String()
+
+	path := filepath.Join("testdata", "corrections.golden")
+	if *updateGolden {
+		if err := os.WriteFile(path, []byte(got), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != string(want) {
+		gotLines, wantLines := strings.Split(got, "\n"), strings.Split(string(want), "\n")
+		for i := range min(len(gotLines), len(wantLines)) {
+			if gotLines[i] != wantLines[i] {
+				t.Errorf("line %d:\n got  %s\n want %s", i+1, gotLines[i], wantLines[i])
+			}
+		}
+		t.Error("corrections differ from testdata/corrections.golden; run go test -update if the change is intended")
+	}
+}
+
+// linearBestMatch is bestMatch without the index: every word within maxDist
+// of the token's length is compared, and the first closest one wins
+func linearBestMatch(c *Corrector, token string, corpus []string, maxDist int) (string, float64) {
+	best := ""
+	bestDist := float64(maxDist + 1)
+	for _, candidate := range corpus {
+		if diff := len(token) - len(candidate); diff < -maxDist || diff > maxDist {
+			continue
+		}
+		d := c.distance(token, candidate)
+		if d == 0 {
+			return "", 0
+		}
+		if d < bestDist {
+			bestDist = d
+			best = candidate
+		}
+	}
+	if bestDist > float64(maxDist) {
+		return "", 0
+	}
+	return best, bestDist
+}
+
+// syntheticCorpus returns n command-like words, starting with the real root
+// commands, and typos of some of them to look up
+func syntheticCorpus(n int) (words, typos []string) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	const letters = "abcdefghijklmnopqrstuvwxyz-"
+	words = append(words, rootCommands...)
+	for len(words) < n {
+		word := make([]byte, 2+rng.IntN(12))
+		for i := range word {
+			word[i] = letters[rng.IntN(len(letters)-1)]
+			if i > 0 && i < len(word)-1 && rng.IntN(8) == 0 {
+				word[i] = '-'
+			}
+		}
+		words = append(words, string(word))
+	}
+
+	for range 500 {
+		word := []byte(words[rng.IntN(len(words))])
+		i := rng.IntN(len(word))
+		switch rng.IntN(4) {
+		case 0: // substitution
+			word[i] = letters[rng.IntN(26)]
+		case 1: // deletion
+			word = append(word[:i], word[i+1:]...)
+		case 2: // insertion
+			word = append(word[:i], append([]byte{letters[rng.IntN(26)]}, word[i:]...)...)
+		default: // transposition
+			if i+1 < len(word) {
+				word[i], word[i+1] = word[i+1], word[i]
+			}
+		}
+		typos = append(typos, string(word))
+	}
+	return words, typos
+}
+
+func TestCorpusMatchesLinearScan(t *testing.T) {
+	words, typos := syntheticCorpus(5000)
+	corpus := NewCorpus(words)
+	for _, keyboardAware := range []bool{false, true} {
+		c := New()
+		c.SetKeyboardAware(keyboardAware)
+		for _, typo := range append(typos, "", "x", "kubectl", "docker-compose", "ünïcode", strings.Repeat("a", 300)) {
+			maxDist := maxDistForLen(typo)
+			gotWord, gotDist := c.bestMatch(typo, corpus, maxDist)
+			wantWord, wantDist := linearBestMatch(c, typo, words, maxDist)
+			if gotWord != wantWord || gotDist != wantDist {
+				t.Errorf("keyboard=%v bestMatch(%q) = %q, %v, want %q, %v", keyboardAware, typo, gotWord, gotDist, wantWord, wantDist)
+			}
+		}
+	}
+}
+
+// BenchmarkCorpus5k looks up typos in a 5000-word corpus with and without
+// the index
+func BenchmarkCorpus5k(b *testing.B) {
+	words, typos := syntheticCorpus(5000)
+	corpus := NewCorpus(words)
+	c := New()
+	b.Run("linear", func(b *testing.B) {
+		for b.Loop() {
+			for _, typo := range typos[:50] {
+				linearBestMatch(c, typo, words, maxDistForLen(typo))
+			}
+		}
+	})
+	b.Run("indexed", func(b *testing.B) {
+		for b.Loop() {
+			for _, typo := range typos[:50] {
+				c.bestMatch(typo, corpus, maxDistForLen(typo))
+			}
+		}
+	})
+}
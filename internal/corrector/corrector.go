@@ -4,10 +4,16 @@
 package corrector
 
 import (
+	"context"
 	"fmt"
 	"regexp"
+	"runtime"
+	"slices"
 	"strings"
 
+	"wut/internal/concurrency"
+	"wut/internal/shellwords"
+
 	"github.com/hbollon/go-edlib"
 )
 
@@ -22,15 +28,26 @@ type Correction struct {
 
 // tokenFix records a single token correction
 type tokenFix struct {
-	original  string
-	corrected string
-	distance  int
+	original    string
+	corrected   string
+	distance    float64
+	description string  // what the corrected flag does, if known
+	score       float64 // confidenceScore of the fix
 }
 
 // Corrector provides command correction functionality
 type Corrector struct {
 	dangerousPatterns []string
-	historyCommands   []string
+	history           []HistoryEntry
+	historyMaxUsage   int
+	historyThreshold  float64
+	historyDisabled   bool
+	aliases           []string
+	roots             *Corpus // rootCorpus plus the aliases
+	minConfidence     float64
+	keyboardAware     bool
+	feedback          FeedbackFunc
+	personal          map[string]string // taught corrections by lowercase typo
 }
 
 // New creates a new Corrector.
@@ -40,9 +57,26 @@ func New() *Corrector {
 	}
 }
 
-// SetHistoryCommands supplies past commands for additional fuzzy matching.
-func (c *Corrector) SetHistoryCommands(cmds []string) {
-	c.historyCommands = cmds
+// SetAliases supplies the names of the user's aliases. They are corrected
+// like root commands, and the arguments after an alias are left alone.
+func (c *Corrector) SetAliases(names []string) {
+	c.aliases = make([]string, len(names))
+	for i, name := range names {
+		c.aliases[i] = strings.ToLower(name)
+	}
+	c.roots = NewCorpus(rootCommands, c.aliases)
+}
+
+// SetMinConfidence sets the confidence a typo or history correction needs to
+// be suggested. Dangerous command warnings are not affected.
+func (c *Corrector) SetMinConfidence(confidence float64) {
+	c.minConfidence = confidence
+}
+
+// SetKeyboardAware makes substituting a key for one next to it on a QWERTY
+// keyboard count as half a typo, so fat-finger slips are corrected first.
+func (c *Corrector) SetKeyboardAware(enabled bool) {
+	c.keyboardAware = enabled
 }
 
 // ──────────────────────────────────────────────────────────────────────────────
@@ -52,38 +86,169 @@ func (c *Corrector) SetHistoryCommands(cmds []string) {
 // Correct analyzes the full command sentence and returns a Correction if any
 // token is misspelled, or nil when no issues are detected.
 func (c *Corrector) Correct(command string) (*Correction, error) {
+	return c.CorrectContext(context.Background(), command)
+}
+
+// CorrectContext is Correct with a context: correction stops between tokens
+// once ctx is done and returns its error. The dangerous-command check always
+// runs first, whatever the state of ctx.
+func (c *Corrector) CorrectContext(ctx context.Context, command string) (*Correction, error) {
 	// 1. Safety check first
 	if d := c.checkDangerous(command); d != nil {
 		return d, nil
 	}
 
+	// 1.2 Corrections the user taught come before anything learned from
+	// the corpus
+	if fix := c.correctPersonal(command); fix != nil {
+		return fix, nil
+	}
+
 	// 1.5 Evaluate error combinations (100% matched rules based on command output)
 	if ruleFix := c.evaluateErrorRules(command); ruleFix != nil {
 		return ruleFix, nil
 	}
 
-	// 2. Full-sentence, context-aware typo scan
-	if fix := c.correctSentence(command); fix != nil {
-		return fix, nil
+	// 2-3. Typo and short-flag correction, one command of a chain at a time
+	var fix *Correction
+	var err error
+	if stages := SplitSequence(command); len(stages) > 1 {
+		fix, err = c.correctSequence(ctx, command, stages)
+	} else {
+		fix, err = c.correctStage(ctx, command)
 	}
-
-	// 3. Short-flag cluster correction (e.g. "-ait" with unknown chars for docker)
-	if fix := c.correctShortFlags(command); fix != nil {
-		return fix, nil
+	if err != nil {
+		return nil, err
+	}
+	if fix != nil {
+		return c.confident(fix), nil
 	}
 
 	// 4. History-based full-sentence fuzzy match
 	if h := c.checkHistory(command); h != nil {
-		return h, nil
+		return c.confident(h), nil
 	}
 
 	return nil, nil
 }
 
+// confident drops a correction whose confidence, after learning from past
+// feedback, is below the minimum, so a dubious change is reported as no
+// correction at all
+func (c *Corrector) confident(fix *Correction) *Correction {
+	if fix = c.learn(fix); fix == nil || fix.Confidence < c.minConfidence {
+		return nil
+	}
+	return fix
+}
+
+// correctStage corrects a single command. Leading VAR=value assignments are
+// kept as typed; only the command after them is corrected.
+func (c *Corrector) correctStage(ctx context.Context, command string) (*Correction, error) {
+	env, rest := SplitEnvAssignments(command)
+
+	// 2. Full-sentence, context-aware typo scan
+	fix, err := c.correctSentence(ctx, rest)
+	if err != nil {
+		return nil, err
+	}
+	if fix != nil {
+		return withEnvPrefix(fix, command, env), nil
+	}
+
+	// 3. Short-flag cluster correction (e.g. "-ait" with unknown chars for docker)
+	if fix := c.correctShortFlags(rest); fix != nil {
+		return withEnvPrefix(fix, command, env), nil
+	}
+	return nil, nil
+}
+
+// correctSequence corrects each command of a chain like "a && b" on its own
+// and joins the results back with the same operators
+func (c *Corrector) correctSequence(ctx context.Context, command string, stages []SequenceStage) (*Correction, error) {
+	corrected := make([]SequenceStage, len(stages))
+	var explanations []string
+	confidence := 1.0
+	for i, stage := range stages {
+		corrected[i] = stage
+		fix, err := c.correctStage(ctx, stage.Command)
+		if err != nil {
+			return nil, err
+		}
+		if fix == nil {
+			continue
+		}
+		corrected[i].Command = fix.Corrected
+		if !slices.Contains(explanations, fix.Explanation) {
+			explanations = append(explanations, fix.Explanation)
+		}
+		confidence = min(confidence, fix.Confidence)
+	}
+	if len(explanations) == 0 {
+		return nil, nil
+	}
+	return &Correction{
+		Original:    command,
+		Corrected:   JoinSequence(corrected),
+		Confidence:  confidence,
+		Explanation: strings.Join(explanations, "; "),
+	}, nil
+}
+
+// IsKnownCommand reports whether name is a shell command the corrector knows,
+// such as git or docker
+func IsKnownCommand(name string) bool {
+	return rootCorpus.Contains(strings.ToLower(name))
+}
+
+// IsEnvAssignment reports whether token is a shell variable assignment like
+// FOO=bar, which may come before a command
+func IsEnvAssignment(token string) bool {
+	name, _, ok := strings.Cut(token, "=")
+	if !ok || name == "" || (name[0] >= '0' && name[0] <= '9') {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if !((c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// SplitEnvAssignments splits the leading VAR=value assignments off command.
+// The prefix keeps its trailing space so prefix+rest rebuilds the command.
+func SplitEnvAssignments(command string) (prefix, rest string) {
+	rest = strings.TrimLeft(command, " \t")
+	for {
+		end := strings.IndexAny(rest, " \t")
+		if end < 0 {
+			end = len(rest)
+		}
+		if !IsEnvAssignment(rest[:end]) {
+			break
+		}
+		rest = strings.TrimLeft(rest[end:], " \t")
+	}
+	return command[:len(command)-len(rest)], rest
+}
+
+// withEnvPrefix puts the assignments split off command back in front of a
+// correction of the rest
+func withEnvPrefix(fix *Correction, command, prefix string) *Correction {
+	if prefix == "" {
+		return fix
+	}
+	fix.Original = command
+	fix.Corrected = prefix + fix.Corrected
+	return fix
+}
+
 // correctShortFlags scans the command for short flag clusters with unknown
 // characters and returns a correction with expanded long-form suggestions.
 func (c *Corrector) correctShortFlags(command string) *Correction {
-	tokens := strings.Fields(command)
+	tokens := shellwords.Fields(command)
 	if len(tokens) == 0 {
 		return nil
 	}
@@ -121,24 +286,57 @@ func (c *Corrector) correctShortFlags(command string) *Correction {
 
 // SuggestAlternative returns modern tool alternatives for a given command.
 func (c *Corrector) SuggestAlternative(command string) []string {
+	var names []string
+	for _, alt := range c.SuggestAlternativeDetailed(command) {
+		names = append(names, alt.Name)
+	}
+	return names
+}
+
+// SuggestAlternativeDetailed returns the modern tool alternatives for a given
+// command with the reason to try each one.
+func (c *Corrector) SuggestAlternativeDetailed(command string) []Alternative {
 	words := strings.Fields(command)
 	if len(words) == 0 {
 		return nil
 	}
-	return modernAlternatives[strings.ToLower(words[0])]
+	root := strings.ToLower(words[0])
+	alts := slices.Clone(modernAlternatives[root])
+	for i := range alts {
+		alts[i].Replaces = root
+	}
+	return alts
+}
+
+// ClassicEquivalent reports the classic command that a modern tool replaces,
+// so "exa" is explained as a modern ls.
+func (c *Corrector) ClassicEquivalent(command string) (Alternative, bool) {
+	words := strings.Fields(command)
+	if len(words) == 0 {
+		return Alternative{}, false
+	}
+	alt, ok := classicCommands[strings.ToLower(words[0])]
+	return alt, ok
 }
 
 // ──────────────────────────────────────────────────────────────────────────────
 // Core correction logic
 // ──────────────────────────────────────────────────────────────────────────────
 
+// parallelTokens is the token count above which correctSentence corrects
+// the arguments of a command concurrently; below it the goroutines cost more
+// than they save
+const parallelTokens = 8
+
 // correctSentence performs per-token correction using the corpus.
 // It is context-aware: the subcommand corpus is chosen based on the root command.
 // PERF: tokens are lowercased once up-front to avoid repeated allocations.
-func (c *Corrector) correctSentence(command string) *Correction {
-	tokens := strings.Fields(command)
-	if len(tokens) == 0 {
-		return nil
+// The arguments of long commands are corrected concurrently; ctx is checked
+// between tokens and its error returned once it is done.
+func (c *Corrector) correctSentence(ctx context.Context, command string) (*Correction, error) {
+	tokens := shellwords.Fields(command)
+	if len(tokens) == 0 || isShellExpansion(tokens[0]) {
+		return nil, nil
 	}
 
 	// Pre-lowercase every token once – avoids repeated ToLower inside the hot loop.
@@ -155,83 +353,88 @@ func (c *Corrector) correctSentence(command string) *Correction {
 
 	// ── Token 0: root command ──────────────────────────────────────────────
 	root := lower[0]
-	bestRoot, bestDist := bestMatch(root, rootCorpus, maxDistForLen(root))
+	if slices.Contains(c.aliases, root) {
+		return nil, nil
+	}
+	corpus := rootCorpus
+	if c.roots != nil {
+		corpus = c.roots
+	}
+	bestRoot, bestDist := c.bestMatch(root, corpus, maxDistForLen(root))
 	if bestRoot != "" && bestRoot != root {
-		fixes = append(fixes, tokenFix{tokens[0], bestRoot, bestDist})
+		fix := tokenFix{tokens[0], bestRoot, bestDist, "", confidenceScore(root, bestDist)}
+		fixes = append(fixes, fix)
 		corrected[0] = bestRoot
-		totalScore += confidenceScore(root, bestDist)
+		totalScore += fix.score
 	} else {
 		bestRoot = root
 	}
+	if slices.Contains(c.aliases, bestRoot) {
+		// An alias's arguments are whatever the alias expects
+		tokens = tokens[:1]
+	}
 
 	// ── Tokens 1…n: subcommands + args ────────────────────────────────────
 	subCorpus := subCmdCorpus[bestRoot]
 	fs := knownFlags[bestRoot] // O(1) map lookup; zero alloc
 
+	args := make([]int, 0, len(tokens)-1)
 	for i := 1; i < len(tokens); i++ {
-		tok := tokens[i]
-		tokLow := lower[i]
-
-		// ── Flags (starts with - or --) ─────────────────────────────────
-		if tok[0] == '-' {
-			if len(fs.long) > 0 && len(tok) > 2 && tok[1] == '-' {
-				// long flag: strip --, get name before =
-				clean := tok[2:]
-				if eq := strings.IndexByte(clean, '='); eq != -1 {
-					clean = clean[:eq]
-				}
-				cleanLow := strings.ToLower(clean)
-				bestFlag, flagDist := bestMatch(cleanLow, fs.long, maxDistForLen(cleanLow))
-				if bestFlag != "" && bestFlag != cleanLow {
-					newTok := "--" + bestFlag
-					fixes = append(fixes, tokenFix{tok, newTok, flagDist})
-					corrected[i] = newTok
-					totalScore += confidenceScore(cleanLow, flagDist)
-				}
-			}
-			continue
-		}
-
-		// Skip paths, URLs and pure numbers
-		if looksLikePathOrURL(tok) || isNumeric(tokLow) {
-			continue
+		args = append(args, i)
+	}
+	correctArg := func(i int) (*tokenFix, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
 		}
+		return c.correctToken(i, tokens[i], lower[i], subCorpus, fs), nil
+	}
 
-		maxDist := maxDistForLen(tokLow)
-		var best string
-		var dist int
-
-		if i == 1 && len(subCorpus) > 0 {
-			best, dist = bestMatch(tokLow, subCorpus, maxDist)
+	var argFixes []*tokenFix
+	if len(tokens) > parallelTokens {
+		// Workers stop taking tokens once ctx is done, so its error is the
+		// only one Map can see
+		argFixes, _ = concurrency.Map(ctx, args, correctArg, min(runtime.NumCPU(), len(args)))
+		if err := ctx.Err(); err != nil {
+			return nil, err
 		}
-		if best == "" {
-			best, dist = bestMatch(tokLow, globalTokens, maxDist)
+	} else {
+		for _, i := range args {
+			fix, err := correctArg(i)
+			if err != nil {
+				return nil, err
+			}
+			argFixes = append(argFixes, fix)
 		}
+	}
 
-		if best != "" && best != tokLow {
-			out := best
-			if isAllUpper(tok) {
-				out = strings.ToUpper(best)
-			}
-			fixes = append(fixes, tokenFix{tok, out, dist})
-			corrected[i] = out
-			totalScore += confidenceScore(tokLow, dist)
+	// Fixes are collected in token order whichever way they were found
+	for n, fix := range argFixes {
+		if fix == nil {
+			continue
 		}
+		i := args[n]
+		fixes = append(fixes, *fix)
+		corrected[i] = fix.corrected
+		totalScore += fix.score
 	}
 
 	if len(fixes) == 0 {
-		return nil
+		return nil, nil
 	}
 
 	// Missing-prefix check (e.g. "status" → "git status")
 	if misfix := c.checkMissingPrefix(command); misfix != nil && len(fixes) == 0 {
-		return misfix
+		return misfix, nil
 	}
 
 	avgConf := totalScore / float64(len(fixes))
 	var explParts []string
 	for _, f := range fixes {
-		explParts = append(explParts, fmt.Sprintf("'%s'→'%s'", f.original, f.corrected))
+		part := fmt.Sprintf("'%s'→'%s'", f.original, f.corrected)
+		if f.description != "" {
+			part += fmt.Sprintf(" (%s)", f.description)
+		}
+		explParts = append(explParts, part)
 	}
 	explanation := "Fixed: " + strings.Join(explParts, ", ")
 
@@ -240,12 +443,71 @@ func (c *Corrector) correctSentence(command string) *Correction {
 		Corrected:   strings.Join(corrected, " "),
 		Confidence:  avgConf,
 		Explanation: explanation,
+	}, nil
+}
+
+// correctToken corrects argument i of a command: a long flag against the
+// root command's flags, the first argument against its subcommands, and any
+// other word against the global corpus. It returns nil when the token is
+// left as typed.
+func (c *Corrector) correctToken(i int, tok, tokLow string, subCorpus *Corpus, fs flagSet) *tokenFix {
+	// Quoted words and command substitutions are left as typed
+	if isShellExpansion(tok) {
+		return nil
+	}
+
+	// ── Flags (starts with - or --) ─────────────────────────────────
+	if tok[0] == '-' {
+		if fs.corpus.Len() > 0 && len(tok) > 2 && tok[1] == '-' {
+			// long flag: strip --, get name before =
+			clean := tok[2:]
+			if eq := strings.IndexByte(clean, '='); eq != -1 {
+				clean = clean[:eq]
+			}
+			cleanLow := strings.ToLower(clean)
+			bestFlag, flagDist := c.bestMatch(cleanLow, fs.corpus, maxDistForLen(cleanLow))
+			if bestFlag != "" && bestFlag != cleanLow {
+				return &tokenFix{tok, "--" + bestFlag, flagDist, fs.descriptions[bestFlag], confidenceScore(cleanLow, flagDist)}
+			}
+		}
+		return nil
 	}
+
+	// Skip paths, URLs and pure numbers
+	if looksLikePathOrURL(tok) || isNumeric(tokLow) {
+		return nil
+	}
+
+	maxDist := maxDistForLen(tokLow)
+	var best string
+	var dist float64
+
+	if i == 1 && subCorpus.Len() > 0 {
+		best, dist = c.bestMatch(tokLow, subCorpus, maxDist)
+	}
+	if best == "" {
+		best, dist = c.bestMatch(tokLow, globalCorpus, maxDist)
+	}
+
+	if best == "" || best == tokLow {
+		return nil
+	}
+	out := best
+	if isAllUpper(tok) {
+		out = strings.ToUpper(best)
+	}
+	return &tokenFix{tok, out, dist, "", confidenceScore(tokLow, dist)}
+}
+
+// isShellExpansion reports whether a word is quoted, escaped or expanded by
+// the shell, so its text is not what the command receives
+func isShellExpansion(word string) bool {
+	return strings.ContainsAny(word, "'\"`$\\(")
 }
 
 // checkMissingPrefix detects git/docker subcommands used without their parent.
 func (c *Corrector) checkMissingPrefix(command string) *Correction {
-	words := strings.Fields(command)
+	words := shellwords.Fields(command)
 	if len(words) == 0 {
 		return nil
 	}
@@ -275,6 +537,20 @@ func (c *Corrector) checkMissingPrefix(command string) *Correction {
 	return nil
 }
 
+// Closest returns the word of corpus that word is most likely a typo of, or
+// "" when word is in corpus or nothing is close enough
+func (c *Corrector) Closest(word string, corpus *Corpus) string {
+	word = strings.ToLower(word)
+	best, _ := c.bestMatch(word, corpus, maxDistForLen(word))
+	return best
+}
+
+// CheckDangerous returns a dangerous-command Correction when the command
+// matches a known destructive pattern, or nil when it looks safe.
+func (c *Corrector) CheckDangerous(command string) *Correction {
+	return c.checkDangerous(command)
+}
+
 // checkDangerous flags destructive commands with a high-confidence warning.
 func (c *Corrector) checkDangerous(command string) *Correction {
 	cmdLower := strings.ToLower(strings.TrimSpace(command))
@@ -301,45 +577,16 @@ func (c *Corrector) checkDangerous(command string) *Correction {
 	return nil
 }
 
-// checkHistory fuzzy-matches the full sentence against previously used commands.
-// PERF: length pre-filter eliminates impossible matches before Levenshtein.
-func (c *Corrector) checkHistory(command string) *Correction {
-	if len(c.historyCommands) == 0 {
-		return nil
-	}
-	cmdLen := len(command)
-	best := ""
-	bestDist := 5
-	for _, h := range c.historyCommands {
-		// Skip if length difference alone already exceeds threshold
-		if diff := cmdLen - len(h); diff < -bestDist || diff > bestDist {
-			continue
-		}
-		d := edlib.OSADamerauLevenshteinDistance(command, h)
-		if d > 0 && d < bestDist {
-			bestDist = d
-			best = h
-		}
-	}
-	if best == "" {
-		return nil
-	}
-	return &Correction{
-		Original:    command,
-		Corrected:   best,
-		Confidence:  0.65 - float64(bestDist)*0.07,
-		Explanation: fmt.Sprintf("Similar to a past command: '%s'", best),
-	}
-}
-
 // flagSet holds the known long flags for a command.
 type flagSet struct {
-	long []string // without leading --
+	long         []string          // without leading --
+	descriptions map[string]string // by long name, filled from flagDescriptions
+	corpus       *Corpus           // long, indexed for bestMatch
 }
 
 // knownFlags is the package-level flag corpus — built once, zero allocation per call.
 // Previously this was a function that rebuilt a large map on every invocation.
-var knownFlags = map[string]flagSet{
+var knownFlags = indexFlagSets(map[string]flagSet{
 	"docker": {
 		long: []string{
 			"privileged", "interactive", "tty", "detach", "rm",
@@ -463,39 +710,85 @@ var knownFlags = map[string]flagSet{
 			"edit", "reset", "shell", "debug", "help", "version",
 		},
 	},
+})
+
+// indexFlagSets attaches the flagDescriptions of each tool's long flags to
+// its flag set and indexes the flags for bestMatch
+func indexFlagSets(sets map[string]flagSet) map[string]flagSet {
+	for root, fs := range sets {
+		fs.corpus = NewCorpus(fs.long)
+		for flag, desc := range flagDescriptions[root] {
+			name, ok := strings.CutPrefix(flag, "--")
+			if !ok {
+				continue
+			}
+			if fs.descriptions == nil {
+				fs.descriptions = make(map[string]string)
+			}
+			fs.descriptions[name] = desc
+		}
+		sets[root] = fs
+	}
+	return sets
 }
 
 // ──────────────────────────────────────────────────────────────────────────────
 // Helpers
 // ──────────────────────────────────────────────────────────────────────────────
 
-// bestMatch finds the closest string in corpus within maxDist.
+// bestMatch finds the closest string in corpus within maxDist; among equally
+// close words the first one wins.
 // PERF optimisations (in order of cost savings):
-//  1. Length pre-filter: Levenshtein(a,b) ≥ |len(a)-len(b)|. If the length
-//     difference already exceeds maxDist, skip the expensive O(m×n) DP call.
-//  2. Early-exit on exact match (d == 0).
-func bestMatch(token string, corpus []string, maxDist int) (string, int) {
-	tokenLen := len(token)
-	best := ""
-	bestDist := maxDist + 1
-	for _, candidate := range corpus {
-		// O(1) length pre-filter – eliminates ~60-80% of candidates on typical corpora.
-		if diff := tokenLen - len(candidate); diff < -maxDist || diff > maxDist {
-			continue
-		}
-		d := edlib.OSADamerauLevenshteinDistance(token, candidate)
-		if d == 0 {
-			return "", 0 // exact match → no correction needed
-		}
-		if d < bestDist {
-			bestDist = d
-			best = candidate
+//  1. Exact match: a word in the corpus needs no correction, found with one
+//     map lookup before any distance is computed.
+//  2. Length buckets: Levenshtein(a,b) ≥ |len(a)-len(b)|, so only the words
+//     within maxDist of the token's length are visited at all.
+//  3. Letter counts: bagDistance is a lower bound on the edit distance, so a
+//     word that cannot beat the best one so far skips the O(m×n) DP call.
+func (c *Corrector) bestMatch(token string, corpus *Corpus, maxDist int) (string, float64) {
+	if corpus.Len() == 0 {
+		return "", 0
+	}
+	if corpus.Contains(token) {
+		return "", 0 // exact match → no correction needed
+	}
+
+	// Every edit costs at least this much, so bagDistance*minCost bounds the
+	// distance from below
+	minCost := 1.0
+	if c.keyboardAware {
+		minCost = adjacentKeyCost
+	}
+
+	tokenBag := newLetterBag(token)
+	best := -1
+	bestDist := float64(maxDist + 1)
+	for n := max(len(token)-maxDist, 0); n <= len(token)+maxDist && n < len(corpus.byLen); n++ {
+		for _, i := range corpus.byLen[n] {
+			bound := float64(bagDistance(&tokenBag, &corpus.bags[i])) * minCost
+			if bound > bestDist || (bound == bestDist && i > best) {
+				continue
+			}
+			d := c.distance(token, corpus.words[i])
+			if d < bestDist || (d == bestDist && i < best) {
+				bestDist = d
+				best = i
+			}
 		}
 	}
-	if bestDist > maxDist {
+	if best < 0 || bestDist > float64(maxDist) {
 		return "", 0
 	}
-	return best, bestDist
+	return corpus.words[best], bestDist
+}
+
+// distance is the edit distance between two words, weighted by the keyboard
+// layout when keyboard-aware correction is on
+func (c *Corrector) distance(a, b string) float64 {
+	if c.keyboardAware {
+		return keyboardDistance(a, b)
+	}
+	return float64(edlib.OSADamerauLevenshteinDistance(a, b))
 }
 
 // maxDistForLen returns the acceptable edit distance based on token length.
@@ -513,8 +806,8 @@ func maxDistForLen(s string) int {
 }
 
 // confidenceScore converts edit distance to a [0,1] confidence value.
-func confidenceScore(original string, dist int) float64 {
-	ratio := float64(dist) / float64(len(original)+1)
+func confidenceScore(original string, dist float64) float64 {
+	ratio := dist / float64(len(original)+1)
 	score := 1.0 - ratio*1.5
 	if score < 0.3 {
 		score = 0.3
@@ -568,8 +861,11 @@ var dangerousList = []string{
 // BOTTLENECK FIX: these were previously functions that rebuilt slices/maps on
 // every call. Elevating them to vars cuts allocation cost to zero per Correct().
 
-// rootCorpus holds all known root-level shell commands.
-var rootCorpus = []string{
+// rootCorpus indexes rootCommands.
+var rootCorpus = NewCorpus(rootCommands)
+
+// rootCommands holds all known root-level shell commands.
+var rootCommands = []string{
 	// Version control
 	"git", "svn", "hg", "fossil",
 	// Containers / orchestration
@@ -610,8 +906,8 @@ var rootCorpus = []string{
 	"wut",
 }
 
-// subCmdCorpus holds per-root subcommand lists, built once at startup.
-var subCmdCorpus = map[string][]string{
+// subCmdCorpus holds per-root subcommand lists, indexed once at startup.
+var subCmdCorpus = indexSubcommands(map[string][]string{
 	"git":       gitSubcommands,
 	"docker":    dockerSubcommands,
 	"kubectl":   kubectlSubcommands,
@@ -631,9 +927,21 @@ var subCmdCorpus = map[string][]string{
 	"apt-get":   {"install", "remove", "purge", "update", "upgrade", "autoremove", "clean", "autoclean", "dist-upgrade"},
 	"brew":      {"install", "uninstall", "update", "upgrade", "list", "info", "search", "tap", "untap", "link", "unlink", "doctor", "cleanup"},
 	"tar":       {"xf", "xzf", "xjf", "cf", "czf", "cjf", "tf", "tzf"},
-	"wut":       {"suggest", "fix", "explain", "smart", "history", "alias", "config", "db", "install", "bookmark", "stats", "undo", "init"},
+	"wut":       {"suggest", "fix", "explain", "smart", "history", "alias", "config", "db", "install", "bookmark", "stats", "undo", "init", "logs"},
+})
+
+// indexSubcommands builds a Corpus for each root's subcommands
+func indexSubcommands(lists map[string][]string) map[string]*Corpus {
+	corpora := make(map[string]*Corpus, len(lists))
+	for root, list := range lists {
+		corpora[root] = NewCorpus(list)
+	}
+	return corpora
 }
 
+// globalCorpus indexes globalTokens.
+var globalCorpus = NewCorpus(globalTokens)
+
 // globalTokens is the fallback corpus for any token that isn't a root command
 // or a subcommand of the detected root.
 var globalTokens = []string{
@@ -695,16 +1003,38 @@ var kubectlSubcommands = []string{
 
 // ── Modern alternatives map ──────────────────────────────────────────────────
 
-var modernAlternatives = map[string][]string{
-	"ls":   {"exa", "lsd"},
-	"cat":  {"bat", "batcat"},
-	"find": {"fd"},
-	"grep": {"ripgrep", "rg"},
-	"ps":   {"procs"},
-	"top":  {"htop", "btop"},
-	"du":   {"dust"},
-	"df":   {"duf"},
-	"diff": {"delta"},
-	"curl": {"httpie"},
-	"ping": {"gping"},
+// Alternative is a modern tool that replaces a classic command
+type Alternative struct {
+	Name     string // the modern tool
+	Reason   string // why it is worth trying
+	Replaces string // the classic command it replaces
+}
+
+var modernAlternatives = map[string][]Alternative{
+	"ls":   {{Name: "exa", Reason: "colors, icons and git status for each file"}, {Name: "lsd", Reason: "colors and icons with ls-compatible flags"}},
+	"cat":  {{Name: "bat", Reason: "syntax highlighting, line numbers and git changes"}, {Name: "batcat", Reason: "bat as packaged on Debian and Ubuntu"}},
+	"find": {{Name: "fd", Reason: "simpler syntax, faster, and respects .gitignore"}},
+	"grep": {{Name: "ripgrep", Reason: "much faster recursive search that respects .gitignore"}, {Name: "rg", Reason: "ripgrep, a much faster recursive search that respects .gitignore"}},
+	"ps":   {{Name: "procs", Reason: "colored, searchable output with ports and container names"}},
+	"top":  {{Name: "htop", Reason: "interactive, colored process viewer"}, {Name: "btop", Reason: "graphs of CPU, memory, disk and network use"}},
+	"du":   {{Name: "dust", Reason: "a visual tree of what takes up the space"}},
+	"df":   {{Name: "duf", Reason: "a readable table of disks with usage bars"}},
+	"diff": {{Name: "delta", Reason: "syntax-highlighted, side-by-side diffs"}},
+	"curl": {{Name: "httpie", Reason: "readable request syntax and formatted JSON"}},
+	"ping": {{Name: "gping", Reason: "graphs latency over time"}},
+}
+
+// classicCommands maps each modern tool back to the classic command it
+// replaces, built once from modernAlternatives
+var classicCommands = buildClassicIndex()
+
+func buildClassicIndex() map[string]Alternative {
+	index := make(map[string]Alternative)
+	for classic, alts := range modernAlternatives {
+		for _, alt := range alts {
+			alt.Replaces = classic
+			index[alt.Name] = alt
+		}
+	}
+	return index
 }
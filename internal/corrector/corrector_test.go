@@ -0,0 +1,282 @@
+package corrector
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCorrectSkipsEnvAssignments(t *testing.T) {
+	c := New()
+
+	tests := []struct {
+		command string
+		want    string
+	}{
+		{"FOO=bar dokcer run -it alpine", "FOO=bar docker run -it alpine"},
+		{"GOOS=linux GOARCH=arm64 gti status", "GOOS=linux GOARCH=arm64 git status"},
+	}
+	for _, tt := range tests {
+		fix, err := c.Correct(tt.command)
+		if err != nil {
+			t.Fatalf("Correct(%q) error = %v", tt.command, err)
+		}
+		if fix == nil || fix.Corrected != tt.want || fix.Original != tt.command {
+			t.Errorf("Correct(%q) = %+v, want %q", tt.command, fix, tt.want)
+		}
+	}
+
+	if fix, _ := c.Correct("FOO=bar docker ps"); fix != nil {
+		t.Errorf("Correct() of a correct command = %+v, want nil", fix)
+	}
+}
+
+func TestSplitEnvAssignments(t *testing.T) {
+	tests := []struct {
+		command, prefix, rest string
+	}{
+		{"docker ps", "", "docker ps"},
+		{"FOO=bar docker ps", "FOO=bar ", "docker ps"},
+		{"A=1  B=2 make", "A=1  B=2 ", "make"},
+		{"A=1", "A=1", ""},
+		{"1A=x ls", "", "1A=x ls"},
+		{"make CC=clang", "", "make CC=clang"},
+	}
+	for _, tt := range tests {
+		prefix, rest := SplitEnvAssignments(tt.command)
+		if prefix != tt.prefix || rest != tt.rest {
+			t.Errorf("SplitEnvAssignments(%q) = %q, %q, want %q, %q", tt.command, prefix, rest, tt.prefix, tt.rest)
+		}
+	}
+}
+
+func TestCorrectSequence(t *testing.T) {
+	c := New()
+
+	tests := []struct {
+		command string
+		want    string
+	}{
+		{"gti add . && gti commit -m wip", "git add . && git commit -m wip"},
+		{"git status || dokcer ps", "git status || docker ps"},
+		{"ls -la; gti status", "ls -la; git status"},
+		{"FOO=1 dokcer ps && git status; gti log", "FOO=1 docker ps && git status; git log"},
+	}
+	for _, tt := range tests {
+		fix, err := c.Correct(tt.command)
+		if err != nil {
+			t.Fatalf("Correct(%q) error = %v", tt.command, err)
+		}
+		if fix == nil || fix.Corrected != tt.want {
+			t.Errorf("Correct(%q) = %+v, want %q", tt.command, fix, tt.want)
+		}
+	}
+
+	if fix, _ := c.Correct("git add . && git commit -m 'a && b'"); fix != nil {
+		t.Errorf("Correct() of a correct chain = %+v, want nil", fix)
+	}
+}
+
+func TestSplitSequence(t *testing.T) {
+	tests := []struct {
+		command string
+		want    []SequenceStage
+	}{
+		{"git status", []SequenceStage{{"", "git status"}}},
+		{"git add . && git commit", []SequenceStage{{"", "git add ."}, {"&&", "git commit"}}},
+		{"make || echo failed; ls", []SequenceStage{{"", "make"}, {"||", "echo failed"}, {";", "ls"}}},
+		// Pipes and background jobs stay inside their stage
+		{"ps aux | grep go && sleep 1 &", []SequenceStage{{"", "ps aux | grep go"}, {"&&", "sleep 1 &"}}},
+		{"cat a |& tee log; ls", []SequenceStage{{"", "cat a |& tee log"}, {";", "ls"}}},
+		// Quoted, escaped and substituted operators are not separators
+		{`echo "a && b" && echo 'c; d'`, []SequenceStage{{"", `echo "a && b"`}, {"&&", `echo 'c; d'`}}},
+		{`echo a \&\& b`, []SequenceStage{{"", `echo a \&\& b`}}},
+		{"echo $(true && echo ok) || echo `false; true`", []SequenceStage{{"", "echo $(true && echo ok)"}, {"||", "echo `false; true`"}}},
+		{"ls;", []SequenceStage{{"", "ls"}}},
+	}
+	for _, tt := range tests {
+		got := SplitSequence(tt.command)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("SplitSequence(%q) = %q, want %q", tt.command, got, tt.want)
+		}
+	}
+
+	if got := JoinSequence(SplitSequence("a&&b;c ||  d")); got != "a && b; c || d" {
+		t.Errorf("JoinSequence() = %q", got)
+	}
+}
+
+func TestCorrectSkipsSubstitutions(t *testing.T) {
+	c := New()
+
+	for _, command := range []string{
+		"echo $(git rev-parse HEAD)",
+		"echo `git rev-parse HEAD`",
+		`echo "$(date +%s)"`,
+		"git log $(git describe --tags --abbrev=0)..HEAD",
+	} {
+		if fix, _ := c.Correct(command); fix != nil {
+			t.Errorf("Correct(%q) = %+v, want the substitution left alone", command, fix)
+		}
+	}
+
+	// The outer command is still corrected, and the substitution kept whole
+	fix, _ := c.Correct("dokcer rm $(docker ps -aq)")
+	if fix == nil || fix.Corrected != "docker rm $(docker ps -aq)" {
+		t.Errorf("Correct() = %+v, want docker rm $(docker ps -aq)", fix)
+	}
+}
+
+func TestCorrectMinConfidence(t *testing.T) {
+	c := New()
+	fix, _ := c.Correct("gti status")
+	if fix == nil {
+		t.Fatal("Correct(gti status) = nil, want a correction")
+	}
+
+	c.SetMinConfidence(fix.Confidence + 0.01)
+	if got, _ := c.Correct("gti status"); got != nil {
+		t.Errorf("Correct() below the floor = %+v, want nil", got)
+	}
+
+	// The floor does not hide dangerous commands
+	c.SetMinConfidence(1.1)
+	if got, _ := c.Correct("rm -rf /"); got == nil || !got.IsDangerous {
+		t.Errorf("Correct(rm -rf /) = %+v, want a dangerous warning", got)
+	}
+}
+
+func TestSuggestAlternatives(t *testing.T) {
+	c := New()
+	if got := c.SuggestAlternative("ls -la"); !reflect.DeepEqual(got, []string{"exa", "lsd"}) {
+		t.Errorf("SuggestAlternative(ls -la) = %q, want exa and lsd", got)
+	}
+
+	detailed := c.SuggestAlternativeDetailed("CAT README.md")
+	if len(detailed) == 0 || detailed[0].Name != "bat" || detailed[0].Replaces != "cat" || detailed[0].Reason == "" {
+		t.Errorf("SuggestAlternativeDetailed(cat) = %+v, want bat with a reason", detailed)
+	}
+
+	alt, ok := c.ClassicEquivalent("rg TODO")
+	if !ok || alt.Replaces != "grep" || alt.Reason == "" {
+		t.Errorf("ClassicEquivalent(rg) = %+v, %v, want a modern grep", alt, ok)
+	}
+	if _, ok := c.ClassicEquivalent("git status"); ok {
+		t.Error("ClassicEquivalent(git) found a classic command")
+	}
+}
+
+func TestCorrectDescribesFixedFlags(t *testing.T) {
+	fix, _ := New().Correct(`git commit --mesage "wip"`)
+	if fix == nil || fix.Corrected != `git commit --message "wip"` {
+		t.Fatalf("Correct() = %+v, want --message", fix)
+	}
+	if !strings.Contains(fix.Explanation, "'--mesage'→'--message' (Use the given text as the commit message)") {
+		t.Errorf("Explanation = %q, want the flag's description", fix.Explanation)
+	}
+
+	// Flags without a description keep the plain rename
+	fix, _ = New().Correct("kubectl get pods --show-lables")
+	if fix == nil || !strings.Contains(fix.Explanation, "'--show-lables'→'--show-labels'") || strings.Contains(fix.Explanation, "(") {
+		t.Errorf("Correct() = %+v, want a plain rename", fix)
+	}
+}
+
+func TestClosestFlag(t *testing.T) {
+	tests := []struct {
+		root, flag, want string
+	}{
+		{"docker", "--detatch", "--detach"},
+		{"kubectl", "--namspace=dev", "--namespace"},
+		{"find", "-nmae", "-name"},
+		{"terraform", "-auto-aprove", "-auto-approve"},
+		{"docker", "--zzzzzz", ""},
+		{"rm", "-rfv", ""},
+		{"unknown-tool", "--verbose", ""},
+	}
+	for _, tt := range tests {
+		got, ok := ClosestFlag(tt.root, tt.flag)
+		if got != tt.want || ok != (tt.want != "") {
+			t.Errorf("ClosestFlag(%q, %q) = %q, %v, want %q", tt.root, tt.flag, got, ok, tt.want)
+		}
+	}
+}
+
+func TestCorrectLearnsFromFeedback(t *testing.T) {
+	counts := map[string][2]int{}
+	c := New()
+	c.SetFeedback(func(original, corrected string) (int, int) {
+		n := counts[original+"→"+corrected]
+		return n[0], n[1]
+	})
+
+	base, _ := c.Correct("gti status")
+	if base == nil || base.Corrected != "git status" {
+		t.Fatalf("Correct(gti status) = %+v", base)
+	}
+	baseConfidence := base.Confidence
+
+	counts["gti status→git status"] = [2]int{0, 1}
+	if fix, _ := c.Correct("gti status"); fix == nil || fix.Confidence >= baseConfidence {
+		t.Errorf("one rejection: Correct() = %+v, want confidence below %.2f", fix, baseConfidence)
+	}
+
+	counts["gti status→git status"] = [2]int{1, 6}
+	if fix, _ := c.Correct("gti status"); fix != nil {
+		t.Errorf("mostly rejected: Correct() = %+v, want it suppressed", fix)
+	}
+
+	counts["gti status→git status"] = [2]int{8, 4}
+	if fix, _ := c.Correct("gti status"); fix == nil {
+		t.Error("mostly accepted but often rejected: Correct() = nil, want a correction")
+	}
+}
+
+func TestCorrectLongCommand(t *testing.T) {
+	c := New()
+	command := "dokcer run --rm -it --detatch --nmae web -p 8080:80 -v data:/data --restrat always ubuntu"
+	want := "docker run --rm -it --detach --name web -p 8080:80 -v data:/data --restart always ubuntu"
+	fix, err := c.Correct(command)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fix == nil || fix.Corrected != want {
+		t.Fatalf("Correct(%q) = %+v, want %q", command, fix, want)
+	}
+	if !strings.HasPrefix(fix.Explanation, "Fixed: 'dokcer'→'docker', '--detatch'→'--detach'") {
+		t.Errorf("Explanation = %q, want the fixes in token order", fix.Explanation)
+	}
+}
+
+func TestCorrectContextCanceled(t *testing.T) {
+	c := New()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if fix, err := c.CorrectContext(ctx, "gti status"); !errors.Is(err, context.Canceled) {
+		t.Errorf("CorrectContext(canceled) = %+v, %v, want context.Canceled", fix, err)
+	}
+	if fix, err := c.CorrectContext(ctx, "rm -rf /"); err != nil || fix == nil || !fix.IsDangerous {
+		t.Errorf("CorrectContext(canceled, rm -rf /) = %+v, %v, want the dangerous warning", fix, err)
+	}
+
+	// Cancel partway through a command with tens of thousands of tokens
+	command := "git " + strings.Repeat("stauts comit ", 20000)
+	ctx, cancel = context.WithCancel(context.Background())
+	var canceled atomic.Int64
+	time.AfterFunc(5*time.Millisecond, func() {
+		canceled.Store(time.Now().UnixNano())
+		cancel()
+	})
+	fix, err := c.CorrectContext(ctx, command)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("CorrectContext() = %+v, %v, want context.Canceled", fix, err)
+	}
+	if elapsed := time.Since(time.Unix(0, canceled.Load())); elapsed > time.Second {
+		t.Errorf("CorrectContext() returned %v after cancel, want it to stop promptly", elapsed)
+	}
+}
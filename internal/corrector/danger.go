@@ -0,0 +1,69 @@
+package corrector
+
+import (
+	"regexp"
+	"strings"
+)
+
+// ──────────────────────────────────────────────────────────────────────────────
+// Danger assessment
+//
+// checkDangerous only catches commands that destroy the whole system, because
+// Correct must not refuse to fix everyday commands. AssessDanger adds rules for
+// commands that are destructive but routinely used (rm -rf build, git reset
+// --hard), with a lower confidence so callers can tell the two apart.
+// ──────────────────────────────────────────────────────────────────────────────
+
+// CriticalDangerConfidence is the confidence at or above which a dangerous
+// command is treated as critical rather than merely destructive.
+const CriticalDangerConfidence = 0.9
+
+// destructiveRule flags a command that can lose data
+type destructiveRule struct {
+	pattern     *regexp.Regexp
+	confidence  float64
+	explanation string
+}
+
+var destructiveRules = []destructiveRule{
+	{regexp.MustCompile(`(?i)\brm\s+(-\w*r\w*f\w*|-\w*f\w*r\w*|-r\s+-f|-f\s+-r)\b`), 0.8,
+		"⚠️  This recursively deletes files without asking"},
+	{regexp.MustCompile(`(?i)\bmkfs(\.\w+)?\b`), 0.9,
+		"⚠️  This formats a filesystem"},
+	{regexp.MustCompile(`(?i)\bdd\b.*\bof=/dev/`), 0.9,
+		"⚠️  This writes directly to a device"},
+	{regexp.MustCompile(`(?i)\bshred\b`), 0.8,
+		"⚠️  This irrecoverably overwrites files"},
+	{regexp.MustCompile(`(?i)\bchmod\s+(-R\s+)?777\s+/`), 0.8,
+		"⚠️  This makes system files writable by everyone"},
+	{regexp.MustCompile(`(?i)\bgit\s+reset\b.*--hard\b`), 0.7,
+		"⚠️  This discards uncommitted changes"},
+	{regexp.MustCompile(`(?i)\bgit\s+clean\s+-\w*f`), 0.7,
+		"⚠️  This deletes untracked files"},
+	{regexp.MustCompile(`(?i)\bgit\s+push\b.*\s(--force|-f)(\s|$)`), 0.7,
+		"⚠️  This overwrites remote history"},
+	{regexp.MustCompile(`(?i)\b(drop\s+(table|database)|truncate\s+table)\b`), 0.8,
+		"⚠️  This deletes database data"},
+}
+
+// AssessDanger reports whether a command is dangerous to run. The returned
+// Correction has IsDangerous set and a Confidence between 0 and 1; it is nil
+// for commands that look safe.
+func (c *Corrector) AssessDanger(command string) *Correction {
+	if d := c.checkDangerous(command); d != nil {
+		return d
+	}
+
+	command = strings.TrimSpace(command)
+	for _, rule := range destructiveRules {
+		if rule.pattern.MatchString(command) {
+			return &Correction{
+				Original:    command,
+				Confidence:  rule.confidence,
+				Explanation: rule.explanation,
+				IsDangerous: true,
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package corrector
+
+import "testing"
+
+func TestAssessDanger(t *testing.T) {
+	tests := []struct {
+		command   string
+		dangerous bool
+		critical  bool
+	}{
+		{command: "rm -rf /", dangerous: true, critical: true},
+		{command: "rm -rf build", dangerous: true},
+		{command: "rm -r -f build", dangerous: true},
+		{command: "sudo mkfs.ext4 /dev/sdb1", dangerous: true, critical: true},
+		{command: "git reset HEAD~1 --hard", dangerous: true},
+		{command: "git push --force origin main", dangerous: true},
+		{command: "git push --force-with-lease", dangerous: false},
+		{command: "rm file.txt", dangerous: false},
+		{command: "git status", dangerous: false},
+		{command: "ls -rf", dangerous: false},
+	}
+
+	c := New()
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			d := c.AssessDanger(tt.command)
+			if (d != nil) != tt.dangerous {
+				t.Fatalf("AssessDanger() = %+v, want dangerous %v", d, tt.dangerous)
+			}
+			if d == nil {
+				return
+			}
+			if !d.IsDangerous {
+				t.Errorf("IsDangerous is not set")
+			}
+			if critical := d.Confidence >= CriticalDangerConfidence; critical != tt.critical {
+				t.Errorf("confidence %.2f, want critical %v", d.Confidence, tt.critical)
+			}
+		})
+	}
+}
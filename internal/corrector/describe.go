@@ -0,0 +1,467 @@
+package corrector
+
+import (
+	"slices"
+	"strings"
+)
+
+// ──────────────────────────────────────────────────────────────────────────────
+// Command and flag descriptions
+//
+// Short descriptions of common commands and of the flags of the tools people
+// ask about most. `wut explain` describes a command that is a semantic intent
+// as that intent, and otherwise prefers the cached TLDR page for a command and
+// falls back to commandDescriptions; flags are looked up here first, then in
+// shortFlagMap and the knownFlags corpus.
+// ──────────────────────────────────────────────────────────────────────────────
+
+// commandDescriptions describes common base commands
+var commandDescriptions = map[string]string{
+	"awk":            "Pattern scanning and text processing language",
+	"cat":            "Print and concatenate files",
+	"cd":             "Change the current directory",
+	"chmod":          "Change file permissions",
+	"chown":          "Change file owner and group",
+	"cp":             "Copy files and directories",
+	"curl":           "Transfer data from or to a server",
+	"df":             "Show free disk space per filesystem",
+	"docker":         "Manage containers, images, networks and volumes",
+	"docker-compose": "Run multi-container Docker applications",
+	"du":             "Estimate file and directory space usage",
+	"echo":           "Print text",
+	"find":           "Search for files in a directory hierarchy",
+	"git":            "Distributed version control system",
+	"go":             "Build, test and manage Go code",
+	"grep":           "Search text for lines matching a pattern",
+	"head":           "Print the first lines of files",
+	"kill":           "Send a signal to a process",
+	"kubectl":        "Control Kubernetes clusters",
+	"ls":             "List directory contents",
+	"make":           "Build targets described in a Makefile",
+	"mkdir":          "Create directories",
+	"mv":             "Move or rename files and directories",
+	"npm":            "Node.js package manager",
+	"pip":            "Python package installer",
+	"ps":             "List running processes",
+	"python":         "Python interpreter",
+	"python3":        "Python 3 interpreter",
+	"rm":             "Remove files and directories",
+	"rsync":          "Fast, incremental file copying, locally or over SSH",
+	"scp":            "Copy files between hosts over SSH",
+	"sed":            "Stream editor for filtering and transforming text",
+	"sort":           "Sort lines of text",
+	"ssh":            "Log in to and run commands on a remote machine",
+	"sudo":           "Run a command as another user, root by default",
+	"tail":           "Print the last lines of files",
+	"tar":            "Create, list and extract archives",
+	"terraform":      "Provision infrastructure as code",
+	"touch":          "Create files or update their timestamps",
+	"uniq":           "Report or omit repeated lines",
+	"wc":             "Count lines, words and bytes",
+	"wget":           "Download files from the web",
+	"xargs":          "Build and run commands from standard input",
+}
+
+// flagDescriptions describes flags per tool, keyed by the flag as typed.
+// Tools like find and go take long options with a single dash.
+var flagDescriptions = map[string]map[string]string{
+	"git": {
+		"--all":                "Act on all branches, remotes or changes",
+		"--amend":              "Replace the last commit instead of adding one",
+		"--abort":              "Cancel the operation in progress and restore the previous state",
+		"--continue":           "Resume the operation after resolving conflicts",
+		"--force":              "Overwrite remote or local state without checks",
+		"--force-with-lease":   "Force push only if the remote is where you last saw it",
+		"--hard":               "Reset the index and working tree, discarding changes",
+		"--soft":               "Reset only HEAD, keeping changes staged",
+		"--mixed":              "Reset HEAD and the index, keeping working tree changes",
+		"--interactive":        "Edit the list of commits before rebasing",
+		"--no-ff":              "Always create a merge commit",
+		"--oneline":            "Show each commit on a single line",
+		"--graph":              "Draw the commit graph",
+		"--decorate":           "Show branch and tag names next to commits",
+		"--rebase":             "Rebase local commits onto the fetched branch",
+		"--set-upstream":       "Track the remote branch from now on",
+		"--staged":             "Act on the staged changes",
+		"--cached":             "Act on the index instead of the working tree",
+		"--dry-run":            "Show what would happen without doing it",
+		"--prune":              "Remove references to deleted remote branches",
+		"--tags":               "Include tags",
+		"--message":            "Use the given text as the commit message",
+		"--author":             "Record the commit under another author",
+		"--patch":              "Pick the changes to act on hunk by hunk",
+		"--quiet":              "Print only errors",
+		"--verbose":            "Print more detail",
+		"--signoff":            "Add a Signed-off-by line to the commit message",
+		"--squash":             "Merge the changes without creating a merge commit",
+		"--autostash":          "Stash local changes before and restore them after",
+		"--no-edit":            "Keep the existing or default commit message",
+		"--track":              "Make the new branch track its start point",
+		"--stat":               "Show a summary of changed files",
+		"--word-diff":          "Show changed words instead of lines",
+		"--skip":               "Skip the current commit and carry on",
+		"--recurse-submodules": "Include submodules",
+		"-i":                   "Edit the list of commits before rebasing",
+		"-S":                   "Find commits that add or remove the given text",
+		"--remote":             "Act on remote-tracking branches",
+		"--upstream":           "Branch to rebase onto or track",
+		"--no-rebase":          "Merge instead of rebasing when pulling",
+		"--color":              "Colorize the output",
+	},
+	"docker": {
+		"--rm":          "Remove the container when it exits",
+		"--detach":      "Run in the background",
+		"--interactive": "Keep STDIN open",
+		"--tty":         "Allocate a pseudo-TTY",
+		"--name":        "Name the container",
+		"--publish":     "Publish a container port on the host",
+		"--volume":      "Bind mount a volume",
+		"--env":         "Set an environment variable",
+		"--privileged":  "Give the container full access to the host",
+		"--network":     "Connect the container to a network",
+		"--restart":     "Restart policy when the container exits",
+		"--tag":         "Name and tag the built image",
+		"--file":        "Path to the Dockerfile",
+		"--no-cache":    "Build without using cached layers",
+		"--follow":      "Keep streaming new output",
+		"--since":       "Only show output since a timestamp or duration",
+		"--tail":        "Number of lines to show from the end",
+		"--all":         "Include stopped containers or unused images",
+		"--force":       "Do not prompt for confirmation",
+		"--mount":       "Attach a filesystem mount to the container",
+		"--expose":      "Expose a port without publishing it",
+		"--platform":    "Platform to run or build for, e.g. linux/arm64",
+		"--entrypoint":  "Override the image's default entrypoint",
+		"--workdir":     "Working directory inside the container",
+		"--user":        "User to run as inside the container",
+		"--memory":      "Memory limit",
+		"--cpus":        "Number of CPUs the container may use",
+		"--label":       "Set metadata on the container or image",
+		"--cap-add":     "Add a Linux capability",
+		"--cap-drop":    "Drop a Linux capability",
+		"--device":      "Give the container access to a host device",
+		"--build-arg":   "Set a build-time variable",
+		"--target":      "Build stage to stop at",
+		"--filter":      "Filter the output by a condition",
+		"--format":      "Format the output with a Go template",
+		"--quiet":       "Only print IDs",
+	},
+	"kubectl": {
+		"--namespace":      "Namespace to act in",
+		"--all-namespaces": "Act across all namespaces",
+		"--output":         "Output format (json, yaml, wide, name)",
+		"--selector":       "Filter by label selector",
+		"--filename":       "File or directory of manifests",
+		"--watch":          "Keep watching for changes",
+		"--replicas":       "Number of replicas",
+		"--container":      "Container within the pod",
+		"--follow":         "Keep streaming new logs",
+		"--dry-run":        "Preview the change without applying it",
+		"--force":          "Delete immediately, bypassing graceful deletion",
+		"--context":        "Kubeconfig context to use",
+		"-it":              "Interactive terminal attached to the container",
+		"--grace-period":   "Seconds to give the resource to shut down",
+		"--wait":           "Wait for the change to finish",
+		"--timeout":        "Give up after this long",
+		"--kubeconfig":     "Path to the kubeconfig file",
+		"--image":          "Container image to use",
+		"--port":           "Port to expose or forward",
+		"--stdin":          "Pass STDIN to the container",
+		"--tty":            "Allocate a TTY for the container",
+		"--from-literal":   "Add a key=value pair to the secret or config map",
+		"--from-file":      "Add a file's contents to the secret or config map",
+		"--sort-by":        "Sort the list by a JSONPath expression",
+		"--field-selector": "Filter by resource fields",
+		"--overwrite":      "Replace existing values",
+		"--recursive":      "Process the directory recursively",
+	},
+	"npm": {
+		"--save-dev":         "Record the package as a devDependency",
+		"--save-exact":       "Record the exact version instead of a range",
+		"--global":           "Install into the global prefix",
+		"--legacy-peer-deps": "Ignore peer dependency conflicts",
+		"--production":       "Skip devDependencies",
+		"--force":            "Override safety checks",
+		"--dry-run":          "Show what would happen without doing it",
+		"--ignore-scripts":   "Do not run package lifecycle scripts",
+		"--no-save":          "Do not record the package in package.json",
+		"--prefer-offline":   "Use the cache before the network",
+		"--workspace":        "Run in the given workspace",
+		"--workspaces":       "Run in every workspace",
+		"--audit":            "Check for known vulnerabilities",
+		"--prefix":           "Directory to run in",
+	},
+	"go": {
+		"-v":        "Print package names or test output as they run",
+		"-race":     "Enable the data race detector",
+		"-run":      "Run only tests matching the pattern",
+		"-bench":    "Run benchmarks matching the pattern",
+		"-count":    "Run each test this many times",
+		"-cover":    "Report test coverage",
+		"-o":        "Write the binary to this file",
+		"-ldflags":  "Flags passed to the linker",
+		"-tags":     "Build tags to enable",
+		"-trimpath": "Remove file system paths from the binary",
+		"-timeout":  "Fail tests running longer than this",
+		"-short":    "Tell long-running tests to shorten",
+		"-u":        "Update modules to newer versions",
+	},
+	"curl": {
+		"--request":         "HTTP method to use",
+		"--header":          "Add a request header",
+		"--data":            "Send data in the request body",
+		"--output":          "Write the response to a file",
+		"--location":        "Follow redirects",
+		"--silent":          "Hide progress and errors",
+		"--insecure":        "Skip TLS certificate verification",
+		"--fail":            "Exit with an error on HTTP errors",
+		"--include":         "Include response headers in the output",
+		"--max-time":        "Give up after this many seconds",
+		"-S":                "Show errors even when silent",
+		"--data-raw":        "Send data without treating @ specially",
+		"--data-binary":     "Send data exactly as given",
+		"--form":            "Send a multipart form field",
+		"--upload-file":     "Upload a file",
+		"--user-agent":      "User-Agent header to send",
+		"--compressed":      "Request a compressed response and decompress it",
+		"--connect-timeout": "Give up connecting after this many seconds",
+		"--retry":           "Retry this many times on transient errors",
+		"--proxy":           "Use this proxy",
+		"--cookie-jar":      "Write cookies to this file",
+	},
+	"tar": {
+		"--extract": "Extract files from an archive",
+		"--create":  "Create a new archive",
+		"--gzip":    "Compress or decompress with gzip",
+		"--file":    "Archive file to use",
+		"--list":    "List the archive contents",
+		"--verbose": "List files as they are processed",
+	},
+	"ls": {
+		"--all":            "Show hidden files",
+		"--human-readable": "Show sizes like 1K, 234M",
+		"--color":          "Colorize the output",
+	},
+	"grep": {
+		"--ignore-case":   "Ignore case when matching",
+		"--recursive":     "Search directories recursively",
+		"--line-number":   "Show line numbers",
+		"--invert-match":  "Show lines that do not match",
+		"--include":       "Only search files matching the glob",
+		"--exclude":       "Skip files matching the glob",
+		"--color":         "Highlight matches",
+		"--fixed-strings": "Treat the pattern as a literal string",
+		"--perl-regexp":   "Use Perl-compatible regular expressions",
+		"--with-filename": "Print the file name with each match",
+		"--no-filename":   "Do not print file names",
+		"--line-regexp":   "Match whole lines only",
+		"--context":       "Show this many lines around each match",
+		"--max-count":     "Stop after this many matches",
+		"--text":          "Treat binary files as text",
+	},
+	"find": {
+		"-name":     "Match file names against a glob",
+		"-iname":    "Match file names, ignoring case",
+		"-type":     "Match a file type (f file, d directory, l link)",
+		"-size":     "Match files by size",
+		"-mtime":    "Match files by modification age in days",
+		"-maxdepth": "Descend at most this many levels",
+		"-exec":     "Run a command on each match",
+		"-delete":   "Delete every match",
+		"-print":    "Print each match",
+		"-path":     "Match the whole path against a glob",
+	},
+	"rm": {
+		"-r":          "Remove directories and their contents recursively",
+		"-R":          "Remove directories and their contents recursively",
+		"-f":          "Never prompt and ignore missing files",
+		"-i":          "Prompt before every removal",
+		"-v":          "Print each removed file",
+		"-d":          "Remove empty directories",
+		"--recursive": "Remove directories and their contents recursively",
+		"--force":     "Never prompt and ignore missing files",
+	},
+	"cp": {
+		"-r":          "Copy directories recursively",
+		"-R":          "Copy directories recursively",
+		"-a":          "Archive mode: copy recursively, preserving attributes",
+		"-f":          "Overwrite destination files without asking",
+		"-i":          "Prompt before overwriting",
+		"-n":          "Never overwrite existing files",
+		"-p":          "Preserve mode, ownership and timestamps",
+		"-v":          "Print each copied file",
+		"--recursive": "Copy directories recursively",
+	},
+	"mv": {
+		"-f": "Overwrite destination files without asking",
+		"-i": "Prompt before overwriting",
+		"-n": "Never overwrite existing files",
+		"-v": "Print each moved file",
+	},
+	"mkdir": {
+		"-p":        "Create parent directories as needed",
+		"-v":        "Print each created directory",
+		"--parents": "Create parent directories as needed",
+	},
+	"chmod": {
+		"--recursive": "Change files and directories recursively",
+	},
+	"rsync": {
+		"--archive":  "Archive mode: recursive, preserving attributes",
+		"--delete":   "Delete files on the receiver that are gone from the sender",
+		"--dry-run":  "Show what would be transferred",
+		"--progress": "Show progress during transfer",
+		"--exclude":  "Skip files matching the pattern",
+	},
+	"ssh": {
+		"-o":     "Set a configuration option",
+		"-J":     "Connect through a jump host",
+		"--jump": "Connect through a jump host",
+	},
+	"docker-compose": {
+		"--detach":         "Run services in the background",
+		"--build":          "Build images before starting",
+		"--no-build":       "Do not build missing images",
+		"--force-recreate": "Recreate containers even if unchanged",
+		"--no-recreate":    "Keep existing containers",
+		"--no-deps":        "Do not start linked services",
+		"--remove-orphans": "Remove containers of services no longer defined",
+		"--volumes":        "Also remove named volumes",
+		"--file":           "Compose file to use",
+		"--project-name":   "Project name to use",
+		"--timeout":        "Seconds to wait for containers to stop",
+	},
+	"apt": {
+		"--yes":                   "Answer yes to every prompt",
+		"--assume-yes":            "Answer yes to every prompt",
+		"--no-install-recommends": "Skip recommended packages",
+		"--install-suggests":      "Also install suggested packages",
+		"--fix-broken":            "Try to fix broken dependencies",
+		"--reinstall":             "Reinstall packages that are already installed",
+		"--purge":                 "Also remove configuration files",
+		"--auto-remove":           "Remove dependencies that are no longer needed",
+		"--dry-run":               "Show what would happen without doing it",
+		"--allow-downgrades":      "Allow installing older versions",
+		"--allow-unauthenticated": "Install packages that cannot be verified",
+	},
+	"systemctl": {
+		"--all":       "Include inactive units",
+		"--type":      "Only show units of this type",
+		"--state":     "Only show units in this state",
+		"--failed":    "Only show failed units",
+		"--no-pager":  "Print without a pager",
+		"--no-block":  "Do not wait for the job to finish",
+		"--user":      "Manage the user's service manager",
+		"--now":       "Also start or stop the unit",
+		"--full":      "Do not shorten unit names or output",
+		"--quiet":     "Print less",
+		"--no-legend": "Leave out column headers and hints",
+	},
+	"terraform": {
+		"--auto-approve": "Apply without asking for confirmation",
+		"-auto-approve":  "Apply without asking for confirmation",
+		"-var":           "Set an input variable",
+		"-var-file":      "Load input variables from a file",
+		"-target":        "Limit the operation to a resource",
+		"-out":           "Save the plan to a file",
+		"-destroy":       "Plan to destroy all resources",
+	},
+}
+
+// DescribeCommand returns a short description of command: what the
+// semantic intent with that command does, or else what its base command is
+func DescribeCommand(command string) (string, bool) {
+	if desc, ok := DescribeIntent(command); ok {
+		return desc, true
+	}
+	words := strings.Fields(command)
+	if len(words) == 0 {
+		return "", false
+	}
+	desc, ok := commandDescriptions[words[0]]
+	return desc, ok
+}
+
+// DescribeIntent is the reverse of QuerySemantic: it returns the description
+// of the intent whose command is command. A placeholder of the intent's
+// command stands for any one argument, and the words of command may come in
+// another order; an exact match wins over one that needs reordering.
+func DescribeIntent(command string) (string, bool) {
+	words := intentWords(command)
+	if len(words) == 0 {
+		return "", false
+	}
+
+	intents := loadIntentSet().intents
+	best, bestLiterals := -1, 0
+	for i, intent := range intents {
+		pattern := intentWords(intent.Command)
+		if matchesIntentCommand(words, pattern) {
+			return intent.Description, true
+		}
+		if literals, ok := matchesIntentWords(words, pattern); ok && literals > bestLiterals {
+			best, bestLiterals = i, literals
+		}
+	}
+	if best < 0 {
+		return "", false
+	}
+	return intents[best].Description, true
+}
+
+// intentWords splits a command into words without their quotes, which
+// intent commands keep around placeholders and a typed command may not
+func intentWords(command string) []string {
+	words := strings.Fields(command)
+	for i, w := range words {
+		words[i] = strings.Trim(w, `'"`)
+	}
+	return words
+}
+
+// matchesIntentCommand reports whether words are pattern word for word, an
+// argument standing in for each placeholder
+func matchesIntentCommand(words, pattern []string) bool {
+	if len(words) != len(pattern) {
+		return false
+	}
+	for i, w := range words {
+		if w != pattern[i] && !placeholderPattern.MatchString(pattern[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesIntentWords reports whether words are pattern's command followed
+// by its other words in any order, with one argument per placeholder, and
+// returns how many words of pattern are not placeholders
+func matchesIntentWords(words, pattern []string) (int, bool) {
+	if len(pattern) == 0 || words[0] != pattern[0] {
+		return 0, false
+	}
+	rest := slices.Clone(words[1:])
+	literals, placeholders := 1, 0
+	for _, p := range pattern[1:] {
+		if placeholderPattern.MatchString(p) {
+			placeholders++
+			continue
+		}
+		i := slices.Index(rest, p)
+		if i < 0 {
+			return 0, false
+		}
+		rest = slices.Delete(rest, i, i+1)
+		literals++
+	}
+	// What is left fills the placeholders, and flags are not arguments
+	if len(rest) != placeholders {
+		return 0, false
+	}
+	for _, w := range rest {
+		if strings.HasPrefix(w, "-") {
+			return 0, false
+		}
+	}
+	return literals, true
+}
@@ -0,0 +1,324 @@
+package corrector
+
+import (
+	"net"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ──────────────────────────────────────────────────────────────────────────────
+// Entity extraction
+//
+// Pulls typed values out of a natural-language query so the placeholders of a
+// semantic match can be filled in: "logs for container redis since 10m" gives
+// a container named redis and a 10m duration. Entities come from two kinds of
+// evidence:
+//   1. A type word followed by a name ("pod api-server", "deployment web")
+//   2. The shape of a token (8080:80, 10m, 10.0.0.1, nginx:1.25)
+// When candidates overlap, the one backed by a type word wins, then the
+// longer one.
+// ──────────────────────────────────────────────────────────────────────────────
+
+// EntityKind is the type of value an Entity holds
+type EntityKind string
+
+const (
+	EntityPort       EntityKind = "port"
+	EntityDuration   EntityKind = "duration"
+	EntityContainer  EntityKind = "container"
+	EntityPod        EntityKind = "pod"
+	EntityDeployment EntityKind = "deployment"
+	EntityImage      EntityKind = "image"
+	EntityHost       EntityKind = "host"
+)
+
+// Entity is a typed value found in a query. Start and End are byte offsets
+// of Value in the query.
+type Entity struct {
+	Kind  EntityKind
+	Value string
+	Start int
+	End   int
+}
+
+// typeWords map the words that announce a name to the kind of that name
+var typeWords = map[string]EntityKind{
+	"container":   EntityContainer,
+	"containers":  EntityContainer,
+	"pod":         EntityPod,
+	"pods":        EntityPod,
+	"deployment":  EntityDeployment,
+	"deployments": EntityDeployment,
+	"deploy":      EntityDeployment,
+	"image":       EntityImage,
+	"images":      EntityImage,
+	"host":        EntityHost,
+	"server":      EntityHost,
+}
+
+// nameFillers may sit between a type word and the name ("pod named api")
+var nameFillers = map[string]bool{"named": true, "called": true}
+
+var (
+	durationPattern = regexp.MustCompile(`^\d+(ms|s|m|h|d|w)$`)
+	namePattern     = regexp.MustCompile(`^[a-z0-9]([a-z0-9._-]*[a-z0-9])?$`)
+	imagePattern    = regexp.MustCompile(`^[a-z0-9]+([._/-][a-z0-9]+)*(:[a-z0-9_][a-z0-9_.-]*)?$`)
+	hostnamePattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?(\.[a-z0-9]([a-z0-9-]*[a-z0-9])?)*$`)
+)
+
+// entityCandidate is a possible entity before overlaps are resolved
+type entityCandidate struct {
+	Entity
+	typed bool // announced by a type word
+}
+
+// queryToken is a word of the query with its byte span
+type queryToken struct {
+	text       string
+	start, end int
+}
+
+// ExtractEntities returns the entities found in query, ordered by position.
+// Overlapping candidates are resolved so no two entities share a byte.
+func ExtractEntities(query string) []Entity {
+	tokens := spanTokens(query)
+
+	var candidates []entityCandidate
+	add := func(kind EntityKind, value string, start int, typed bool) {
+		candidates = append(candidates, entityCandidate{
+			Entity: Entity{Kind: kind, Value: value, Start: start, End: start + len(value)},
+			typed:  typed,
+		})
+	}
+
+	portContext := false
+	for i, tok := range tokens {
+		lower := strings.ToLower(tok.text)
+
+		// Names announced by a type word
+		if kind, ok := typeWords[lower]; ok {
+			j := i + 1
+			if j < len(tokens) && nameFillers[strings.ToLower(tokens[j].text)] {
+				j++
+			}
+			if j < len(tokens) {
+				next := tokens[j]
+				if kind == EntityHost {
+					if host, _, ok := splitHostPort(next.text); ok {
+						add(EntityHost, host, next.start, true)
+					}
+				} else if isEntityName(kind, next.text) {
+					add(kind, next.text, next.start, true)
+				}
+			}
+		}
+
+		switch {
+		case lower == "port" || lower == "ports":
+			portContext = true
+			continue
+		case portContext && (lower == "and" || lower == "to"):
+			continue
+		}
+
+		if ports, ok := parsePorts(lower, portContext); ok {
+			add(EntityPort, ports, tok.start, portContext)
+			continue
+		}
+		portContext = false
+
+		if durationPattern.MatchString(lower) {
+			add(EntityDuration, lower, tok.start, false)
+			continue
+		}
+		if host, port, ok := splitHostPort(lower); ok && isAddress(host) {
+			add(EntityHost, tok.text[:len(host)], tok.start, false)
+			if port != "" {
+				add(EntityPort, port, tok.start+len(host)+1, false)
+			}
+			continue
+		}
+		if strings.Contains(lower, ":") && imagePattern.MatchString(lower) {
+			add(EntityImage, tok.text, tok.start, false)
+		}
+	}
+
+	return resolveEntities(candidates)
+}
+
+// resolveEntities drops candidates that overlap a stronger one: typed beats
+// untyped, then longer beats shorter, then earlier beats later
+func resolveEntities(candidates []entityCandidate) []Entity {
+	sort.SliceStable(candidates, func(a, b int) bool {
+		ca, cb := candidates[a], candidates[b]
+		if ca.typed != cb.typed {
+			return ca.typed
+		}
+		if la, lb := ca.End-ca.Start, cb.End-cb.Start; la != lb {
+			return la > lb
+		}
+		return ca.Start < cb.Start
+	})
+
+	var entities []Entity
+	for _, c := range candidates {
+		overlaps := false
+		for _, e := range entities {
+			if c.Start < e.End && e.Start < c.End {
+				overlaps = true
+				break
+			}
+		}
+		if !overlaps {
+			entities = append(entities, c.Entity)
+		}
+	}
+
+	sort.Slice(entities, func(a, b int) bool {
+		return entities[a].Start < entities[b].Start
+	})
+	return entities
+}
+
+// spanTokens splits s into words with their byte spans, trimming the
+// punctuation around them
+func spanTokens(s string) []queryToken {
+	var tokens []queryToken
+	start := -1
+	flush := func(end int) {
+		if start < 0 {
+			return
+		}
+		word := s[start:end]
+		trimmedLeft := strings.TrimLeft(word, "\"'(,;!?")
+		offset := start + len(word) - len(trimmedLeft)
+		word = strings.TrimRight(trimmedLeft, "\"'),;!?.")
+		if word != "" {
+			tokens = append(tokens, queryToken{text: word, start: offset, end: offset + len(word)})
+		}
+		start = -1
+	}
+	for i, r := range s {
+		if r == ' ' || r == '\t' || r == '\n' {
+			flush(i)
+		} else if start < 0 {
+			start = i
+		}
+	}
+	flush(len(s))
+	return tokens
+}
+
+// isEntityName reports whether word can name a resource of the given kind
+func isEntityName(kind EntityKind, word string) bool {
+	word = strings.ToLower(word)
+	if stopWords[word] || typeWords[word] != "" {
+		return false
+	}
+	if kind == EntityImage {
+		return imagePattern.MatchString(word)
+	}
+	return namePattern.MatchString(word)
+}
+
+// parsePorts recognises :8080, 8080:80 and, after the word port, 8080. Every
+// port must be in 1–65535.
+func parsePorts(word string, afterPortWord bool) (string, bool) {
+	parts := strings.Split(strings.TrimPrefix(word, ":"), ":")
+	if len(parts) > 2 || (len(parts) == 1 && !afterPortWord && !strings.HasPrefix(word, ":")) {
+		return "", false
+	}
+	for _, p := range parts {
+		if !validPort(p) {
+			return "", false
+		}
+	}
+	return strings.Join(parts, ":"), true
+}
+
+func validPort(s string) bool {
+	n, err := strconv.Atoi(s)
+	return err == nil && n >= 1 && n <= 65535 && s[0] != '+'
+}
+
+// splitHostPort splits host[:port], validating the port when present
+func splitHostPort(word string) (host, port string, ok bool) {
+	host, port, found := strings.Cut(word, ":")
+	if found && !validPort(port) {
+		return "", "", false
+	}
+	if !isAddress(host) && !hostnamePattern.MatchString(strings.ToLower(host)) {
+		return "", "", false
+	}
+	return host, port, true
+}
+
+// isAddress reports whether host is an IP address or localhost
+func isAddress(host string) bool {
+	return host == "localhost" || net.ParseIP(host) != nil
+}
+
+// placeholderPattern matches a <placeholder> in an intent command
+var placeholderPattern = regexp.MustCompile(`<([^<>]+)>`)
+
+// placeholderKinds maps placeholder names used by intents to entity kinds
+var placeholderKinds = map[string]EntityKind{
+	"port":       EntityPort,
+	"ports":      EntityPort,
+	"duration":   EntityDuration,
+	"container":  EntityContainer,
+	"pod":        EntityPod,
+	"deployment": EntityDeployment,
+	"image":      EntityImage,
+	"host":       EntityHost,
+}
+
+// FillEntities replaces the placeholders of command with entities of the
+// matching kind, in order of appearance. <name> takes the deployment,
+// container or pod the command is about. Placeholders without an entity are
+// left in place.
+func FillEntities(command string, entities []Entity) string {
+	used := make([]bool, len(entities))
+	next := func(kinds ...EntityKind) (string, bool) {
+		for _, kind := range kinds {
+			for i, e := range entities {
+				if !used[i] && e.Kind == kind {
+					used[i] = true
+					return e.Value, true
+				}
+			}
+		}
+		return "", false
+	}
+
+	return placeholderPattern.ReplaceAllStringFunc(command, func(placeholder string) string {
+		name := placeholder[1 : len(placeholder)-1]
+		var kinds []EntityKind
+		switch {
+		case name == "name":
+			kinds = nameKinds(command)
+		case placeholderKinds[name] != "":
+			kinds = []EntityKind{placeholderKinds[name]}
+		default:
+			return placeholder
+		}
+		if value, ok := next(kinds...); ok {
+			return value
+		}
+		return placeholder
+	})
+}
+
+// nameKinds guesses which kind of resource a <name> placeholder refers to
+func nameKinds(command string) []EntityKind {
+	switch {
+	case strings.Contains(command, "deployment"):
+		return []EntityKind{EntityDeployment}
+	case strings.HasPrefix(command, "docker build"):
+		return []EntityKind{EntityImage}
+	default:
+		return nil
+	}
+}
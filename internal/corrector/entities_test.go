@@ -0,0 +1,124 @@
+package corrector
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractEntities(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []Entity
+	}{
+		{
+			query: "forward port 8080 of pod api-server",
+			want: []Entity{
+				{Kind: EntityPort, Value: "8080", Start: 13, End: 17},
+				{Kind: EntityPod, Value: "api-server", Start: 25, End: 35},
+			},
+		},
+		{
+			query: "logs for container redis since 10m",
+			want: []Entity{
+				{Kind: EntityContainer, Value: "redis", Start: 19, End: 24},
+				{Kind: EntityDuration, Value: "10m", Start: 31, End: 34},
+			},
+		},
+		{
+			query: "ports 3000 and 9090",
+			want: []Entity{
+				{Kind: EntityPort, Value: "3000", Start: 6, End: 10},
+				{Kind: EntityPort, Value: "9090", Start: 15, End: 19},
+			},
+		},
+		{
+			// Out of range ports and bare numbers without a port word are not ports
+			query: "port 70000 and 42 retries",
+			want:  nil,
+		},
+		{
+			query: "scale deployment named web to 3",
+			want: []Entity{
+				{Kind: EntityDeployment, Value: "web", Start: 23, End: 26},
+			},
+		},
+		{
+			query: "pull nginx:1.25 then ping 10.0.0.7",
+			want: []Entity{
+				{Kind: EntityImage, Value: "nginx:1.25", Start: 5, End: 15},
+				{Kind: EntityHost, Value: "10.0.0.7", Start: 26, End: 34},
+			},
+		},
+		{
+			// An address with a port splits into a host and a port, not an image
+			query: "curl localhost:8080",
+			want: []Entity{
+				{Kind: EntityHost, Value: "localhost", Start: 5, End: 14},
+				{Kind: EntityPort, Value: "8080", Start: 15, End: 19},
+			},
+		},
+		{
+			// Container names cannot contain a colon, so this is an image
+			query: "restart container redis:7",
+			want: []Entity{
+				{Kind: EntityImage, Value: "redis:7", Start: 18, End: 25},
+			},
+		},
+		{
+			// The type word wins over the host and port read from the token
+			query: "push to image localhost:5000",
+			want: []Entity{
+				{Kind: EntityImage, Value: "localhost:5000", Start: 14, End: 28},
+			},
+		},
+		{
+			// A port mapping, and a host announced by its type word
+			query: "port 8080:80 on host db.internal",
+			want: []Entity{
+				{Kind: EntityPort, Value: "8080:80", Start: 5, End: 12},
+				{Kind: EntityHost, Value: "db.internal", Start: 21, End: 32},
+			},
+		},
+		{
+			// Type words and fillers are never names
+			query: "list pods in the namespace",
+			want:  nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			got := ExtractEntities(tt.query)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ExtractEntities(%q)\n got: %+v\nwant: %+v", tt.query, got, tt.want)
+			}
+			for _, e := range got {
+				if tt.query[e.Start:e.End] != e.Value {
+					t.Errorf("span %d:%d is %q, value %q", e.Start, e.End, tt.query[e.Start:e.End], e.Value)
+				}
+			}
+		})
+	}
+}
+
+func TestQuerySemanticFillsPlaceholders(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"forward port 8080 of pod api-server", "kubectl port-forward api-server 8080"},
+		{"show logs for container redis since 10m", "docker logs --since 10m redis"},
+		{"scale deployment web to more replicas", "kubectl scale deployment web --replicas=<n>"},
+	}
+
+	for _, tt := range tests {
+		results := QuerySemantic(tt.query, 1)
+		if len(results) == 0 {
+			t.Errorf("QuerySemantic(%q) found nothing", tt.query)
+			continue
+		}
+		if results[0].Command != tt.want {
+			t.Errorf("QuerySemantic(%q) command = %q, want %q", tt.query, results[0].Command, tt.want)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package corrector
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/hbollon/go-edlib"
+)
+
+// HistoryEntry is a past command with how often and how recently it ran
+type HistoryEntry struct {
+	Command    string
+	UsageCount int
+	LastUsed   time.Time
+}
+
+const (
+	// DefaultHistoryThreshold is the score a past command needs to be
+	// proposed when no threshold is set
+	DefaultHistoryThreshold = 0.5
+
+	// maxHistoryDistance is the most edits a past command may be away
+	maxHistoryDistance = 4
+
+	// historyHalfLife is how long it takes a command's recency to halve
+	historyHalfLife = 14 * 24 * time.Hour
+
+	// How much closeness, usage and recency count towards a history score
+	historySimilarityWeight = 0.6
+	historyFrequencyWeight  = 0.25
+	historyRecencyWeight    = 0.15
+
+	// historyMaxConfidence is the confidence of a perfect history score
+	historyMaxConfidence = 0.7
+)
+
+// SetHistoryCommands supplies past commands for additional fuzzy matching.
+// Each counts as used once, at an unknown time; SetHistoryEntries lets the
+// commands used most, and most lately, win.
+func (c *Corrector) SetHistoryCommands(cmds []string) {
+	entries := make([]HistoryEntry, len(cmds))
+	for i, cmd := range cmds {
+		entries[i] = HistoryEntry{Command: cmd, UsageCount: 1}
+	}
+	c.SetHistoryEntries(entries)
+}
+
+// SetHistoryEntries supplies past commands with their usage for fuzzy
+// matching. A typed command is matched to the one whose blend of closeness,
+// usage count and recency scores highest.
+func (c *Corrector) SetHistoryEntries(entries []HistoryEntry) {
+	c.history = entries
+	c.historyMaxUsage = 1
+	for _, e := range entries {
+		c.historyMaxUsage = max(c.historyMaxUsage, e.UsageCount)
+	}
+}
+
+// SetHistoryThreshold sets the score, from 0 to 1, a past command needs to
+// be proposed as a correction. Zero or less uses DefaultHistoryThreshold.
+func (c *Corrector) SetHistoryThreshold(threshold float64) {
+	c.historyThreshold = threshold
+}
+
+// SetUseHistory turns proposing similar past commands as corrections on or
+// off. It is on by default.
+func (c *Corrector) SetUseHistory(enabled bool) {
+	c.historyDisabled = !enabled
+}
+
+// UsesHistory reports whether past commands are proposed as corrections
+func (c *Corrector) UsesHistory() bool {
+	return !c.historyDisabled
+}
+
+// checkHistory fuzzy-matches the full sentence against previously used commands.
+// PERF: length pre-filter eliminates impossible matches before Levenshtein.
+func (c *Corrector) checkHistory(command string) *Correction {
+	if c.historyDisabled || len(c.history) == 0 {
+		return nil
+	}
+	threshold := c.historyThreshold
+	if threshold <= 0 {
+		threshold = DefaultHistoryThreshold
+	}
+
+	now := time.Now()
+	cmdLen := len(command)
+	var best *HistoryEntry
+	bestScore := 0.0
+	for i := range c.history {
+		h := &c.history[i]
+		if h.Command == command {
+			// Already something the user runs
+			return nil
+		}
+		// Skip if length difference alone already exceeds threshold
+		if diff := cmdLen - len(h.Command); diff < -maxHistoryDistance || diff > maxHistoryDistance {
+			continue
+		}
+		d := edlib.OSADamerauLevenshteinDistance(command, h.Command)
+		if d == 0 || d > maxHistoryDistance {
+			continue
+		}
+		score := c.historyScore(h, d, cmdLen, now)
+		if score < threshold {
+			continue
+		}
+		// Equal scores go to the command run last
+		if best == nil || score > bestScore || score == bestScore && h.LastUsed.After(best.LastUsed) {
+			best, bestScore = h, score
+		}
+	}
+	if best == nil {
+		return nil
+	}
+
+	explanation := fmt.Sprintf("Similar to a past command: '%s'", best.Command)
+	if best.UsageCount > 1 {
+		explanation += fmt.Sprintf(" (run %d times)", best.UsageCount)
+	}
+	return &Correction{
+		Original:    command,
+		Corrected:   best.Command,
+		Confidence:  bestScore * historyMaxConfidence,
+		Explanation: explanation,
+	}
+}
+
+// historyScore blends, from 0 to 1, how close a past command is to the typed
+// one (edit distance over the longer length), how often it ran relative to
+// the most used one, and how recently, halving every historyHalfLife
+func (c *Corrector) historyScore(h *HistoryEntry, dist, cmdLen int, now time.Time) float64 {
+	similarity := 1 - float64(dist)/float64(max(cmdLen, len(h.Command)))
+
+	frequency := 0.0
+	if h.UsageCount > 0 {
+		frequency = math.Log1p(float64(h.UsageCount)) / math.Log1p(float64(c.historyMaxUsage))
+	}
+
+	recency := 0.0
+	if !h.LastUsed.IsZero() {
+		age := max(now.Sub(h.LastUsed), 0)
+		recency = math.Exp2(-float64(age) / float64(historyHalfLife))
+	}
+
+	return similarity*historySimilarityWeight + frequency*historyFrequencyWeight + recency*historyRecencyWeight
+}
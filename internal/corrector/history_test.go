@@ -0,0 +1,95 @@
+package corrector
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCheckHistoryPrefersFrequentRecent(t *testing.T) {
+	now := time.Now()
+	c := New()
+	c.SetHistoryEntries([]HistoryEntry{
+		{Command: "deploy.sh --prod-e", UsageCount: 1, LastUsed: now.AddDate(0, -6, 0)},
+		{Command: "deploy.sh --prod-us", UsageCount: 200, LastUsed: now.Add(-time.Hour)},
+	})
+
+	fix := c.checkHistory("deploy.sh --prod-eu")
+	if fix == nil || fix.Corrected != "deploy.sh --prod-us" {
+		t.Fatalf("checkHistory() = %+v, want the command run daily over the closer one run once", fix)
+	}
+	if fix.Explanation != "Similar to a past command: 'deploy.sh --prod-us' (run 200 times)" {
+		t.Errorf("Explanation = %q", fix.Explanation)
+	}
+
+	// The same distance scores lower once the command is rare and stale
+	stale := New()
+	stale.SetHistoryEntries([]HistoryEntry{
+		{Command: "deploy.sh --prod-us", UsageCount: 1, LastUsed: now.AddDate(-1, 0, 0)},
+		{Command: "make build", UsageCount: 200, LastUsed: now},
+	})
+	old := stale.checkHistory("deploy.sh --prod-eu")
+	if old == nil || old.Confidence >= fix.Confidence {
+		t.Errorf("stale checkHistory() = %+v, want confidence below %.2f", old, fix.Confidence)
+	}
+}
+
+func TestCheckHistoryPrefersRecentOnTie(t *testing.T) {
+	now := time.Now()
+	c := New()
+	c.SetHistoryEntries([]HistoryEntry{
+		{Command: "kubectl get pods", UsageCount: 3, LastUsed: now.AddDate(-2, 0, 0)},
+		{Command: "kubectl get node", UsageCount: 3, LastUsed: now.Add(-time.Minute)},
+	})
+	if fix := c.checkHistory("kubectl get pode"); fix == nil || fix.Corrected != "kubectl get node" {
+		t.Errorf("checkHistory() = %+v, want the equally close command run a minute ago", fix)
+	}
+
+	// Scores too close to tell apart go to the later command
+	tied := New()
+	tied.SetHistoryEntries([]HistoryEntry{
+		{Command: "kubectl get pods", UsageCount: 3, LastUsed: now.AddDate(-2, 0, 0)},
+		{Command: "kubectl get node", UsageCount: 3, LastUsed: now.AddDate(-2, 0, 0).Add(time.Second)},
+	})
+	if fix := tied.checkHistory("kubectl get pode"); fix == nil || fix.Corrected != "kubectl get node" {
+		t.Errorf("tied checkHistory() = %+v, want the command run last", fix)
+	}
+}
+
+func TestCheckHistoryExactMatch(t *testing.T) {
+	c := New()
+	c.SetHistoryEntries([]HistoryEntry{
+		{Command: "make deploy-stage", UsageCount: 50, LastUsed: time.Now()},
+		{Command: "make deploy-staging", UsageCount: 1},
+	})
+	if fix := c.checkHistory("make deploy-staging"); fix != nil {
+		t.Errorf("checkHistory() of a past command = %+v, want nil", fix)
+	}
+}
+
+func TestCheckHistoryThreshold(t *testing.T) {
+	c := New()
+	c.SetHistoryCommands([]string{"make deploy-staging"})
+
+	fix := c.checkHistory("make deploy-stagign")
+	if fix == nil || fix.Corrected != "make deploy-staging" {
+		t.Fatalf("checkHistory() = %+v, want make deploy-staging", fix)
+	}
+
+	c.SetHistoryThreshold(0.95)
+	if fix := c.checkHistory("make deploy-stagign"); fix != nil {
+		t.Errorf("checkHistory() above the threshold = %+v, want nil", fix)
+	}
+}
+
+func TestSetUseHistory(t *testing.T) {
+	c := New()
+	c.SetHistoryCommands([]string{"make deploy-staging"})
+	if fix, _ := c.Correct("make deploy-stagign"); fix == nil || fix.Corrected != "make deploy-staging" {
+		t.Fatalf("Correct() = %+v, want make deploy-staging from history", fix)
+	}
+
+	c.SetUseHistory(false)
+	if fix, _ := c.Correct("make deploy-stagign"); fix != nil {
+		t.Errorf("Correct() with history off = %+v, want nil", fix)
+	}
+}
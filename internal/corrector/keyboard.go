@@ -0,0 +1,101 @@
+package corrector
+
+// adjacentKeyCost is the cost of substituting a key for one next to it on a
+// QWERTY keyboard; any other substitution costs 1.
+const adjacentKeyCost = 0.5
+
+// qwertyRows lays out the keys; each row is offset half a key from the one
+// above, so a key touches two keys in the rows above and below it.
+var qwertyRows = []string{
+	"1234567890-=",
+	"qwertyuiop[]",
+	"asdfghjkl;'",
+	"zxcvbnm,./",
+}
+
+// adjacentKeys[a][b] reports whether keys a and b touch, built once from
+// qwertyRows
+var adjacentKeys = buildKeyAdjacency()
+
+func buildKeyAdjacency() *[128][128]bool {
+	var adj [128][128]bool
+	link := func(a, b byte) {
+		adj[a][b] = true
+		adj[b][a] = true
+	}
+	for r, row := range qwertyRows {
+		for i := 0; i < len(row); i++ {
+			if i+1 < len(row) {
+				link(row[i], row[i+1])
+			}
+			if r+1 < len(qwertyRows) {
+				// The row below is shifted right: key i sits above keys i-1 and i
+				below := qwertyRows[r+1]
+				for _, j := range []int{i - 1, i} {
+					if j >= 0 && j < len(below) {
+						link(row[i], below[j])
+					}
+				}
+			}
+		}
+	}
+	return &adj
+}
+
+// keyboardDistance is the optimal string alignment distance between a and b
+// where the fat-finger slips cost adjacentKeyCost: hitting a key next to the
+// right one ("dpcker") and pressing a key twice ("lss"). Other insertions,
+// deletions, substitutions and transpositions cost 1.
+func keyboardDistance(a, b string) float64 {
+	if a == b {
+		return 0
+	}
+
+	// Three rolling rows of the DP table; small words stay on the stack
+	var buf [3 * 32]float64
+	n := len(b) + 1
+	var rows []float64
+	if 3*n <= len(buf) {
+		rows = buf[:3*n]
+	} else {
+		rows = make([]float64, 3*n)
+	}
+	prev2, prev, cur := rows[:n], rows[n:2*n], rows[2*n:]
+	for j := 1; j < n; j++ {
+		prev[j] = prev[j-1] + indelCost(b, j-1)
+	}
+
+	for i := 1; i <= len(a); i++ {
+		cur[0] = prev[0] + indelCost(a, i-1)
+		for j := 1; j <= len(b); j++ {
+			cost := substitutionCost(a[i-1], b[j-1])
+			d := min(prev[j]+indelCost(a, i-1), cur[j-1]+indelCost(b, j-1), prev[j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d = min(d, prev2[j-2]+1)
+			}
+			cur[j] = d
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return prev[len(b)]
+}
+
+// indelCost is the cost of inserting or deleting s[i], which is cheap when it
+// repeats the key before it
+func indelCost(s string, i int) float64 {
+	if i > 0 && s[i] == s[i-1] {
+		return adjacentKeyCost
+	}
+	return 1
+}
+
+func substitutionCost(a, b byte) float64 {
+	switch {
+	case a == b:
+		return 0
+	case a < 128 && b < 128 && adjacentKeys[a][b]:
+		return adjacentKeyCost
+	default:
+		return 1
+	}
+}
@@ -0,0 +1,66 @@
+package corrector
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestKeyboardDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"docker", "docker", 0},
+		{"dpcker", "docker", 0.5}, // p is next to o
+		{"dxcker", "docker", 1},
+		{"gti", "git", 1},
+		{"lss", "ls", 0.5}, // s pressed twice
+		{"lsa", "ls", 1},
+		{"", "ls", 2},
+		{"stauts", "status", 1},
+		{"qa", "aq", 1},
+		{"statsu", "status", 1},
+		{strings.Repeat("k", 40), strings.Repeat("l", 40), 20},
+	}
+	for _, tt := range tests {
+		if got := keyboardDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("keyboardDistance(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+		if got := keyboardDistance(tt.b, tt.a); got != tt.want {
+			t.Errorf("keyboardDistance(%q, %q) = %v, want %v", tt.b, tt.a, got, tt.want)
+		}
+	}
+}
+
+func TestBestMatchKeyboardAware(t *testing.T) {
+	corpus := NewCorpus([]string{"decker", "docker"})
+
+	// Both are one edit away, so plain distance keeps the first
+	c := New()
+	if got, _ := c.bestMatch("dpcker", corpus, 2); got != "decker" {
+		t.Errorf("bestMatch() = %q, want decker", got)
+	}
+
+	c.SetKeyboardAware(true)
+	got, dist := c.bestMatch("dpcker", corpus, 2)
+	if got != "docker" || dist != 0.5 {
+		t.Errorf("keyboard-aware bestMatch() = %q, %v, want docker, 0.5", got, dist)
+	}
+}
+
+func BenchmarkBestMatch(b *testing.B) {
+	for _, keyboardAware := range []bool{false, true} {
+		name := "plain"
+		if keyboardAware {
+			name = "keyboard"
+		}
+		b.Run(name, func(b *testing.B) {
+			c := New()
+			c.SetKeyboardAware(keyboardAware)
+			for b.Loop() {
+				c.bestMatch("dpcker", rootCorpus, 2)
+				c.bestMatch("stauts", globalCorpus, 2)
+			}
+		})
+	}
+}
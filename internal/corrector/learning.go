@@ -0,0 +1,42 @@
+package corrector
+
+const (
+	// minRejections is how many times a correction must be turned down
+	// before it can be suppressed
+	minRejections = 3
+
+	// suppressBelow is the acceptance rate under which a correction that
+	// was turned down often enough is no longer suggested
+	suppressBelow = 0.25
+)
+
+// FeedbackFunc returns how often the user accepted and rejected a correction
+// from original to corrected
+type FeedbackFunc func(original, corrected string) (accepted, rejected int)
+
+// SetFeedback supplies the accepted and rejected counts of past corrections.
+// A correction's confidence rises with its acceptance rate and falls as it is
+// rejected; one rejected most of the time is no longer suggested.
+func (c *Corrector) SetFeedback(feedback FeedbackFunc) {
+	c.feedback = feedback
+}
+
+// learn adjusts the confidence of a correction by how often the same
+// correction was accepted before, or drops it when it is usually rejected
+func (c *Corrector) learn(fix *Correction) *Correction {
+	if c.feedback == nil {
+		return fix
+	}
+	accepted, rejected := c.feedback(fix.Original, fix.Corrected)
+	if accepted+rejected == 0 {
+		return fix
+	}
+
+	// Laplace smoothing keeps a single answer from swinging the rate to 0 or 1
+	rate := float64(accepted+1) / float64(accepted+rejected+2)
+	if rejected >= minRejections && rate < suppressBelow {
+		return nil
+	}
+	fix.Confidence = min(fix.Confidence*(0.5+rate), 1.0)
+	return fix
+}
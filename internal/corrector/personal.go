@@ -0,0 +1,60 @@
+package corrector
+
+import (
+	"fmt"
+	"strings"
+
+	"wut/internal/shellwords"
+)
+
+// SetPersonalCorrections supplies the corrections the user taught, as typo to
+// command. They are consulted before the corpus: a command that is a taught
+// typo, or has one as a word, gets the taught replacement with full
+// confidence and no further correction. Typos are matched without regard to
+// case.
+func (c *Corrector) SetPersonalCorrections(corrections map[string]string) {
+	c.personal = make(map[string]string, len(corrections))
+	for typo, command := range corrections {
+		c.personal[personalKey(typo)] = command
+	}
+}
+
+func personalKey(typo string) string {
+	return strings.ToLower(strings.Join(strings.Fields(typo), " "))
+}
+
+// correctPersonal applies the taught corrections to command: the whole
+// command first, then each of its words
+func (c *Corrector) correctPersonal(command string) *Correction {
+	if len(c.personal) == 0 {
+		return nil
+	}
+
+	trimmed := strings.TrimSpace(command)
+	if fixed, ok := c.personal[personalKey(trimmed)]; ok {
+		return &Correction{
+			Original:    command,
+			Corrected:   fixed,
+			Confidence:  1.0,
+			Explanation: fmt.Sprintf("Your correction: '%s'→'%s'", trimmed, fixed),
+		}
+	}
+
+	words := shellwords.Fields(trimmed)
+	var fixes []string
+	for i, word := range words {
+		if fixed, ok := c.personal[strings.ToLower(word)]; ok {
+			fixes = append(fixes, fmt.Sprintf("'%s'→'%s'", word, fixed))
+			words[i] = fixed
+		}
+	}
+	if len(fixes) == 0 {
+		return nil
+	}
+	return &Correction{
+		Original:    command,
+		Corrected:   strings.Join(words, " "),
+		Confidence:  1.0,
+		Explanation: "Your correction: " + strings.Join(fixes, ", "),
+	}
+}
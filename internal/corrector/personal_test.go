@@ -0,0 +1,38 @@
+package corrector
+
+import "testing"
+
+func TestPersonalCorrections(t *testing.T) {
+	c := New()
+	c.SetPersonalCorrections(map[string]string{
+		"gs":    "git status -sb",
+		"dk":    "docker",
+		"gti":   "got", // wins over the corpus's git
+		"k  gp": "kubectl get pods",
+	})
+
+	tests := []struct {
+		command string
+		want    string
+	}{
+		{"gs", "git status -sb"},
+		{"GS", "git status -sb"},
+		{"dk ps -a", "docker ps -a"},
+		{"gti status", "got status"},
+		{"k gp", "kubectl get pods"},
+	}
+	for _, tt := range tests {
+		fix, err := c.Correct(tt.command)
+		if err != nil {
+			t.Fatalf("Correct(%q) error = %v", tt.command, err)
+		}
+		if fix == nil || fix.Corrected != tt.want || fix.Confidence != 1 {
+			t.Errorf("Correct(%q) = %+v, want %q with full confidence", tt.command, fix, tt.want)
+		}
+	}
+
+	// Dangerous commands are still flagged first
+	if fix, _ := c.Correct("rm -rf /"); fix == nil || !fix.IsDangerous {
+		t.Errorf("Correct(rm -rf /) = %+v, want a danger warning", fix)
+	}
+}
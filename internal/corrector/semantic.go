@@ -38,10 +38,14 @@ type IntentMatch struct {
 	Intent     Intent
 	Score      float64
 	Confidence float64
+	// Command is Intent.Command with the placeholders the query has values
+	// for filled in
+	Command string
 }
 
-// semanticIntents is the global intent database.
-// Add new entries here to extend semantic coverage.
+// semanticIntents is the built-in intent database.
+// Add new entries here to extend semantic coverage; RegisterIntents adds
+// more at run time.
 var semanticIntents = []Intent{
 	// ── Docker ─────────────────────────────────────────────────────────────
 	{
@@ -93,6 +97,13 @@ var semanticIntents = []Intent{
 		Description: "Stream logs of a Docker container",
 		Category:    "docker",
 	},
+	{
+		Keywords:    []string{"logs", "container", "since"},
+		Phrases:     []string{"logs since", "recent container logs", "logs from the last"},
+		Command:     "docker logs --since <duration> <container>",
+		Description: "Show container logs from a recent time window",
+		Category:    "docker",
+	},
 	{
 		Keywords:    []string{"enter", "shell", "container"},
 		Phrases:     []string{"enter container", "bash into container", "open shell container", "exec into container"},
@@ -116,7 +127,7 @@ var semanticIntents = []Intent{
 	},
 	{
 		Keywords:    []string{"clean", "prune", "docker"},
-		Phrases:     []string{"clean docker", "prune docker", "free docker space"},
+		Phrases:     []string{"clean docker", "prune docker", "free docker space", "free up docker"},
 		Command:     "docker system prune -a",
 		Description: "Remove all unused Docker data (images, containers, volumes)",
 		Category:    "docker",
@@ -230,6 +241,13 @@ var semanticIntents = []Intent{
 		Description: "Get logs from a pod",
 		Category:    "kubernetes",
 	},
+	{
+		Keywords:    []string{"forward", "port", "pod"},
+		Phrases:     []string{"forward port", "port forward", "port-forward"},
+		Command:     "kubectl port-forward <pod> <ports>",
+		Description: "Forward a local port to a port of a pod",
+		Category:    "kubernetes",
+	},
 	{
 		Keywords:    []string{"exec", "shell", "pod"},
 		Phrases:     []string{"open shell in pod", "exec into pod", "bash into pod"},
@@ -392,6 +410,9 @@ var semanticIntents = []Intent{
 // Uses two passes:
 //  1. Keyword frequency scoring (weighted by IDF)
 //  2. Fuzzy phrase matching via sahilm/fuzzy
+//
+// Only intents that the semantic index reports as possible matches are
+// scored; every other intent would score zero anyway.
 func QuerySemantic(query string, limit int) []IntentMatch {
 	if limit <= 0 {
 		limit = 5
@@ -402,15 +423,66 @@ func QuerySemantic(query string, limit int) []IntentMatch {
 		return nil
 	}
 
+	set := loadIntentSet()
+	candidates := set.index.candidates(query, queryTokens)
+	return set.rankIntents(query, queryTokens, candidates, limit)
+}
+
+// QuerySemanticInCategory is QuerySemantic restricted to the intents of one
+// category, for queries whose words would also match other tools. The
+// category is matched without regard to case; an empty one matches every
+// intent.
+func QuerySemanticInCategory(query, category string, limit int) []IntentMatch {
+	if category == "" {
+		return QuerySemantic(query, limit)
+	}
+	if limit <= 0 {
+		limit = 5
+	}
+
+	queryTokens := tokenize(query)
+	if len(queryTokens) == 0 {
+		return nil
+	}
+
+	set := loadIntentSet()
+	var candidates []int
+	for _, idx := range set.index.candidates(query, queryTokens) {
+		if strings.EqualFold(set.intents[idx].Category, category) {
+			candidates = append(candidates, idx)
+		}
+	}
+	return set.rankIntents(query, queryTokens, candidates, limit)
+}
+
+// IntentCategories returns the categories of the semantic intents in sorted
+// order
+func IntentCategories() []string {
+	seen := make(map[string]bool)
+	var categories []string
+	for _, intent := range loadIntentSet().intents {
+		if !seen[intent.Category] {
+			seen[intent.Category] = true
+			categories = append(categories, intent.Category)
+		}
+	}
+	sort.Strings(categories)
+	return categories
+}
+
+// rankIntents scores the intents at the given indexes of the set (in
+// ascending order) and returns the best matches.
+func (s *intentSet) rankIntents(query string, queryTokens []string, candidates []int, limit int) []IntentMatch {
 	// Build description strings for fuzzy matching
-	descriptions := make([]string, len(semanticIntents))
-	for i, intent := range semanticIntents {
-		descriptions[i] = intent.Description + " " + strings.Join(intent.Phrases, " ")
+	descriptions := make([]string, len(candidates))
+	for i, idx := range candidates {
+		descriptions[i] = intentSearchText(s.intents[idx])
 	}
 
-	scored := make([]IntentMatch, len(semanticIntents))
-	for i, intent := range semanticIntents {
-		score := keywordScore(queryTokens, intent)
+	scored := make([]IntentMatch, len(candidates))
+	for i, idx := range candidates {
+		intent := s.intents[idx]
+		score := s.keywordScore(queryTokens, intent)
 		scored[i] = IntentMatch{
 			Intent: intent,
 			Score:  score,
@@ -429,19 +501,21 @@ func QuerySemantic(query string, limit int) []IntentMatch {
 		scored[i].Score += fuzzyBonus[i]
 	}
 
-	// Sort by score descending
-	sort.Slice(scored, func(a, b int) bool {
+	// Sort by score descending; ties keep database order
+	sort.SliceStable(scored, func(a, b int) bool {
 		return scored[a].Score > scored[b].Score
 	})
 
 	// Filter out very low scores
 	var results []IntentMatch
+	entities := ExtractEntities(query)
 	for _, m := range scored {
 		if m.Score < 0.4 {
 			break
 		}
 		// Normalise to a 0–1 confidence
 		m.Confidence = math.Min(1.0, m.Score/3.0)
+		m.Command = FillEntities(m.Intent.Command, entities)
 		results = append(results, m)
 		if len(results) >= limit {
 			break
@@ -450,44 +524,81 @@ func QuerySemantic(query string, limit int) []IntentMatch {
 	return results
 }
 
-// keywordScore computes a simple keyword-overlap score between query tokens
-// and an intent using a weighted Jaccard-like formula.
-func keywordScore(queryTokens []string, intent Intent) float64 {
+// ambiguityRatio is how close the runner-up's score must come to the best
+// score for a query to be ambiguous
+const ambiguityRatio = 0.85
+
+// Ambiguous reports whether the two best matches score too close to tell
+// which one the user meant
+func Ambiguous(matches []IntentMatch) bool {
+	return len(matches) >= 2 && matches[1].Score >= matches[0].Score*ambiguityRatio
+}
+
+// intentSearchText is the text an intent is fuzzy-matched against
+func intentSearchText(intent Intent) string {
+	return intent.Description + " " + strings.Join(intent.Phrases, " ")
+}
+
+// keywordScore computes a keyword-overlap score between query tokens and an
+// intent. Keywords shared by many intents ("list", "all", "show") count for
+// less than specific ones, longer phrases count for more than short ones, and
+// an intent matched only through generic keywords is penalised so it cannot
+// shadow a more specific intent.
+func (s *intentSet) keywordScore(queryTokens []string, intent Intent) float64 {
+	weights := s.weights
 	score := 0.0
+	specific := false
 
-	// Exact keyword hits
+	// Exact keyword hits, weighted by specificity
 	for _, kw := range intent.Keywords {
+		w := weights[kw]
 		for _, qt := range queryTokens {
 			if qt == kw {
-				score += 1.0
+				score += w
+				if w >= genericKeywordWeight {
+					specific = true
+				}
 			} else if strings.Contains(qt, kw) || strings.Contains(kw, qt) {
-				score += 0.4
+				score += 0.4 * w
 			}
 		}
 	}
 
-	// Whole-phrase bonus (much stronger signal)
+	// Whole-phrase bonus (much stronger signal, growing with phrase length)
 	queryLower := strings.ToLower(strings.Join(queryTokens, " "))
 	for _, phrase := range intent.Phrases {
 		if strings.Contains(queryLower, strings.ToLower(phrase)) {
-			score += 2.5
+			score += 2.0 + 0.5*float64(len(strings.Fields(phrase)))
+			specific = true
 		}
 	}
 
 	// Synonym expansion (common query words → canonical keywords)
 	for _, qt := range queryTokens {
-		if expanded, ok := synonymMap[qt]; ok {
+		if expanded, ok := s.synonyms[qt]; ok {
 			for _, kw := range intent.Keywords {
 				if expanded == kw {
-					score += 0.7
+					score += 0.7 * weights[kw]
 				}
 			}
 		}
 	}
 
+	if !specific {
+		score *= genericOnlyPenalty
+	}
 	return score
 }
 
+const (
+	// genericKeywordWeight is the weight below which a keyword is considered
+	// generic
+	genericKeywordWeight = 1.0
+	// genericOnlyPenalty scales the score of intents matched by neither a
+	// phrase nor a specific keyword
+	genericOnlyPenalty = 0.5
+)
+
 // tokenize lowercases and splits a string into meaningful word tokens,
 // removing stop words that carry no semantic weight.
 func tokenize(s string) []string {
@@ -518,6 +629,7 @@ var stopWords = map[string]bool{
 }
 
 // synonymMap maps common query words to the canonical keywords used in intents.
+// RegisterSynonyms adds more at run time.
 var synonymMap = map[string]string{
 	// list/show synonyms
 	"display": "list",
@@ -0,0 +1,179 @@
+package corrector
+
+import (
+	"math/bits"
+	"strings"
+	"unicode"
+
+	"wut/internal/performance"
+)
+
+// ──────────────────────────────────────────────────────────────────────────────
+// Semantic candidate index
+//
+// QuerySemantic only needs to score intents that can get a non-zero score.
+// The index answers "which intents could match?" for each scoring rule:
+//   - keyword hits        → inverted index over keywords + keyword substrings
+//   - whole-phrase bonus  → phrase lookup over substrings of the query
+//   - fuzzy bonus         → per-rune bitsets (a fuzzy match needs every rune)
+//
+// Every intent outside the candidate set would score exactly zero, so results
+// are identical to scoring the whole database.
+// ──────────────────────────────────────────────────────────────────────────────
+
+// semanticIndex pre-filters the intents of an intentSet for a query
+type semanticIndex struct {
+	keywords     *performance.InvertedIndex // intent keywords, Data is the intent index
+	partials     map[string][]int           // proper substrings of keywords → intents
+	phrases      map[string][]int           // lowercased phrases → intents
+	maxPhraseLen int
+	runes        map[rune][]uint64 // folded rune → bitset of intents containing it
+	always       []int             // intents with an empty keyword match any query
+	synonyms     map[string]string // query words → the keywords they stand for
+	size         int
+}
+
+func newSemanticIndex(intents []Intent, synonyms map[string]string) *semanticIndex {
+	idx := &semanticIndex{
+		synonyms: synonyms,
+		keywords: performance.NewInvertedIndex(),
+		partials: make(map[string][]int),
+		phrases:  make(map[string][]int),
+		runes:    make(map[rune][]uint64),
+		size:     len(intents),
+	}
+	words := (len(intents) + 63) / 64
+
+	for i, intent := range intents {
+		idx.keywords.AddDocument(strings.Join(intent.Keywords, " "), i)
+
+		partials := make(map[string]bool)
+		for _, kw := range intent.Keywords {
+			if kw == "" {
+				idx.always = append(idx.always, i)
+				continue
+			}
+			for start := 0; start < len(kw); start++ {
+				for end := start + 1; end <= len(kw); end++ {
+					if start == 0 && end == len(kw) {
+						continue
+					}
+					partials[kw[start:end]] = true
+				}
+			}
+		}
+		for sub := range partials {
+			idx.partials[sub] = append(idx.partials[sub], i)
+		}
+
+		for _, phrase := range intent.Phrases {
+			phrase = strings.ToLower(phrase)
+			idx.phrases[phrase] = append(idx.phrases[phrase], i)
+			if len(phrase) > idx.maxPhraseLen {
+				idx.maxPhraseLen = len(phrase)
+			}
+		}
+
+		for _, r := range intentSearchText(intent) {
+			key := foldRune(r)
+			set, ok := idx.runes[key]
+			if !ok {
+				set = make([]uint64, words)
+				idx.runes[key] = set
+			}
+			set[i/64] |= 1 << (i % 64)
+		}
+	}
+
+	return idx
+}
+
+// candidates returns the indexes of intents that may score above zero for
+// the query, in ascending order.
+func (idx *semanticIndex) candidates(query string, queryTokens []string) []int {
+	words := (idx.size + 63) / 64
+	found := make([]uint64, words)
+	mark := func(i int) { found[i/64] |= 1 << (i % 64) }
+
+	for _, i := range idx.always {
+		mark(i)
+	}
+
+	// Keyword hits: qt == kw, qt contains kw, or a synonym of qt == kw
+	var terms []string
+	for _, qt := range queryTokens {
+		for start := 0; start < len(qt); start++ {
+			for end := start + 1; end <= len(qt); end++ {
+				terms = append(terms, qt[start:end])
+			}
+		}
+		if expanded, ok := idx.synonyms[qt]; ok {
+			terms = append(terms, expanded)
+		}
+
+		// kw contains qt
+		for _, i := range idx.partials[qt] {
+			mark(i)
+		}
+	}
+	if len(terms) > 0 {
+		for _, r := range idx.keywords.Search(strings.Join(terms, " "), idx.size) {
+			if i, ok := r.Data.(int); ok {
+				mark(i)
+			}
+		}
+	}
+
+	// Whole-phrase bonus: the phrase occurs somewhere in the query
+	queryLower := strings.ToLower(strings.Join(queryTokens, " "))
+	for start := 0; start < len(queryLower); start++ {
+		for end := start + 1; end <= len(queryLower) && end-start <= idx.maxPhraseLen; end++ {
+			for _, i := range idx.phrases[queryLower[start:end]] {
+				mark(i)
+			}
+		}
+	}
+
+	// Fuzzy bonus: every rune of the query must appear in the search text
+	fuzzySet := make([]uint64, words)
+	for w := range fuzzySet {
+		fuzzySet[w] = ^uint64(0)
+	}
+	for _, r := range query {
+		set, ok := idx.runes[foldRune(r)]
+		if !ok {
+			clear(fuzzySet)
+			break
+		}
+		for w := range fuzzySet {
+			fuzzySet[w] &= set[w]
+		}
+	}
+	for w := range found {
+		found[w] |= fuzzySet[w]
+	}
+
+	var result []int
+	for w, word := range found {
+		for word != 0 {
+			i := w*64 + bits.TrailingZeros64(word)
+			if i < idx.size {
+				result = append(result, i)
+			}
+			word &= word - 1
+		}
+	}
+	return result
+}
+
+// foldRune maps a rune to the smallest rune it is case-fold equivalent to,
+// matching the equalFold comparison used by sahilm/fuzzy.
+func foldRune(r rune) rune {
+	smallest := r
+	for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+		if f < smallest {
+			smallest = f
+		}
+	}
+	return smallest
+}
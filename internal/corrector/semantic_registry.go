@@ -0,0 +1,144 @@
+package corrector
+
+import (
+	"maps"
+	"math"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+// ──────────────────────────────────────────────────────────────────────────────
+// Intent registry
+//
+// Queries read the intents, synonyms and everything derived from them through
+// an intentSet, a snapshot that is never changed once built. Registering
+// intents or synonyms builds a new set and swaps it in, so a query keeps
+// working on the set it loaded when it started while a reload happens, and
+// no lock is taken on the query path.
+// ──────────────────────────────────────────────────────────────────────────────
+
+// intentSet is an immutable snapshot of the intent database
+type intentSet struct {
+	intents  []Intent
+	synonyms map[string]string
+	// weights, index and keywords are derived from intents and synonyms
+	weights  map[string]float64
+	index    *semanticIndex
+	keywords *Corpus
+}
+
+var (
+	// registerMu serializes the writers that replace the current set
+	registerMu sync.Mutex
+	currentSet atomic.Pointer[intentSet]
+)
+
+// loadIntentSet returns the current intent set, building the built-in one
+// on first use
+func loadIntentSet() *intentSet {
+	if set := currentSet.Load(); set != nil {
+		return set
+	}
+	registerMu.Lock()
+	defer registerMu.Unlock()
+	if set := currentSet.Load(); set != nil {
+		return set
+	}
+	set := newIntentSet(semanticIntents, synonymMap)
+	currentSet.Store(set)
+	return set
+}
+
+// RegisterIntents adds intents to the semantic intent database. It is safe
+// to call while other goroutines query; queries already running finish on the
+// intents they started with.
+func RegisterIntents(intents ...Intent) {
+	if len(intents) == 0 {
+		return
+	}
+	registerMu.Lock()
+	defer registerMu.Unlock()
+
+	old := currentSet.Load()
+	if old == nil {
+		old = newIntentSet(semanticIntents, synonymMap)
+	}
+	merged := slices.Clip(slices.Clone(old.intents))
+	for _, intent := range intents {
+		// The caller keeps its slices; the set must not see later changes
+		intent.Keywords = slices.Clone(intent.Keywords)
+		intent.Phrases = slices.Clone(intent.Phrases)
+		merged = append(merged, intent)
+	}
+	currentSet.Store(newIntentSet(merged, old.synonyms))
+}
+
+// RegisterSynonyms maps more query words to intent keywords, replacing the
+// keyword a word already maps to. Like RegisterIntents it is safe to call
+// while other goroutines query.
+func RegisterSynonyms(synonyms map[string]string) {
+	if len(synonyms) == 0 {
+		return
+	}
+	registerMu.Lock()
+	defer registerMu.Unlock()
+
+	old := currentSet.Load()
+	if old == nil {
+		old = newIntentSet(semanticIntents, synonymMap)
+	}
+	merged := maps.Clone(old.synonyms)
+	maps.Copy(merged, synonyms)
+	currentSet.Store(newIntentSet(old.intents, merged))
+}
+
+// newIntentSet builds a set and the data derived from it
+func newIntentSet(intents []Intent, synonyms map[string]string) *intentSet {
+	return &intentSet{
+		intents:  intents,
+		synonyms: synonyms,
+		weights:  newKeywordWeights(intents),
+		index:    newSemanticIndex(intents, synonyms),
+		keywords: newKeywordCorpus(intents),
+	}
+}
+
+// newKeywordWeights maps every intent keyword to an IDF-style weight: 1.5
+// for a keyword used by a single intent, falling towards 0.5 for one used by
+// all.
+func newKeywordWeights(intents []Intent) map[string]float64 {
+	counts := make(map[string]int)
+	for _, intent := range intents {
+		seen := make(map[string]bool)
+		for _, kw := range intent.Keywords {
+			if !seen[kw] {
+				seen[kw] = true
+				counts[kw]++
+			}
+		}
+	}
+
+	n := float64(len(intents))
+	weights := make(map[string]float64, len(counts))
+	for kw, count := range counts {
+		weights[kw] = 0.5 + math.Log(n/float64(count))/math.Log(n)
+	}
+	return weights
+}
+
+// newKeywordCorpus indexes every intent keyword once, in intent order, for
+// correcting the words of a semantic query
+func newKeywordCorpus(intents []Intent) *Corpus {
+	seen := make(map[string]bool)
+	var keywords []string
+	for _, intent := range intents {
+		for _, kw := range intent.Keywords {
+			if !seen[kw] {
+				seen[kw] = true
+				keywords = append(keywords, kw)
+			}
+		}
+	}
+	return NewCorpus(keywords)
+}
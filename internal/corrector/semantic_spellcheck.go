@@ -0,0 +1,57 @@
+package corrector
+
+import (
+	"strings"
+	"unicode"
+)
+
+// SpellcheckQuery corrects the misspelled words of a natural-language query
+// to the closest intent keyword, so "lst runing containrs" reads "list
+// running containers" before QuerySemantic scores it. Words that already mean
+// something to the intent engine are kept, and so are words with digits or
+// punctuation, which are more likely names, paths or ports than typos.
+func (c *Corrector) SpellcheckQuery(query string) string {
+	set := loadIntentSet()
+	words := strings.Fields(query)
+	changed := false
+	for i, word := range words {
+		w := strings.ToLower(strings.Trim(word, ".,!?;:\"'()"))
+		if !set.spellcheckable(w) || set.keywords.Contains(w) {
+			continue
+		}
+		if best, _ := c.bestMatch(w, set.keywords, semanticMaxDist(w)); best != "" {
+			words[i] = best
+			changed = true
+		}
+	}
+	if !changed {
+		return query
+	}
+	return strings.Join(words, " ")
+}
+
+// spellcheckable reports whether a query word may be a misspelled keyword
+func (s *intentSet) spellcheckable(w string) bool {
+	if len(w) < 3 || stopWords[w] {
+		return false
+	}
+	if _, ok := s.synonyms[w]; ok {
+		return false
+	}
+	for _, r := range w {
+		if !unicode.IsLetter(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// semanticMaxDist is how many edits a query word may be from a keyword.
+// Prose words are further apart than command names, so this allows fewer
+// than maxDistForLen.
+func semanticMaxDist(w string) int {
+	if len(w) <= 5 {
+		return 1
+	}
+	return 2
+}
@@ -0,0 +1,265 @@
+package corrector
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestQuerySemanticMatchesBruteForce(t *testing.T) {
+	queries := []string{
+		"list running containers",
+		"show me all the containers please",
+		"undo last commit",
+		"how do I check disk space",
+		"kill process on port 8080",
+		"ram usage",
+		"compress folder",
+		"lst contaners",
+		"DELETE Branch",
+		"what's listening on ports?",
+		"xyzzy",
+		"ſtop container",
+		"tidy go modules",
+	}
+	for _, intent := range semanticIntents {
+		queries = append(queries, intent.Description, strings.Join(intent.Keywords, " "))
+		queries = append(queries, intent.Phrases...)
+	}
+
+	all := make([]int, len(semanticIntents))
+	for i := range all {
+		all[i] = i
+	}
+
+	for _, query := range queries {
+		tokens := tokenize(query)
+		if len(tokens) == 0 {
+			continue
+		}
+		got := QuerySemantic(query, 10)
+		want := loadIntentSet().rankIntents(query, tokens, all, 10)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("QuerySemantic(%q) differs from brute force:\n got: %v\nwant: %v", query, commands(got), commands(want))
+		}
+	}
+}
+
+func TestSemanticIndexPrefilters(t *testing.T) {
+	query := "undo last commit"
+	candidates := loadIntentSet().index.candidates(query, tokenize(query))
+	if len(candidates) == 0 || len(candidates) >= len(semanticIntents) {
+		t.Errorf("candidates = %d of %d intents, want a strict subset", len(candidates), len(semanticIntents))
+	}
+}
+
+func commands(matches []IntentMatch) []string {
+	out := make([]string, len(matches))
+	for i, m := range matches {
+		out[i] = m.Intent.Command
+	}
+	return out
+}
+
+// minSemanticAccuracy is the share of labeled queries whose expected command
+// must rank first
+const minSemanticAccuracy = 0.9
+
+// TestQuerySemanticAccuracy ranks every query in testdata/semantic_queries.tsv
+// and reports which commands it was confused with
+func TestQuerySemanticAccuracy(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "semantic_queries.tsv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	total, correct := 0, 0
+	confusion := map[string]map[string]int{}
+	for _, line := range strings.Split(string(data), "\n") {
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		query, want, ok := strings.Cut(line, "\t")
+		if !ok {
+			t.Fatalf("malformed line %q", line)
+		}
+		total++
+
+		got := "(none)"
+		if results := QuerySemantic(query, 1); len(results) > 0 {
+			got = results[0].Intent.Command
+		}
+		if got == want {
+			correct++
+			continue
+		}
+		if confusion[want] == nil {
+			confusion[want] = map[string]int{}
+		}
+		confusion[want][got]++
+		t.Logf("%q: got %q, want %q", query, got, want)
+	}
+
+	for want, row := range confusion {
+		for got, n := range row {
+			t.Logf("confused %q with %q %d time(s)", want, got, n)
+		}
+	}
+
+	accuracy := float64(correct) / float64(total)
+	t.Logf("accuracy %d/%d = %.2f", correct, total, accuracy)
+	if accuracy < minSemanticAccuracy {
+		t.Errorf("accuracy %.2f is below %.2f", accuracy, minSemanticAccuracy)
+	}
+}
+
+func TestAmbiguous(t *testing.T) {
+	if results := QuerySemantic("undo last commit", 5); Ambiguous(results) {
+		t.Errorf("undo last commit should not be ambiguous: %v", commands(results))
+	}
+	if results := QuerySemantic("remove the feature branch", 5); !Ambiguous(results) {
+		t.Errorf("remove the feature branch should be ambiguous: %v", commands(results))
+	}
+}
+
+func TestQuerySemanticInCategory(t *testing.T) {
+	// "show logs" reads as kubectl logs first, but git and docker have logs too
+	query := "show logs"
+	if got := QuerySemantic(query, 1); len(got) == 0 || got[0].Intent.Category != "kubernetes" {
+		t.Fatalf("QuerySemantic(%q) = %v, want a kubernetes intent first", query, commands(got))
+	}
+
+	for _, category := range []string{"docker", "Git"} {
+		got := QuerySemanticInCategory(query, category, 5)
+		if len(got) == 0 {
+			t.Errorf("QuerySemanticInCategory(%q, %q) found nothing", query, category)
+		}
+		for _, m := range got {
+			if !strings.EqualFold(m.Intent.Category, category) {
+				t.Errorf("QuerySemanticInCategory(%q, %q) returned %q from %s", query, category, m.Command, m.Intent.Category)
+			}
+		}
+	}
+
+	if got := QuerySemanticInCategory(query, "npm", 5); len(got) != 0 {
+		t.Errorf("QuerySemanticInCategory(%q, npm) = %v, want nothing", query, commands(got))
+	}
+	if got, want := QuerySemanticInCategory(query, "", 3), QuerySemantic(query, 3); !reflect.DeepEqual(got, want) {
+		t.Errorf("an empty category = %v, want QuerySemantic's %v", commands(got), commands(want))
+	}
+}
+
+func TestIntentCategories(t *testing.T) {
+	categories := IntentCategories()
+	if !slices.IsSorted(categories) || !slices.Contains(categories, "docker") || !slices.Contains(categories, "git") {
+		t.Errorf("IntentCategories() = %v", categories)
+	}
+	if len(slices.Compact(slices.Clone(categories))) != len(categories) {
+		t.Errorf("IntentCategories() has duplicates: %v", categories)
+	}
+}
+
+func TestDescribeCommand(t *testing.T) {
+	tests := []struct {
+		command string
+		want    string
+		ok      bool
+	}{
+		{"docker system prune -a", "Remove all unused Docker data (images, containers, volumes)", true},
+		{"  git  stash  pop ", "Restore the latest stashed changes", true},
+		// Placeholders stand for arguments, quoted or not
+		{"kubectl logs api-7d9f", "Get logs from a pod", true},
+		{"git log -S 'TODO'", "Search commit history for changes introducing a specific string", true},
+		// Flags in another order still match
+		{"git log --graph --decorate --oneline", "Show a condensed, graphical commit log", true},
+		// No intent: the base command's description
+		{"docker system prune", "Manage containers, images, networks and volumes", true},
+		{"rsync -av src/ dst/", "Fast, incremental file copying, locally or over SSH", true},
+		{"frobnicate --all", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := DescribeCommand(tt.command)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("DescribeCommand(%q) = %q, %v, want %q, %v", tt.command, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestSpellcheckQuery(t *testing.T) {
+	c := New()
+	query := "lst runing containrs"
+	if got := c.SpellcheckQuery(query); got != "list running containers" {
+		t.Errorf("SpellcheckQuery(%q) = %q", query, got)
+	}
+	if matches := QuerySemantic(c.SpellcheckQuery(query), 1); len(matches) == 0 || matches[0].Command != "docker ps" {
+		t.Errorf("QuerySemantic(corrected %q) = %+v, want docker ps", query, matches)
+	}
+
+	// Names, ports, paths and words the engine knows are left alone
+	for _, kept := range []string{"kill process on port 8080", "show logs for api-7d9f", "compress ./src", "undo last commit"} {
+		if got := c.SpellcheckQuery(kept); got != kept {
+			t.Errorf("SpellcheckQuery(%q) = %q, want it unchanged", kept, got)
+		}
+	}
+}
+
+// TestRegisterIntentsWhileQuerying is meant for go test -race: queries run
+// while intents and synonyms are registered, and see them once registered
+func TestRegisterIntentsWhileQuerying(t *testing.T) {
+	original := loadIntentSet()
+	t.Cleanup(func() { currentSet.Store(original) })
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Go(func() {
+			c := New()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				QuerySemantic("list running containers", 3)
+				QuerySemanticInCategory("ship to staging", "make", 3)
+				DescribeCommand("docker ps")
+				IntentCategories()
+				c.SpellcheckQuery("lst runing containrs")
+			}
+		})
+	}
+
+	for i := range 20 {
+		RegisterIntents(Intent{
+			Keywords:    []string{"deploy", fmt.Sprintf("stage%d", i)},
+			Phrases:     []string{fmt.Sprintf("deploy stage%d", i)},
+			Command:     fmt.Sprintf("make deploy-%d", i),
+			Description: fmt.Sprintf("Deploy to stage %d", i),
+			Category:    "make",
+		})
+		RegisterSynonyms(map[string]string{"ship": "deploy"})
+	}
+	close(stop)
+	wg.Wait()
+
+	matches := QuerySemantic("ship stage7", 1)
+	if len(matches) == 0 || matches[0].Command != "make deploy-7" {
+		t.Errorf("QuerySemantic(ship stage7) = %v, want the registered intent", commands(matches))
+	}
+	if desc, ok := DescribeCommand("make deploy-7"); !ok || desc != "Deploy to stage 7" {
+		t.Errorf("DescribeCommand(make deploy-7) = %q, %v", desc, ok)
+	}
+	if !slices.Contains(IntentCategories(), "make") {
+		t.Errorf("IntentCategories() = %v, want the registered category", IntentCategories())
+	}
+	// The built-in intents are still there
+	if matches := QuerySemantic("list running containers", 1); len(matches) == 0 || matches[0].Command != "docker ps" {
+		t.Errorf("QuerySemantic(list running containers) = %v", commands(matches))
+	}
+}
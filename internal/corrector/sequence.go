@@ -0,0 +1,56 @@
+package corrector
+
+import (
+	"strings"
+
+	"wut/internal/shellwords"
+)
+
+// SequenceStage is one command of a chain joined by &&, || or ;
+type SequenceStage struct {
+	Operator string // how it joins the previous stage; empty for the first
+	Command  string
+}
+
+// SplitSequence splits a command line at the &&, || and ; that separate its
+// commands. Operators inside quotes, after a backslash or inside $(...) and
+// backticks are part of a command. Pipes and & stay inside their stage.
+func SplitSequence(command string) []SequenceStage {
+	var stages []SequenceStage
+	operator := ""
+	start := 0
+	add := func(end int) {
+		if stage := strings.TrimSpace(command[start:end]); stage != "" {
+			stages = append(stages, SequenceStage{Operator: operator, Command: stage})
+		}
+	}
+
+	for _, tok := range shellwords.Lex(command) {
+		if tok.Kind != shellwords.Control || (tok.Text != "&&" && tok.Text != "||" && tok.Text != ";") {
+			continue
+		}
+		add(tok.Start)
+		if len(stages) > 0 {
+			operator = tok.Text
+		}
+		start = tok.End
+	}
+	add(len(command))
+	return stages
+}
+
+// JoinSequence rebuilds a command line from its stages
+func JoinSequence(stages []SequenceStage) string {
+	var b strings.Builder
+	for i, stage := range stages {
+		if i > 0 {
+			if stage.Operator == ";" {
+				b.WriteString("; ")
+			} else {
+				b.WriteString(" " + stage.Operator + " ")
+			}
+		}
+		b.WriteString(stage.Command)
+	}
+	return b.String()
+}
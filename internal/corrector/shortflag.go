@@ -13,6 +13,7 @@ package corrector
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 )
 
@@ -275,3 +276,67 @@ func ExplainShortFlagCluster(root, flagCluster string) string {
 	}
 	return strings.Join(parts, "  ")
 }
+
+// DescribeFlag returns the meaning of a single flag ("-i" or "--interactive")
+// for the given root command, preferring the flagDescriptions table. Long
+// flags that are only present in the flag corpus are reported as recognized
+// without a description. ok is false when the flag is unknown.
+func DescribeFlag(root, flag string) (string, bool) {
+	name, _, _ := strings.Cut(flag, "=")
+	if desc, ok := flagDescriptions[root][name]; ok {
+		return desc, true
+	}
+
+	knownMap := shortFlagMap[root]
+
+	if strings.HasPrefix(flag, "--") {
+		for _, info := range knownMap {
+			if info.LongOption == name {
+				return info.Description, true
+			}
+		}
+		if fs, ok := knownFlags[root]; ok {
+			for _, long := range fs.long {
+				if "--"+long == name {
+					return "recognized option", true
+				}
+			}
+		}
+		return "", false
+	}
+
+	key := strings.TrimPrefix(flag, "-")
+	if info, ok := knownMap[key]; ok {
+		return fmt.Sprintf("%s (%s)", info.Description, info.LongOption), true
+	}
+	return "", false
+}
+
+// ClosestFlag returns the known flag of root nearest to an unknown one, e.g.
+// --detach for --detatch. Long flags are matched against the flag corpus and
+// descriptions; single-dash long options like find's -nmae only against the
+// descriptions. ok is false when nothing is close enough.
+func ClosestFlag(root, flag string) (string, bool) {
+	name, _, _ := strings.Cut(strings.ToLower(flag), "=")
+	long := strings.HasPrefix(name, "--")
+	if !long && len(name) <= 2 {
+		return "", false
+	}
+
+	var candidates []string
+	if long {
+		for _, l := range knownFlags[root].long {
+			candidates = append(candidates, "--"+l)
+		}
+	}
+	for f := range flagDescriptions[root] {
+		if strings.HasPrefix(f, "--") == long && len(f) > 2 {
+			candidates = append(candidates, f)
+		}
+	}
+	// Ties go to the first candidate, so keep the order stable
+	slices.Sort(candidates)
+
+	best, _ := New().bestMatch(name, NewCorpus(candidates), maxDistForLen(strings.TrimLeft(name, "-")))
+	return best, best != ""
+}
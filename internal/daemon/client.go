@@ -0,0 +1,39 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+)
+
+// ErrNotRunning is returned by Call when no daemon answers on the socket
+var ErrNotRunning = errors.New("daemon is not running")
+
+// Call sends req to the daemon listening on the unix socket at path and
+// returns its response. It gives up when ctx is done. A request the daemon
+// could not answer returns the daemon's error.
+func Call(ctx context.Context, path string, req Request) (*Response, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNotRunning, err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	req.Version = ProtocolVersion
+	if err := WriteMessage(conn, req); err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	var resp Response
+	if err := ReadMessage(conn, &resp); err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.Error != "" {
+		return nil, errors.New(resp.Error)
+	}
+	return &resp, nil
+}
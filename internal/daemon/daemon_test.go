@@ -0,0 +1,159 @@
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"wut/internal/config"
+	"wut/internal/smart"
+)
+
+func TestMessageRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	want := Request{Version: ProtocolVersion, Op: OpSuggest, Query: "list running containers", Dir: "/src", Limit: 3}
+	if err := WriteMessage(&buf, want); err != nil {
+		t.Fatal(err)
+	}
+	if size := binary.BigEndian.Uint32(buf.Bytes()); int(size) != buf.Len()-4 {
+		t.Errorf("length prefix = %d, want %d", size, buf.Len()-4)
+	}
+	var got Request
+	if err := ReadMessage(&buf, &got); err != nil || got != want {
+		t.Errorf("ReadMessage() = %+v, %v; want %+v", got, err, want)
+	}
+
+	var oversized bytes.Buffer
+	_ = binary.Write(&oversized, binary.BigEndian, uint32(maxMessageSize+1))
+	if err := ReadMessage(&oversized, &got); err == nil {
+		t.Error("ReadMessage() accepted a message over the size limit")
+	}
+	truncated := bytes.NewReader([]byte{0, 0, 0, 10, '{'})
+	if err := ReadMessage(truncated, &got); err == nil {
+		t.Error("ReadMessage() accepted a truncated message")
+	}
+}
+
+// startServer serves a daemon without storage or external sources on a
+// socket in a temporary directory
+func startServer(t *testing.T) (path string, done <-chan error) {
+	t.Helper()
+	dir, err := os.MkdirTemp("", "wutd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	path = filepath.Join(dir, "wut.sock")
+
+	listener, err := Listen(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine := smart.NewEngineWithConfig(nil, &config.Config{})
+	engine.SetExternalSources(nil, 0)
+	server := NewServer(engine, filepath.Join(dir, "missing.db"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() { errc <- server.Serve(ctx, listener) }()
+	t.Cleanup(cancel)
+	return path, errc
+}
+
+func TestServer(t *testing.T) {
+	path, done := startServer(t)
+	ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+	defer cancel()
+
+	if _, err := Listen(path); !errors.Is(err, ErrRunning) {
+		t.Errorf("Listen() on a served socket = %v, want ErrRunning", err)
+	}
+
+	resp, err := Call(ctx, path, Request{Op: OpSuggest, Query: "list running containers", Dir: t.TempDir(), Limit: 3})
+	if err != nil {
+		t.Fatalf("suggest error = %v", err)
+	}
+	if len(resp.Suggestions) == 0 || resp.Suggestions[0].Command != "docker ps" || len(resp.Suggestions) > 3 {
+		t.Errorf("suggest = %+v, want up to 3 with docker ps first", resp.Suggestions)
+	}
+	if resp.Context == nil || resp.Context.WorkingDir == "" {
+		t.Errorf("suggest context = %+v, want the client's directory analysed", resp.Context)
+	}
+
+	if _, err := Call(ctx, path, Request{Op: "reindex"}); err == nil {
+		t.Error("an unknown operation was answered")
+	}
+	if _, err := Call(ctx, path, Request{Op: OpSuggest, Query: "git"}); err == nil {
+		t.Error("a suggest request without a directory was answered")
+	}
+
+	status, err := Call(ctx, path, Request{Op: OpStop})
+	if err != nil || status.PID != os.Getpid() || status.Requests != 4 {
+		t.Fatalf("stop = %+v, %v; want this process after 4 requests", status, err)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Serve() after stop = %v", err)
+		}
+	case <-ctx.Done():
+		t.Fatal("Serve() did not return after stop")
+	}
+	if _, err := Call(ctx, path, Request{Op: OpPing}); !errors.Is(err, ErrNotRunning) {
+		t.Errorf("ping after stop = %v, want ErrNotRunning", err)
+	}
+}
+
+func TestServerRejectsOtherVersions(t *testing.T) {
+	path, _ := startServer(t)
+	ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+	defer cancel()
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if err := WriteMessage(conn, Request{Version: ProtocolVersion + 1, Op: OpPing}); err != nil {
+		t.Fatal(err)
+	}
+	var resp Response
+	if err := ReadMessage(conn, &resp); err != nil || resp.Error == "" {
+		t.Errorf("a request of another version = %+v, %v; want an error", resp, err)
+	}
+}
+
+func TestListenReplacesStaleSocket(t *testing.T) {
+	dir, err := os.MkdirTemp("", "wutd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "wut.sock")
+	if err := os.WriteFile(path, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	// The directory is closed to others before the socket is created
+	if err := os.Chmod(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	listener, err := Listen(path)
+	if err != nil {
+		t.Fatalf("Listen() over a stale socket = %v", err)
+	}
+	defer listener.Close()
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("socket mode = %v, %v; want 0600", info, err)
+	}
+	if info, err := os.Stat(dir); err != nil || info.Mode().Perm() != 0700 {
+		t.Errorf("socket directory mode = %v, %v; want 0700", info, err)
+	}
+}
@@ -0,0 +1,98 @@
+// Package daemon keeps a warmed suggestion engine in a long-lived process
+// and answers suggestion requests over a local unix socket
+package daemon
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/goccy/go-json"
+
+	appctx "wut/internal/context"
+	"wut/internal/smart"
+)
+
+// ProtocolVersion is sent with every request and response. A daemon answers
+// a request of another version with an error, so a client upgraded before
+// the daemon was restarted answers in-process instead.
+const ProtocolVersion = 1
+
+// maxMessageSize bounds a message, so a stray writer on the socket cannot
+// make the daemon allocate without limit
+const maxMessageSize = 4 << 20
+
+// Operations a Request asks for
+const (
+	// OpPing reports the daemon's status
+	OpPing = "ping"
+	// OpSuggest returns the smart suggestions for a query
+	OpSuggest = "suggest"
+	// OpStop makes the daemon exit once it has answered
+	OpStop = "stop"
+)
+
+// Request is a message from a client. One connection carries one request
+// and its response.
+type Request struct {
+	Version int    `json:"version"`
+	Op      string `json:"op"`
+	// Query, Limit and Category are the arguments of wut suggest; Dir is
+	// the client's working directory, whose context ranks the suggestions
+	Query    string `json:"query,omitempty"`
+	Dir      string `json:"dir,omitempty"`
+	Limit    int    `json:"limit,omitempty"`
+	Category string `json:"category,omitempty"`
+}
+
+// Response is the daemon's answer to a Request
+type Response struct {
+	Version int `json:"version"`
+	// Error is set when the request failed; the other fields are then empty
+	Error       string             `json:"error,omitempty"`
+	Suggestions []smart.Suggestion `json:"suggestions,omitempty"`
+	Context     *appctx.Context    `json:"context,omitempty"`
+
+	// PID, StartedAt and Requests describe the daemon, for OpPing
+	PID       int       `json:"pid,omitempty"`
+	StartedAt time.Time `json:"started_at"`
+	Requests  int64     `json:"requests,omitempty"`
+}
+
+// WriteMessage writes v as one message: its JSON encoding preceded by the
+// encoding's length as 4 bytes, big-endian
+func WriteMessage(w io.Writer, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode message: %w", err)
+	}
+	if len(data) > maxMessageSize {
+		return fmt.Errorf("message of %d bytes exceeds the %d byte limit", len(data), maxMessageSize)
+	}
+	frame := make([]byte, 4+len(data))
+	binary.BigEndian.PutUint32(frame, uint32(len(data)))
+	copy(frame[4:], data)
+	_, err = w.Write(frame)
+	return err
+}
+
+// ReadMessage reads one message written by WriteMessage into v
+func ReadMessage(r io.Reader, v any) error {
+	var header [4]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return err
+	}
+	size := binary.BigEndian.Uint32(header[:])
+	if size > maxMessageSize {
+		return fmt.Errorf("message of %d bytes exceeds the %d byte limit", size, maxMessageSize)
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return fmt.Errorf("truncated message: %w", err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to decode message: %w", err)
+	}
+	return nil
+}
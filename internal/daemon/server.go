@@ -0,0 +1,211 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	appctx "wut/internal/context"
+	"wut/internal/db"
+	"wut/internal/logger"
+	"wut/internal/smart"
+)
+
+// ErrRunning is returned by Listen when a daemon already answers on the
+// socket
+var ErrRunning = errors.New("daemon is already running")
+
+const (
+	// connTimeout bounds reading a request and writing its response, so a
+	// client that stops halfway does not hold a connection open
+	connTimeout = 10 * time.Second
+	// suggestTimeout bounds answering a suggest request, like wut suggest
+	// in-process
+	suggestTimeout = 3 * time.Second
+	// storageTimeout is how long a request waits for a command writing to
+	// the database before it is answered without the history
+	storageTimeout = 200 * time.Millisecond
+)
+
+// Server answers requests with one smart engine, kept warm between them
+type Server struct {
+	engine  *smart.Engine
+	dbPath  string
+	started time.Time
+	log     *logger.Logger
+
+	requests atomic.Int64
+
+	// mu serializes suggest requests: the engine's storage and intent
+	// category are set for each one
+	mu sync.Mutex
+
+	stopOnce sync.Once
+	stop     chan struct{}
+}
+
+// NewServer returns a Server answering with engine. The database at dbPath
+// is opened read-only for each request and closed after it, so the daemon
+// never keeps other WUT commands from recording history. The key of
+// encrypted history is kept between requests rather than asked of the OS
+// keyring for each.
+func NewServer(engine *smart.Engine, dbPath string) *Server {
+	db.RememberKeys()
+	return &Server{
+		engine:  engine,
+		dbPath:  dbPath,
+		started: time.Now(),
+		log:     logger.With("daemon"),
+		stop:    make(chan struct{}),
+	}
+}
+
+// Listen listens on the unix socket at path, only for the current user. The
+// socket is created in a directory only the user can enter, so it is never
+// reachable by others, even before its own permissions are restricted. A
+// socket left by a daemon that did not exit cleanly is replaced; one that a
+// daemon answers on is ErrRunning.
+func Listen(path string) (net.Listener, error) {
+	if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+		conn.Close()
+		return nil, fmt.Errorf("%w on %s", ErrRunning, path)
+	}
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create socket directory: %w", err)
+	}
+	if err := os.Chmod(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to restrict socket directory permissions: %w", err)
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to remove stale socket: %w", err)
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", path, err)
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to restrict socket permissions: %w", err)
+	}
+	return listener, nil
+}
+
+// Serve answers the connections on listener until ctx is done or a client
+// asks the daemon to stop, then closes listener and waits for the requests
+// being answered
+func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-s.stop:
+		}
+		listener.Close()
+	}()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-s.stop:
+				return nil
+			default:
+				return fmt.Errorf("failed to accept connection: %w", err)
+			}
+		}
+		wg.Go(func() { s.handle(ctx, conn) })
+	}
+}
+
+// handle answers the request on conn
+func (s *Server) handle(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(connTimeout))
+
+	var req Request
+	if err := ReadMessage(conn, &req); err != nil {
+		s.log.Debug("failed to read request", "error", err)
+		return
+	}
+	s.requests.Add(1)
+
+	resp := s.answer(ctx, req)
+	resp.Version = ProtocolVersion
+	if err := WriteMessage(conn, resp); err != nil {
+		s.log.Debug("failed to write response", "op", req.Op, "error", err)
+	}
+}
+
+// answer returns the response to req
+func (s *Server) answer(ctx context.Context, req Request) *Response {
+	if req.Version != ProtocolVersion {
+		return &Response{Error: fmt.Sprintf("protocol version %d is not supported; the daemon speaks version %d", req.Version, ProtocolVersion)}
+	}
+
+	switch req.Op {
+	case OpPing:
+		return s.status()
+	case OpStop:
+		s.stopOnce.Do(func() { close(s.stop) })
+		return s.status()
+	case OpSuggest:
+		return s.suggest(ctx, req)
+	default:
+		return &Response{Error: fmt.Sprintf("unknown operation %q", req.Op)}
+	}
+}
+
+func (s *Server) status() *Response {
+	return &Response{PID: os.Getpid(), StartedAt: s.started, Requests: s.requests.Load()}
+}
+
+// suggest answers a suggest request the way wut suggest answers a task
+// description in-process
+func (s *Server) suggest(ctx context.Context, req Request) *Response {
+	if req.Dir == "" {
+		return &Response{Error: "a suggest request needs the client's working directory"}
+	}
+	ctx, cancel := context.WithTimeout(ctx, suggestTimeout)
+	defer cancel()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	storage, err := db.OpenReadOnly(s.dbPath, storageTimeout)
+	if err != nil {
+		s.log.Debug("answering without history", "error", err)
+		storage = nil
+	}
+	s.engine.SetStorage(storage)
+	defer func() {
+		s.engine.SetStorage(nil)
+		if storage != nil {
+			storage.Close()
+		}
+	}()
+	s.engine.SetIntentCategory(req.Category)
+
+	contextData, err := s.engine.ContextIn(ctx, req.Dir)
+	if err != nil {
+		s.log.Warn("failed to detect context", "dir", req.Dir, "error", err)
+		contextData = &appctx.Context{WorkingDir: req.Dir, ProjectType: "unknown"}
+	}
+	suggestions, err := s.engine.Suggest(ctx, req.Query, contextData, req.Limit)
+	if err != nil {
+		return &Response{Error: fmt.Sprintf("failed to get suggestions: %v", err)}
+	}
+	return &Response{Suggestions: suggestions, Context: contextData}
+}
@@ -0,0 +1,222 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-json"
+)
+
+const aliasBucketName = "user_aliases"
+
+// ErrAliasNotFound is returned when no alias has the requested name
+var ErrAliasNotFound = errors.New("alias not found")
+
+// UserAlias is a named shortcut for a command. The command may use the
+// positional parameters $1 to $9 and $@, filled in when it is run.
+type UserAlias struct {
+	Name        string    `json:"name"`
+	Command     string    `json:"command"`
+	Description string    `json:"description,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// SaveAlias creates an alias, or replaces the command and description of an
+// existing one
+func (s *Storage) SaveAlias(ctx context.Context, name, command, description string) error {
+	if s == nil || s.db == nil {
+		return fmt.Errorf("storage not initialized")
+	}
+
+	name = strings.TrimSpace(name)
+	if !ValidAliasName(name) {
+		return fmt.Errorf("invalid alias name %q: use letters, digits, - and _", name)
+	}
+	command = strings.TrimSpace(command)
+	if command == "" {
+		return fmt.Errorf("command cannot be empty")
+	}
+
+	return s.update(ctx, func(tx kvTx) error {
+		bucket, err := tx.CreateBucketIfNotExists([]byte(aliasBucketName))
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		alias := UserAlias{Name: name, CreatedAt: now}
+		if data := bucket.Get([]byte(name)); data != nil {
+			if err := json.Unmarshal(data, &alias); err != nil {
+				return fmt.Errorf("failed to decode alias %s: %w", name, err)
+			}
+		}
+		alias.Command = command
+		alias.Description = strings.TrimSpace(description)
+		alias.UpdatedAt = now
+
+		data, err := json.Marshal(alias)
+		if err != nil {
+			return fmt.Errorf("failed to marshal alias: %w", err)
+		}
+		return bucket.Put([]byte(name), data)
+	})
+}
+
+// GetAlias returns the alias with the given name, or ErrAliasNotFound
+func (s *Storage) GetAlias(ctx context.Context, name string) (*UserAlias, error) {
+	if s == nil || s.db == nil {
+		return nil, fmt.Errorf("storage not initialized")
+	}
+
+	var alias *UserAlias
+	err := s.view(ctx, func(tx kvTx) error {
+		bucket := tx.Bucket([]byte(aliasBucketName))
+		if bucket == nil {
+			return ErrAliasNotFound
+		}
+		data := bucket.Get([]byte(name))
+		if data == nil {
+			return ErrAliasNotFound
+		}
+		alias = &UserAlias{}
+		return json.Unmarshal(data, alias)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return alias, nil
+}
+
+// ListAliases returns all aliases in name order
+func (s *Storage) ListAliases(ctx context.Context) ([]UserAlias, error) {
+	if s == nil || s.db == nil {
+		return nil, fmt.Errorf("storage not initialized")
+	}
+
+	var aliases []UserAlias
+	err := s.view(ctx, func(tx kvTx) error {
+		bucket := tx.Bucket([]byte(aliasBucketName))
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(_, v []byte) error {
+			var alias UserAlias
+			if err := json.Unmarshal(v, &alias); err == nil {
+				aliases = append(aliases, alias)
+			}
+			return nil
+		})
+	})
+	return aliases, err
+}
+
+// DeleteAlias removes the alias with the given name, or returns
+// ErrAliasNotFound
+func (s *Storage) DeleteAlias(ctx context.Context, name string) error {
+	if s == nil || s.db == nil {
+		return fmt.Errorf("storage not initialized")
+	}
+
+	return s.update(ctx, func(tx kvTx) error {
+		bucket := tx.Bucket([]byte(aliasBucketName))
+		if bucket == nil || bucket.Get([]byte(name)) == nil {
+			return ErrAliasNotFound
+		}
+		return bucket.Delete([]byte(name))
+	})
+}
+
+// ValidAliasName reports whether name can be used as an alias: letters,
+// digits, - and _, not starting with -
+func ValidAliasName(name string) bool {
+	if name == "" || name[0] == '-' {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if !((c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c == '_' || c == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+// AliasParams returns the positional parameters an alias command uses, $1 to
+// $9 in numeric order followed by $@
+func AliasParams(command string) []string {
+	var numbered []int
+	all := false
+	scanAliasParams(command, func(param string) string {
+		if param == "@" {
+			all = true
+		} else if n, _ := strconv.Atoi(param); !slices.Contains(numbered, n) {
+			numbered = append(numbered, n)
+		}
+		return ""
+	})
+
+	sort.Ints(numbered)
+	params := make([]string, 0, len(numbered)+1)
+	for _, n := range numbered {
+		params = append(params, "$"+strconv.Itoa(n))
+	}
+	if all {
+		params = append(params, "$@")
+	}
+	return params
+}
+
+// ExpandAlias substitutes args for the positional parameters of an alias
+// command: $1 is the first argument and $@ all of them. Missing arguments
+// expand to nothing. Like a shell alias, a command without parameters gets
+// the arguments appended.
+func ExpandAlias(command string, args []string) string {
+	if len(AliasParams(command)) == 0 {
+		if len(args) == 0 {
+			return command
+		}
+		return command + " " + strings.Join(args, " ")
+	}
+
+	expanded := scanAliasParams(command, func(param string) string {
+		if param == "@" {
+			return strings.Join(args, " ")
+		}
+		n, _ := strconv.Atoi(param)
+		if n <= len(args) {
+			return args[n-1]
+		}
+		return ""
+	})
+	return strings.TrimSpace(expanded)
+}
+
+// scanAliasParams rewrites each $1-$9 and $@ in command with replace's
+// result, given the parameter without its $. Single-quoted text is left
+// alone, as the shell would.
+func scanAliasParams(command string, replace func(param string) string) string {
+	var b strings.Builder
+	quoted := false
+	for i := 0; i < len(command); i++ {
+		c := command[i]
+		if c == '\'' {
+			quoted = !quoted
+		}
+		if c == '$' && !quoted && i+1 < len(command) {
+			if next := command[i+1]; next == '@' || (next >= '1' && next <= '9') {
+				b.WriteString(replace(string(next)))
+				i++
+				continue
+			}
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
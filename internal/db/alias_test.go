@@ -0,0 +1,111 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestAliasCRUD(t *testing.T) {
+	storage, err := NewStorage(filepath.Join(t.TempDir(), "wut.db"))
+	if err != nil {
+		t.Fatalf("NewStorage() error = %v", err)
+	}
+	defer storage.Close()
+	ctx := context.Background()
+
+	if aliases, err := storage.ListAliases(ctx); err != nil || len(aliases) != 0 {
+		t.Fatalf("ListAliases() on a new database = %v, %v", aliases, err)
+	}
+	if _, err := storage.GetAlias(ctx, "deploy"); !errors.Is(err, ErrAliasNotFound) {
+		t.Fatalf("GetAlias() on a new database error = %v, want ErrAliasNotFound", err)
+	}
+
+	if err := storage.SaveAlias(ctx, "deploy", "git push origin main", ""); err != nil {
+		t.Fatalf("SaveAlias() error = %v", err)
+	}
+	if err := storage.SaveAlias(ctx, "klogs", "kubectl logs -f $1", "Follow logs"); err != nil {
+		t.Fatalf("SaveAlias() error = %v", err)
+	}
+
+	first, err := storage.GetAlias(ctx, "deploy")
+	if err != nil {
+		t.Fatalf("GetAlias() error = %v", err)
+	}
+	if first.Command != "git push origin main" {
+		t.Errorf("GetAlias() command = %q", first.Command)
+	}
+
+	// Saving again replaces the command but keeps the creation time
+	if err := storage.SaveAlias(ctx, "deploy", "git push origin main && kubectl rollout restart deployment/api", "Ship it"); err != nil {
+		t.Fatalf("SaveAlias() replace error = %v", err)
+	}
+	replaced, err := storage.GetAlias(ctx, "deploy")
+	if err != nil {
+		t.Fatalf("GetAlias() error = %v", err)
+	}
+	if replaced.Command != "git push origin main && kubectl rollout restart deployment/api" || replaced.Description != "Ship it" {
+		t.Errorf("replaced alias = %+v", replaced)
+	}
+	if !replaced.CreatedAt.Equal(first.CreatedAt) {
+		t.Errorf("replacing changed CreatedAt from %v to %v", first.CreatedAt, replaced.CreatedAt)
+	}
+
+	aliases, err := storage.ListAliases(ctx)
+	if err != nil {
+		t.Fatalf("ListAliases() error = %v", err)
+	}
+	if len(aliases) != 2 || aliases[0].Name != "deploy" || aliases[1].Name != "klogs" {
+		t.Fatalf("ListAliases() = %+v, want deploy and klogs", aliases)
+	}
+
+	if err := storage.DeleteAlias(ctx, "deploy"); err != nil {
+		t.Fatalf("DeleteAlias() error = %v", err)
+	}
+	if err := storage.DeleteAlias(ctx, "deploy"); !errors.Is(err, ErrAliasNotFound) {
+		t.Errorf("second DeleteAlias() error = %v, want ErrAliasNotFound", err)
+	}
+	if aliases, _ := storage.ListAliases(ctx); len(aliases) != 1 {
+		t.Errorf("ListAliases() after delete = %+v", aliases)
+	}
+
+	for _, name := range []string{"", "two words", "-flag", "semi;colon"} {
+		if err := storage.SaveAlias(ctx, name, "ls", ""); err == nil {
+			t.Errorf("SaveAlias(%q) accepted an invalid name", name)
+		}
+	}
+	if err := storage.SaveAlias(ctx, "empty", "  ", ""); err == nil {
+		t.Error("SaveAlias() accepted an empty command")
+	}
+}
+
+func TestExpandAlias(t *testing.T) {
+	tests := []struct {
+		command string
+		args    []string
+		want    string
+		params  []string
+	}{
+		{"kubectl logs -f deployment/$1", []string{"api"}, "kubectl logs -f deployment/api", []string{"$1"}},
+		{"cp $2 $1", []string{"dst", "src"}, "cp src dst", []string{"$1", "$2"}},
+		{"git commit -m $1 $@", []string{"msg", "--amend"}, "git commit -m msg msg --amend", []string{"$1", "$@"}},
+		{"docker exec -it $1 sh", nil, "docker exec -it  sh", []string{"$1"}},
+		// Without parameters the arguments are appended, like a shell alias
+		{"git push origin", []string{"main"}, "git push origin main", nil},
+		{"git status", nil, "git status", nil},
+		// Single quotes and other variables are left to the shell
+		{"awk '{print $1}' $1", []string{"file"}, "awk '{print $1}' file", []string{"$1"}},
+		{"echo $HOME $0", nil, "echo $HOME $0", nil},
+	}
+
+	for _, tt := range tests {
+		if got := ExpandAlias(tt.command, tt.args); got != tt.want {
+			t.Errorf("ExpandAlias(%q, %q) = %q, want %q", tt.command, tt.args, got, tt.want)
+		}
+		if got := AliasParams(tt.command); !reflect.DeepEqual(got, tt.params) && !(len(got) == 0 && len(tt.params) == 0) {
+			t.Errorf("AliasParams(%q) = %q, want %q", tt.command, got, tt.params)
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/goccy/go-json"
+)
+
+const (
+	// autoSyncKey is the metadata key of the AutoSyncRecord
+	autoSyncKey = "auto_sync"
+
+	// autoSyncTimeout is short so checking whether a sync is due never slows
+	// a command down while another process holds the database
+	autoSyncTimeout = 50 * time.Millisecond
+
+	// autoSyncLockTTL is how long a started auto sync keeps other processes
+	// from starting one; a sync that died is retried after it
+	autoSyncLockTTL = 30 * time.Minute
+
+	// autoSyncRetry is how long to wait after an auto sync that failed or
+	// found no network before trying again
+	autoSyncRetry = time.Hour
+)
+
+// AutoSyncStatus is where the last auto sync got to
+type AutoSyncStatus string
+
+const (
+	AutoSyncRunning   AutoSyncStatus = "running"
+	AutoSyncSucceeded AutoSyncStatus = "succeeded"
+	AutoSyncFailed    AutoSyncStatus = "failed"
+	AutoSyncOffline   AutoSyncStatus = "offline"
+)
+
+// AutoSyncRecord is what the last automatic TLDR sync did. While it is
+// running, the record also keeps other shells from starting another.
+type AutoSyncRecord struct {
+	StartedAt  time.Time      `json:"started_at"`
+	FinishedAt time.Time      `json:"finished_at,omitzero"`
+	Status     AutoSyncStatus `json:"status"`
+	Updated    int            `json:"updated"`
+	Failed     int            `json:"failed"`
+	Error      string         `json:"error,omitempty"`
+}
+
+// Running reports whether the sync is still under way. A sync that has not
+// finished within autoSyncLockTTL is taken to have died.
+func (r *AutoSyncRecord) Running() bool {
+	return r != nil && r.Status == AutoSyncRunning && time.Since(r.StartedAt) < autoSyncLockTTL
+}
+
+// Finish records the outcome of a sync that updated stale pages
+func (r *AutoSyncRecord) Finish(result *SyncResult, err error) {
+	r.FinishedAt = time.Now()
+	switch {
+	case err != nil:
+		r.Status = AutoSyncFailed
+		r.Error = err.Error()
+	case result != nil && result.Failed > 0 && result.Downloaded == 0:
+		r.Status = AutoSyncFailed
+		r.Failed = result.Failed
+		r.Error = fmt.Sprintf("all %d stale pages failed to update", result.Failed)
+	default:
+		r.Status = AutoSyncSucceeded
+		if result != nil {
+			r.Updated, r.Failed = result.Downloaded, result.Failed
+		}
+	}
+}
+
+// autoSyncDue reports whether an auto sync should start at now, given when
+// the pages were last synced, the last auto sync and the sync interval
+func autoSyncDue(lastSync time.Time, record *AutoSyncRecord, interval time.Duration, now time.Time) bool {
+	if now.Sub(lastSync) < interval {
+		return false
+	}
+	if record == nil {
+		return true
+	}
+	wait := autoSyncRetry
+	switch record.Status {
+	case AutoSyncRunning:
+		wait = autoSyncLockTTL
+	case AutoSyncSucceeded:
+		// A sync with no stale pages to update leaves lastSync as it was
+		wait = interval
+	}
+	return now.Sub(record.StartedAt) >= wait
+}
+
+// readAutoSyncState returns the last sync time and the auto sync record kept
+// in the metadata bucket
+func readAutoSyncState(tx kvTx) (time.Time, *AutoSyncRecord, error) {
+	bucket := tx.Bucket([]byte(metadataBucket))
+	if bucket == nil {
+		return time.Time{}, nil, nil
+	}
+	var lastSync time.Time
+	if data := bucket.Get([]byte("metadata")); data != nil {
+		var meta Metadata
+		if err := json.Unmarshal(data, &meta); err != nil {
+			return time.Time{}, nil, fmt.Errorf("failed to decode metadata: %w", err)
+		}
+		lastSync = meta.LastSync
+	}
+	data := bucket.Get([]byte(autoSyncKey))
+	if data == nil {
+		return lastSync, nil, nil
+	}
+	var record AutoSyncRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return lastSync, nil, fmt.Errorf("failed to decode auto sync record: %w", err)
+	}
+	return lastSync, &record, nil
+}
+
+// GetAutoSyncRecord returns the record of the last auto sync, or nil when
+// none has run
+func (s *Storage) GetAutoSyncRecord() (*AutoSyncRecord, error) {
+	var record *AutoSyncRecord
+	err := s.db.View(func(tx kvTx) error {
+		var err error
+		_, record, err = readAutoSyncState(tx)
+		return err
+	})
+	return record, err
+}
+
+// SaveAutoSyncRecord stores the record of the current auto sync
+func (s *Storage) SaveAutoSyncRecord(record *AutoSyncRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal auto sync record: %w", err)
+	}
+	return s.db.Update(func(tx kvTx) error {
+		bucket, err := tx.CreateBucketIfNotExists([]byte(metadataBucket))
+		if err != nil {
+			return err
+		}
+		return bucket.Put([]byte(autoSyncKey), data)
+	})
+}
+
+// ClaimAutoSync reports whether the TLDR database at dbPath is due an auto
+// sync, the pages being older than interval and no other process syncing
+// them, and if so records a running sync so no other process starts one. It
+// returns false without waiting when the database does not exist yet or
+// another process holds it; a later command checks again.
+func ClaimAutoSync(dbPath string, interval time.Duration) (bool, error) {
+	if interval <= 0 || !fileExists(dbPath) {
+		return false, nil
+	}
+
+	// Most commands find nothing due, so look with a shared handle first
+	storage, err := OpenReadOnly(dbPath, autoSyncTimeout)
+	if errors.Is(err, ErrDatabaseLocked) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	var due bool
+	err = storage.db.View(func(tx kvTx) error {
+		lastSync, record, err := readAutoSyncState(tx)
+		due = autoSyncDue(lastSync, record, interval, time.Now())
+		return err
+	})
+	storage.Close()
+	if err != nil || !due {
+		return false, err
+	}
+
+	db, err := openKV(dbPath, autoSyncTimeout, false)
+	if errors.Is(err, errLockTimeout) {
+		return false, nil
+	}
+	if err != nil {
+		return false, openError(dbPath, err)
+	}
+	defer db.Close()
+
+	claimed := false
+	err = db.Update(func(tx kvTx) error {
+		// Another process may have claimed it since the first look
+		lastSync, record, err := readAutoSyncState(tx)
+		if err != nil || !autoSyncDue(lastSync, record, interval, time.Now()) {
+			return err
+		}
+		bucket, err := tx.CreateBucketIfNotExists([]byte(metadataBucket))
+		if err != nil {
+			return err
+		}
+		data, err := json.Marshal(AutoSyncRecord{StartedAt: time.Now(), Status: AutoSyncRunning})
+		if err != nil {
+			return err
+		}
+		claimed = true
+		return bucket.Put([]byte(autoSyncKey), data)
+	})
+	return claimed && err == nil, err
+}
+
+// RunAutoSync updates the pages older than maxAge for an auto sync that
+// ClaimAutoSync started, and records how it went. With checkOnline, a sync
+// without network is recorded as offline instead of failing page by page.
+func (sm *SyncManager) RunAutoSync(ctx context.Context, maxAge time.Duration, checkOnline bool) *AutoSyncRecord {
+	record := &AutoSyncRecord{StartedAt: time.Now(), Status: AutoSyncRunning}
+	if err := sm.storage.SaveAutoSyncRecord(record); err != nil {
+		sm.log.Warn("failed to record auto sync", "error", err)
+	}
+
+	if checkOnline && !sm.client.IsOnline(ctx) {
+		record.Status = AutoSyncOffline
+		record.FinishedAt = time.Now()
+	} else {
+		result, err := sm.UpdateStalePages(ctx, maxAge, SyncOptions{})
+		record.Finish(result, err)
+	}
+
+	if err := sm.storage.SaveAutoSyncRecord(record); err != nil {
+		sm.log.Warn("failed to record auto sync", "error", err)
+	}
+	sm.log.Info("auto sync finished", "status", record.Status, "updated", record.Updated, "failed", record.Failed)
+	return record
+}
@@ -0,0 +1,89 @@
+package db
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAutoSyncDue(t *testing.T) {
+	now := time.Date(2026, 3, 14, 12, 0, 0, 0, time.UTC)
+	week := 7 * 24 * time.Hour
+	stale := now.Add(-8 * 24 * time.Hour)
+
+	tests := []struct {
+		name     string
+		lastSync time.Time
+		record   *AutoSyncRecord
+		want     bool
+	}{
+		{"never synced", time.Time{}, nil, true},
+		{"fresh pages", now.Add(-time.Hour), nil, false},
+		{"stale pages", stale, nil, true},
+		{"running", stale, &AutoSyncRecord{StartedAt: now.Add(-time.Minute), Status: AutoSyncRunning}, false},
+		{"died while running", stale, &AutoSyncRecord{StartedAt: now.Add(-time.Hour), Status: AutoSyncRunning}, true},
+		{"failed recently", stale, &AutoSyncRecord{StartedAt: now.Add(-10 * time.Minute), Status: AutoSyncFailed}, false},
+		{"offline a while ago", stale, &AutoSyncRecord{StartedAt: now.Add(-2 * time.Hour), Status: AutoSyncOffline}, true},
+		{"nothing to update this week", stale, &AutoSyncRecord{StartedAt: now.Add(-2 * 24 * time.Hour), Status: AutoSyncSucceeded}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := autoSyncDue(tt.lastSync, tt.record, week, now); got != tt.want {
+				t.Errorf("autoSyncDue() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClaimAutoSync(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tldr.db")
+	week := 7 * 24 * time.Hour
+
+	if claimed, err := ClaimAutoSync(path, week); claimed || err != nil {
+		t.Fatalf("ClaimAutoSync() without a database = %v, %v", claimed, err)
+	}
+
+	storage, err := NewStorage(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.SaveMetadata(&Metadata{LastSync: time.Now().Add(-8 * 24 * time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Another process holding the database is not waited for
+	start := time.Now()
+	if claimed, err := ClaimAutoSync(path, week); claimed || err != nil {
+		t.Errorf("ClaimAutoSync() of a held database = %v, %v", claimed, err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("ClaimAutoSync() waited %v for the database", elapsed)
+	}
+	storage.Close()
+
+	if claimed, err := ClaimAutoSync(path, week); !claimed || err != nil {
+		t.Fatalf("ClaimAutoSync() of stale pages = %v, %v, want claimed", claimed, err)
+	}
+	if claimed, _ := ClaimAutoSync(path, week); claimed {
+		t.Error("a second shell claimed the running auto sync")
+	}
+
+	storage, err = NewStorage(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer storage.Close()
+	record, err := storage.GetAutoSyncRecord()
+	if err != nil || !record.Running() {
+		t.Fatalf("GetAutoSyncRecord() = %+v, %v, want a running sync", record, err)
+	}
+
+	// With no stale pages the sync succeeds without going online
+	record = NewSyncManager(storage).RunAutoSync(t.Context(), week, false)
+	if record.Status != AutoSyncSucceeded || record.FinishedAt.IsZero() {
+		t.Errorf("RunAutoSync() = %+v, want a finished sync", record)
+	}
+	if saved, _ := storage.GetAutoSyncRecord(); saved == nil || saved.Status != AutoSyncSucceeded {
+		t.Errorf("saved record = %+v, want the finished sync", saved)
+	}
+}
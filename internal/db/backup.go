@@ -0,0 +1,275 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	backupPrefix     = "wut-"
+	backupExt        = ".db"
+	backupTimeFormat = "20060102-150405"
+
+	// autoBackupTimeout is short so a busy database never slows a command down
+	autoBackupTimeout = 100 * time.Millisecond
+)
+
+// ErrNoBackups is returned when a backup directory holds no backups
+var ErrNoBackups = errors.New("no backups found")
+
+// BackupFile is a database backup on disk
+type BackupFile struct {
+	Path      string
+	CreatedAt time.Time
+	Size      int64
+}
+
+// BackupPolicy controls how many backups are kept
+type BackupPolicy struct {
+	Interval time.Duration // minimum age of the latest backup before a new one
+	Keep     int           // most recent backups to keep, 0 for no limit
+	MaxBytes int64         // cap on the total size of the backups, 0 for none
+}
+
+// BackupDir returns the directory that holds the backups of a database
+func BackupDir(dbPath string) string {
+	return filepath.Join(filepath.Dir(dbPath), "backups")
+}
+
+// Backup writes a consistent copy of the database to a timestamped file in
+// dir and returns it. The copy is taken in a read transaction, so other
+// readers are not blocked.
+func (s *Storage) Backup(ctx context.Context, dir string) (*BackupFile, error) {
+	if s == nil || s.db == nil {
+		return nil, fmt.Errorf("storage not initialized")
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	now := time.Now()
+	path := filepath.Join(dir, backupPrefix+now.Format(backupTimeFormat)+backupExt)
+	for i := 1; fileExists(path); i++ {
+		path = filepath.Join(dir, fmt.Sprintf("%s%s-%d%s", backupPrefix, now.Format(backupTimeFormat), i, backupExt))
+	}
+
+	tmp, err := os.CreateTemp(dir, ".backup-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create backup: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	var size int64
+	if err = contextErr(ctx); err == nil {
+		size, err = s.db.WriteTo(tmp)
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to write backup: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return nil, fmt.Errorf("failed to save backup: %w", err)
+	}
+
+	return &BackupFile{Path: path, CreatedAt: now, Size: size}, nil
+}
+
+// ListBackups returns the backups in dir, newest first
+func ListBackups(dir string) ([]BackupFile, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []BackupFile
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, backupPrefix) || !strings.HasSuffix(name, backupExt) {
+			continue
+		}
+		stamp := strings.TrimSuffix(strings.TrimPrefix(name, backupPrefix), backupExt)
+		if len(stamp) > len(backupTimeFormat) {
+			stamp = stamp[:len(backupTimeFormat)]
+		}
+		createdAt, err := time.ParseInLocation(backupTimeFormat, stamp, time.Local)
+		if err != nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		backups = append(backups, BackupFile{
+			Path:      filepath.Join(dir, name),
+			CreatedAt: createdAt,
+			Size:      info.Size(),
+		})
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		if backups[i].CreatedAt.Equal(backups[j].CreatedAt) {
+			return backups[i].Path > backups[j].Path
+		}
+		return backups[i].CreatedAt.After(backups[j].CreatedAt)
+	})
+	return backups, nil
+}
+
+// LatestBackup returns the newest backup in dir, or ErrNoBackups
+func LatestBackup(dir string) (*BackupFile, error) {
+	backups, err := ListBackups(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(backups) == 0 {
+		return nil, ErrNoBackups
+	}
+	return &backups[0], nil
+}
+
+// AutoBackup backs up the database at dbPath when its latest backup is older
+// than policy.Interval, then prunes old backups. It returns nil without a
+// backup when none is due, the database does not exist yet or another wut
+// instance is writing to it; the next run tries again.
+func AutoBackup(ctx context.Context, dbPath string, policy BackupPolicy) (*BackupFile, error) {
+	dir := BackupDir(dbPath)
+	if latest, err := LatestBackup(dir); err == nil && time.Since(latest.CreatedAt) < policy.Interval {
+		return nil, nil
+	}
+	if !fileExists(dbPath) {
+		return nil, nil
+	}
+
+	storage, err := OpenReadOnly(dbPath, autoBackupTimeout)
+	if errors.Is(err, ErrDatabaseLocked) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	backup, err := storage.Backup(ctx, dir)
+	storage.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = PruneBackups(dir, policy)
+	return backup, err
+}
+
+// PruneBackups removes the oldest backups beyond policy.Keep, then more of
+// the oldest until the rest fit in policy.MaxBytes. The newest backup is
+// always kept. It returns the removed backups.
+func PruneBackups(dir string, policy BackupPolicy) ([]BackupFile, error) {
+	backups, err := ListBackups(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var total int64
+	var removed []BackupFile
+	for i, backup := range backups {
+		total += backup.Size
+		overCount := policy.Keep > 0 && i >= policy.Keep
+		overSize := policy.MaxBytes > 0 && total > policy.MaxBytes
+		if i == 0 || (!overCount && !overSize) {
+			continue
+		}
+		if err := os.Remove(backup.Path); err != nil {
+			return removed, fmt.Errorf("failed to remove old backup: %w", err)
+		}
+		total -= backup.Size
+		removed = append(removed, backup)
+	}
+	return removed, nil
+}
+
+// ValidateBackup opens a backup read-only and checks that it is a WUT
+// database
+func ValidateBackup(path string) error {
+	storage, err := OpenReadOnly(path, lockTimeout)
+	if err != nil {
+		return fmt.Errorf("%s is not a readable database: %w", path, err)
+	}
+	defer storage.Close()
+
+	return storage.db.View(func(tx kvTx) error {
+		for _, name := range []string{tldrBucketName, metadataBucket} {
+			if tx.Bucket([]byte(name)) == nil {
+				return fmt.Errorf("%s is not a WUT database: bucket %s is missing", path, name)
+			}
+		}
+		return nil
+	})
+}
+
+// RestoreBackup validates a backup and swaps it in for the database at
+// dbPath. The current database is kept as dbPath.pre-restore. The database
+// must not be open.
+func RestoreBackup(dbPath, backupPath string) error {
+	if err := ValidateBackup(backupPath); err != nil {
+		return err
+	}
+
+	// Copy next to the database first so the swap is a rename
+	tmp, err := os.CreateTemp(filepath.Dir(dbPath), ".restore-*")
+	if err != nil {
+		return fmt.Errorf("failed to prepare restore: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	src, err := os.Open(backupPath)
+	if err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to read backup: %w", err)
+	}
+	_, err = io.Copy(tmp, src)
+	src.Close()
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to copy backup: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0600); err != nil {
+		return fmt.Errorf("failed to prepare restore: %w", err)
+	}
+
+	// Make sure no other wut instance has the database open
+	if fileExists(dbPath) {
+		current, err := openKV(dbPath, lockTimeout, false)
+		if err != nil {
+			return openError(dbPath, err)
+		}
+		current.Close()
+
+		if err := os.Rename(dbPath, dbPath+".pre-restore"); err != nil {
+			return fmt.Errorf("failed to keep the current database: %w", err)
+		}
+	}
+	if err := os.Rename(tmp.Name(), dbPath); err != nil {
+		_ = os.Rename(dbPath+".pre-restore", dbPath)
+		return fmt.Errorf("failed to restore database: %w", err)
+	}
+	return nil
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,150 @@
+package db
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBackupAndRestore(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "wut.db")
+	backupDir := BackupDir(dbPath)
+	ctx := context.Background()
+
+	storage, err := NewStorage(dbPath)
+	if err != nil {
+		t.Fatalf("NewStorage() error = %v", err)
+	}
+	if _, err := storage.AddBookmark(ctx, "make test", nil, ""); err != nil {
+		t.Fatalf("AddBookmark() error = %v", err)
+	}
+	backup, err := storage.Backup(ctx, backupDir)
+	if err != nil {
+		t.Fatalf("Backup() error = %v", err)
+	}
+	if _, err := storage.AddBookmark(ctx, "make lint", nil, ""); err != nil {
+		t.Fatalf("AddBookmark() error = %v", err)
+	}
+	storage.Close()
+
+	latest, err := LatestBackup(backupDir)
+	if err != nil || latest.Path != backup.Path || latest.Size != backup.Size {
+		t.Fatalf("LatestBackup() = %+v, %v, want %+v", latest, err, backup)
+	}
+
+	if err := RestoreBackup(dbPath, latest.Path); err != nil {
+		t.Fatalf("RestoreBackup() error = %v", err)
+	}
+	if _, err := os.Stat(dbPath + ".pre-restore"); err != nil {
+		t.Errorf("the previous database was not kept: %v", err)
+	}
+
+	restored, err := NewStorage(dbPath)
+	if err != nil {
+		t.Fatalf("NewStorage() after restore error = %v", err)
+	}
+	defer restored.Close()
+	if bookmarks, _ := restored.GetBookmarks(ctx); len(bookmarks) != 1 || bookmarks[0].Command != "make test" {
+		t.Errorf("restored bookmarks = %+v, want only make test", bookmarks)
+	}
+}
+
+func TestRestoreRejectsInvalidBackup(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "wut.db")
+	storage, err := NewStorage(dbPath)
+	if err != nil {
+		t.Fatalf("NewStorage() error = %v", err)
+	}
+	storage.Close()
+
+	bogus := filepath.Join(dir, "bogus.db")
+	if err := os.WriteFile(bogus, []byte("not a database"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := RestoreBackup(dbPath, bogus); err == nil {
+		t.Fatal("RestoreBackup() accepted a file that is not a database")
+	}
+	if _, err := os.Stat(dbPath + ".pre-restore"); !os.IsNotExist(err) {
+		t.Errorf("a rejected restore moved the database: %v", err)
+	}
+}
+
+func TestRestoreLockedDatabase(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "wut.db")
+	storage, err := NewStorage(dbPath)
+	if err != nil {
+		t.Fatalf("NewStorage() error = %v", err)
+	}
+	defer storage.Close()
+	backup, err := storage.Backup(context.Background(), BackupDir(dbPath))
+	if err != nil {
+		t.Fatalf("Backup() error = %v", err)
+	}
+
+	defer func(timeout time.Duration) { lockTimeout = timeout }(lockTimeout)
+	lockTimeout = 50 * time.Millisecond
+	if err := RestoreBackup(dbPath, backup.Path); err == nil {
+		t.Fatal("RestoreBackup() replaced a database that is in use")
+	}
+}
+
+func TestPruneBackups(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.Local)
+	for i := range 4 {
+		name := backupPrefix + start.Add(time.Duration(i)*time.Hour).Format(backupTimeFormat) + backupExt
+		if err := os.WriteFile(filepath.Join(dir, name), make([]byte, 100), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Files that are not backups are left alone
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	removed, err := PruneBackups(dir, BackupPolicy{Keep: 3})
+	if err != nil || len(removed) != 1 || !removed[0].CreatedAt.Equal(start) {
+		t.Fatalf("PruneBackups(Keep: 3) = %+v, %v, want the oldest removed", removed, err)
+	}
+
+	removed, err = PruneBackups(dir, BackupPolicy{Keep: 3, MaxBytes: 150})
+	if err != nil || len(removed) != 2 {
+		t.Fatalf("PruneBackups(MaxBytes: 150) = %+v, %v, want 2 removed", removed, err)
+	}
+
+	// The newest backup is kept even when it alone is over the cap
+	removed, _ = PruneBackups(dir, BackupPolicy{MaxBytes: 10})
+	backups, _ := ListBackups(dir)
+	if len(removed) != 0 || len(backups) != 1 || !backups[0].CreatedAt.Equal(start.Add(3*time.Hour)) {
+		t.Errorf("after pruning backups = %+v, want only the newest", backups)
+	}
+}
+
+func TestAutoBackup(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "wut.db")
+	policy := BackupPolicy{Interval: time.Hour, Keep: 2}
+
+	if backup, err := AutoBackup(context.Background(), dbPath, policy); backup != nil || err != nil {
+		t.Fatalf("AutoBackup() without a database = %+v, %v", backup, err)
+	}
+
+	storage, err := NewStorage(dbPath)
+	if err != nil {
+		t.Fatalf("NewStorage() error = %v", err)
+	}
+	storage.Close()
+
+	first, err := AutoBackup(context.Background(), dbPath, policy)
+	if err != nil || first == nil {
+		t.Fatalf("AutoBackup() = %+v, %v, want a backup", first, err)
+	}
+	if again, err := AutoBackup(context.Background(), dbPath, policy); again != nil || err != nil {
+		t.Errorf("AutoBackup() within the interval = %+v, %v, want none", again, err)
+	}
+}
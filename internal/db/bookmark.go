@@ -2,62 +2,107 @@ package db
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/goccy/go-json"
-	"go.etcd.io/bbolt"
 )
 
 const bookmarkBucketName = "command_bookmarks"
 
-// Bookmark represents a saved command with a label/tag
+// ErrBookmarkNotFound is returned when no bookmark has the requested ID
+var ErrBookmarkNotFound = errors.New("bookmark not found")
+
+// Bookmark represents a pinned command with optional tags and a note
 type Bookmark struct {
 	ID        string    `json:"id"`
 	Command   string    `json:"command"`
-	Label     string    `json:"label"`
-	Notes     string    `json:"notes"`
+	Tags      []string  `json:"tags,omitempty"`
+	Notes     string    `json:"notes,omitempty"`
 	CreatedAt time.Time `json:"created_at"`
 }
 
-// AddBookmark adds a new command bookmark
-func (s *Storage) AddBookmark(ctx context.Context, command, label, notes string) error {
+// ShortID is the suffix of the ID shown in listings and accepted by
+// FindBookmark
+func (b Bookmark) ShortID() string {
+	if len(b.ID) <= 6 {
+		return b.ID
+	}
+	return b.ID[len(b.ID)-6:]
+}
+
+// HasTag reports whether the bookmark carries tag, ignoring case
+func (b Bookmark) HasTag(tag string) bool {
+	return slices.ContainsFunc(b.Tags, func(t string) bool { return strings.EqualFold(t, tag) })
+}
+
+// AddBookmark pins a command. Bookmarking a command again adds the new tags
+// to the existing bookmark and replaces its note when one is given.
+func (s *Storage) AddBookmark(ctx context.Context, command string, tags []string, notes string) (*Bookmark, error) {
 	if s == nil || s.db == nil {
-		return fmt.Errorf("storage not initialized")
+		return nil, fmt.Errorf("storage not initialized")
 	}
 
 	command = strings.TrimSpace(command)
 	if command == "" {
-		return fmt.Errorf("command cannot be empty")
+		return nil, fmt.Errorf("command cannot be empty")
 	}
 
-	now := time.Now()
-	id := fmt.Sprintf("%020d", now.UnixNano())
+	var saved Bookmark
+	err := s.update(ctx, func(tx kvTx) error {
+		bucket, err := tx.CreateBucketIfNotExists([]byte(bookmarkBucketName))
+		if err != nil {
+			return err
+		}
 
-	bookmark := Bookmark{
-		ID:        id,
-		Command:   command,
-		Label:     label,
-		Notes:     notes,
-		CreatedAt: now,
+		if existing := findBookmarkByCommand(bucket, command); existing != nil {
+			saved = *existing
+		} else {
+			now := time.Now()
+			saved = Bookmark{
+				ID:        fmt.Sprintf("%020d", now.UnixNano()),
+				Command:   command,
+				CreatedAt: now,
+			}
+		}
+		saved.Tags = mergeTags(saved.Tags, tags)
+		if notes = strings.TrimSpace(notes); notes != "" {
+			saved.Notes = notes
+		}
+		return putBookmark(bucket, saved)
+	})
+	if err != nil {
+		return nil, err
 	}
+	return &saved, nil
+}
 
-	data, err := json.Marshal(bookmark)
-	if err != nil {
-		return fmt.Errorf("failed to marshal bookmark: %w", err)
+// UpdateBookmark replaces the command, tags and note of an existing bookmark
+func (s *Storage) UpdateBookmark(ctx context.Context, bookmark Bookmark) error {
+	if s == nil || s.db == nil {
+		return fmt.Errorf("storage not initialized")
 	}
 
-	return s.db.Update(func(tx *bbolt.Tx) error {
-		bucket, err := tx.CreateBucketIfNotExists([]byte(bookmarkBucketName))
-		if err != nil {
-			return err
+	bookmark.Command = strings.TrimSpace(bookmark.Command)
+	if bookmark.Command == "" {
+		return fmt.Errorf("command cannot be empty")
+	}
+	bookmark.Tags = mergeTags(nil, bookmark.Tags)
+
+	return s.update(ctx, func(tx kvTx) error {
+		bucket := tx.Bucket([]byte(bookmarkBucketName))
+		if bucket == nil || bucket.Get([]byte(bookmark.ID)) == nil {
+			return ErrBookmarkNotFound
 		}
-		return bucket.Put([]byte(id), data)
+		return putBookmark(bucket, bookmark)
 	})
 }
 
-// GetBookmarks retrieves all bookmarks
+// GetBookmarks retrieves all bookmarks, oldest first
 func (s *Storage) GetBookmarks(ctx context.Context) ([]Bookmark, error) {
 	if s == nil || s.db == nil {
 		return nil, fmt.Errorf("storage not initialized")
@@ -65,7 +110,7 @@ func (s *Storage) GetBookmarks(ctx context.Context) ([]Bookmark, error) {
 
 	var entries []Bookmark
 
-	err := s.db.View(func(tx *bbolt.Tx) error {
+	err := s.view(ctx, func(tx kvTx) error {
 		bucket := tx.Bucket([]byte(bookmarkBucketName))
 		if bucket == nil {
 			return nil
@@ -73,8 +118,7 @@ func (s *Storage) GetBookmarks(ctx context.Context) ([]Bookmark, error) {
 
 		c := bucket.Cursor()
 		for k, v := c.First(); k != nil; k, v = c.Next() {
-			var entry Bookmark
-			if err := json.Unmarshal(v, &entry); err == nil {
+			if entry, err := decodeBookmark(v); err == nil {
 				entries = append(entries, entry)
 			}
 		}
@@ -84,7 +128,37 @@ func (s *Storage) GetBookmarks(ctx context.Context) ([]Bookmark, error) {
 	return entries, err
 }
 
-// SearchBookmarks searches bookmarks by label, command or notes
+// FindBookmark returns the bookmark whose ID is, or ends with, ref
+func (s *Storage) FindBookmark(ctx context.Context, ref string) (*Bookmark, error) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return nil, ErrBookmarkNotFound
+	}
+
+	bookmarks, err := s.GetBookmarks(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var match *Bookmark
+	for i := range bookmarks {
+		switch {
+		case bookmarks[i].ID == ref:
+			return &bookmarks[i], nil
+		case strings.HasSuffix(bookmarks[i].ID, ref):
+			if match != nil {
+				return nil, fmt.Errorf("bookmark ID %s is ambiguous, use more digits", ref)
+			}
+			match = &bookmarks[i]
+		}
+	}
+	if match == nil {
+		return nil, ErrBookmarkNotFound
+	}
+	return match, nil
+}
+
+// SearchBookmarks searches bookmarks by command, tag or notes
 func (s *Storage) SearchBookmarks(ctx context.Context, query string) ([]Bookmark, error) {
 	allEntries, err := s.GetBookmarks(ctx)
 	if err != nil {
@@ -100,8 +174,8 @@ func (s *Storage) SearchBookmarks(ctx context.Context, query string) ([]Bookmark
 
 	for _, entry := range allEntries {
 		if strings.Contains(strings.ToLower(entry.Command), queryLower) ||
-			strings.Contains(strings.ToLower(entry.Label), queryLower) ||
-			strings.Contains(strings.ToLower(entry.Notes), queryLower) {
+			strings.Contains(strings.ToLower(entry.Notes), queryLower) ||
+			entry.HasTag(query) {
 			results = append(results, entry)
 		}
 	}
@@ -109,17 +183,128 @@ func (s *Storage) SearchBookmarks(ctx context.Context, query string) ([]Bookmark
 	return results, nil
 }
 
-// DeleteBookmark deletes a bookmark by its ID
+// BookmarksWithTag returns the bookmarks carrying tag
+func (s *Storage) BookmarksWithTag(ctx context.Context, tag string) ([]Bookmark, error) {
+	allEntries, err := s.GetBookmarks(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []Bookmark
+	for _, entry := range allEntries {
+		if entry.HasTag(tag) {
+			results = append(results, entry)
+		}
+	}
+	return results, nil
+}
+
+// DeleteBookmark deletes a bookmark by its ID, or returns ErrBookmarkNotFound
 func (s *Storage) DeleteBookmark(ctx context.Context, id string) error {
 	if s == nil || s.db == nil {
 		return fmt.Errorf("storage not initialized")
 	}
 
-	return s.db.Update(func(tx *bbolt.Tx) error {
+	return s.update(ctx, func(tx kvTx) error {
 		bucket := tx.Bucket([]byte(bookmarkBucketName))
-		if bucket == nil {
-			return nil
+		if bucket == nil || bucket.Get([]byte(id)) == nil {
+			return ErrBookmarkNotFound
 		}
 		return bucket.Delete([]byte(id))
 	})
 }
+
+// ExportBookmarks writes all bookmarks to a JSON file
+func (s *Storage) ExportBookmarks(ctx context.Context, filepath string) error {
+	bookmarks, err := s.GetBookmarks(ctx)
+	if err != nil {
+		return err
+	}
+	if bookmarks == nil {
+		bookmarks = []Bookmark{}
+	}
+
+	data, err := json.MarshalIndent(bookmarks, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal bookmarks: %w", err)
+	}
+
+	return os.WriteFile(filepath, data, 0644)
+}
+
+// ImportBookmarks adds the bookmarks from a JSON file written by
+// ExportBookmarks. Commands that are already bookmarked get the imported tags
+// merged in. It returns the number of bookmarks read.
+func (s *Storage) ImportBookmarks(ctx context.Context, filepath string) (int, error) {
+	data, err := os.ReadFile(filepath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return 0, fmt.Errorf("failed to parse bookmarks: %w", err)
+	}
+
+	imported := 0
+	for _, item := range raw {
+		bookmark, err := decodeBookmark(item)
+		if err != nil || strings.TrimSpace(bookmark.Command) == "" {
+			continue
+		}
+		if _, err := s.AddBookmark(ctx, bookmark.Command, bookmark.Tags, bookmark.Notes); err != nil {
+			return imported, err
+		}
+		imported++
+	}
+	return imported, nil
+}
+
+// decodeBookmark reads a stored bookmark. Bookmarks saved before tags had a
+// single label, which becomes their tag.
+func decodeBookmark(data []byte) (Bookmark, error) {
+	var stored struct {
+		Bookmark
+		Label string `json:"label"`
+	}
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return Bookmark{}, err
+	}
+	bookmark := stored.Bookmark
+	if len(bookmark.Tags) == 0 && stored.Label != "" && stored.Label != "default" {
+		bookmark.Tags = []string{stored.Label}
+	}
+	return bookmark, nil
+}
+
+func putBookmark(bucket kvBucket, bookmark Bookmark) error {
+	data, err := json.Marshal(bookmark)
+	if err != nil {
+		return fmt.Errorf("failed to marshal bookmark: %w", err)
+	}
+	return bucket.Put([]byte(bookmark.ID), data)
+}
+
+func findBookmarkByCommand(bucket kvBucket, command string) *Bookmark {
+	c := bucket.Cursor()
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		if bookmark, err := decodeBookmark(v); err == nil && bookmark.Command == command {
+			return &bookmark
+		}
+	}
+	return nil
+}
+
+// mergeTags appends the new tags that are not already present, ignoring
+// case and blanks
+func mergeTags(tags, add []string) []string {
+	merged := slices.Clone(tags)
+	for _, tag := range add {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || slices.ContainsFunc(merged, func(t string) bool { return strings.EqualFold(t, tag) }) {
+			continue
+		}
+		merged = append(merged, tag)
+	}
+	return merged
+}
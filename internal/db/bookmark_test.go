@@ -0,0 +1,126 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestBookmarkCRUD(t *testing.T) {
+	storage, err := NewStorage(filepath.Join(t.TempDir(), "wut.db"))
+	if err != nil {
+		t.Fatalf("NewStorage() error = %v", err)
+	}
+	defer storage.Close()
+	ctx := context.Background()
+
+	deploy, err := storage.AddBookmark(ctx, "git push origin main", []string{"deploy"}, "prod release")
+	if err != nil {
+		t.Fatalf("AddBookmark() error = %v", err)
+	}
+	if _, err := storage.AddBookmark(ctx, "kubectl get pods", []string{"k8s"}, ""); err != nil {
+		t.Fatalf("AddBookmark() error = %v", err)
+	}
+
+	// Bookmarking the same command again merges tags instead of duplicating
+	again, err := storage.AddBookmark(ctx, "git push origin main", []string{"Deploy", "git"}, "")
+	if err != nil {
+		t.Fatalf("AddBookmark() again error = %v", err)
+	}
+	if again.ID != deploy.ID || !reflect.DeepEqual(again.Tags, []string{"deploy", "git"}) || again.Notes != "prod release" {
+		t.Errorf("re-bookmarked = %+v, want the same bookmark with tags deploy and git", again)
+	}
+
+	all, err := storage.GetBookmarks(ctx)
+	if err != nil || len(all) != 2 {
+		t.Fatalf("GetBookmarks() = %+v, %v, want 2 bookmarks", all, err)
+	}
+
+	found, err := storage.FindBookmark(ctx, deploy.ShortID())
+	if err != nil || found.ID != deploy.ID {
+		t.Errorf("FindBookmark(%q) = %+v, %v", deploy.ShortID(), found, err)
+	}
+	if _, err := storage.FindBookmark(ctx, "nope"); !errors.Is(err, ErrBookmarkNotFound) {
+		t.Errorf("FindBookmark(nope) error = %v, want ErrBookmarkNotFound", err)
+	}
+
+	if tagged, _ := storage.BookmarksWithTag(ctx, "K8S"); len(tagged) != 1 || tagged[0].Command != "kubectl get pods" {
+		t.Errorf("BookmarksWithTag(K8S) = %+v", tagged)
+	}
+	if results, _ := storage.SearchBookmarks(ctx, "release"); len(results) != 1 || results[0].ID != deploy.ID {
+		t.Errorf("SearchBookmarks(release) = %+v", results)
+	}
+
+	found.Tags = []string{"release"}
+	if err := storage.UpdateBookmark(ctx, *found); err != nil {
+		t.Fatalf("UpdateBookmark() error = %v", err)
+	}
+	if tagged, _ := storage.BookmarksWithTag(ctx, "deploy"); len(tagged) != 0 {
+		t.Errorf("UpdateBookmark() kept the old tags: %+v", tagged)
+	}
+
+	if err := storage.DeleteBookmark(ctx, deploy.ID); err != nil {
+		t.Fatalf("DeleteBookmark() error = %v", err)
+	}
+	if err := storage.DeleteBookmark(ctx, deploy.ID); !errors.Is(err, ErrBookmarkNotFound) {
+		t.Errorf("second DeleteBookmark() error = %v, want ErrBookmarkNotFound", err)
+	}
+}
+
+func TestBookmarkExportImport(t *testing.T) {
+	dir := t.TempDir()
+	source, err := NewStorage(filepath.Join(dir, "source.db"))
+	if err != nil {
+		t.Fatalf("NewStorage() error = %v", err)
+	}
+	defer source.Close()
+	ctx := context.Background()
+
+	if _, err := source.AddBookmark(ctx, "docker compose up -d", []string{"docker"}, "start the stack"); err != nil {
+		t.Fatalf("AddBookmark() error = %v", err)
+	}
+	// Bookmarks saved before tags had a single label
+	legacy := `{"id":"00000000000000000001","command":"make test","label":"ci","notes":"","created_at":"2024-01-01T00:00:00Z"}`
+	if err := source.db.Update(func(tx kvTx) error {
+		return tx.Bucket([]byte(bookmarkBucketName)).Put([]byte("00000000000000000001"), []byte(legacy))
+	}); err != nil {
+		t.Fatalf("writing legacy bookmark: %v", err)
+	}
+
+	path := filepath.Join(dir, "bookmarks.json")
+	if err := source.ExportBookmarks(ctx, path); err != nil {
+		t.Fatalf("ExportBookmarks() error = %v", err)
+	}
+
+	target, err := NewStorage(filepath.Join(dir, "target.db"))
+	if err != nil {
+		t.Fatalf("NewStorage() error = %v", err)
+	}
+	defer target.Close()
+	if _, err := target.AddBookmark(ctx, "make test", []string{"local"}, ""); err != nil {
+		t.Fatalf("AddBookmark() error = %v", err)
+	}
+
+	n, err := target.ImportBookmarks(ctx, path)
+	if err != nil || n != 2 {
+		t.Fatalf("ImportBookmarks() = %d, %v, want 2", n, err)
+	}
+	bookmarks, _ := target.GetBookmarks(ctx)
+	if len(bookmarks) != 2 {
+		t.Fatalf("after import GetBookmarks() = %+v, want 2 bookmarks", bookmarks)
+	}
+	for _, b := range bookmarks {
+		switch b.Command {
+		case "make test":
+			if !reflect.DeepEqual(b.Tags, []string{"local", "ci"}) {
+				t.Errorf("merged tags = %q, want local and ci", b.Tags)
+			}
+		case "docker compose up -d":
+			if b.Notes != "start the stack" || !b.HasTag("docker") {
+				t.Errorf("imported bookmark = %+v", b)
+			}
+		}
+	}
+}
@@ -14,6 +14,8 @@ import (
 	"sync/atomic"
 	"time"
 
+	"wut/internal/logger"
+	"wut/internal/metrics"
 	"wut/internal/performance"
 )
 
@@ -71,6 +73,33 @@ type Page struct {
 	RawContent  string
 }
 
+// MoreInfoURL returns the documentation link from the page's
+// "> More information: <url>." line, or "" if there is none.
+func (p *Page) MoreInfoURL() string {
+	for line := range strings.SplitSeq(p.RawContent, "\n") {
+		if after, ok := strings.CutPrefix(strings.TrimSpace(line), "> "); ok {
+			if url := moreInfoLink(after); url != "" {
+				return url
+			}
+		}
+	}
+	return ""
+}
+
+// moreInfoLink extracts the URL from a TLDR "More information: <url>." line
+func moreInfoLink(line string) string {
+	after, ok := strings.CutPrefix(line, "More information:")
+	if !ok {
+		return ""
+	}
+	start := strings.Index(after, "<")
+	end := strings.LastIndex(after, ">")
+	if start < 0 || end <= start {
+		return ""
+	}
+	return strings.TrimSpace(after[start+1 : end])
+}
+
 // variableRe is used to format TLDR command examples
 var variableRe = regexp.MustCompile(`\{\{([^}]+)\}\}`)
 
@@ -219,11 +248,12 @@ func (c *Client) GetPage(ctx context.Context, command, platform string) (*Page,
 	// Check memory cache first
 	if c.cacheInMemory {
 		c.cacheMu.RLock()
-		if page, ok := c.memoryCache[cacheKey]; ok {
-			c.cacheMu.RUnlock()
+		page, ok := c.memoryCache[cacheKey]
+		c.cacheMu.RUnlock()
+		metrics.RecordCache("pages", ok)
+		if ok {
 			return page, nil
 		}
-		c.cacheMu.RUnlock()
 	}
 
 	// Check local storage second
@@ -297,6 +327,7 @@ func (c *Client) GetPage(ctx context.Context, command, platform string) (*Page,
 
 // SearchPages searches for TLDR pages across all platforms
 func (c *Client) SearchPages(ctx context.Context, query string) ([]Page, error) {
+	defer logger.With("tldr").Timed("search pages", "query", query)()
 	// Try local storage first if offline mode or auto-detect
 	if c.offlineMode.Load() || (c.autoDetect && !c.IsOnline(ctx)) {
 		if c.storage != nil {
@@ -410,6 +441,7 @@ func (c *Client) GetPageAnyPlatform(ctx context.Context, command string) (*Page,
 
 // fetch retrieves raw content from the given URL
 func (c *Client) fetch(ctx context.Context, url string) (string, error) {
+	defer logger.With("tldr").Timed("fetch", "url", url)()
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
@@ -442,71 +474,18 @@ func (c *Client) fetch(ctx context.Context, url string) (string, error) {
 	return string(body), nil
 }
 
-// parsePage parses raw markdown content into a Page struct
+// parsePage parses raw markdown content into a Page struct, keeping what
+// can be read of a page that does not follow the format
 func (c *Client) parsePage(content, name, platform, language string) *Page {
 	if language == "" {
 		language = "en"
 	}
-	page := &Page{
-		Name:       name,
-		Platform:   platform,
-		Language:   language,
-		RawContent: content,
-		Examples:   []Example{},
-	}
-
-	lines := strings.Split(content, "\n")
-	var inExample bool
-	var currentExample Example
-
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-
-		if line == "" {
-			continue
-		}
-
-		// Title line (starts with #)
-		if after, ok := strings.CutPrefix(line, "# "); ok {
-			page.Name = after
-			continue
-		}
-
-		// Description line (starts with >)
-		if after, ok := strings.CutPrefix(line, "> "); ok {
-			page.Description = after
-			continue
-		}
-
-		// Example description (starts with -)
-		if strings.HasPrefix(line, "- ") {
-			// Save previous example if exists
-			if currentExample.Command != "" {
-				page.Examples = append(page.Examples, currentExample)
-			}
-			currentExample = Example{
-				Description: strings.TrimPrefix(line, "- "),
-			}
-			inExample = true
-			continue
-		}
-
-		// Example command (starts with `)
-		if inExample && strings.HasPrefix(line, "`") && strings.HasSuffix(line, "`") {
-			cmd := strings.Trim(line, "`")
-			// Replace {{variable}} with <variable>
-			cmd = formatCommand(cmd)
-			currentExample.Command = cmd
-			inExample = false
-
-			// Save the example
-			if currentExample.Description != "" {
-				page.Examples = append(page.Examples, currentExample)
-				currentExample = Example{}
-			}
-		}
+	page, _ := parseTLDR(content)
+	if page.Name == "" {
+		page.Name = name
 	}
-
+	page.Platform = platform
+	page.Language = language
 	return page
 }
 
@@ -548,6 +527,7 @@ func (c *Client) GetAvailableCommands(ctx context.Context) ([]string, error) {
 
 // FindCommandMatches returns ranked command-name suggestions for a query.
 func (c *Client) FindCommandMatches(ctx context.Context, query string, limit int) ([]string, error) {
+	defer logger.With("tldr").Timed("search commands", "query", query)()
 	if limit <= 0 {
 		limit = 10
 	}
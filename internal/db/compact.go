@@ -0,0 +1,259 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/goccy/go-json"
+
+	"wut/internal/logger"
+)
+
+const (
+	prunedStatsBucket = "history_pruned_stats"
+	prunedStatsKey    = "stats"
+
+	// pruneHighWater is the share of the size limit that pruning aims for,
+	// so the next few commands do not trigger it again
+	pruneHighWater = 0.8
+
+	// leafElementSize is the header bbolt stores with each key and value,
+	// also a fair estimate of SQLite's per-row overhead
+	leafElementSize = 16
+
+	// largeImport is the batch size after which the size limit is checked
+	largeImport = 1000
+)
+
+// maxSize caps the database data in bytes; 0 means unlimited
+var maxSize int64
+
+// SetMaxSize caps the data held by databases opened for writing. When a
+// database grows past it, its oldest history entries are pruned and the file
+// is compacted. 0 means unlimited.
+func SetMaxSize(bytes int64) {
+	maxSize = bytes
+}
+
+// prunedStats keeps the statistics of pruned history entries so
+// GetHistoryStats still counts them
+type prunedStats struct {
+	Executions        int            `json:"executions"`
+	Commands          map[string]int `json:"commands"`
+	TimeDistribution  map[string]int `json:"time_distribution"`
+	OSDistribution    map[string]int `json:"os_distribution"`
+	ShellDistribution map[string]int `json:"shell_distribution"`
+}
+
+func newPrunedStats() *prunedStats {
+	return &prunedStats{
+		Commands:          make(map[string]int),
+		TimeDistribution:  make(map[string]int),
+		OSDistribution:    make(map[string]int),
+		ShellDistribution: make(map[string]int),
+	}
+}
+
+func (p *prunedStats) add(entry CommandExecution) {
+	ensureHistoryMetadata(&entry)
+	p.Executions++
+	p.Commands[entry.Command]++
+	p.TimeDistribution[timeOfDay(entry.Timestamp.Hour())]++
+	p.OSDistribution[entry.SourceOS]++
+	p.ShellDistribution[entry.Shell]++
+}
+
+// CompactResult reports what Compact did
+type CompactResult struct {
+	Pruned     int
+	SizeBefore int64
+	SizeAfter  int64
+}
+
+// Compact prunes the oldest history entries when the database holds more
+// than the size limit, then rewrites it into a fresh file to give freed pages
+// back to the file system.
+func (s *Storage) Compact(ctx context.Context) (*CompactResult, error) {
+	if s == nil || s.db == nil {
+		return nil, fmt.Errorf("storage not initialized")
+	}
+
+	before, err := s.fileSize()
+	if err != nil {
+		return nil, err
+	}
+	result := &CompactResult{SizeBefore: before}
+	if maxSize > 0 {
+		if result.Pruned, err = s.pruneToHighWater(ctx); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.compact(); err != nil {
+		return nil, err
+	}
+	result.SizeAfter, err = s.fileSize()
+	return result, err
+}
+
+// enforceMaxSize prunes and compacts the database once it holds more than
+// the size limit. Locked encrypted history is left alone until it is
+// unlocked.
+func (s *Storage) enforceMaxSize(ctx context.Context) error {
+	if maxSize <= 0 || s.db.IsReadOnly() || s.historyReady() != nil {
+		return nil
+	}
+	if s.db.UsedSize() <= maxSize {
+		return nil
+	}
+
+	// The page estimate in PruneHistory is rough, so measure again after
+	// each compaction
+	total := 0
+	var used int64
+	for range 3 {
+		pruned, err := s.pruneToHighWater(ctx)
+		if err != nil {
+			return err
+		}
+		total += pruned
+		if err := s.compact(); err != nil {
+			return err
+		}
+		if used = s.db.UsedSize(); pruned == 0 || used <= int64(float64(maxSize)*pruneHighWater) {
+			break
+		}
+	}
+
+	if total > 0 {
+		logger.With("db").Info("pruned old history to fit database.max_size",
+			"pruned", total, "size_bytes", used, "max_bytes", maxSize, "path", s.path)
+	}
+	return nil
+}
+
+// pruneToHighWater prunes the oldest history entries until the live data
+// fits under the high-water mark of the size limit
+func (s *Storage) pruneToHighWater(ctx context.Context) (int, error) {
+	return s.PruneHistory(ctx, s.db.LiveSize()-int64(float64(maxSize)*pruneHighWater))
+}
+
+// PruneHistory removes the oldest history entries until about bytes of
+// storage are freed and returns how many were removed. Their statistics are
+// kept, so GetHistoryStats is unchanged.
+func (s *Storage) PruneHistory(ctx context.Context, bytes int64) (int, error) {
+	if s == nil || s.db == nil {
+		return 0, fmt.Errorf("storage not initialized")
+	}
+	if err := s.historyReady(); err != nil {
+		return 0, err
+	}
+	if bytes <= 0 {
+		return 0, nil
+	}
+
+	pruned := 0
+	err := s.update(ctx, func(tx kvTx) error {
+		bucket := tx.Bucket([]byte(historyBucketName))
+		if bucket == nil {
+			return nil
+		}
+		stats, err := s.loadPrunedStats(tx)
+		if err != nil {
+			return err
+		}
+
+		var keys [][]byte
+		var freed int64
+		c := bucket.Cursor()
+		for k, v := c.First(); k != nil && freed < bytes; k, v = c.Next() {
+			var entry CommandExecution
+			if err := s.decodeHistory(v, &entry); err == nil {
+				stats.add(entry)
+			}
+			keys = append(keys, append([]byte(nil), k...))
+			freed += int64(len(k) + len(v) + leafElementSize)
+		}
+		for _, key := range keys {
+			if err := bucket.Delete(key); err != nil {
+				return err
+			}
+		}
+		pruned = len(keys)
+		if pruned == 0 {
+			return nil
+		}
+		return s.savePrunedStats(tx, stats)
+	})
+	return pruned, err
+}
+
+// loadPrunedStats reads the statistics of pruned entries, decrypting them
+// when the history is encrypted
+func (s *Storage) loadPrunedStats(tx kvTx) (*prunedStats, error) {
+	return loadPrunedStatsWith(tx, s.historyKey())
+}
+
+func loadPrunedStatsWith(tx kvTx, c *historyCipher) (*prunedStats, error) {
+	stats := newPrunedStats()
+	bucket := tx.Bucket([]byte(prunedStatsBucket))
+	if bucket == nil {
+		return stats, nil
+	}
+	data := bucket.Get([]byte(prunedStatsKey))
+	if data == nil {
+		return stats, nil
+	}
+	if c != nil {
+		text, err := c.open(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt pruned history stats: %w", err)
+		}
+		data = []byte(text)
+	}
+	if err := json.Unmarshal(data, stats); err != nil {
+		return nil, fmt.Errorf("failed to read pruned history stats: %w", err)
+	}
+	return stats, nil
+}
+
+func (s *Storage) savePrunedStats(tx kvTx, stats *prunedStats) error {
+	return savePrunedStatsWith(tx, s.historyKey(), stats)
+}
+
+func savePrunedStatsWith(tx kvTx, c *historyCipher, stats *prunedStats) error {
+	bucket, err := tx.CreateBucketIfNotExists([]byte(prunedStatsBucket))
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(stats)
+	if err != nil {
+		return err
+	}
+	if c != nil {
+		sealed, err := c.seal(string(data))
+		if err != nil {
+			return err
+		}
+		data = []byte(sealed)
+	}
+	return bucket.Put([]byte(prunedStatsKey), data)
+}
+
+// compact rewrites the database to give the space freed by deleted entries
+// back to the file system
+func (s *Storage) compact() error {
+	db, err := s.db.Compact(s.path)
+	if db != nil {
+		s.db = db
+	}
+	return err
+}
+
+func (s *Storage) fileSize() (int64, error) {
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
@@ -0,0 +1,111 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fillHistory adds n distinct commands, oldest first
+func fillHistory(t *testing.T, s *Storage, n int) {
+	t.Helper()
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	entries := make([]CommandExecution, n)
+	for i := range entries {
+		entries[i] = CommandExecution{
+			Command:   fmt.Sprintf("echo %d %s", i%50, strings.Repeat("x", 200)),
+			Timestamp: start.Add(time.Duration(i) * time.Minute),
+			SourceOS:  "linux",
+			Shell:     "bash",
+		}
+	}
+	if _, err := s.AddHistoryBatch(context.Background(), entries); err != nil {
+		t.Fatalf("AddHistoryBatch() error = %v", err)
+	}
+}
+
+func useMaxSize(t *testing.T, bytes int64) {
+	t.Helper()
+	old := maxSize
+	maxSize = bytes
+	t.Cleanup(func() { maxSize = old })
+}
+
+func TestCompactPrunesOldestAndKeepsStats(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wut.db")
+	ctx := context.Background()
+
+	storage, err := NewStorage(path)
+	if err != nil {
+		t.Fatalf("NewStorage() error = %v", err)
+	}
+	defer storage.Close()
+	fillHistory(t, storage, 5000)
+
+	want, err := storage.GetHistoryStats(ctx)
+	if err != nil {
+		t.Fatalf("GetHistoryStats() error = %v", err)
+	}
+
+	useMaxSize(t, 512*1024)
+	result, err := storage.Compact(ctx)
+	if err != nil {
+		t.Fatalf("Compact() error = %v", err)
+	}
+	if result.Pruned == 0 || result.SizeAfter > maxSize || result.SizeAfter >= result.SizeBefore {
+		t.Fatalf("Compact() = %+v, want pruned entries and a file under %d bytes", result, maxSize)
+	}
+
+	history, err := storage.GetAllHistory(ctx)
+	if err != nil {
+		t.Fatalf("GetAllHistory() error = %v", err)
+	}
+	if len(history) != 5000-result.Pruned {
+		t.Errorf("%d entries left, want %d", len(history), 5000-result.Pruned)
+	}
+	if !strings.HasPrefix(history[len(history)-1].Command, fmt.Sprintf("echo %d ", result.Pruned%50)) {
+		t.Errorf("oldest remaining entry = %q, want the oldest ones pruned", history[len(history)-1].Command[:10])
+	}
+
+	got, err := storage.GetHistoryStats(ctx)
+	if err != nil {
+		t.Fatalf("GetHistoryStats() error = %v", err)
+	}
+	if got.TotalExecutions != want.TotalExecutions || got.UniqueCommands != want.UniqueCommands ||
+		got.MostUsedCount != want.MostUsedCount || got.OSDistribution["linux"] != want.OSDistribution["linux"] ||
+		got.TimeDistribution["Morning (06:00-12:00)"] != want.TimeDistribution["Morning (06:00-12:00)"] {
+		t.Errorf("GetHistoryStats() after pruning = %+v, want %+v", got, want)
+	}
+}
+
+func TestNewStorageEnforcesMaxSize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wut.db")
+
+	storage, err := NewStorage(path)
+	if err != nil {
+		t.Fatalf("NewStorage() error = %v", err)
+	}
+	fillHistory(t, storage, 3000)
+	storage.Close()
+
+	useMaxSize(t, 256*1024)
+	storage, err = NewStorage(path)
+	if err != nil {
+		t.Fatalf("NewStorage() error = %v", err)
+	}
+	defer storage.Close()
+	if size, _ := storage.fileSize(); size > maxSize {
+		t.Errorf("database is %d bytes after opening, want at most %d", size, maxSize)
+	}
+	if stats, _ := storage.GetHistoryStats(context.Background()); stats.TotalExecutions != 3000 {
+		t.Errorf("TotalExecutions = %d, want 3000", stats.TotalExecutions)
+	}
+
+	useMaxSize(t, 0)
+	if err := storage.enforceMaxSize(context.Background()); err != nil {
+		t.Errorf("enforceMaxSize() with no limit error = %v", err)
+	}
+}
@@ -0,0 +1,538 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/goccy/go-json"
+)
+
+const (
+	encryptionBucketName = "encryption"
+	encryptionHeaderKey  = "header"
+
+	// PassphraseEnv supplies the encryption passphrase to non-interactive runs
+	PassphraseEnv = "WUT_PASSPHRASE"
+
+	encryptedPrefix = "enc:v1:"
+	encryptionKDF   = "pbkdf2-sha256"
+	encryptionCheck = "wut"
+	encryptionKey   = 32 // AES-256
+)
+
+// kdfIterations is the PBKDF2 work factor for new headers
+var kdfIterations = 600_000
+
+var (
+	// ErrWrongPassphrase is returned when a passphrase does not match the key
+	// the data was encrypted with
+	ErrWrongPassphrase = errors.New("wrong passphrase")
+
+	// ErrEncryptionLocked is returned when the history is encrypted and no
+	// passphrase or cached key is available
+	ErrEncryptionLocked = errors.New("history is encrypted and locked")
+)
+
+// EncryptionHeader holds what is needed to derive the key from the
+// passphrase. It is stored in the database and in encrypted exports, so the
+// data can be opened anywhere with the passphrase alone.
+type EncryptionHeader struct {
+	Version    int    `json:"version"`
+	KDF        string `json:"kdf"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+	Check      string `json:"check"` // a known value sealed with the key
+}
+
+// historyCipher encrypts history text with AES-GCM
+type historyCipher struct {
+	key  []byte
+	aead cipher.AEAD
+}
+
+// encryptedExport is the file written by ExportHistory when the history is
+// encrypted
+type encryptedExport struct {
+	Encryption *EncryptionHeader `json:"wut_encryption"`
+	Data       string            `json:"data"`
+}
+
+func newEncryptionHeader() (*EncryptionHeader, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+	return &EncryptionHeader{Version: 1, KDF: encryptionKDF, Iterations: kdfIterations, Salt: salt}, nil
+}
+
+// keyID names the key in the OS keyring
+func (h *EncryptionHeader) keyID() string {
+	return "history-" + hex.EncodeToString(h.Salt[:8])
+}
+
+// derive turns a passphrase into a cipher and checks it against the header.
+// A header without a check value gets one.
+func (h *EncryptionHeader) derive(passphrase string) (*historyCipher, error) {
+	if h.KDF != encryptionKDF || h.Iterations <= 0 || len(h.Salt) < 8 {
+		return nil, fmt.Errorf("unsupported encryption header (kdf %q)", h.KDF)
+	}
+	key, err := pbkdf2.Key(sha256.New, passphrase, h.Salt, h.Iterations, encryptionKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+	return h.withKey(key)
+}
+
+// withKey builds a cipher from a derived key and checks it against the header
+func (h *EncryptionHeader) withKey(key []byte) (*historyCipher, error) {
+	c, err := newHistoryCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if h.Check == "" {
+		if h.Check, err = c.seal(encryptionCheck); err != nil {
+			return nil, err
+		}
+		return c, nil
+	}
+	if check, err := c.open(h.Check); err != nil || check != encryptionCheck {
+		return nil, ErrWrongPassphrase
+	}
+	return c, nil
+}
+
+func newHistoryCipher(key []byte) (*historyCipher, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &historyCipher{key: key, aead: aead}, nil
+}
+
+// seal encrypts text into a printable value
+func (c *historyCipher) seal(text string) (string, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := c.aead.Seal(nonce, nonce, []byte(text), nil)
+	return encryptedPrefix + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// open decrypts a value made by seal. Text without the prefix was never
+// encrypted and is returned as is.
+func (c *historyCipher) open(value string) (string, error) {
+	encoded, ok := strings.CutPrefix(value, encryptedPrefix)
+	if !ok {
+		return value, nil
+	}
+	sealed, err := base64.RawStdEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < c.aead.NonceSize() {
+		return "", fmt.Errorf("corrupt encrypted value")
+	}
+	nonce, data := sealed[:c.aead.NonceSize()], sealed[c.aead.NonceSize():]
+	text, err := c.aead.Open(nil, nonce, data, nil)
+	if err != nil {
+		return "", ErrWrongPassphrase
+	}
+	return string(text), nil
+}
+
+// IsEncrypted reports whether the history in this database is encrypted
+func (s *Storage) IsEncrypted() bool {
+	return s.encryption != nil
+}
+
+// loadEncryption reads the encryption header. The key is only looked up
+// when the history is first read or written, so opening the database for
+// something else does not start the keyring CLI.
+func (s *Storage) loadEncryption() error {
+	var header *EncryptionHeader
+	err := s.db.View(func(tx kvTx) error {
+		bucket := tx.Bucket([]byte(encryptionBucketName))
+		if bucket == nil {
+			return nil
+		}
+		data := bucket.Get([]byte(encryptionHeaderKey))
+		if data == nil {
+			return nil
+		}
+		header = &EncryptionHeader{}
+		return json.Unmarshal(data, header)
+	})
+	if err != nil || header == nil {
+		return err
+	}
+	s.encryption = header
+	return nil
+}
+
+// historyKey returns the cipher of encrypted history, unlocking it on first
+// use with the key remembered by RememberKeys, the key cached in the OS
+// keyring or the passphrase in WUT_PASSPHRASE. It is nil while the history
+// is locked or not encrypted.
+func (s *Storage) historyKey() *historyCipher {
+	s.keyMu.Lock()
+	defer s.keyMu.Unlock()
+	if s.encryption == nil || s.keyLoaded {
+		return s.cipher
+	}
+	s.keyLoaded = true
+
+	header := s.encryption
+	if key, ok := rememberedKey(header.keyID()); ok {
+		if c, err := header.withKey(key); err == nil {
+			s.cipher = c
+			return c
+		}
+	}
+	if cached, err := keyring.Get(header.keyID()); err == nil {
+		if key, err := base64.StdEncoding.DecodeString(cached); err == nil {
+			if c, err := header.withKey(key); err == nil {
+				s.cipher = c
+				rememberKey(header.keyID(), key)
+				return c
+			}
+		}
+	}
+	if passphrase := os.Getenv(PassphraseEnv); passphrase != "" {
+		s.cipher, s.lockErr = header.derive(passphrase)
+		if s.cipher != nil {
+			rememberKey(header.keyID(), s.cipher.key)
+		}
+		return s.cipher
+	}
+	s.lockErr = ErrEncryptionLocked
+	return nil
+}
+
+// setKey replaces the encryption header and cipher after the history was
+// unlocked, encrypted, decrypted or re-keyed
+func (s *Storage) setKey(header *EncryptionHeader, c *historyCipher) {
+	s.keyMu.Lock()
+	defer s.keyMu.Unlock()
+	s.encryption, s.cipher, s.lockErr, s.keyLoaded = header, c, nil, true
+	if c != nil {
+		rememberKey(header.keyID(), c.key)
+	}
+}
+
+// keyMemo holds the keys unlocked so far when RememberKeys is on
+var keyMemo struct {
+	sync.Mutex
+	keys map[string][]byte
+}
+
+// RememberKeys keeps the keys of encrypted history in memory once they are
+// unlocked, for a long-running process such as the daemon that opens the
+// database for every request and would otherwise ask the OS keyring each
+// time.
+func RememberKeys() {
+	keyMemo.Lock()
+	defer keyMemo.Unlock()
+	if keyMemo.keys == nil {
+		keyMemo.keys = make(map[string][]byte)
+	}
+}
+
+func rememberedKey(id string) ([]byte, bool) {
+	keyMemo.Lock()
+	defer keyMemo.Unlock()
+	key, ok := keyMemo.keys[id]
+	return key, ok
+}
+
+func rememberKey(id string, key []byte) {
+	keyMemo.Lock()
+	defer keyMemo.Unlock()
+	if keyMemo.keys != nil {
+		keyMemo.keys[id] = key
+	}
+}
+
+func forgetKey(id string) {
+	keyMemo.Lock()
+	defer keyMemo.Unlock()
+	delete(keyMemo.keys, id)
+}
+
+// Unlock opens encrypted history with its passphrase and caches the key in
+// the OS keyring when one is available
+func (s *Storage) Unlock(passphrase string) error {
+	if s.encryption == nil {
+		return nil
+	}
+	c, err := s.encryption.derive(passphrase)
+	if err != nil {
+		return err
+	}
+	s.setKey(s.encryption, c)
+	_ = keyring.Set(s.encryption.keyID(), base64.StdEncoding.EncodeToString(c.key))
+	return nil
+}
+
+// EnableEncryption encrypts the history with a key derived from passphrase.
+// When migrate is set, the entries already stored are encrypted too; it
+// returns how many were. Command sequences index commands by their text, so
+// they are dropped and not kept while encryption is on.
+func (s *Storage) EnableEncryption(ctx context.Context, passphrase string, migrate bool) (int, error) {
+	if s.encryption != nil {
+		return 0, fmt.Errorf("history is already encrypted")
+	}
+	header, err := newEncryptionHeader()
+	if err != nil {
+		return 0, err
+	}
+	c, err := header.derive(passphrase)
+	if err != nil {
+		return 0, err
+	}
+
+	var migrated int
+	err = s.update(ctx, func(tx kvTx) error {
+		if migrate {
+			n, err := recodeHistory(tx, nil, c)
+			if err != nil {
+				return err
+			}
+			migrated = n
+		}
+		_ = tx.DeleteBucket([]byte(sequenceBucketName))
+		return putEncryptionHeader(tx, header)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	s.setKey(header, c)
+	_ = keyring.Set(header.keyID(), base64.StdEncoding.EncodeToString(c.key))
+	return migrated, nil
+}
+
+// DisableEncryption decrypts the history back to plaintext and returns how
+// many entries were decrypted. The history must be unlocked.
+func (s *Storage) DisableEncryption(ctx context.Context) (int, error) {
+	if s.encryption == nil {
+		return 0, nil
+	}
+	if err := s.historyReady(); err != nil {
+		return 0, err
+	}
+
+	var decrypted int
+	err := s.update(ctx, func(tx kvTx) error {
+		n, err := recodeHistory(tx, s.historyKey(), nil)
+		if err != nil {
+			return err
+		}
+		decrypted = n
+		return tx.DeleteBucket([]byte(encryptionBucketName))
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	_ = keyring.Delete(s.encryption.keyID())
+	forgetKey(s.encryption.keyID())
+	s.setKey(nil, nil)
+	return decrypted, nil
+}
+
+// ChangePassphrase re-encrypts the history under a new passphrase with a
+// fresh salt. The history must be unlocked.
+func (s *Storage) ChangePassphrase(ctx context.Context, passphrase string) error {
+	if s.encryption == nil {
+		return fmt.Errorf("history is not encrypted")
+	}
+	if err := s.historyReady(); err != nil {
+		return err
+	}
+	header, err := newEncryptionHeader()
+	if err != nil {
+		return err
+	}
+	c, err := header.derive(passphrase)
+	if err != nil {
+		return err
+	}
+
+	err = s.update(ctx, func(tx kvTx) error {
+		if _, err := recodeHistory(tx, s.historyKey(), c); err != nil {
+			return err
+		}
+		return putEncryptionHeader(tx, header)
+	})
+	if err != nil {
+		return err
+	}
+
+	_ = keyring.Delete(s.encryption.keyID())
+	forgetKey(s.encryption.keyID())
+	s.setKey(header, c)
+	_ = keyring.Set(header.keyID(), base64.StdEncoding.EncodeToString(c.key))
+	return nil
+}
+
+// historyReady returns why the history cannot be read or written, if it
+// cannot
+func (s *Storage) historyReady() error {
+	if s.encryption == nil || s.historyKey() != nil {
+		return nil
+	}
+	if errors.Is(s.lockErr, ErrWrongPassphrase) {
+		return fmt.Errorf("cannot unlock the history: %s does not hold the right passphrase: %w", PassphraseEnv, s.lockErr)
+	}
+	return fmt.Errorf("%w: set %s or run 'wut config --set privacy.encrypt_data true' to unlock it", ErrEncryptionLocked, PassphraseEnv)
+}
+
+// encodeHistory marshals an entry, encrypting its command when the history
+// is encrypted
+func (s *Storage) encodeHistory(entry CommandExecution) ([]byte, error) {
+	if c := s.historyKey(); c != nil {
+		sealed, err := c.seal(entry.Command)
+		if err != nil {
+			return nil, err
+		}
+		entry.Command = sealed
+	}
+	return json.Marshal(entry)
+}
+
+// decodeHistory unmarshals an entry written by encodeHistory
+func (s *Storage) decodeHistory(data []byte, entry *CommandExecution) error {
+	return decodeHistoryWith(s.historyKey(), data, entry)
+}
+
+func decodeHistoryWith(c *historyCipher, data []byte, entry *CommandExecution) error {
+	if err := json.Unmarshal(data, entry); err != nil {
+		return err
+	}
+	if c == nil || !strings.HasPrefix(entry.Command, encryptedPrefix) {
+		return nil
+	}
+	command, err := c.open(entry.Command)
+	if err != nil {
+		return err
+	}
+	entry.Command = command
+	return nil
+}
+
+// recodeHistory rewrites every history entry from one cipher to another; a
+// nil cipher means plaintext
+func recodeHistory(tx kvTx, from, to *historyCipher) (int, error) {
+	bucket := tx.Bucket([]byte(historyBucketName))
+	if bucket == nil {
+		return 0, nil
+	}
+
+	recoded := make(map[string][]byte)
+	err := bucket.ForEach(func(k, v []byte) error {
+		var entry CommandExecution
+		if err := decodeHistoryWith(from, v, &entry); err != nil {
+			return fmt.Errorf("failed to decrypt history entry %s: %w", k, err)
+		}
+		if to != nil {
+			sealed, err := to.seal(entry.Command)
+			if err != nil {
+				return err
+			}
+			entry.Command = sealed
+		}
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		recoded[string(k)] = data
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for k, v := range recoded {
+		if err := bucket.Put([]byte(k), v); err != nil {
+			return 0, err
+		}
+	}
+
+	if tx.Bucket([]byte(prunedStatsBucket)) != nil {
+		stats, err := loadPrunedStatsWith(tx, from)
+		if err != nil {
+			return 0, err
+		}
+		if err := savePrunedStatsWith(tx, to, stats); err != nil {
+			return 0, err
+		}
+	}
+	return len(recoded), nil
+}
+
+func putEncryptionHeader(tx kvTx, header *EncryptionHeader) error {
+	bucket, err := tx.CreateBucketIfNotExists([]byte(encryptionBucketName))
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(header)
+	if err != nil {
+		return err
+	}
+	return bucket.Put([]byte(encryptionHeaderKey), data)
+}
+
+// sealExport wraps exported history in an encrypted envelope that carries
+// the header, so it can be imported with the passphrase alone
+func (s *Storage) sealExport(data []byte) ([]byte, error) {
+	sealed, err := s.historyKey().seal(string(data))
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(encryptedExport{Encryption: s.encryption, Data: sealed}, "", "  ")
+}
+
+// openExport returns the plaintext of an exported history file, decrypting
+// it when it is encrypted. The key of this database is tried first, then
+// the passphrase in WUT_PASSPHRASE.
+func (s *Storage) openExport(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		return data, nil
+	}
+	var envelope encryptedExport
+	if err := json.Unmarshal(data, &envelope); err != nil || envelope.Encryption == nil {
+		return nil, fmt.Errorf("failed to parse history: not a wut history export")
+	}
+
+	var c *historyCipher
+	if own := s.historyKey(); own != nil && bytes.Equal(s.encryption.Salt, envelope.Encryption.Salt) {
+		c = own
+	} else if passphrase := os.Getenv(PassphraseEnv); passphrase != "" {
+		var err error
+		if c, err = envelope.Encryption.derive(passphrase); err != nil {
+			return nil, fmt.Errorf("cannot decrypt the export: %w", err)
+		}
+	} else {
+		return nil, fmt.Errorf("the export is encrypted: set %s to its passphrase", PassphraseEnv)
+	}
+
+	text, err := c.open(envelope.Data)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decrypt the export: %w", err)
+	}
+	return []byte(text), nil
+}
@@ -0,0 +1,303 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// memoryKeyring stands in for the OS keyring
+type memoryKeyring map[string]string
+
+func (m memoryKeyring) Get(account string) (string, error) {
+	if secret, ok := m[account]; ok {
+		return secret, nil
+	}
+	return "", errNoKeyring
+}
+
+func (m memoryKeyring) Set(account, secret string) error {
+	m[account] = secret
+	return nil
+}
+
+func (m memoryKeyring) Delete(account string) error {
+	delete(m, account)
+	return nil
+}
+
+// useTestKeyring swaps in an empty keyring and a cheap KDF for one test
+func useTestKeyring(t *testing.T) memoryKeyring {
+	t.Helper()
+	ring := memoryKeyring{}
+	oldRing, oldIterations := keyring, kdfIterations
+	keyring, kdfIterations = ring, 1000
+	t.Cleanup(func() { keyring, kdfIterations = oldRing, oldIterations })
+	t.Setenv(PassphraseEnv, "")
+	return ring
+}
+
+// rawHistory returns the stored bytes of every history entry
+func rawHistory(t *testing.T, s *Storage) []byte {
+	t.Helper()
+	var raw []byte
+	if err := s.db.View(func(tx kvTx) error {
+		return tx.Bucket([]byte(historyBucketName)).ForEach(func(_, v []byte) error {
+			raw = append(raw, v...)
+			return nil
+		})
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func TestEncryptionRoundTrip(t *testing.T) {
+	ring := useTestKeyring(t)
+	path := filepath.Join(t.TempDir(), "wut.db")
+	ctx := context.Background()
+
+	storage, err := NewStorage(path)
+	if err != nil {
+		t.Fatalf("NewStorage() error = %v", err)
+	}
+	if err := storage.AddHistory(ctx, "export TOKEN=secret"); err != nil {
+		t.Fatalf("AddHistory() error = %v", err)
+	}
+
+	migrated, err := storage.EnableEncryption(ctx, "correct horse", true)
+	if err != nil || migrated != 1 {
+		t.Fatalf("EnableEncryption() = %d, %v, want 1 entry migrated", migrated, err)
+	}
+	if err := storage.AddHistory(ctx, "curl -H 'Authorization: secret'"); err != nil {
+		t.Fatalf("AddHistory() encrypted error = %v", err)
+	}
+	if raw := rawHistory(t, storage); bytes.Contains(raw, []byte("secret")) {
+		t.Errorf("history is stored in plaintext: %s", raw)
+	}
+	if history, _ := storage.GetAllHistory(ctx); len(history) != 2 || history[1].Command != "export TOKEN=secret" {
+		t.Errorf("GetAllHistory() = %+v, want both commands decrypted", history)
+	}
+	storage.Close()
+
+	// The key cached in the keyring unlocks the history on the next open
+	storage, err = NewStorage(path)
+	if err != nil {
+		t.Fatalf("NewStorage() error = %v", err)
+	}
+	if history, err := storage.GetAllHistory(ctx); err != nil || len(history) != 2 {
+		t.Errorf("GetAllHistory() with a cached key = %d entries, %v", len(history), err)
+	}
+	storage.Close()
+
+	// Without the key the history is locked, not garbage
+	clear(ring)
+	storage, err = NewStorage(path)
+	if err != nil {
+		t.Fatalf("NewStorage() error = %v", err)
+	}
+	defer storage.Close()
+	if _, err := storage.GetAllHistory(ctx); !errors.Is(err, ErrEncryptionLocked) {
+		t.Errorf("GetAllHistory() without a key error = %v, want ErrEncryptionLocked", err)
+	}
+	if err := storage.Unlock("wrong horse"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("Unlock(wrong) error = %v, want ErrWrongPassphrase", err)
+	}
+	if err := storage.Unlock("correct horse"); err != nil {
+		t.Fatalf("Unlock() error = %v", err)
+	}
+
+	decrypted, err := storage.DisableEncryption(ctx)
+	if err != nil || decrypted != 2 {
+		t.Fatalf("DisableEncryption() = %d, %v, want 2", decrypted, err)
+	}
+	if storage.IsEncrypted() || !bytes.Contains(rawHistory(t, storage), []byte("export TOKEN=secret")) {
+		t.Error("DisableEncryption() left the history encrypted")
+	}
+}
+
+func TestEncryptionWrongPassphraseFromEnv(t *testing.T) {
+	ring := useTestKeyring(t)
+	path := filepath.Join(t.TempDir(), "wut.db")
+	ctx := context.Background()
+
+	storage, err := NewStorage(path)
+	if err != nil {
+		t.Fatalf("NewStorage() error = %v", err)
+	}
+	if _, err := storage.EnableEncryption(ctx, "right", false); err != nil {
+		t.Fatalf("EnableEncryption() error = %v", err)
+	}
+	storage.Close()
+	clear(ring)
+
+	t.Setenv(PassphraseEnv, "wrong")
+	storage, err = NewStorage(path)
+	if err != nil {
+		t.Fatalf("NewStorage() error = %v", err)
+	}
+	defer storage.Close()
+	if err := storage.AddHistory(ctx, "ls"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("AddHistory() with a wrong passphrase error = %v, want ErrWrongPassphrase", err)
+	}
+}
+
+func TestChangePassphrase(t *testing.T) {
+	ring := useTestKeyring(t)
+	path := filepath.Join(t.TempDir(), "wut.db")
+	ctx := context.Background()
+
+	storage, err := NewStorage(path)
+	if err != nil {
+		t.Fatalf("NewStorage() error = %v", err)
+	}
+	if _, err := storage.EnableEncryption(ctx, "old", false); err != nil {
+		t.Fatalf("EnableEncryption() error = %v", err)
+	}
+	if err := storage.AddHistory(ctx, "git push"); err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.ChangePassphrase(ctx, "new"); err != nil {
+		t.Fatalf("ChangePassphrase() error = %v", err)
+	}
+	if len(ring) != 1 {
+		t.Errorf("keyring holds %d keys after rotation, want 1", len(ring))
+	}
+	storage.Close()
+	clear(ring)
+
+	storage, err = NewStorage(path)
+	if err != nil {
+		t.Fatalf("NewStorage() error = %v", err)
+	}
+	defer storage.Close()
+	if err := storage.Unlock("old"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("Unlock(old) error = %v, want ErrWrongPassphrase", err)
+	}
+	if err := storage.Unlock("new"); err != nil {
+		t.Fatalf("Unlock(new) error = %v", err)
+	}
+	if history, _ := storage.GetAllHistory(ctx); len(history) != 1 || history[0].Command != "git push" {
+		t.Errorf("history after rotation = %+v", history)
+	}
+}
+
+func TestEncryptedExportImport(t *testing.T) {
+	ring := useTestKeyring(t)
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	source, err := NewStorage(filepath.Join(dir, "source.db"))
+	if err != nil {
+		t.Fatalf("NewStorage() error = %v", err)
+	}
+	defer source.Close()
+	if _, err := source.EnableEncryption(ctx, "pass", false); err != nil {
+		t.Fatalf("EnableEncryption() error = %v", err)
+	}
+	if err := source.AddHistory(ctx, "ssh admin@secret-host"); err != nil {
+		t.Fatal(err)
+	}
+	export := filepath.Join(dir, "history.json")
+	if err := source.ExportHistory(ctx, export); err != nil {
+		t.Fatalf("ExportHistory() error = %v", err)
+	}
+	if data, _ := os.ReadFile(export); bytes.Contains(data, []byte("secret-host")) {
+		t.Errorf("export is in plaintext: %s", data)
+	}
+	clear(ring)
+
+	target, err := NewStorage(filepath.Join(dir, "target.db"))
+	if err != nil {
+		t.Fatalf("NewStorage() error = %v", err)
+	}
+	defer target.Close()
+	if err := target.ImportHistory(ctx, export); err == nil {
+		t.Fatal("ImportHistory() without a passphrase succeeded")
+	}
+	t.Setenv(PassphraseEnv, "nope")
+	if err := target.ImportHistory(ctx, export); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("ImportHistory() with a wrong passphrase error = %v, want ErrWrongPassphrase", err)
+	}
+	t.Setenv(PassphraseEnv, "pass")
+	if err := target.ImportHistory(ctx, export); err != nil {
+		t.Fatalf("ImportHistory() error = %v", err)
+	}
+	if history, _ := target.GetAllHistory(ctx); len(history) != 1 || history[0].Command != "ssh admin@secret-host" {
+		t.Errorf("imported history = %+v", history)
+	}
+}
+
+// countingKeyring counts the lookups that would start the keyring CLI
+type countingKeyring struct {
+	memoryKeyring
+	gets int
+}
+
+func (c *countingKeyring) Get(account string) (string, error) {
+	c.gets++
+	return c.memoryKeyring.Get(account)
+}
+
+func TestEncryptionKeyLoadedOnFirstUse(t *testing.T) {
+	ring := &countingKeyring{memoryKeyring: useTestKeyring(t)}
+	keyring = ring
+	path := filepath.Join(t.TempDir(), "wut.db")
+	ctx := context.Background()
+
+	storage, err := NewStorage(path)
+	if err != nil {
+		t.Fatalf("NewStorage() error = %v", err)
+	}
+	if _, err := storage.EnableEncryption(ctx, "pass", false); err != nil {
+		t.Fatalf("EnableEncryption() error = %v", err)
+	}
+	if err := storage.AddHistory(ctx, "git push"); err != nil {
+		t.Fatal(err)
+	}
+	storage.Close()
+
+	// Opening the database and reading pages leaves the keyring alone
+	open := func() *Storage {
+		t.Helper()
+		storage, err := OpenReadOnly(path, time.Second)
+		if err != nil {
+			t.Fatalf("OpenReadOnly() error = %v", err)
+		}
+		return storage
+	}
+	storage = open()
+	if _, err := storage.CountPages(); err != nil {
+		t.Fatal(err)
+	}
+	if ring.gets != 0 {
+		t.Errorf("keyring was asked %d times before the history was read", ring.gets)
+	}
+	if history, err := storage.GetAllHistory(ctx); err != nil || len(history) != 1 {
+		t.Fatalf("GetAllHistory() = %d entries, %v", len(history), err)
+	}
+	// A second read reuses the key of this open
+	_, _ = storage.GetAllHistory(ctx)
+	storage.Close()
+	if ring.gets != 1 {
+		t.Errorf("keyring was asked %d times, want once per open", ring.gets)
+	}
+
+	// With RememberKeys, later opens reuse the key without the keyring
+	t.Cleanup(func() { keyMemo.keys = nil })
+	RememberKeys()
+	for range 3 {
+		storage = open()
+		if history, err := storage.GetAllHistory(ctx); err != nil || len(history) != 1 {
+			t.Fatalf("GetAllHistory() = %d entries, %v", len(history), err)
+		}
+		storage.Close()
+	}
+	if ring.gets != 2 {
+		t.Errorf("keyring was asked %d times, want once more after RememberKeys", ring.gets)
+	}
+}
@@ -0,0 +1,297 @@
+package db
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+// Storage engines a database can be kept in
+const (
+	EngineBolt   = "bbolt"
+	EngineSQLite = "sqlite"
+)
+
+// Engines lists the storage engines, the default first
+var Engines = []string{EngineBolt, EngineSQLite}
+
+// sqliteHeader starts every SQLite database file
+var sqliteHeader = []byte("SQLite format 3\x00")
+
+// errLockTimeout is returned by openKV when another process holds the
+// database for longer than the timeout
+var errLockTimeout = errors.New("timed out waiting for the database lock")
+
+// newEngine is the engine databases that do not exist yet are created with
+var newEngine = EngineBolt
+
+// SetEngine picks the storage engine new databases are created with. A
+// database that already exists keeps its engine until it is migrated with
+// MigrateDatabase. Unknown engines are ignored.
+func SetEngine(name string) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for _, e := range Engines {
+		if name == e {
+			newEngine = e
+			return
+		}
+	}
+}
+
+// DatabaseEngine returns the engine of the database at path, read from the
+// file itself, or the engine it would be created with when it does not
+// exist yet.
+func DatabaseEngine(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return newEngine
+	}
+	defer f.Close()
+
+	header := make([]byte, len(sqliteHeader))
+	if _, err := io.ReadFull(f, header); err == nil && bytes.Equal(header, sqliteHeader) {
+		return EngineSQLite
+	}
+	return EngineBolt
+}
+
+// kvDB is what Storage is written against: named buckets of keys kept in
+// byte order, read and written in transactions. bbolt provides it directly;
+// the SQLite engine keeps the same buckets in a table.
+type kvDB interface {
+	View(fn func(kvTx) error) error
+	Update(fn func(kvTx) error) error
+	// Batch is Update for callers that may run concurrently; fn can be
+	// called more than once
+	Batch(fn func(kvTx) error) error
+	IsReadOnly() bool
+	Close() error
+	Engine() string
+
+	// UsedSize is the size of the data file without preallocated space
+	UsedSize() int64
+	// LiveSize is the size of the live data, about what Compact leaves
+	LiveSize() int64
+	// Compact rewrites the database at path to give freed space back and
+	// returns the handle to use from then on
+	Compact(path string) (kvDB, error)
+	// WriteTo writes a consistent copy of the database file to w
+	WriteTo(w io.Writer) (int64, error)
+}
+
+// kvTx is a transaction of a kvDB
+type kvTx interface {
+	// Bucket returns the named bucket, or nil when it does not exist
+	Bucket(name []byte) kvBucket
+	CreateBucket(name []byte) (kvBucket, error)
+	CreateBucketIfNotExists(name []byte) (kvBucket, error)
+	DeleteBucket(name []byte) error
+	ForEach(fn func(name []byte, b kvBucket) error) error
+	// ID changes with every committed write
+	ID() int
+}
+
+// kvBucket is a bucket of keys in byte order. Keys and values it returns
+// are only valid until the transaction ends.
+type kvBucket interface {
+	Get(key []byte) []byte
+	Put(key, value []byte) error
+	Delete(key []byte) error
+	ForEach(fn func(k, v []byte) error) error
+	Cursor() kvCursor
+	// KeyN is the number of keys in the bucket
+	KeyN() int
+}
+
+// kvCursor walks a bucket in key order. A nil key means it ran off either
+// end.
+type kvCursor interface {
+	First() (key, value []byte)
+	Last() (key, value []byte)
+	Next() (key, value []byte)
+	Prev() (key, value []byte)
+	Seek(seek []byte) (key, value []byte)
+}
+
+// openKV opens the database at path with the engine it was written with,
+// creating it with the engine picked by SetEngine when it does not exist.
+// timeout bounds how long it waits for another process to release the
+// database.
+func openKV(path string, timeout time.Duration, readOnly bool) (kvDB, error) {
+	return openKVEngine(DatabaseEngine(path), path, timeout, readOnly)
+}
+
+// openKVEngine opens the database at path with the given engine
+func openKVEngine(engine, path string, timeout time.Duration, readOnly bool) (kvDB, error) {
+	switch engine {
+	case EngineSQLite:
+		return openSQLite(path, timeout, readOnly)
+	case EngineBolt:
+		return openBolt(path, timeout, readOnly)
+	default:
+		return nil, fmt.Errorf("unknown storage engine %q", engine)
+	}
+}
+
+// ── bbolt ────────────────────────────────────────────────────────────────────
+
+type boltDB struct {
+	db *bbolt.DB
+}
+
+func openBolt(path string, timeout time.Duration, readOnly bool) (kvDB, error) {
+	db, err := bbolt.Open(path, 0600, &bbolt.Options{
+		Timeout:  timeout,
+		ReadOnly: readOnly,
+	})
+	if errors.Is(err, bbolt.ErrTimeout) {
+		return nil, errLockTimeout
+	}
+	if err != nil {
+		return nil, err
+	}
+	return boltDB{db: db}, nil
+}
+
+func (d boltDB) View(fn func(kvTx) error) error {
+	return d.db.View(func(tx *bbolt.Tx) error { return fn(boltTx{tx}) })
+}
+
+func (d boltDB) Update(fn func(kvTx) error) error {
+	return d.db.Update(func(tx *bbolt.Tx) error { return fn(boltTx{tx}) })
+}
+
+func (d boltDB) Batch(fn func(kvTx) error) error {
+	return d.db.Batch(func(tx *bbolt.Tx) error { return fn(boltTx{tx}) })
+}
+
+func (d boltDB) IsReadOnly() bool { return d.db.IsReadOnly() }
+func (d boltDB) Close() error     { return d.db.Close() }
+func (d boltDB) Engine() string   { return EngineBolt }
+
+// UsedSize returns the bytes used by pages up to the high-water mark, which
+// unlike the file size leaves out the space bbolt preallocates
+func (d boltDB) UsedSize() int64 {
+	var used int64
+	_ = d.db.View(func(tx *bbolt.Tx) error {
+		used = tx.Size()
+		return nil
+	})
+	return used
+}
+
+// LiveSize walks every page, so it is only called once the database is too
+// big
+func (d boltDB) LiveSize() int64 {
+	var live int64
+	_ = d.db.View(func(tx *bbolt.Tx) error {
+		return tx.ForEach(func(_ []byte, b *bbolt.Bucket) error {
+			stats := b.Stats()
+			live += int64(stats.BranchInuse + stats.LeafInuse)
+			return nil
+		})
+	})
+	return live
+}
+
+// Compact copies the database into a new file and swaps it in. The handle
+// is closed either way; the one returned is open on whichever file ended up
+// at path.
+func (d boltDB) Compact(path string) (kvDB, error) {
+	tmpPath := path + ".compact"
+	_ = os.Remove(tmpPath)
+	dst, err := bbolt.Open(tmpPath, 0600, &bbolt.Options{Timeout: lockTimeout})
+	if err != nil {
+		return d, fmt.Errorf("failed to compact database: %w", err)
+	}
+	if err := bbolt.Compact(dst, d.db, 0); err != nil {
+		dst.Close()
+		os.Remove(tmpPath)
+		return d, fmt.Errorf("failed to compact database: %w", err)
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(tmpPath)
+		return d, fmt.Errorf("failed to compact database: %w", err)
+	}
+
+	if err := d.db.Close(); err != nil {
+		os.Remove(tmpPath)
+		return d, fmt.Errorf("failed to compact database: %w", err)
+	}
+	renameErr := os.Rename(tmpPath, path)
+	reopened, err := openBolt(path, lockTimeout, false)
+	if err != nil {
+		return nil, openError(path, err)
+	}
+	if renameErr != nil {
+		os.Remove(tmpPath)
+		return reopened, fmt.Errorf("failed to replace database with its compacted copy: %w", renameErr)
+	}
+	return reopened, nil
+}
+
+func (d boltDB) WriteTo(w io.Writer) (int64, error) {
+	var n int64
+	err := d.db.View(func(tx *bbolt.Tx) error {
+		var err error
+		n, err = tx.WriteTo(w)
+		return err
+	})
+	return n, err
+}
+
+type boltTx struct {
+	tx *bbolt.Tx
+}
+
+func (t boltTx) Bucket(name []byte) kvBucket {
+	if b := t.tx.Bucket(name); b != nil {
+		return boltBucket{b}
+	}
+	return nil
+}
+
+func (t boltTx) CreateBucket(name []byte) (kvBucket, error) {
+	b, err := t.tx.CreateBucket(name)
+	if err != nil {
+		return nil, err
+	}
+	return boltBucket{b}, nil
+}
+
+func (t boltTx) CreateBucketIfNotExists(name []byte) (kvBucket, error) {
+	b, err := t.tx.CreateBucketIfNotExists(name)
+	if err != nil {
+		return nil, err
+	}
+	return boltBucket{b}, nil
+}
+
+func (t boltTx) DeleteBucket(name []byte) error {
+	return t.tx.DeleteBucket(name)
+}
+
+func (t boltTx) ForEach(fn func(name []byte, b kvBucket) error) error {
+	return t.tx.ForEach(func(name []byte, b *bbolt.Bucket) error {
+		return fn(name, boltBucket{b})
+	})
+}
+
+func (t boltTx) ID() int { return t.tx.ID() }
+
+type boltBucket struct {
+	b *bbolt.Bucket
+}
+
+func (b boltBucket) Get(key []byte) []byte                    { return b.b.Get(key) }
+func (b boltBucket) Put(key, value []byte) error              { return b.b.Put(key, value) }
+func (b boltBucket) Delete(key []byte) error                  { return b.b.Delete(key) }
+func (b boltBucket) ForEach(fn func(k, v []byte) error) error { return b.b.ForEach(fn) }
+func (b boltBucket) Cursor() kvCursor                         { return b.b.Cursor() }
+func (b boltBucket) KeyN() int                                { return b.b.Stats().KeyN }
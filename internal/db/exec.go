@@ -0,0 +1,174 @@
+package db
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+	"time"
+	"unicode/utf16"
+)
+
+// ExecResult describes how a command launched by ExecuteCommand finished
+type ExecResult struct {
+	Command  string
+	Shell    string
+	ExitCode int
+	Duration time.Duration
+	Signaled bool
+	Signal   string
+	NotFound bool
+}
+
+// ExitError is returned when an executed command finishes unsuccessfully.
+// Callers can use the exit code to mirror the command's status.
+type ExitError struct {
+	Result *ExecResult
+}
+
+func (e *ExitError) Error() string {
+	switch {
+	case e.Result.NotFound:
+		return fmt.Sprintf("command not found: %s", e.Result.Command)
+	case e.Result.Signaled:
+		return fmt.Sprintf("command killed by signal %s", e.Result.Signal)
+	default:
+		return fmt.Sprintf("command exited with status %d", e.Result.ExitCode)
+	}
+}
+
+// Placeholder is a <placeholder> in a TLDR example command
+type Placeholder struct {
+	Name    string // text between the angle brackets
+	Default string // first option of a <a|b> choice, empty otherwise
+}
+
+// Placeholders returns the placeholders of an example command in order
+func Placeholders(cmd string) []Placeholder {
+	var placeholders []Placeholder
+	scanPlaceholders(cmd, func(p Placeholder) string {
+		placeholders = append(placeholders, p)
+		return ""
+	})
+	return placeholders
+}
+
+// FillPlaceholders replaces the i-th placeholder with values[i]. Placeholders
+// without a non-empty value fall back to their default, so choices keep
+// their first option and plain placeholders are removed.
+func FillPlaceholders(cmd string, values []string) string {
+	i := 0
+	result := scanPlaceholders(cmd, func(p Placeholder) string {
+		value := ""
+		if i < len(values) {
+			value = strings.TrimSpace(values[i])
+		}
+		i++
+		if value == "" {
+			return p.Default
+		}
+		return value
+	})
+	return strings.TrimSpace(result)
+}
+
+// scanPlaceholders rewrites each <placeholder> in cmd with replace's result
+func scanPlaceholders(cmd string, replace func(Placeholder) string) string {
+	var b strings.Builder
+	rest := cmd
+	for {
+		start := strings.Index(rest, "<")
+		if start == -1 {
+			break
+		}
+		end := strings.Index(rest[start:], ">")
+		if end == -1 {
+			break
+		}
+		end += start
+
+		p := Placeholder{Name: rest[start+1 : end]}
+		// A choice uses its first option, without [ and ]
+		if before, _, ok := strings.Cut(p.Name, "|"); ok {
+			p.Default = strings.Trim(strings.TrimSpace(before), "[]")
+		}
+
+		b.WriteString(rest[:start])
+		b.WriteString(replace(p))
+		rest = rest[end+1:]
+	}
+	b.WriteString(rest)
+	return b.String()
+}
+
+// ExecuteCommand runs a command through the user's shell with inherited stdio.
+// Placeholders are stripped first. A non-nil result is returned whenever the
+// command was started; a non-zero exit is reported as *ExitError.
+func ExecuteCommand(ctx context.Context, cmd string) (*ExecResult, error) {
+	cleanCmd := cleanCommand(cmd)
+
+	command, shell := shellCommand(ctx, cleanCmd)
+	command.Stdout = os.Stdout
+	command.Stderr = os.Stderr
+	command.Stdin = os.Stdin
+
+	result := &ExecResult{
+		Command: cleanCmd,
+		Shell:   shell,
+	}
+
+	start := time.Now()
+	err := command.Run()
+	result.Duration = time.Since(start)
+
+	if err == nil {
+		return result, nil
+	}
+
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return nil, fmt.Errorf("failed to start %s: %w", shell, err)
+	}
+
+	result.ExitCode = exitErr.ExitCode()
+	if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		result.Signaled = true
+		result.Signal = status.Signal().String()
+		// Follow the shell convention of 128+signal
+		result.ExitCode = 128 + int(status.Signal())
+	}
+	if ctx.Err() != nil && !result.Signaled {
+		result.Signaled = true
+		result.Signal = ctx.Err().Error()
+	}
+	result.NotFound = isNotFoundExit(result.ExitCode)
+
+	return result, &ExitError{Result: result}
+}
+
+// powerShellArgs returns the arguments that run cmd in PowerShell. Commands
+// with quotes or line breaks do not survive Windows argument quoting intact,
+// so they are passed base64-encoded through -EncodedCommand instead.
+func powerShellArgs(cmd string) []string {
+	if !strings.ContainsAny(cmd, "\"\r\n") {
+		return []string{"-NoProfile", "-Command", cmd}
+	}
+	units := utf16.Encode([]rune(cmd))
+	buf := make([]byte, 2*len(units))
+	for i, u := range units {
+		binary.LittleEndian.PutUint16(buf[2*i:], u)
+	}
+	return []string{"-NoProfile", "-EncodedCommand", base64.StdEncoding.EncodeToString(buf)}
+}
+
+// cmdExeCommandLine returns the raw cmd.exe command line that runs cmd. /S
+// strips only the outer quotes, so inner quotes and operators such as & and
+// | reach cmd.exe as typed.
+func cmdExeCommandLine(cmd string) string {
+	return `cmd.exe /S /C "` + cmd + `"`
+}
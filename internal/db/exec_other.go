@@ -0,0 +1,23 @@
+//go:build !windows
+
+package db
+
+import (
+	"context"
+	"os"
+	"os/exec"
+)
+
+// shellCommand builds the invocation of the user's $SHELL (or /bin/sh)
+func shellCommand(ctx context.Context, cmd string) (*exec.Cmd, string) {
+	shell := os.Getenv("SHELL")
+	if shell == "" {
+		shell = "/bin/sh"
+	}
+	return exec.CommandContext(ctx, shell, "-c", cmd), shell
+}
+
+// isNotFoundExit reports the POSIX shell "command not found" status
+func isNotFoundExit(code int) bool {
+	return code == 127
+}
@@ -0,0 +1,66 @@
+package db
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"runtime"
+	"slices"
+	"testing"
+	"unicode/utf16"
+)
+
+func TestPowerShellArgs(t *testing.T) {
+	if got := powerShellArgs("Get-ChildItem -Force"); !slices.Equal(got, []string{"-NoProfile", "-Command", "Get-ChildItem -Force"}) {
+		t.Errorf("powerShellArgs() of a simple command = %q", got)
+	}
+
+	cmd := "Write-Output \"a & b\"\nGet-Date # ü"
+	got := powerShellArgs(cmd)
+	if len(got) != 3 || got[1] != "-EncodedCommand" {
+		t.Fatalf("powerShellArgs() of a quoted command = %q, want -EncodedCommand", got)
+	}
+	raw, err := base64.StdEncoding.DecodeString(got[2])
+	if err != nil || len(raw)%2 != 0 {
+		t.Fatalf("encoded command %q is not base64 UTF-16: %v", got[2], err)
+	}
+	units := make([]uint16, len(raw)/2)
+	for i := range units {
+		units[i] = uint16(raw[2*i]) | uint16(raw[2*i+1])<<8
+	}
+	if decoded := string(utf16.Decode(units)); decoded != cmd {
+		t.Errorf("encoded command decodes to %q, want %q", decoded, cmd)
+	}
+}
+
+func TestCmdExeCommandLine(t *testing.T) {
+	got := cmdExeCommandLine(`echo "a & b" & dir "C:\Program Files"`)
+	want := `cmd.exe /S /C "echo "a & b" & dir "C:\Program Files""`
+	if got != want {
+		t.Errorf("cmdExeCommandLine() = %q, want %q", got, want)
+	}
+}
+
+func TestExecuteCommandExitStatus(t *testing.T) {
+	result, err := ExecuteCommand(context.Background(), "exit 3")
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) {
+		t.Fatalf("ExecuteCommand(exit 3) error = %v, want *ExitError", err)
+	}
+	if exitErr.Result != result || result.ExitCode != 3 || result.Signaled || result.NotFound {
+		t.Errorf("ExecuteCommand(exit 3) result = %+v, want exit code 3", result)
+	}
+
+	if result, err := ExecuteCommand(context.Background(), "exit 0"); err != nil || result.ExitCode != 0 {
+		t.Errorf("ExecuteCommand(exit 0) = %+v, %v; want success", result, err)
+	}
+
+	if runtime.GOOS == "windows" {
+		return
+	}
+	// A signal is reported the way the shell reports it, as 128+signal
+	result, err = ExecuteCommand(context.Background(), "kill -TERM $$")
+	if !errors.As(err, &exitErr) || !result.Signaled || result.ExitCode != 128+15 {
+		t.Errorf("ExecuteCommand(kill -TERM $$) = %+v, %v; want signaled with status 143", result, err)
+	}
+}
@@ -0,0 +1,32 @@
+//go:build windows
+
+package db
+
+import (
+	"context"
+	"os/exec"
+	"syscall"
+)
+
+// shellCommand prefers PowerShell 7 (pwsh), then Windows PowerShell, then cmd.exe
+func shellCommand(ctx context.Context, cmd string) (*exec.Cmd, string) {
+	for _, shell := range []string{"pwsh", "powershell"} {
+		if path, err := exec.LookPath(shell); err == nil {
+			return exec.CommandContext(ctx, path, powerShellArgs(cmd)...), shell
+		}
+	}
+
+	// cmd.exe does not follow the usual argv quoting rules, so pass the raw
+	// command line to keep inner quotes intact.
+	command := exec.CommandContext(ctx, "cmd.exe")
+	command.SysProcAttr = &syscall.SysProcAttr{
+		CmdLine: cmdExeCommandLine(cmd),
+	}
+	return command, "cmd"
+}
+
+// isNotFoundExit reports "command not found" for cmd.exe (9009) and shells
+// that follow the POSIX convention.
+func isNotFoundExit(code int) bool {
+	return code == 9009 || code == 127
+}
@@ -0,0 +1,33 @@
+//go:build windows
+
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestExecuteCommandWindows(t *testing.T) {
+	tests := []struct {
+		name string
+		cmd  string
+		code int
+	}{
+		{"exit code", "exit 3", 3},
+		{"quotes and ampersand", `if ("a & b" -eq 'a & b') { exit 4 }`, 4},
+		{"multi-line", "$n = 5\nexit $n", 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ExecuteCommand(context.Background(), tt.cmd)
+			var exitErr *ExitError
+			if !errors.As(err, &exitErr) {
+				t.Fatalf("ExecuteCommand(%q) error = %v, want *ExitError", tt.cmd, err)
+			}
+			if result.ExitCode != tt.code {
+				t.Errorf("ExecuteCommand(%q) exit code = %d, want %d (shell %s)", tt.cmd, result.ExitCode, tt.code, result.Shell)
+			}
+		})
+	}
+}
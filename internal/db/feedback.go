@@ -0,0 +1,102 @@
+package db
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/goccy/go-json"
+)
+
+const feedbackBucketName = "correction_feedback"
+
+// CorrectionFeedback counts how often a correction was accepted or rejected
+type CorrectionFeedback struct {
+	Accepted int       `json:"accepted"`
+	Rejected int       `json:"rejected"`
+	LastSeen time.Time `json:"last_seen"`
+}
+
+// feedbackKey identifies a correction by a hash of the original and
+// corrected commands, so the bucket holds no command text
+func feedbackKey(original, corrected string) []byte {
+	sum := sha256.Sum256([]byte(original + "\x00" + corrected))
+	return []byte(hex.EncodeToString(sum[:]))
+}
+
+// RecordCorrectionFeedback counts a correction as accepted or rejected
+func (s *Storage) RecordCorrectionFeedback(ctx context.Context, original, corrected string, accepted bool) error {
+	if s == nil || s.db == nil {
+		return fmt.Errorf("storage not initialized")
+	}
+
+	return s.update(ctx, func(tx kvTx) error {
+		bucket, err := tx.CreateBucketIfNotExists([]byte(feedbackBucketName))
+		if err != nil {
+			return err
+		}
+
+		key := feedbackKey(original, corrected)
+		var feedback CorrectionFeedback
+		if data := bucket.Get(key); data != nil {
+			if err := json.Unmarshal(data, &feedback); err != nil {
+				return fmt.Errorf("failed to decode correction feedback: %w", err)
+			}
+		}
+		if accepted {
+			feedback.Accepted++
+		} else {
+			feedback.Rejected++
+		}
+		feedback.LastSeen = time.Now()
+
+		data, err := json.Marshal(feedback)
+		if err != nil {
+			return fmt.Errorf("failed to marshal correction feedback: %w", err)
+		}
+		return bucket.Put(key, data)
+	})
+}
+
+// GetCorrectionFeedback returns the feedback recorded for a correction; the
+// counts are zero when there is none
+func (s *Storage) GetCorrectionFeedback(ctx context.Context, original, corrected string) (CorrectionFeedback, error) {
+	var feedback CorrectionFeedback
+	if s == nil || s.db == nil {
+		return feedback, fmt.Errorf("storage not initialized")
+	}
+
+	err := s.view(ctx, func(tx kvTx) error {
+		bucket := tx.Bucket([]byte(feedbackBucketName))
+		if bucket == nil {
+			return nil
+		}
+		data := bucket.Get(feedbackKey(original, corrected))
+		if data == nil {
+			return nil
+		}
+		return json.Unmarshal(data, &feedback)
+	})
+	return feedback, err
+}
+
+// ResetCorrectionFeedback forgets all recorded feedback and returns how many
+// corrections it had been recorded for
+func (s *Storage) ResetCorrectionFeedback(ctx context.Context) (int, error) {
+	if s == nil || s.db == nil {
+		return 0, fmt.Errorf("storage not initialized")
+	}
+
+	count := 0
+	err := s.update(ctx, func(tx kvTx) error {
+		bucket := tx.Bucket([]byte(feedbackBucketName))
+		if bucket == nil {
+			return nil
+		}
+		count = bucket.KeyN()
+		return tx.DeleteBucket([]byte(feedbackBucketName))
+	})
+	return count, err
+}
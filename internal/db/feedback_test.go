@@ -0,0 +1,41 @@
+package db
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestCorrectionFeedback(t *testing.T) {
+	storage, err := NewStorage(filepath.Join(t.TempDir(), "wut.db"))
+	if err != nil {
+		t.Fatalf("NewStorage() error = %v", err)
+	}
+	defer storage.Close()
+	ctx := context.Background()
+
+	for _, accepted := range []bool{true, true, false} {
+		if err := storage.RecordCorrectionFeedback(ctx, "gti status", "git status", accepted); err != nil {
+			t.Fatalf("RecordCorrectionFeedback() error = %v", err)
+		}
+	}
+	if err := storage.RecordCorrectionFeedback(ctx, "sl", "ls", false); err != nil {
+		t.Fatal(err)
+	}
+
+	feedback, err := storage.GetCorrectionFeedback(ctx, "gti status", "git status")
+	if err != nil || feedback.Accepted != 2 || feedback.Rejected != 1 || feedback.LastSeen.IsZero() {
+		t.Errorf("GetCorrectionFeedback() = %+v, %v, want 2 accepted and 1 rejected", feedback, err)
+	}
+	if feedback, _ := storage.GetCorrectionFeedback(ctx, "gti", "git"); feedback.Accepted+feedback.Rejected != 0 {
+		t.Errorf("GetCorrectionFeedback() for an unseen correction = %+v", feedback)
+	}
+
+	reset, err := storage.ResetCorrectionFeedback(ctx)
+	if err != nil || reset != 2 {
+		t.Fatalf("ResetCorrectionFeedback() = %d, %v, want 2", reset, err)
+	}
+	if feedback, _ := storage.GetCorrectionFeedback(ctx, "gti status", "git status"); feedback.Accepted != 0 {
+		t.Errorf("feedback survived a reset: %+v", feedback)
+	}
+}
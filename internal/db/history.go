@@ -6,16 +6,17 @@ import (
 	"fmt"
 	"math"
 	"os"
+	"path/filepath"
 	"runtime"
 	"sort"
 	"strings"
 	"time"
 
 	"github.com/goccy/go-json"
-	"go.etcd.io/bbolt"
 
 	"wut/internal/commandsearch"
 	"wut/internal/historyml"
+	"wut/internal/metrics"
 	"wut/internal/performance"
 	shellmeta "wut/internal/shell"
 )
@@ -35,6 +36,10 @@ type CommandExecution struct {
 	SessionID string    `json:"session_id"`
 	SourceOS  string    `json:"source_os,omitempty"`
 	Shell     string    `json:"source_shell,omitempty"`
+	ExitCode  int       `json:"exit_code,omitempty"`
+	Duration  int64     `json:"duration_ms,omitempty"`
+	Timed     bool      `json:"timed,omitempty"`    // ExitCode and Duration were recorded
+	Imported  bool      `json:"imported,omitempty"` // read from a shell history file; Dir is unknown
 }
 
 // HistoryCommandSummary represents aggregated history for a single command.
@@ -44,6 +49,18 @@ type HistoryCommandSummary struct {
 	LastUsed    time.Time
 	SourceOS    string
 	SourceShell string
+	// Runs counts the executions whose exit status was recorded, and
+	// Failures those of them that exited non-zero
+	Runs     int
+	Failures int
+}
+
+// FailureRate is the share of the recorded runs of the command that failed
+func (s HistoryCommandSummary) FailureRate() float64 {
+	if s.Runs == 0 {
+		return 0
+	}
+	return float64(s.Failures) / float64(s.Runs)
 }
 
 // HistoryStats represents statistics computed from the raw execution log
@@ -56,8 +73,38 @@ type HistoryStats struct {
 	TimeDistribution  map[string]int
 	OSDistribution    map[string]int
 	ShellDistribution map[string]int
+
+	// TimedExecutions counts the executions whose exit status and duration
+	// were recorded, and FailedExecutions those of them that failed
+	TimedExecutions  int
+	FailedExecutions int
+	// SlowestCommands and FailingCommands rank the commands by average
+	// duration and by failure rate
+	SlowestCommands []TimingStat
+	FailingCommands []TimingStat
 }
 
+// TimingStat summarizes the recorded runs of a command
+type TimingStat struct {
+	Command     string
+	Runs        int
+	Failures    int
+	AvgDuration time.Duration
+	MaxDuration time.Duration
+}
+
+// FailureRate is the share of the runs that failed
+func (t TimingStat) FailureRate() float64 {
+	if t.Runs == 0 {
+		return 0
+	}
+	return float64(t.Failures) / float64(t.Runs)
+}
+
+// minFailingRuns is how many recorded runs a command needs before its
+// failure rate is reported
+const minFailingRuns = 3
+
 // HistoryImportState tracks incremental shell-history import progress.
 type HistoryImportState struct {
 	ImportedCount int       `json:"imported_count"`
@@ -105,15 +152,44 @@ func (s *Storage) AddHistory(ctx context.Context, command string) error {
 	return err
 }
 
+// trackTiming keeps the exit status and duration of recorded executions
+var trackTiming = true
+
+// SetTrackTiming sets whether history keeps the exit status and duration of
+// the commands it records, as history.track_timing
+func SetTrackTiming(enabled bool) {
+	trackTiming = enabled
+}
+
+// RecordExecution logs a command run by WUT together with its exit code and
+// duration.
+func (s *Storage) RecordExecution(ctx context.Context, result *ExecResult) error {
+	if result == nil {
+		return nil
+	}
+
+	_, err := s.AddHistoryBatch(ctx, []CommandExecution{{
+		Command:   result.Command,
+		Timestamp: time.Now().Add(-result.Duration),
+		ExitCode:  result.ExitCode,
+		Duration:  result.Duration.Milliseconds(),
+		Timed:     true,
+	}})
+	return err
+}
+
 // GetHistory retrieves command execution logs, newest first
 func (s *Storage) GetHistory(ctx context.Context, limit int) ([]CommandExecution, error) {
 	if s == nil || s.db == nil {
 		return nil, fmt.Errorf("storage not initialized")
 	}
+	if err := s.historyReady(); err != nil {
+		return nil, err
+	}
 
 	var entries []CommandExecution
 
-	err := s.db.View(func(tx *bbolt.Tx) error {
+	err := s.view(ctx, func(tx kvTx) error {
 		bucket := tx.Bucket([]byte(historyBucketName))
 		if bucket == nil {
 			return nil
@@ -124,7 +200,7 @@ func (s *Storage) GetHistory(ctx context.Context, limit int) ([]CommandExecution
 		count := 0
 		for k, v := c.Last(); k != nil; k, v = c.Prev() {
 			var entry CommandExecution
-			if err := json.Unmarshal(v, &entry); err == nil {
+			if err := s.decodeHistory(v, &entry); err == nil {
 				ensureHistoryMetadata(&entry)
 				entries = append(entries, entry)
 				count++
@@ -162,9 +238,13 @@ func (s *Storage) SearchHistory(ctx context.Context, query string, limit int) ([
 // SearchHistoryMatches searches the raw execution log and returns ranked raw
 // matches so callers can reuse the same retrieval path as `wut history`.
 func (s *Storage) SearchHistoryMatches(ctx context.Context, query string, limit int) ([]HistorySearchMatch, error) {
+	defer metrics.ObserveLatency(metrics.LatencySearch, time.Now())
 	if s == nil || s.db == nil {
 		return nil, fmt.Errorf("storage not initialized")
 	}
+	if err := s.historyReady(); err != nil {
+		return nil, err
+	}
 
 	query = strings.TrimSpace(query)
 	if query == "" {
@@ -191,7 +271,7 @@ func (s *Storage) SearchHistoryMatches(ctx context.Context, query string, limit
 	commandStats := make(map[string]*HistoryCommandSummary)
 	scanRank := 0
 
-	err := s.db.View(func(tx *bbolt.Tx) error {
+	err := s.view(ctx, func(tx kvTx) error {
 		bucket := tx.Bucket([]byte(historyBucketName))
 		if bucket == nil {
 			return nil
@@ -204,7 +284,7 @@ func (s *Storage) SearchHistoryMatches(ctx context.Context, query string, limit
 			}
 
 			var entry CommandExecution
-			if err := json.Unmarshal(v, &entry); err != nil {
+			if err := s.decodeHistory(v, &entry); err != nil {
 				continue
 			}
 			ensureHistoryMetadata(&entry)
@@ -251,6 +331,13 @@ func (s *Storage) SearchHistoryMatches(ctx context.Context, query string, limit
 		results[i].score += historyRankBoost(results[i].entry, summary, ranker)
 	}
 
+	// Commands boosted or buried with wut train scale their score
+	if biases, err := s.CommandBiasMultipliers(ctx); err == nil && len(biases) > 0 {
+		for i := range results {
+			results[i].score *= biases.Multiplier(results[i].entry.Command)
+		}
+	}
+
 	sort.SliceStable(results, func(i, j int) bool {
 		return historyResultLess(results[i], results[j])
 	})
@@ -275,6 +362,9 @@ func (s *Storage) AddHistoryBatch(ctx context.Context, entries []CommandExecutio
 	if s == nil || s.db == nil {
 		return 0, fmt.Errorf("storage not initialized")
 	}
+	if err := s.historyReady(); err != nil {
+		return 0, err
+	}
 
 	prepared := make([]CommandExecution, 0, len(entries))
 	now := time.Now()
@@ -290,12 +380,18 @@ func (s *Storage) AddHistoryBatch(ctx context.Context, entries []CommandExecutio
 		if entry.Command == "" {
 			continue
 		}
+		if anonymize {
+			entry.Command = RedactSecrets(entry.Command)
+		}
+		if !trackTiming {
+			entry.ExitCode, entry.Duration, entry.Timed = 0, 0, false
+		}
 		if entry.Timestamp.IsZero() {
 			entry.Timestamp = now.Add(time.Duration(i) * time.Nanosecond)
 		} else {
 			entry.Timestamp = entry.Timestamp.Add(time.Duration(i) * time.Nanosecond)
 		}
-		if entry.Dir == "" {
+		if entry.Dir == "" && !entry.Imported {
 			entry.Dir = dir
 		}
 		if entry.SessionID == "" {
@@ -315,14 +411,20 @@ func (s *Storage) AddHistoryBatch(ctx context.Context, entries []CommandExecutio
 		return 0, nil
 	}
 
-	err := s.db.Update(func(tx *bbolt.Tx) error {
+	err := s.update(ctx, func(tx kvTx) error {
+		if s.encryption == nil {
+			if err := recordSequences(tx, prepared); err != nil {
+				return err
+			}
+		}
+
 		bucket, err := tx.CreateBucketIfNotExists([]byte(historyBucketName))
 		if err != nil {
 			return err
 		}
 
 		for _, entry := range prepared {
-			data, err := json.Marshal(entry)
+			data, err := s.encodeHistory(entry)
 			if err != nil {
 				return fmt.Errorf("failed to marshal command execution: %w", err)
 			}
@@ -337,6 +439,11 @@ func (s *Storage) AddHistoryBatch(ctx context.Context, entries []CommandExecutio
 		return 0, err
 	}
 
+	if len(prepared) >= largeImport {
+		if err := s.enforceMaxSize(ctx); err != nil {
+			return len(prepared), err
+		}
+	}
 	return len(prepared), nil
 }
 
@@ -350,13 +457,13 @@ func (s *Storage) TrimHistory(ctx context.Context, maxEntries int) error {
 		return nil
 	}
 
-	return s.db.Update(func(tx *bbolt.Tx) error {
+	return s.update(ctx, func(tx kvTx) error {
 		bucket := tx.Bucket([]byte(historyBucketName))
 		if bucket == nil {
 			return nil
 		}
 
-		removeCount := bucket.Stats().KeyN - maxEntries
+		removeCount := bucket.KeyN() - maxEntries
 		if removeCount <= 0 {
 			return nil
 		}
@@ -386,6 +493,9 @@ func (s *Storage) GetRecentUniqueHistory(ctx context.Context, limit int, scanLim
 	if s == nil || s.db == nil {
 		return nil, fmt.Errorf("storage not initialized")
 	}
+	if err := s.historyReady(); err != nil {
+		return nil, err
+	}
 	if limit <= 0 {
 		limit = 20
 	}
@@ -394,7 +504,7 @@ func (s *Storage) GetRecentUniqueHistory(ctx context.Context, limit int, scanLim
 	seen := make(map[string]struct{}, limit)
 	scanned := 0
 
-	err := s.db.View(func(tx *bbolt.Tx) error {
+	err := s.view(ctx, func(tx kvTx) error {
 		bucket := tx.Bucket([]byte(historyBucketName))
 		if bucket == nil {
 			return nil
@@ -407,7 +517,7 @@ func (s *Storage) GetRecentUniqueHistory(ctx context.Context, limit int, scanLim
 			}
 
 			var entry CommandExecution
-			if err := json.Unmarshal(v, &entry); err != nil {
+			if err := s.decodeHistory(v, &entry); err != nil {
 				continue
 			}
 			ensureHistoryMetadata(&entry)
@@ -449,11 +559,14 @@ func (s *Storage) GetHistoryCommandSummaries(ctx context.Context, scanLimit int)
 	if s == nil || s.db == nil {
 		return nil, fmt.Errorf("storage not initialized")
 	}
+	if err := s.historyReady(); err != nil {
+		return nil, err
+	}
 
 	summaries := make(map[string]*HistoryCommandSummary)
 	scanned := 0
 
-	err := s.db.View(func(tx *bbolt.Tx) error {
+	err := s.view(ctx, func(tx kvTx) error {
 		bucket := tx.Bucket([]byte(historyBucketName))
 		if bucket == nil {
 			return nil
@@ -466,7 +579,7 @@ func (s *Storage) GetHistoryCommandSummaries(ctx context.Context, scanLimit int)
 			}
 
 			var entry CommandExecution
-			if err := json.Unmarshal(v, &entry); err != nil {
+			if err := s.decodeHistory(v, &entry); err != nil {
 				continue
 			}
 			ensureHistoryMetadata(&entry)
@@ -506,12 +619,106 @@ func (s *Storage) GetHistoryCommandSummaries(ctx context.Context, scanLimit int)
 	return results, nil
 }
 
+// DirectoryUsage counts how often a command ran in a directory and anywhere
+// inside its project.
+type DirectoryUsage struct {
+	InDir     int
+	InProject int
+}
+
+// GetDirectoryUsage counts, per command, the executions recorded in dir and
+// under projectRoot. projectRoot may be empty. Entries without a recorded
+// directory are skipped.
+func (s *Storage) GetDirectoryUsage(ctx context.Context, dir, projectRoot string, scanLimit int) (map[string]DirectoryUsage, error) {
+	if s == nil || s.db == nil {
+		return nil, fmt.Errorf("storage not initialized")
+	}
+	if err := s.historyReady(); err != nil {
+		return nil, err
+	}
+
+	dir = cleanDir(dir)
+	projectRoot = cleanDir(projectRoot)
+	usage := make(map[string]DirectoryUsage)
+	if dir == "" && projectRoot == "" {
+		return usage, nil
+	}
+
+	scanned := 0
+	err := s.view(ctx, func(tx kvTx) error {
+		bucket := tx.Bucket([]byte(historyBucketName))
+		if bucket == nil {
+			return nil
+		}
+
+		c := bucket.Cursor()
+		for k, v := c.Last(); k != nil; k, v = c.Prev() {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			scanned++
+			if scanLimit > 0 && scanned > scanLimit {
+				break
+			}
+
+			var entry CommandExecution
+			if err := s.decodeHistory(v, &entry); err != nil || entry.Imported {
+				continue
+			}
+			entryDir := cleanDir(entry.Dir)
+			command := strings.TrimSpace(entry.Command)
+			if entryDir == "" || command == "" {
+				continue
+			}
+
+			inDir := dir != "" && entryDir == dir
+			inProject := inDir || (projectRoot != "" && isWithinDir(projectRoot, entryDir))
+			if !inProject {
+				continue
+			}
+
+			u := usage[command]
+			if inDir {
+				u.InDir++
+			}
+			u.InProject++
+			usage[command] = u
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return usage, nil
+}
+
+func cleanDir(dir string) string {
+	dir = strings.TrimSpace(dir)
+	if dir == "" {
+		return ""
+	}
+	return filepath.Clean(dir)
+}
+
+// isWithinDir reports whether path is root or one of its descendants
+func isWithinDir(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
+
 // GetCommandUsageCount counts how often an exact command appears in history.
 // If stopAt is positive, the scan stops early once the count reaches that value.
 func (s *Storage) GetCommandUsageCount(ctx context.Context, command string, stopAt int) (int, error) {
 	if s == nil || s.db == nil {
 		return 0, fmt.Errorf("storage not initialized")
 	}
+	if err := s.historyReady(); err != nil {
+		return 0, err
+	}
 
 	command = strings.TrimSpace(command)
 	if command == "" {
@@ -519,7 +726,7 @@ func (s *Storage) GetCommandUsageCount(ctx context.Context, command string, stop
 	}
 
 	count := 0
-	err := s.db.View(func(tx *bbolt.Tx) error {
+	err := s.view(ctx, func(tx kvTx) error {
 		bucket := tx.Bucket([]byte(historyBucketName))
 		if bucket == nil {
 			return nil
@@ -532,7 +739,7 @@ func (s *Storage) GetCommandUsageCount(ctx context.Context, command string, stop
 			}
 
 			var entry CommandExecution
-			if err := json.Unmarshal(v, &entry); err != nil {
+			if err := s.decodeHistory(v, &entry); err != nil {
 				continue
 			}
 			if entry.Command != command {
@@ -567,7 +774,7 @@ func (s *Storage) GetHistoryImportState(ctx context.Context, sourceKey string) (
 	}
 
 	var state HistoryImportState
-	err := s.db.View(func(tx *bbolt.Tx) error {
+	err := s.view(ctx, func(tx kvTx) error {
 		if err := ctx.Err(); err != nil {
 			return err
 		}
@@ -606,7 +813,7 @@ func (s *Storage) SaveHistoryImportState(ctx context.Context, sourceKey string,
 		return nil
 	}
 
-	return s.db.Update(func(tx *bbolt.Tx) error {
+	return s.update(ctx, func(tx kvTx) error {
 		if err := ctx.Err(); err != nil {
 			return err
 		}
@@ -633,26 +840,40 @@ func (s *Storage) ClearHistory(ctx context.Context) error {
 		return fmt.Errorf("storage not initialized")
 	}
 
-	return s.db.Update(func(tx *bbolt.Tx) error {
+	return s.update(ctx, func(tx kvTx) error {
 		_ = tx.DeleteBucket([]byte(historyBucketName))
 		// Support removing the legacy history bucket too
 		_ = tx.DeleteBucket([]byte("command_history"))
+		_ = tx.DeleteBucket([]byte(sequenceBucketName))
+		_ = tx.DeleteBucket([]byte(prunedStatsBucket))
 		_, err := tx.CreateBucket([]byte(historyBucketName))
 		return err
 	})
 }
 
-// ExportHistory exports raw execution history to a JSON file
+// ExportHistory exports raw execution history to a JSON file. Encrypted
+// history is exported encrypted, with the header needed to open it, and
+// secrets are redacted when anonymizing.
 func (s *Storage) ExportHistory(ctx context.Context, filepath string) error {
 	entries, err := s.GetAllHistory(ctx)
 	if err != nil {
 		return err
 	}
+	if anonymize {
+		for i := range entries {
+			entries[i].Command = RedactSecrets(entries[i].Command)
+		}
+	}
 
 	data, err := json.MarshalIndent(entries, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal history: %w", err)
 	}
+	if s.encryption != nil {
+		if data, err = s.sealExport(data); err != nil {
+			return fmt.Errorf("failed to encrypt history: %w", err)
+		}
+	}
 
 	return os.WriteFile(filepath, data, 0644)
 }
@@ -663,6 +884,9 @@ func (s *Storage) ImportHistory(ctx context.Context, filepath string) error {
 	if err != nil {
 		return fmt.Errorf("failed to read file: %w", err)
 	}
+	if data, err = s.openExport(data); err != nil {
+		return err
+	}
 
 	var entries []CommandExecution
 	if err := json.Unmarshal(data, &entries); err != nil {
@@ -680,36 +904,45 @@ func (s *Storage) GetHistoryStats(ctx context.Context) (*HistoryStats, error) {
 		return nil, err
 	}
 
+	// Entries pruned to honor database.max_size still count
+	var pruned *prunedStats
+	err = s.view(ctx, func(tx kvTx) error {
+		pruned, err = s.loadPrunedStats(tx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
 	stats := &HistoryStats{
-		TotalExecutions:   len(entries),
+		TotalExecutions:   len(entries) + pruned.Executions,
 		TopCommands:       []CommandStat{},
-		TimeDistribution:  make(map[string]int),
-		OSDistribution:    make(map[string]int),
-		ShellDistribution: make(map[string]int),
+		TimeDistribution:  pruned.TimeDistribution,
+		OSDistribution:    pruned.OSDistribution,
+		ShellDistribution: pruned.ShellDistribution,
 	}
 
-	if len(entries) == 0 {
+	if stats.TotalExecutions == 0 {
 		return stats, nil
 	}
 
-	counts := make(map[string]int)
+	counts := pruned.Commands
+	timings := make(map[string]*TimingStat)
 	for _, entry := range entries {
 		ensureHistoryMetadata(&entry)
 		counts[entry.Command]++
 		stats.OSDistribution[entry.SourceOS]++
 		stats.ShellDistribution[entry.Shell]++
-
-		hour := entry.Timestamp.Hour()
-		if hour >= 6 && hour < 12 {
-			stats.TimeDistribution["Morning (06:00-12:00)"]++
-		} else if hour >= 12 && hour < 18 {
-			stats.TimeDistribution["Afternoon (12:00-18:00)"]++
-		} else if hour >= 18 && hour < 24 {
-			stats.TimeDistribution["Evening (18:00-24:00)"]++
-		} else {
-			stats.TimeDistribution["Night (00:00-06:00)"]++
+		stats.TimeDistribution[timeOfDay(entry.Timestamp.Hour())]++
+		if entry.Timed {
+			stats.TimedExecutions++
+			if entry.ExitCode != 0 {
+				stats.FailedExecutions++
+			}
+			addTiming(timings, entry)
 		}
 	}
+	stats.SlowestCommands, stats.FailingCommands = rankTimings(timings, 5)
 
 	stats.UniqueCommands = len(counts)
 
@@ -735,6 +968,75 @@ func (s *Storage) GetHistoryStats(ctx context.Context) (*HistoryStats, error) {
 	return stats, nil
 }
 
+// addTiming adds a recorded run to the timing of its command. AvgDuration
+// holds the total until rankTimings averages it.
+func addTiming(timings map[string]*TimingStat, entry CommandExecution) {
+	timing, ok := timings[entry.Command]
+	if !ok {
+		timing = &TimingStat{Command: entry.Command}
+		timings[entry.Command] = timing
+	}
+	duration := time.Duration(entry.Duration) * time.Millisecond
+	timing.Runs++
+	timing.AvgDuration += duration
+	timing.MaxDuration = max(timing.MaxDuration, duration)
+	if entry.ExitCode != 0 {
+		timing.Failures++
+	}
+}
+
+// rankTimings returns up to limit commands by average duration, and up to
+// limit of those run at least minFailingRuns times that failed by failure
+// rate
+func rankTimings(timings map[string]*TimingStat, limit int) (slowest, failing []TimingStat) {
+	for _, timing := range timings {
+		timing.AvgDuration /= time.Duration(timing.Runs)
+		if timing.AvgDuration > 0 {
+			slowest = append(slowest, *timing)
+		}
+		if timing.Runs >= minFailingRuns && timing.Failures > 0 {
+			failing = append(failing, *timing)
+		}
+	}
+	sort.Slice(slowest, func(i, j int) bool {
+		if slowest[i].AvgDuration == slowest[j].AvgDuration {
+			return slowest[i].Command < slowest[j].Command
+		}
+		return slowest[i].AvgDuration > slowest[j].AvgDuration
+	})
+	sort.Slice(failing, func(i, j int) bool {
+		ri, rj := failing[i].FailureRate(), failing[j].FailureRate()
+		if ri == rj {
+			if failing[i].Runs == failing[j].Runs {
+				return failing[i].Command < failing[j].Command
+			}
+			return failing[i].Runs > failing[j].Runs
+		}
+		return ri > rj
+	})
+	if len(slowest) > limit {
+		slowest = slowest[:limit]
+	}
+	if len(failing) > limit {
+		failing = failing[:limit]
+	}
+	return slowest, failing
+}
+
+// timeOfDay names the part of the day an hour falls in
+func timeOfDay(hour int) string {
+	switch {
+	case hour >= 6 && hour < 12:
+		return "Morning (06:00-12:00)"
+	case hour >= 12 && hour < 18:
+		return "Afternoon (12:00-18:00)"
+	case hour >= 18 && hour < 24:
+		return "Evening (18:00-24:00)"
+	default:
+		return "Night (00:00-06:00)"
+	}
+}
+
 func currentSourceOS() string {
 	if sourceOS := strings.TrimSpace(os.Getenv("WUT_SOURCE_OS")); sourceOS != "" {
 		return strings.ToLower(sourceOS)
@@ -782,6 +1084,12 @@ func updateHistorySummary(summaries map[string]*HistoryCommandSummary, entry Com
 	}
 
 	summary.UsageCount++
+	if entry.Timed {
+		summary.Runs++
+		if entry.ExitCode != 0 {
+			summary.Failures++
+		}
+	}
 	if entry.Timestamp.After(summary.LastUsed) {
 		summary.LastUsed = entry.Timestamp
 		summary.SourceOS = entry.SourceOS
@@ -0,0 +1,140 @@
+package db
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestGetDirectoryUsage(t *testing.T) {
+	storage, err := NewStorage(filepath.Join(t.TempDir(), "wut.db"))
+	if err != nil {
+		t.Fatalf("NewStorage() error = %v", err)
+	}
+	defer storage.Close()
+
+	root := filepath.Join(string(filepath.Separator), "src", "app")
+	sub := filepath.Join(root, "web")
+	other := filepath.Join(string(filepath.Separator), "src", "application")
+
+	ctx := context.Background()
+	if _, err := storage.AddHistoryBatch(ctx, []CommandExecution{
+		{Command: "make test", Dir: sub},
+		{Command: "make test", Dir: sub},
+		{Command: "make test", Dir: root},
+		{Command: "npm run dev", Dir: sub + string(filepath.Separator)},
+		{Command: "cargo build", Dir: other},
+		// Shell history imports do not know where a command ran
+		{Command: "make test", Imported: true},
+	}); err != nil {
+		t.Fatalf("AddHistoryBatch() error = %v", err)
+	}
+
+	usage, err := storage.GetDirectoryUsage(ctx, sub, root, 0)
+	if err != nil {
+		t.Fatalf("GetDirectoryUsage() error = %v", err)
+	}
+
+	if got := usage["make test"]; got != (DirectoryUsage{InDir: 2, InProject: 3}) {
+		t.Errorf("make test usage = %+v, want 2 here and 3 in the project", got)
+	}
+	if got := usage["npm run dev"]; got != (DirectoryUsage{InDir: 1, InProject: 1}) {
+		t.Errorf("npm run dev usage = %+v, want 1 here", got)
+	}
+	if got, ok := usage["cargo build"]; ok {
+		t.Errorf("cargo build from a sibling directory counted: %+v", got)
+	}
+
+	entries, err := storage.GetHistory(ctx, 1)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("GetHistory() = %v, %v", entries, err)
+	}
+	if entries[0].Dir != "" {
+		t.Errorf("imported entry got directory %q", entries[0].Dir)
+	}
+}
+
+func TestHistoryTimingStats(t *testing.T) {
+	storage, err := NewStorage(filepath.Join(t.TempDir(), "wut.db"))
+	if err != nil {
+		t.Fatalf("NewStorage() error = %v", err)
+	}
+	defer storage.Close()
+
+	run := func(command string, status int, ms int64) CommandExecution {
+		return CommandExecution{Command: command, ExitCode: status, Duration: ms, Timed: true}
+	}
+	ctx := context.Background()
+	if _, err := storage.AddHistoryBatch(ctx, []CommandExecution{
+		run("make test", 0, 4000),
+		run("make test", 2, 6000),
+		run("make test", 2, 5000),
+		run("go build ./...", 0, 900),
+		run("npm start", 1, 100),
+		run("npm start", 1, 120),
+		run("ls", 0, 0),
+		// Commands from history files have no exit status
+		{Command: "make test", Imported: true},
+	}); err != nil {
+		t.Fatalf("AddHistoryBatch() error = %v", err)
+	}
+
+	stats, err := storage.GetHistoryStats(ctx)
+	if err != nil {
+		t.Fatalf("GetHistoryStats() error = %v", err)
+	}
+	if stats.TotalExecutions != 8 || stats.TimedExecutions != 7 || stats.FailedExecutions != 4 {
+		t.Errorf("executions = %d total, %d timed, %d failed; want 8, 7, 4",
+			stats.TotalExecutions, stats.TimedExecutions, stats.FailedExecutions)
+	}
+	want := []string{"make test", "go build ./...", "npm start"}
+	if len(stats.SlowestCommands) != len(want) {
+		t.Fatalf("SlowestCommands = %+v, want %v", stats.SlowestCommands, want)
+	}
+	for i, command := range want {
+		if stats.SlowestCommands[i].Command != command {
+			t.Errorf("SlowestCommands[%d] = %s, want %s", i, stats.SlowestCommands[i].Command, command)
+		}
+	}
+	if slowest := stats.SlowestCommands[0]; slowest.AvgDuration != 5*time.Second || slowest.MaxDuration != 6*time.Second {
+		t.Errorf("make test timing = %+v, want avg 5s and max 6s", slowest)
+	}
+	// npm start fails every time but has too few runs to be ranked
+	if len(stats.FailingCommands) != 1 || stats.FailingCommands[0].Command != "make test" || stats.FailingCommands[0].Failures != 2 {
+		t.Errorf("FailingCommands = %+v, want make test with 2 failures", stats.FailingCommands)
+	}
+
+	summaries, err := storage.GetHistoryCommandSummaries(ctx, 0)
+	if err != nil {
+		t.Fatalf("GetHistoryCommandSummaries() error = %v", err)
+	}
+	for _, summary := range summaries {
+		if summary.Command == "make test" && (summary.UsageCount != 4 || summary.Runs != 3 || summary.Failures != 2) {
+			t.Errorf("make test summary = %+v, want 4 uses, 3 runs, 2 failures", summary)
+		}
+	}
+}
+
+func TestTrackTimingOff(t *testing.T) {
+	storage, err := NewStorage(filepath.Join(t.TempDir(), "wut.db"))
+	if err != nil {
+		t.Fatalf("NewStorage() error = %v", err)
+	}
+	defer storage.Close()
+
+	SetTrackTiming(false)
+	defer SetTrackTiming(true)
+
+	ctx := context.Background()
+	if _, err := storage.AddHistoryBatch(ctx, []CommandExecution{{Command: "make test", ExitCode: 2, Duration: 4000, Timed: true}}); err != nil {
+		t.Fatalf("AddHistoryBatch() error = %v", err)
+	}
+	entries, err := storage.GetHistory(ctx, 1)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("GetHistory() = %v, %v", entries, err)
+	}
+	if e := entries[0]; e.Timed || e.ExitCode != 0 || e.Duration != 0 {
+		t.Errorf("entry = %+v, want no exit status or duration", e)
+	}
+}
@@ -0,0 +1,199 @@
+package db
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// maxImportPageSize skips files too large to be a tldr page
+const maxImportPageSize = 1 << 20
+
+// ImportPages imports tldr pages from a local clone of tldr-pages/tldr, a
+// directory inside one, or a .zip or .tar.gz archive of either. Pages are
+// found by their pages[.lang]/<platform>/<command>.md path. The result
+// counts the imported pages per platform.
+func (sm *SyncManager) ImportPages(ctx context.Context, path string) (*SyncResult, error) {
+	start := time.Now()
+	saver := newBatchPageSaver(sm.storage, sm.log, 500)
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	lower := strings.ToLower(path)
+	switch {
+	case info.IsDir():
+		err = sm.importDir(ctx, path, saver)
+	case strings.HasSuffix(lower, ".zip"):
+		err = sm.importZip(ctx, path, saver)
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		err = sm.importTarGz(ctx, path, saver)
+	default:
+		return nil, fmt.Errorf("%s is not a directory, .zip or .tar.gz archive", path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if saver.parsed == 0 && saver.failed == 0 {
+		return nil, fmt.Errorf("no tldr pages found in %s", path)
+	}
+
+	sm.log.Info("parsed pages from source", "count", saver.parsed, "path", path)
+	return sm.finishBatchSync(saver.Result(start))
+}
+
+// importDir imports the pages below dir
+func (sm *SyncManager) importDir(ctx context.Context, dir string, saver *batchPageSaver) error {
+	// The base name is part of the page path, so dir may be a pages directory
+	base := filepath.Dir(filepath.Clean(dir))
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(base, path)
+		if err != nil {
+			return nil
+		}
+		sm.importPage(saver, filepath.ToSlash(rel), func() (io.ReadCloser, error) {
+			return os.Open(path)
+		})
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed walking pages dir: %w", err)
+	}
+	return nil
+}
+
+// importZip imports the pages in a zip archive
+func (sm *SyncManager) importZip(ctx context.Context, path string, saver *batchPageSaver) error {
+	zipReader, err := zip.OpenReader(path)
+	if err != nil {
+		return fmt.Errorf("invalid zip file: %w", err)
+	}
+	defer zipReader.Close()
+
+	for _, f := range zipReader.File {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		sm.importPage(saver, f.Name, f.Open)
+	}
+	return nil
+}
+
+// importTarGz imports the pages in a gzip-compressed tar archive
+func (sm *SyncManager) importTarGz(ctx context.Context, path string, saver *batchPageSaver) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return fmt.Errorf("invalid tar.gz file: %w", err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("invalid tar.gz file: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		sm.importPage(saver, header.Name, func() (io.ReadCloser, error) {
+			return io.NopCloser(tr), nil
+		})
+	}
+}
+
+// importPage parses the file at name into a page when name is a page path
+func (sm *SyncManager) importPage(saver *batchPageSaver, name string, open func() (io.ReadCloser, error)) {
+	_, platform, language, ok := pagePath(name)
+	if !ok {
+		return
+	}
+
+	rc, err := open()
+	if err != nil {
+		saver.AddFailure(fmt.Errorf("failed to open page %s: %w", name, err))
+		sm.log.Warn("failed to open page", "file", name, "error", err)
+		return
+	}
+	content, err := io.ReadAll(io.LimitReader(rc, maxImportPageSize+1))
+	rc.Close()
+	if err == nil && len(content) > maxImportPageSize {
+		err = fmt.Errorf("larger than %d bytes", maxImportPageSize)
+	}
+	if err != nil {
+		saver.AddFailure(fmt.Errorf("failed to read page %s: %w", name, err))
+		sm.log.Warn("failed to read page", "file", name, "error", err)
+		return
+	}
+
+	page, err := ParseTLDRMarkdown(content)
+	if err != nil {
+		saver.AddFailure(fmt.Errorf("%s: %w", name, err))
+		sm.log.Warn("skipped malformed page", "file", name, "error", err)
+		return
+	}
+	page.Platform = platform
+	page.Language = language
+	saver.Add(page)
+}
+
+// pagePath splits a slash-separated path ending in
+// pages[.lang]/<platform>/<command>.md
+func pagePath(name string) (command, platform, language string, ok bool) {
+	parts := strings.Split(strings.TrimPrefix(name, "./"), "/")
+	if len(parts) < 3 || !strings.HasSuffix(parts[len(parts)-1], ".md") {
+		return "", "", "", false
+	}
+
+	langDir := parts[len(parts)-3]
+	switch {
+	case langDir == "pages":
+		language = "en"
+	case strings.HasPrefix(langDir, "pages."):
+		language = strings.TrimPrefix(langDir, "pages.")
+	default:
+		return "", "", "", false
+	}
+
+	command = strings.TrimSuffix(parts[len(parts)-1], ".md")
+	platform = parts[len(parts)-2]
+	if command == "" || platform == "" {
+		return "", "", "", false
+	}
+	return command, platform, language, true
+}
@@ -0,0 +1,137 @@
+package db
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"maps"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// importFixture is a small tldr checkout, keyed by path
+var importFixture = map[string]string{
+	"tldr-main/pages/common/git.md":                "# git\n\n> Distributed version control.\n\n- Show the status:\n\n`git status`\n",
+	"tldr-main/pages/common/tar.md":                "# tar\n\n> Archiving utility.\n\n- Extract an archive:\n\n`tar xf {{path/to/file.tar}}`\n",
+	"tldr-main/pages/linux/apt.md":                 "# apt\n\n> Package manager.\n\n- Install a package:\n\n`sudo apt install {{package}}`\n",
+	"tldr-main/pages.de/common/git.md":             "# git\n\n> Versionsverwaltung.\n\n- Status anzeigen:\n\n`git status`\n",
+	"tldr-main/pages/README.md":                    "not a page",
+	"tldr-main/contributing-guides/style-guide.md": "not a page either",
+}
+
+func TestImportPages(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range importFixture {
+		path := filepath.Join(dir, "src", filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	zipPath := filepath.Join(dir, "tldr.zip")
+	zf, err := os.Create(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(zf)
+	for name, content := range importFixture {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = w.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	zf.Close()
+
+	tarPath := filepath.Join(dir, "tldr.tar.gz")
+	tf, err := os.Create(tarPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gw := gzip.NewWriter(tf)
+	tw := tar.NewWriter(gw)
+	for name, content := range importFixture {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		_, _ = tw.Write([]byte(content))
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	gw.Close()
+	tf.Close()
+
+	all := map[string]int{"common": 3, "linux": 1}
+	tests := []struct {
+		name string
+		path string
+		want map[string]int
+	}{
+		{"checkout", filepath.Join(dir, "src", "tldr-main"), all},
+		{"pages directory", filepath.Join(dir, "src", "tldr-main", "pages.de"), map[string]int{"common": 1}},
+		{"zip", zipPath, all},
+		{"tar.gz", tarPath, all},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage, err := NewStorage(filepath.Join(t.TempDir(), "tldr.db"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer storage.Close()
+			sm := NewSyncManager(storage)
+			defer sm.Stop()
+
+			result, err := sm.ImportPages(context.Background(), tt.path)
+			if err != nil {
+				t.Fatalf("ImportPages() error = %v", err)
+			}
+			if !maps.Equal(result.Platforms, tt.want) || result.Failed != 0 {
+				t.Errorf("ImportPages() platforms = %v, failed = %d, want %v", result.Platforms, result.Failed, tt.want)
+			}
+
+			language := "en"
+			if tt.name == "pages directory" {
+				language = "de"
+			}
+			page, err := storage.GetPage("git", "common", language)
+			if err != nil {
+				t.Fatalf("GetPage(git) error = %v", err)
+			}
+			if len(page.Examples) != 1 || page.Examples[0].Command != "git status" {
+				t.Errorf("imported git page examples = %+v", page.Examples)
+			}
+		})
+	}
+}
+
+func TestImportPagesRejects(t *testing.T) {
+	storage, err := NewStorage(filepath.Join(t.TempDir(), "tldr.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer storage.Close()
+	sm := NewSyncManager(storage)
+	defer sm.Stop()
+
+	empty := t.TempDir()
+	if _, err := sm.ImportPages(context.Background(), empty); err == nil {
+		t.Error("ImportPages() of a directory without pages succeeded")
+	}
+	other := filepath.Join(empty, "pages.txt")
+	if err := os.WriteFile(other, []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sm.ImportPages(context.Background(), other); err == nil {
+		t.Error("ImportPages() of a text file succeeded")
+	}
+}
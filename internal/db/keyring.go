@@ -0,0 +1,82 @@
+package db
+
+import (
+	"errors"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+const keyringService = "wut"
+
+// errNoKeyring is returned when the OS has no keyring WUT can use
+var errNoKeyring = errors.New("no OS keyring available")
+
+// keyStore caches secrets outside the database
+type keyStore interface {
+	Get(account string) (string, error)
+	Set(account, secret string) error
+	Delete(account string) error
+}
+
+// keyring caches the history key; tests replace it
+var keyring keyStore = systemKeyring{}
+
+// systemKeyring uses the macOS keychain through security(1) and the Secret
+// Service through secret-tool(1) on Linux and the BSDs
+type systemKeyring struct{}
+
+func (systemKeyring) Get(account string) (string, error) {
+	var cmd *exec.Cmd
+	switch {
+	case runtime.GOOS == "darwin":
+		cmd = exec.Command("security", "find-generic-password", "-s", keyringService, "-a", account, "-w")
+	case hasSecretTool():
+		cmd = exec.Command("secret-tool", "lookup", "service", keyringService, "account", account)
+	default:
+		return "", errNoKeyring
+	}
+	out, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func (systemKeyring) Set(account, secret string) error {
+	var cmd *exec.Cmd
+	switch {
+	case runtime.GOOS == "darwin":
+		// -w last without a value prompts for the secret, twice, which keeps
+		// it off the command line other processes can read
+		cmd = exec.Command("security", "add-generic-password", "-U", "-s", keyringService, "-a", account, "-w")
+		cmd.Stdin = strings.NewReader(secret + "\n" + secret + "\n")
+	case hasSecretTool():
+		cmd = exec.Command("secret-tool", "store", "--label=WUT history key", "service", keyringService, "account", account)
+		cmd.Stdin = strings.NewReader(secret)
+	default:
+		return errNoKeyring
+	}
+	return cmd.Run()
+}
+
+func (systemKeyring) Delete(account string) error {
+	var cmd *exec.Cmd
+	switch {
+	case runtime.GOOS == "darwin":
+		cmd = exec.Command("security", "delete-generic-password", "-s", keyringService, "-a", account)
+	case hasSecretTool():
+		cmd = exec.Command("secret-tool", "clear", "service", keyringService, "account", account)
+	default:
+		return errNoKeyring
+	}
+	return cmd.Run()
+}
+
+func hasSecretTool() bool {
+	if runtime.GOOS == "windows" {
+		return false
+	}
+	_, err := exec.LookPath("secret-tool")
+	return err == nil
+}
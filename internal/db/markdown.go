@@ -0,0 +1,151 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrMalformedPage is returned for markdown that does not follow the tldr
+// page format
+var ErrMalformedPage = errors.New("malformed tldr page")
+
+// ParseTLDRMarkdown parses a tldr page:
+//
+//	# command
+//
+//	> Description.
+//	> See also: `other`.
+//	> More information: <https://example.com>.
+//
+//	- Example description:
+//
+//	`command {{placeholder}}`
+//
+// Placeholders become <placeholder>; \{\{ and \}\} are literal braces. The
+// "More information" and "See also" lines are left out of Description and
+// read with MoreInfoURL and SeeAlso. Platform and language come from the
+// page's path, so they are left for the caller to set. The error names the
+// first line that breaks the format.
+func ParseTLDRMarkdown(data []byte) (*Page, error) {
+	page, err := parseTLDR(string(data))
+	if err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// parseTLDR parses what it can of a page, skipping the lines that break the
+// format; the error describes the first of them
+func parseTLDR(content string) (*Page, error) {
+	page := &Page{RawContent: content, Examples: []Example{}}
+
+	var firstErr error
+	fail := func(line int, format string, args ...any) {
+		if firstErr == nil {
+			firstErr = fmt.Errorf("%w: line %d: %s", ErrMalformedPage, line, fmt.Sprintf(format, args...))
+		}
+	}
+
+	// pending is an example description still waiting for its command
+	pending, pendingLine := "", 0
+	for i, line := range strings.Split(content, "\n") {
+		n := i + 1
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+			continue
+
+		case strings.HasPrefix(line, "# "):
+			if page.Name != "" {
+				fail(n, "second title %q", line)
+				continue
+			}
+			page.Name = strings.TrimSpace(strings.TrimPrefix(line, "# "))
+
+		case line == ">" || strings.HasPrefix(line, "> "):
+			if page.Name == "" {
+				fail(n, "description before the '# command' title")
+			}
+			if len(page.Examples) > 0 || pending != "" {
+				fail(n, "description after the examples")
+			}
+			text := strings.TrimSpace(strings.TrimPrefix(line, ">"))
+			if text == "" || moreInfoLink(text) != "" || strings.HasPrefix(text, "See also:") {
+				continue
+			}
+			if page.Description != "" {
+				page.Description += " "
+			}
+			page.Description += text
+
+		case strings.HasPrefix(line, "- "):
+			if pending != "" {
+				fail(pendingLine, "example %q has no command", pending)
+			}
+			pending, pendingLine = strings.TrimSpace(strings.TrimPrefix(line, "- ")), n
+
+		case strings.HasPrefix(line, "`"):
+			if len(line) < 2 || !strings.HasSuffix(line, "`") {
+				fail(n, "unterminated command %s", line)
+				continue
+			}
+			if pending == "" {
+				fail(n, "command %s has no example description", line)
+				continue
+			}
+			cmd, err := formatExample(line[1 : len(line)-1])
+			if err != nil {
+				fail(n, "%v", err)
+				continue
+			}
+			page.Examples = append(page.Examples, Example{Description: pending, Command: cmd})
+			pending = ""
+
+		default:
+			fail(n, "unexpected text %q", line)
+		}
+	}
+
+	if pending != "" {
+		fail(pendingLine, "example %q has no command", pending)
+	}
+	if page.Name == "" {
+		return page, fmt.Errorf("%w: missing '# command' title", ErrMalformedPage)
+	}
+	return page, firstErr
+}
+
+// formatExample turns the {{placeholders}} of an example command into
+// <placeholders>, keeping escaped \{\{ and \}\} as literal braces
+func formatExample(cmd string) (string, error) {
+	const lbraces, rbraces = "\x00{\x00", "\x00}\x00"
+	escaped := strings.NewReplacer(`\{\{`, lbraces, `\}\}`, rbraces).Replace(cmd)
+
+	rest := variableRe.ReplaceAllString(escaped, "")
+	if strings.Contains(rest, "{{") || strings.Contains(rest, "}}") {
+		return "", fmt.Errorf("unbalanced placeholder in `%s`", cmd)
+	}
+
+	formatted := formatCommand(escaped)
+	return strings.NewReplacer(lbraces, "{{", rbraces, "}}").Replace(formatted), nil
+}
+
+// SeeAlso returns the related commands from the page's "> See also:" line
+func (p *Page) SeeAlso() []string {
+	var related []string
+	for line := range strings.SplitSeq(p.RawContent, "\n") {
+		after, ok := strings.CutPrefix(strings.TrimSpace(line), "> See also:")
+		if !ok {
+			continue
+		}
+		// Commands are quoted with backticks: `a`, `b` or `c`.
+		parts := strings.Split(after, "`")
+		for i := 1; i < len(parts); i += 2 {
+			if name := strings.TrimSpace(parts[i]); name != "" {
+				related = append(related, name)
+			}
+		}
+	}
+	return related
+}
@@ -0,0 +1,110 @@
+package db
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseTLDRMarkdown(t *testing.T) {
+	tests := []struct {
+		file        string
+		name        string
+		description string
+		examples    []Example
+		seeAlso     []string
+		moreInfo    string
+	}{
+		{
+			file:        "git.md",
+			name:        "git",
+			description: "Distributed version control system. Some subcommands such as `commit`, `add`, `branch`, `switch`, `push`, etc. have their own usage documentation.",
+			examples: []Example{
+				{"Create an empty Git repository:", "git init"},
+				{"Clone a remote Git repository from the internet:", "git clone <https://example.com/repo.git>"},
+				{"View the status of the local repository:", "git status"},
+				{"Stage all changes for a commit:", "git add <[-A|--all]>"},
+				{"Commit changes to version history:", "git commit <[-m|--message]> <message_text>"},
+				{"Show a template with literal braces:", "git log --format='{{%h}} <format>'"},
+			},
+			seeAlso:  []string{"git-commit", "git-log"},
+			moreInfo: "https://git-scm.com/",
+		},
+		{
+			file:        "tar.md",
+			name:        "tar",
+			description: "Archiving utility. Often combined with a compression method, such as `gzip` or `bzip2`.",
+			examples: []Example{
+				{"[c]reate an archive and write it to a [f]ile:", "tar cf <path/to/target.tar> <path/to/file1 path/to/file2 ...>"},
+				{"E[x]tract a (compressed) archive [f]ile into the current directory [v]erbosely:", "tar xvf <path/to/source.tar[.gz|.bz2|.xz]>"},
+				{"Lis[t] the contents of a tar [f]ile [v]erbosely:", "tar tvf <path/to/source.tar>"},
+			},
+			moreInfo: "https://www.gnu.org/software/tar",
+		},
+		{
+			file:        "no-examples.md",
+			name:        "no-examples",
+			description: "A page that only describes its command.",
+			examples:    []Example{},
+			moreInfo:    "https://example.com/no-examples",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", "pages", tt.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			page, err := ParseTLDRMarkdown(data)
+			if err != nil {
+				t.Fatalf("ParseTLDRMarkdown() error = %v", err)
+			}
+			if page.Name != tt.name || page.Description != tt.description {
+				t.Errorf("Name, Description = %q, %q, want %q, %q", page.Name, page.Description, tt.name, tt.description)
+			}
+			if !slices.Equal(page.Examples, tt.examples) {
+				t.Errorf("Examples =\n%q\nwant\n%q", page.Examples, tt.examples)
+			}
+			if !slices.Equal(page.SeeAlso(), tt.seeAlso) {
+				t.Errorf("SeeAlso() = %q, want %q", page.SeeAlso(), tt.seeAlso)
+			}
+			if page.MoreInfoURL() != tt.moreInfo {
+				t.Errorf("MoreInfoURL() = %q, want %q", page.MoreInfoURL(), tt.moreInfo)
+			}
+		})
+	}
+}
+
+func TestParseTLDRMarkdownMalformed(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"empty", "", "missing '# command' title"},
+		{"no title", "> Lists files.\n\n- List:\n\n`ls`\n", "missing '# command' title"},
+		{"title after description", "> Lists files.\n# ls\n", "line 1: description before the '# command' title"},
+		{"second title", "# ls\n# dir\n", `line 2: second title "# dir"`},
+		{"example without command", "# ls\n\n- List files:\n\n- List all files:\n\n`ls -a`\n", `line 3: example "List files:" has no command`},
+		{"last example without command", "# ls\n\n- List files:\n", `line 3: example "List files:" has no command`},
+		{"command without example", "# ls\n\n`ls`\n", "line 3: command `ls` has no example description"},
+		{"unterminated command", "# ls\n\n- List files:\n\n`ls -la\n", "line 5: unterminated command `ls -la"},
+		{"unbalanced placeholder", "# cp\n\n- Copy:\n\n`cp {{source target`\n", "line 5: unbalanced placeholder in `cp {{source target`"},
+		{"description after examples", "# ls\n\n- List files:\n\n`ls`\n\n> Late.\n", "line 7: description after the examples"},
+		{"stray text", "# ls\n\nLists files.\n", `line 3: unexpected text "Lists files."`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := ParseTLDRMarkdown([]byte(tt.content))
+			if !errors.Is(err, ErrMalformedPage) {
+				t.Fatalf("ParseTLDRMarkdown() = %+v, %v, want ErrMalformedPage", page, err)
+			}
+			if !strings.HasSuffix(err.Error(), tt.wantErr) {
+				t.Errorf("error = %q, want it to end in %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+)
+
+// migrateBatch is how many entries are copied per write transaction
+const migrateBatch = 1000
+
+// ErrSameEngine is returned by MigrateDatabase for a database that already
+// uses the engine asked for
+var ErrSameEngine = errors.New("database already uses this engine")
+
+// MigrateResult reports what MigrateDatabase copied
+type MigrateResult struct {
+	From     string
+	To       string
+	Buckets  int
+	Entries  int
+	Previous string // the database in its old engine, kept next to it
+}
+
+// MigrateDatabase copies every bucket of the database at path into a new
+// file kept in the engine to, checks that each bucket has as many entries as
+// before and swaps the copy in. The original is kept as path.pre-migrate.
+// progress, when not nil, is called with the entries copied so far and the
+// total. The database must not be in use by another wut instance.
+func MigrateDatabase(ctx context.Context, path, to string, progress func(copied, total int)) (*MigrateResult, error) {
+	if !slices.Contains(Engines, to) {
+		return nil, fmt.Errorf("unknown storage engine %q", to)
+	}
+	if !fileExists(path) {
+		return nil, fmt.Errorf("no database at %s", path)
+	}
+	from := DatabaseEngine(path)
+	if from == to {
+		return nil, fmt.Errorf("%s: %w %s", path, ErrSameEngine, to)
+	}
+	if progress == nil {
+		progress = func(int, int) {}
+	}
+
+	src, err := openKVEngine(from, path, lockTimeout, false)
+	if err != nil {
+		return nil, openError(path, err)
+	}
+	srcOpen := true
+	defer func() {
+		if srcOpen {
+			src.Close()
+		}
+	}()
+
+	tmpPath := path + ".migrate"
+	_ = os.Remove(tmpPath)
+	dst, err := openKVEngine(to, tmpPath, lockTimeout, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s database: %w", to, err)
+	}
+	defer os.Remove(tmpPath)
+
+	result := &MigrateResult{From: from, To: to, Previous: path + ".pre-migrate"}
+	counts, err := countEntries(src)
+	if err != nil {
+		dst.Close()
+		return nil, err
+	}
+	for _, n := range counts {
+		result.Buckets++
+		result.Entries += n
+	}
+
+	copied := 0
+	progress(copied, result.Entries)
+	err = src.View(func(tx kvTx) error {
+		return tx.ForEach(func(name []byte, b kvBucket) error {
+			return copyBucket(ctx, dst, name, b, func(n int) {
+				copied += n
+				progress(copied, result.Entries)
+			})
+		})
+	})
+	if err == nil {
+		err = verifyEntries(dst, counts)
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to migrate %s to %s: %w", path, to, err)
+	}
+
+	srcOpen = false
+	if err := src.Close(); err != nil {
+		return nil, err
+	}
+	_ = os.Remove(result.Previous)
+	if err := os.Rename(path, result.Previous); err != nil {
+		return nil, fmt.Errorf("failed to keep the %s database: %w", from, err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		_ = os.Rename(result.Previous, path)
+		return nil, fmt.Errorf("failed to replace the database with its %s copy: %w", to, err)
+	}
+	return result, nil
+}
+
+// countEntries returns the number of entries in each bucket
+func countEntries(db kvDB) (map[string]int, error) {
+	counts := make(map[string]int)
+	err := db.View(func(tx kvTx) error {
+		return tx.ForEach(func(name []byte, b kvBucket) error {
+			counts[string(name)] = b.KeyN()
+			return nil
+		})
+	})
+	return counts, err
+}
+
+// copyBucket copies a bucket into dst a batch of entries per transaction,
+// calling copied after each batch. The bucket is created even when empty.
+func copyBucket(ctx context.Context, dst kvDB, name []byte, src kvBucket, copied func(int)) error {
+	c := src.Cursor()
+	k, v := c.First()
+	for first := true; first || k != nil; first = false {
+		if err := contextErr(ctx); err != nil {
+			return err
+		}
+		n := 0
+		err := dst.Update(func(tx kvTx) error {
+			b, err := tx.CreateBucketIfNotExists(name)
+			if err != nil {
+				return err
+			}
+			for ; k != nil && n < migrateBatch; k, v = c.Next() {
+				if err := b.Put(k, v); err != nil {
+					return fmt.Errorf("bucket %s: %w", name, err)
+				}
+				n++
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		copied(n)
+	}
+	return nil
+}
+
+// verifyEntries checks that db has exactly the buckets in want, each with
+// as many entries
+func verifyEntries(db kvDB, want map[string]int) error {
+	got, err := countEntries(db)
+	if err != nil {
+		return err
+	}
+	for name, n := range want {
+		if _, ok := got[name]; !ok {
+			return fmt.Errorf("bucket %s is missing after the copy", name)
+		}
+		if got[name] != n {
+			return fmt.Errorf("bucket %s has %d entries after the copy, want %d", name, got[name], n)
+		}
+	}
+	if len(got) != len(want) {
+		return fmt.Errorf("copy has %d buckets, want %d", len(got), len(want))
+	}
+	return nil
+}
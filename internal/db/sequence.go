@@ -0,0 +1,241 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-json"
+	"go.etcd.io/bbolt"
+)
+
+const (
+	sequenceBucketName = "command_sequences"
+
+	// sequenceMaxGap is the longest pause between two commands that still
+	// counts as one running the other
+	sequenceMaxGap = 30 * time.Minute
+
+	// sequenceMaxFollowers bounds how many distinct follow-ups are kept per
+	// command; the rarest are dropped first
+	sequenceMaxFollowers = 32
+)
+
+// CommandSequence records how often Next was run right after Command
+type CommandSequence struct {
+	Command  string
+	Next     string
+	Count    int
+	LastSeen time.Time
+}
+
+// sequenceFollower is the stored count of one follow-up command
+type sequenceFollower struct {
+	Count    int       `json:"count"`
+	LastSeen time.Time `json:"last_seen"`
+}
+
+// GetNextCommands returns the commands most often run right after command,
+// most frequent first.
+func (s *Storage) GetNextCommands(ctx context.Context, command string, limit int) ([]CommandSequence, error) {
+	if s == nil || s.db == nil {
+		return nil, fmt.Errorf("storage not initialized")
+	}
+
+	command = strings.TrimSpace(command)
+	if command == "" {
+		return nil, nil
+	}
+
+	var followers map[string]sequenceFollower
+	err := s.view(ctx, func(tx kvTx) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		bucket := tx.Bucket([]byte(sequenceBucketName))
+		if bucket == nil {
+			return nil
+		}
+
+		data := bucket.Get([]byte(command))
+		if len(data) == 0 {
+			return nil
+		}
+		return json.Unmarshal(data, &followers)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]CommandSequence, 0, len(followers))
+	for next, follower := range followers {
+		results = append(results, CommandSequence{
+			Command:  command,
+			Next:     next,
+			Count:    follower.Count,
+			LastSeen: follower.LastSeen,
+		})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return sequenceLess(results[i].Count, results[i].LastSeen, results[j].Count, results[j].LastSeen)
+	})
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+
+	return results, nil
+}
+
+// recordSequences counts each consecutive pair among entries, starting from
+// the newest command already in the log. When the sequence bucket does not
+// exist yet it is first built from the whole log, so history recorded before
+// sequences were tracked still counts.
+func recordSequences(tx kvTx, entries []CommandExecution) error {
+	historyBucket := tx.Bucket([]byte(historyBucketName))
+
+	bucket := tx.Bucket([]byte(sequenceBucketName))
+	if bucket == nil {
+		var err error
+		bucket, err = tx.CreateBucket([]byte(sequenceBucketName))
+		if err != nil {
+			return err
+		}
+		if historyBucket != nil {
+			if err := rebuildSequences(bucket, historyBucket); err != nil {
+				return err
+			}
+		}
+	}
+
+	var previous *CommandExecution
+	if historyBucket != nil {
+		if _, v := historyBucket.Cursor().Last(); v != nil {
+			var entry CommandExecution
+			if err := json.Unmarshal(v, &entry); err == nil {
+				previous = &entry
+			}
+		}
+	}
+
+	pending := make(map[string]map[string]sequenceFollower)
+	for i := range entries {
+		entry := &entries[i]
+		if previous != nil && isSequence(*previous, *entry) {
+			addSequence(bucket, pending, previous.Command, entry.Command, entry.Timestamp)
+		}
+		previous = entry
+	}
+
+	return flushSequences(bucket, pending)
+}
+
+// rebuildSequences counts every consecutive pair in the execution log
+func rebuildSequences(bucket, historyBucket kvBucket) error {
+	pending := make(map[string]map[string]sequenceFollower)
+
+	var previous *CommandExecution
+	c := historyBucket.Cursor()
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		var entry CommandExecution
+		if err := json.Unmarshal(v, &entry); err != nil {
+			continue
+		}
+		if previous != nil && isSequence(*previous, entry) {
+			addSequence(bucket, pending, previous.Command, entry.Command, entry.Timestamp)
+		}
+		previous = &entry
+	}
+
+	return flushSequences(bucket, pending)
+}
+
+// isSequence reports whether next directly follows previous in the same
+// session. Repeating a command is not a sequence, and neither is a command
+// too long to be a bbolt key, which would fail the whole batch.
+func isSequence(previous, next CommandExecution) bool {
+	prevCommand := strings.TrimSpace(previous.Command)
+	nextCommand := strings.TrimSpace(next.Command)
+	if prevCommand == "" || nextCommand == "" || prevCommand == nextCommand {
+		return false
+	}
+	if len(prevCommand) > bbolt.MaxKeySize || len(nextCommand) > bbolt.MaxKeySize {
+		return false
+	}
+	if previous.SessionID != "" && next.SessionID != "" && previous.SessionID != next.SessionID {
+		return false
+	}
+	if !previous.Timestamp.IsZero() && !next.Timestamp.IsZero() {
+		gap := next.Timestamp.Sub(previous.Timestamp)
+		if gap < 0 || gap > sequenceMaxGap {
+			return false
+		}
+	}
+	return true
+}
+
+// addSequence counts one occurrence of next after command in pending,
+// loading the stored counts for command on first use
+func addSequence(bucket kvBucket, pending map[string]map[string]sequenceFollower, command, next string, seen time.Time) {
+	command = strings.TrimSpace(command)
+	next = strings.TrimSpace(next)
+
+	followers, ok := pending[command]
+	if !ok {
+		followers = make(map[string]sequenceFollower)
+		if data := bucket.Get([]byte(command)); len(data) > 0 {
+			if err := json.Unmarshal(data, &followers); err != nil {
+				followers = make(map[string]sequenceFollower)
+			}
+		}
+		pending[command] = followers
+	}
+
+	follower := followers[next]
+	follower.Count++
+	if seen.After(follower.LastSeen) {
+		follower.LastSeen = seen
+	}
+	followers[next] = follower
+}
+
+// flushSequences writes the updated counts back, keeping at most
+// sequenceMaxFollowers follow-ups per command
+func flushSequences(bucket kvBucket, pending map[string]map[string]sequenceFollower) error {
+	for command, followers := range pending {
+		if len(followers) > sequenceMaxFollowers {
+			pruneFollowers(followers)
+		}
+		data, err := json.Marshal(followers)
+		if err != nil {
+			return fmt.Errorf("failed to marshal command sequences: %w", err)
+		}
+		if err := bucket.Put([]byte(command), data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func pruneFollowers(followers map[string]sequenceFollower) {
+	names := make([]string, 0, len(followers))
+	for name := range followers {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		a, b := followers[names[i]], followers[names[j]]
+		return sequenceLess(a.Count, a.LastSeen, b.Count, b.LastSeen)
+	})
+	for _, name := range names[sequenceMaxFollowers:] {
+		delete(followers, name)
+	}
+}
+
+func sequenceLess(leftCount int, leftSeen time.Time, rightCount int, rightSeen time.Time) bool {
+	if leftCount == rightCount {
+		return leftSeen.After(rightSeen)
+	}
+	return leftCount > rightCount
+}
@@ -0,0 +1,136 @@
+package db
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+func TestGetNextCommands(t *testing.T) {
+	storage, err := NewStorage(filepath.Join(t.TempDir(), "wut.db"))
+	if err != nil {
+		t.Fatalf("NewStorage() error = %v", err)
+	}
+	defer storage.Close()
+
+	ctx := context.Background()
+	start := time.Now().Add(-24 * time.Hour)
+	at := func(minutes int) time.Time { return start.Add(time.Duration(minutes) * time.Minute) }
+
+	entries := []CommandExecution{
+		{Command: "git add .", Timestamp: at(0)},
+		{Command: "git commit", Timestamp: at(1)},
+		{Command: "git add .", Timestamp: at(2)},
+		{Command: "git commit", Timestamp: at(3)},
+		{Command: "git add .", Timestamp: at(4)},
+		{Command: "git status", Timestamp: at(5)},
+		// Too long a pause to count as following git status
+		{Command: "git add .", Timestamp: at(120)},
+		// Repeats are not sequences
+		{Command: "git add .", Timestamp: at(121)},
+	}
+	// The first batch is recorded in one go, the rest one at a time like the
+	// shell hooks do
+	if _, err := storage.AddHistoryBatch(ctx, entries[:4]); err != nil {
+		t.Fatalf("AddHistoryBatch() error = %v", err)
+	}
+	for _, entry := range entries[4:] {
+		if _, err := storage.AddHistoryBatch(ctx, []CommandExecution{entry}); err != nil {
+			t.Fatalf("AddHistoryBatch() error = %v", err)
+		}
+	}
+
+	next, err := storage.GetNextCommands(ctx, "git add .", 0)
+	if err != nil {
+		t.Fatalf("GetNextCommands() error = %v", err)
+	}
+	if len(next) != 2 {
+		t.Fatalf("GetNextCommands() = %+v, want 2 follow-ups", next)
+	}
+	if next[0].Next != "git commit" || next[0].Count != 2 {
+		t.Errorf("top follow-up = %+v, want git commit twice", next[0])
+	}
+	if next[1].Next != "git status" || next[1].Count != 1 {
+		t.Errorf("second follow-up = %+v, want git status once", next[1])
+	}
+
+	after, err := storage.GetNextCommands(ctx, "git status", 0)
+	if err != nil {
+		t.Fatalf("GetNextCommands() error = %v", err)
+	}
+	if len(after) != 0 {
+		t.Errorf("GetNextCommands(git status) = %+v, want none", after)
+	}
+}
+
+func TestSequencesRebuiltFromExistingHistory(t *testing.T) {
+	storage, err := NewStorage(filepath.Join(t.TempDir(), "wut.db"))
+	if err != nil {
+		t.Fatalf("NewStorage() error = %v", err)
+	}
+	defer storage.Close()
+
+	ctx := context.Background()
+	if _, err := storage.AddHistoryBatch(ctx, []CommandExecution{
+		{Command: "make"},
+		{Command: "make test"},
+	}); err != nil {
+		t.Fatalf("AddHistoryBatch() error = %v", err)
+	}
+
+	// Simulate a database written before sequences were tracked
+	if err := storage.db.Update(func(tx kvTx) error {
+		return tx.DeleteBucket([]byte(sequenceBucketName))
+	}); err != nil {
+		t.Fatalf("delete sequence bucket: %v", err)
+	}
+
+	if err := storage.AddHistory(ctx, "make"); err != nil {
+		t.Fatalf("AddHistory() error = %v", err)
+	}
+	if err := storage.AddHistory(ctx, "make test"); err != nil {
+		t.Fatalf("AddHistory() error = %v", err)
+	}
+
+	next, err := storage.GetNextCommands(ctx, "make", 0)
+	if err != nil {
+		t.Fatalf("GetNextCommands() error = %v", err)
+	}
+	if len(next) != 1 || next[0].Next != "make test" || next[0].Count != 2 {
+		t.Errorf("GetNextCommands(make) = %+v, want make test twice", next)
+	}
+}
+
+func TestSequencesSkipCommandsOverKeyLimit(t *testing.T) {
+	storage, err := NewStorage(filepath.Join(t.TempDir(), "wut.db"))
+	if err != nil {
+		t.Fatalf("NewStorage() error = %v", err)
+	}
+	defer storage.Close()
+
+	ctx := context.Background()
+	long := "echo " + strings.Repeat("x", bbolt.MaxKeySize)
+	start := time.Now().Add(-time.Hour)
+	entries := []CommandExecution{
+		{Command: "make", Timestamp: start},
+		{Command: long, Timestamp: start.Add(time.Second)},
+		{Command: "make test", Timestamp: start.Add(2 * time.Second)},
+	}
+	// The long command is still recorded, it just starts no sequence
+	if _, err := storage.AddHistoryBatch(ctx, entries); err != nil {
+		t.Fatalf("AddHistoryBatch() with a command over the key limit error = %v", err)
+	}
+	if next, err := storage.GetNextCommands(ctx, long, 0); err != nil || len(next) != 0 {
+		t.Errorf("GetNextCommands(long) = %+v, %v; want none", next, err)
+	}
+	if next, err := storage.GetNextCommands(ctx, "make", 0); err != nil || len(next) != 0 {
+		t.Errorf("GetNextCommands(make) = %+v, %v; want none", next, err)
+	}
+	if history, err := storage.GetHistory(ctx, 0); err != nil || len(history) != len(entries) {
+		t.Errorf("history holds %d entries, %v; want %d", len(history), err, len(entries))
+	}
+}
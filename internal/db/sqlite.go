@@ -0,0 +1,617 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.etcd.io/bbolt"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// sqliteApplicationID marks a SQLite file as a WUT database ("WUT1")
+const sqliteApplicationID = 0x57555431
+
+// sqliteCursorChunk is how many rows a cursor reads ahead at most
+const sqliteCursorChunk = 512
+
+// sqliteMigrations create and upgrade the schema, oldest first. A
+// database's user_version is the number of them it has applied, so new
+// migrations are only ever appended.
+var sqliteMigrations = []string{
+	// 1: the buckets of keys Storage is written against, plus the number
+	// of the last write for Revision
+	`CREATE TABLE buckets (
+		name BLOB PRIMARY KEY
+	) WITHOUT ROWID;
+	CREATE TABLE entries (
+		bucket BLOB NOT NULL,
+		key    BLOB NOT NULL,
+		value  BLOB NOT NULL,
+		PRIMARY KEY (bucket, key)
+	) WITHOUT ROWID;
+	CREATE TABLE revision (
+		id INTEGER NOT NULL
+	);
+	INSERT INTO revision (id) VALUES (0);`,
+}
+
+// sqliteDB keeps Storage's buckets in a SQLite database. Keys are BLOBs,
+// which SQLite compares byte by byte, so they sort the way they do in bbolt.
+type sqliteDB struct {
+	db       *sql.DB
+	readOnly bool
+}
+
+func openSQLite(path string, timeout time.Duration, readOnly bool) (kvDB, error) {
+	if !readOnly {
+		// SQLite would create the file readable by everyone
+		f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+		if err != nil {
+			return nil, err
+		}
+		f.Close()
+	} else if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open("sqlite", sqliteDSN(path, timeout, readOnly))
+	if err != nil {
+		return nil, err
+	}
+	d := &sqliteDB{db: db, readOnly: readOnly}
+	if err := d.migrate(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return d, nil
+}
+
+// sqliteDSN returns the URI that opens path. The busy timeout makes
+// transactions wait for another process's write the way bbolt waits for its
+// file lock.
+func sqliteDSN(path string, timeout time.Duration, readOnly bool) string {
+	escaped := strings.NewReplacer("%", "%25", "?", "%3f", "#", "%23").Replace(filepath.ToSlash(path))
+	if filepath.VolumeName(path) != "" {
+		escaped = "/" + escaped
+	}
+	dsn := fmt.Sprintf("file:%s?_busy_timeout=%d", escaped, timeout.Milliseconds())
+	if readOnly {
+		dsn += "&mode=ro"
+	}
+	return dsn
+}
+
+// migrate brings the schema up to date. Opening for writing takes the write
+// lock even when there is nothing to do, so it fails like bbolt does while
+// another process is writing.
+func (d *sqliteDB) migrate() error {
+	ctx := context.Background()
+	conn, err := d.db.Conn(ctx)
+	if err != nil {
+		return sqliteError(err)
+	}
+	defer conn.Close()
+
+	if d.readOnly {
+		return migrateSQLite(ctx, conn, false)
+	}
+	if _, err := conn.ExecContext(ctx, "BEGIN IMMEDIATE"); err != nil {
+		return sqliteError(err)
+	}
+	if err := migrateSQLite(ctx, conn, true); err != nil {
+		_, _ = conn.ExecContext(ctx, "ROLLBACK")
+		return err
+	}
+	_, err = conn.ExecContext(ctx, "COMMIT")
+	return sqliteError(err)
+}
+
+// migrateSQLite applies the migrations the database has not had yet. Without
+// writable it only checks that there are none.
+func migrateSQLite(ctx context.Context, conn *sql.Conn, writable bool) error {
+	var version, appID int
+	if err := conn.QueryRowContext(ctx, "PRAGMA user_version").Scan(&version); err != nil {
+		return sqliteError(err)
+	}
+	if err := conn.QueryRowContext(ctx, "PRAGMA application_id").Scan(&appID); err != nil {
+		return sqliteError(err)
+	}
+
+	switch {
+	case version > len(sqliteMigrations):
+		return fmt.Errorf("database schema version %d is newer than this wut supports (%d)", version, len(sqliteMigrations))
+	case version > 0 && appID != sqliteApplicationID:
+		return errors.New("not a WUT database")
+	case version == len(sqliteMigrations):
+		return nil
+	case !writable:
+		return fmt.Errorf("database schema version %d needs upgrading; open it for writing first", version)
+	}
+
+	if version == 0 {
+		var tables int
+		if err := conn.QueryRowContext(ctx, "SELECT count(*) FROM sqlite_master").Scan(&tables); err != nil {
+			return sqliteError(err)
+		}
+		if tables > 0 {
+			return errors.New("not a WUT database")
+		}
+	}
+
+	for i := version; i < len(sqliteMigrations); i++ {
+		if _, err := conn.ExecContext(ctx, sqliteMigrations[i]); err != nil {
+			return fmt.Errorf("failed to migrate database schema to version %d: %w", i+1, err)
+		}
+	}
+	if _, err := conn.ExecContext(ctx, fmt.Sprintf("PRAGMA user_version = %d", len(sqliteMigrations))); err != nil {
+		return sqliteError(err)
+	}
+	_, err := conn.ExecContext(ctx, fmt.Sprintf("PRAGMA application_id = %d", sqliteApplicationID))
+	return sqliteError(err)
+}
+
+// sqliteError reports SQLite's "database is locked" the way a bbolt lock
+// timeout is reported
+func sqliteError(err error) error {
+	var e *sqlite.Error
+	if errors.As(err, &e) && e.Code()&0xff == sqlite3.SQLITE_BUSY {
+		return errLockTimeout
+	}
+	return err
+}
+
+func (d *sqliteDB) View(fn func(kvTx) error) error {
+	return d.run(false, fn)
+}
+
+func (d *sqliteDB) Update(fn func(kvTx) error) error {
+	if d.readOnly {
+		return bbolt.ErrDatabaseReadOnly
+	}
+	return d.run(true, fn)
+}
+
+func (d *sqliteDB) Batch(fn func(kvTx) error) error {
+	return d.Update(fn)
+}
+
+// run runs fn in a transaction on a connection of its own. A write
+// transaction takes the write lock up front, so it never fails halfway
+// through because another process wrote first.
+func (d *sqliteDB) run(writable bool, fn func(kvTx) error) error {
+	ctx := context.Background()
+	conn, err := d.db.Conn(ctx)
+	if err != nil {
+		return sqliteError(err)
+	}
+	defer conn.Close()
+
+	begin := "BEGIN"
+	if writable {
+		begin = "BEGIN IMMEDIATE"
+	}
+	if _, err := conn.ExecContext(ctx, begin); err != nil {
+		return sqliteError(err)
+	}
+
+	tx := &sqliteTx{conn: conn, writable: writable, stmts: make(map[string]*sql.Stmt)}
+	committed := false
+	defer func() {
+		tx.closeStmts()
+		if !committed {
+			_, _ = conn.ExecContext(ctx, "ROLLBACK")
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if tx.err != nil {
+		return tx.err
+	}
+	if writable {
+		if _, err := tx.exec("UPDATE revision SET id = id + 1"); err != nil {
+			return err
+		}
+	}
+	tx.closeStmts()
+	if _, err := conn.ExecContext(ctx, "COMMIT"); err != nil {
+		return sqliteError(err)
+	}
+	committed = true
+	return nil
+}
+
+func (d *sqliteDB) IsReadOnly() bool { return d.readOnly }
+func (d *sqliteDB) Close() error     { return d.db.Close() }
+func (d *sqliteDB) Engine() string   { return EngineSQLite }
+
+// UsedSize is the size of the file; SQLite does not preallocate
+func (d *sqliteDB) UsedSize() int64 {
+	return d.pragmaSize("page_count")
+}
+
+// LiveSize leaves out the pages on the free list
+func (d *sqliteDB) LiveSize() int64 {
+	return d.pragmaSize("page_count") - d.pragmaSize("freelist_count")
+}
+
+// pragmaSize returns a page count pragma in bytes
+func (d *sqliteDB) pragmaSize(pragma string) int64 {
+	var pages, pageSize int64
+	if err := d.db.QueryRow("PRAGMA " + pragma).Scan(&pages); err != nil {
+		return 0
+	}
+	if err := d.db.QueryRow("PRAGMA page_size").Scan(&pageSize); err != nil {
+		return 0
+	}
+	return pages * pageSize
+}
+
+// Compact rebuilds the database in place
+func (d *sqliteDB) Compact(string) (kvDB, error) {
+	if _, err := d.db.Exec("VACUUM"); err != nil {
+		return d, fmt.Errorf("failed to compact database: %w", sqliteError(err))
+	}
+	return d, nil
+}
+
+// WriteTo has SQLite write a consistent copy to a temporary file first,
+// since it can only copy a database to a path
+func (d *sqliteDB) WriteTo(w io.Writer) (int64, error) {
+	dir, err := os.MkdirTemp("", "wut-sqlite-*")
+	if err != nil {
+		return 0, err
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "copy.db")
+	if _, err := d.db.Exec("VACUUM INTO ?", path); err != nil {
+		return 0, sqliteError(err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	return io.Copy(w, f)
+}
+
+// sqliteTx is a transaction of a sqliteDB. kvBucket has no room for errors
+// on reads, so the first one is kept and fails the transaction.
+type sqliteTx struct {
+	conn     *sql.Conn
+	writable bool
+	stmts    map[string]*sql.Stmt
+	err      error
+}
+
+// stmt returns query prepared on the transaction's connection
+func (tx *sqliteTx) stmt(query string) (*sql.Stmt, error) {
+	if s, ok := tx.stmts[query]; ok {
+		return s, nil
+	}
+	s, err := tx.conn.PrepareContext(context.Background(), query)
+	if err != nil {
+		return nil, sqliteError(err)
+	}
+	tx.stmts[query] = s
+	return s, nil
+}
+
+func (tx *sqliteTx) closeStmts() {
+	for query, s := range tx.stmts {
+		s.Close()
+		delete(tx.stmts, query)
+	}
+}
+
+func (tx *sqliteTx) exec(query string, args ...any) (sql.Result, error) {
+	s, err := tx.stmt(query)
+	if err != nil {
+		return nil, err
+	}
+	result, err := s.Exec(args...)
+	return result, sqliteError(err)
+}
+
+// fail keeps the first error a read ran into
+func (tx *sqliteTx) fail(err error) {
+	if tx.err == nil {
+		tx.err = sqliteError(err)
+	}
+}
+
+// rows runs a query and returns every row of its two BLOB columns
+func (tx *sqliteTx) rows(query string, args ...any) ([][2][]byte, error) {
+	s, err := tx.stmt(query)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.Query(args...)
+	if err != nil {
+		return nil, sqliteError(err)
+	}
+	defer rows.Close()
+
+	var result [][2][]byte
+	for rows.Next() {
+		var row [2][]byte
+		if err := rows.Scan(&row[0], &row[1]); err != nil {
+			return nil, err
+		}
+		if row[1] == nil {
+			row[1] = []byte{}
+		}
+		result = append(result, row)
+	}
+	return result, sqliteError(rows.Err())
+}
+
+func (tx *sqliteTx) bucketExists(name []byte) (bool, error) {
+	s, err := tx.stmt("SELECT count(*) FROM buckets WHERE name = ?")
+	if err != nil {
+		return false, err
+	}
+	var n int
+	if err := s.QueryRow(name).Scan(&n); err != nil {
+		return false, sqliteError(err)
+	}
+	return n > 0, nil
+}
+
+func (tx *sqliteTx) Bucket(name []byte) kvBucket {
+	exists, err := tx.bucketExists(name)
+	if err != nil {
+		tx.fail(err)
+		return nil
+	}
+	if !exists {
+		return nil
+	}
+	return &sqliteBucket{tx: tx, name: append([]byte(nil), name...)}
+}
+
+func (tx *sqliteTx) CreateBucket(name []byte) (kvBucket, error) {
+	if !tx.writable {
+		return nil, bbolt.ErrTxNotWritable
+	}
+	if len(name) == 0 {
+		return nil, bbolt.ErrBucketNameRequired
+	}
+	exists, err := tx.bucketExists(name)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, bbolt.ErrBucketExists
+	}
+	if _, err := tx.exec("INSERT INTO buckets (name) VALUES (?)", name); err != nil {
+		return nil, err
+	}
+	return &sqliteBucket{tx: tx, name: append([]byte(nil), name...)}, nil
+}
+
+func (tx *sqliteTx) CreateBucketIfNotExists(name []byte) (kvBucket, error) {
+	if b := tx.Bucket(name); b != nil {
+		return b, nil
+	}
+	if tx.err != nil {
+		return nil, tx.err
+	}
+	return tx.CreateBucket(name)
+}
+
+func (tx *sqliteTx) DeleteBucket(name []byte) error {
+	if !tx.writable {
+		return bbolt.ErrTxNotWritable
+	}
+	result, err := tx.exec("DELETE FROM buckets WHERE name = ?", name)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return bbolt.ErrBucketNotFound
+	}
+	_, err = tx.exec("DELETE FROM entries WHERE bucket = ?", name)
+	return err
+}
+
+func (tx *sqliteTx) ForEach(fn func(name []byte, b kvBucket) error) error {
+	names, err := tx.rows("SELECT name, NULL FROM buckets ORDER BY name")
+	if err != nil {
+		return err
+	}
+	for _, row := range names {
+		if err := fn(row[0], &sqliteBucket{tx: tx, name: row[0]}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (tx *sqliteTx) ID() int {
+	s, err := tx.stmt("SELECT id FROM revision")
+	if err != nil {
+		tx.fail(err)
+		return 0
+	}
+	var id int
+	if err := s.QueryRow().Scan(&id); err != nil {
+		tx.fail(err)
+	}
+	return id
+}
+
+type sqliteBucket struct {
+	tx   *sqliteTx
+	name []byte
+}
+
+func (b *sqliteBucket) Get(key []byte) []byte {
+	s, err := b.tx.stmt("SELECT value FROM entries WHERE bucket = ? AND key = ?")
+	if err != nil {
+		b.tx.fail(err)
+		return nil
+	}
+	var value []byte
+	err = s.QueryRow(b.name, key).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		b.tx.fail(err)
+		return nil
+	}
+	if value == nil {
+		value = []byte{}
+	}
+	return value
+}
+
+func (b *sqliteBucket) Put(key, value []byte) error {
+	switch {
+	case !b.tx.writable:
+		return bbolt.ErrTxNotWritable
+	case len(key) == 0:
+		return bbolt.ErrKeyRequired
+	case len(key) > bbolt.MaxKeySize:
+		return bbolt.ErrKeyTooLarge
+	case int64(len(value)) > bbolt.MaxValueSize:
+		return bbolt.ErrValueTooLarge
+	}
+	if value == nil {
+		value = []byte{}
+	}
+	_, err := b.tx.exec(`INSERT INTO entries (bucket, key, value) VALUES (?, ?, ?)
+		ON CONFLICT (bucket, key) DO UPDATE SET value = excluded.value`, b.name, key, value)
+	return err
+}
+
+func (b *sqliteBucket) Delete(key []byte) error {
+	if !b.tx.writable {
+		return bbolt.ErrTxNotWritable
+	}
+	_, err := b.tx.exec("DELETE FROM entries WHERE bucket = ? AND key = ?", b.name, key)
+	return err
+}
+
+func (b *sqliteBucket) ForEach(fn func(k, v []byte) error) error {
+	c := b.Cursor()
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		if err := fn(k, v); err != nil {
+			return err
+		}
+	}
+	return b.tx.err
+}
+
+func (b *sqliteBucket) Cursor() kvCursor {
+	return &sqliteCursor{b: b}
+}
+
+func (b *sqliteBucket) KeyN() int {
+	s, err := b.tx.stmt("SELECT count(*) FROM entries WHERE bucket = ?")
+	if err != nil {
+		b.tx.fail(err)
+		return 0
+	}
+	var n int
+	if err := s.QueryRow(b.name).Scan(&n); err != nil {
+		b.tx.fail(err)
+	}
+	return n
+}
+
+// sqliteCursor reads rows ahead in the direction it moves, a few at first
+// and more the further it goes, so a cursor that only looks at the last key
+// reads one row
+type sqliteCursor struct {
+	b     *sqliteBucket
+	rows  [][2][]byte
+	pos   int
+	desc  bool // rows were read in descending key order
+	chunk int
+}
+
+func (c *sqliteCursor) First() ([]byte, []byte) { return c.read(nil, false, true) }
+func (c *sqliteCursor) Last() ([]byte, []byte)  { return c.read(nil, true, true) }
+
+func (c *sqliteCursor) Seek(seek []byte) ([]byte, []byte) {
+	return c.read(seek, false, true)
+}
+
+func (c *sqliteCursor) Next() ([]byte, []byte) {
+	if c.pos >= len(c.rows) {
+		return nil, nil
+	}
+	if !c.desc && c.pos+1 < len(c.rows) {
+		c.pos++
+		return c.current()
+	}
+	return c.read(c.rows[c.pos][0], false, false)
+}
+
+func (c *sqliteCursor) Prev() ([]byte, []byte) {
+	if c.pos >= len(c.rows) {
+		return nil, nil
+	}
+	if c.desc && c.pos+1 < len(c.rows) {
+		c.pos++
+		return c.current()
+	}
+	return c.read(c.rows[c.pos][0], true, false)
+}
+
+func (c *sqliteCursor) current() ([]byte, []byte) {
+	if c.pos >= len(c.rows) {
+		return nil, nil
+	}
+	return c.rows[c.pos][0], c.rows[c.pos][1]
+}
+
+// read loads the rows from the bound on, in descending order when desc is
+// set. A nil bound starts at the first or last key.
+func (c *sqliteCursor) read(bound []byte, desc, inclusive bool) ([]byte, []byte) {
+	if bound == nil || inclusive {
+		c.chunk = 1
+	} else {
+		c.chunk = min(c.chunk*4, sqliteCursorChunk)
+	}
+
+	query := "SELECT key, value FROM entries WHERE bucket = ?"
+	args := []any{c.b.name}
+	if bound != nil {
+		op := ">"
+		if desc {
+			op = "<"
+		}
+		if inclusive {
+			op += "="
+		}
+		query += " AND key " + op + " ?"
+		args = append(args, bound)
+	}
+	if desc {
+		query += " ORDER BY key DESC LIMIT ?"
+	} else {
+		query += " ORDER BY key LIMIT ?"
+	}
+	args = append(args, c.chunk)
+
+	rows, err := c.b.tx.rows(query, args...)
+	if err != nil {
+		c.b.tx.fail(err)
+		rows = nil
+	}
+	c.rows, c.pos, c.desc = rows, 0, desc
+	return c.current()
+}
@@ -2,14 +2,18 @@
 package db
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/goccy/go-json"
 	"go.etcd.io/bbolt"
+
+	"wut/internal/logger"
 )
 
 const (
@@ -17,14 +21,46 @@ const (
 	metadataBucket = "tldr_metadata"
 )
 
+// lockTimeout bounds how long opening a database waits for another process
+// to release its lock
+var lockTimeout = 1 * time.Second
+
 var errStopScan = errors.New("stop scan")
 
-// Storage provides local storage for TLDR pages
+// ErrDatabaseLocked is returned when another process holds the database lock
+// for longer than the open timeout
+var ErrDatabaseLocked = errors.New("database is locked")
+
+// Storage provides local storage for TLDR pages, history and the rest of
+// WUT's data in a bbolt or SQLite database
 type Storage struct {
-	db   *bbolt.DB
+	db   kvDB
 	path string
+
+	encryption *EncryptionHeader // nil unless the history is encrypted
+	cipher     *historyCipher    // nil while encrypted history is locked
+	lockErr    error             // why encrypted history is locked
+	keyMu      sync.Mutex        // guards loading the key on first use
+	keyLoaded  bool              // whether the key was looked up yet
 }
 
+// Store is what the history and page commands need from a database. Storage
+// provides it on either storage engine.
+type Store interface {
+	AddHistory(ctx context.Context, command string) error
+	GetHistory(ctx context.Context, limit int) ([]CommandExecution, error)
+	ClearHistory(ctx context.Context) error
+	ExportHistory(ctx context.Context, filepath string) error
+	ImportHistory(ctx context.Context, filepath string) error
+	GetHistoryStats(ctx context.Context) (*HistoryStats, error)
+	SavePage(page *Page) error
+	GetPage(name, platform, language string) (*Page, error)
+	GetAllPages() ([]StoredPage, error)
+	Close() error
+}
+
+var _ Store = (*Storage)(nil)
+
 // StoredPage represents a TLDR page stored locally
 type StoredPage struct {
 	Name        string    `json:"name"`
@@ -85,17 +121,20 @@ func summaryToStoredPage(summary storedPageSummary) StoredPage {
 	}
 }
 
-// NewStorage creates a new TLDR storage
+// NewStorage opens the database at dbPath, creating it with the engine
+// picked by SetEngine when it does not exist yet
 func NewStorage(dbPath string) (*Storage, error) {
-	db, err := bbolt.Open(dbPath, 0600, &bbolt.Options{
-		Timeout: 1 * time.Second,
-	})
+	defer logger.With("db").Timed("open storage", "path", dbPath)()
+	db, err := openKV(dbPath, lockTimeout, false)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open database: %w", err)
+		return nil, openError(dbPath, err)
+	}
+	if engine := db.Engine(); engine != newEngine {
+		logger.With("db").Debug("database uses another engine than database.type", "path", dbPath, "engine", engine)
 	}
 
 	// Create buckets
-	err = db.Update(func(tx *bbolt.Tx) error {
+	err = db.Update(func(tx kvTx) error {
 		if _, err := tx.CreateBucketIfNotExists([]byte(tldrBucketName)); err != nil {
 			return fmt.Errorf("create tldr bucket: %w", err)
 		}
@@ -109,10 +148,98 @@ func NewStorage(dbPath string) (*Storage, error) {
 		return nil, err
 	}
 
-	return &Storage{
+	storage := &Storage{
 		db:   db,
 		path: dbPath,
-	}, nil
+	}
+	if err := storage.loadEncryption(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to read encryption header: %w", err)
+	}
+	if err := storage.enforceMaxSize(context.Background()); err != nil {
+		logger.With("db").Warn("failed to enforce database.max_size", "path", dbPath, "error", err)
+	}
+	return storage, nil
+}
+
+// OpenReadOnly opens an existing database without creating buckets. It
+// shares the file with other readers and gives up after timeout when a
+// writer holds it, for callers such as shell completion that must not block.
+func OpenReadOnly(dbPath string, timeout time.Duration) (*Storage, error) {
+	defer logger.With("db").Timed("open storage read-only", "path", dbPath)()
+	db, err := openKV(dbPath, timeout, true)
+	if err != nil {
+		return nil, openError(dbPath, err)
+	}
+
+	storage := &Storage{
+		db:   db,
+		path: dbPath,
+	}
+	if err := storage.loadEncryption(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to read encryption header: %w", err)
+	}
+	return storage, nil
+}
+
+// OpenForReading opens a database for a command that mostly reads it. When
+// another wut instance holds the database, it falls back to a read-only
+// handle shared with other readers; writes through that handle fail with
+// bbolt.ErrDatabaseReadOnly and can be skipped.
+func OpenForReading(dbPath string) (*Storage, error) {
+	storage, err := NewStorage(dbPath)
+	if !errors.Is(err, ErrDatabaseLocked) {
+		return storage, err
+	}
+	return OpenReadOnly(dbPath, lockTimeout)
+}
+
+// IsReadOnly reports whether the storage was opened read-only
+func (s *Storage) IsReadOnly() bool {
+	return s.db.IsReadOnly()
+}
+
+// openError explains why a database could not be opened
+func openError(dbPath string, err error) error {
+	if errors.Is(err, errLockTimeout) {
+		return fmt.Errorf("another wut instance is using the database at %s: %w", dbPath, ErrDatabaseLocked)
+	}
+	return fmt.Errorf("failed to open database: %w", err)
+}
+
+// view runs fn in a read transaction unless ctx is done
+func (s *Storage) view(ctx context.Context, fn func(kvTx) error) error {
+	if err := contextErr(ctx); err != nil {
+		return err
+	}
+	return s.db.View(func(tx kvTx) error {
+		if err := fn(tx); err != nil {
+			return err
+		}
+		return contextErr(ctx)
+	})
+}
+
+// update runs fn in a write transaction. The transaction is rolled back when
+// ctx is done before it commits.
+func (s *Storage) update(ctx context.Context, fn func(kvTx) error) error {
+	if err := contextErr(ctx); err != nil {
+		return err
+	}
+	return s.db.Update(func(tx kvTx) error {
+		if err := fn(tx); err != nil {
+			return err
+		}
+		return contextErr(ctx)
+	})
+}
+
+func contextErr(ctx context.Context) error {
+	if ctx == nil {
+		return nil
+	}
+	return ctx.Err()
 }
 
 // Close closes the storage
@@ -120,6 +247,18 @@ func (s *Storage) Close() error {
 	return s.db.Close()
 }
 
+// Revision returns the number of the last write to the database. It changes
+// whenever history or anything else is written, so a process that reopens
+// the database can tell whether what it read before is still current.
+func (s *Storage) Revision() (int, error) {
+	var rev int
+	err := s.db.View(func(tx kvTx) error {
+		rev = tx.ID()
+		return nil
+	})
+	return rev, err
+}
+
 // SavePage saves a TLDR page to local storage
 func (s *Storage) SavePage(page *Page) error {
 	stored := StoredPage{
@@ -139,7 +278,7 @@ func (s *Storage) SavePage(page *Page) error {
 
 	key := pageKey(page.Language, page.Platform, page.Name)
 
-	return s.db.Update(func(tx *bbolt.Tx) error {
+	return s.db.Update(func(tx kvTx) error {
 		bucket := tx.Bucket([]byte(tldrBucketName))
 		return bucket.Put([]byte(key), data)
 	})
@@ -147,7 +286,7 @@ func (s *Storage) SavePage(page *Page) error {
 
 // SavePages saves multiple TLDR pages to local storage in a single transaction
 func (s *Storage) SavePages(pages []*Page) error {
-	return s.db.Update(func(tx *bbolt.Tx) error {
+	return s.db.Update(func(tx kvTx) error {
 		bucket := tx.Bucket([]byte(tldrBucketName))
 		for _, page := range pages {
 			stored := StoredPage{
@@ -183,7 +322,7 @@ func (s *Storage) GetPage(name, platform, language string) (*Page, error) {
 	key := pageKey(language, platform, name)
 
 	var stored StoredPage
-	err := s.db.View(func(tx *bbolt.Tx) error {
+	err := s.db.View(func(tx kvTx) error {
 		bucket := tx.Bucket([]byte(tldrBucketName))
 		data := bucket.Get([]byte(key))
 
@@ -231,8 +370,11 @@ func (s *Storage) GetPageAnyPlatform(name, language string) (*Page, error) {
 	}
 
 	var stored StoredPage
-	err := s.db.View(func(tx *bbolt.Tx) error {
+	err := s.db.View(func(tx kvTx) error {
 		bucket := tx.Bucket([]byte(tldrBucketName))
+		if bucket == nil {
+			return fmt.Errorf("page not found")
+		}
 		languages := []string{language}
 		if language != "en" {
 			languages = append(languages, "en")
@@ -272,7 +414,7 @@ func (s *Storage) PageExists(name, platform, language string) bool {
 	key := pageKey(language, platform, name)
 	exists := false
 
-	err := s.db.View(func(tx *bbolt.Tx) error {
+	err := s.db.View(func(tx kvTx) error {
 		bucket := tx.Bucket([]byte(tldrBucketName))
 		exists = bucket.Get([]byte(key)) != nil
 		return nil
@@ -302,7 +444,7 @@ func (s *Storage) PageExistsAnyPlatform(name, language string) bool {
 
 	exists := false
 
-	_ = s.db.View(func(tx *bbolt.Tx) error {
+	_ = s.db.View(func(tx kvTx) error {
 		bucket := tx.Bucket([]byte(tldrBucketName))
 		languages := []string{language}
 		if language != "en" {
@@ -331,7 +473,7 @@ func (s *Storage) IsPageStale(name, platform, language string, maxAge time.Durat
 	key := pageKey(language, platform, name)
 	isStale := true
 
-	_ = s.db.View(func(tx *bbolt.Tx) error {
+	_ = s.db.View(func(tx kvTx) error {
 		bucket := tx.Bucket([]byte(tldrBucketName))
 		data := bucket.Get([]byte(key))
 		if data == nil {
@@ -354,7 +496,7 @@ func (s *Storage) IsPageStale(name, platform, language string, maxAge time.Durat
 func (s *Storage) GetAllPages() ([]StoredPage, error) {
 	var pages []StoredPage
 
-	err := s.db.View(func(tx *bbolt.Tx) error {
+	err := s.db.View(func(tx kvTx) error {
 		bucket := tx.Bucket([]byte(tldrBucketName))
 		return bucket.ForEach(func(k, v []byte) error {
 			var stored StoredPage
@@ -373,7 +515,7 @@ func (s *Storage) GetAllPages() ([]StoredPage, error) {
 func (s *Storage) GetPageSummaries(limit int) ([]StoredPage, error) {
 	var pages []StoredPage
 
-	err := s.db.View(func(tx *bbolt.Tx) error {
+	err := s.db.View(func(tx kvTx) error {
 		bucket := tx.Bucket([]byte(tldrBucketName))
 		return bucket.ForEach(func(k, v []byte) error {
 			var summary storedPageSummary
@@ -399,8 +541,11 @@ func (s *Storage) ListCommands(limit int) ([]string, error) {
 	seen := make(map[string]struct{})
 	commands := make([]string, 0)
 
-	err := s.db.View(func(tx *bbolt.Tx) error {
+	err := s.db.View(func(tx kvTx) error {
 		bucket := tx.Bucket([]byte(tldrBucketName))
+		if bucket == nil {
+			return nil
+		}
 		return bucket.ForEach(func(k, v []byte) error {
 			_, _, name, ok := parsePageKey(k)
 			if !ok {
@@ -433,7 +578,7 @@ func (s *Storage) GetPagesByPlatform(platform string) ([]StoredPage, error) {
 	var pages []StoredPage
 	platform = strings.ToLower(strings.TrimSpace(platform))
 
-	err := s.db.View(func(tx *bbolt.Tx) error {
+	err := s.db.View(func(tx kvTx) error {
 		bucket := tx.Bucket([]byte(tldrBucketName))
 		return bucket.ForEach(func(k, v []byte) error {
 			_, keyPlatform, _, ok := parsePageKey(k)
@@ -457,7 +602,7 @@ func (s *Storage) DeletePage(name, platform, language string) error {
 		language = "en"
 	}
 	key := pageKey(language, platform, name)
-	return s.db.Update(func(tx *bbolt.Tx) error {
+	return s.db.Update(func(tx kvTx) error {
 		bucket := tx.Bucket([]byte(tldrBucketName))
 		return bucket.Delete([]byte(key))
 	})
@@ -465,7 +610,7 @@ func (s *Storage) DeletePage(name, platform, language string) error {
 
 // ClearAll removes all pages from local storage
 func (s *Storage) ClearAll() error {
-	return s.db.Update(func(tx *bbolt.Tx) error {
+	return s.db.Update(func(tx kvTx) error {
 		for _, bucketName := range []string{tldrBucketName, metadataBucket} {
 			if err := tx.DeleteBucket([]byte(bucketName)); err != nil && !errors.Is(err, bbolt.ErrBucketNotFound) {
 				return err
@@ -485,7 +630,7 @@ func (s *Storage) SaveMetadata(meta *Metadata) error {
 		return fmt.Errorf("failed to marshal metadata: %w", err)
 	}
 
-	return s.db.Update(func(tx *bbolt.Tx) error {
+	return s.db.Update(func(tx kvTx) error {
 		bucket := tx.Bucket([]byte(metadataBucket))
 		return bucket.Put([]byte("metadata"), data)
 	})
@@ -494,7 +639,7 @@ func (s *Storage) SaveMetadata(meta *Metadata) error {
 // GetMetadata retrieves metadata from storage
 func (s *Storage) GetMetadata() (*Metadata, error) {
 	var meta Metadata
-	err := s.db.View(func(tx *bbolt.Tx) error {
+	err := s.db.View(func(tx kvTx) error {
 		bucket := tx.Bucket([]byte(metadataBucket))
 		data := bucket.Get([]byte("metadata"))
 		if data == nil {
@@ -518,7 +663,7 @@ func (s *Storage) GetStats() (map[string]any, error) {
 	platforms := map[string]int{}
 	totalPages := 0
 
-	err := s.db.View(func(tx *bbolt.Tx) error {
+	err := s.db.View(func(tx kvTx) error {
 		bucket := tx.Bucket([]byte(tldrBucketName))
 		return bucket.ForEach(func(k, v []byte) error {
 			_, platform, _, ok := parsePageKey(k)
@@ -548,7 +693,7 @@ func (s *Storage) GetStats() (map[string]any, error) {
 func (s *Storage) CountPages() (int, error) {
 	totalPages := 0
 
-	err := s.db.View(func(tx *bbolt.Tx) error {
+	err := s.db.View(func(tx kvTx) error {
 		bucket := tx.Bucket([]byte(tldrBucketName))
 		return bucket.ForEach(func(k, v []byte) error {
 			if _, _, _, ok := parsePageKey(k); ok {
@@ -569,7 +714,7 @@ func (s *Storage) ListStalePages(maxAge time.Duration, limit int) ([]PageRef, er
 	stalePages := make([]PageRef, 0)
 	now := time.Now()
 
-	err := s.db.View(func(tx *bbolt.Tx) error {
+	err := s.db.View(func(tx kvTx) error {
 		bucket := tx.Bucket([]byte(tldrBucketName))
 		return bucket.ForEach(func(k, v []byte) error {
 			language, platform, name, ok := parsePageKey(k)
@@ -617,7 +762,7 @@ func (s *Storage) SearchLocalLimited(query string, limit int) ([]StoredPage, err
 	var results []StoredPage
 	queryLower := strings.ToLower(strings.TrimSpace(query))
 
-	err := s.db.View(func(tx *bbolt.Tx) error {
+	err := s.db.View(func(tx kvTx) error {
 		bucket := tx.Bucket([]byte(tldrBucketName))
 		return bucket.ForEach(func(k, v []byte) error {
 			_, _, keyName, ok := parsePageKey(k)
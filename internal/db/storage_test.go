@@ -0,0 +1,80 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewStorageLocked(t *testing.T) {
+	defer func(timeout time.Duration) { lockTimeout = timeout }(lockTimeout)
+	lockTimeout = 100 * time.Millisecond
+
+	path := filepath.Join(t.TempDir(), "wut.db")
+	held := make(chan *Storage)
+	go func() {
+		storage, err := NewStorage(path)
+		if err != nil {
+			t.Errorf("NewStorage() error = %v", err)
+		}
+		held <- storage
+	}()
+	holder := <-held
+	if holder == nil {
+		t.FailNow()
+	}
+
+	start := time.Now()
+	_, err := NewStorage(path)
+	if !errors.Is(err, ErrDatabaseLocked) {
+		t.Fatalf("second NewStorage() error = %v, want ErrDatabaseLocked", err)
+	}
+	if !strings.Contains(err.Error(), "another wut instance is using the database at "+path) {
+		t.Errorf("error = %q, want it to name the database", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("second NewStorage() took %v, want it to give up after the lock timeout", elapsed)
+	}
+	holder.Close()
+
+	// A read-only holder leaves room for other readers
+	reader, err := OpenReadOnly(path, lockTimeout)
+	if err != nil {
+		t.Fatalf("OpenReadOnly() error = %v", err)
+	}
+	defer reader.Close()
+	shared, err := OpenForReading(path)
+	if err != nil {
+		t.Fatalf("OpenForReading() next to a reader error = %v", err)
+	}
+	defer shared.Close()
+	if !shared.IsReadOnly() {
+		t.Error("OpenForReading() next to a reader returned a writable handle")
+	}
+	if _, err := shared.GetHistory(context.Background(), 10); err != nil {
+		t.Errorf("GetHistory() on the shared handle error = %v", err)
+	}
+}
+
+func TestStorageRespectsContext(t *testing.T) {
+	storage, err := NewStorage(filepath.Join(t.TempDir(), "wut.db"))
+	if err != nil {
+		t.Fatalf("NewStorage() error = %v", err)
+	}
+	defer storage.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := storage.SaveAlias(ctx, "deploy", "git push", ""); !errors.Is(err, context.Canceled) {
+		t.Errorf("SaveAlias() with a cancelled context error = %v", err)
+	}
+	if _, err := storage.ListAliases(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("ListAliases() with a cancelled context error = %v", err)
+	}
+	if aliases, _ := storage.ListAliases(context.Background()); len(aliases) != 0 {
+		t.Errorf("cancelled SaveAlias() still saved %+v", aliases)
+	}
+}
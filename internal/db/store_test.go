@@ -0,0 +1,458 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"go.etcd.io/bbolt"
+)
+
+// useEngine makes the databases the test creates use engine
+func useEngine(t *testing.T, engine string) {
+	t.Helper()
+	old := newEngine
+	SetEngine(engine)
+	t.Cleanup(func() { newEngine = old })
+}
+
+// forEachEngine runs test once per storage engine with a path for a new
+// database
+func forEachEngine(t *testing.T, test func(t *testing.T, path string)) {
+	for _, engine := range Engines {
+		t.Run(engine, func(t *testing.T) {
+			useEngine(t, engine)
+			test(t, filepath.Join(t.TempDir(), "wut.db"))
+		})
+	}
+}
+
+func TestStoreHistory(t *testing.T) {
+	forEachEngine(t, func(t *testing.T, path string) {
+		ctx := context.Background()
+		storage, err := NewStorage(path)
+		if err != nil {
+			t.Fatalf("NewStorage() error = %v", err)
+		}
+		defer storage.Close()
+		var store Store = storage
+
+		for _, command := range []string{"git status", "ls -la", "git status"} {
+			if err := store.AddHistory(ctx, command); err != nil {
+				t.Fatalf("AddHistory(%q) error = %v", command, err)
+			}
+		}
+		history, err := store.GetHistory(ctx, 2)
+		if err != nil {
+			t.Fatalf("GetHistory() error = %v", err)
+		}
+		if len(history) != 2 || history[0].Command != "git status" || history[1].Command != "ls -la" {
+			t.Errorf("GetHistory(2) = %v, want the two newest entries newest first", history)
+		}
+
+		stats, err := store.GetHistoryStats(ctx)
+		if err != nil {
+			t.Fatalf("GetHistoryStats() error = %v", err)
+		}
+		if stats.TotalExecutions != 3 || stats.UniqueCommands != 2 || stats.MostUsedCommand != "git status" {
+			t.Errorf("stats = %d executions, %d unique, most used %q; want 3, 2, \"git status\"",
+				stats.TotalExecutions, stats.UniqueCommands, stats.MostUsedCommand)
+		}
+
+		export := filepath.Join(t.TempDir(), "history.json")
+		if err := store.ExportHistory(ctx, export); err != nil {
+			t.Fatalf("ExportHistory() error = %v", err)
+		}
+		if err := store.ClearHistory(ctx); err != nil {
+			t.Fatalf("ClearHistory() error = %v", err)
+		}
+		if history, _ := store.GetHistory(ctx, 10); len(history) != 0 {
+			t.Fatalf("GetHistory() after ClearHistory() = %d entries, want 0", len(history))
+		}
+		if err := store.ImportHistory(ctx, export); err != nil {
+			t.Fatalf("ImportHistory() error = %v", err)
+		}
+		if history, _ := store.GetHistory(ctx, 10); len(history) != 3 {
+			t.Errorf("GetHistory() after ImportHistory() = %d entries, want 3", len(history))
+		}
+	})
+}
+
+func TestStorePages(t *testing.T) {
+	forEachEngine(t, func(t *testing.T, path string) {
+		storage, err := NewStorage(path)
+		if err != nil {
+			t.Fatalf("NewStorage() error = %v", err)
+		}
+		var store Store = storage
+
+		for _, name := range []string{"tar", "git", "ls"} {
+			if err := store.SavePage(&Page{
+				Name:     name,
+				Platform: "common",
+				Language: "en",
+				Examples: []Example{{Description: "Run " + name, Command: name}},
+			}); err != nil {
+				t.Fatalf("SavePage(%s) error = %v", name, err)
+			}
+		}
+		if err := store.Close(); err != nil {
+			t.Fatalf("Close() error = %v", err)
+		}
+
+		if got := DatabaseEngine(path); got != newEngine {
+			t.Errorf("DatabaseEngine() = %s, want %s", got, newEngine)
+		}
+		storage, err = NewStorage(path)
+		if err != nil {
+			t.Fatalf("NewStorage() reopening error = %v", err)
+		}
+		defer storage.Close()
+		store = storage
+
+		page, err := store.GetPage("git", "common", "en")
+		if err != nil {
+			t.Fatalf("GetPage() error = %v", err)
+		}
+		if len(page.Examples) != 1 || page.Examples[0].Command != "git" {
+			t.Errorf("GetPage() examples = %v", page.Examples)
+		}
+		if _, err := store.GetPage("missing", "common", "en"); err == nil {
+			t.Error("GetPage() of a missing page succeeded")
+		}
+
+		pages, err := store.GetAllPages()
+		if err != nil {
+			t.Fatalf("GetAllPages() error = %v", err)
+		}
+		var names []string
+		for _, p := range pages {
+			names = append(names, p.Name)
+		}
+		if fmt.Sprint(names) != "[git ls tar]" {
+			t.Errorf("GetAllPages() = %v, want [git ls tar]", names)
+		}
+	})
+}
+
+func TestStoreMaintenance(t *testing.T) {
+	forEachEngine(t, func(t *testing.T, path string) {
+		ctx := context.Background()
+		storage, err := NewStorage(path)
+		if err != nil {
+			t.Fatalf("NewStorage() error = %v", err)
+		}
+		defer storage.Close()
+
+		before, err := storage.Revision()
+		if err != nil {
+			t.Fatalf("Revision() error = %v", err)
+		}
+		fillHistory(t, storage, 500)
+		if after, _ := storage.Revision(); after == before {
+			t.Error("Revision() did not change after a write")
+		}
+
+		if err := storage.ClearHistory(ctx); err != nil {
+			t.Fatalf("ClearHistory() error = %v", err)
+		}
+		if _, err := storage.Compact(ctx); err != nil {
+			t.Fatalf("Compact() error = %v", err)
+		}
+		if err := storage.AddHistory(ctx, "echo after compact"); err != nil {
+			t.Fatalf("AddHistory() after Compact() error = %v", err)
+		}
+
+		backup, err := storage.Backup(ctx, t.TempDir())
+		if err != nil {
+			t.Fatalf("Backup() error = %v", err)
+		}
+		if err := ValidateBackup(backup.Path); err != nil {
+			t.Fatalf("ValidateBackup() error = %v", err)
+		}
+		if got := DatabaseEngine(backup.Path); got != newEngine {
+			t.Errorf("backup engine = %s, want %s", got, newEngine)
+		}
+		copied, err := OpenReadOnly(backup.Path, lockTimeout)
+		if err != nil {
+			t.Fatalf("OpenReadOnly() error = %v", err)
+		}
+		defer copied.Close()
+		history, err := copied.GetHistory(ctx, 10)
+		if err != nil || len(history) != 1 || history[0].Command != "echo after compact" {
+			t.Errorf("backup history = %v, %v; want the entry added after Compact()", history, err)
+		}
+	})
+}
+
+func TestStoreReadOnly(t *testing.T) {
+	forEachEngine(t, func(t *testing.T, path string) {
+		storage, err := NewStorage(path)
+		if err != nil {
+			t.Fatalf("NewStorage() error = %v", err)
+		}
+		storage.Close()
+
+		readOnly, err := OpenReadOnly(path, lockTimeout)
+		if err != nil {
+			t.Fatalf("OpenReadOnly() error = %v", err)
+		}
+		defer readOnly.Close()
+		if !readOnly.IsReadOnly() {
+			t.Error("IsReadOnly() = false")
+		}
+		if err := readOnly.AddHistory(context.Background(), "ls"); !errors.Is(err, bbolt.ErrDatabaseReadOnly) {
+			t.Errorf("AddHistory() error = %v, want %v", err, bbolt.ErrDatabaseReadOnly)
+		}
+	})
+}
+
+func TestKVBuckets(t *testing.T) {
+	forEachEngine(t, func(t *testing.T, path string) {
+		db, err := openKV(path, lockTimeout, false)
+		if err != nil {
+			t.Fatalf("openKV() error = %v", err)
+		}
+		defer db.Close()
+		if db.Engine() != newEngine {
+			t.Errorf("Engine() = %s, want %s", db.Engine(), newEngine)
+		}
+
+		err = db.Update(func(tx kvTx) error {
+			if _, err := tx.CreateBucket([]byte("a")); err != nil {
+				return err
+			}
+			if _, err := tx.CreateBucket([]byte("a")); !errors.Is(err, bbolt.ErrBucketExists) {
+				t.Errorf("CreateBucket() of an existing bucket error = %v", err)
+			}
+			if _, err := tx.CreateBucketIfNotExists([]byte("a")); err != nil {
+				t.Errorf("CreateBucketIfNotExists() error = %v", err)
+			}
+			if err := tx.DeleteBucket([]byte("missing")); !errors.Is(err, bbolt.ErrBucketNotFound) {
+				t.Errorf("DeleteBucket() of a missing bucket error = %v", err)
+			}
+			b, err := tx.CreateBucket([]byte("b"))
+			if err != nil {
+				return err
+			}
+			if err := b.Put(nil, []byte("v")); !errors.Is(err, bbolt.ErrKeyRequired) {
+				t.Errorf("Put() with an empty key error = %v", err)
+			}
+			return b.Put([]byte("empty"), nil)
+		})
+		if err != nil {
+			t.Fatalf("Update() error = %v", err)
+		}
+
+		rollback := errors.New("rollback")
+		err = db.Update(func(tx kvTx) error {
+			if err := tx.DeleteBucket([]byte("a")); err != nil {
+				return err
+			}
+			return rollback
+		})
+		if !errors.Is(err, rollback) {
+			t.Fatalf("Update() error = %v, want %v", err, rollback)
+		}
+
+		err = db.View(func(tx kvTx) error {
+			var names []string
+			_ = tx.ForEach(func(name []byte, _ kvBucket) error {
+				names = append(names, string(name))
+				return nil
+			})
+			if fmt.Sprint(names) != "[a b]" {
+				t.Errorf("buckets = %v, want [a b] after the rollback", names)
+			}
+			if tx.Bucket([]byte("missing")) != nil {
+				t.Error("Bucket() of a missing bucket is not nil")
+			}
+			b := tx.Bucket([]byte("b"))
+			if v := b.Get([]byte("empty")); v == nil || len(v) != 0 {
+				t.Errorf("Get() of an empty value = %v, want an empty slice", v)
+			}
+			if v := b.Get([]byte("missing")); v != nil {
+				t.Errorf("Get() of a missing key = %v, want nil", v)
+			}
+			if _, err := tx.CreateBucket([]byte("c")); !errors.Is(err, bbolt.ErrTxNotWritable) {
+				t.Errorf("CreateBucket() in a read transaction error = %v", err)
+			}
+			if err := b.Put([]byte("k"), []byte("v")); !errors.Is(err, bbolt.ErrTxNotWritable) {
+				t.Errorf("Put() in a read transaction error = %v", err)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("View() error = %v", err)
+		}
+	})
+}
+
+func TestKVCursor(t *testing.T) {
+	forEachEngine(t, func(t *testing.T, path string) {
+		db, err := openKV(path, lockTimeout, false)
+		if err != nil {
+			t.Fatalf("openKV() error = %v", err)
+		}
+		defer db.Close()
+
+		// Enough keys for the SQLite cursor to read several chunks, stored
+		// out of order and with a key that sorts between the others
+		const n = 1500
+		key := func(i int) []byte { return []byte(fmt.Sprintf("k%05d", i*2)) }
+		err = db.Update(func(tx kvTx) error {
+			b, err := tx.CreateBucket([]byte("keys"))
+			if err != nil {
+				return err
+			}
+			for i := n - 1; i >= 0; i-- {
+				if err := b.Put(key(i), []byte{byte(i)}); err != nil {
+					return err
+				}
+			}
+			return b.Put([]byte{0xff}, []byte("last"))
+		})
+		if err != nil {
+			t.Fatalf("Update() error = %v", err)
+		}
+
+		err = db.View(func(tx kvTx) error {
+			b := tx.Bucket([]byte("keys"))
+			if b.KeyN() != n+1 {
+				t.Errorf("KeyN() = %d, want %d", b.KeyN(), n+1)
+			}
+
+			c := b.Cursor()
+			count := 0
+			var prev []byte
+			for k, _ := c.First(); k != nil; k, _ = c.Next() {
+				if prev != nil && bytes.Compare(prev, k) >= 0 {
+					t.Fatalf("Next() went from %q to %q", prev, k)
+				}
+				prev = append(prev[:0], k...)
+				count++
+			}
+			if count != n+1 {
+				t.Errorf("First/Next visited %d keys, want %d", count, n+1)
+			}
+
+			count = 0
+			for k, _ := c.Last(); k != nil; k, _ = c.Prev() {
+				count++
+			}
+			if count != n+1 {
+				t.Errorf("Last/Prev visited %d keys, want %d", count, n+1)
+			}
+
+			if k, v := c.Last(); !bytes.Equal(k, []byte{0xff}) || string(v) != "last" {
+				t.Errorf("Last() = %q, %q", k, v)
+			}
+			if k, _ := c.Seek([]byte("k00003")); string(k) != "k00004" {
+				t.Errorf("Seek() between keys = %q, want k00004", k)
+			}
+			if k, _ := c.Next(); string(k) != "k00006" {
+				t.Errorf("Next() after Seek() = %q, want k00006", k)
+			}
+			if k, _ := c.Prev(); string(k) != "k00004" {
+				t.Errorf("Prev() after Next() = %q, want k00004", k)
+			}
+			if k, _ := c.Seek([]byte{0xff, 0}); k != nil {
+				t.Errorf("Seek() past the end = %q, want nil", k)
+			}
+			if k, _ := c.First(); string(k) != "k00000" {
+				t.Errorf("First() = %q, want k00000", k)
+			}
+			if k, _ := c.Prev(); k != nil {
+				t.Errorf("Prev() before the first key = %q, want nil", k)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("View() error = %v", err)
+		}
+	})
+}
+
+func TestMigrateDatabase(t *testing.T) {
+	for _, engines := range [][2]string{{EngineBolt, EngineSQLite}, {EngineSQLite, EngineBolt}} {
+		from, to := engines[0], engines[1]
+		t.Run(from+" to "+to, func(t *testing.T) {
+			useEngine(t, from)
+			ctx := context.Background()
+			path := filepath.Join(t.TempDir(), "wut.db")
+
+			storage, err := NewStorage(path)
+			if err != nil {
+				t.Fatalf("NewStorage() error = %v", err)
+			}
+			fillHistory(t, storage, 2500)
+			if err := storage.SavePage(&Page{Name: "tar", Platform: "common", Language: "en"}); err != nil {
+				t.Fatalf("SavePage() error = %v", err)
+			}
+			storage.Close()
+
+			lastCopied, lastTotal := -1, -1
+			result, err := MigrateDatabase(ctx, path, to, func(copied, total int) {
+				if copied < lastCopied {
+					t.Errorf("progress went back from %d to %d", lastCopied, copied)
+				}
+				lastCopied, lastTotal = copied, total
+			})
+			if err != nil {
+				t.Fatalf("MigrateDatabase() error = %v", err)
+			}
+			if result.From != from || result.To != to {
+				t.Errorf("result = %s to %s, want %s to %s", result.From, result.To, from, to)
+			}
+			if lastCopied != result.Entries || lastTotal != result.Entries {
+				t.Errorf("last progress = %d of %d, want %d of %d", lastCopied, lastTotal, result.Entries, result.Entries)
+			}
+			if got := DatabaseEngine(path); got != to {
+				t.Errorf("DatabaseEngine() after migrating = %s, want %s", got, to)
+			}
+			if got := DatabaseEngine(result.Previous); got != from {
+				t.Errorf("DatabaseEngine(%s) = %s, want %s", result.Previous, got, from)
+			}
+			if fileExists(path + ".migrate") {
+				t.Error("the temporary copy was left behind")
+			}
+
+			storage, err = NewStorage(path)
+			if err != nil {
+				t.Fatalf("NewStorage() after migrating error = %v", err)
+			}
+			defer storage.Close()
+			history, err := storage.GetAllHistory(ctx)
+			if err != nil || len(history) != 2500 {
+				t.Errorf("GetAllHistory() = %d entries, %v; want 2500", len(history), err)
+			}
+			if _, err := storage.GetPage("tar", "common", "en"); err != nil {
+				t.Errorf("GetPage() after migrating error = %v", err)
+			}
+
+			if _, err := MigrateDatabase(ctx, path, to, nil); !errors.Is(err, ErrSameEngine) {
+				t.Errorf("MigrateDatabase() to the same engine error = %v, want %v", err, ErrSameEngine)
+			}
+		})
+	}
+}
+
+func TestMigrateDatabaseInUse(t *testing.T) {
+	useEngine(t, EngineBolt)
+	path := filepath.Join(t.TempDir(), "wut.db")
+	storage, err := NewStorage(path)
+	if err != nil {
+		t.Fatalf("NewStorage() error = %v", err)
+	}
+	defer storage.Close()
+
+	if _, err := MigrateDatabase(context.Background(), path, EngineSQLite, nil); !errors.Is(err, ErrDatabaseLocked) {
+		t.Errorf("MigrateDatabase() of an open database error = %v, want %v", err, ErrDatabaseLocked)
+	}
+	if DatabaseEngine(path) != EngineBolt {
+		t.Error("the database was replaced while in use")
+	}
+}
@@ -44,6 +44,8 @@ type SyncResult struct {
 	Skipped    int
 	Errors     []error
 	Duration   time.Duration
+	// Platforms counts the saved pages per platform
+	Platforms map[string]int
 }
 
 var errPageAlreadyCached = errors.New("page already cached")
@@ -105,6 +107,7 @@ type batchPageSaver struct {
 	saved     int
 	failed    int
 	errors    []error
+	platforms map[string]int
 }
 
 func newBatchPageSaver(storage *Storage, log *logger.Logger, batchSize int) *batchPageSaver {
@@ -116,6 +119,7 @@ func newBatchPageSaver(storage *Storage, log *logger.Logger, batchSize int) *bat
 		log:       log,
 		batchSize: batchSize,
 		batch:     make([]*Page, 0, batchSize),
+		platforms: make(map[string]int),
 	}
 }
 
@@ -150,6 +154,9 @@ func (s *batchPageSaver) flush() {
 		s.log.Warn("batch save failed", "size", len(s.batch), "error", err)
 	} else {
 		s.saved += len(s.batch)
+		for _, page := range s.batch {
+			s.platforms[page.Platform]++
+		}
 	}
 
 	s.batch = s.batch[:0]
@@ -162,6 +169,7 @@ func (s *batchPageSaver) Result(start time.Time) *SyncResult {
 		Failed:     s.failed,
 		Errors:     s.errors,
 		Duration:   time.Since(start),
+		Platforms:  s.platforms,
 	}
 }
 
@@ -0,0 +1,235 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-json"
+)
+
+const (
+	personalCorrectionBucketName = "personal_corrections"
+	commandBiasBucketName        = "command_bias"
+)
+
+// ErrCorrectionNotFound is returned when no personal correction is taught for
+// the requested typo
+var ErrCorrectionNotFound = errors.New("correction not found")
+
+// ErrBiasNotFound is returned when the requested command has no bias
+var ErrBiasNotFound = errors.New("command bias not found")
+
+// MaxBiasLevel bounds how far repeated boosts or buries move a command
+const MaxBiasLevel = 3
+
+// biasStep is the score multiplier of one boost; one bury divides by it
+const biasStep = 1.5
+
+// PersonalCorrection is a correction taught with wut train correct. The
+// corrector applies it before looking anything up in its corpus.
+type PersonalCorrection struct {
+	Typo      string    `json:"typo"`
+	Command   string    `json:"command"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CommandBias is how often a command was boosted, counted up, or buried,
+// counted down, between -MaxBiasLevel and MaxBiasLevel
+type CommandBias struct {
+	Command   string    `json:"command"`
+	Level     int       `json:"level"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Multiplier is the factor the command's search and suggestion scores are
+// multiplied by
+func (b CommandBias) Multiplier() float64 {
+	return math.Pow(biasStep, float64(b.Level))
+}
+
+// normalizeTrainedCommand collapses runs of whitespace so the same command
+// typed with different spacing shares one entry
+func normalizeTrainedCommand(command string) string {
+	return strings.Join(strings.Fields(command), " ")
+}
+
+// SavePersonalCorrection teaches that typo should be corrected to command,
+// replacing what was taught for the same typo before. Typos are matched
+// without regard to case.
+func (s *Storage) SavePersonalCorrection(ctx context.Context, typo, command string) error {
+	if s == nil || s.db == nil {
+		return fmt.Errorf("storage not initialized")
+	}
+
+	typo = normalizeTrainedCommand(typo)
+	command = normalizeTrainedCommand(command)
+	if typo == "" || command == "" {
+		return fmt.Errorf("typo and correction cannot be empty")
+	}
+	if strings.EqualFold(typo, command) {
+		return fmt.Errorf("%q already is the correction", typo)
+	}
+
+	return s.update(ctx, func(tx kvTx) error {
+		bucket, err := tx.CreateBucketIfNotExists([]byte(personalCorrectionBucketName))
+		if err != nil {
+			return err
+		}
+		data, err := json.Marshal(PersonalCorrection{Typo: typo, Command: command, CreatedAt: time.Now()})
+		if err != nil {
+			return fmt.Errorf("failed to marshal correction: %w", err)
+		}
+		return bucket.Put([]byte(strings.ToLower(typo)), data)
+	})
+}
+
+// ListPersonalCorrections returns the taught corrections in typo order
+func (s *Storage) ListPersonalCorrections(ctx context.Context) ([]PersonalCorrection, error) {
+	if s == nil || s.db == nil {
+		return nil, fmt.Errorf("storage not initialized")
+	}
+
+	var corrections []PersonalCorrection
+	err := s.view(ctx, func(tx kvTx) error {
+		bucket := tx.Bucket([]byte(personalCorrectionBucketName))
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(_, v []byte) error {
+			var correction PersonalCorrection
+			if err := json.Unmarshal(v, &correction); err == nil {
+				corrections = append(corrections, correction)
+			}
+			return nil
+		})
+	})
+	return corrections, err
+}
+
+// DeletePersonalCorrection forgets the correction taught for typo, or returns
+// ErrCorrectionNotFound
+func (s *Storage) DeletePersonalCorrection(ctx context.Context, typo string) error {
+	if s == nil || s.db == nil {
+		return fmt.Errorf("storage not initialized")
+	}
+
+	key := []byte(strings.ToLower(normalizeTrainedCommand(typo)))
+	return s.update(ctx, func(tx kvTx) error {
+		bucket := tx.Bucket([]byte(personalCorrectionBucketName))
+		if bucket == nil || bucket.Get(key) == nil {
+			return ErrCorrectionNotFound
+		}
+		return bucket.Delete(key)
+	})
+}
+
+// AdjustCommandBias boosts command by delta levels, or buries it for a
+// negative delta, and returns its new bias. The level stays within
+// MaxBiasLevel either way; a command back at level 0 loses its entry.
+func (s *Storage) AdjustCommandBias(ctx context.Context, command string, delta int) (CommandBias, error) {
+	bias := CommandBias{Command: normalizeTrainedCommand(command)}
+	if s == nil || s.db == nil {
+		return bias, fmt.Errorf("storage not initialized")
+	}
+	if bias.Command == "" {
+		return bias, fmt.Errorf("command cannot be empty")
+	}
+
+	err := s.update(ctx, func(tx kvTx) error {
+		bucket, err := tx.CreateBucketIfNotExists([]byte(commandBiasBucketName))
+		if err != nil {
+			return err
+		}
+		key := []byte(bias.Command)
+		if data := bucket.Get(key); data != nil {
+			if err := json.Unmarshal(data, &bias); err != nil {
+				return fmt.Errorf("failed to decode command bias: %w", err)
+			}
+		}
+		bias.Level = max(-MaxBiasLevel, min(MaxBiasLevel, bias.Level+delta))
+		bias.UpdatedAt = time.Now()
+		if bias.Level == 0 {
+			return bucket.Delete(key)
+		}
+
+		data, err := json.Marshal(bias)
+		if err != nil {
+			return fmt.Errorf("failed to marshal command bias: %w", err)
+		}
+		return bucket.Put(key, data)
+	})
+	return bias, err
+}
+
+// ListCommandBiases returns the boosted and buried commands, most boosted
+// first
+func (s *Storage) ListCommandBiases(ctx context.Context) ([]CommandBias, error) {
+	if s == nil || s.db == nil {
+		return nil, fmt.Errorf("storage not initialized")
+	}
+
+	var biases []CommandBias
+	err := s.view(ctx, func(tx kvTx) error {
+		bucket := tx.Bucket([]byte(commandBiasBucketName))
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(_, v []byte) error {
+			var bias CommandBias
+			if err := json.Unmarshal(v, &bias); err == nil {
+				biases = append(biases, bias)
+			}
+			return nil
+		})
+	})
+	sort.SliceStable(biases, func(i, j int) bool {
+		return biases[i].Level > biases[j].Level
+	})
+	return biases, err
+}
+
+// CommandBiases maps boosted and buried commands to their score multipliers
+type CommandBiases map[string]float64
+
+// Multiplier returns the score multiplier of command, 1 when it has no bias
+func (b CommandBiases) Multiplier(command string) float64 {
+	if multiplier, ok := b[normalizeTrainedCommand(command)]; ok {
+		return multiplier
+	}
+	return 1
+}
+
+// CommandBiasMultipliers returns the multiplier of every boosted or buried
+// command
+func (s *Storage) CommandBiasMultipliers(ctx context.Context) (CommandBiases, error) {
+	biases, err := s.ListCommandBiases(ctx)
+	if err != nil {
+		return nil, err
+	}
+	multipliers := make(CommandBiases, len(biases))
+	for _, bias := range biases {
+		multipliers[bias.Command] = bias.Multiplier()
+	}
+	return multipliers, nil
+}
+
+// DeleteCommandBias drops the bias of command, or returns ErrBiasNotFound
+func (s *Storage) DeleteCommandBias(ctx context.Context, command string) error {
+	if s == nil || s.db == nil {
+		return fmt.Errorf("storage not initialized")
+	}
+
+	key := []byte(normalizeTrainedCommand(command))
+	return s.update(ctx, func(tx kvTx) error {
+		bucket := tx.Bucket([]byte(commandBiasBucketName))
+		if bucket == nil || bucket.Get(key) == nil {
+			return ErrBiasNotFound
+		}
+		return bucket.Delete(key)
+	})
+}
@@ -0,0 +1,129 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestPersonalCorrections(t *testing.T) {
+	storage, err := NewStorage(filepath.Join(t.TempDir(), "wut.db"))
+	if err != nil {
+		t.Fatalf("NewStorage() error = %v", err)
+	}
+	defer storage.Close()
+	ctx := context.Background()
+
+	if err := storage.SavePersonalCorrection(ctx, "gti", "git"); err != nil {
+		t.Fatalf("SavePersonalCorrection() error = %v", err)
+	}
+	if err := storage.SavePersonalCorrection(ctx, "GTI", "git  --no-pager"); err != nil {
+		t.Fatalf("SavePersonalCorrection() error = %v", err)
+	}
+	if err := storage.SavePersonalCorrection(ctx, "git", "git"); err == nil {
+		t.Error("SavePersonalCorrection() accepted a correction to itself")
+	}
+
+	corrections, err := storage.ListPersonalCorrections(ctx)
+	if err != nil {
+		t.Fatalf("ListPersonalCorrections() error = %v", err)
+	}
+	if len(corrections) != 1 || corrections[0].Typo != "GTI" || corrections[0].Command != "git --no-pager" {
+		t.Errorf("ListPersonalCorrections() = %+v, want gti replaced by the second correction", corrections)
+	}
+
+	if err := storage.DeletePersonalCorrection(ctx, "gti"); err != nil {
+		t.Fatalf("DeletePersonalCorrection() error = %v", err)
+	}
+	if err := storage.DeletePersonalCorrection(ctx, "gti"); !errors.Is(err, ErrCorrectionNotFound) {
+		t.Errorf("DeletePersonalCorrection() twice error = %v, want ErrCorrectionNotFound", err)
+	}
+}
+
+func TestCommandBias(t *testing.T) {
+	storage, err := NewStorage(filepath.Join(t.TempDir(), "wut.db"))
+	if err != nil {
+		t.Fatalf("NewStorage() error = %v", err)
+	}
+	defer storage.Close()
+	ctx := context.Background()
+
+	for range MaxBiasLevel + 2 {
+		if _, err := storage.AdjustCommandBias(ctx, "git status -sb", 1); err != nil {
+			t.Fatalf("AdjustCommandBias() error = %v", err)
+		}
+	}
+	bias, err := storage.AdjustCommandBias(ctx, "git  status", -1)
+	if err != nil {
+		t.Fatalf("AdjustCommandBias() error = %v", err)
+	}
+	if bias.Command != "git status" || bias.Level != -1 {
+		t.Errorf("AdjustCommandBias() = %+v, want git status buried once", bias)
+	}
+
+	biases, err := storage.CommandBiasMultipliers(ctx)
+	if err != nil {
+		t.Fatalf("CommandBiasMultipliers() error = %v", err)
+	}
+	if got := biases.Multiplier("git status -sb"); got != 1.5*1.5*1.5 {
+		t.Errorf("Multiplier(boosted past the cap) = %v, want 1.5^%d", got, MaxBiasLevel)
+	}
+	if got := biases.Multiplier("git status"); got != 1/1.5 {
+		t.Errorf("Multiplier(buried) = %v, want 1/1.5", got)
+	}
+	if got := biases.Multiplier("git log"); got != 1 {
+		t.Errorf("Multiplier(untrained) = %v, want 1", got)
+	}
+
+	// Boosting a buried command back to level 0 forgets it
+	if _, err := storage.AdjustCommandBias(ctx, "git status", 1); err != nil {
+		t.Fatalf("AdjustCommandBias() error = %v", err)
+	}
+	if err := storage.DeleteCommandBias(ctx, "git status"); !errors.Is(err, ErrBiasNotFound) {
+		t.Errorf("DeleteCommandBias() of a command back at 0 error = %v, want ErrBiasNotFound", err)
+	}
+	if err := storage.DeleteCommandBias(ctx, "git status -sb"); err != nil {
+		t.Errorf("DeleteCommandBias() error = %v", err)
+	}
+}
+
+func TestCommandBiasReordersHistorySearch(t *testing.T) {
+	storage, err := NewStorage(filepath.Join(t.TempDir(), "wut.db"))
+	if err != nil {
+		t.Fatalf("NewStorage() error = %v", err)
+	}
+	defer storage.Close()
+	ctx := context.Background()
+
+	for _, command := range []string{"git stash", "git status", "git status", "git status"} {
+		if err := storage.AddHistory(ctx, command); err != nil {
+			t.Fatalf("AddHistory() error = %v", err)
+		}
+	}
+	top := func() string {
+		matches, err := storage.SearchHistoryMatches(ctx, "git st", 5)
+		if err != nil {
+			t.Fatalf("SearchHistoryMatches() error = %v", err)
+		}
+		if len(matches) == 0 {
+			t.Fatal("SearchHistoryMatches() found nothing")
+		}
+		return matches[0].Entry.Command
+	}
+
+	if got := top(); got != "git status" {
+		t.Fatalf("top match before training = %q, want the most used command", got)
+	}
+	for _, adjust := range []struct {
+		command string
+		delta   int
+	}{{"git status", -1}, {"git status", -1}, {"git stash", 1}} {
+		if _, err := storage.AdjustCommandBias(ctx, adjust.command, adjust.delta); err != nil {
+			t.Fatalf("AdjustCommandBias() error = %v", err)
+		}
+	}
+	if got := top(); got != "git stash" {
+		t.Errorf("top match after burying git status = %q, want git stash", got)
+	}
+}
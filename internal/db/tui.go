@@ -4,32 +4,35 @@ package db
 import (
 	"context"
 	"fmt"
-	"os"
-	"os/exec"
-	"runtime"
+	"net/url"
 	"strings"
 	"time"
 
-	"github.com/atotto/clipboard"
 	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/charmbracelet/lipgloss/table"
+
+	"wut/internal/corrector"
+	"wut/internal/metrics"
+	"wut/internal/terminal"
+	"wut/internal/ui"
 )
 
 // Styles for the TUI
 var (
 	// Colors
-	primaryColor   = lipgloss.Color("#7C3AED") // Purple
-	secondaryColor = lipgloss.Color("#10B981") // Emerald
-	accentColor    = lipgloss.Color("#F59E0B") // Amber
-	dangerColor    = lipgloss.Color("#EF4444") // Red
-	infoColor      = lipgloss.Color("#3B82F6") // Blue
-	mutedColor     = lipgloss.Color("#6B7280") // Gray
-	textColor      = lipgloss.Color("#F3F4F6") // Light gray
-	bgColor        = lipgloss.Color("#1F2937") // Dark gray
+	primaryColor   = ui.ColorBrand   // Purple
+	secondaryColor = ui.ColorSuccess // Emerald
+	accentColor    = ui.ColorWarning // Amber
+	dangerColor    = ui.ColorError   // Red
+	infoColor      = ui.ColorPrimary // Blue
+	mutedColor     = ui.ColorMuted   // Gray
+	textColor      = ui.ColorText    // Light gray
+	bgColor        = ui.ColorBase    // Dark gray
 
 	// Title styles
 	titleStyle = lipgloss.NewStyle().
@@ -43,7 +46,7 @@ var (
 	commandStyle = lipgloss.NewStyle().
 			Bold(true).
 			Foreground(secondaryColor).
-			Background(lipgloss.Color("#064E3B")).
+			Background(ui.ColorSuccessBg).
 			Padding(0, 1)
 
 	// Description style
@@ -58,14 +61,14 @@ var (
 	// Command example style
 	exampleCmdStyle = lipgloss.NewStyle().
 			Foreground(textColor).
-			Background(lipgloss.Color("#374151")).
+			Background(ui.ColorSurface).
 			Padding(0, 1).
 			MarginLeft(2)
 
 	// Selected example style
 	selectedExampleStyle = lipgloss.NewStyle().
 				Foreground(textColor).
-				Background(lipgloss.Color("#4B5563")).
+				Background(ui.ColorBorder).
 				Padding(0, 1).
 				MarginLeft(2).
 				Bold(true)
@@ -95,6 +98,11 @@ var (
 			BorderForeground(primaryColor).
 			Padding(0, 1)
 
+	// Documentation link style
+	linkStyle = lipgloss.NewStyle().
+			Foreground(infoColor).
+			Underline(true)
+
 	// Notification style
 	notificationStyle = lipgloss.NewStyle().
 				Foreground(bgColor).
@@ -108,6 +116,7 @@ type DBItem struct {
 	Page      *Page
 	ItemTitle string
 	ItemDesc  string
+	Width     int // wraps the description when set
 }
 
 // FilterValue implements list.Item interface
@@ -122,7 +131,10 @@ func (i DBItem) Title() string {
 
 // Description returns the item description for the list
 func (i DBItem) Description() string {
-	return i.ItemDesc
+	if i.Width <= 0 {
+		return i.ItemDesc
+	}
+	return strings.Join(ui.Wrap(i.ItemDesc, i.Width, listDescLines), "\n")
 }
 
 // Model represents the DB TUI model
@@ -145,10 +157,50 @@ type Model struct {
 	notification     string
 	notificationTime int
 	executedCmd      string // Store command to execute after TUI closes
+	uncopiedCmd      string // Command the clipboard fallbacks could not copy
 	searchToken      int
 	lastSearchQuery  string
+	searchCancel     context.CancelFunc // cancels the in-flight search query
+	spinner          spinner.Model
+	previewEnabled   bool // ui.show_preview
+	showPreview      bool
+	previewToggled   bool             // set once the user toggles the pane with ctrl+o
+	previews         map[string]*Page // cached TLDR pages by command, nil when not cached
+	previewPending   map[string]bool
+	previewWidth     int
+	previewHeight    int
+	confirmDangerous bool                 // ui.confirm_dangerous
+	danger           *corrector.Corrector // flags dangerous examples
+	pendingDangerous string               // dangerous command awaiting a second keypress
+	bookmark         func(command string) error
+	rank             func(command string, delta int) error
+	bookmarked       map[string]bool // base commands of bookmarks, listed first
 }
 
+const (
+	// searchDebounce is how long typing must pause before a search is started
+	searchDebounce = 120 * time.Millisecond
+
+	// previewMinWidth is the narrowest terminal that shows the preview pane
+	// by default, and beside the list rather than below it
+	previewMinWidth = 100
+
+	// previewBottomHeight is the height of the preview pane below the list
+	previewBottomHeight = 10
+
+	// previewExamples is how many examples the preview pane shows
+	previewExamples = 3
+
+	// maxDescriptionLines caps wrapped page and item descriptions
+	maxDescriptionLines = 4
+
+	// maxExampleDescLines caps wrapped example descriptions
+	maxExampleDescLines = 3
+
+	// listDescLines is how many description lines each search result shows
+	listDescLines = 2
+)
+
 // NewModel creates a new DB TUI model
 func NewModel() *Model {
 	// Setup input
@@ -160,12 +212,18 @@ func NewModel() *Model {
 
 	// Setup list
 	items := []list.Item{}
-	l := list.New(items, list.NewDefaultDelegate(), 0, 0)
+	delegate := list.NewDefaultDelegate()
+	delegate.SetHeight(1 + listDescLines)
+	l := list.New(items, delegate, 0, 0)
 	l.Title = "Command Reference"
 	l.SetShowHelp(false)
 	// Setup viewport
 	vp := viewport.New(0, 0)
 
+	sp := spinner.New()
+	sp.Spinner = spinner.MiniDot
+	sp.Style = lipgloss.NewStyle().Foreground(mutedColor)
+
 	return &Model{
 		client:          NewClient(),
 		input:           input,
@@ -174,6 +232,45 @@ func NewModel() *Model {
 		pages:           []Page{},
 		mode:            "search",
 		selectedExample: 0,
+		spinner:         sp,
+		previews:        make(map[string]*Page),
+		previewPending:  make(map[string]bool),
+		danger:          corrector.New(),
+	}
+}
+
+// SetPreviewEnabled enables the TLDR preview pane in search mode. The pane
+// still starts hidden on terminals narrower than 100 columns.
+func (m *Model) SetPreviewEnabled(enabled bool) {
+	m.previewEnabled = enabled
+	m.showPreview = enabled && (m.width == 0 || m.width >= previewMinWidth)
+}
+
+// SetConfirmDangerous makes running a dangerous example require a second
+// keypress.
+func (m *Model) SetConfirmDangerous(enabled bool) {
+	m.confirmDangerous = enabled
+}
+
+// SetBookmarker lets the b key bookmark the selected example with fn
+func (m *Model) SetBookmarker(fn func(command string) error) {
+	m.bookmark = fn
+}
+
+// SetRanker lets the + and - keys boost and bury the selected example with
+// fn, which is given +1 or -1
+func (m *Model) SetRanker(fn func(command string, delta int) error) {
+	m.rank = fn
+}
+
+// SetBookmarkedCommands lists the pages of bookmarked commands ahead of
+// other search results
+func (m *Model) SetBookmarkedCommands(commands []string) {
+	m.bookmarked = make(map[string]bool, len(commands))
+	for _, command := range commands {
+		if fields := strings.Fields(command); len(fields) > 0 {
+			m.bookmarked[fields[0]] = true
+		}
 	}
 }
 
@@ -197,11 +294,18 @@ func (m *Model) SetInitialPage(page *Page) {
 	m.refreshDetailViewport()
 }
 
-// GetExecutedCommand returns the command that should be executed
+// GetExecutedCommand returns the example command that should be executed,
+// with its <placeholders> still in place
 func (m *Model) GetExecutedCommand() string {
 	return m.executedCmd
 }
 
+// GetUncopiedCommand returns the last command that could not be copied to the
+// clipboard, so the caller can print it after the TUI exits.
+func (m *Model) GetUncopiedCommand() string {
+	return m.uncopiedCmd
+}
+
 // Init initializes the model
 func (m *Model) Init() tea.Cmd {
 	if m.currentPage != nil {
@@ -235,12 +339,11 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		m.input.Width = inputW
 
-		// List size
-		listH := h - 8
-		if listH < 5 {
-			listH = 5
+		// List and preview size
+		if !m.previewToggled {
+			m.showPreview = m.previewEnabled && w >= previewMinWidth
 		}
-		m.list.SetSize(w, listH)
+		m.layoutList()
 
 		// Viewport size
 		vpW := w - 4
@@ -270,6 +373,12 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			case "esc":
 				return m, tea.Quit
 
+			case "ctrl+o":
+				m.showPreview = !m.showPreview
+				m.previewToggled = true
+				m.layoutList()
+				return m, m.requestPreview()
+
 			case "enter":
 				query := strings.TrimSpace(m.input.Value())
 				if query != "" {
@@ -299,7 +408,12 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.input.Focus()
 			}
 		} else { // detail mode
-			switch msg.String() {
+			key := msg.String()
+			if key != "e" && key != "enter" {
+				m.pendingDangerous = ""
+			}
+
+			switch key {
 			case "esc", "backspace", "q":
 				m.mode = "search"
 				m.currentPage = nil
@@ -322,17 +436,49 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				// Copy current example to clipboard
 				if m.currentPage != nil && m.selectedExample < len(m.currentPage.Examples) {
 					cmd := cleanCommand(m.currentPage.Examples[m.selectedExample].Command)
-					if err := clipboard.WriteAll(cmd); err == nil {
-						return m, m.showNotification("Copied to clipboard")
-					} else {
-						return m, m.showNotification("Copy failed: " + err.Error())
+					method, err := terminal.Copy(cmd)
+					if err != nil {
+						m.uncopiedCmd = cmd
+						return m, m.showNotification("Copy failed - command will be printed on exit")
+					}
+					m.uncopiedCmd = ""
+					return m, m.showNotification("Copied via " + method)
+				}
+
+			case "b":
+				// Bookmark current example, keeping its placeholders
+				if m.bookmark != nil && m.currentPage != nil && m.selectedExample < len(m.currentPage.Examples) {
+					cmd := m.currentPage.Examples[m.selectedExample].Command
+					if err := m.bookmark(cmd); err != nil {
+						return m, m.showNotification("Bookmark failed: " + err.Error())
 					}
+					return m, m.showNotification("Bookmarked " + cleanCommand(cmd))
+				}
+
+			case "+", "=", "-":
+				// Rank the current example higher or lower in suggestions
+				if m.rank != nil && m.currentPage != nil && m.selectedExample < len(m.currentPage.Examples) {
+					cmd := cleanCommand(m.currentPage.Examples[m.selectedExample].Command)
+					delta, verb := 1, "Boosted "
+					if key == "-" {
+						delta, verb = -1, "Buried "
+					}
+					if err := m.rank(cmd, delta); err != nil {
+						return m, m.showNotification("Ranking failed: " + err.Error())
+					}
+					return m, m.showNotification(verb + cmd)
 				}
 
 			case "e", "enter":
 				// Execute current example
 				if m.currentPage != nil && m.selectedExample < len(m.currentPage.Examples) {
-					cmd := cleanCommand(m.currentPage.Examples[m.selectedExample].Command)
+					// Placeholders are kept so they can be filled in before running
+					cmd := m.currentPage.Examples[m.selectedExample].Command
+					if d := m.danger.AssessDanger(cleanCommand(cmd)); d != nil && m.confirmDangerous && m.pendingDangerous != cmd {
+						m.pendingDangerous = cmd
+						return m, m.showNotification(fmt.Sprintf("%s - press %s again to run", d.Explanation, key))
+					}
+					m.pendingDangerous = ""
 					m.executedCmd = cmd
 					return m, tea.Quit
 				}
@@ -364,29 +510,46 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 
+	case searchDebounceMsg:
+		if msg.token != m.searchToken {
+			return m, nil
+		}
+		return m, m.startSearch(msg.query, msg.token)
+
+	case previewLoadedMsg:
+		delete(m.previewPending, msg.name)
+		m.previews[msg.name] = msg.page
+		return m, nil
+
+	case spinner.TickMsg:
+		if !m.loading {
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+
 	case searchResultsMsg:
 		if msg.token != m.searchToken || msg.query != strings.TrimSpace(m.input.Value()) {
 			return m, nil
 		}
 		m.loading = false
+		if m.searchCancel != nil {
+			m.searchCancel()
+			m.searchCancel = nil
+		}
 		if msg.err != nil {
 			m.err = msg.err
 		} else {
 			m.pages = msg.pages
-			items := make([]list.Item, len(msg.pages))
 			suggestions := make([]string, 0, len(msg.pages))
-			for i, page := range msg.pages {
-				items[i] = DBItem{
-					Page:      &page,
-					ItemTitle: page.Name,
-					ItemDesc:  page.Description,
-				}
+			for _, page := range msg.pages {
 				suggestions = append(suggestions, page.Name)
 			}
-			m.list.SetItems(items)
+			m.refreshListItems()
 			m.input.SetSuggestions(suggestions)
 		}
-		return m, nil
+		return m, m.requestPreview()
 
 	case tickMsg:
 		if m.notificationTime > 0 {
@@ -408,13 +571,13 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// Update list
 		newList, listCmd := m.list.Update(msg)
 		m.list = newList
-		cmds = append(cmds, listCmd)
+		cmds = append(cmds, listCmd, m.requestPreview())
 
 		// Real-time search on input change
 		if _, ok := msg.(tea.KeyMsg); ok {
 			query := strings.TrimSpace(m.input.Value())
 			if query != m.lastSearchQuery {
-				cmds = append(cmds, m.loadSuggestions(query))
+				cmds = append(cmds, m.debounceSearch(query))
 			}
 		}
 	} else {
@@ -448,16 +611,14 @@ func (m *Model) searchView() string {
 	b.WriteString(title)
 	b.WriteString("\n")
 
-	// Search input
-	inputBox := inputStyle.Render(m.input.View())
-	b.WriteString(inputBox)
-	b.WriteString("\n")
-
-	// Loading indicator
+	// Search input, with a subtle spinner while a query is in flight
+	inputView := m.input.View()
 	if m.loading {
-		b.WriteString("⏳ Searching...")
-		b.WriteString("\n")
+		inputView += " " + m.spinner.View()
 	}
+	inputBox := inputStyle.Render(inputView)
+	b.WriteString(inputBox)
+	b.WriteString("\n")
 
 	// Error message
 	if m.err != nil {
@@ -468,11 +629,20 @@ func (m *Model) searchView() string {
 		b.WriteString("\n")
 	}
 
-	// List
-	b.WriteString(m.list.View())
+	// List, with the preview pane beside it on wide terminals and below otherwise
+	switch {
+	case !m.showPreview:
+		b.WriteString(m.list.View())
+	case m.width >= previewMinWidth:
+		b.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, m.list.View(), " ", m.previewView()))
+	default:
+		b.WriteString(m.list.View())
+		b.WriteString("\n")
+		b.WriteString(m.previewView())
+	}
 
 	// Help
-	helpText := "enter: view • /: search • esc/q: quit"
+	helpText := "enter: view • /: search • ctrl+o: preview • esc/q: quit"
 	if m.width < 50 {
 		helpText = "enter/open • /search • q: quit"
 	}
@@ -491,6 +661,140 @@ func (m *Model) searchView() string {
 	return wrapper
 }
 
+// layoutList sizes the list and the preview pane for the current window
+func (m *Model) layoutList() {
+	listW := m.width
+	listH := m.height - 8
+	if listH < 5 {
+		listH = 5
+	}
+
+	if m.showPreview {
+		if m.width >= previewMinWidth {
+			listW = m.width * 55 / 100
+			m.previewWidth = m.width - listW - 3
+			m.previewHeight = listH
+		} else {
+			m.previewWidth = m.width - 2
+			m.previewHeight = previewBottomHeight
+			listH -= previewBottomHeight
+			if listH < 5 {
+				listH = 5
+			}
+		}
+	}
+
+	m.list.SetSize(listW, listH)
+	m.refreshListItems()
+}
+
+// refreshListItems rebuilds the list items so descriptions wrap to the
+// current list width
+func (m *Model) refreshListItems() {
+	// The default delegate indents items by 2 columns
+	width := m.list.Width() - 2
+	items := make([]list.Item, len(m.pages))
+	for i := range m.pages {
+		page := &m.pages[i]
+		items[i] = DBItem{
+			Page:      page,
+			ItemTitle: page.Name,
+			ItemDesc:  page.Description,
+			Width:     width,
+		}
+	}
+	m.list.SetItems(items)
+}
+
+// previewName returns the command highlighted in the search list
+func (m *Model) previewName() string {
+	item, ok := m.list.SelectedItem().(DBItem)
+	if !ok || item.Page == nil {
+		return ""
+	}
+	return item.Page.Name
+}
+
+// requestPreview looks up the highlighted command's cached TLDR page in the
+// background. Lookups are cached per command so moving the cursor stays instant.
+func (m *Model) requestPreview() tea.Cmd {
+	name := m.previewName()
+	if !m.showPreview || m.storage == nil || name == "" {
+		return nil
+	}
+	if _, ok := m.previews[name]; ok || m.previewPending[name] {
+		return nil
+	}
+	m.previewPending[name] = true
+
+	storage := m.storage
+	language := m.client.language
+	return func() tea.Msg {
+		page, err := storage.GetPageAnyPlatform(name, language)
+		if err != nil {
+			// Fall back to the base command, e.g. "git" for "git commit"
+			if fields := strings.Fields(name); len(fields) > 1 {
+				page, err = storage.GetPageAnyPlatform(fields[0], language)
+			}
+		}
+		if err != nil {
+			page = nil
+		}
+		return previewLoadedMsg{name: name, page: page}
+	}
+}
+
+// previewView renders the TLDR preview for the highlighted command
+func (m *Model) previewView() string {
+	width := m.previewWidth
+	if width < 20 {
+		width = 20
+	}
+	muted := lipgloss.NewStyle().Foreground(mutedColor)
+
+	// Wrap first, then cut to the pane height so the border stays intact
+	render := func(content string) string {
+		lines := strings.Split(lipgloss.NewStyle().Width(width-4).Render(content), "\n")
+		if maxLines := m.previewHeight - 2; maxLines > 0 && len(lines) > maxLines {
+			lines = lines[:maxLines]
+		}
+		return boxStyle.Render(strings.Join(lines, "\n"))
+	}
+
+	name := m.previewName()
+	page, cached := m.previews[name]
+	switch {
+	case name == "":
+		return render(muted.Render("Nothing selected"))
+	case m.previewPending[name]:
+		return render(muted.Render("Loading preview..."))
+	case !cached || page == nil:
+		return render(commandStyle.Render(name) + "\n\n" +
+			muted.Render("No cached TLDR page - run 'wut db sync' to download pages"))
+	}
+
+	var b strings.Builder
+	b.WriteString(commandStyle.Render(page.Name))
+	b.WriteString("\n")
+	if page.Description != "" {
+		b.WriteString(descriptionStyle.Render(page.Description))
+		b.WriteString("\n")
+	}
+
+	for i, ex := range page.Examples {
+		if i == previewExamples {
+			break
+		}
+		b.WriteString("\n")
+		b.WriteString(exampleDescStyle.Render(ex.Description))
+		b.WriteString("\n")
+		b.WriteString(exampleCmdStyle.Render(ex.Command))
+		b.WriteString("\n")
+	}
+
+	return render(strings.TrimRight(b.String(), "\n"))
+}
+
 // detailView renders the detail mode
 func (m *Model) detailView() string {
 	if m.currentPage == nil {
@@ -520,7 +824,14 @@ func (m *Model) detailView() string {
 	}
 
 	// Footer
-	footerText := "↑/↓: select • pgup/pgdn: scroll • 1-9: jump • c: copy • e: run • esc: back"
+	footerText := "↑/↓: select • pgup/pgdn: scroll • 1-9: jump • c: copy • e: run"
+	if m.bookmark != nil {
+		footerText += " • b: bookmark"
+	}
+	if m.rank != nil {
+		footerText += " • +/-: rank"
+	}
+	footerText += " • esc: back"
 	if m.width < 70 {
 		footerText = "↑/↓: sel • pgup/pgdn: scroll • c: copy • e: run • esc: back"
 	}
@@ -579,10 +890,16 @@ func (m *Model) renderPage(page *Page) string {
 				cmdStyle = selectedExampleStyle
 			}
 			b.WriteString(cmdStyle.Render(ex.Command))
+			if marker := m.dangerMarker(ex.Command); marker != "" {
+				b.WriteString(" ")
+				b.WriteString(marker)
+			}
 			b.WriteString("\n")
 		}
 	}
 
+	b.WriteString(renderPageLinks(page))
+
 	// Wrap content to fit viewport width and prevent horizontal overflow
 	contentWidth := m.viewport.Width - 2
 	if contentWidth < 10 {
@@ -591,6 +908,60 @@ func (m *Model) renderPage(page *Page) string {
 	return lipgloss.NewStyle().Width(contentWidth).Render(b.String())
 }
 
+// dangerMarker returns a ⚠ marker for dangerous example commands, red when
+// the corrector is confident the command is critical
+func (m *Model) dangerMarker(command string) string {
+	d := m.danger.AssessDanger(cleanCommand(command))
+	if d == nil {
+		return ""
+	}
+	color := accentColor
+	if d.Confidence >= corrector.CriticalDangerConfidence {
+		color = dangerColor
+	}
+	return lipgloss.NewStyle().Foreground(color).Bold(true).Render("⚠")
+}
+
+// renderPageLinks renders the page's "more info" link, man page reference
+// and related pages. Links become clickable OSC-8 links on terminals that
+// support them.
+func renderPageLinks(page *Page) string {
+	var b strings.Builder
+
+	if link := page.MoreInfoURL(); link != "" {
+		b.WriteString("\n")
+		b.WriteString(lipgloss.NewStyle().Foreground(mutedColor).Render("More info: "))
+		b.WriteString(terminal.Hyperlink(link, linkStyle.Render(link)))
+		b.WriteString("\n")
+	}
+
+	if page.Platform != PlatformWindows && page.Name != "" {
+		if b.Len() == 0 {
+			b.WriteString("\n")
+		}
+		manRef := "man " + page.Name
+		b.WriteString(lipgloss.NewStyle().Foreground(mutedColor).Render("Manual:    "))
+		b.WriteString(terminal.Hyperlink(manPageURL(page.Name), linkStyle.Render(manRef)))
+		b.WriteString("\n")
+	}
+
+	if related := page.SeeAlso(); len(related) > 0 {
+		if b.Len() == 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(lipgloss.NewStyle().Foreground(mutedColor).Render("See also:  "))
+		b.WriteString(strings.Join(related, ", "))
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+// manPageURL points at the online man page for a command
+func manPageURL(name string) string {
+	return "https://manned.org/" + url.PathEscape(name)
+}
+
 // Selected returns the selected command
 func (m *Model) Selected() string {
 	return m.selected
@@ -607,6 +978,14 @@ type searchResultsMsg struct {
 	query string
 	token int
 }
+type previewLoadedMsg struct {
+	name string
+	page *Page
+}
+type searchDebounceMsg struct {
+	query string
+	token int
+}
 type tickMsg struct{}
 
 // showNotification shows a notification for a few seconds
@@ -623,25 +1002,53 @@ func (m *Model) tick() tea.Cmd {
 	})
 }
 
-// loadSuggestions refreshes search results for the current query.
+// loadSuggestions refreshes search results for the current query immediately.
 func (m *Model) loadSuggestions(query string) tea.Cmd {
 	query = strings.TrimSpace(query)
-	m.loading = true
-	m.err = nil
+	m.lastSearchQuery = query
+	m.searchToken++
+	return m.startSearch(query, m.searchToken)
+}
+
+// debounceSearch schedules a search once typing pauses. Each keystroke bumps
+// the token, so only the last scheduled search actually runs.
+func (m *Model) debounceSearch(query string) tea.Cmd {
+	query = strings.TrimSpace(query)
 	m.lastSearchQuery = query
 	m.searchToken++
 	token := m.searchToken
 
-	return func() tea.Msg {
+	return tea.Tick(searchDebounce, func(time.Time) tea.Msg {
+		return searchDebounceMsg{query: query, token: token}
+	})
+}
+
+// startSearch cancels any in-flight query and runs a new one in the background.
+func (m *Model) startSearch(query string, token int) tea.Cmd {
+	if m.searchCancel != nil {
+		m.searchCancel()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	m.searchCancel = cancel
+
+	wasLoading := m.loading
+	m.loading = true
+	m.err = nil
+
+	search := func() tea.Msg {
+		defer metrics.ObserveLatency(metrics.LatencySearch, time.Now())
 		matchQuery := query
 		if len(matchQuery) < 2 {
 			matchQuery = ""
 		}
 
-		commands, err := m.client.FindCommandMatches(context.Background(), matchQuery, 50)
+		commands, err := m.client.FindCommandMatches(ctx, matchQuery, 50)
 		if err != nil {
 			return searchResultsMsg{err: err, query: query, token: token}
 		}
+		if ctx.Err() != nil {
+			return searchResultsMsg{err: ctx.Err(), query: query, token: token}
+		}
 
 		var pages []Page
 		for _, cmd := range commands {
@@ -666,12 +1073,39 @@ func (m *Model) loadSuggestions(query string) tea.Cmd {
 			}
 		}
 
+		pages = m.bookmarkedFirst(pages)
+
 		if len(pages) == 0 && query != "" {
 			return searchResultsMsg{err: fmt.Errorf("command not found: %s", query), query: query, token: token}
 		}
 
 		return searchResultsMsg{pages: pages, query: query, token: token}
 	}
+
+	if wasLoading {
+		return search
+	}
+	return tea.Batch(search, m.spinner.Tick)
+}
+
+// bookmarkedFirst moves the pages of bookmarked commands to the front,
+// keeping the order within both groups
+func (m *Model) bookmarkedFirst(pages []Page) []Page {
+	if len(m.bookmarked) == 0 {
+		return pages
+	}
+	sorted := make([]Page, 0, len(pages))
+	for _, page := range pages {
+		if m.bookmarked[page.Name] {
+			sorted = append(sorted, page)
+		}
+	}
+	for _, page := range pages {
+		if !m.bookmarked[page.Name] {
+			sorted = append(sorted, page)
+		}
+	}
+	return sorted
 }
 
 // showPage loads and shows a specific page
@@ -729,68 +1163,7 @@ func (m *Model) selectedExampleLine() int {
 
 // cleanCommand removes placeholder syntax for execution
 func cleanCommand(cmd string) string {
-	// Remove <placeholder> syntax
-	result := cmd
-	for {
-		start := strings.Index(result, "<")
-		if start == -1 {
-			break
-		}
-		end := strings.Index(result[start:], ">")
-		if end == -1 {
-			break
-		}
-		end += start
-
-		// Extract the placeholder content
-		placeholder := result[start+1 : end]
-
-		// If it has | (choices), use the first one
-		if before, _, ok := strings.Cut(placeholder, "|"); ok {
-			choice := strings.TrimSpace(before)
-			// Remove [ and ] if present
-			choice = strings.Trim(choice, "[]")
-			result = result[:start] + choice + result[end+1:]
-		} else {
-			// Just a simple placeholder, replace with empty
-			result = result[:start] + result[end+1:]
-		}
-	}
-
-	return strings.TrimSpace(result)
-}
-
-// ExecuteCommand executes a command in the shell
-func ExecuteCommand(cmd string) error {
-	cleanCmd := cleanCommand(cmd)
-
-	var shell string
-	var args []string
-
-	switch runtime.GOOS {
-	case "windows":
-		// Try PowerShell first, then CMD
-		if _, err := exec.LookPath("powershell"); err == nil {
-			shell = "powershell"
-			args = []string{"-Command", cleanCmd}
-		} else {
-			shell = "cmd"
-			args = []string{"/C", cleanCmd}
-		}
-	default:
-		shell = os.Getenv("SHELL")
-		if shell == "" {
-			shell = "/bin/sh"
-		}
-		args = []string{"-c", cleanCmd}
-	}
-
-	command := exec.Command(shell, args...)
-	command.Stdout = os.Stdout
-	command.Stderr = os.Stderr
-	command.Stdin = os.Stdin
-
-	return command.Run()
+	return FillPlaceholders(cmd, nil)
 }
 
 // CreateTable creates a table for displaying multiple pages
@@ -828,12 +1201,25 @@ func CreateTable(pages []Page) string {
 	return t.String()
 }
 
-// FormatPage formats a single page for terminal output
+// FormatPage formats a single page for terminal output, wrapping it to the
+// width of the terminal
 func FormatPage(page *Page) string {
+	return FormatPageWidth(page, terminal.Detect().Width)
+}
+
+// FormatPageWidth formats a single page so that it fits in width columns.
+// Long descriptions wrap onto multiple lines, up to a fixed number of lines.
+func FormatPageWidth(page *Page, width int) string {
 	if page == nil {
 		return ""
 	}
 
+	// Border and padding of boxStyle take 4 columns
+	inner := width - 4
+	if inner < 20 {
+		inner = 20
+	}
+
 	var b strings.Builder
 
 	// Title with platform
@@ -848,8 +1234,10 @@ func FormatPage(page *Page) string {
 
 	// Description
 	if page.Description != "" {
-		b.WriteString(descriptionStyle.Render(page.Description))
-		b.WriteString("\n")
+		for _, line := range ui.Wrap(page.Description, inner, maxDescriptionLines) {
+			b.WriteString(descriptionStyle.Render(line))
+			b.WriteString("\n")
+		}
 	}
 
 	// Examples
@@ -862,19 +1250,33 @@ func FormatPage(page *Page) string {
 
 		for i, ex := range page.Examples {
 			// Example number and description
+			num := fmt.Sprintf("%d.", i+1)
 			b.WriteString(lipgloss.NewStyle().
 				Foreground(accentColor).
 				Bold(true).
-				Render(fmt.Sprintf("%d.", i+1)))
+				Render(num))
 			b.WriteString(" ")
-			b.WriteString(exampleDescStyle.Render(ex.Description))
-			b.WriteString("\n")
+			indent := strings.Repeat(" ", len(num)+1)
+			descLines := ui.Wrap(ex.Description, inner-len(indent), maxExampleDescLines)
+			if len(descLines) == 0 {
+				b.WriteString("\n")
+			}
+			for j, line := range descLines {
+				if j > 0 {
+					b.WriteString(indent)
+				}
+				b.WriteString(exampleDescStyle.Render(line))
+				b.WriteString("\n")
+			}
 
-			// Command
-			b.WriteString(exampleCmdStyle.Render(ex.Command))
+			// Command; margin and padding of exampleCmdStyle take 4 columns
+			cmdLines := ui.Wrap(ex.Command, inner-4, 0)
+			b.WriteString(exampleCmdStyle.Render(strings.Join(cmdLines, "\n")))
 			b.WriteString("\n")
 		}
 	}
 
+	b.WriteString(renderPageLinks(page))
+
 	return boxStyle.Render(b.String())
 }
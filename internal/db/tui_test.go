@@ -1,7 +1,14 @@
 package db
 
 import (
+	"fmt"
+	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 )
 
 func TestCleanCommand(t *testing.T) {
@@ -36,6 +43,28 @@ func TestCleanCommand(t *testing.T) {
 	}
 }
 
+func TestFillPlaceholders(t *testing.T) {
+	cmd := "git commit <[-a|--all]> -m <message> <path/to/file>"
+
+	placeholders := Placeholders(cmd)
+	want := []Placeholder{{Name: "[-a|--all]", Default: "-a"}, {Name: "message"}, {Name: "path/to/file"}}
+	if len(placeholders) != len(want) {
+		t.Fatalf("Placeholders() = %+v, want %+v", placeholders, want)
+	}
+	for i := range want {
+		if placeholders[i] != want[i] {
+			t.Errorf("Placeholders()[%d] = %+v, want %+v", i, placeholders[i], want[i])
+		}
+	}
+
+	if got := FillPlaceholders(cmd, []string{"", "'fix typo'", " main.go "}); got != "git commit -a -m 'fix typo' main.go" {
+		t.Errorf("FillPlaceholders() = %q", got)
+	}
+	if got, want := FillPlaceholders(cmd, nil), cleanCommand(cmd); got != want {
+		t.Errorf("FillPlaceholders(nil) = %q, want cleanCommand result %q", got, want)
+	}
+}
+
 func TestModelIgnoresStaleSearchResults(t *testing.T) {
 	model := NewModel()
 	model.input.SetValue("git")
@@ -66,6 +95,25 @@ func TestModelIgnoresStaleSearchResults(t *testing.T) {
 	}
 }
 
+func TestModelDebounceKeepsOnlyLatestQuery(t *testing.T) {
+	model := NewModel()
+	model.debounceSearch("gi")
+	model.debounceSearch("git")
+
+	updated, cmd := model.Update(searchDebounceMsg{query: "gi", token: 1})
+	got := updated.(*Model)
+	if cmd != nil || got.loading || got.searchCancel != nil {
+		t.Fatalf("superseded debounce should not start a search")
+	}
+
+	updated, cmd = got.Update(searchDebounceMsg{query: "git", token: 2})
+	got = updated.(*Model)
+	if cmd == nil || !got.loading || got.searchCancel == nil {
+		t.Fatalf("latest debounce should start a search")
+	}
+	got.searchCancel()
+}
+
 func TestSelectedExampleLine(t *testing.T) {
 	model := NewModel()
 	model.currentPage = &Page{
@@ -82,3 +130,194 @@ func TestSelectedExampleLine(t *testing.T) {
 		t.Fatalf("selectedExampleLine() = %d, want 6", got)
 	}
 }
+
+func TestParsePageMoreInfo(t *testing.T) {
+	content := "# tar\n\n> Archiving utility.\n> Often combined with a compression method.\n> More information: <https://www.gnu.org/software/tar>.\n\n- Create an archive:\n\n`tar cf {{target.tar}} {{file}}`\n"
+	page := NewClient().parsePage(content, "tar", PlatformCommon, "en")
+
+	if want := "Archiving utility. Often combined with a compression method."; page.Description != want {
+		t.Errorf("Description = %q, want %q", page.Description, want)
+	}
+	if got := page.MoreInfoURL(); got != "https://www.gnu.org/software/tar" {
+		t.Errorf("MoreInfoURL() = %q", got)
+	}
+
+	t.Setenv("NO_COLOR", "1")
+	t.Setenv("FORCE_HYPERLINK", "1")
+	out := FormatPage(page)
+	if strings.Contains(out, "\x1b]8;;") {
+		t.Errorf("hyperlinks should fall back to plain text when color is off")
+	}
+	if !strings.Contains(out, "https://www.gnu.org/software/tar") {
+		t.Errorf("more info link missing from page output:\n%s", out)
+	}
+}
+
+func TestModelPreviewPane(t *testing.T) {
+	storage, err := NewStorage(filepath.Join(t.TempDir(), "tldr.db"))
+	if err != nil {
+		t.Fatalf("NewStorage() error = %v", err)
+	}
+	defer storage.Close()
+
+	model := NewModel()
+	model.SetStorage(storage)
+	model.SetPreviewEnabled(true)
+
+	updated, _ := model.Update(tea.WindowSizeMsg{Width: 80, Height: 30})
+	got := updated.(*Model)
+	if got.showPreview {
+		t.Fatalf("preview should default to off below %d columns", previewMinWidth)
+	}
+
+	updated, _ = got.Update(tea.WindowSizeMsg{Width: 120, Height: 30})
+	got = updated.(*Model)
+	if !got.showPreview {
+		t.Fatalf("preview should default to on for wide terminals")
+	}
+
+	got.list.SetItems([]list.Item{DBItem{Page: &Page{Name: "tar"}, ItemTitle: "tar"}})
+	if cmd := got.requestPreview(); cmd == nil {
+		t.Fatalf("uncached command should trigger a lookup")
+	}
+	if cmd := got.requestPreview(); cmd != nil {
+		t.Fatalf("pending lookup should not be repeated")
+	}
+
+	page := &Page{Name: "tar", Description: "Archiving utility.", Examples: []Example{{Description: "Extract", Command: "tar xf <file>"}}}
+	updated, _ = got.Update(previewLoadedMsg{name: "tar", page: page})
+	got = updated.(*Model)
+	if cmd := got.requestPreview(); cmd != nil {
+		t.Fatalf("cached lookup should not be repeated")
+	}
+	if view := got.previewView(); !strings.Contains(view, "Archiving utility.") {
+		t.Errorf("preview is missing the page description:\n%s", view)
+	}
+
+	updated, _ = got.Update(tea.KeyMsg{Type: tea.KeyCtrlO})
+	got = updated.(*Model)
+	if got.showPreview {
+		t.Fatalf("ctrl+o should toggle the preview off")
+	}
+	updated, _ = got.Update(tea.WindowSizeMsg{Width: 130, Height: 30})
+	if updated.(*Model).showPreview {
+		t.Fatalf("resizing should not override the user's toggle")
+	}
+}
+
+func TestFormatPageWidth(t *testing.T) {
+	page := &Page{
+		Name:        "rsync",
+		Platform:    PlatformCommon,
+		Description: strings.Repeat("Transfer files either to or from a remote host, but not between two remote hosts. ", 4),
+		Examples: []Example{
+			{Description: "Transfer a directory and all its contents from a remote host to the local machine, preserving permissions", Command: "rsync -av <remote_host>:<path/to/remote_directory> <path/to/local_directory>"},
+		},
+	}
+
+	descLines := make(map[int]int)
+	for _, width := range []int{40, 80, 200} {
+		t.Run(fmt.Sprintf("width %d", width), func(t *testing.T) {
+			out := FormatPageWidth(page, width)
+			for _, line := range strings.Split(out, "\n") {
+				if w := lipgloss.Width(line); w > width {
+					t.Errorf("line is %d columns wide, want at most %d: %q", w, width, line)
+				}
+			}
+			if !strings.Contains(out, "...") && width < 200 {
+				t.Errorf("long description should be capped with an ellipsis:\n%s", out)
+			}
+			descLines[width] = strings.Count(out, "\n")
+		})
+	}
+
+	if descLines[40] <= descLines[200] {
+		t.Errorf("narrow output should wrap onto more lines: 40 cols = %d lines, 200 cols = %d lines", descLines[40], descLines[200])
+	}
+}
+
+func TestModelConfirmsDangerousExample(t *testing.T) {
+	page := &Page{Name: "rm", Examples: []Example{
+		{Description: "Remove a directory and its contents", Command: "rm -rf <path/to/directory>"},
+		{Description: "Remove files", Command: "rm <path/to/file>"},
+	}}
+	enter := tea.KeyMsg{Type: tea.KeyEnter}
+	down := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("j")}
+
+	model := NewModel()
+	model.SetConfirmDangerous(true)
+	model.SetInitialPage(page)
+
+	if !strings.Contains(model.renderPage(page), "⚠") {
+		t.Errorf("dangerous example is not marked")
+	}
+
+	model.Update(enter)
+	if model.GetExecutedCommand() != "" {
+		t.Fatalf("dangerous command ran without confirmation")
+	}
+	if model.notification == "" {
+		t.Errorf("no confirmation prompt was shown")
+	}
+	model.Update(enter)
+	if got := model.GetExecutedCommand(); got != "rm -rf <path/to/directory>" {
+		t.Fatalf("confirmed command = %q, want %q", got, "rm -rf <path/to/directory>")
+	}
+
+	// Moving away cancels a pending confirmation
+	model = NewModel()
+	model.SetConfirmDangerous(true)
+	model.SetInitialPage(page)
+	model.Update(enter)
+	model.Update(down)
+	model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("k")})
+	model.Update(enter)
+	if model.GetExecutedCommand() != "" {
+		t.Fatalf("confirmation survived a selection change")
+	}
+
+	// Safe commands and disabled confirmation run on the first keypress
+	model.Update(down)
+	model.Update(enter)
+	if got := model.GetExecutedCommand(); got != "rm <path/to/file>" {
+		t.Errorf("safe command = %q, want %q", got, "rm <path/to/file>")
+	}
+
+	model = NewModel()
+	model.SetInitialPage(page)
+	model.Update(enter)
+	if got := model.GetExecutedCommand(); got != "rm -rf <path/to/directory>" {
+		t.Errorf("command with confirmation disabled = %q, want %q", got, "rm -rf <path/to/directory>")
+	}
+}
+
+func TestModelBookmarks(t *testing.T) {
+	page := &Page{Name: "tar", Examples: []Example{
+		{Description: "Extract an archive", Command: "tar xf <path/to/file.tar>"},
+	}}
+
+	var saved []string
+	model := NewModel()
+	model.SetBookmarker(func(command string) error {
+		saved = append(saved, command)
+		return nil
+	})
+	model.SetInitialPage(page)
+	model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("b")})
+	if len(saved) != 1 || saved[0] != "tar xf <path/to/file.tar>" {
+		t.Fatalf("bookmarked %q, want the selected example", saved)
+	}
+	if !strings.Contains(model.notification, "Bookmarked") {
+		t.Errorf("notification = %q, want a bookmark confirmation", model.notification)
+	}
+
+	model.SetBookmarkedCommands([]string{"docker compose up -d", "tar czf backup.tgz ."})
+	pages := []Page{{Name: "git"}, {Name: "tar"}, {Name: "ls"}, {Name: "docker"}}
+	var names []string
+	for _, p := range model.bookmarkedFirst(pages) {
+		names = append(names, p.Name)
+	}
+	if got := strings.Join(names, " "); got != "tar docker git ls" {
+		t.Errorf("bookmarkedFirst() = %s, want tar docker git ls", got)
+	}
+}
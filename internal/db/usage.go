@@ -0,0 +1,94 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/goccy/go-json"
+
+	"wut/internal/metrics"
+)
+
+const (
+	usageBucketName = "usage_metrics"
+	usageKey        = "usage"
+
+	// usageFlushTimeout is short so recording usage never delays exit
+	usageFlushTimeout = 100 * time.Millisecond
+)
+
+// FlushUsage adds usage recorded by this process to the totals in the
+// database. When another wut instance holds the database the usage is
+// dropped rather than waited for.
+func FlushUsage(ctx context.Context, dbPath string, usage *metrics.Usage) error {
+	if usage == nil || usage.Empty() || !fileExists(dbPath) {
+		return nil
+	}
+
+	db, err := openKV(dbPath, usageFlushTimeout, false)
+	if errors.Is(err, errLockTimeout) {
+		return nil
+	}
+	if err != nil {
+		return openError(dbPath, err)
+	}
+	storage := &Storage{db: db, path: dbPath}
+	defer storage.Close()
+
+	return storage.update(ctx, func(tx kvTx) error {
+		bucket, err := tx.CreateBucketIfNotExists([]byte(usageBucketName))
+		if err != nil {
+			return err
+		}
+		total, err := readUsage(bucket)
+		if err != nil {
+			return err
+		}
+		total.Merge(usage)
+
+		data, err := json.Marshal(total)
+		if err != nil {
+			return fmt.Errorf("failed to marshal usage: %w", err)
+		}
+		return bucket.Put([]byte(usageKey), data)
+	})
+}
+
+// GetUsage returns the usage recorded by every run of wut
+func (s *Storage) GetUsage(ctx context.Context) (*metrics.Usage, error) {
+	if s == nil || s.db == nil {
+		return nil, fmt.Errorf("storage not initialized")
+	}
+
+	usage := metrics.NewUsage()
+	err := s.view(ctx, func(tx kvTx) error {
+		bucket := tx.Bucket([]byte(usageBucketName))
+		if bucket == nil {
+			return nil
+		}
+		var err error
+		usage, err = readUsage(bucket)
+		return err
+	})
+	return usage, err
+}
+
+func readUsage(bucket kvBucket) (*metrics.Usage, error) {
+	usage := metrics.NewUsage()
+	data := bucket.Get([]byte(usageKey))
+	if data == nil {
+		return usage, nil
+	}
+	if err := json.Unmarshal(data, usage); err != nil {
+		return nil, fmt.Errorf("failed to read usage: %w", err)
+	}
+	if usage.Counters == nil {
+		usage.Counters = make(map[string]int64)
+	}
+	if usage.Histograms == nil {
+		usage.Histograms = make(map[string]metrics.HistogramData)
+	}
+	return usage, nil
+}
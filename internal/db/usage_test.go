@@ -0,0 +1,53 @@
+package db
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"wut/internal/metrics"
+)
+
+func TestFlushUsage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wut.db")
+	ctx := context.Background()
+
+	// Nothing is written without a database
+	usage := metrics.NewUsage()
+	usage.Counters["invocations.fix"] = 1
+	if err := FlushUsage(ctx, path, usage); err != nil || fileExists(path) {
+		t.Fatalf("FlushUsage() without a database = %v, created %v", err, fileExists(path))
+	}
+
+	storage, err := NewStorage(path)
+	if err != nil {
+		t.Fatalf("NewStorage() error = %v", err)
+	}
+	if err := storage.Close(); err != nil {
+		t.Fatal(err)
+	}
+	for range 2 {
+		if err := FlushUsage(ctx, path, usage); err != nil {
+			t.Fatalf("FlushUsage() error = %v", err)
+		}
+	}
+
+	storage, err = NewStorage(path)
+	if err != nil {
+		t.Fatalf("NewStorage() error = %v", err)
+	}
+	defer storage.Close()
+
+	// A locked database drops the usage instead of waiting
+	if err := FlushUsage(ctx, path, usage); err != nil {
+		t.Errorf("FlushUsage() on a locked database error = %v", err)
+	}
+
+	total, err := storage.GetUsage(ctx)
+	if err != nil {
+		t.Fatalf("GetUsage() error = %v", err)
+	}
+	if got := total.Invocations()["fix"]; got != 2 {
+		t.Errorf("fix invocations = %d, want 2", got)
+	}
+}
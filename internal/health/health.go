@@ -38,7 +38,8 @@ type Check struct {
 	Name        string
 	Description string
 	Checker     func(ctx context.Context) error
-	Critical    bool // If true, failure makes system unhealthy
+	Critical    bool   // If true, failure makes system unhealthy
+	Hint        string // How to fix a failure
 	Timeout     time.Duration
 }
 
@@ -50,6 +51,7 @@ type Result struct {
 	Response  time.Duration `json:"response_time"`
 	Timestamp time.Time     `json:"timestamp"`
 	Critical  bool          `json:"critical"`
+	Hint      string        `json:"hint,omitempty"`
 }
 
 // Health represents the overall health status
@@ -172,6 +174,7 @@ func (c *Checker) runCheck(ctx context.Context, check Check) Result {
 	if err != nil {
 		result.Status = "unhealthy"
 		result.Error = err.Error()
+		result.Hint = check.Hint
 	} else {
 		result.Status = "healthy"
 	}
@@ -2,10 +2,14 @@
 package logger
 
 import (
+	"compress/gzip"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -30,32 +34,57 @@ const (
 	FatalLevel
 )
 
-// Logger wraps charmbracelet/log with additional functionality
+// Log formats
+const (
+	// FormatText writes human-readable lines
+	FormatText = "text"
+	// FormatJSON writes one JSON object per line, for log aggregators
+	FormatJSON = "json"
+)
+
+// componentKey holds the With component in JSON output
+const componentKey = "component"
+
+// Logger wraps charmbracelet/log with additional functionality. It writes
+// structured lines to the log file and, optionally, readable ones to stderr.
 type Logger struct {
-	logger *log.Logger
-	level  Level
-	writer io.Writer
+	logger  *log.Logger
+	console *log.Logger
+	level   Level
+	writer  io.Writer
+
+	// root is the logger without a component, which JSON loggers derive
+	// from so nested With calls replace the component instead of repeating it
+	root *log.Logger
+	json bool
+	file *rotatingWriter
 }
 
 // Config holds logger configuration
 type Config struct {
-	Level      string
-	File       string
-	MaxSize    int  // MB
-	MaxBackups int  // number of backups
-	MaxAge     int  // days
-	Console    bool // output to console
+	Level        string
+	Format       string // text or json
+	File         string
+	MaxSize      int    // MB
+	MaxBackups   int    // number of backups
+	MaxAge       int    // days
+	Console      bool   // output to console
+	ConsoleLevel string // level for the console; empty uses Level
 }
 
 // DefaultConfig returns default logger configuration
 func DefaultConfig() Config {
 	return Config{
 		Level:      "info",
+		Format:     FormatText,
 		File:       "",
 		MaxSize:    10,
 		MaxBackups: 5,
 		MaxAge:     30,
 		Console:    true,
+		// Only problems reach the terminal unless --verbose or --debug
+		// asks for more
+		ConsoleLevel: "warn",
 	}
 }
 
@@ -68,51 +97,85 @@ func Initialize(cfg Config) error {
 	return initErr
 }
 
+// Configure replaces the global logger with one built from cfg, once the
+// configuration file has been read
+func Configure(cfg Config) error {
+	previous := globalLogger
+	if err := initLogger(cfg); err != nil {
+		return err
+	}
+	once.Do(func() {})
+	if previous != nil && previous.file != nil {
+		return previous.file.Close()
+	}
+	return nil
+}
+
 // initLogger creates and configures the logger
 func initLogger(cfg Config) error {
-	level := parseLevel(cfg.Level)
-
-	var writers []io.Writer
-
-	// Console output
-	if cfg.Console {
-		writers = append(writers, os.Stdout)
+	l, err := newLogger(cfg)
+	if err != nil {
+		return err
 	}
+	globalLogger = l
+	return nil
+}
+
+// newLogger builds a logger writing to the log file, and to the console
+// when cfg.Console is set
+func newLogger(cfg Config) (*Logger, error) {
+	level := parseLevel(cfg.Level)
 
-	// File output
+	var writer io.Writer = io.Discard
+	var fileWriter *rotatingWriter
 	if cfg.File != "" {
 		// Ensure log directory exists
 		dir := filepath.Dir(cfg.File)
 		if err := os.MkdirAll(dir, 0755); err != nil {
-			return fmt.Errorf("failed to create log directory: %w", err)
+			return nil, fmt.Errorf("failed to create log directory: %w", err)
 		}
 
-		fileWriter, err := newRotatingWriter(cfg)
+		var err error
+		fileWriter, err = newRotatingWriter(cfg)
 		if err != nil {
-			return fmt.Errorf("failed to create log file: %w", err)
+			return nil, fmt.Errorf("failed to create log file: %w", err)
 		}
-		writers = append(writers, fileWriter)
-	}
-
-	var writer io.Writer
-	if len(writers) == 1 {
-		writer = writers[0]
-	} else {
-		writer = io.MultiWriter(writers...)
+		writer = fileWriter
 	}
 
+	// Both formats write through the same writer, so the log file rotates
+	// the same way whichever is used
 	l := log.New(writer)
-	l.SetLevel(log.Level(level))
+	l.SetLevel(level.charmLevel())
 	l.SetTimeFormat(time.RFC3339)
 	l.SetReportTimestamp(true)
+	isJSON := parseFormat(cfg.Format) == FormatJSON
+	if isJSON {
+		l.SetFormatter(log.JSONFormatter)
+	}
 
-	globalLogger = &Logger{
-		logger: l,
-		level:  level,
-		writer: writer,
+	// Console output goes to stderr so it never mixes with command output
+	var console *log.Logger
+	if cfg.Console {
+		consoleLevel := level
+		if cfg.ConsoleLevel != "" {
+			consoleLevel = parseLevel(cfg.ConsoleLevel)
+		}
+		console = log.New(os.Stderr)
+		console.SetLevel(consoleLevel.charmLevel())
+		console.SetTimeFormat("15:04:05.000")
+		console.SetReportTimestamp(true)
 	}
 
-	return nil
+	return &Logger{
+		logger:  l,
+		console: console,
+		level:   level,
+		writer:  writer,
+		root:    l,
+		json:    isJSON,
+		file:    fileWriter,
+	}, nil
 }
 
 // Get returns the global logger instance
@@ -126,42 +189,74 @@ func Get() *Logger {
 
 // Debug logs debug message
 func (l *Logger) Debug(msg string, keyvals ...any) {
+	if l.console != nil {
+		l.console.Debug(msg, keyvals...)
+	}
 	l.logger.Debug(msg, keyvals...)
 }
 
 // Info logs info message
 func (l *Logger) Info(msg string, keyvals ...any) {
+	if l.console != nil {
+		l.console.Info(msg, keyvals...)
+	}
 	l.logger.Info(msg, keyvals...)
 }
 
 // Warn logs warning message
 func (l *Logger) Warn(msg string, keyvals ...any) {
+	if l.console != nil {
+		l.console.Warn(msg, keyvals...)
+	}
 	l.logger.Warn(msg, keyvals...)
 }
 
 // Error logs error message
 func (l *Logger) Error(msg string, keyvals ...any) {
+	if l.console != nil {
+		l.console.Error(msg, keyvals...)
+	}
 	l.logger.Error(msg, keyvals...)
 }
 
 // Fatal logs fatal message and exits
 func (l *Logger) Fatal(msg string, keyvals ...any) {
+	if l.console != nil {
+		l.console.Log(log.FatalLevel, msg, keyvals...)
+	}
 	l.logger.Fatal(msg, keyvals...)
 }
 
-// With returns logger with prefix
+// With returns logger with prefix. JSON loggers record it as the
+// "component" field.
 func (l *Logger) With(prefix string) *Logger {
-	return &Logger{
-		logger: l.logger.WithPrefix(prefix),
-		level:  l.level,
-		writer: l.writer,
+	child := *l
+	if l.json {
+		child.logger = l.root.With(componentKey, prefix)
+	} else {
+		child.logger = l.logger.WithPrefix(prefix)
+	}
+	if l.console != nil {
+		child.console = l.console.WithPrefix(prefix)
+	}
+	return &child
+}
+
+// Timed starts timing an operation and returns a function that logs, at
+// debug level, how long it took:
+//
+//	defer log.Timed("open storage", "path", path)()
+func (l *Logger) Timed(op string, keyvals ...any) func() {
+	start := time.Now()
+	return func() {
+		l.Debug(op, append(keyvals, "took", time.Since(start).Round(time.Microsecond))...)
 	}
 }
 
 // SetLevel sets logging level
 func (l *Logger) SetLevel(level Level) {
 	l.level = level
-	l.logger.SetLevel(log.Level(level))
+	l.logger.SetLevel(level.charmLevel())
 }
 
 // Sync flushes the log buffer
@@ -202,6 +297,27 @@ func With(prefix string) *Logger {
 	return Get().With(prefix)
 }
 
+// Timed times an operation with the global logger
+func Timed(op string, keyvals ...any) func() {
+	return Get().Timed(op, keyvals...)
+}
+
+// charmLevel is the charmbracelet/log level of l
+func (l Level) charmLevel() log.Level {
+	switch l {
+	case DebugLevel:
+		return log.DebugLevel
+	case WarnLevel:
+		return log.WarnLevel
+	case ErrorLevel:
+		return log.ErrorLevel
+	case FatalLevel:
+		return log.FatalLevel
+	default:
+		return log.InfoLevel
+	}
+}
+
 // parseLevel parses level string to Level
 func parseLevel(level string) Level {
 	switch level {
@@ -220,39 +336,62 @@ func parseLevel(level string) Level {
 	}
 }
 
-// rotatingWriter handles log rotation
+// parseFormat parses a log format, falling back to text
+func parseFormat(format string) string {
+	if strings.EqualFold(strings.TrimSpace(format), FormatJSON) {
+		return FormatJSON
+	}
+	return FormatText
+}
+
+// backupTimeFormat dates rotated log files, as wut.log.2006-01-02T15-04-05.000.gz;
+// it has no colons so the names are valid on Windows
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// rotatingWriter writes the log file and moves it into a timestamped backup
+// once it grows past maxSize MB. Several wut processes can write the same
+// file: they rotate it under an advisory lock, and a writer whose file was
+// rotated by another process reopens the new one before writing. Backups
+// are gzip-compressed at the rotation after theirs, once no writer can
+// still be appending to them.
 type rotatingWriter struct {
+	mu         sync.Mutex
 	filename   string
 	maxSize    int
 	maxBackups int
 	maxAge     int
 	file       *os.File
-	size       int64
+	// errOut is where the first failure to rotate is reported, as the log
+	// itself cannot say so
+	errOut         io.Writer
+	rotateReported bool
 }
 
-// newRotatingWriter creates a new rotating file writer
+// renameFile moves the log file into a backup; tests replace it to make
+// rotating fail
+var renameFile = os.Rename
+
+// newRotatingWriter creates a new rotating file writer, removing the backups
+// that max_backups and max_age no longer keep
 func newRotatingWriter(cfg Config) (*rotatingWriter, error) {
 	rw := &rotatingWriter{
 		filename:   cfg.File,
 		maxSize:    cfg.MaxSize,
 		maxBackups: cfg.MaxBackups,
 		maxAge:     cfg.MaxAge,
+		errOut:     os.Stderr,
 	}
 
 	if err := rw.open(); err != nil {
 		return nil, err
 	}
+	rw.prune()
 
 	return rw, nil
 }
 
 // open opens or creates the log file
 func (rw *rotatingWriter) open() error {
-	info, err := os.Stat(rw.filename)
-	if err == nil {
-		rw.size = info.Size()
-	}
-
 	file, err := os.OpenFile(rw.filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
 	if err != nil {
 		return err
@@ -262,45 +401,235 @@ func (rw *rotatingWriter) open() error {
 	return nil
 }
 
-// Write implements io.Writer
+// Write implements io.Writer. The line is written even when rotating fails,
+// so a full disk or a locked file costs the rotation rather than the log.
+// The first failure is reported on stderr.
 func (rw *rotatingWriter) Write(p []byte) (n int, err error) {
-	// Check if rotation is needed
-	if rw.size+int64(len(p)) > int64(rw.maxSize*1024*1024) {
-		if err := rw.rotate(); err != nil {
-			return 0, err
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+
+	if err := rw.reopenIfRotated(); err != nil {
+		return 0, err
+	}
+	if rw.needsRotation(len(p)) {
+		if err := rw.rotate(len(p)); err != nil && !rw.rotateReported {
+			rw.rotateReported = true
+			fmt.Fprintf(rw.errOut, "wut: failed to rotate %s: %v\n", rw.filename, err)
 		}
 	}
+	return rw.file.Write(p)
+}
 
-	n, err = rw.file.Write(p)
-	rw.size += int64(n)
-	return n, err
+// needsRotation reports whether writing n more bytes takes the log file
+// past maxSize. The size is the file's, so it counts what other processes
+// wrote.
+func (rw *rotatingWriter) needsRotation(n int) bool {
+	if rw.maxSize <= 0 {
+		return false
+	}
+	info, err := rw.file.Stat()
+	return err == nil && info.Size() > 0 && info.Size()+int64(n) > int64(rw.maxSize)*1024*1024
 }
 
-// rotate rotates the log file
-func (rw *rotatingWriter) rotate() error {
-	if rw.file != nil {
-		rw.file.Close()
+// reopenIfRotated reopens the log file when another process has moved it
+// into a backup since this one opened it
+func (rw *rotatingWriter) reopenIfRotated() error {
+	current, err := rw.file.Stat()
+	if err == nil {
+		if info, err := os.Stat(rw.filename); err == nil && os.SameFile(current, info) {
+			return nil
+		}
 	}
+	rw.file.Close()
+	return rw.open()
+}
 
-	// Remove oldest backup if exists
-	oldest := rw.filename + fmt.Sprintf(".%d", rw.maxBackups)
-	os.Remove(oldest)
+// rotate moves the log file into a timestamped backup before n more bytes
+// are written, unless another process rotated it while this one waited for
+// the lock
+func (rw *rotatingWriter) rotate(n int) error {
+	unlock, err := lockFile(rw.filename + ".lock")
+	if err != nil {
+		return fmt.Errorf("failed to lock log file: %w", err)
+	}
+	defer unlock()
 
-	// Shift backups
-	for i := rw.maxBackups - 1; i > 0; i-- {
-		old := rw.filename + fmt.Sprintf(".%d", i)
-		new := rw.filename + fmt.Sprintf(".%d", i+1)
-		_ = os.Rename(old, new)
+	if err := rw.reopenIfRotated(); err != nil {
+		return err
+	}
+	if !rw.needsRotation(n) {
+		return nil
 	}
 
-	// Rename current file
-	_ = os.Rename(rw.filename, rw.filename+".1")
+	// Windows cannot rename a file this process holds open, so it is closed
+	// first and reopened whatever the rename does
+	backup := rw.backupName(time.Now())
+	rw.file.Close()
+	renameErr := renameFile(rw.filename, backup)
+	if err := rw.open(); err != nil {
+		return err
+	}
+	if renameErr != nil {
+		return renameErr
+	}
 
-	return rw.open()
+	rw.compressBackups(backup)
+	rw.prune()
+	return nil
+}
+
+// backupName returns a name for a backup rotated at t that is not taken
+func (rw *rotatingWriter) backupName(t time.Time) string {
+	for {
+		name := rw.filename + "." + t.Format(backupTimeFormat)
+		if !fileExists(name) && !fileExists(name+".gz") {
+			return name
+		}
+		t = t.Add(time.Millisecond)
+	}
+}
+
+// compressBackups gzips the uncompressed backups other than latest, which
+// writers that have not yet noticed the rotation may still append to.
+// A backup that cannot be compressed is kept as it is.
+func (rw *rotatingWriter) compressBackups(latest string) {
+	backups, err := listBackups(rw.filename)
+	if err != nil {
+		return
+	}
+	for _, backup := range backups {
+		if backup.path == latest || strings.HasSuffix(backup.path, ".gz") {
+			continue
+		}
+		if err := compressFile(backup.path, backup.path+".gz"); err == nil {
+			_ = os.Remove(backup.path)
+		}
+	}
+}
+
+// prune deletes the backups past the newest maxBackups, and those rotated
+// more than maxAge days ago. Zero keeps any number, or any age.
+func (rw *rotatingWriter) prune() {
+	backups, err := listBackups(rw.filename)
+	if err != nil {
+		return
+	}
+	cutoff := time.Now().AddDate(0, 0, -rw.maxAge)
+	for i, backup := range backups {
+		if (rw.maxBackups > 0 && i >= rw.maxBackups) || (rw.maxAge > 0 && backup.rotated.Before(cutoff)) {
+			_ = os.Remove(backup.path)
+		}
+	}
+}
+
+// logBackup is a rotated log file
+type logBackup struct {
+	path    string
+	size    int64
+	rotated time.Time
+}
+
+// listBackups returns the backups of the log file at filename, newest
+// first. The numbered backups written by older versions, wut.log.1.gz and
+// up, are dated by their modification time.
+func listBackups(filename string) ([]logBackup, error) {
+	entries, err := os.ReadDir(filepath.Dir(filename))
+	if err != nil {
+		return nil, err
+	}
+	prefix := filepath.Base(filename) + "."
+
+	var backups []logBackup
+	for _, entry := range entries {
+		suffix, ok := strings.CutPrefix(entry.Name(), prefix)
+		if !ok || entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		stamp := strings.TrimSuffix(suffix, ".gz")
+		rotated, err := time.ParseInLocation(backupTimeFormat, stamp, time.Local)
+		if err != nil {
+			if _, err := strconv.Atoi(stamp); err != nil {
+				continue
+			}
+			rotated = info.ModTime()
+		}
+		backups = append(backups, logBackup{
+			path:    filepath.Join(filepath.Dir(filename), entry.Name()),
+			size:    info.Size(),
+			rotated: rotated,
+		})
+	}
+	slices.SortFunc(backups, func(a, b logBackup) int {
+		return b.rotated.Compare(a.rotated)
+	})
+	return backups, nil
+}
+
+// DiskUsage returns the number of bytes taken by the log file at filename
+// and its backups, and how many files that is
+func DiskUsage(filename string) (size int64, files int, err error) {
+	if info, err := os.Stat(filename); err == nil {
+		size, files = info.Size(), 1
+	} else if !os.IsNotExist(err) {
+		return 0, 0, err
+	}
+
+	backups, err := listBackups(filename)
+	if err != nil && !os.IsNotExist(err) {
+		return 0, 0, err
+	}
+	for _, backup := range backups {
+		size += backup.size
+	}
+	return size, files + len(backups), nil
+}
+
+// fileExists reports whether a file exists at path
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// compressFile writes a gzip copy of src to dst with the same modification
+// time, so backups age from their last log line
+func compressFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(out)
+	_, err = io.Copy(zw, in)
+	if closeErr := zw.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(dst)
+		return err
+	}
+	return os.Chtimes(dst, info.ModTime(), info.ModTime())
 }
 
 // Close closes the file
 func (rw *rotatingWriter) Close() error {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
 	if rw.file != nil {
 		return rw.file.Close()
 	}
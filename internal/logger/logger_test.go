@@ -0,0 +1,303 @@
+package logger
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestJSONFormat(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "wut.log")
+	l, err := newLogger(Config{Level: "debug", Format: "JSON", File: file, MaxSize: 1, MaxBackups: 2})
+	if err != nil {
+		t.Fatalf("newLogger() error = %v", err)
+	}
+	defer l.file.Close()
+
+	l.With("db").With("compact").Warn("pruned", "entries", 3)
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var line map[string]any
+	if err := json.Unmarshal(data, &line); err != nil {
+		t.Fatalf("log line %q is not JSON: %v", data, err)
+	}
+	if _, err := time.Parse(time.RFC3339, line["time"].(string)); err != nil {
+		t.Errorf("time = %v, want RFC 3339", line["time"])
+	}
+	want := map[string]any{"level": "warn", "component": "compact", "msg": "pruned", "entries": float64(3)}
+	for key, value := range want {
+		if line[key] != value {
+			t.Errorf("%s = %v, want %v", key, line[key], value)
+		}
+	}
+}
+
+func TestTextFormat(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "wut.log")
+	l, err := newLogger(Config{Level: "debug", File: file, MaxSize: 1})
+	if err != nil {
+		t.Fatalf("newLogger() error = %v", err)
+	}
+	defer l.file.Close()
+
+	l.With("db").Warn("pruned", "entries", 3)
+
+	data, _ := os.ReadFile(file)
+	if line := string(data); !strings.Contains(line, "WARN db: pruned entries=3") {
+		t.Errorf("log line = %q, want text", line)
+	}
+}
+
+// fillPast writes log lines until the log file passes 1 MB
+func fillPast(l *Logger) {
+	padding := strings.Repeat("x", 1024)
+	for range 1100 {
+		l.Warn("filler", "padding", padding)
+	}
+}
+
+// readLog returns the contents of a log file, decompressing backups
+func readLog(t *testing.T, path string) string {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var r io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		zr, err := gzip.NewReader(f)
+		if err != nil {
+			t.Fatalf("%s is not gzip: %v", path, err)
+		}
+		r = zr
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestRotation(t *testing.T) {
+	for _, format := range []string{FormatText, FormatJSON} {
+		t.Run(format, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "wut.log")
+			l, err := newLogger(Config{Level: "debug", Format: format, File: file, MaxSize: 1, MaxBackups: 2, MaxAge: 1})
+			if err != nil {
+				t.Fatalf("newLogger() error = %v", err)
+			}
+			defer l.file.Close()
+
+			// Writing past the limit moves the log into a timestamped backup,
+			// left uncompressed while other writers may still append to it
+			fillPast(l)
+			if info, err := os.Stat(file); err != nil || info.Size() >= 1024*1024 {
+				t.Fatalf("log file was not rotated: %v", err)
+			}
+			backups, err := listBackups(file)
+			if err != nil || len(backups) != 1 {
+				t.Fatalf("backups = %+v, %v; want one", backups, err)
+			}
+			first := backups[0].path
+			if _, err := time.Parse(backupTimeFormat, strings.TrimPrefix(first, file+".")); err != nil {
+				t.Errorf("backup %s is not named by its rotation time", first)
+			}
+			if data := readLog(t, first); len(data) > 1024*1024 || !strings.Contains(data, "filler") {
+				t.Errorf("backup holds %d bytes, want the rotated log of at most 1 MB", len(data))
+			}
+
+			// The next rotation compresses the previous backup
+			fillPast(l)
+			if _, err := os.Stat(first + ".gz"); err != nil {
+				t.Errorf("previous backup was not compressed: %v", err)
+			}
+			if data := readLog(t, first+".gz"); !strings.Contains(data, "filler") {
+				t.Errorf("compressed backup lost its lines")
+			}
+
+			// Only MaxBackups backups are kept, the newest
+			fillPast(l)
+			backups, _ = listBackups(file)
+			if len(backups) != 2 || fileExists(first+".gz") {
+				t.Errorf("backups = %+v, want the 2 newest", backups)
+			}
+		})
+	}
+}
+
+func TestPruneOnStartup(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "wut.log")
+	now := time.Now()
+	backup := func(age time.Duration) string {
+		return file + "." + now.Add(-age).Format(backupTimeFormat) + ".gz"
+	}
+	recent, older, expired := backup(time.Hour), backup(2*time.Hour), backup(72*time.Hour)
+	surplus := backup(3 * time.Hour)
+	legacy := file + ".1.gz"
+	others := []string{file + ".lock", file + ".bak"}
+	for _, path := range append([]string{recent, older, surplus, expired, legacy}, others...) {
+		if err := os.WriteFile(path, []byte("line\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Numbered backups of older versions age by their modification time
+	old := now.AddDate(0, 0, -3)
+	if err := os.Chtimes(legacy, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := newLogger(Config{File: file, MaxSize: 1, MaxBackups: 2, MaxAge: 1})
+	if err != nil {
+		t.Fatalf("newLogger() error = %v", err)
+	}
+	defer l.file.Close()
+
+	for _, path := range append([]string{recent, older}, others...) {
+		if !fileExists(path) {
+			t.Errorf("%s was removed", filepath.Base(path))
+		}
+	}
+	for _, path := range []string{surplus, expired, legacy} {
+		if fileExists(path) {
+			t.Errorf("%s was kept", filepath.Base(path))
+		}
+	}
+}
+
+// TestConcurrentWriters writes from two writers, as two wut processes
+// would, across several rotations and checks that no line is lost
+func TestConcurrentWriters(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "wut.log")
+	const lines = 1500
+	line := strings.Repeat("x", 1024)
+
+	var wg sync.WaitGroup
+	for w := range 2 {
+		rw, err := newRotatingWriter(Config{File: file, MaxSize: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rw.Close()
+		wg.Go(func() {
+			for i := range lines {
+				if _, err := fmt.Fprintf(rw, "%d-%d %s\n", w, i, line); err != nil {
+					t.Errorf("write error = %v", err)
+					return
+				}
+			}
+		})
+	}
+	wg.Wait()
+
+	backups, err := listBackups(file)
+	if err != nil || len(backups) < 2 {
+		t.Fatalf("backups = %+v, %v; want several rotations", backups, err)
+	}
+	count := strings.Count(readLog(t, file), "\n")
+	for _, backup := range backups {
+		count += strings.Count(readLog(t, backup.path), "\n")
+	}
+	if count != 2*lines {
+		t.Errorf("log files hold %d lines, want %d", count, 2*lines)
+	}
+
+	size, files, err := DiskUsage(file)
+	if err != nil || files != len(backups)+1 || size <= 0 {
+		t.Errorf("DiskUsage() = %d, %d, %v; want %d files", size, files, err, len(backups)+1)
+	}
+}
+
+func TestLevels(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "wut.log")
+	l, err := newLogger(Config{Level: "info", File: file})
+	if err != nil {
+		t.Fatalf("newLogger() error = %v", err)
+	}
+	defer l.file.Close()
+
+	l.Debug("hidden")
+	l.Info("shown")
+	l.Timed("open storage")()
+
+	data, _ := os.ReadFile(file)
+	if log := string(data); !strings.Contains(log, "INFO shown") || strings.Contains(log, "hidden") || strings.Contains(log, "open storage") {
+		t.Errorf("info log = %q, want info lines only", log)
+	}
+
+	l.SetLevel(DebugLevel)
+	l.With("db").Timed("open storage", "path", "wut.db")()
+	data, _ = os.ReadFile(file)
+	if log := string(data); !strings.Contains(log, "DEBU db: open storage path=wut.db took=") {
+		t.Errorf("debug log = %q, want the timed operation", log)
+	}
+}
+
+func TestRotateWithFileOpenElsewhere(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "wut.log")
+	rw, err := newRotatingWriter(Config{File: file, MaxSize: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rw.Close()
+	var errOut bytes.Buffer
+	rw.errOut = &errOut
+
+	// Another process holding the log open does not stop the rotation, but
+	// for Windows, which refuses to rename a file open elsewhere
+	other, err := os.OpenFile(file, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close()
+	line := strings.Repeat("x", 1024) + "\n"
+	for range 1100 {
+		if _, err := io.WriteString(rw, line); err != nil {
+			t.Fatalf("write error = %v", err)
+		}
+	}
+	if _, err := io.WriteString(other, "other\n"); err != nil {
+		t.Fatalf("write through the other handle error = %v", err)
+	}
+	backups, err := listBackups(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	switch {
+	case len(backups) == 1 && errOut.Len() == 0:
+	case len(backups) == 0 && runtime.GOOS == "windows" && errOut.Len() > 0:
+		return
+	default:
+		t.Fatalf("backups = %+v, reported %q; want one rotation", backups, errOut.String())
+	}
+
+	// A rename that fails keeps the log writable and is reported once
+	origRename := renameFile
+	t.Cleanup(func() { renameFile = origRename })
+	renameFile = func(string, string) error { return errors.New("file in use") }
+	for range 1100 {
+		if _, err := io.WriteString(rw, line); err != nil {
+			t.Fatalf("write error after a failed rotation = %v", err)
+		}
+	}
+	if got := strings.Count(errOut.String(), "file in use"); got != 1 {
+		t.Errorf("rotation failure reported %d times, want once:\n%s", got, errOut.String())
+	}
+	if info, err := os.Stat(file); err != nil || info.Size() < 1024*1024 {
+		t.Errorf("log file was not written after the failure: %v", err)
+	}
+}
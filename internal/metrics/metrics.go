@@ -3,7 +3,9 @@ package metrics
 
 import (
 	"context"
+	"net"
 	"net/http"
+	"net/http/pprof"
 	"runtime"
 	"sync"
 	"sync/atomic"
@@ -36,6 +38,11 @@ type Metrics struct {
 	customGauges     map[string]*atomic.Int64
 	customHistograms map[string]*histogram
 	mu               sync.RWMutex
+
+	// What TakeUsage already returned
+	usageSince        time.Time
+	flushedCounters   map[string]int64
+	flushedHistograms map[string]HistogramData
 }
 
 // histogram represents a histogram metric
@@ -63,6 +70,9 @@ func Initialize(version, commit string) *Metrics {
 			customCounters:   make(map[string]*atomic.Int64),
 			customGauges:     make(map[string]*atomic.Int64),
 			customHistograms: make(map[string]*histogram),
+
+			flushedCounters:   make(map[string]int64),
+			flushedHistograms: make(map[string]HistogramData),
 		}
 	})
 	return globalMetrics
@@ -246,13 +256,9 @@ func (m *Metrics) JSON() ([]byte, error) {
 
 // StartServer starts the metrics HTTP server
 func (m *Metrics) StartServer(ctx context.Context, addr string) error {
-	mux := http.NewServeMux()
-	mux.HandleFunc("/metrics", m.handleMetrics)
-	mux.HandleFunc("/health", m.handleHealth)
-
 	server := &http.Server{
-		Addr:    addr,
-		Handler: mux,
+		Addr:    LocalAddr(addr),
+		Handler: m.handler(),
 	}
 
 	go func() {
@@ -265,6 +271,50 @@ func (m *Metrics) StartServer(ctx context.Context, addr string) error {
 	return server.ListenAndServe()
 }
 
+// Serve starts the metrics HTTP server in the background. It returns the
+// address it listens on and a function that shuts it down.
+func (m *Metrics) Serve(addr string) (net.Addr, func(), error) {
+	listener, err := net.Listen("tcp", LocalAddr(addr))
+	if err != nil {
+		return nil, nil, err
+	}
+	server := &http.Server{
+		Handler:           m.handler(),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go func() { _ = server.Serve(listener) }()
+
+	shutdown := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		_ = server.Shutdown(ctx)
+	}
+	return listener.Addr(), shutdown, nil
+}
+
+// LocalAddr binds an address without a host, such as ":6060", to localhost,
+// so metrics and profiles are not exposed to the network by accident
+func LocalAddr(addr string) string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil || host != "" {
+		return addr
+	}
+	return net.JoinHostPort("127.0.0.1", port)
+}
+
+// handler serves the metrics, a health check and the net/http/pprof profiles
+func (m *Metrics) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", m.handleMetrics)
+	mux.HandleFunc("/health", m.handleHealth)
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	return mux
+}
+
 // handleMetrics handles /metrics endpoint
 func (m *Metrics) handleMetrics(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
@@ -0,0 +1,57 @@
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestLocalAddr(t *testing.T) {
+	tests := map[string]string{
+		":6060":          "127.0.0.1:6060",
+		"0.0.0.0:6060":   "0.0.0.0:6060",
+		"localhost:6060": "localhost:6060",
+		"[::1]:6060":     "[::1]:6060",
+	}
+	for addr, want := range tests {
+		if got := LocalAddr(addr); got != want {
+			t.Errorf("LocalAddr(%q) = %q, want %q", addr, got, want)
+		}
+	}
+}
+
+func TestServe(t *testing.T) {
+	m := Initialize("test", "test")
+	m.RecordCommandExplained()
+
+	addr, shutdown, err := m.Serve(":0")
+	if err != nil {
+		t.Fatalf("Serve() error = %v", err)
+	}
+	defer shutdown()
+	if !strings.HasPrefix(addr.String(), "127.0.0.1:") {
+		t.Errorf("Serve(:0) listens on %s, want localhost", addr)
+	}
+
+	for path, want := range map[string]string{
+		"/metrics":             `"explained"`,
+		"/debug/pprof/":        "goroutine",
+		"/debug/pprof/cmdline": "",
+	} {
+		resp, err := http.Get("http://" + addr.String() + path)
+		if err != nil {
+			t.Fatalf("GET %s error = %v", path, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), want) {
+			t.Errorf("GET %s = %d %q, want 200 containing %q", path, resp.StatusCode, body, want)
+		}
+	}
+
+	shutdown()
+	if _, err := http.Get("http://" + addr.String() + "/metrics"); err == nil {
+		t.Error("server still answers after shutdown")
+	}
+}
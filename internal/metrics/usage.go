@@ -0,0 +1,247 @@
+package metrics
+
+import (
+	"strings"
+	"time"
+)
+
+// Usage metric names. Counters and histograms under these prefixes are kept
+// in the local database and shown by `wut stats`; they are never sent
+// anywhere.
+const (
+	invocationPrefix = "invocations."
+	cachePrefix      = "cache."
+
+	CorrectionsHit      = "corrections.hit"
+	CorrectionsMiss     = "corrections.miss"
+	CorrectionsAccepted = "corrections.accepted"
+	CorrectionsRejected = "corrections.rejected"
+
+	LatencySuggest = "latency.suggest"
+	LatencySearch  = "latency.search"
+)
+
+// latencyBuckets are the upper bounds, in milliseconds, of latency histograms
+var latencyBuckets = []int64{5, 10, 25, 50, 100, 250, 500, 1000, 2500}
+
+// Usage is a set of counters and histograms, either recorded since the last
+// flush or accumulated in the database
+type Usage struct {
+	Since      time.Time                `json:"since"`
+	Counters   map[string]int64         `json:"counters"`
+	Histograms map[string]HistogramData `json:"histograms"`
+}
+
+// HistogramData is the content of a histogram. Counts has one more entry
+// than Buckets, for values above the last bound.
+type HistogramData struct {
+	Buckets []int64 `json:"buckets"`
+	Counts  []int64 `json:"counts"`
+	Sum     int64   `json:"sum"`
+	Count   int64   `json:"count"`
+}
+
+// NewUsage returns empty usage
+func NewUsage() *Usage {
+	return &Usage{
+		Counters:   make(map[string]int64),
+		Histograms: make(map[string]HistogramData),
+	}
+}
+
+// Empty reports whether nothing was recorded
+func (u *Usage) Empty() bool {
+	return len(u.Counters) == 0 && len(u.Histograms) == 0
+}
+
+// Merge adds other to u
+func (u *Usage) Merge(other *Usage) {
+	if u.Since.IsZero() || (!other.Since.IsZero() && other.Since.Before(u.Since)) {
+		u.Since = other.Since
+	}
+	for name, value := range other.Counters {
+		u.Counters[name] += value
+	}
+	for name, h := range other.Histograms {
+		merged, ok := u.Histograms[name]
+		if !ok || len(merged.Counts) != len(h.Counts) {
+			// Buckets changed between versions; the newer ones win
+			u.Histograms[name] = h
+			continue
+		}
+		counts := make([]int64, len(h.Counts))
+		for i := range counts {
+			counts[i] = merged.Counts[i] + h.Counts[i]
+		}
+		u.Histograms[name] = HistogramData{
+			Buckets: h.Buckets,
+			Counts:  counts,
+			Sum:     merged.Sum + h.Sum,
+			Count:   merged.Count + h.Count,
+		}
+	}
+}
+
+// Mean returns the average value recorded in a histogram
+func (h HistogramData) Mean() float64 {
+	if h.Count == 0 {
+		return 0
+	}
+	return float64(h.Sum) / float64(h.Count)
+}
+
+// Invocations returns how often each command was run
+func (u *Usage) Invocations() map[string]int64 {
+	return u.withPrefix(invocationPrefix)
+}
+
+// Ratio returns hits / (hits + misses), or -1 when neither was recorded
+func (u *Usage) Ratio(hits, misses string) float64 {
+	h, m := u.Counters[hits], u.Counters[misses]
+	if h+m == 0 {
+		return -1
+	}
+	return float64(h) / float64(h+m)
+}
+
+// CacheHitRatios returns the hit ratio of each cache that was used
+func (u *Usage) CacheHitRatios() map[string]float64 {
+	ratios := make(map[string]float64)
+	for name := range u.withPrefix(cachePrefix) {
+		cache, _, _ := strings.Cut(name, ".")
+		if _, ok := ratios[cache]; !ok {
+			ratios[cache] = u.Ratio(cachePrefix+cache+".hit", cachePrefix+cache+".miss")
+		}
+	}
+	return ratios
+}
+
+func (u *Usage) withPrefix(prefix string) map[string]int64 {
+	values := make(map[string]int64)
+	for name, value := range u.Counters {
+		if rest, ok := strings.CutPrefix(name, prefix); ok {
+			values[rest] = value
+		}
+	}
+	return values
+}
+
+// RecordInvocation counts a run of a WUT command
+func (m *Metrics) RecordInvocation(command string) {
+	m.IncrementCounter(invocationPrefix + command)
+}
+
+// RecordCorrection counts whether the corrector found something to fix
+func (m *Metrics) RecordCorrection(corrected bool) {
+	if corrected {
+		m.IncrementCounter(CorrectionsHit)
+	} else {
+		m.IncrementCounter(CorrectionsMiss)
+	}
+}
+
+// RecordCorrectionFeedback counts whether the user took a correction
+func (m *Metrics) RecordCorrectionFeedback(accepted bool) {
+	if accepted {
+		m.IncrementCounter(CorrectionsAccepted)
+	} else {
+		m.IncrementCounter(CorrectionsRejected)
+	}
+}
+
+// RecordCache counts a cache lookup
+func (m *Metrics) RecordCache(cache string, hit bool) {
+	if hit {
+		m.IncrementCounter(cachePrefix + cache + ".hit")
+	} else {
+		m.IncrementCounter(cachePrefix + cache + ".miss")
+	}
+}
+
+// ObserveLatency records how long an operation that began at start took
+func (m *Metrics) ObserveLatency(name string, start time.Time) {
+	m.RecordHistogram(name, time.Since(start).Milliseconds(), latencyBuckets)
+}
+
+// TakeUsage returns the counters and histograms recorded since the last call
+func (m *Metrics) TakeUsage() *Usage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	usage := NewUsage()
+	usage.Since = m.usageSince
+	if usage.Since.IsZero() {
+		usage.Since = m.StartTime
+	}
+	m.usageSince = time.Now()
+
+	for name, counter := range m.customCounters {
+		value := counter.Load()
+		if delta := value - m.flushedCounters[name]; delta > 0 {
+			usage.Counters[name] = delta
+		}
+		m.flushedCounters[name] = value
+	}
+	for name, h := range m.customHistograms {
+		current := h.data()
+		flushed, ok := m.flushedHistograms[name]
+		m.flushedHistograms[name] = current
+		if ok {
+			for i := range current.Counts {
+				current.Counts[i] -= flushed.Counts[i]
+			}
+			current.Sum -= flushed.Sum
+			current.Count -= flushed.Count
+		}
+		if current.Count > 0 {
+			usage.Histograms[name] = current
+		}
+	}
+	return usage
+}
+
+// data copies the content of a histogram
+func (h *histogram) data() HistogramData {
+	counts := make([]int64, len(h.counts))
+	for i := range h.counts {
+		counts[i] = h.counts[i].Load()
+	}
+	return HistogramData{
+		Buckets: h.buckets,
+		Counts:  counts,
+		Sum:     h.sum.Load(),
+		Count:   h.count.Load(),
+	}
+}
+
+// Convenience functions
+
+// RecordInvocation counts a run of a WUT command
+func RecordInvocation(command string) {
+	Get().RecordInvocation(command)
+}
+
+// RecordCorrection counts whether the corrector found something to fix
+func RecordCorrection(corrected bool) {
+	Get().RecordCorrection(corrected)
+}
+
+// RecordCorrectionFeedback counts whether the user took a correction
+func RecordCorrectionFeedback(accepted bool) {
+	Get().RecordCorrectionFeedback(accepted)
+}
+
+// RecordCache counts a cache lookup
+func RecordCache(cache string, hit bool) {
+	Get().RecordCache(cache, hit)
+}
+
+// ObserveLatency records how long an operation that began at start took
+func ObserveLatency(name string, start time.Time) {
+	Get().ObserveLatency(name, start)
+}
+
+// TakeUsage returns the usage recorded since the last call
+func TakeUsage() *Usage {
+	return Get().TakeUsage()
+}
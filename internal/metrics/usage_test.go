@@ -0,0 +1,73 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTakeUsage(t *testing.T) {
+	m := Initialize("test", "test")
+	m.RecordInvocation("fix")
+	m.RecordInvocation("fix")
+	m.RecordCorrection(true)
+	m.RecordCache("suggestions", true)
+	m.RecordCache("suggestions", false)
+	m.ObserveLatency(LatencySuggest, time.Now().Add(-30*time.Millisecond))
+
+	usage := m.TakeUsage()
+	if got := usage.Invocations()["fix"]; got != 2 {
+		t.Errorf("fix invocations = %d, want 2", got)
+	}
+	if got := usage.Ratio(CorrectionsHit, CorrectionsMiss); got != 1 {
+		t.Errorf("correction hit rate = %v, want 1", got)
+	}
+	if got := usage.Ratio(CorrectionsAccepted, CorrectionsRejected); got != -1 {
+		t.Errorf("acceptance rate = %v, want -1 when nothing was recorded", got)
+	}
+	if got := usage.CacheHitRatios()["suggestions"]; got != 0.5 {
+		t.Errorf("suggestions cache hit ratio = %v, want 0.5", got)
+	}
+	if h := usage.Histograms[LatencySuggest]; h.Count != 1 || h.Mean() < 30 {
+		t.Errorf("suggest latency = %+v, want one value of at least 30ms", h)
+	}
+
+	// A second take only returns what was recorded since the first
+	m.RecordInvocation("stats")
+	usage = m.TakeUsage()
+	if got := usage.Invocations(); len(got) != 1 || got["stats"] != 1 {
+		t.Errorf("invocations after second take = %v, want only stats", got)
+	}
+	if len(usage.Histograms) != 0 {
+		t.Errorf("histograms after second take = %v, want none", usage.Histograms)
+	}
+}
+
+func TestUsageMerge(t *testing.T) {
+	earlier := time.Now().Add(-time.Hour)
+	total := NewUsage()
+	total.Merge(&Usage{
+		Since:    earlier,
+		Counters: map[string]int64{"invocations.fix": 1},
+		Histograms: map[string]HistogramData{
+			LatencySearch: {Buckets: []int64{10}, Counts: []int64{1, 0}, Sum: 4, Count: 1},
+		},
+	})
+	total.Merge(&Usage{
+		Since:    time.Now(),
+		Counters: map[string]int64{"invocations.fix": 2},
+		Histograms: map[string]HistogramData{
+			LatencySearch: {Buckets: []int64{10}, Counts: []int64{0, 1}, Sum: 20, Count: 1},
+		},
+	})
+
+	if !total.Since.Equal(earlier) {
+		t.Errorf("Since = %v, want the earliest %v", total.Since, earlier)
+	}
+	if got := total.Counters["invocations.fix"]; got != 3 {
+		t.Errorf("fix invocations = %d, want 3", got)
+	}
+	h := total.Histograms[LatencySearch]
+	if h.Count != 2 || h.Mean() != 12 || h.Counts[0] != 1 || h.Counts[1] != 1 {
+		t.Errorf("merged histogram = %+v", h)
+	}
+}
@@ -2,17 +2,54 @@
 package performance
 
 import (
+	"sort"
+	"strings"
 	"unicode"
 
 	"github.com/lithammer/fuzzysearch/fuzzy"
 )
 
+// Algorithm is how a FastMatcher scores a query that is not part of the
+// target as written
+type Algorithm string
+
+const (
+	// AlgorithmHybrid matches the query's characters in order and falls back
+	// to Levenshtein distance for typos
+	AlgorithmHybrid Algorithm = "hybrid"
+	// AlgorithmLevenshtein scores by the edits turning the query into the
+	// target
+	AlgorithmLevenshtein Algorithm = "levenshtein"
+	// AlgorithmDamerau is AlgorithmLevenshtein counting a swap of two
+	// neighbouring characters as one edit
+	AlgorithmDamerau Algorithm = "damerau"
+	// AlgorithmJaroWinkler scores by the characters the strings share near
+	// the same place, favoring a shared start
+	AlgorithmJaroWinkler Algorithm = "jaro_winkler"
+)
+
+// Algorithms are the algorithms a FastMatcher implements
+var Algorithms = []Algorithm{AlgorithmHybrid, AlgorithmLevenshtein, AlgorithmDamerau, AlgorithmJaroWinkler}
+
+// ParseAlgorithm returns the algorithm called name; ok is false and the
+// algorithm AlgorithmHybrid when there is none
+func ParseAlgorithm(name string) (algorithm Algorithm, ok bool) {
+	algorithm = Algorithm(strings.ToLower(strings.TrimSpace(name)))
+	for _, a := range Algorithms {
+		if a == algorithm {
+			return a, true
+		}
+	}
+	return AlgorithmHybrid, false
+}
+
 // FastMatcher provides high-performance fuzzy matching
 // Uses optimized algorithms with minimal allocations
 type FastMatcher struct {
 	caseSensitive bool
 	threshold     float64
 	maxDistance   int
+	algorithm     Algorithm
 }
 
 // NewFastMatcher creates a new fast matcher
@@ -21,16 +58,35 @@ func NewFastMatcher(caseSensitive bool, threshold float64, maxDistance int) *Fas
 		caseSensitive: caseSensitive,
 		threshold:     threshold,
 		maxDistance:   maxDistance,
+		algorithm:     AlgorithmHybrid,
 	}
 }
 
+// SetAlgorithm sets how a query that is not part of the target is scored;
+// an unknown algorithm is AlgorithmHybrid. It is not safe to call while the
+// matcher is in use.
+func (m *FastMatcher) SetAlgorithm(algorithm Algorithm) {
+	m.algorithm, _ = ParseAlgorithm(string(algorithm))
+}
+
 // MatchResult represents a fuzzy match result
 type MatchResult struct {
-	Score      float64
+	Score float64
+	// Distance is the edit distance of a match scored by one, and the
+	// characters of the target the query skipped for an in-order match
 	Distance   int
 	Matched    bool
 	MatchStart int
 	MatchEnd   int
+	// Spans are the parts of the target the query's characters matched, in
+	// order. A match found by edit distance has none.
+	Spans []Span
+}
+
+// Span is a range of bytes of a match target, End exclusive
+type Span struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
 }
 
 // Match performs fuzzy matching between query and target
@@ -41,7 +97,7 @@ func (m *FastMatcher) Match(query, target string) MatchResult {
 	}
 
 	if query == target {
-		return MatchResult{Score: 1.0, Distance: 0, Matched: true, MatchStart: 0, MatchEnd: len(target)}
+		return MatchResult{Score: 1.0, Distance: 0, Matched: true, MatchStart: 0, MatchEnd: len(target), Spans: []Span{{0, len(target)}}}
 	}
 
 	// Preprocess
@@ -67,6 +123,7 @@ func (m *FastMatcher) Match(query, target string) MatchResult {
 			Matched:    true,
 			MatchStart: idx,
 			MatchEnd:   idx + len(query),
+			Spans:      []Span{{idx, idx + len(query)}},
 		}
 	}
 
@@ -78,29 +135,27 @@ func (m *FastMatcher) Match(query, target string) MatchResult {
 			Matched:    true,
 			MatchStart: 0,
 			MatchEnd:   len(query),
+			Spans:      []Span{{0, len(query)}},
 		}
 	}
 
+	switch m.algorithm {
+	case AlgorithmLevenshtein:
+		return m.distanceMatch(query, target, fuzzy.LevenshteinDistance(query, target))
+	case AlgorithmDamerau:
+		return m.distanceMatch(query, target, osaDistance(query, target))
+	case AlgorithmJaroWinkler:
+		if score := jaroWinkler(query, target); score >= m.threshold {
+			return MatchResult{Score: score, Matched: true}
+		}
+		return MatchResult{Score: 0, Matched: false}
+	}
+
 	// Fuzzy match
 	matched, positions := fuzzyMatch(query, target)
 	if !matched {
 		// Try highly optimized Levenshtein distance from fuzzysearch
-		dist := fuzzy.LevenshteinDistance(query, target)
-		if dist > m.maxDistance {
-			return MatchResult{Score: 0, Matched: false}
-		}
-
-		maxLen := maxInt(len(query), len(target))
-		score := 1.0 - float64(dist)/float64(maxLen)
-		if score < m.threshold {
-			return MatchResult{Score: 0, Matched: false}
-		}
-
-		return MatchResult{
-			Score:    score,
-			Distance: dist,
-			Matched:  true,
-		}
+		return m.distanceMatch(query, target, fuzzy.LevenshteinDistance(query, target))
 	}
 
 	// Calculate score based on match quality
@@ -115,7 +170,170 @@ func (m *FastMatcher) Match(query, target string) MatchResult {
 		Matched:    true,
 		MatchStart: positions[0],
 		MatchEnd:   positions[len(positions)-1] + 1,
+		Spans:      positionSpans(positions),
+	}
+}
+
+// distanceMatch scores a match dist edits away, relative to the longer of
+// query and target
+func (m *FastMatcher) distanceMatch(query, target string, dist int) MatchResult {
+	if dist > m.maxDistance {
+		return MatchResult{Score: 0, Matched: false}
+	}
+
+	maxLen := maxInt(len(query), len(target))
+	score := 1.0 - float64(dist)/float64(maxLen)
+	if score < m.threshold {
+		return MatchResult{Score: 0, Matched: false}
+	}
+
+	return MatchResult{
+		Score:    score,
+		Distance: dist,
+		Matched:  true,
+	}
+}
+
+// osaDistance returns the edits turning a into b, where an edit inserts,
+// deletes or replaces a byte or swaps two neighbouring ones, and no part of
+// the string is edited twice
+func osaDistance(a, b string) int {
+	if len(a) < len(b) {
+		a, b = b, a
+	}
+	if len(b) == 0 {
+		return len(a)
+	}
+
+	// Rows of the distance table for the two previous bytes of a and the
+	// current one
+	var buf [3 * 65]int
+	rows := buf[:]
+	if len(b) >= 65 {
+		rows = make([]int, 3*(len(b)+1))
+	}
+	prev2, prev, cur := rows[:len(b)+1], rows[len(b)+1:2*(len(b)+1)], rows[2*(len(b)+1):3*(len(b)+1)]
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				cur[j] = min(cur[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return prev[len(b)]
+}
+
+// jaroWinkler returns the Jaro-Winkler similarity of a and b, from 0 to 1.
+// Bytes match when equal and less than half the longer string apart, and
+// every byte of a shared prefix, up to 4, adds a tenth of what the Jaro
+// similarity lacks.
+func jaroWinkler(a, b string) float64 {
+	if a == b {
+		return 1
+	}
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+
+	var aBuf, bBuf [64]bool
+	aMatched, bMatched := aBuf[:], bBuf[:]
+	if len(b) > len(bBuf) {
+		aMatched, bMatched = make([]bool, len(a)), make([]bool, len(b))
+	}
+	window := max(len(b)/2-1, 0)
+	matches := 0
+	for i := 0; i < len(a); i++ {
+		for j := max(i-window, 0); j < min(i+window+1, len(b)); j++ {
+			if !bMatched[j] && a[i] == b[j] {
+				aMatched[i], bMatched[j] = true, true
+				matches++
+				break
+			}
+		}
+	}
+	if matches == 0 {
+		return 0
+	}
+
+	// Matched bytes out of order count half a transposition each
+	transpositions := 0
+	j := 0
+	for i := 0; i < len(a); i++ {
+		if !aMatched[i] {
+			continue
+		}
+		for !bMatched[j] {
+			j++
+		}
+		if a[i] != b[j] {
+			transpositions++
+		}
+		j++
+	}
+
+	m := float64(matches)
+	jaro := (m/float64(len(a)) + m/float64(len(b)) + (m-float64(transpositions)/2)/m) / 3
+
+	prefix := 0
+	for prefix < min(4, len(a)) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	return jaro + float64(prefix)*0.1*(1-jaro)
+}
+
+// MatchSpans returns the parts of target that the words of query matched,
+// each word matched on its own, in order and with overlaps merged. It is
+// what a list highlights to show why target came up for query.
+func (m *FastMatcher) MatchSpans(query, target string) []Span {
+	var spans []Span
+	for _, word := range strings.Fields(query) {
+		if result := m.Match(word, target); result.Matched {
+			spans = append(spans, result.Spans...)
+		}
+	}
+	if len(spans) < 2 {
+		return spans
+	}
+
+	sort.Slice(spans, func(i, j int) bool {
+		return spans[i].Start < spans[j].Start
+	})
+	merged := spans[:1]
+	for _, span := range spans[1:] {
+		last := &merged[len(merged)-1]
+		if span.Start <= last.End {
+			last.End = max(last.End, span.End)
+			continue
+		}
+		merged = append(merged, span)
+	}
+	return merged
+}
+
+// positionSpans joins runs of consecutive matched positions into spans
+func positionSpans(positions []int) []Span {
+	var spans []Span
+	for _, pos := range positions {
+		if n := len(spans); n > 0 && spans[n-1].End == pos {
+			spans[n-1].End++
+			continue
+		}
+		spans = append(spans, Span{pos, pos + 1})
 	}
+	return spans
 }
 
 // MatchMultiple matches query against multiple targets
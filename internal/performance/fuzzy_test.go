@@ -0,0 +1,169 @@
+package performance
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// rankingDistractors are command names that no query in
+// testdata/fuzzy_ranking.tsv means, ranked alongside the expected ones
+var rankingDistractors = []string{
+	"cat", "cp", "ls", "ps", "mv", "du", "df", "jq", "sed", "awk", "tar",
+	"scp", "pip", "npx", "vim", "top", "kill", "node", "cargo", "gzip",
+	"gpg", "dig", "sort", "sudo", "tree", "ping", "kubens", "pytest",
+}
+
+// minRankingAccuracy is the share of the queries in
+// testdata/fuzzy_ranking.tsv whose expected name each algorithm must rank
+// first; each is what it ranks now, so any new miss fails
+var minRankingAccuracy = map[Algorithm]float64{
+	AlgorithmHybrid:      0.94,
+	AlgorithmLevenshtein: 0.92,
+	AlgorithmDamerau:     0.96,
+	AlgorithmJaroWinkler: 0.96,
+}
+
+type rankingCase struct {
+	query, want string
+}
+
+func loadRankingCases(t testing.TB) (cases []rankingCase, candidates []string) {
+	data, err := os.ReadFile(filepath.Join("testdata", "fuzzy_ranking.tsv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	candidates = slices.Clone(rankingDistractors)
+	for _, line := range strings.Split(string(data), "\n") {
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		query, want, ok := strings.Cut(line, "\t")
+		if !ok {
+			t.Fatalf("malformed line %q", line)
+		}
+		cases = append(cases, rankingCase{query, want})
+		if !slices.Contains(candidates, want) {
+			candidates = append(candidates, want)
+		}
+	}
+	return cases, candidates
+}
+
+// TestFastMatcherRankingQuality ranks the names in
+// testdata/fuzzy_ranking.tsv for each of its queries with every algorithm
+func TestFastMatcherRankingQuality(t *testing.T) {
+	cases, candidates := loadRankingCases(t)
+	for _, algorithm := range Algorithms {
+		t.Run(string(algorithm), func(t *testing.T) {
+			m := NewFastMatcher(false, 0.3, 3)
+			m.SetAlgorithm(algorithm)
+
+			correct := 0
+			for _, c := range cases {
+				got := "(none)"
+				if matches := m.MatchMultiple(c.query, candidates); len(matches) > 0 {
+					got = matches[0].Target
+				}
+				if got == c.want {
+					correct++
+					continue
+				}
+				t.Logf("%q: got %q, want %q", c.query, got, c.want)
+			}
+
+			accuracy := float64(correct) / float64(len(cases))
+			t.Logf("accuracy %d/%d = %.2f", correct, len(cases), accuracy)
+			if accuracy < minRankingAccuracy[algorithm] {
+				t.Errorf("accuracy %.2f is below %.2f", accuracy, minRankingAccuracy[algorithm])
+			}
+		})
+	}
+}
+
+func TestJaroWinkler(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"martha", "marhta", 0.9611},
+		{"dixon", "dicksonx", 0.8133},
+		{"dwayne", "duane", 0.84},
+		{"git", "git", 1},
+		{"git", "", 0},
+		{"abc", "xyz", 0},
+	}
+	for _, tt := range tests {
+		for _, pair := range [][2]string{{tt.a, tt.b}, {tt.b, tt.a}} {
+			if got := jaroWinkler(pair[0], pair[1]); math.Abs(got-tt.want) > 0.0001 {
+				t.Errorf("jaroWinkler(%q, %q) = %.4f, want %.4f", pair[0], pair[1], got, tt.want)
+			}
+		}
+	}
+
+	long := strings.Repeat("kubectl ", 10)
+	if got := jaroWinkler(long, long+"x"); got < 0.99 {
+		t.Errorf("jaroWinkler() of long strings a byte apart = %.4f", got)
+	}
+}
+
+func TestOSADistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"git", "git", 0},
+		{"gti", "git", 1},
+		{"dcoker", "docker", 1},
+		{"ca", "abc", 3},
+		{"kitten", "sitting", 3},
+		{"", "npm", 3},
+	}
+	for _, tt := range tests {
+		if got := osaDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("osaDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+		if got := osaDistance(tt.b, tt.a); got != tt.want {
+			t.Errorf("osaDistance(%q, %q) = %d, want %d", tt.b, tt.a, got, tt.want)
+		}
+	}
+
+	long := strings.Repeat("docker ", 12)
+	if got := osaDistance(long+"ps", long+"sp"); got != 1 {
+		t.Errorf("osaDistance() of long strings a swap apart = %d, want 1", got)
+	}
+}
+
+func TestParseAlgorithm(t *testing.T) {
+	if got, ok := ParseAlgorithm(" Jaro_Winkler "); !ok || got != AlgorithmJaroWinkler {
+		t.Errorf("ParseAlgorithm(Jaro_Winkler) = %q, %v", got, ok)
+	}
+	if got, ok := ParseAlgorithm("soundex"); ok || got != AlgorithmHybrid {
+		t.Errorf("ParseAlgorithm(soundex) = %q, %v; want hybrid, false", got, ok)
+	}
+
+	m := NewFastMatcher(false, 0.3, 3)
+	m.SetAlgorithm("")
+	if m.algorithm != AlgorithmHybrid {
+		t.Errorf("SetAlgorithm(\"\") left %q, want hybrid", m.algorithm)
+	}
+}
+
+func BenchmarkFastMatcherMatchMultiple(b *testing.B) {
+	cases, candidates := loadRankingCases(b)
+	for _, algorithm := range Algorithms {
+		b.Run(string(algorithm), func(b *testing.B) {
+			m := NewFastMatcher(false, 0.3, 3)
+			m.SetAlgorithm(algorithm)
+			b.ReportAllocs()
+			for b.Loop() {
+				for _, c := range cases {
+					m.MatchMultiple(c.query, candidates)
+				}
+			}
+		})
+	}
+}
@@ -240,67 +240,32 @@ func NewConcurrentSearcher(searchers []Searcher, workers int) *ConcurrentSearche
 	}
 }
 
-// Search searches across all sources concurrently
+// Search searches across all sources concurrently, running at most the
+// searcher's workers at once. Results come in the order of the sources.
 func (cs *ConcurrentSearcher) Search(ctx context.Context, query string, limitPerSource int) ([]SearchResult, error) {
 	if len(cs.searchers) == 0 {
 		return nil, nil
 	}
 
-	resultsChan := make(chan []SearchResult, len(cs.searchers))
-	errChan := make(chan error, len(cs.searchers))
-
-	var wg sync.WaitGroup
-
-	// Limit concurrent searches
-	semaphore := make(chan struct{}, cs.workers)
-
-	for _, searcher := range cs.searchers {
-		wg.Add(1)
-		go func(s Searcher) {
-			defer wg.Done()
-
-			select {
-			case semaphore <- struct{}{}:
-				defer func() { <-semaphore }()
-			case <-ctx.Done():
-				errChan <- ctx.Err()
-				return
-			}
-
-			results := s.Search(query, limitPerSource)
-			resultsChan <- results
-		}(searcher)
-	}
-
-	// Close channels when done
-	go func() {
-		wg.Wait()
-		close(resultsChan)
-		close(errChan)
-	}()
-
-	// Collect results
-	var allResults []SearchResult
-	done := make(chan struct{})
-
-	go func() {
-		for results := range resultsChan {
-			allResults = append(allResults, results...)
-		}
-		close(done)
-	}()
-
-	select {
-	case <-done:
-		// Results collected
-	case err := <-errChan:
-		if err != nil {
-			return nil, err
+	tasks := make([]func(context.Context) []SearchResult, len(cs.searchers))
+	for i, s := range cs.searchers {
+		tasks[i] = func(context.Context) []SearchResult {
+			return s.Search(query, limitPerSource)
 		}
-	case <-ctx.Done():
-		return nil, ctx.Err()
+	}
+	perSource, err := FanOut(ctx, tasks, cs.workers)
+	if err != nil {
+		return nil, err
 	}
 
+	total := 0
+	for _, results := range perSource {
+		total += len(results)
+	}
+	allResults := make([]SearchResult, 0, total)
+	for _, results := range perSource {
+		allResults = append(allResults, results...)
+	}
 	return allResults, nil
 }
 
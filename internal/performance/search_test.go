@@ -0,0 +1,86 @@
+package performance
+
+import (
+	"context"
+	"runtime"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// stubSearcher returns one result named after itself, once release is
+// closed if it has one
+type stubSearcher struct {
+	id      string
+	release chan struct{}
+}
+
+func (s stubSearcher) Search(query string, limit int) []SearchResult {
+	if s.release != nil {
+		<-s.release
+	}
+	return []SearchResult{{ID: s.id + ":" + query, Score: 1, Matched: true}}
+}
+
+func stubSearchers(n int, release chan struct{}) []Searcher {
+	searchers := make([]Searcher, n)
+	for i := range searchers {
+		searchers[i] = stubSearcher{id: strconv.Itoa(i), release: release}
+	}
+	return searchers
+}
+
+func TestConcurrentSearcherSearch(t *testing.T) {
+	cs := NewConcurrentSearcher(stubSearchers(20, nil), 3)
+	results, err := cs.Search(t.Context(), "git", 10)
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if len(results) != 20 {
+		t.Fatalf("Search() returned %d results, want one from each of 20 sources", len(results))
+	}
+	for i, r := range results {
+		if want := strconv.Itoa(i) + ":git"; r.ID != want {
+			t.Errorf("results[%d] = %q, want %q in source order", i, r.ID, want)
+		}
+	}
+}
+
+// TestConcurrentSearcherSearchCancel cancels a search whose sources block,
+// and checks that Search returns at once and that its goroutines exit once
+// the sources do. goleak is not a dependency, so the goroutines are counted.
+func TestConcurrentSearcherSearchCancel(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	release := make(chan struct{})
+	cs := NewConcurrentSearcher(stubSearchers(50, release), 4)
+	ctx, cancel := context.WithTimeout(t.Context(), 20*time.Millisecond)
+	defer cancel()
+
+	results, err := cs.Search(ctx, "git", 10)
+	if err != context.DeadlineExceeded || results != nil {
+		t.Fatalf("Search() = %v, %v; want no results and the deadline error", results, err)
+	}
+
+	close(release)
+	deadline := time.Now().Add(2 * time.Second)
+	for runtime.NumGoroutine() > before {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d goroutines still running after the search, want %d", runtime.NumGoroutine(), before)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// BenchmarkConcurrentSearcherSearch measures the cost of the fan-out itself,
+// the sources doing next to no work
+func BenchmarkConcurrentSearcherSearch(b *testing.B) {
+	cs := NewConcurrentSearcher(stubSearchers(8, nil), 4)
+	ctx := context.Background()
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := cs.Search(ctx, "git", 10); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -501,6 +501,63 @@ func ParallelFilter[T any](items []T, predicate func(T) bool, maxWorkers int) []
 	return filtered
 }
 
+// FanOut runs tasks on at most workers goroutines and returns their results
+// in task order. It returns once every task has finished, or with ctx.Err()
+// as soon as ctx is done; the results of tasks that had not finished by then
+// are left as the zero value. Tasks not started before ctx is done are
+// skipped, and tasks still running should return soon after it, as nothing
+// waits for them.
+func FanOut[R any](ctx context.Context, tasks []func(context.Context) R, workers int) ([]R, error) {
+	results := make([]R, len(tasks))
+	if len(tasks) == 0 {
+		return results, ctx.Err()
+	}
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	workers = min(workers, len(tasks))
+
+	var (
+		// mu guards results once FanOut may have returned
+		mu        sync.Mutex
+		abandoned bool
+		next      atomic.Int64
+		wg        sync.WaitGroup
+	)
+	for range workers {
+		wg.Go(func() {
+			for {
+				i := int(next.Add(1) - 1)
+				if i >= len(tasks) || ctx.Err() != nil {
+					return
+				}
+				result := tasks[i](ctx)
+				mu.Lock()
+				if !abandoned {
+					results[i] = result
+				}
+				mu.Unlock()
+			}
+		})
+	}
+
+	finished := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+		return results, nil
+	case <-ctx.Done():
+		mu.Lock()
+		abandoned = true
+		mu.Unlock()
+		return results, ctx.Err()
+	}
+}
+
 // Pipeline represents a processing pipeline
 type Pipeline[T any] struct {
 	stages []PipelineStage[T]
@@ -0,0 +1,151 @@
+package shell
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Cobra generates completion scripts for bash, zsh, fish and PowerShell. For
+// Nushell and Elvish wut ships its own scripts, which ask `wut __complete` for
+// candidates the same way cobra's scripts do. The installer writes them to
+// files the shells load on their own, next to the config file.
+
+// generatedHeader starts every file the installer generates. Uninstall only
+// removes files that still carry it.
+const generatedHeader = "# Generated by wut. Changes are overwritten by `wut install`."
+
+// generatedFile is a file the installer writes besides the config block
+type generatedFile struct {
+	Path    string
+	Content string
+}
+
+// CompletionScript returns the completion script for shells cobra has no
+// generator for, and an empty string for the others
+func CompletionScript(shellName string) string {
+	switch CanonicalName(shellName) {
+	case "nushell":
+		return generatedHeader + "\n" + nushellCompletion
+	case "elvish":
+		return generatedHeader + "\n" + elvishCompletion
+	default:
+		return ""
+	}
+}
+
+// generatedFiles lists the files installed for a shell whose config file is
+// configFile. Nushell sources every file in its autoload directory; Elvish
+// finds modules in the lib directory next to rc.elv.
+func generatedFiles(shellName, configFile string) []generatedFile {
+	dir := filepath.Dir(configFile)
+	switch CanonicalName(shellName) {
+	case "nushell":
+		return []generatedFile{{
+			Path:    filepath.Join(dir, "autoload", "wut-completions.nu"),
+			Content: CompletionScript("nushell"),
+		}}
+	case "elvish":
+		return []generatedFile{{
+			Path:    filepath.Join(dir, "lib", "wut-completion.elv"),
+			Content: CompletionScript("elvish"),
+		}}
+	default:
+		return nil
+	}
+}
+
+// generatedFilesCurrent reports whether every file exists with the content
+// this binary generates
+func generatedFilesCurrent(files []generatedFile) bool {
+	for _, f := range files {
+		data, err := os.ReadFile(f.Path)
+		if err != nil || !bytes.Equal(data, []byte(f.Content)) {
+			return false
+		}
+	}
+	return true
+}
+
+// generatedFilesExist reports whether every file exists, current or not
+func generatedFilesExist(files []generatedFile) bool {
+	for _, f := range files {
+		if _, err := os.Stat(f.Path); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func writeGeneratedFiles(files []generatedFile) error {
+	for _, f := range files {
+		if err := os.MkdirAll(filepath.Dir(f.Path), 0755); err != nil {
+			return fmt.Errorf("failed to create %s: %w", filepath.Dir(f.Path), err)
+		}
+		if err := os.WriteFile(f.Path, []byte(f.Content), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", f.Path, err)
+		}
+	}
+	return nil
+}
+
+// removeGeneratedFiles deletes the files wut generated. A file without the
+// generated header was replaced by the user and is kept.
+func removeGeneratedFiles(files []generatedFile) error {
+	for _, f := range files {
+		data, err := os.ReadFile(f.Path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", f.Path, err)
+		}
+		if !strings.HasPrefix(string(data), generatedHeader) {
+			continue
+		}
+		if err := os.Remove(f.Path); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", f.Path, err)
+		}
+	}
+	return nil
+}
+
+// nushellCompletion declares wut as an extern whose arguments are completed
+// by `wut __complete`. Its last output line is cobra's directive.
+const nushellCompletion = `# Completions for wut, printed by ` + "`wut completion nushell`" + `
+
+def "nu-complete wut" [context: string] {
+    let words = ($context | split row -r '\s+' | skip 1)
+    ^wut __complete ...$words
+    | complete
+    | get stdout
+    | lines
+    | drop 1
+    | parse --regex '^(?P<value>[^\t]*)(?:\t(?P<description>.*))?$'
+}
+
+extern "wut" [...args: string@"nu-complete wut"]
+`
+
+// elvishCompletion is a module whose complete function is installed as the
+// arg-completer for wut by the rc.elv block
+const elvishCompletion = `# Completions for wut, printed by ` + "`wut completion elvish`" + `
+
+fn complete {|@words|
+    var lines = []
+    try {
+        set lines = [(wut __completeNoDesc (all $words[1..]) 2> /dev/null)]
+    } catch {
+        return
+    }
+    if (== (count $lines) 0) {
+        return
+    }
+    # The last line is cobra's completion directive
+    for line $lines[..(- (count $lines) 1)] {
+        put $line
+    }
+}
+`
@@ -0,0 +1,140 @@
+package shell
+
+import (
+	"flag"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+func TestNushellElvishGolden(t *testing.T) {
+	tests := []struct {
+		golden string
+		got    string
+	}{
+		{"nushell.golden", GenerateShellCode("nushell")},
+		{"nushell-completion.golden", CompletionScript("nushell")},
+		{"elvish.golden", GenerateShellCode("elvish")},
+		{"elvish-completion.golden", CompletionScript("elvish")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.golden, func(t *testing.T) {
+			path := filepath.Join("testdata", tt.golden)
+			if *updateGolden {
+				if err := os.WriteFile(path, []byte(tt.got), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if want := readFile(t, path); tt.got != want {
+				t.Errorf("%s differs from the generated script; run go test -update if the change is intended\ngot:\n%s", tt.golden, tt.got)
+			}
+		})
+	}
+}
+
+// TestNushellElvishParse checks the golden files with the shells' own
+// parsers when they are installed
+func TestNushellElvishParse(t *testing.T) {
+	t.Run("nushell", func(t *testing.T) {
+		nu, err := exec.LookPath("nu")
+		if err != nil {
+			t.Skip("nu is not installed")
+		}
+		for _, name := range []string{"nushell.golden", "nushell-completion.golden"} {
+			path, _ := filepath.Abs(filepath.Join("testdata", name))
+			out, err := exec.Command(nu, "--no-config-file", "-c", "nu-check '"+path+"'").CombinedOutput()
+			if err != nil || strings.TrimSpace(string(out)) != "true" {
+				t.Errorf("%s does not parse: %v\n%s", name, err, out)
+			}
+		}
+	})
+
+	t.Run("elvish", func(t *testing.T) {
+		elvish, err := exec.LookPath("elvish")
+		if err != nil {
+			t.Skip("elvish is not installed")
+		}
+		for _, name := range []string{"elvish.golden", "elvish-completion.golden"} {
+			out, err := exec.Command(elvish, "-compileonly", filepath.Join("testdata", name)).CombinedOutput()
+			if err != nil {
+				t.Errorf("%s does not compile: %v\n%s", name, err, out)
+			}
+		}
+	})
+}
+
+func TestInstallerGeneratedFiles(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("uses the linux config paths")
+	}
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	// Keep GetConfigFile from asking a real nu for its config path
+	t.Setenv("PATH", "")
+
+	installer := &Installer{}
+	for _, sh := range []string{"nushell", "elvish"} {
+		t.Run(sh, func(t *testing.T) {
+			configFile, err := GetConfigFile(sh)
+			if err != nil {
+				t.Fatal(err)
+			}
+			files := generatedFiles(sh, configFile)
+			if len(files) != 1 {
+				t.Fatalf("generatedFiles(%s) = %v", sh, files)
+			}
+			generated := files[0].Path
+
+			if err := installer.Install(sh); err != nil {
+				t.Fatalf("Install() error = %v", err)
+			}
+			if got := readFile(t, generated); got != CompletionScript(sh) {
+				t.Fatalf("%s was not written", generated)
+			}
+			if !installer.IsShellInstalled(sh) || installer.NeedsUpgrade(sh) {
+				t.Fatalf("fresh install: installed=%v needsUpgrade=%v", installer.IsShellInstalled(sh), installer.NeedsUpgrade(sh))
+			}
+
+			// A stale generated file is refreshed without touching the config
+			if err := os.WriteFile(generated, []byte(generatedHeader+"\nold\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if !installer.NeedsUpgrade(sh) {
+				t.Errorf("stale generated file should need an upgrade")
+			}
+			if err := installer.Install(sh); err != nil {
+				t.Fatalf("Install() over a stale file error = %v", err)
+			}
+			if got := readFile(t, generated); got != CompletionScript(sh) {
+				t.Errorf("stale file was not refreshed")
+			}
+
+			if err := os.Remove(generated); err != nil {
+				t.Fatal(err)
+			}
+			if installer.IsShellInstalled(sh) {
+				t.Errorf("missing generated file should not count as installed")
+			}
+			if err := installer.Install(sh); err != nil {
+				t.Fatalf("Install() error = %v", err)
+			}
+
+			if err := installer.Uninstall(sh); err != nil {
+				t.Fatalf("Uninstall() error = %v", err)
+			}
+			if _, err := os.Stat(generated); !os.IsNotExist(err) {
+				t.Errorf("Uninstall() left %s behind", generated)
+			}
+			if IsInstalled(configFile) {
+				t.Errorf("Uninstall() left the block in %s", configFile)
+			}
+		})
+	}
+}
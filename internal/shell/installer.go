@@ -1,24 +1,47 @@
 package shell
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
+	"time"
 
 	"wut/internal/config"
 )
 
 const (
-	integrationStartMarker = "# WUT Shell Integration"
-	integrationEndMarker   = "# End WUT Integration"
-	legacyIntegrationEnd   = "# End WUT Shell Integration"
-	cmdAutoRunKey          = `HKCU\Software\Microsoft\Command Processor`
-	cmdAutoRunValue        = "AutoRun"
+	// IntegrationVersion is the version of the shell fragments generated by
+	// this binary. Bump it whenever a fragment changes so `wut install
+	// --upgrade` refreshes installed copies.
+	IntegrationVersion = 6
+
+	integrationBeginMarker = "# >>> wut initialize >>>"
+	integrationEndMarker   = "# <<< wut initialize <<<"
+	integrationVersionTag  = "# wut integration version: "
+
+	// Markers written by versions before the sentinel block. Their blocks
+	// count as fragment version 1.
+	legacyStartMarker = "# WUT Shell Integration"
+	legacyEndMarker   = "# End WUT Integration"
+	legacyAltEnd      = "# End WUT Shell Integration"
+
+	// stateDirToken is replaced with the quoted shell state directory
+	stateDirToken = "@WUT_STATE_DIR@"
+
+	backupTimeFormat = "20060102-150405"
+	cmdAutoRunKey    = `HKCU\Software\Microsoft\Command Processor`
+	cmdAutoRunValue  = "AutoRun"
 )
 
+// ErrAlreadyInstalled is returned by Install when the shell already has an
+// up-to-date integration block.
+var ErrAlreadyInstalled = errors.New("already installed")
+
 type Installer struct {
 	shells []string
 }
@@ -29,6 +52,9 @@ func NewInstaller() *Installer {
 	}
 }
 
+// Install writes the integration block to the shell's config file, replacing
+// an existing block in place, and refreshes the files generated for the shell.
+// The config file is backed up before it is changed.
 func (i *Installer) Install(shellName string) error {
 	shellName = CanonicalName(shellName)
 	if shellName == "" {
@@ -49,29 +75,40 @@ func (i *Installer) Install(shellName string) error {
 	if err := os.MkdirAll(filepath.Dir(configFile), 0755); err != nil {
 		return fmt.Errorf("failed to create shell config directory: %w", err)
 	}
-	if IsInstalled(configFile) {
-		return fmt.Errorf("already installed")
-	}
 
 	shellCode := strings.TrimSpace(GenerateShellCode(shellName))
 	if shellCode == "" {
 		return fmt.Errorf("unsupported shell for installation: %s", shellName)
 	}
 
-	f, err := os.OpenFile(configFile, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0644)
+	content, err := os.ReadFile(configFile)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read shell config: %w", err)
+	}
+
+	updated, err := replaceIntegrationBlock(string(content), integrationBlock(shellCode))
 	if err != nil {
-		return fmt.Errorf("failed to open shell config: %w", err)
+		return err
+	}
+	files := generatedFiles(shellName, configFile)
+	if updated == string(content) && generatedFilesCurrent(files) {
+		return ErrAlreadyInstalled
 	}
-	defer f.Close()
 
-	marker := fmt.Sprintf("\n%s\n%s\n%s\n", integrationStartMarker, shellCode, integrationEndMarker)
-	if _, err := f.WriteString(marker); err != nil {
-		return fmt.Errorf("failed to write shell config: %w", err)
+	if updated != string(content) {
+		if _, err := backupConfigFile(configFile); err != nil {
+			return err
+		}
+		if err := os.WriteFile(configFile, []byte(updated), 0644); err != nil {
+			return fmt.Errorf("failed to write shell config: %w", err)
+		}
 	}
 
-	return nil
+	return writeGeneratedFiles(files)
 }
 
+// Uninstall removes exactly the integration block from the shell's config
+// file, after backing the file up, and the files generated for the shell.
 func (i *Installer) Uninstall(shellName string) error {
 	shellName = CanonicalName(shellName)
 	if shellName == "" {
@@ -87,37 +124,82 @@ func (i *Installer) Uninstall(shellName string) error {
 		return err
 	}
 
+	if err := removeGeneratedFiles(generatedFiles(shellName, configFile)); err != nil {
+		return err
+	}
+
 	content, err := os.ReadFile(configFile)
 	if err != nil {
 		return fmt.Errorf("failed to read shell config: %w", err)
 	}
 
-	lines := strings.Split(string(content), "\n")
-	newLines := make([]string, 0, len(lines))
-	inWUTSection := false
-
-	for _, line := range lines {
-		if strings.Contains(line, integrationStartMarker) {
-			inWUTSection = true
-			continue
-		}
-		if strings.Contains(line, integrationEndMarker) || strings.Contains(line, legacyIntegrationEnd) {
-			inWUTSection = false
-			continue
-		}
-		if !inWUTSection {
-			newLines = append(newLines, line)
-		}
+	updated, found, err := removeIntegrationBlock(string(content))
+	if err != nil {
+		return err
+	}
+	if !found {
+		return nil
 	}
 
-	newContent := strings.Join(newLines, "\n")
-	if err := os.WriteFile(configFile, []byte(newContent), 0644); err != nil {
+	if _, err := backupConfigFile(configFile); err != nil {
+		return err
+	}
+	if err := os.WriteFile(configFile, []byte(updated), 0644); err != nil {
 		return fmt.Errorf("failed to write shell config: %w", err)
 	}
 
 	return nil
 }
 
+// IsShellInstalled reports whether the shell has a wut integration installed,
+// including the files generated for it
+func (i *Installer) IsShellInstalled(shellName string) bool {
+	shellName = CanonicalName(shellName)
+	if shellName == "cmd" {
+		return runtime.GOOS == "windows" && isCmdInstalled(cmdInitScriptPath())
+	}
+
+	configFile, err := GetConfigFile(shellName)
+	if err != nil {
+		return false
+	}
+	return IsInstalled(configFile) && generatedFilesExist(generatedFiles(shellName, configFile))
+}
+
+// NeedsUpgrade reports whether the shell has an integration block that was
+// written by an older version of wut, or generated files that are missing or
+// out of date.
+func (i *Installer) NeedsUpgrade(shellName string) bool {
+	shellName = CanonicalName(shellName)
+	if shellName == "cmd" {
+		return cmdNeedsUpgrade()
+	}
+
+	configFile, err := GetConfigFile(shellName)
+	if err != nil {
+		return false
+	}
+	version, ok := InstalledVersion(configFile)
+	if !ok {
+		return false
+	}
+	return version < IntegrationVersion || !generatedFilesCurrent(generatedFiles(shellName, configFile))
+}
+
+// InstalledVersion returns the fragment version of the integration block in
+// configFile, and false when no block is installed.
+func InstalledVersion(configFile string) (int, bool) {
+	content, err := os.ReadFile(configFile)
+	if err != nil {
+		return 0, false
+	}
+	block, ok := findIntegrationBlock(string(content))
+	if !ok {
+		return 0, false
+	}
+	return block.version, true
+}
+
 func GetDetectedShells() []string {
 	return DetectInstallableShells()
 }
@@ -219,20 +301,151 @@ func GetConfigFile(shellName string) (string, error) {
 }
 
 func IsInstalled(configFile string) bool {
+	_, ok := InstalledVersion(configFile)
+	return ok
+}
+
+// ── Integration block ────────────────────────────────────────────────────────
+
+// integrationSpan locates an integration block by byte offsets. end includes
+// the end marker's trailing newline.
+type integrationSpan struct {
+	start   int
+	end     int
+	version int
+}
+
+// integrationBlock wraps shell code in the sentinel markers
+func integrationBlock(shellCode string) string {
+	return fmt.Sprintf("%s\n%s%d\n%s\n%s\n",
+		integrationBeginMarker, integrationVersionTag, IntegrationVersion, shellCode, integrationEndMarker)
+}
+
+// findIntegrationBlock finds the sentinel block, or a legacy block when there
+// is none. A begin marker without a matching end marker is not a block.
+func findIntegrationBlock(content string) (integrationSpan, bool) {
+	if span, ok := findMarkedBlock(content, integrationBeginMarker, integrationEndMarker); ok {
+		span.version = 1
+		lines := strings.SplitN(content[span.start:span.end], "\n", 3)
+		if len(lines) > 1 {
+			if v, err := strconv.Atoi(strings.TrimPrefix(strings.TrimSpace(lines[1]), integrationVersionTag)); err == nil {
+				span.version = v
+			}
+		}
+		return span, true
+	}
+
+	for _, end := range []string{legacyEndMarker, legacyAltEnd} {
+		if span, ok := findMarkedBlock(content, legacyStartMarker, end); ok {
+			span.version = 1
+			return span, true
+		}
+	}
+	return integrationSpan{}, false
+}
+
+// findMarkedBlock finds the lines from begin to end, both matched as whole
+// lines
+func findMarkedBlock(content, begin, end string) (integrationSpan, bool) {
+	start := -1
+	offset := 0
+	for _, line := range strings.SplitAfter(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case start < 0 && trimmed == begin:
+			start = offset
+		case start >= 0 && trimmed == end:
+			return integrationSpan{start: start, end: offset + len(line)}, true
+		}
+		offset += len(line)
+	}
+	return integrationSpan{}, false
+}
+
+// replaceIntegrationBlock swaps an existing block for block, or appends block
+// when there is none
+func replaceIntegrationBlock(content, block string) (string, error) {
+	if span, ok := findIntegrationBlock(content); ok {
+		return content[:span.start] + block + content[span.end:], nil
+	}
+	if hasDanglingMarker(content) {
+		return "", fmt.Errorf("shell config has a wut block without an end marker; remove it manually")
+	}
+
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	if content != "" {
+		content += "\n"
+	}
+	return content + block, nil
+}
+
+// removeIntegrationBlock deletes the block and the blank line Install put
+// before it
+func removeIntegrationBlock(content string) (string, bool, error) {
+	span, ok := findIntegrationBlock(content)
+	if !ok {
+		if hasDanglingMarker(content) {
+			return "", false, fmt.Errorf("shell config has a wut block without an end marker; remove it manually")
+		}
+		return content, false, nil
+	}
+
+	before := content[:span.start]
+	if strings.HasSuffix(before, "\n\n") {
+		before = before[:len(before)-1]
+	}
+	return before + content[span.end:], true, nil
+}
+
+func hasDanglingMarker(content string) bool {
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == integrationBeginMarker || trimmed == legacyStartMarker {
+			return true
+		}
+	}
+	return false
+}
+
+// backupConfigFile copies configFile next to itself with a timestamp suffix.
+// It returns an empty path when there was no file to back up.
+func backupConfigFile(configFile string) (string, error) {
 	content, err := os.ReadFile(configFile)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
 	if err != nil {
-		return false
+		return "", fmt.Errorf("failed to read shell config: %w", err)
+	}
+
+	info, err := os.Stat(configFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to stat shell config: %w", err)
+	}
+
+	stamp := time.Now().Format(backupTimeFormat)
+	backup := configFile + ".wut-backup-" + stamp
+	for n := 1; ; n++ {
+		if _, err := os.Stat(backup); os.IsNotExist(err) {
+			break
+		}
+		backup = fmt.Sprintf("%s.wut-backup-%s-%d", configFile, stamp, n)
 	}
-	return strings.Contains(string(content), integrationStartMarker)
+	if err := os.WriteFile(backup, content, info.Mode().Perm()); err != nil {
+		return "", fmt.Errorf("failed to back up shell config: %w", err)
+	}
+	return backup, nil
 }
 
 func GenerateShellCode(shellName string) string {
 	shellName = CanonicalName(shellName)
 	switch shellName {
 	case "bash", "zsh":
-		return generateBashZshCode()
+		return strings.ReplaceAll(generateBashZshCode(), stateDirToken, quotePOSIX(StateDir()))
 	case "fish":
-		return generateFishCode()
+		return strings.ReplaceAll(generateFishCode(), stateDirToken, quoteFish(StateDir()))
 	case "powershell", "pwsh":
 		return generatePowerShellCode(shellName)
 	case "nushell":
@@ -342,11 +555,60 @@ command_not_found_handler() {
     return 127
 }
 
+export WUT_SESSION=$$
+__wut_state_dir=@WUT_STATE_DIR@
+
+__wut_save_last_command() {
+    mkdir -p "$__wut_state_dir" 2>/dev/null
+    printf '%s\t%s\n%s\n' "$1" "${BASH_VERSION:+bash}${ZSH_VERSION:+zsh}" "$2" > "$__wut_state_dir/last-command-$WUT_SESSION" 2>/dev/null
+}
+
+__wut_fix_last_command() {
+    WUT_SOURCE_SHELL="${WUT_SOURCE_SHELL:-${BASH_VERSION:+bash}${ZSH_VERSION:+zsh}}" wut fix --last --shell 2>/dev/null
+}
+
+fixlast() {
+    local fixed
+    fixed="$(__wut_fix_last_command)"
+    if [[ -z "$fixed" ]]; then
+        wut fix --last
+        return 1
+    fi
+
+    if [[ -n "$ZSH_VERSION" ]]; then
+        print -z -- "$fixed"
+    else
+        history -s -- "$fixed"
+        printf '%s\n' "$fixed"
+        printf 'Press Up to edit and run it.\n'
+    fi
+}
+
 __wut_last_hist_id=""
+__wut_cmd_start=""
+
+# __wut_clock sets __wut_now to the time in microseconds, as precise as the
+# shell's clock
+__wut_clock() {
+    if [[ -n "${EPOCHREALTIME:-}" ]]; then
+        local secs="${EPOCHREALTIME%[.,]*}" frac="${EPOCHREALTIME#*[.,]}000000"
+        __wut_now=$(( secs * 1000000 + 10#${frac:0:6} ))
+    else
+        __wut_now=$(( SECONDS * 1000000 ))
+    fi
+}
 
 __wut_record_last_command() {
+    local exit_status="$1"
     local histnum=""
     local cmd=""
+    local timing=()
+
+    if [[ -n "$__wut_cmd_start" ]]; then
+        __wut_clock
+        timing=(--status "$exit_status" --duration "$(( (__wut_now - __wut_cmd_start) / 1000 ))")
+        __wut_cmd_start=""
+    fi
 
     if [[ -n "$BASH_VERSION" ]]; then
         local hist_entry
@@ -360,22 +622,50 @@ __wut_record_last_command() {
 
     if [[ -n "$cmd" && "$histnum" != "$__wut_last_hist_id" && "$cmd" != wut\ * ]]; then
         __wut_last_hist_id="$histnum"
-        WUT_SOURCE_SHELL="${WUT_SOURCE_SHELL:-${BASH_VERSION:+bash}${ZSH_VERSION:+zsh}}" wut pro-tip "$cmd"
+        case "$cmd" in
+            oops*|again*|fixlast*) ;;
+            *) __wut_save_last_command "$exit_status" "$cmd" ;;
+        esac
+        WUT_SOURCE_SHELL="${WUT_SOURCE_SHELL:-${BASH_VERSION:+bash}${ZSH_VERSION:+zsh}}" wut __record "${timing[@]}" -- "$cmd"
     fi
 }
 
 __wut_protip() {
     local exitStatus=$?
-    __wut_record_last_command
+    __wut_record_last_command "$exitStatus"
     return $exitStatus
 }
 
 if [[ -n "$BASH_VERSION" ]]; then
+    __wut_bash_fix_last() {
+        local fixed
+        fixed="$(__wut_fix_last_command)"
+        if [[ -n "$fixed" ]]; then
+            READLINE_LINE="$fixed"
+            READLINE_POINT=${#fixed}
+        fi
+    }
     bind '"\C-@":"\C-uwut suggest\C-m"' 2>/dev/null || true
     bind '"\C-g":"\C-awut suggest \"\C-e\"\C-m"' 2>/dev/null || true
+    bind -x '"\e\e":__wut_bash_fix_last' 2>/dev/null || true
     PROMPT_COMMAND="__wut_protip; $PROMPT_COMMAND"
+    # PS0 is expanded as a command starts; the subscript notes the time
+    __wut_noop=()
+    if [[ "${PS0:-}" != *__wut_cmd_start* ]]; then
+        if [[ -n "${EPOCHREALTIME:-}" ]]; then
+            PS0="${PS0:-}"'${__wut_noop[__wut_cmd_start=${EPOCHREALTIME/[.,]/}]}'
+        else
+            PS0="${PS0:-}"'${__wut_noop[__wut_cmd_start=SECONDS*1000000]}'
+        fi
+    fi
 elif [[ -n "$ZSH_VERSION" ]]; then
+    zmodload zsh/datetime 2>/dev/null
+    __wut_preexec() {
+        __wut_clock
+        __wut_cmd_start="$__wut_now"
+    }
     autoload -Uz add-zsh-hook 2>/dev/null
+    add-zsh-hook preexec __wut_preexec 2>/dev/null || true
     add-zsh-hook precmd __wut_protip 2>/dev/null || true
     __wut_zle_tui() {
         BUFFER='wut suggest'
@@ -386,10 +676,21 @@ elif [[ -n "$ZSH_VERSION" ]]; then
         BUFFER="wut suggest ${(q)cmd}"
         zle accept-line
     }
+    __wut_zle_fix_last() {
+        local fixed
+        fixed="$(__wut_fix_last_command)"
+        if [[ -n "$fixed" ]]; then
+            BUFFER="$fixed"
+            CURSOR=${#BUFFER}
+        fi
+        zle redisplay
+    }
     zle -N __wut_zle_tui
     zle -N __wut_zle_current
+    zle -N __wut_zle_fix_last
     bindkey '^@' __wut_zle_tui 2>/dev/null || true
     bindkey '^G' __wut_zle_current 2>/dev/null || true
+    bindkey '\e\e' __wut_zle_fix_last 2>/dev/null || true
 fi
 `
 }
@@ -452,18 +753,42 @@ function fish_command_not_found
     end
 end
 
-set -g __wut_last_command ''
+set -gx WUT_SESSION $fish_pid
+set -g __wut_state_dir @WUT_STATE_DIR@
 
-function __wut_protip --on-event fish_prompt
-    set -l cmd $history[1]
-    if test -n "$cmd"; and test "$cmd" != "$__wut_last_command"
-        set -g __wut_last_command $cmd
-        env WUT_SOURCE_SHELL=fish wut pro-tip "$cmd"
+function __wut_save_last_command --on-event fish_postexec
+    set -l exit_status $status
+    set -l cmd (string trim -- "$argv")
+    if test -z "$cmd"; or string match -qr '^(oops|again|fixlast|wut)\b' -- $cmd
+        return
     end
+    mkdir -p $__wut_state_dir 2>/dev/null
+    printf '%s\t%s\n%s\n' $exit_status fish "$cmd" > $__wut_state_dir/last-command-$WUT_SESSION 2>/dev/null
+    env WUT_SOURCE_SHELL=fish wut __record --status $exit_status --duration $CMD_DURATION -- "$cmd"
+end
+
+function __wut_fix_last
+    set -l fixed (env WUT_SOURCE_SHELL=fish wut fix --last --shell 2>/dev/null)
+    if test -n "$fixed"
+        commandline -r -- $fixed
+        commandline -f end-of-line
+    end
+    commandline -f repaint
+end
+
+function fixlast
+    set -l fixed (env WUT_SOURCE_SHELL=fish wut fix --last --shell 2>/dev/null)
+    if test -z "$fixed"
+        wut fix --last
+        return 1
+    end
+    echo $fixed
+    echo 'Press Esc Esc at the prompt to edit and run it.'
 end
 
 bind \c@ __wut_tui 2>/dev/null; or true
 bind \cg __wut_with_current 2>/dev/null; or true
+bind \e\e __wut_fix_last 2>/dev/null; or true
 `
 }
 
@@ -542,6 +867,9 @@ if (-not $global:WUTOriginalPrompt) {
 }
 
 function global:prompt {
+    # How the last command exited, before anything here changes it
+    $lastSucceeded = $?
+    $lastExitCode = $global:LASTEXITCODE
     $promptText = ""
     if ($global:WUTOriginalPrompt) {
         $promptText = & $global:WUTOriginalPrompt
@@ -554,11 +882,14 @@ function global:prompt {
         if ($last -and $global:WUTLastHistoryId -ne $last.Id -and $last.CommandLine -notlike 'wut *') {
             $global:WUTLastHistoryId = $last.Id
             $env:WUT_SOURCE_SHELL = '%s'
-            wut pro-tip "$($last.CommandLine)"
+            $duration = [long]($last.EndExecutionTime - $last.StartExecutionTime).TotalMilliseconds
+            $status = if ($lastSucceeded) { 0 } elseif ($lastExitCode) { $lastExitCode } else { 1 }
+            wut __record --status $status --duration $duration '--' "$($last.CommandLine)"
             Remove-Item Env:\WUT_SOURCE_SHELL -ErrorAction SilentlyContinue
         }
     } catch {
     }
+    $global:LASTEXITCODE = $lastExitCode
 
     return $promptText
 }
@@ -571,7 +902,6 @@ Set-PSReadLineKeyHandler -Chord 'Ctrl+g' -ScriptBlock { Invoke-WUT-WithCurrent }
 func generateNushellCode() string {
 	return `# WUT integration for Nushell
 $env.WUT_LAST_COMMAND = ($env.WUT_LAST_COMMAND? | default "")
-$env.WUT_LAST_RECORDED = ($env.WUT_LAST_RECORDED? | default "")
 
 $env.config = ($env.config | default {})
 $env.config.hooks = ($env.config.hooks? | default {})
@@ -589,10 +919,12 @@ $env.config.hooks.pre_prompt = (
     | default []
     | append {||
         let cmd = (($env.WUT_LAST_COMMAND? | default "") | str trim)
-        let last = ($env.WUT_LAST_RECORDED? | default "")
-        if ($cmd | str length) > 0 and $cmd != $last and not ($cmd | str starts-with "wut ") {
-            $env.WUT_LAST_RECORDED = $cmd
-            with-env { WUT_SOURCE_SHELL: "nushell" } { ^wut pro-tip $cmd }
+        let status = ($env.LAST_EXIT_CODE? | default 0 | into string)
+        let duration = ($env.CMD_DURATION_MS? | default "0")
+        # Cleared so a prompt without a new command records nothing
+        $env.WUT_LAST_COMMAND = ""
+        if ($cmd | str length) > 0 and not ($cmd | str starts-with "wut ") {
+            with-env { WUT_SOURCE_SHELL: "nushell" } { ^wut __record --status $status --duration $duration -- $cmd }
         }
     }
 )
@@ -610,15 +942,16 @@ def --env wut-current-line [] {
     ^wut suggest (commandline)
 }
 
-def --env oops [...args] {
-    if (($args | length) == 0) {
-        ^wut fix --exec
-    } else {
-        ^wut fix --exec ...$args
-    }
+# Wrappers pass flags through to wut; completions are in autoload/wut-completions.nu
+def --wrapped w [...args] {
+    ^wut suggest ...$args
 }
 
-def --env again [...args] {
+def --env --wrapped oops [...args] {
+    ^wut fix --exec ...$args
+}
+
+def --env --wrapped again [...args] {
     oops ...$args
 }
 `
@@ -642,7 +975,10 @@ def _wut_postcommand(cmd, rtn=None, out=None, ts=None, **kwargs):
         return
     env = dict(os.environ)
     env["WUT_SOURCE_SHELL"] = "xonsh"
-    subprocess.run(["wut", "pro-tip", line], env=env, check=False)
+    timing = []
+    if rtn is not None and ts and len(ts) == 2 and ts[1] is not None:
+        timing = ["--status", str(rtn), "--duration", str(int((ts[1] - ts[0]) * 1000))]
+    subprocess.run(["wut", "__record", *timing, "--", line], env=env, check=False)
 
 @events.on_command_not_found
 def _wut_command_not_found(cmd, **kwargs):
@@ -668,13 +1004,12 @@ func generateElvishCode() string {
 use edit
 use str
 
-var wut:last-command = ''
-
-set edit:after-readline = [ $@edit:after-readline {|line|
-    var cmd = (str:trim-space $line)
-    if (and (!=s $cmd '') (!=s $cmd $wut:last-command) (not (str:has-prefix $cmd 'wut '))) {
-        set wut:last-command = $cmd
-        E:WUT_SOURCE_SHELL=elvish wut pro-tip $cmd > /dev/null 2> /dev/null
+set edit:after-command = [ $@edit:after-command {|m|
+    var cmd = (str:trim-space $m[src][code])
+    if (and (!=s $cmd '') (not (str:has-prefix $cmd 'wut '))) {
+        var status = (if (eq $m[error] $nil) { put 0 } else { put 1 })
+        var duration = (printf '%.0f' (* $m[duration] 1000))
+        E:WUT_SOURCE_SHELL=elvish wut __record --status $status --duration $duration -- $cmd > /dev/null 2> /dev/null
     }
 } ]
 
@@ -693,6 +1028,10 @@ fn oops {|@args|
 fn again {|@args|
     oops $@args
 }
+
+# Completions from lib/wut-completion.elv, regenerated by ` + "`wut install --upgrade`" + `
+use wut-completion
+set edit:completion:arg-completer[wut] = $wut-completion:complete~
 `
 }
 
@@ -755,8 +1094,8 @@ func installCmdIntegration() error {
 	}
 
 	scriptPath := cmdInitScriptPath()
-	if isCmdInstalled(scriptPath) {
-		return fmt.Errorf("already installed")
+	if isCmdInstalled(scriptPath) && !cmdNeedsUpgrade() {
+		return ErrAlreadyInstalled
 	}
 	if err := os.MkdirAll(filepath.Dir(scriptPath), 0755); err != nil {
 		return fmt.Errorf("failed to create cmd integration directory: %w", err)
@@ -827,6 +1166,16 @@ func isCmdInstalled(scriptPath string) bool {
 	return strings.Contains(currentValue, cmdAutoRunSnippet(scriptPath))
 }
 
+// cmdNeedsUpgrade reports whether the installed cmd init script differs from
+// the one this binary generates
+func cmdNeedsUpgrade() bool {
+	content, err := os.ReadFile(cmdInitScriptPath())
+	if err != nil {
+		return false
+	}
+	return string(content) != generateCmdCode()
+}
+
 func cmdInitScriptPath() string {
 	return filepath.Join(config.GetDataDir(), "shell", "wut-cmd-init.cmd")
 }
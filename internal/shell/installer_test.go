@@ -0,0 +1,100 @@
+package shell
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestInstallerSentinelBlock(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("uses the linux bash config path")
+	}
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	rcFile := filepath.Join(home, ".bashrc")
+
+	legacy := "export PATH=$HOME/bin:$PATH\n" +
+		"\n" + legacyStartMarker + "\nalias old=wut\n" + legacyEndMarker + "\n" +
+		"alias ll='ls -l'\n"
+	if err := os.WriteFile(rcFile, []byte(legacy), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	installer := &Installer{}
+	if !installer.NeedsUpgrade("bash") {
+		t.Fatalf("legacy block should need an upgrade")
+	}
+
+	if err := installer.Install("bash"); err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+	content := readFile(t, rcFile)
+	if strings.Contains(content, "alias old=wut") || strings.Count(content, integrationBeginMarker) != 1 {
+		t.Fatalf("legacy block was not replaced:\n%s", content)
+	}
+	if !strings.HasPrefix(content, "export PATH") || !strings.HasSuffix(content, "alias ll='ls -l'\n") {
+		t.Fatalf("lines around the block changed:\n%s", content)
+	}
+	if version, ok := InstalledVersion(rcFile); !ok || version != IntegrationVersion {
+		t.Fatalf("InstalledVersion() = %d, %v", version, ok)
+	}
+	if installer.NeedsUpgrade("bash") {
+		t.Errorf("fresh block should not need an upgrade")
+	}
+
+	backups, _ := filepath.Glob(rcFile + ".wut-backup-*")
+	if len(backups) == 0 {
+		t.Fatalf("no backup was written")
+	}
+	if got := readFile(t, backups[0]); got != legacy {
+		t.Errorf("backup content = %q, want %q", got, legacy)
+	}
+
+	if err := installer.Install("bash"); !errors.Is(err, ErrAlreadyInstalled) {
+		t.Fatalf("second Install() error = %v, want ErrAlreadyInstalled", err)
+	}
+	if got := readFile(t, rcFile); got != content {
+		t.Fatalf("second Install() changed the file")
+	}
+
+	// Uninstall removes exactly the block, even one the user edited
+	edited := strings.Replace(content, integrationEndMarker, "echo user edit\n"+integrationEndMarker, 1)
+	if err := os.WriteFile(rcFile, []byte(edited), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := installer.Uninstall("bash"); err != nil {
+		t.Fatalf("Uninstall() error = %v", err)
+	}
+	want := "export PATH=$HOME/bin:$PATH\nalias ll='ls -l'\n"
+	if got := readFile(t, rcFile); got != want {
+		t.Errorf("after Uninstall() = %q, want %q", got, want)
+	}
+}
+
+func TestRemoveIntegrationBlockDanglingMarker(t *testing.T) {
+	content := "a\n" + integrationBeginMarker + "\nb\nc\n"
+	if _, _, err := removeIntegrationBlock(content); err == nil {
+		t.Fatalf("a begin marker without an end marker should not remove lines")
+	}
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestShellCodeRecordsCommands(t *testing.T) {
+	for _, shellName := range []string{"bash", "zsh", "fish", "powershell", "nushell", "xonsh", "elvish"} {
+		if code := GenerateShellCode(shellName); !strings.Contains(code, "__record") {
+			t.Errorf("%s integration does not record commands with wut __record", shellName)
+		}
+	}
+}
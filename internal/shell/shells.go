@@ -175,28 +175,54 @@ func DetectCurrentShell() string {
 	return ""
 }
 
-func DetectAvailableShells() []string {
-	ordered := []struct {
-		name        string
-		executables []string
-	}{
-		{name: "bash", executables: []string{"bash"}},
-		{name: "zsh", executables: []string{"zsh"}},
-		{name: "fish", executables: []string{"fish"}},
-		{name: "powershell", executables: []string{"powershell"}},
-		{name: "pwsh", executables: []string{"pwsh"}},
-		{name: "nushell", executables: []string{"nu"}},
-		{name: "xonsh", executables: []string{"xonsh"}},
-		{name: "elvish", executables: []string{"elvish"}},
-		{name: "tcsh", executables: []string{"tcsh"}},
-		{name: "csh", executables: []string{"csh"}},
-		{name: "ksh", executables: []string{"ksh", "ksh93"}},
-		{name: "mksh", executables: []string{"mksh"}},
-		{name: "yash", executables: []string{"yash"}},
-		{name: "dash", executables: []string{"dash"}},
-		{name: "ash", executables: []string{"ash"}},
-		{name: "sh", executables: []string{"sh"}},
+// shellExecutables lists the executables of each shell in detection order
+var shellExecutables = []struct {
+	name        string
+	executables []string
+}{
+	{name: "bash", executables: []string{"bash"}},
+	{name: "zsh", executables: []string{"zsh"}},
+	{name: "fish", executables: []string{"fish"}},
+	{name: "powershell", executables: []string{"powershell"}},
+	{name: "pwsh", executables: []string{"pwsh"}},
+	{name: "nushell", executables: []string{"nu"}},
+	{name: "xonsh", executables: []string{"xonsh"}},
+	{name: "elvish", executables: []string{"elvish"}},
+	{name: "tcsh", executables: []string{"tcsh"}},
+	{name: "csh", executables: []string{"csh"}},
+	{name: "ksh", executables: []string{"ksh", "ksh93"}},
+	{name: "mksh", executables: []string{"mksh"}},
+	{name: "yash", executables: []string{"yash"}},
+	{name: "dash", executables: []string{"dash"}},
+	{name: "ash", executables: []string{"ash"}},
+	{name: "sh", executables: []string{"sh"}},
+}
+
+// ShellExecutable returns the path of the shell's executable, or an empty
+// string when it is not installed
+func ShellExecutable(shellName string) string {
+	shellName = CanonicalName(shellName)
+	if shellName == "cmd" {
+		if path, err := exec.LookPath("cmd"); err == nil {
+			return path
+		}
+		return ""
 	}
+	for _, candidate := range shellExecutables {
+		if candidate.name != shellName {
+			continue
+		}
+		for _, executable := range candidate.executables {
+			if path, err := exec.LookPath(executable); err == nil {
+				return path
+			}
+		}
+	}
+	return ""
+}
+
+func DetectAvailableShells() []string {
+	ordered := shellExecutables
 
 	seen := make(map[string]struct{}, len(ordered)+2)
 	shells := make([]string, 0, len(ordered)+2)
@@ -328,7 +354,7 @@ func DetectHistorySources() []HistorySource {
 		filepath.Join(home, ".sh_history"),
 	)
 
-	for _, source := range detectPowerShellHistorySources(home, xdgDataHome, xdgConfigHome) {
+	for _, source := range detectPowerShellHistorySources(runtime.GOOS, home, appData, xdgDataHome) {
 		addSource(source)
 	}
 	for _, source := range detectClinkHistorySources(localAppData, appData) {
@@ -349,7 +375,7 @@ func DetectHistorySources() []HistorySource {
 			filepath.Join(xdgConfigHome, "nushell", "history.txt"),
 			filepath.Join(home, ".local", "share", "nushell", "history.txt"),
 			filepath.Join(home, ".config", "nushell", "history.txt"),
-			filepath.Join(appData, "nushell", "history.txt"),
+			joinIfSet(appData, "nushell", "history.txt"),
 		)
 	}
 
@@ -431,6 +457,22 @@ func readHistoryFile(shellName, path string) ([]string, error) {
 				commands = append(commands, after)
 			}
 		}
+	case "powershell", "pwsh":
+		// PSReadLine ends each line of a multi-line command but the last
+		// with a backtick
+		var pending []string
+		for scanner.Scan() {
+			line := scanner.Text()
+			if before, ok := strings.CutSuffix(line, "`"); ok {
+				pending = append(pending, before)
+				continue
+			}
+			commands = append(commands, strings.Join(append(pending, line), "\n"))
+			pending = nil
+		}
+		if len(pending) > 0 {
+			commands = append(commands, strings.Join(pending, "\n"))
+		}
 	case "zsh":
 		for scanner.Scan() {
 			line := scanner.Text()
@@ -485,6 +527,16 @@ func uniqueExistingPaths(candidates ...string) []string {
 	return results
 }
 
+// joinIfSet joins elem onto base, or returns "" when base is unset so a
+// missing %APPDATA% does not turn into a path relative to the working
+// directory
+func joinIfSet(base string, elem ...string) string {
+	if base == "" {
+		return ""
+	}
+	return filepath.Join(append([]string{base}, elem...)...)
+}
+
 func xdgDirs(home string) (string, string) {
 	xdgDataHome := strings.TrimSpace(os.Getenv("XDG_DATA_HOME"))
 	if xdgDataHome == "" {
@@ -499,71 +551,46 @@ func xdgDirs(home string) (string, string) {
 	return xdgDataHome, xdgConfigHome
 }
 
-func detectPowerShellHistorySources(home, xdgDataHome, xdgConfigHome string) []HistorySource {
-	directories := make([]string, 0, 8)
-	if runtime.GOOS == "windows" {
-		appData := strings.TrimSpace(os.Getenv("APPDATA"))
-		directories = append(directories,
-			filepath.Join(appData, "Microsoft", "Windows", "PowerShell", "PSReadLine"),
-			filepath.Join(appData, "Microsoft", "PowerShell", "PSReadLine"),
-			filepath.Join(home, "AppData", "Roaming", "Microsoft", "Windows", "PowerShell", "PSReadLine"),
-			filepath.Join(home, "AppData", "Roaming", "Microsoft", "PowerShell", "PSReadLine"),
-		)
-	} else {
-		directories = append(directories,
-			filepath.Join(xdgDataHome, "powershell", "PSReadLine"),
-			filepath.Join(xdgConfigHome, "powershell", "PSReadLine"),
-		)
+// powerShellHistoryDirs returns where PSReadLine saves history. On Windows
+// both Windows PowerShell 5.1 and PowerShell 7 write to the same directory
+// under %APPDATA%; elsewhere only PowerShell 7 exists and follows XDG.
+func powerShellHistoryDirs(goos, home, appData, xdgDataHome string) []string {
+	if goos != "windows" {
+		return []string{filepath.Join(xdgDataHome, "powershell", "PSReadLine")}
+	}
+	dirs := []string{joinIfSet(appData, "Microsoft", "Windows", "PowerShell", "PSReadLine")}
+	if home != "" {
+		dirs = append(dirs, filepath.Join(home, "AppData", "Roaming", "Microsoft", "Windows", "PowerShell", "PSReadLine"))
 	}
+	return dirs
+}
 
-	seen := make(map[string]struct{}, len(directories))
-	results := make([]HistorySource, 0, len(directories))
-	for _, dir := range directories {
-		dir = strings.TrimSpace(dir)
-		if dir == "" {
-			continue
-		}
-		if _, err := os.Stat(dir); err != nil {
-			continue
-		}
+// powerShellHistoryShell names the shell a PSReadLine history belongs to.
+// The Windows file is shared by both editions, so it is labelled powershell.
+func powerShellHistoryShell(goos string) string {
+	if goos == "windows" {
+		return "powershell"
+	}
+	return "pwsh"
+}
 
-		pattern := filepath.Join(dir, "*_history.txt")
-		matches, _ := filepath.Glob(pattern)
-		if len(matches) == 0 {
-			matches = append(matches, filepath.Join(dir, "ConsoleHost_history.txt"))
-		}
-		for _, match := range matches {
-			match = filepath.Clean(match)
-			if _, ok := seen[match]; ok {
-				continue
-			}
-			if _, err := os.Stat(match); err != nil {
-				continue
-			}
-			seen[match] = struct{}{}
+// detectPowerShellHistorySources finds the PSReadLine history files of every
+// host, e.g. ConsoleHost_history.txt and "Visual Studio Code Host_history.txt"
+func detectPowerShellHistorySources(goos, home, appData, xdgDataHome string) []HistorySource {
+	var results []HistorySource
+	for _, dir := range uniqueExistingPaths(powerShellHistoryDirs(goos, home, appData, xdgDataHome)...) {
+		matches, _ := filepath.Glob(filepath.Join(dir, "*_history.txt"))
+		for _, match := range uniqueExistingPaths(matches...) {
 			results = append(results, HistorySource{
-				Shell: detectPowerShellShell(match),
+				Shell: powerShellHistoryShell(goos),
 				Path:  match,
 				Kind:  HistorySourceFile,
 			})
 		}
 	}
-
 	return results
 }
 
-func detectPowerShellShell(path string) string {
-	lower := strings.ToLower(filepath.ToSlash(path))
-	switch {
-	case strings.Contains(lower, "/windowspowershell/"):
-		return "powershell"
-	case strings.Contains(lower, "/powershell/"):
-		return "pwsh"
-	default:
-		return "powershell"
-	}
-}
-
 func detectClinkHistorySources(localAppData, appData string) []HistorySource {
 	candidates := make([]string, 0, 6)
 	if clinkProfile := strings.TrimSpace(os.Getenv("CLINK_PROFILE")); clinkProfile != "" {
@@ -573,10 +600,10 @@ func detectClinkHistorySources(localAppData, appData string) []HistorySource {
 		)
 	}
 	candidates = append(candidates,
-		filepath.Join(localAppData, "clink", "clink_history"),
-		filepath.Join(localAppData, "clink", "history"),
-		filepath.Join(appData, "clink", "clink_history"),
-		filepath.Join(appData, "clink", "history"),
+		joinIfSet(localAppData, "clink", "clink_history"),
+		joinIfSet(localAppData, "clink", "history"),
+		joinIfSet(appData, "clink", "clink_history"),
+		joinIfSet(appData, "clink", "history"),
 	)
 
 	paths := uniqueExistingPaths(candidates...)
@@ -0,0 +1,75 @@
+package shell
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestPowerShellHistoryDirs(t *testing.T) {
+	home := filepath.Join("C:", "Users", "ana")
+	appData := filepath.Join(home, "AppData", "Roaming")
+	psReadLine := filepath.Join(appData, "Microsoft", "Windows", "PowerShell", "PSReadLine")
+
+	tests := []struct {
+		name    string
+		goos    string
+		appData string
+		want    []string
+	}{
+		{"windows", "windows", appData, []string{psReadLine, psReadLine}},
+		// No %APPDATA% must not produce a path relative to the working directory
+		{"windows without APPDATA", "windows", "", []string{"", psReadLine}},
+		{"linux", "linux", appData, []string{filepath.Join("/xdg", "powershell", "PSReadLine")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := powerShellHistoryDirs(tt.goos, home, tt.appData, "/xdg"); !slices.Equal(got, tt.want) {
+				t.Errorf("powerShellHistoryDirs() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectPowerShellHistorySources(t *testing.T) {
+	home := t.TempDir()
+	dir := filepath.Join(home, "AppData", "Roaming", "Microsoft", "Windows", "PowerShell", "PSReadLine")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"ConsoleHost_history.txt", "Visual Studio Code Host_history.txt", "notes.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("Get-Date\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sources := detectPowerShellHistorySources("windows", home, filepath.Join(home, "AppData", "Roaming"), "")
+	var paths []string
+	for _, s := range sources {
+		if s.Shell != "powershell" || s.Kind != HistorySourceFile {
+			t.Errorf("source %+v, want a powershell file", s)
+		}
+		paths = append(paths, filepath.Base(s.Path))
+	}
+	if want := []string{"ConsoleHost_history.txt", "Visual Studio Code Host_history.txt"}; !slices.Equal(paths, want) {
+		t.Errorf("detected %q, want %q", paths, want)
+	}
+}
+
+func TestReadPowerShellHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ConsoleHost_history.txt")
+	history := "Get-Date\r\nfunction f {`\r\n  'hi'`\r\n}\r\nGet-ChildItem -Force\r\n"
+	if err := os.WriteFile(path, []byte(history), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := readHistoryFile("pwsh", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"Get-Date", "function f {\n  'hi'\n}", "Get-ChildItem -Force"}
+	if !slices.Equal(got, want) {
+		t.Errorf("readHistoryFile() = %q, want %q", got, want)
+	}
+}
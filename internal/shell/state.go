@@ -0,0 +1,148 @@
+package shell
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"wut/internal/config"
+)
+
+// The shell hooks record the previous command line on every prompt into a
+// small state file, one per shell session, so `wut fix --last` can correct
+// it. The file is written by the shell itself to avoid starting a process per
+// prompt:
+//
+//	<exit status>\t<shell>
+//	<command line>
+//
+// WriteLastCommand produces the same format for shells whose hook calls wut.
+
+// SessionEnv names the environment variable that identifies a shell session.
+// The integration snippets export it as the shell's PID.
+const SessionEnv = "WUT_SESSION"
+
+const lastCommandPrefix = "last-command"
+
+// LastCommand is the previous command line recorded by a shell hook
+type LastCommand struct {
+	Command  string
+	ExitCode int
+	Shell    string
+	Time     time.Time // modification time of the state file
+}
+
+// Failed reports whether the command exited with a non-zero status
+func (c *LastCommand) Failed() bool {
+	return c.ExitCode != 0
+}
+
+// StateDir returns the directory holding the shell state files
+func StateDir() string {
+	return filepath.Join(config.GetDataDir(), "shell")
+}
+
+// LastCommandPath returns the state file for a shell session. An empty
+// session uses a shared file.
+func LastCommandPath(session string) string {
+	name := lastCommandPrefix
+	if session = strings.TrimSpace(session); session != "" {
+		name += "-" + filepath.Base(session)
+	}
+	return filepath.Join(StateDir(), name)
+}
+
+// CurrentLastCommandPath returns the state file of the session wut runs in
+func CurrentLastCommandPath() string {
+	return LastCommandPath(os.Getenv(SessionEnv))
+}
+
+// WriteLastCommand records a command line in the state file at path
+func WriteLastCommand(path string, last LastCommand) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create shell state directory: %w", err)
+	}
+
+	content := fmt.Sprintf("%d\t%s\n%s\n", last.ExitCode, last.Shell, last.Command)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(content), 0600); err != nil {
+		return fmt.Errorf("failed to write shell state: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("failed to write shell state: %w", err)
+	}
+	return nil
+}
+
+// ReadLastCommand reads the state file at path
+func ReadLastCommand(path string) (*LastCommand, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	last, err := parseLastCommand(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid shell state %s: %w", path, err)
+	}
+	last.Time = info.ModTime()
+	return last, nil
+}
+
+func parseLastCommand(content string) (*LastCommand, error) {
+	header, command, ok := strings.Cut(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	if !ok {
+		return nil, fmt.Errorf("missing command line")
+	}
+
+	status, shellName, _ := strings.Cut(header, "\t")
+	exitCode, err := strconv.Atoi(strings.TrimSpace(status))
+	if err != nil {
+		return nil, fmt.Errorf("bad exit status %q", status)
+	}
+
+	command = strings.TrimSpace(command)
+	if command == "" {
+		return nil, fmt.Errorf("empty command line")
+	}
+
+	return &LastCommand{
+		Command:  command,
+		ExitCode: exitCode,
+		Shell:    strings.TrimSpace(shellName),
+	}, nil
+}
+
+// PruneLastCommands removes state files of sessions that have not recorded a
+// command for longer than maxAge
+func PruneLastCommands(maxAge time.Duration) {
+	matches, err := filepath.Glob(filepath.Join(StateDir(), lastCommandPrefix+"-*"))
+	if err != nil {
+		return
+	}
+	cutoff := time.Now().Add(-maxAge)
+	for _, path := range matches {
+		if info, err := os.Stat(path); err == nil && info.ModTime().Before(cutoff) {
+			_ = os.Remove(path)
+		}
+	}
+}
+
+// quotePOSIX single-quotes s for bash and zsh
+func quotePOSIX(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// quoteFish single-quotes s for fish
+func quoteFish(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return "'" + strings.ReplaceAll(s, "'", `\'`) + "'"
+}
@@ -0,0 +1,73 @@
+package shell
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"wut/internal/config"
+)
+
+func TestLastCommandRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "shell", "last-command-42")
+
+	want := LastCommand{Command: "gti status", ExitCode: 127, Shell: "bash"}
+	if err := WriteLastCommand(path, want); err != nil {
+		t.Fatalf("WriteLastCommand() error = %v", err)
+	}
+
+	got, err := ReadLastCommand(path)
+	if err != nil {
+		t.Fatalf("ReadLastCommand() error = %v", err)
+	}
+	if got.Command != want.Command || got.ExitCode != want.ExitCode || got.Shell != want.Shell {
+		t.Errorf("ReadLastCommand() = %+v, want %+v", got, want)
+	}
+	if !got.Failed() {
+		t.Errorf("exit status 127 should count as failed")
+	}
+}
+
+func TestParseLastCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+		wantErr bool
+	}{
+		// Written by the bash/zsh printf in the integration snippet
+		{name: "shell format", content: "1\tzsh\ndocker ps -a\n", want: "docker ps -a"},
+		{name: "multi-line command", content: "0\tbash\nfor f in *; do\n  echo $f\ndone\n", want: "for f in *; do\n  echo $f\ndone"},
+		{name: "windows line endings", content: "2\tpwsh\r\nGet-ChildItem\r\n", want: "Get-ChildItem"},
+		{name: "missing command", content: "1\tbash\n\n", wantErr: true},
+		{name: "bad status", content: "x\tbash\nls\n", wantErr: true},
+		{name: "empty", content: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseLastCommand(tt.content)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseLastCommand() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && got.Command != tt.want {
+				t.Errorf("parseLastCommand() command = %q, want %q", got.Command, tt.want)
+			}
+		})
+	}
+}
+
+func TestShellCodeEmbedsStateDir(t *testing.T) {
+	prev := config.Get()
+	t.Cleanup(func() { config.Set(prev) })
+	config.Set(&config.Config{Database: config.DatabaseConfig{Path: filepath.Join(t.TempDir(), "it's home", "wut.db")}})
+
+	bash := GenerateShellCode("bash")
+	if strings.Contains(bash, stateDirToken) || !strings.Contains(bash, `it'\''s home`) {
+		t.Errorf("bash snippet does not quote the state directory:\n%s", bash)
+	}
+	fish := GenerateShellCode("fish")
+	if strings.Contains(fish, stateDirToken) || !strings.Contains(fish, `it\'s home`) {
+		t.Errorf("fish snippet does not quote the state directory")
+	}
+}
@@ -0,0 +1,117 @@
+package shell
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// lookupTimeout bounds how long a shell may take to resolve the wut binary
+const lookupTimeout = 5 * time.Second
+
+// IntegrationStatus describes the wut integration of one shell
+type IntegrationStatus struct {
+	Shell        string
+	Executable   string // empty when the shell is not installed
+	ConfigFile   string
+	ConfigExists bool
+	Installed    bool
+	Version      int    // fragment version of the installed block
+	WutPath      string // wut as resolved by the shell, empty when not found
+	LookupErr    error  // why WutPath could not be resolved
+}
+
+// Stale reports whether the installed block is older than this binary's
+func (s IntegrationStatus) Stale() bool {
+	return s.Installed && s.Version < IntegrationVersion
+}
+
+// Status inspects the integration of a shell. Resolving wut starts the shell,
+// so it is skipped when the shell is not installed.
+func (i *Installer) Status(ctx context.Context, shellName string) IntegrationStatus {
+	shellName = CanonicalName(shellName)
+	status := IntegrationStatus{
+		Shell:      shellName,
+		Executable: ShellExecutable(shellName),
+	}
+
+	if configFile, err := GetConfigFile(shellName); err == nil {
+		status.ConfigFile = configFile
+		if _, err := os.Stat(configFile); err == nil {
+			status.ConfigExists = true
+		}
+	}
+
+	if shellName == "cmd" {
+		status.Installed = i.IsShellInstalled(shellName)
+		status.Version = IntegrationVersion
+		if status.Installed && cmdNeedsUpgrade() {
+			status.Version = 1
+		}
+	} else if status.ConfigFile != "" {
+		status.Version, status.Installed = InstalledVersion(status.ConfigFile)
+	}
+
+	if status.Executable != "" {
+		status.WutPath, status.LookupErr = lookupWut(ctx, shellName, status.Executable)
+	}
+	return status
+}
+
+// lookupWut asks the shell where it finds the wut binary. Interactive flags
+// are used where the shell needs them to read its rc file, since that is
+// usually where PATH is extended.
+func lookupWut(ctx context.Context, shellName, executable string) (string, error) {
+	var args []string
+	switch shellName {
+	case "bash", "zsh":
+		args = []string{"-i", "-c", "command -v wut"}
+	case "fish":
+		args = []string{"-c", "command -v wut"}
+	case "powershell", "pwsh":
+		args = []string{"-NoLogo", "-NonInteractive", "-Command", "(Get-Command wut -ErrorAction Stop).Source"}
+	case "nushell":
+		args = []string{"-c", "which wut | get path.0"}
+	case "xonsh":
+		args = []string{"-c", "import shutil; print(shutil.which('wut') or '')"}
+	case "elvish":
+		args = []string{"-c", "search-external wut"}
+	case "cmd":
+		args = []string{"/c", "where wut"}
+	default:
+		return "", fmt.Errorf("unsupported shell: %s", shellName)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, lookupTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, executable, args...)
+	out, err := cmd.Output()
+	if ctx.Err() != nil {
+		return "", fmt.Errorf("%s did not answer within %s", shellName, lookupTimeout)
+	}
+
+	path := lastLine(string(out))
+	if path == "" {
+		if err != nil {
+			return "", fmt.Errorf("wut is not on %s's PATH: %w", shellName, err)
+		}
+		return "", fmt.Errorf("wut is not on %s's PATH", shellName)
+	}
+	return path, nil
+}
+
+// lastLine returns the last non-empty line of s; interactive shells may print
+// banners before the answer
+func lastLine(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		if line := strings.TrimSpace(lines[i]); line != "" {
+			return line
+		}
+	}
+	return ""
+}
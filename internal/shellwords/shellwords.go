@@ -0,0 +1,233 @@
+// Package shellwords splits shell command lines into words and operators
+package shellwords
+
+import "strings"
+
+// Kind is what a token of a command line is
+type Kind int
+
+const (
+	Word Kind = iota
+	Control
+	Redirect
+)
+
+// Token is a word or operator of a command line. Words have their quotes
+// removed; redirections keep their file descriptor prefix.
+type Token struct {
+	Kind       Kind
+	Text       string
+	Target     string // inline target of >&2 style redirections
+	Start, End int    // byte span in the command line
+}
+
+// operators are matched longest first
+var operators = []string{
+	"&>>", "<<<", "&>", "<<", ">>", ">&", "<&", "||", "&&", "|&",
+	"|", ";", "&", "<", ">",
+}
+
+// Lex splits a command line into words, control operators and
+// redirections. It handles quoting, backslash escapes, backticks and $(...)
+// substitutions well enough to explain or correct a command; it does not
+// expand anything.
+func Lex(line string) []Token {
+	var tokens []Token
+	var word strings.Builder
+	inWord, quoted := false, false
+	wordStart := 0
+
+	flush := func(end int) {
+		if inWord {
+			tokens = append(tokens, Token{Kind: Word, Text: word.String(), Start: wordStart, End: end})
+		}
+		word.Reset()
+		inWord, quoted = false, false
+	}
+	startWord := func(i int) {
+		if !inWord {
+			inWord = true
+			wordStart = i
+		}
+	}
+
+	for i := 0; i < len(line); {
+		c := line[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			flush(i)
+			i++
+
+		case c == '\'' || c == '"' || c == '`' || c == '$' && i+1 < len(line) && line[i+1] == '(' ||
+			c == '\\' && i+1 < len(line):
+			startWord(i)
+			end, closed := scanQuoted(line, i)
+			switch c {
+			case '\'':
+				quoted = true
+				word.WriteString(line[i+1 : closedBody(end, closed)])
+			case '"':
+				quoted = true
+				word.WriteString(unescapeDouble(line[i+1 : closedBody(end, closed)]))
+			case '\\':
+				word.WriteString(line[i+1 : end])
+			default:
+				// Substitutions stay part of the word as written
+				word.WriteString(line[i:end])
+			}
+			i = end
+
+		case strings.IndexByte("|&;<>", c) >= 0:
+			op := ""
+			for _, candidate := range operators {
+				if strings.HasPrefix(line[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+
+			// A word of digits right before > or < is the redirected descriptor
+			fd := ""
+			if (c == '>' || c == '<') && inWord && !quoted && IsDigits(word.String()) {
+				fd = word.String()
+				word.Reset()
+				inWord = false
+			} else {
+				flush(i)
+			}
+
+			tok := Token{Kind: Control, Text: fd + op, Start: i - len(fd), End: i + len(op)}
+			i += len(op)
+			if strings.ContainsAny(op, "<>") {
+				tok.Kind = Redirect
+				if op == ">&" || op == "<&" {
+					j := i
+					for j < len(line) && (IsDigits(line[j:j+1]) || line[j] == '-') {
+						j++
+					}
+					tok.Target = line[i:j]
+					tok.End = j
+					i = j
+				}
+			}
+			tokens = append(tokens, tok)
+
+		default:
+			startWord(i)
+			word.WriteByte(c)
+			i++
+		}
+	}
+	flush(len(line))
+	return tokens
+}
+
+// Fields splits a command into words at unquoted whitespace. Quoted
+// strings, escapes and substitutions stay inside their word, which keeps its
+// quotes, and operators are not split from the words around them.
+func Fields(command string) []string {
+	var words []string
+	start := -1
+	for i := 0; i < len(command); {
+		if c := command[i]; c == ' ' || c == '\t' || c == '\n' {
+			if start >= 0 {
+				words = append(words, command[start:i])
+				start = -1
+			}
+			i++
+			continue
+		}
+		if start < 0 {
+			start = i
+		}
+		if end, _ := scanQuoted(command, i); end > i {
+			i = end
+		} else {
+			i++
+		}
+	}
+	if start >= 0 {
+		words = append(words, command[start:])
+	}
+	return words
+}
+
+// IsDigits reports whether s is a non-empty run of ASCII digits
+func IsDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// scanQuoted returns the index just past the quoted string, escape or
+// command substitution starting at line[i], or i when there is none, and
+// whether it was closed. An unclosed one runs to the end of the line.
+func scanQuoted(line string, i int) (int, bool) {
+	switch c := line[i]; {
+	case c == '\\':
+		return min(i+2, len(line)), i+1 < len(line)
+	case c == '\'':
+		if end := strings.IndexByte(line[i+1:], '\''); end >= 0 {
+			return i + end + 2, true
+		}
+	case c == '"' || c == '`':
+		for j := i + 1; j < len(line); j++ {
+			switch line[j] {
+			case '\\':
+				j++
+			case c:
+				return j + 1, true
+			}
+		}
+	case c == '$' && i+1 < len(line) && line[i+1] == '(':
+		depth := 0
+		for j := i + 1; j < len(line); {
+			switch line[j] {
+			case '(':
+				depth++
+			case ')':
+				if depth--; depth == 0 {
+					return j + 1, true
+				}
+			case '\\', '\'', '"', '`':
+				j, _ = scanQuoted(line, j)
+				continue
+			}
+			j++
+		}
+	default:
+		return i, false
+	}
+	return len(line), false
+}
+
+// closedBody is where the body of a quoted string ending at end stops: before
+// its closing quote, if it has one
+func closedBody(end int, closed bool) int {
+	if closed {
+		return end - 1
+	}
+	return end
+}
+
+// unescapeDouble removes the backslashes that escape a character inside
+// double quotes
+func unescapeDouble(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) && strings.IndexByte(`"\$`+"`", s[i+1]) >= 0 {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
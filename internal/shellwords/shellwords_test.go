@@ -0,0 +1,58 @@
+package shellwords
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLex(t *testing.T) {
+	tests := []struct {
+		line string
+		want []Token
+	}{
+		{`git commit -m "fix \"it\""`, []Token{
+			{Kind: Word, Text: "git", Start: 0, End: 3},
+			{Kind: Word, Text: "commit", Start: 4, End: 10},
+			{Kind: Word, Text: "-m", Start: 11, End: 13},
+			{Kind: Word, Text: `fix "it"`, Start: 14, End: 26},
+		}},
+		{"make 2>&1 | tee 'build log'", []Token{
+			{Kind: Word, Text: "make", Start: 0, End: 4},
+			{Kind: Redirect, Text: "2>&", Target: "1", Start: 5, End: 9},
+			{Kind: Control, Text: "|", Start: 10, End: 11},
+			{Kind: Word, Text: "tee", Start: 12, End: 15},
+			{Kind: Word, Text: "build log", Start: 16, End: 27},
+		}},
+		// Substitutions are kept whole, operators inside them included
+		{"echo $(a && b) `c; d` e\\;f", []Token{
+			{Kind: Word, Text: "echo", Start: 0, End: 4},
+			{Kind: Word, Text: "$(a && b)", Start: 5, End: 14},
+			{Kind: Word, Text: "`c; d`", Start: 15, End: 21},
+			{Kind: Word, Text: "e;f", Start: 22, End: 26},
+		}},
+		// A quoted number is an argument, not a descriptor
+		{`echo "2">out`, []Token{
+			{Kind: Word, Text: "echo", Start: 0, End: 4},
+			{Kind: Word, Text: "2", Start: 5, End: 8},
+			{Kind: Redirect, Text: ">", Start: 8, End: 9},
+			{Kind: Word, Text: "out", Start: 9, End: 12},
+		}},
+		{`echo 'unterminated`, []Token{
+			{Kind: Word, Text: "echo", Start: 0, End: 4},
+			{Kind: Word, Text: "unterminated", Start: 5, End: 18},
+		}},
+	}
+	for _, tt := range tests {
+		if got := Lex(tt.line); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Lex(%q) = %+v, want %+v", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestFields(t *testing.T) {
+	got := Fields(`echo  $(git log --format="%h %s") 'a b' "c d" e\ f ` + "`date +%s`")
+	want := []string{"echo", `$(git log --format="%h %s")`, "'a b'", `"c d"`, `e\ f`, "`date +%s`"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Fields() = %q, want %q", got, want)
+	}
+}
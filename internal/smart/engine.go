@@ -5,33 +5,56 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"os"
+	"path"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
 	"time"
 
 	"wut/internal/commandsearch"
+	"wut/internal/config"
 	appctx "wut/internal/context"
+	"wut/internal/corrector"
 	"wut/internal/db"
 	"wut/internal/historyml"
+	"wut/internal/logger"
+	"wut/internal/metrics"
 	"wut/internal/performance"
 	"wut/internal/shell"
 )
 
 // Engine provides intelligent command suggestions
 type Engine struct {
-	storage      *db.Storage
+	storage *db.Storage
+	// storageRev is the revision of the database the cached suggestions
+	// were read from, noRevision when some were made without one
+	storageRev int
+
+	cfg          *config.Config
 	matcher      *performance.FastMatcher
 	cache        *performance.LRUCache[string, []Suggestion]
-	ctxCache     *performance.LRUCache[string, *appctx.Context]
+	ctxCache     *performance.LRUCache[string, cachedContext]
 	index        *performance.InvertedIndex
 	autocomplete *performance.Autocomplete
 
 	// Scoring weights
 	weights ScoringWeights
 
+	// explainRanking attaches a ScoreBreakdown to every suggestion
+	explainRanking bool
+
+	// intentCategory restricts semantic suggestions to one intent category
+	intentCategory string
+
+	// sources are the external suggestion sources, read on first use
+	sources       []ExternalSource
+	sourceTimeout time.Duration
+	sourcesLoaded bool
+
 	mu sync.RWMutex
 }
 
@@ -59,6 +82,25 @@ func DefaultScoringWeights() ScoringWeights {
 	}
 }
 
+// WeightsFromConfig applies the weights set in the config file on top of
+// DefaultScoringWeights
+func WeightsFromConfig(c config.ScoringWeightsConfig) ScoringWeights {
+	w := DefaultScoringWeights()
+	override := func(dst *float64, v *float64) {
+		if v != nil {
+			*dst = *v
+		}
+	}
+	override(&w.ExactMatch, c.ExactMatch)
+	override(&w.PrefixMatch, c.PrefixMatch)
+	override(&w.ContainsMatch, c.ContainsMatch)
+	override(&w.FuzzyMatch, c.FuzzyMatch)
+	override(&w.HistoryFreq, c.HistoryFreq)
+	override(&w.Recency, c.Recency)
+	override(&w.ContextRelevance, c.ContextRelevance)
+	return w
+}
+
 // Suggestion represents a command suggestion
 type Suggestion struct {
 	Command        string
@@ -70,21 +112,115 @@ type Suggestion struct {
 	LastUsed       time.Time
 	ContextMatch   float64
 	IsPerfectMatch bool
+	// Confidence is how sure the semantic engine is, from 0 to 1, that the
+	// command does what the query describes; other sources leave it 0
+	Confidence float64
+	// MatchSpans are the parts of Command the query's words matched, for
+	// highlighting
+	MatchSpans []performance.Span
+
+	// Breakdown is only set when ranking explanations are enabled
+	Breakdown *ScoreBreakdown
+}
+
+// ScoreBreakdown splits a suggestion's final score into what each
+// ScoringWeights term contributed. Source is the score its source gave it.
+type ScoreBreakdown struct {
+	Source   float64
+	Exact    float64
+	Prefix   float64
+	Contains float64
+	Fuzzy    float64
+	History  float64
+	Recency  float64
+	Context  float64
+	// Bias is what a boost or bury from wut train added or took away
+	Bias float64
+}
+
+// Total is the sum of all contributions, the suggestion's final score
+func (b ScoreBreakdown) Total() float64 {
+	return b.Source + b.Exact + b.Prefix + b.Contains + b.Fuzzy + b.History + b.Recency + b.Context + b.Bias
 }
 
-// NewEngine creates a new smart engine
+func (b ScoreBreakdown) add(other ScoreBreakdown) ScoreBreakdown {
+	return ScoreBreakdown{
+		Source:   b.Source + other.Source,
+		Exact:    b.Exact + other.Exact,
+		Prefix:   b.Prefix + other.Prefix,
+		Contains: b.Contains + other.Contains,
+		Fuzzy:    b.Fuzzy + other.Fuzzy,
+		History:  b.History + other.History,
+		Recency:  b.Recency + other.Recency,
+		Context:  b.Context + other.Context,
+		Bias:     b.Bias + other.Bias,
+	}
+}
+
+// NewEngine creates a new smart engine with the loaded configuration
 func NewEngine(storage *db.Storage) *Engine {
+	return NewEngineWithConfig(storage, config.Get())
+}
+
+// NewEngineWithConfig creates a smart engine that takes its settings from
+// cfg rather than the loaded configuration
+func NewEngineWithConfig(storage *db.Storage, cfg *config.Config) *Engine {
 	return &Engine{
 		storage:      storage,
-		matcher:      performance.NewFastMatcher(false, 0.3, 3),
+		cfg:          cfg,
+		matcher:      newMatcher(cfg),
 		cache:        performance.NewLRUCache[string, []Suggestion](1000, 32),
-		ctxCache:     performance.NewLRUCache[string, *appctx.Context](100, 8),
+		ctxCache:     performance.NewLRUCache[string, cachedContext](100, 8),
 		index:        performance.NewInvertedIndex(),
 		autocomplete: performance.NewAutocomplete(100),
-		weights:      DefaultScoringWeights(),
+		weights:      WeightsFromConfig(cfg.Smart.Weights),
+		storageRev:   noRevision,
 	}
 }
 
+// newMatcher returns the matcher suggestions are ranked and highlighted
+// with, scoring typos with the configured algorithm
+func newMatcher(cfg *config.Config) *performance.FastMatcher {
+	matcher := performance.NewFastMatcher(false, 0.3, 3)
+	matcher.SetAlgorithm(performance.Algorithm(cfg.Fuzzy.Algorithm))
+	return matcher
+}
+
+// noRevision stands for a database whose revision is not known
+const noRevision = -1
+
+// SetStorage replaces the database suggestions are read from, for a
+// process that opens it only while answering so other WUT commands can
+// write to it in between. The cached suggestions are kept until the
+// database has been written since they were made; nil only detaches it. It
+// must not be called while Suggest runs.
+func (e *Engine) SetStorage(storage *db.Storage) {
+	rev := noRevision
+	if storage != nil {
+		if r, err := storage.Revision(); err == nil {
+			rev = r
+		}
+	}
+
+	e.mu.Lock()
+	e.storage = storage
+	stale := storage != nil && (rev == noRevision || rev != e.storageRev)
+	if storage != nil {
+		e.storageRev = rev
+	}
+	e.mu.Unlock()
+	if stale {
+		e.ClearSuggestions()
+	}
+}
+
+// store returns the database suggestions are read from, nil without one
+func (e *Engine) store() *db.Storage {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.storage
+}
+
 // SetWeights sets custom scoring weights
 func (e *Engine) SetWeights(weights ScoringWeights) {
 	e.mu.Lock()
@@ -92,93 +228,107 @@ func (e *Engine) SetWeights(weights ScoringWeights) {
 	e.weights = weights
 }
 
+// SetExplainRanking makes Suggest attach a ScoreBreakdown to every
+// suggestion, for tuning the weights
+func (e *Engine) SetExplainRanking(enabled bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.explainRanking = enabled
+}
+
+func (e *Engine) explaining() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.explainRanking
+}
+
+// SetIntentCategory restricts semantic suggestions to the intents of one
+// category, such as docker or git, and answers every query with them; an
+// empty category lifts the restriction
+func (e *Engine) SetIntentCategory(category string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.intentCategory = category
+}
+
+func (e *Engine) category() string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.intentCategory
+}
+
+// suggestWorkers is how many suggestion sources Suggest runs at once. Most
+// of them spend their time waiting on the database or an external program,
+// so this need not follow the number of CPUs.
+const suggestWorkers = 4
+
 // Suggest returns intelligent command suggestions
 func (e *Engine) Suggest(ctx context.Context, query string, contextData *appctx.Context, limit int) ([]Suggestion, error) {
+	defer metrics.ObserveLatency(metrics.LatencySuggest, time.Now())
+	defer logger.With("smart").Timed("suggest", "query", query)()
 	if limit < 0 {
 		limit = 10
 	}
 	if contextData == nil {
-		contextData = &appctx.Context{ProjectType: "unknown"}
+		var err error
+		if contextData, err = e.Context(ctx); err != nil {
+			contextData = &appctx.Context{ProjectType: "unknown"}
+		}
 	}
 
-	// Check cache for exact query
-	cacheKey := query + ":" + contextData.ProjectType
-	if cached, ok := e.cache.Get(cacheKey); ok {
+	// Check cache for exact query; history is weighted by directory and the
+	// git suggestions follow the repository's state, so both are part of the key
+	cacheKey := query + ":" + contextData.ProjectType + ":" + contextData.WorkingDir + ":" + gitFingerprint(contextData)
+	if e.explaining() {
+		cacheKey += ":explain"
+	}
+	if category := e.category(); category != "" {
+		cacheKey += ":" + category
+	}
+	cached, ok := e.cache.Get(cacheKey)
+	metrics.RecordCache("suggestions", ok)
+	if ok {
 		return e.limitSuggestions(cached, limit), nil
 	}
 
-	// Collect suggestions from all sources concurrently
-	suggestionChan := make(chan []Suggestion, 5)
-	var wg sync.WaitGroup
-
-	// 1. History-based suggestions
-	wg.Go(func() {
-		select {
-		case suggestionChan <- e.getHistorySuggestions(ctx, query, limit):
-		case <-ctx.Done():
-		}
-	})
-
-	// 2. Context-specific suggestions
-	wg.Go(func() {
-		select {
-		case suggestionChan <- e.getContextSuggestions(contextData, query):
-		case <-ctx.Done():
-		}
-	})
-
-	// 3. Common workflow suggestions
-	wg.Go(func() {
-		select {
-		case suggestionChan <- e.getWorkflowSuggestions(contextData, query):
-		case <-ctx.Done():
-		}
-	})
-
-	// 4. Fuzzy matched suggestions
-	wg.Go(func() {
-		select {
-		case suggestionChan <- e.getFuzzySuggestions(query, limit):
-		case <-ctx.Done():
-		}
-	})
-
-	// 5. Command catalog / TLDR suggestions
-	wg.Go(func() {
-		select {
-		case suggestionChan <- e.getCatalogSuggestions(ctx, query, limit):
-		case <-ctx.Done():
-		}
-	})
-
-	// Close channel when done
-	go func() {
-		wg.Wait()
-		close(suggestionChan)
-	}()
+	// Collect suggestions from all sources concurrently. A source that is
+	// still running when ctx is done is left out.
+	perSource, _ := performance.FanOut(ctx, []func(context.Context) []Suggestion{
+		// 1. History-based suggestions
+		func(ctx context.Context) []Suggestion { return e.getHistorySuggestions(ctx, query, contextData, limit) },
+		// 2. Context-specific suggestions
+		func(context.Context) []Suggestion { return e.getContextSuggestions(contextData, query) },
+		// 3. Common workflow suggestions
+		func(context.Context) []Suggestion { return e.getWorkflowSuggestions(contextData, query) },
+		// 4. Fuzzy matched suggestions
+		func(context.Context) []Suggestion { return e.getFuzzySuggestions(query, limit) },
+		// 5. Command catalog / TLDR suggestions
+		func(ctx context.Context) []Suggestion { return e.getCatalogSuggestions(ctx, query, limit) },
+		// 6. Likely follow-ups to the previous command
+		func(ctx context.Context) []Suggestion { return e.getNextSuggestions(ctx, query, limit) },
+		// 7. Commands for a task described in words
+		func(context.Context) []Suggestion { return e.getSemanticSuggestions(query, limit) },
+		// 8. The user's own aliases
+		func(ctx context.Context) []Suggestion { return e.getAliasSuggestions(ctx, query) },
+		// 9. Bookmarked commands
+		func(ctx context.Context) []Suggestion { return e.getBookmarkSuggestions(ctx, query) },
+		// 10. External sources from the sources directory and the config
+		func(ctx context.Context) []Suggestion {
+			return e.getExternalSuggestions(ctx, query, contextData, limit)
+		},
+	}, suggestWorkers)
 
-	// Collect and deduplicate with context check
+	// Deduplicate, merging what several sources say about a command
 	suggestionMap := make(map[string]Suggestion)
-	for {
-		select {
-		case suggestions, ok := <-suggestionChan:
-			if !ok {
-				// Channel closed, all workers done
-				goto done
-			}
-			for _, s := range suggestions {
-				if existing, ok := suggestionMap[s.Command]; ok {
-					suggestionMap[s.Command] = mergeSuggestion(existing, s)
-				} else {
-					suggestionMap[s.Command] = s
-				}
+	for _, suggestions := range perSource {
+		for _, s := range suggestions {
+			if existing, ok := suggestionMap[s.Command]; ok {
+				suggestionMap[s.Command] = mergeSuggestion(existing, s)
+			} else {
+				suggestionMap[s.Command] = s
 			}
-		case <-ctx.Done():
-			// Context cancelled/timed out, return what we have
-			goto done
 		}
 	}
-done:
 
 	// Convert to slice and sort
 	results := make([]Suggestion, 0, len(suggestionMap))
@@ -187,17 +337,23 @@ done:
 	}
 
 	// Score and sort
-	results = e.scoreAndSort(results, query, contextData)
+	results = e.scoreAndSort(results, query, contextData, e.commandBiases(ctx))
 
-	// Cache results
+	// Cache results; those made without a database are dropped once one is
+	// set
+	e.mu.Lock()
+	if e.storage == nil {
+		e.storageRev = noRevision
+	}
+	e.mu.Unlock()
 	e.cache.Set(cacheKey, results, 30*time.Second)
 
 	return e.limitSuggestions(results, limit), nil
 }
 
 // getHistorySuggestions gets suggestions from command history sequentially
-func (e *Engine) getHistorySuggestions(ctx context.Context, query string, limit int) []Suggestion {
-	if e.storage == nil {
+func (e *Engine) getHistorySuggestions(ctx context.Context, query string, contextData *appctx.Context, limit int) []Suggestion {
+	if e.store() == nil {
 		return nil
 	}
 
@@ -207,14 +363,69 @@ func (e *Engine) getHistorySuggestions(ctx context.Context, query string, limit
 	default:
 	}
 
+	var suggestions []Suggestion
 	if strings.TrimSpace(query) != "" {
-		return e.getHistoryLogSuggestions(ctx, query, limit)
+		suggestions = e.getHistoryLogSuggestions(ctx, query, limit)
+	} else {
+		suggestions = e.getHistorySummarySuggestions(ctx, limit)
 	}
 
-	return e.getHistorySummarySuggestions(ctx, limit)
+	usage := e.getDirectoryUsage(ctx, contextData)
+	for i := range suggestions {
+		applyDirectoryUsage(&suggestions[i], usage[suggestions[i].Command])
+	}
+	return suggestions
+}
+
+// directoryScanLimit bounds how much of the execution log is scanned for
+// commands run in the current directory
+const directoryScanLimit = 5000
+
+// getDirectoryUsage counts the commands previously run in the current
+// directory and its git repository. A repository rooted at the home directory
+// (dotfiles) is not treated as a project.
+func (e *Engine) getDirectoryUsage(ctx context.Context, contextData *appctx.Context) map[string]db.DirectoryUsage {
+	storage := e.store()
+	if storage == nil || contextData == nil || contextData.WorkingDir == "" {
+		return nil
+	}
+
+	projectRoot := contextData.GitRoot
+	if projectRoot != "" && filepath.Clean(projectRoot) == filepath.Clean(contextData.HomeDir) {
+		projectRoot = ""
+	}
+
+	usage, err := storage.GetDirectoryUsage(ctx, contextData.WorkingDir, projectRoot, directoryScanLimit)
+	if err != nil {
+		return nil
+	}
+	return usage
+}
+
+// applyDirectoryUsage boosts a history suggestion that was run in the
+// current directory, and to a lesser degree elsewhere in the same project
+func applyDirectoryUsage(s *Suggestion, usage db.DirectoryUsage) {
+	if usage.InProject == 0 {
+		return
+	}
+
+	elsewhere := usage.InProject - usage.InDir
+	s.Score += math.Log1p(float64(usage.InDir))*0.6 + math.Log1p(float64(elsewhere))*0.25
+
+	if usage.InDir > 0 {
+		s.ContextMatch = maxFloat64(s.ContextMatch, 1.0)
+		s.Description = strings.TrimSpace(s.Description + " · used here")
+	} else {
+		s.ContextMatch = maxFloat64(s.ContextMatch, 0.7)
+		s.Description = strings.TrimSpace(s.Description + " · used in this project")
+	}
 }
 
 func (e *Engine) getHistorySummarySuggestions(ctx context.Context, limit int) []Suggestion {
+	storage := e.store()
+	if storage == nil {
+		return nil
+	}
 	scanLimit := 0
 	if limit > 0 && limit < 100 {
 		scanLimit = limit * 400
@@ -223,7 +434,7 @@ func (e *Engine) getHistorySummarySuggestions(ctx context.Context, limit int) []
 		}
 	}
 
-	summaries, err := e.storage.GetHistoryCommandSummaries(ctx, scanLimit)
+	summaries, err := storage.GetHistoryCommandSummaries(ctx, scanLimit)
 	if err != nil || len(summaries) == 0 {
 		return nil
 	}
@@ -238,6 +449,7 @@ func (e *Engine) getHistorySummarySuggestions(ctx context.Context, limit int) []
 
 		score := historySummaryBoost(summary, ranker)
 		score += historySummarySourceBoost(summary, currentOS, currentShell)
+		score -= historySummaryFailurePenalty(summary)
 
 		description := historySummaryDescription(summary, profile)
 		contextMatch := 0.0
@@ -261,7 +473,8 @@ func (e *Engine) getHistorySummarySuggestions(ctx context.Context, limit int) []
 }
 
 func (e *Engine) getHistoryLogSuggestions(ctx context.Context, query string, limit int) []Suggestion {
-	if e.storage == nil {
+	storage := e.store()
+	if storage == nil {
 		return nil
 	}
 	searchLimit := 0
@@ -275,7 +488,7 @@ func (e *Engine) getHistoryLogSuggestions(ctx context.Context, query string, lim
 		}
 	}
 
-	matches, err := e.storage.SearchHistoryMatches(ctx, query, searchLimit)
+	matches, err := storage.SearchHistoryMatches(ctx, query, searchLimit)
 	if err != nil || len(matches) == 0 {
 		return nil
 	}
@@ -409,6 +622,79 @@ func (e *Engine) getContextSuggestions(ctx *appctx.Context, query string) []Sugg
 			{Command: "cargo fmt", Description: "Format code", Source: "🎯 Context", Icon: "✨"},
 			{Command: "cargo update", Description: "Update dependencies", Source: "🎯 Context", Icon: "🔄"},
 		},
+		"maven": {
+			{Command: "mvn clean install", Description: "Build and install to the local repository", Source: "🎯 Context", Icon: "🔨"},
+			{Command: "mvn test", Description: "Run tests", Source: "🎯 Context", Icon: "🧪"},
+			{Command: "mvn package", Description: "Package the project", Source: "🎯 Context", Icon: "📦"},
+			{Command: "mvn dependency:tree", Description: "Show dependency tree", Source: "🎯 Context", Icon: "🌳"},
+			{Command: "mvn versions:display-dependency-updates", Description: "Check outdated dependencies", Source: "🎯 Context", Icon: "📋"},
+		},
+		"gradle": {
+			{Command: "./gradlew build", Description: "Build project", Source: "🎯 Context", Icon: "🔨"},
+			{Command: "./gradlew test", Description: "Run tests", Source: "🎯 Context", Icon: "🧪"},
+			{Command: "./gradlew clean", Description: "Remove build outputs", Source: "🎯 Context", Icon: "🧹"},
+			{Command: "./gradlew dependencies", Description: "Show dependency tree", Source: "🎯 Context", Icon: "🌳"},
+			{Command: "./gradlew tasks", Description: "List available tasks", Source: "🎯 Context", Icon: "📋"},
+		},
+		"php": {
+			{Command: "composer install", Description: "Install dependencies", Source: "🎯 Context", Icon: "📦"},
+			{Command: "composer update", Description: "Update dependencies", Source: "🎯 Context", Icon: "🔄"},
+			{Command: "composer dump-autoload", Description: "Regenerate the autoloader", Source: "🎯 Context", Icon: "⚙️"},
+			{Command: "vendor/bin/phpunit", Description: "Run tests", Source: "🎯 Context", Icon: "🧪"},
+			{Command: "composer outdated", Description: "Check outdated packages", Source: "🎯 Context", Icon: "📋"},
+		},
+		"ruby": {
+			{Command: "bundle install", Description: "Install dependencies", Source: "🎯 Context", Icon: "📦"},
+			{Command: "bundle exec rake", Description: "Run the default rake task", Source: "🎯 Context", Icon: "▶️"},
+			{Command: "bundle exec rspec", Description: "Run tests", Source: "🎯 Context", Icon: "🧪"},
+			{Command: "bundle update", Description: "Update dependencies", Source: "🎯 Context", Icon: "🔄"},
+			{Command: "bundle outdated", Description: "Check outdated gems", Source: "🎯 Context", Icon: "📋"},
+		},
+		"dotnet": {
+			{Command: "dotnet restore", Description: "Restore dependencies", Source: "🎯 Context", Icon: "📦"},
+			{Command: "dotnet build", Description: "Build project", Source: "🎯 Context", Icon: "🔨"},
+			{Command: "dotnet test", Description: "Run tests", Source: "🎯 Context", Icon: "🧪"},
+			{Command: "dotnet run", Description: "Run project", Source: "🎯 Context", Icon: "▶️"},
+			{Command: "dotnet format", Description: "Format code", Source: "🎯 Context", Icon: "✨"},
+		},
+		"terraform": {
+			{Command: "terraform init", Description: "Initialize the working directory", Source: "🎯 Context", Icon: "⚙️"},
+			{Command: "terraform plan", Description: "Preview changes", Source: "🎯 Context", Icon: "📋"},
+			{Command: "terraform apply", Description: "Apply changes", Source: "🎯 Context", Icon: "🚀"},
+			{Command: "terraform fmt", Description: "Format configuration", Source: "🎯 Context", Icon: "✨"},
+			{Command: "terraform validate", Description: "Validate configuration", Source: "🎯 Context", Icon: "✅"},
+		},
+	}
+
+	// The project's own scripts, targets and recipes come first; once
+	// package.json scripts are known, the generic npm scripts are left out
+	tasks := projectTasks(ctx)
+	hasScripts := false
+	for _, task := range tasks {
+		hasScripts = hasScripts || task.File == "package.json"
+		suggestions = append(suggestions, Suggestion{
+			Command:      task.Command,
+			Description:  task.Description,
+			Source:       "🎯 Project",
+			Icon:         "📜",
+			ContextMatch: 1.0,
+		})
+	}
+	if hasScripts {
+		projectCommands["nodejs"] = slices.DeleteFunc(projectCommands["nodejs"], func(s Suggestion) bool {
+			return isNpmScript(s.Command)
+		})
+	}
+
+	// With a Compose file, spell Compose the way it is installed and name
+	// its services
+	if ctx.ComposeCommand != "" {
+		for i, cmd := range projectCommands["docker"] {
+			if rest, ok := strings.CutPrefix(cmd.Command, "docker-compose "); ok {
+				projectCommands["docker"][i].Command = ctx.ComposeCommand + " " + rest
+			}
+		}
+		projectCommands["docker"] = append(projectCommands["docker"], composeServiceSuggestions(ctx)...)
 	}
 
 	// Get commands for current project type
@@ -419,6 +705,27 @@ func (e *Engine) getContextSuggestions(ctx *appctx.Context, query string) []Sugg
 		}
 	}
 
+	// Secondary project types rank just below the primary one
+	for _, projectType := range ctx.SecondaryTypes {
+		for _, cmd := range projectCommands[projectType] {
+			cmd.ContextMatch = 0.8
+			suggestions = append(suggestions, cmd)
+		}
+	}
+
+	// Projects elsewhere in the repository, such as ./web next to ./api,
+	// weigh less the further away they are
+	for _, project := range ctx.Projects {
+		if project.Type == ctx.ProjectType || slices.Contains(ctx.SecondaryTypes, project.Type) {
+			continue
+		}
+		for _, cmd := range projectCommands[project.Type] {
+			cmd.ContextMatch = projectProximity(project.Distance)
+			cmd.Description += " (" + projectLocation(project) + ")"
+			suggestions = append(suggestions, cmd)
+		}
+	}
+
 	// Git commands for git repos
 	if ctx.IsGitRepo {
 		if cmds, ok := projectCommands["git"]; ok {
@@ -437,13 +744,98 @@ func (e *Engine) getContextSuggestions(ctx *appctx.Context, query string) []Sugg
 	return e.filterSuggestions(suggestions, query)
 }
 
+// projectTasks returns the tasks defined in the working directory and in
+// the directory of its project, without repeats
+func projectTasks(ctx *appctx.Context) []appctx.Task {
+	dirs := []string{ctx.WorkingDir}
+	for _, project := range ctx.Projects {
+		if project.Type == ctx.ProjectType && project.Dir != ctx.WorkingDir {
+			dirs = append(dirs, project.Dir)
+		}
+	}
+
+	var tasks []appctx.Task
+	seen := make(map[string]bool)
+	for _, dir := range dirs {
+		if dir == "" {
+			continue
+		}
+		found, _ := appctx.ProjectTasks(dir)
+		for _, task := range found {
+			if !seen[task.Command] {
+				seen[task.Command] = true
+				tasks = append(tasks, task)
+			}
+		}
+	}
+	return tasks
+}
+
+// maxComposeSuggestions caps the suggestions made for Compose services and
+// profiles, so a file with dozens of services does not crowd out the rest
+const maxComposeSuggestions = 24
+
+// composeServiceSuggestions returns logs, up, restart and exec commands for
+// each Compose service, and an up command for each profile
+func composeServiceSuggestions(ctx *appctx.Context) []Suggestion {
+	compose := ctx.ComposeCommand
+	var suggestions []Suggestion
+	add := func(command, description, icon string) bool {
+		if len(suggestions) >= maxComposeSuggestions {
+			return false
+		}
+		suggestions = append(suggestions, Suggestion{Command: command, Description: description, Source: "🎯 Context", Icon: icon})
+		return true
+	}
+
+	for _, service := range ctx.ComposeServices {
+		if !add(compose+" logs -f "+service, "Follow the logs of "+service, "📋") ||
+			!add(compose+" up -d "+service, "Start "+service, "🐳") ||
+			!add(compose+" restart "+service, "Restart "+service, "🔄") ||
+			!add(compose+" exec "+service+" sh", "Open a shell in "+service, "💻") {
+			break
+		}
+	}
+	for _, profile := range ctx.ComposeProfiles {
+		if !add(compose+" --profile "+profile+" up -d", "Start the services of the "+profile+" profile", "🐳") {
+			break
+		}
+	}
+	return suggestions
+}
+
+// isNpmScript reports whether a command runs a package.json script
+func isNpmScript(command string) bool {
+	return strings.HasPrefix(command, "npm run ") || command == "npm test" || command == "npm start"
+}
+
+// projectProximity weighs the commands of a project distance directories
+// away from the working directory: 0.4 one step away, 0.27 two steps away
+func projectProximity(distance int) float64 {
+	return 0.8 / float64(1+distance)
+}
+
+// projectLocation names the directory of a project relative to the git
+// root, like "./web"
+func projectLocation(project appctx.Project) string {
+	dir := path.Dir(project.Evidence)
+	if dir == "." {
+		return "./"
+	}
+	return "./" + dir
+}
+
 // getWorkflowSuggestions gets common workflow suggestions
 func (e *Engine) getWorkflowSuggestions(ctx *appctx.Context, query string) []Suggestion {
 	var suggestions []Suggestion
 
 	// Quick actions based on context
 	if ctx.IsGitRepo {
-		if len(ctx.GitStatus.ModifiedFiles) > 0 || len(ctx.GitStatus.StagedFiles) > 0 {
+		status := ctx.GitStatus
+		switch {
+		case status.Operation != "":
+			// Committing in the middle of a rebase or merge is rarely meant
+		case len(status.ModifiedFiles) > 0 || len(status.StagedFiles) > 0:
 			suggestions = append(suggestions, Suggestion{
 				Command:      "git add . && git commit -m \"update\"",
 				Description:  "Quick commit all changes",
@@ -451,16 +843,17 @@ func (e *Engine) getWorkflowSuggestions(ctx *appctx.Context, query string) []Sug
 				Icon:         "⚡",
 				ContextMatch: 0.8,
 			})
-		}
-		if ctx.GitStatus.Ahead > 0 {
+		case len(status.UntrackedFiles) > 0:
 			suggestions = append(suggestions, Suggestion{
-				Command:      "git push",
-				Description:  "Push commits to remote",
+				Command:      "git add -A",
+				Description:  fmt.Sprintf("Stage %s", pluralize(len(status.UntrackedFiles), "new file")),
 				Source:       "⚡ Quick",
-				Icon:         "🚀",
-				ContextMatch: 0.9,
+				Icon:         "➕",
+				ContextMatch: 0.85,
 			})
 		}
+		suggestions = append(suggestions, gitOperationSuggestions(status)...)
+		suggestions = append(suggestions, gitSyncSuggestions(status)...)
 	}
 
 	// Filter by query
@@ -471,10 +864,262 @@ func (e *Engine) getWorkflowSuggestions(ctx *appctx.Context, query string) []Sug
 	return e.filterSuggestions(suggestions, query)
 }
 
+// testCommands runs a project type's tests, for QuickActions
+var testCommands = map[string]string{
+	"go":     "go test ./...",
+	"nodejs": "npm test",
+	"python": "python -m pytest",
+	"rust":   "cargo test",
+	"maven":  "mvn test",
+	"gradle": "./gradlew test",
+	"php":    "vendor/bin/phpunit",
+	"ruby":   "bundle exec rspec",
+	"dotnet": "dotnet test",
+}
+
+// QuickActions returns the one-key actions for the context: the git
+// workflow steps (commit all, push, pull, finishing a rebase) followed by
+// running the project's tests and starting its Compose services
+func (e *Engine) QuickActions(ctx *appctx.Context) []Suggestion {
+	actions := e.getWorkflowSuggestions(ctx, "")
+	if test, ok := testCommands[ctx.ProjectType]; ok {
+		actions = append(actions, Suggestion{
+			Command:      test,
+			Description:  "Run the tests",
+			Source:       "⚡ Quick",
+			Icon:         "🧪",
+			ContextMatch: 0.8,
+		})
+	}
+	if ctx.ComposeCommand != "" {
+		actions = append(actions, Suggestion{
+			Command:      ctx.ComposeCommand + " up -d",
+			Description:  "Start the Compose services",
+			Source:       "⚡ Quick",
+			Icon:         "🐳",
+			ContextMatch: 0.8,
+		})
+	}
+	return actions
+}
+
+// gitOperationSuggestions suggests continuing or aborting the merge, rebase,
+// cherry-pick or revert in progress
+func gitOperationSuggestions(status appctx.GitStatus) []Suggestion {
+	if status.Operation == "" {
+		return nil
+	}
+	name := status.Operation
+	if status.Operation == appctx.GitRebase && status.Branch != "" {
+		name += " of " + status.Branch
+	}
+
+	// With conflicts left, aborting is as likely as continuing
+	continueMatch := 1.0
+	if status.HasConflicts {
+		continueMatch = 0.9
+	}
+	return []Suggestion{
+		{
+			Command:      "git " + status.Operation + " --continue",
+			Description:  "Continue the " + name + " once conflicts are resolved and staged",
+			Source:       "⚡ Quick",
+			Icon:         "▶️",
+			ContextMatch: continueMatch,
+		},
+		{
+			Command:      "git " + status.Operation + " --abort",
+			Description:  "Abort the " + name + " and go back to where it started",
+			Source:       "⚡ Quick",
+			Icon:         "⏹️",
+			ContextMatch: 0.95,
+		},
+	}
+}
+
+// gitSyncSuggestions suggests how to bring the branch in line with its
+// upstream: resolve conflicts first, stash changes a pull would refuse to
+// overwrite, then push, pull, or rebase/merge when the branches diverged.
+// A branch without an upstream is published with push -u.
+func gitSyncSuggestions(status appctx.GitStatus) []Suggestion {
+	var suggestions []Suggestion
+
+	if status.HasConflicts {
+		suggestions = append(suggestions, Suggestion{
+			Command:      "git diff --name-only --diff-filter=U",
+			Description:  fmt.Sprintf("Resolve conflicts in %s", pluralize(len(status.ConflictedFiles), "file")),
+			Source:       "⚡ Quick",
+			Icon:         "⚠️",
+			ContextMatch: 1.0,
+		})
+	}
+
+	// The operation in progress has to be finished before anything else
+	if status.Operation != "" {
+		return suggestions
+	}
+
+	if len(status.PullBlockingFiles) > 0 {
+		suggestions = append(suggestions, Suggestion{
+			Command:      "git stash",
+			Description:  "Stash local changes that block pulling: " + strings.Join(status.PullBlockingFiles, ", "),
+			Source:       "⚡ Quick",
+			Icon:         "📦",
+			ContextMatch: 0.95,
+		})
+	}
+
+	switch {
+	case status.Upstream == "" && status.Branch != "" && status.Remote != "":
+		suggestions = append(suggestions, Suggestion{
+			Command:      "git push -u " + status.Remote + " " + status.Branch,
+			Description:  "Publish " + status.Branch + " and track it on " + status.Remote,
+			Source:       "⚡ Quick",
+			Icon:         "🚀",
+			ContextMatch: 0.9,
+		})
+	case status.Ahead > 0 && status.Behind > 0:
+		diverged := fmt.Sprintf("%d ahead, %d behind", status.Ahead, status.Behind)
+		suggestions = append(suggestions,
+			Suggestion{
+				Command:      "git pull --rebase",
+				Description:  "Branch has diverged (" + diverged + "): rebase your commits onto remote",
+				Source:       "⚡ Quick",
+				Icon:         "🔀",
+				ContextMatch: 0.9,
+			},
+			Suggestion{
+				Command:      "git pull --no-rebase",
+				Description:  "Branch has diverged (" + diverged + "): merge remote into your branch",
+				Source:       "⚡ Quick",
+				Icon:         "🔀",
+				ContextMatch: 0.85,
+			},
+		)
+	case status.Ahead > 0:
+		suggestions = append(suggestions, Suggestion{
+			Command:      "git push",
+			Description:  "Push commits to remote",
+			Source:       "⚡ Quick",
+			Icon:         "🚀",
+			ContextMatch: 0.9,
+		})
+	case status.Behind > 0:
+		suggestions = append(suggestions, Suggestion{
+			Command:      "git pull --rebase",
+			Description:  fmt.Sprintf("Pull %s from %s", pluralize(status.Behind, "new commit"), upstreamName(status)),
+			Source:       "⚡ Quick",
+			Icon:         "⬇️",
+			ContextMatch: 0.9,
+		})
+	}
+
+	return suggestions
+}
+
+// upstreamName names the upstream in descriptions
+func upstreamName(status appctx.GitStatus) string {
+	if status.Upstream != "" {
+		return status.Upstream
+	}
+	return "remote"
+}
+
+// SuggestNext returns the commands the user most often runs right after
+// lastCommand, ranked by how large a share of its follow-ups they make up.
+func (e *Engine) SuggestNext(ctx context.Context, lastCommand string) ([]Suggestion, error) {
+	storage := e.store()
+	if storage == nil {
+		return nil, nil
+	}
+
+	lastCommand = strings.TrimSpace(lastCommand)
+	sequences, err := storage.GetNextCommands(ctx, lastCommand, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	total := 0
+	for _, seq := range sequences {
+		total += seq.Count
+	}
+
+	suggestions := make([]Suggestion, 0, len(sequences))
+	for _, seq := range sequences {
+		if seq.Count < minSequenceCount {
+			continue
+		}
+		share := float64(seq.Count) / float64(total)
+		suggestions = append(suggestions, Suggestion{
+			Command:      seq.Next,
+			Description:  fmt.Sprintf("Usually follows %s (%s)", lastCommand, formatCount(seq.Count)),
+			Score:        share*2.0 + math.Log1p(float64(seq.Count))*0.5,
+			Source:       "⚡ Next",
+			Icon:         "⚡",
+			UsageCount:   seq.Count,
+			LastUsed:     seq.LastSeen,
+			ContextMatch: share,
+		})
+	}
+
+	return suggestions, nil
+}
+
+// minSequenceCount is how often a follow-up must have been seen before it is
+// suggested, so one-off pairs do not show up
+const minSequenceCount = 2
+
+// getNextSuggestions suggests follow-ups to the command the user ran last
+func (e *Engine) getNextSuggestions(ctx context.Context, query string, limit int) []Suggestion {
+	lastCommand := e.lastCommand(ctx)
+	if lastCommand == "" {
+		return nil
+	}
+
+	suggestions, err := e.SuggestNext(ctx, lastCommand)
+	if err != nil || len(suggestions) == 0 {
+		return nil
+	}
+	if query != "" {
+		suggestions = e.filterSuggestions(suggestions, query)
+	}
+	return e.limitSuggestions(suggestions, limit)
+}
+
+// lastCommand returns the previous command line of the current shell session
+// as recorded by the shell hook, falling back to the newest history entry.
+// wut's own invocations are skipped.
+func (e *Engine) lastCommand(ctx context.Context) string {
+	if last, err := shell.ReadLastCommand(shell.CurrentLastCommandPath()); err == nil && !isWutCommand(last.Command) {
+		return last.Command
+	}
+
+	storage := e.store()
+	if storage == nil {
+		return ""
+	}
+	entries, err := storage.GetHistory(ctx, 20)
+	if err != nil {
+		return ""
+	}
+	for _, entry := range entries {
+		if !isWutCommand(entry.Command) {
+			return strings.TrimSpace(entry.Command)
+		}
+	}
+	return ""
+}
+
+func isWutCommand(command string) bool {
+	fields := strings.Fields(command)
+	return len(fields) > 0 && strings.EqualFold(fields[0], "wut")
+}
+
 // getCatalogSuggestions broadens discovery using the local command catalog and
 // TLDR database so smart search can surface commands the user has not used yet.
 func (e *Engine) getCatalogSuggestions(ctx context.Context, query string, limit int) []Suggestion {
-	if e.storage == nil || strings.TrimSpace(query) == "" {
+	storage := e.store()
+	if storage == nil || strings.TrimSpace(query) == "" {
 		return nil
 	}
 
@@ -487,7 +1132,7 @@ func (e *Engine) getCatalogSuggestions(ctx context.Context, query string, limit
 		suggestionMap[s.Command] = s
 	}
 
-	commands, err := e.storage.ListCommands(0)
+	commands, err := storage.ListCommands(0)
 	if err == nil {
 		for _, match := range e.matcher.MatchMultiple(query, commands) {
 			addSuggestion(Suggestion{
@@ -508,7 +1153,7 @@ func (e *Engine) getCatalogSuggestions(ctx context.Context, query string, limit
 	if limit > 0 {
 		searchPageLimit = limit * 6
 	}
-	pages, err := e.storage.SearchLocalLimited(query, searchPageLimit)
+	pages, err := storage.SearchLocalLimited(query, searchPageLimit)
 	if err == nil {
 		for _, page := range pages {
 			match := e.matcher.Match(strings.ToLower(query), strings.ToLower(page.Name+" "+page.Description))
@@ -542,23 +1187,220 @@ func (e *Engine) getCatalogSuggestions(ctx context.Context, query string, limit
 	return results
 }
 
+// commonCommands are the commands fuzzy suggestions are drawn from
+var commonCommands = []string{
+	"git", "docker", "kubectl", "npm", "yarn", "cargo", "go",
+	"ls", "cd", "pwd", "cat", "grep", "find", "awk", "sed",
+	"ssh", "scp", "curl", "wget", "ping", "netstat",
+	"tar", "zip", "unzip", "gzip",
+	"chmod", "chown", "mkdir", "rm", "cp", "mv",
+	"ps", "top", "htop", "kill",
+	"vim", "nvim", "code", "nano",
+	"make", "cmake", "gcc", "g++",
+}
+
+// commandRoots are first words that make a query a command prefix rather
+// than a description of what to do
+var commandRoots = func() map[string]bool {
+	roots := map[string]bool{
+		"apt": true, "brew": true, "helm": true, "pip": true, "python": true,
+		"python3": true, "sudo": true, "systemctl": true, "terraform": true,
+		"wut": true,
+	}
+	for _, command := range commonCommands {
+		roots[command] = true
+	}
+	return roots
+}()
+
+// maxSemanticScore caps the intent score a semantic match brings, so an exact
+// phrase match cannot bury commands from history
+const maxSemanticScore = 5.0
+
+// IsNaturalLanguage reports whether query describes a task in words, such as
+// "free up docker disk space", instead of starting a command: it has three or
+// more words and does not begin with a known command.
+func IsNaturalLanguage(query string) bool {
+	words := strings.Fields(strings.ToLower(query))
+	return len(words) >= 3 && !commandRoots[words[0]]
+}
+
+// minTaskConfidence is the semantic confidence a two-word query needs to be
+// taken as a task, such as "compress folder"
+const minTaskConfidence = 0.8
+
+// IsTaskQuery reports whether query should be answered by the semantic intent
+// engine rather than matched against command names: it is natural language,
+// or two words that do not begin with a known command and that an intent
+// matches with confidence. A single word never is.
+func IsTaskQuery(query string) bool {
+	return isTaskQuery(SpellcheckQuery(query))
+}
+
+// isTaskQuery is IsTaskQuery of a query already spell-checked
+func isTaskQuery(query string) bool {
+	if IsNaturalLanguage(query) {
+		return true
+	}
+	words := strings.Fields(strings.ToLower(query))
+	if len(words) != 2 || commandRoots[words[0]] {
+		return false
+	}
+	matches := corrector.QuerySemantic(query, 1)
+	return len(matches) > 0 && matches[0].Confidence >= minTaskConfidence
+}
+
+// SpellcheckQuery corrects the misspelled words of a natural-language query
+// before the semantic intent engine scores it, when
+// corrector.semantic_spellcheck is on
+func SpellcheckQuery(query string) string {
+	return spellcheckQuery(query, config.Get().Corrector)
+}
+
+// spellcheckQuery is SpellcheckQuery with the corrector settings in cfg
+func spellcheckQuery(query string, cfg config.CorrectorConfig) string {
+	if !cfg.SemanticSpellcheck {
+		return query
+	}
+	c := corrector.New()
+	c.SetKeyboardAware(cfg.KeyboardAware)
+	return c.SpellcheckQuery(query)
+}
+
+// getSemanticSuggestions translates a natural-language query into commands
+// with the semantic intent engine. With an intent category set, any query is
+// translated, within that category.
+func (e *Engine) getSemanticSuggestions(query string, limit int) []Suggestion {
+	query = spellcheckQuery(query, e.cfg.Corrector)
+	category := e.category()
+	if category == "" && !isTaskQuery(query) {
+		return nil
+	}
+	if limit <= 0 || limit > 5 {
+		limit = 5
+	}
+
+	matches := corrector.QuerySemanticInCategory(query, category, limit)
+	suggestions := make([]Suggestion, 0, len(matches))
+	for _, match := range matches {
+		suggestions = append(suggestions, Suggestion{
+			Command:      match.Command,
+			Description:  match.Intent.Description,
+			Score:        math.Min(match.Score, maxSemanticScore),
+			Source:       "🧠 Semantic",
+			Icon:         "🧠",
+			ContextMatch: 0.1,
+			Confidence:   match.Confidence,
+		})
+	}
+	return suggestions
+}
+
+// Scores of alias suggestions: an alias named by the query outranks
+// everything but a strong history match
+const (
+	aliasExactScore  = 4.0
+	aliasPrefixScore = 2.0
+)
+
+// getAliasSuggestions suggests the expansion of the user aliases whose name
+// the query starts with or spells out, passing on the query's other words as
+// arguments
+func (e *Engine) getAliasSuggestions(ctx context.Context, query string) []Suggestion {
+	words := strings.Fields(query)
+	storage := e.store()
+	if storage == nil || len(words) == 0 {
+		return nil
+	}
+	aliases, err := storage.ListAliases(ctx)
+	if err != nil {
+		return nil
+	}
+
+	name := strings.ToLower(words[0])
+	var suggestions []Suggestion
+	for _, a := range aliases {
+		lower := strings.ToLower(a.Name)
+		score := aliasExactScore
+		switch {
+		case lower == name:
+		case len(words) == 1 && strings.HasPrefix(lower, name):
+			score = aliasPrefixScore
+		default:
+			continue
+		}
+
+		command := a.Command
+		if len(words) > 1 {
+			command = db.ExpandAlias(a.Command, words[1:])
+		}
+		description := "Alias " + a.Name
+		if a.Description != "" {
+			description += ": " + a.Description
+		}
+		suggestions = append(suggestions, Suggestion{
+			Command:      command,
+			Description:  description,
+			Score:        score,
+			Source:       "🔖 Alias",
+			Icon:         "🔖",
+			ContextMatch: 0.2,
+		})
+	}
+	return suggestions
+}
+
+// bookmarkScore is the boost a bookmarked command gets. Bookmarks usually
+// match other sources too, and the scores add up when they are merged.
+const bookmarkScore = 3.0
+
+// getBookmarkSuggestions suggests the bookmarked commands matching the query
+// by command, tag or note. Without a query every bookmark is suggested.
+func (e *Engine) getBookmarkSuggestions(ctx context.Context, query string) []Suggestion {
+	storage := e.store()
+	if storage == nil {
+		return nil
+	}
+	bookmarks, err := storage.GetBookmarks(ctx)
+	if err != nil {
+		return nil
+	}
+
+	query = strings.TrimSpace(query)
+	queryLower := strings.ToLower(query)
+	var suggestions []Suggestion
+	for _, b := range bookmarks {
+		matched := query == "" ||
+			strings.Contains(strings.ToLower(b.Command), queryLower) ||
+			strings.Contains(strings.ToLower(b.Notes), queryLower) ||
+			b.HasTag(query) ||
+			e.matcher.Match(query, b.Command).Matched
+		if !matched {
+			continue
+		}
+
+		description := b.Notes
+		if description == "" && len(b.Tags) > 0 {
+			description = "Bookmarked: " + strings.Join(b.Tags, ", ")
+		}
+		suggestions = append(suggestions, Suggestion{
+			Command:      b.Command,
+			Description:  description,
+			Score:        bookmarkScore,
+			Source:       "⭐ Bookmark",
+			Icon:         "⭐",
+			ContextMatch: 0.2,
+		})
+	}
+	return suggestions
+}
+
 // getFuzzySuggestions gets fuzzy-matched suggestions from common commands
 func (e *Engine) getFuzzySuggestions(query string, limit int) []Suggestion {
 	if query == "" {
 		return nil
 	}
 
-	commonCommands := []string{
-		"git", "docker", "kubectl", "npm", "yarn", "cargo", "go",
-		"ls", "cd", "pwd", "cat", "grep", "find", "awk", "sed",
-		"ssh", "scp", "curl", "wget", "ping", "netstat",
-		"tar", "zip", "unzip", "gzip",
-		"chmod", "chown", "mkdir", "rm", "cp", "mv",
-		"ps", "top", "htop", "kill",
-		"vim", "nvim", "code", "nano",
-		"make", "cmake", "gcc", "g++",
-	}
-
 	results := e.matcher.MatchMultiple(query, commonCommands)
 
 	suggestions := make([]Suggestion, 0, len(results))
@@ -575,6 +1417,10 @@ func (e *Engine) getFuzzySuggestions(query string, limit int) []Suggestion {
 	return suggestions
 }
 
+// minFilterCoverage is how much of a query a command's words must cover for
+// filterSuggestions to keep it when the query as a whole does not match
+const minFilterCoverage = 0.75
+
 // filterSuggestions filters suggestions by query
 func (e *Engine) filterSuggestions(suggestions []Suggestion, query string) []Suggestion {
 	if query == "" {
@@ -582,6 +1428,8 @@ func (e *Engine) filterSuggestions(suggestions []Suggestion, query string) []Sug
 	}
 
 	queryLower := strings.ToLower(query)
+	queryProfile := commandsearch.ParseQuery(query)
+	explain := e.explaining()
 	var filtered []Suggestion
 
 	for _, s := range suggestions {
@@ -589,14 +1437,21 @@ func (e *Engine) filterSuggestions(suggestions []Suggestion, query string) []Sug
 		descLower := strings.ToLower(s.Description)
 		cmdMatch := e.matcher.Match(queryLower, cmdLower)
 		descMatch := e.matcher.Match(queryLower, descLower)
+		// Word by word, so misspelt and reordered words still match
+		coverage := commandsearch.Coverage(queryProfile, commandsearch.BuildProfile(s.Command))
 
-		if cmdMatch.Matched || descMatch.Matched || strings.Contains(cmdLower, queryLower) || strings.Contains(descLower, queryLower) {
+		if cmdMatch.Matched || descMatch.Matched || strings.Contains(cmdLower, queryLower) || strings.Contains(descLower, queryLower) || coverage >= minFilterCoverage {
+			var b ScoreBreakdown
 			if strings.HasPrefix(cmdLower, queryLower) {
-				s.Score += e.weights.PrefixMatch
+				b.Prefix = e.weights.PrefixMatch
 			} else if strings.Contains(cmdLower, queryLower) {
-				s.Score += e.weights.ContainsMatch
+				b.Contains = e.weights.ContainsMatch
+			}
+			b.Fuzzy = max(cmdMatch.Score, descMatch.Score*0.6, coverage) * e.weights.FuzzyMatch
+			s.Score += b.Prefix + b.Contains + b.Fuzzy
+			if explain {
+				s.Breakdown = &b
 			}
-			s.Score += maxFloat64(cmdMatch.Score, descMatch.Score*0.6) * e.weights.FuzzyMatch
 			filtered = append(filtered, s)
 		}
 	}
@@ -604,11 +1459,29 @@ func (e *Engine) filterSuggestions(suggestions []Suggestion, query string) []Sug
 	return filtered
 }
 
-// scoreAndSort scores and sorts suggestions
-func (e *Engine) scoreAndSort(suggestions []Suggestion, query string, ctx *appctx.Context) []Suggestion {
+// commandBiases returns the multipliers of the commands boosted or buried
+// with wut train, or nil without storage
+func (e *Engine) commandBiases(ctx context.Context) db.CommandBiases {
+	storage := e.store()
+	if storage == nil {
+		return nil
+	}
+	// Suggestions collected before a timeout are still ranked with them
+	biases, err := storage.CommandBiasMultipliers(context.WithoutCancel(ctx))
+	if err != nil {
+		return nil
+	}
+	return biases
+}
+
+// scoreAndSort scores and sorts suggestions. biases scale the score of
+// trained commands and may be nil.
+func (e *Engine) scoreAndSort(suggestions []Suggestion, query string, ctx *appctx.Context, biases db.CommandBiases) []Suggestion {
+	explain := e.explaining()
+
 	// Score each suggestion
 	for i := range suggestions {
-		suggestions[i] = e.calculateFinalScore(suggestions[i], query, ctx)
+		suggestions[i] = e.calculateFinalScore(suggestions[i], query, ctx, explain, biases.Multiplier(suggestions[i].Command))
 	}
 
 	// Sort by score (descending)
@@ -625,44 +1498,64 @@ func (e *Engine) scoreAndSort(suggestions []Suggestion, query string, ctx *appct
 	return suggestions
 }
 
-// calculateFinalScore calculates the final score for a suggestion
-func (e *Engine) calculateFinalScore(s Suggestion, query string, ctx *appctx.Context) Suggestion {
-	score := s.Score
+// calculateFinalScore calculates the final score for a suggestion. With
+// explain set, every contribution is recorded in s.Breakdown, on top of the
+// prefix, contains and fuzzy boosts filterSuggestions already gave it.
+// multiplier is the command's trained bias, 1 for none, and scales the sum of
+// the other contributions.
+func (e *Engine) calculateFinalScore(s Suggestion, query string, ctx *appctx.Context, explain bool, multiplier float64) Suggestion {
+	var b ScoreBreakdown
+	if s.Breakdown != nil {
+		b = *s.Breakdown
+	}
+	b.Source = s.Score - b.Prefix - b.Contains - b.Fuzzy
+	s.MatchSpans = nil
+	if query != "" {
+		s.MatchSpans = e.matcher.MatchSpans(query, s.Command)
+	}
 
 	// Boost perfect matches
 	if query != "" && strings.EqualFold(s.Command, query) {
-		score += e.weights.ExactMatch
+		b.Exact += e.weights.ExactMatch
 		s.IsPerfectMatch = true
 	} else if query != "" {
 		match := e.matcher.Match(query, s.Command)
 		if match.Matched {
-			score += match.Score * e.weights.FuzzyMatch
+			b.Fuzzy += match.Score * e.weights.FuzzyMatch
 			if match.MatchStart == 0 {
-				score += e.weights.PrefixMatch * 0.5
+				b.Prefix += e.weights.PrefixMatch * 0.5
 			}
 		}
 	}
 
 	// Context relevance boost
-	score += s.ContextMatch * e.weights.ContextRelevance
+	b.Context += s.ContextMatch * e.weights.ContextRelevance
 
 	if s.UsageCount > 0 {
-		score += math.Min(1.0, math.Log1p(float64(s.UsageCount))/3.0) * e.weights.HistoryFreq
+		b.History += math.Min(1.0, math.Log1p(float64(s.UsageCount))/3.0) * e.weights.HistoryFreq
 	}
 
 	if !s.LastUsed.IsZero() {
 		hoursSince := time.Since(s.LastUsed).Hours()
 		switch {
 		case hoursSince < 24:
-			score += e.weights.Recency
+			b.Recency += e.weights.Recency
 		case hoursSince < 24*7:
-			score += e.weights.Recency * 0.6
+			b.Recency += e.weights.Recency * 0.6
 		case hoursSince < 24*30:
-			score += e.weights.Recency * 0.3
+			b.Recency += e.weights.Recency * 0.3
 		}
 	}
 
-	s.Score = score
+	if multiplier != 1 {
+		b.Bias = b.Total() * (multiplier - 1)
+	}
+
+	s.Score = b.Total()
+	s.Breakdown = nil
+	if explain {
+		s.Breakdown = &b
+	}
 	return s
 }
 
@@ -679,10 +1572,14 @@ func (e *Engine) limitSuggestions(suggestions []Suggestion, limit int) []Suggest
 
 // formatCount formats a count for display
 func formatCount(n int) string {
+	return pluralize(n, "time")
+}
+
+func pluralize(n int, noun string) string {
 	if n == 1 {
-		return "1 time"
+		return "1 " + noun
 	}
-	return fmt.Sprintf("%d times", n)
+	return fmt.Sprintf("%d %ss", n, noun)
 }
 
 func mergeSuggestion(existing, incoming Suggestion) Suggestion {
@@ -693,6 +1590,14 @@ func mergeSuggestion(existing, incoming Suggestion) Suggestion {
 	}
 	existing.ContextMatch = maxFloat64(existing.ContextMatch, incoming.ContextMatch)
 	existing.IsPerfectMatch = existing.IsPerfectMatch || incoming.IsPerfectMatch
+	existing.Confidence = maxFloat64(existing.Confidence, incoming.Confidence)
+	if incoming.Breakdown != nil {
+		merged := *incoming.Breakdown
+		if existing.Breakdown != nil {
+			merged = existing.Breakdown.add(merged)
+		}
+		existing.Breakdown = &merged
+	}
 
 	if existing.Description == "" || (incoming.Description != "" && len(incoming.Description) < len(existing.Description)) {
 		existing.Description = incoming.Description
@@ -785,11 +1690,31 @@ func historySummarySourceBoost(summary db.HistoryCommandSummary, currentOS, curr
 	return boost
 }
 
+// minFailureRuns and failureRateThreshold are how many recorded runs a
+// command needs, and how many of them must fail, before it ranks lower
+const (
+	minFailureRuns       = 3
+	failureRateThreshold = 0.5
+)
+
+// historySummaryFailurePenalty ranks commands that usually fail lower, once
+// history.track_timing has recorded enough of their runs
+func historySummaryFailurePenalty(summary db.HistoryCommandSummary) float64 {
+	rate := summary.FailureRate()
+	if summary.Runs < minFailureRuns || rate < failureRateThreshold {
+		return 0
+	}
+	return rate * 1.5
+}
+
 func historySummaryDescription(summary db.HistoryCommandSummary, profile commandsearch.Profile) string {
 	parts := []string{fmt.Sprintf("Used %s", formatCount(summary.UsageCount))}
 	if age := formatRelativeAge(summary.LastUsed); age != "" {
 		parts = append(parts, age)
 	}
+	if historySummaryFailurePenalty(summary) > 0 {
+		parts = append(parts, fmt.Sprintf("failed %d of %s", summary.Failures, pluralize(summary.Runs, "run")))
+	}
 	if profile.Intent != "" && profile.Intent != profile.Executable && profile.Intent != profile.Normalized {
 		parts = append(parts, "intent: "+profile.Intent)
 	}
@@ -862,14 +1787,15 @@ func shouldSuppressSmartHistoryCommand(query commandsearch.Query, raw string, pr
 		return false
 	}
 
-	if query.Executable != "" && query.Executable != profile.Executable {
-		if !intentMentionsQueryExecutable(query, profile) {
+	// The executable and subcommand may be misspelt or typed in another order
+	if query.Executable != "" && !commandsearch.SameToken(query.Executable, profile.Executable) {
+		if !intentMentionsQueryExecutable(query, profile) && !queryMentions(query, profile.Executable) {
 			return true
 		}
 	}
 
 	if query.Subcommand != "" {
-		if profile.Subcommand != "" && query.Subcommand != profile.Subcommand {
+		if profile.Subcommand != "" && !commandsearch.SameToken(query.Subcommand, profile.Subcommand) && !queryMentions(query, profile.Subcommand) {
 			return true
 		}
 		if profile.Subcommand == "" && !rootCommandMentionsSubcommand(raw, query.Executable, query.Subcommand) {
@@ -891,6 +1817,16 @@ func shouldSuppressSmartHistoryCommand(query commandsearch.Query, raw string, pr
 	}
 }
 
+// queryMentions reports whether any query token names token
+func queryMentions(query commandsearch.Query, token string) bool {
+	for _, t := range query.Tokens {
+		if commandsearch.SameToken(t, token) {
+			return true
+		}
+	}
+	return false
+}
+
 func intentMentionsQueryExecutable(query commandsearch.Query, profile commandsearch.Profile) bool {
 	if query.Executable == "" {
 		return false
@@ -992,12 +1928,127 @@ func (e *Engine) Preload(ctx context.Context, ctxData *appctx.Context) {
 	}()
 }
 
+// contextTTL bounds how long an analysed context is reused. Before the TTL
+// expires the context is still rebuilt as soon as the directory's mtime, the
+// commit HEAD points to or the git index changes, which covers creating and
+// removing files, checkouts, commits and staging. Edits inside existing files
+// show up once the TTL expires or InvalidateContext is called.
+const contextTTL = 30 * time.Second
+
+// gitFingerprint sums up the git state the suggestions depend on, so cached
+// suggestions are not reused once a commit, checkout, pull or staging
+// changed it
+func gitFingerprint(c *appctx.Context) string {
+	if !c.IsGitRepo {
+		return ""
+	}
+	status := c.GitStatus
+	return fmt.Sprintf("%s/%s/s%d.m%d.u%d/+%d-%d/%t/%s",
+		c.GitBranch, status.Upstream,
+		len(status.StagedFiles), len(status.ModifiedFiles), len(status.UntrackedFiles),
+		status.Ahead, status.Behind, status.HasConflicts, status.Operation)
+}
+
+// cachedContext is an analysed context and the state it was built from
+type cachedContext struct {
+	data  *appctx.Context
+	stamp contextStamp
+}
+
+// contextStamp is the state that invalidates a cached context; every field
+// can be read without running git
+type contextStamp struct {
+	dirModTime   time.Time
+	head         string
+	indexModTime time.Time
+}
+
+func readContextStamp(dir, gitRoot string) contextStamp {
+	var stamp contextStamp
+	if info, err := os.Stat(dir); err == nil {
+		stamp.dirModTime = info.ModTime()
+	}
+	if gitRoot != "" {
+		stamp.head = appctx.GitHead(gitRoot)
+		if info, err := os.Stat(filepath.Join(gitRoot, ".git", "index")); err == nil {
+			stamp.indexModTime = info.ModTime()
+		}
+	}
+	return stamp
+}
+
+// Context returns the analysed context of the current directory. The result
+// is cached per absolute directory and shared, so callers must not modify it.
+func (e *Engine) Context(ctx context.Context) (*appctx.Context, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get working directory: %w", err)
+	}
+	return e.ContextIn(ctx, dir)
+}
+
+// ContextIn is Context for dir rather than the current directory, for a
+// process answering for another one
+func (e *Engine) ContextIn(ctx context.Context, dir string) (*appctx.Context, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	gitRoot := ""
+	if cached, ok := e.ctxCache.Get(dir); ok {
+		if cached.stamp == readContextStamp(dir, cached.data.GitRoot) {
+			metrics.RecordCache("context", true)
+			return cached.data, nil
+		}
+		gitRoot = cached.data.GitRoot
+	}
+	metrics.RecordCache("context", false)
+
+	// Read the stamp before analysing so a change during the analysis
+	// invalidates the result
+	stamp := readContextStamp(dir, gitRoot)
+
+	data, err := appctx.NewAnalyzer().AnalyzeDir(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+	if data.GitRoot != gitRoot {
+		stamp = readContextStamp(dir, data.GitRoot)
+	}
+	e.ctxCache.Set(dir, cachedContext{data: data, stamp: stamp}, contextTTL)
+	return data, nil
+}
+
+// InvalidateContext drops the cached context of dir, for callers that know
+// it changed in ways the cache cannot see, such as after running a command
+func (e *Engine) InvalidateContext(dir string) {
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	e.ctxCache.Delete(dir)
+}
+
+// CommandExecuted drops what a command run through WUT in dir may have made
+// stale: the analysed context of dir and the suggestions, which follow the
+// last command and the state of the repository
+func (e *Engine) CommandExecuted(dir string) {
+	e.InvalidateContext(dir)
+	e.ClearSuggestions()
+}
+
 // ClearCache clears the suggestion cache
 func (e *Engine) ClearCache() {
 	e.cache.Clear()
 	e.ctxCache.Clear()
 }
 
+// ClearSuggestions drops the cached suggestions but keeps the analysed
+// contexts, for callers that saw the context change under them
+func (e *Engine) ClearSuggestions() {
+	e.cache.Clear()
+}
+
 // GetAutocomplete returns autocomplete suggestions
 func (e *Engine) GetAutocomplete(prefix string) []string {
 	return e.autocomplete.Suggest(prefix)
@@ -0,0 +1,746 @@
+package smart
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"wut/internal/commandsearch"
+	"wut/internal/config"
+	appctx "wut/internal/context"
+	"wut/internal/db"
+	"wut/internal/metrics"
+	"wut/internal/performance"
+)
+
+func TestMain(m *testing.M) {
+	// Keep NewEngine from loading the user's config file
+	config.Set(&config.Config{})
+	os.Exit(m.Run())
+}
+
+func TestWeightsFromConfig(t *testing.T) {
+	fuzzy, recency := 0.6, 0.0
+	got := WeightsFromConfig(config.ScoringWeightsConfig{FuzzyMatch: &fuzzy, Recency: &recency})
+
+	want := DefaultScoringWeights()
+	want.FuzzyMatch = 0.6
+	want.Recency = 0
+	if got != want {
+		t.Errorf("WeightsFromConfig() = %+v, want %+v", got, want)
+	}
+
+	config.Set(&config.Config{Smart: config.SmartConfig{Weights: config.ScoringWeightsConfig{FuzzyMatch: &fuzzy}}})
+	t.Cleanup(func() { config.Set(&config.Config{}) })
+	if w := NewEngine(nil).weights; w.FuzzyMatch != 0.6 || w.ExactMatch != DefaultScoringWeights().ExactMatch {
+		t.Errorf("NewEngine() weights = %+v, want fuzzy_match 0.6 over the defaults", w)
+	}
+}
+
+func TestContextCache(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+
+	dir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=wut", "-c", "user.email=wut@example.com"}, args...)...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	git("init", "-q")
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module x\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	git("add", ".")
+	git("commit", "-q", "-m", "initial")
+	t.Chdir(dir)
+
+	e := NewEngine(nil)
+	ctx := t.Context()
+	analyse := func() any {
+		t.Helper()
+		data, err := e.Context(ctx)
+		if err != nil {
+			t.Fatalf("Context() error = %v", err)
+		}
+		return data
+	}
+
+	first := analyse()
+	if analyse() != first {
+		t.Fatal("unchanged directory was analysed again")
+	}
+
+	// A commit moves HEAD without touching the directory itself
+	git("commit", "-q", "--allow-empty", "-m", "second")
+	afterCommit := analyse()
+	if afterCommit == first {
+		t.Error("commit did not invalidate the cached context")
+	}
+
+	// Creating a file changes the directory's mtime
+	later := time.Now().Add(time.Second)
+	if err := os.WriteFile(filepath.Join(dir, "Dockerfile"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(dir, later, later); err != nil {
+		t.Fatal(err)
+	}
+	afterCreate := analyse()
+	if afterCreate == afterCommit {
+		t.Error("new file did not invalidate the cached context")
+	}
+
+	e.InvalidateContext(".")
+	if analyse() == afterCreate {
+		t.Error("InvalidateContext did not drop the cached context")
+	}
+}
+
+// TestSuggestCacheFollowsGitState commits between two Suggest calls, which
+// leaves the query and directory as they were but must not reuse the
+// suggestions made for the dirty tree
+func TestSuggestCacheFollowsGitState(t *testing.T) {
+	const commitAll = "git add . && git commit -m \"update\""
+	e := NewEngine(nil)
+	dir := t.TempDir()
+	dirty := &appctx.Context{WorkingDir: dir, IsGitRepo: true, GitBranch: "main",
+		GitStatus: appctx.GitStatus{Branch: "main", ModifiedFiles: []string{"main.go"}}}
+	clean := &appctx.Context{WorkingDir: dir, IsGitRepo: true, GitBranch: "main",
+		GitStatus: appctx.GitStatus{Branch: "main", IsClean: true}}
+
+	suggests := func(contextData *appctx.Context, command string) bool {
+		t.Helper()
+		suggestions, err := e.Suggest(t.Context(), "", contextData, 0)
+		if err != nil {
+			t.Fatalf("Suggest() error = %v", err)
+		}
+		return slices.ContainsFunc(suggestions, func(s Suggestion) bool { return s.Command == command })
+	}
+
+	metrics.TakeUsage()
+	if !suggests(dirty, commitAll) {
+		t.Fatalf("%q not suggested for a dirty tree", commitAll)
+	}
+	if suggests(clean, commitAll) {
+		t.Errorf("%q still suggested after the commit", commitAll)
+	}
+	suggests(clean, commitAll)
+	usage := metrics.TakeUsage()
+	if hits, misses := usage.Counters["cache.suggestions.hit"], usage.Counters["cache.suggestions.miss"]; hits != 1 || misses != 2 {
+		t.Errorf("suggestion cache hits, misses = %d, %d; want 1, 2", hits, misses)
+	}
+
+	// A command run through WUT can change what is suggested next
+	e.CommandExecuted(dir)
+	suggests(clean, commitAll)
+	if misses := metrics.TakeUsage().Counters["cache.suggestions.miss"]; misses != 1 {
+		t.Errorf("CommandExecuted() left the suggestions cached, %d misses", misses)
+	}
+}
+
+func TestSuggestCacheFollowsStorageRevision(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wut.db")
+	storage, err := db.NewStorage(path)
+	if err != nil {
+		t.Fatalf("NewStorage() error = %v", err)
+	}
+	storage.Close()
+
+	e := NewEngine(nil)
+	contextData := &appctx.Context{WorkingDir: t.TempDir(), ProjectType: "unknown"}
+	// answer opens the database for one query, the way the daemon does
+	answer := func() {
+		t.Helper()
+		storage, err := db.OpenReadOnly(path, time.Second)
+		if err != nil {
+			t.Fatalf("OpenReadOnly() error = %v", err)
+		}
+		defer storage.Close()
+		e.SetStorage(storage)
+		defer e.SetStorage(nil)
+		if _, err := e.Suggest(t.Context(), "git", contextData, 0); err != nil {
+			t.Fatalf("Suggest() error = %v", err)
+		}
+	}
+	misses := func() int64 { return metrics.TakeUsage().Counters["cache.suggestions.miss"] }
+
+	metrics.TakeUsage()
+	answer()
+	answer()
+	if got := misses(); got != 1 {
+		t.Errorf("reopening an unchanged database missed the cache %d times, want 1", got)
+	}
+
+	// Recording history in between makes the cached suggestions stale
+	storage, err = db.NewStorage(path)
+	if err != nil {
+		t.Fatalf("NewStorage() error = %v", err)
+	}
+	if err := storage.AddHistory(t.Context(), "git status"); err != nil {
+		t.Fatal(err)
+	}
+	storage.Close()
+	answer()
+	if got := misses(); got != 1 {
+		t.Errorf("a written database hit the cache, %d misses", got)
+	}
+}
+
+func TestExplainRanking(t *testing.T) {
+	contextData := &appctx.Context{WorkingDir: t.TempDir(), ProjectType: "go"}
+
+	e := NewEngine(nil)
+	plain, err := e.Suggest(t.Context(), "git st", contextData, 10)
+	if err != nil {
+		t.Fatalf("Suggest() error = %v", err)
+	}
+	if len(plain) == 0 {
+		t.Fatal("Suggest() returned nothing")
+	}
+	for _, s := range plain {
+		if s.Breakdown != nil {
+			t.Fatalf("%q has a breakdown without --explain-ranking", s.Command)
+		}
+	}
+
+	e.SetExplainRanking(true)
+	explained, err := e.Suggest(t.Context(), "git st", contextData, 10)
+	if err != nil {
+		t.Fatalf("Suggest() error = %v", err)
+	}
+	if len(explained) != len(plain) {
+		t.Fatalf("explaining changed the results: %d vs %d suggestions", len(explained), len(plain))
+	}
+	for i, s := range explained {
+		if s.Command != plain[i].Command {
+			t.Errorf("explaining changed the ranking at %d: %q vs %q", i, s.Command, plain[i].Command)
+		}
+		if s.Breakdown == nil {
+			t.Errorf("%q has no breakdown", s.Command)
+			continue
+		}
+		if total := s.Breakdown.Total(); math.Abs(total-s.Score) > 1e-9 {
+			t.Errorf("%q breakdown sums to %v, score is %v", s.Command, total, s.Score)
+		}
+	}
+}
+
+func TestMatchSpans(t *testing.T) {
+	contextData := &appctx.Context{WorkingDir: t.TempDir(), ProjectType: "go"}
+	scored := NewEngine(nil).scoreAndSort([]Suggestion{
+		{Command: "git status", Score: 1},
+		{Command: "ls", Score: 1},
+	}, "git st", contextData, nil)
+
+	// Both words of the query are highlighted
+	if want := []performance.Span{{Start: 0, End: 3}, {Start: 4, End: 6}}; !reflect.DeepEqual(scored[0].MatchSpans, want) {
+		t.Errorf("git status spans = %v, want %v", scored[0].MatchSpans, want)
+	}
+	if scored[1].MatchSpans != nil {
+		t.Errorf("ls spans = %v, want none", scored[1].MatchSpans)
+	}
+}
+
+func TestContextSuggestionsFromSubprojects(t *testing.T) {
+	contextData := &appctx.Context{
+		WorkingDir:  t.TempDir(),
+		ProjectType: "go",
+		Projects: []appctx.Project{
+			{Type: "go", Evidence: "api/go.mod", Distance: 0},
+			{Type: "nodejs", Evidence: "web/package.json", Distance: 2},
+			{Type: "terraform", Evidence: "main.tf", Distance: 1},
+		},
+	}
+
+	matches := make(map[string]Suggestion)
+	for _, s := range NewEngine(nil).getContextSuggestions(contextData, "") {
+		matches[s.Command] = s
+	}
+	for command, want := range map[string]float64{"go test ./...": 1, "terraform plan": 0.4, "npm install": 0.8 / 3} {
+		s, ok := matches[command]
+		if !ok {
+			t.Errorf("%q is missing", command)
+			continue
+		}
+		if math.Abs(s.ContextMatch-want) > 1e-9 {
+			t.Errorf("%q ContextMatch = %v, want %v", command, s.ContextMatch, want)
+		}
+	}
+	if s := matches["npm install"]; !strings.HasSuffix(s.Description, "(./web)") {
+		t.Errorf("npm install description = %q, want its directory", s.Description)
+	}
+}
+
+func TestContextSuggestionsFromProjectScripts(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "package.json"), []byte(`{"scripts": {"storybook": "storybook dev"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	contextData := &appctx.Context{WorkingDir: dir, ProjectType: "nodejs"}
+
+	commands := make(map[string]Suggestion)
+	for _, s := range NewEngine(nil).getContextSuggestions(contextData, "") {
+		commands[s.Command] = s
+	}
+	if s, ok := commands["npm run storybook"]; !ok || s.Source != "🎯 Project" || s.Description != "storybook dev" {
+		t.Errorf("npm run storybook = %+v, want a project suggestion", s)
+	}
+	if _, ok := commands["npm run dev"]; ok {
+		t.Error("generic npm run dev is suggested next to the real scripts")
+	}
+	if _, ok := commands["npm install"]; !ok {
+		t.Error("npm install is missing")
+	}
+
+	// A malformed package.json falls back to the generic scripts
+	if err := os.WriteFile(filepath.Join(dir, "package.json"), []byte(`{"scripts": `), 0644); err != nil {
+		t.Fatal(err)
+	}
+	commands = make(map[string]Suggestion)
+	for _, s := range NewEngine(nil).getContextSuggestions(contextData, "") {
+		commands[s.Command] = s
+	}
+	if _, ok := commands["npm run dev"]; !ok {
+		t.Error("generic npm run dev is missing for a malformed package.json")
+	}
+}
+
+func TestComposeServiceSuggestions(t *testing.T) {
+	contextData := &appctx.Context{
+		WorkingDir:      t.TempDir(),
+		ProjectType:     "docker",
+		ComposeCommand:  "docker compose",
+		ComposeServices: []string{"api", "db"},
+		ComposeProfiles: []string{"tools"},
+	}
+
+	commands := make(map[string]bool)
+	for _, s := range NewEngine(nil).getContextSuggestions(contextData, "") {
+		commands[s.Command] = true
+	}
+	for _, want := range []string{"docker compose logs -f api", "docker compose exec db sh", "docker compose --profile tools up -d", "docker compose up -d"} {
+		if !commands[want] {
+			t.Errorf("%q is missing", want)
+		}
+	}
+	if commands["docker-compose up -d"] {
+		t.Error("legacy docker-compose spelling is suggested with the v2 plugin")
+	}
+
+	for i := range 20 {
+		contextData.ComposeServices = append(contextData.ComposeServices, fmt.Sprintf("worker%d", i))
+	}
+	if got := len(composeServiceSuggestions(contextData)); got != maxComposeSuggestions {
+		t.Errorf("%d Compose suggestions, want the cap of %d", got, maxComposeSuggestions)
+	}
+}
+
+func TestGitStateSuggestions(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  appctx.GitStatus
+		want    []string
+		notWant []string
+	}{
+		{
+			name:    "rebase in progress",
+			status:  appctx.GitStatus{Operation: appctx.GitRebase, Branch: "feature", ModifiedFiles: []string{"a.go"}, Behind: 2},
+			want:    []string{"git rebase --continue", "git rebase --abort"},
+			notWant: []string{"git add . && git commit -m \"update\"", "git pull --rebase"},
+		},
+		{
+			name:   "merge in progress",
+			status: appctx.GitStatus{Operation: appctx.GitMerge, HasConflicts: true, ConflictedFiles: []string{"a.go"}},
+			want:   []string{"git diff --name-only --diff-filter=U", "git merge --continue", "git merge --abort"},
+		},
+		{
+			name:    "branch without upstream",
+			status:  appctx.GitStatus{Branch: "fix/login", Remote: "origin"},
+			want:    []string{"git push -u origin fix/login"},
+			notWant: []string{"git push"},
+		},
+		{
+			name:   "behind upstream",
+			status: appctx.GitStatus{Branch: "main", Upstream: "origin/main", Behind: 3},
+			want:   []string{"git pull --rebase"},
+		},
+		{
+			name:    "untracked files only",
+			status:  appctx.GitStatus{Branch: "main", Upstream: "origin/main", UntrackedFiles: []string{"new.go"}},
+			want:    []string{"git add -A"},
+			notWant: []string{"git add . && git commit -m \"update\""},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			contextData := &appctx.Context{IsGitRepo: true, GitStatus: tt.status}
+			got := make(map[string]float64)
+			for _, s := range NewEngine(nil).getWorkflowSuggestions(contextData, "") {
+				got[s.Command] = s.ContextMatch
+			}
+			for _, want := range tt.want {
+				if match, ok := got[want]; !ok || match < 0.85 {
+					t.Errorf("%q missing or ranked low (%v) in %v", want, match, got)
+				}
+			}
+			for _, command := range tt.notWant {
+				if _, ok := got[command]; ok {
+					t.Errorf("%q is suggested", command)
+				}
+			}
+		})
+	}
+}
+
+func TestNaturalLanguageSuggestions(t *testing.T) {
+	contextData := &appctx.Context{WorkingDir: t.TempDir(), ProjectType: "unknown"}
+	e := NewEngine(nil)
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"undo last commit", "git reset --soft HEAD~1"},
+		{"what's listening on port 3000", "ss -tlnp | grep 3000"},
+		{"free up docker disk space", "docker system prune -a"},
+		{"show running containers", "docker ps"},
+		{"how do I stop all docker containers", "docker stop $(docker ps -q)"},
+		{"compress folder", "tar -czf archive.tar.gz <directory>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			suggestions, err := e.Suggest(t.Context(), tt.query, contextData, 5)
+			if err != nil {
+				t.Fatalf("Suggest() error = %v", err)
+			}
+			if len(suggestions) == 0 {
+				t.Fatal("Suggest() returned nothing")
+			}
+			top := suggestions[0]
+			if top.Command != tt.want {
+				t.Errorf("top suggestion = %q, want %q", top.Command, tt.want)
+			}
+			if !strings.Contains(top.Source, "Semantic") {
+				t.Errorf("top suggestion source = %q, want a semantic match", top.Source)
+			}
+			if top.Confidence <= 0 {
+				t.Errorf("top suggestion confidence = %v, want the semantic confidence", top.Confidence)
+			}
+		})
+	}
+}
+
+func TestIntentCategorySuggestions(t *testing.T) {
+	contextData := &appctx.Context{WorkingDir: t.TempDir(), ProjectType: "unknown"}
+	e := NewEngine(nil)
+	e.SetIntentCategory("docker")
+
+	suggestions, err := e.Suggest(t.Context(), "show logs", contextData, 5)
+	if err != nil {
+		t.Fatalf("Suggest() error = %v", err)
+	}
+	var semantic []string
+	for _, s := range suggestions {
+		if strings.Contains(s.Source, "Semantic") {
+			semantic = append(semantic, s.Command)
+		}
+	}
+	if len(semantic) == 0 {
+		t.Fatal("Suggest() returned no semantic suggestions for a category")
+	}
+	for _, command := range semantic {
+		if !strings.HasPrefix(command, "docker ") {
+			t.Errorf("semantic suggestion %q is not a docker command", command)
+		}
+	}
+}
+
+func TestIsTaskQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{"how do I stop all docker containers", true},
+		{"compress folder", true},
+		{"undo commit", true},
+		{"free space", false}, // too vague to take over from the page lookup
+		{"docker ps", false},
+		{"make build", false},
+		{"tar", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsTaskQuery(tt.query); got != tt.want {
+			t.Errorf("IsTaskQuery(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestSemanticSpellcheck(t *testing.T) {
+	t.Cleanup(func() { config.Set(&config.Config{}) })
+	contextData := &appctx.Context{WorkingDir: t.TempDir(), ProjectType: "unknown"}
+	semantic := func() []string {
+		suggestions, err := NewEngine(nil).Suggest(t.Context(), "lst runing containrs", contextData, 5)
+		if err != nil {
+			t.Fatalf("Suggest() error = %v", err)
+		}
+		var commands []string
+		for _, s := range suggestions {
+			if strings.Contains(s.Source, "Semantic") {
+				commands = append(commands, s.Command)
+			}
+		}
+		return commands
+	}
+
+	config.Set(&config.Config{})
+	if IsTaskQuery("runing containrs") {
+		t.Error("a misspelled query was corrected with corrector.semantic_spellcheck off")
+	}
+
+	cfg := &config.Config{}
+	cfg.Corrector.SemanticSpellcheck = true
+	config.Set(cfg)
+	if !IsTaskQuery("runing containrs") {
+		t.Error("IsTaskQuery(runing containrs) = false with the spell check on")
+	}
+	if got := semantic(); len(got) == 0 || got[0] != "docker ps" {
+		t.Errorf("semantic suggestions = %v, want docker ps first", got)
+	}
+}
+
+func TestIsNaturalLanguage(t *testing.T) {
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{"undo last commit", true},
+		{"what's listening on port 3000", true},
+		{"git commit --amend", false},
+		{"docker system prune", false},
+		{"undo commit", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsNaturalLanguage(tt.query); got != tt.want {
+			t.Errorf("IsNaturalLanguage(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestAliasSuggestions(t *testing.T) {
+	storage, err := db.NewStorage(filepath.Join(t.TempDir(), "wut.db"))
+	if err != nil {
+		t.Fatalf("NewStorage() error = %v", err)
+	}
+	defer storage.Close()
+	if err := storage.SaveAlias(t.Context(), "klogs", "kubectl logs -f deployment/$1", "Follow logs"); err != nil {
+		t.Fatalf("SaveAlias() error = %v", err)
+	}
+
+	e := NewEngine(storage)
+	contextData := &appctx.Context{WorkingDir: t.TempDir(), ProjectType: "unknown"}
+	suggestions, err := e.Suggest(t.Context(), "klogs api", contextData, 5)
+	if err != nil {
+		t.Fatalf("Suggest() error = %v", err)
+	}
+	if len(suggestions) == 0 {
+		t.Fatal("Suggest() returned nothing")
+	}
+	if top := suggestions[0]; top.Command != "kubectl logs -f deployment/api" || !strings.Contains(top.Source, "Alias") {
+		t.Errorf("top suggestion = %q from %q, want the expanded alias", top.Command, top.Source)
+	}
+}
+
+func TestBookmarkSuggestions(t *testing.T) {
+	storage, err := db.NewStorage(filepath.Join(t.TempDir(), "wut.db"))
+	if err != nil {
+		t.Fatalf("NewStorage() error = %v", err)
+	}
+	defer storage.Close()
+	if _, err := storage.AddBookmark(t.Context(), "git log --oneline --graph --all", []string{"history"}, ""); err != nil {
+		t.Fatalf("AddBookmark() error = %v", err)
+	}
+
+	e := NewEngine(storage)
+	contextData := &appctx.Context{WorkingDir: t.TempDir(), ProjectType: "unknown"}
+	suggestions, err := e.Suggest(t.Context(), "git log", contextData, 5)
+	if err != nil {
+		t.Fatalf("Suggest() error = %v", err)
+	}
+	if len(suggestions) == 0 {
+		t.Fatal("Suggest() returned nothing")
+	}
+	if top := suggestions[0]; top.Command != "git log --oneline --graph --all" || !strings.Contains(top.Source, "Bookmark") {
+		t.Errorf("top suggestion = %q from %q, want the bookmark", top.Command, top.Source)
+	}
+
+	// Tags find bookmarks whose command does not mention them
+	suggestions, _ = e.Suggest(t.Context(), "history", contextData, 5)
+	found := false
+	for _, s := range suggestions {
+		found = found || s.Command == "git log --oneline --graph --all"
+	}
+	if !found {
+		t.Errorf("Suggest(history) = %+v, want the bookmark tagged history", suggestions)
+	}
+}
+
+func TestTypoTolerantSuggestions(t *testing.T) {
+	storage, err := db.NewStorage(filepath.Join(t.TempDir(), "wut.db"))
+	if err != nil {
+		t.Fatalf("NewStorage() error = %v", err)
+	}
+	defer storage.Close()
+	for _, command := range []string{
+		"git commit --amend", "git commit -m wip", "git status", "git push origin main",
+		"kubectl get pods", "kubectl get svc", "kubectl describe pod web",
+		"docker compose up -d", "docker compose down", "docker ps",
+	} {
+		if err := storage.AddHistory(t.Context(), command); err != nil {
+			t.Fatalf("AddHistory() error = %v", err)
+		}
+	}
+
+	e := NewEngine(storage)
+	contextData := &appctx.Context{WorkingDir: t.TempDir(), ProjectType: "unknown"}
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"git comit amend", "git commit --amend"},
+		{"kubctl get pod", "kubectl get pods"},
+		{"dockr comps up", "docker compose up -d"},
+		{"amend comit git", "git commit --amend"},
+	}
+	for _, tt := range tests {
+		suggestions, err := e.Suggest(t.Context(), tt.query, contextData, 5)
+		if err != nil {
+			t.Fatalf("Suggest(%q) error = %v", tt.query, err)
+		}
+		if len(suggestions) == 0 || suggestions[0].Command != tt.want {
+			var got []string
+			for _, s := range suggestions {
+				got = append(got, s.Command)
+			}
+			t.Errorf("Suggest(%q) = %q, want %q first", tt.query, got, tt.want)
+		}
+	}
+
+	// Built-in suggestions are matched word by word too
+	goProject := &appctx.Context{WorkingDir: t.TempDir(), ProjectType: "go"}
+	var found bool
+	for _, s := range e.getContextSuggestions(goProject, "tset go") {
+		found = found || s.Command == "go test ./..."
+	}
+	if !found {
+		t.Error(`getContextSuggestions("tset go") is missing "go test ./..."`)
+	}
+}
+
+func TestQuickActions(t *testing.T) {
+	contextData := &appctx.Context{
+		IsGitRepo:      true,
+		ProjectType:    "go",
+		ComposeCommand: "docker compose",
+		GitStatus:      appctx.GitStatus{Branch: "main", Upstream: "origin/main", ModifiedFiles: []string{"a.go"}, Ahead: 1},
+	}
+	var got []string
+	for _, s := range NewEngine(nil).QuickActions(contextData) {
+		got = append(got, s.Command)
+	}
+	want := []string{"git add . && git commit -m \"update\"", "git push", "go test ./...", "docker compose up -d"}
+	for _, command := range want {
+		if !slices.Contains(got, command) {
+			t.Errorf("QuickActions() = %q, missing %q", got, command)
+		}
+	}
+
+	if actions := NewEngine(nil).QuickActions(&appctx.Context{ProjectType: "unknown"}); len(actions) != 0 {
+		t.Errorf("QuickActions() outside a project = %+v, want none", actions)
+	}
+}
+
+func TestCommandBiasReordersSuggestions(t *testing.T) {
+	storage, err := db.NewStorage(filepath.Join(t.TempDir(), "wut.db"))
+	if err != nil {
+		t.Fatalf("NewStorage() error = %v", err)
+	}
+	defer storage.Close()
+	for _, command := range []string{"git stash", "git status", "git status", "git status"} {
+		if err := storage.AddHistory(t.Context(), command); err != nil {
+			t.Fatalf("AddHistory() error = %v", err)
+		}
+	}
+	contextData := &appctx.Context{WorkingDir: t.TempDir(), ProjectType: "unknown"}
+	rank := func(command string) int {
+		suggestions, err := NewEngine(storage).Suggest(t.Context(), "git", contextData, 20)
+		if err != nil {
+			t.Fatalf("Suggest() error = %v", err)
+		}
+		for i, s := range suggestions {
+			if s.Command == command {
+				return i
+			}
+		}
+		t.Fatalf("%q is not suggested", command)
+		return -1
+	}
+
+	if rank("git status") > rank("git stash") {
+		t.Fatal("git status ranks below git stash before training")
+	}
+	for range db.MaxBiasLevel {
+		if _, err := storage.AdjustCommandBias(t.Context(), "git status", -1); err != nil {
+			t.Fatalf("AdjustCommandBias() error = %v", err)
+		}
+	}
+	if _, err := storage.AdjustCommandBias(t.Context(), "git stash", 1); err != nil {
+		t.Fatalf("AdjustCommandBias() error = %v", err)
+	}
+	if rank("git status") < rank("git stash") {
+		t.Error("git status still ranks above git stash after burying it")
+	}
+}
+
+func TestHistorySummaryFailurePenalty(t *testing.T) {
+	tests := []struct {
+		name    string
+		summary db.HistoryCommandSummary
+		penalty bool
+	}{
+		{"untimed", db.HistoryCommandSummary{Command: "make test", UsageCount: 9}, false},
+		{"too few runs", db.HistoryCommandSummary{Command: "make test", Runs: 2, Failures: 2}, false},
+		{"usually succeeds", db.HistoryCommandSummary{Command: "make test", Runs: 4, Failures: 1}, false},
+		{"usually fails", db.HistoryCommandSummary{Command: "make test", Runs: 4, Failures: 3}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			penalty := historySummaryFailurePenalty(tt.summary)
+			if (penalty > 0) != tt.penalty {
+				t.Errorf("historySummaryFailurePenalty() = %v, want a penalty: %v", penalty, tt.penalty)
+			}
+			description := historySummaryDescription(tt.summary, commandsearch.BuildProfile(tt.summary.Command))
+			if strings.Contains(description, "failed") != tt.penalty {
+				t.Errorf("description = %q", description)
+			}
+		})
+	}
+}
@@ -0,0 +1,271 @@
+package smart
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goccy/go-json"
+
+	"wut/internal/config"
+	appctx "wut/internal/context"
+	"wut/internal/logger"
+	"wut/internal/shellwords"
+)
+
+// maxExternalScore is the score of a suggestion its source rates 1, the
+// highest rating it may give
+const maxExternalScore = 3.0
+
+// maxExternalSuggestions caps how many suggestions one source contributes
+const maxExternalSuggestions = 20
+
+// defaultSourceTimeout applies when smart.source_timeout is not set
+const defaultSourceTimeout = time.Second
+
+// ExternalSource is a program that suggests commands for a query: an
+// executable in the sources directory, or a command declared in the config.
+// It is run with the query as its last argument and an ExternalSourceRequest
+// on stdin, and prints one JSON object per line with command, description
+// and a score from 0 to 1.
+type ExternalSource struct {
+	Name string
+	// Path is the program and Args the arguments it gets before the query
+	Path string
+	Args []string
+	// Declared is set for sources from smart.sources rather than the
+	// sources directory
+	Declared bool
+	Enabled  bool
+}
+
+// Label is the Source a suggestion from this source carries
+func (s ExternalSource) Label() string {
+	return "🔌 " + s.Name
+}
+
+// ExternalSourceRequest is what an external source reads on stdin
+type ExternalSourceRequest struct {
+	Query       string `json:"query"`
+	WorkingDir  string `json:"working_dir"`
+	ProjectType string `json:"project_type"`
+	Limit       int    `json:"limit"`
+}
+
+// externalSuggestion is one line of an external source's output
+type externalSuggestion struct {
+	Command     string  `json:"command"`
+	Description string  `json:"description"`
+	Score       float64 `json:"score"`
+}
+
+// ExternalSources returns the sources declared in cfg and the executables
+// in dir, by name. A declared source hides an executable of the same name,
+// and the sources cfg disables are returned with Enabled unset.
+func ExternalSources(cfg config.SmartConfig, dir string) []ExternalSource {
+	byName := make(map[string]ExternalSource)
+	// Sources run in the working directory, so their paths must not be relative
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	if entries, err := os.ReadDir(dir); err == nil {
+		for _, entry := range entries {
+			info, err := entry.Info()
+			if err != nil || !isExecutableFile(entry.Name(), info) {
+				continue
+			}
+			name := strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+			byName[name] = ExternalSource{Name: name, Path: filepath.Join(dir, entry.Name())}
+		}
+	}
+	for _, declared := range cfg.Sources {
+		// Quotes and escapes group words as in a shell, but no shell runs the
+		// command, so operators reach the source as plain arguments
+		var fields []string
+		for _, tok := range shellwords.Lex(declared.Command) {
+			fields = append(fields, tok.Text)
+		}
+		if declared.Name == "" || len(fields) == 0 {
+			continue
+		}
+		byName[declared.Name] = ExternalSource{
+			Name:     declared.Name,
+			Path:     expandHome(fields[0]),
+			Args:     fields[1:],
+			Declared: true,
+		}
+	}
+
+	sources := make([]ExternalSource, 0, len(byName))
+	for _, source := range byName {
+		source.Enabled = !slices.Contains(cfg.DisabledSources, source.Name)
+		sources = append(sources, source)
+	}
+	sort.Slice(sources, func(i, j int) bool {
+		return sources[i].Name < sources[j].Name
+	})
+	return sources
+}
+
+// isExecutableFile reports whether a directory entry can be run as a source.
+// Windows has no execute bit, so there the extension decides.
+func isExecutableFile(name string, info os.FileInfo) bool {
+	if !info.Mode().IsRegular() || strings.HasPrefix(name, ".") {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		switch strings.ToLower(filepath.Ext(name)) {
+		case ".exe", ".bat", ".cmd", ".com":
+			return true
+		}
+		return false
+	}
+	return info.Mode().Perm()&0111 != 0
+}
+
+// expandHome expands a leading ~ in a declared source's program
+func expandHome(path string) string {
+	if rest, ok := strings.CutPrefix(path, "~"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+	return path
+}
+
+// SourceTimeout is how long an external source may take, from cfg
+func SourceTimeout(cfg config.SmartConfig) time.Duration {
+	if cfg.SourceTimeout <= 0 {
+		return defaultSourceTimeout
+	}
+	return time.Duration(cfg.SourceTimeout) * time.Millisecond
+}
+
+// RunExternalSource runs source for req and returns its suggestions. Lines
+// that are not a suggestion are skipped; a source that fails, or does not
+// finish before ctx does, returns an error.
+func RunExternalSource(ctx context.Context, source ExternalSource, req ExternalSourceRequest) ([]Suggestion, error) {
+	input, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.CommandContext(ctx, source.Path, append(slices.Clone(source.Args), req.Query)...)
+	cmd.Dir = req.WorkingDir
+	cmd.Stdin = bytes.NewReader(input)
+	// Do not wait on pipes a killed source's children still hold open
+	cmd.WaitDelay = 100 * time.Millisecond
+	output, err := cmd.Output()
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("source %s timed out: %w", source.Name, ctx.Err())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("source %s failed: %w", source.Name, err)
+	}
+
+	var suggestions []Suggestion
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() && len(suggestions) < maxExternalSuggestions {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var s externalSuggestion
+		if err := json.Unmarshal(line, &s); err != nil || strings.TrimSpace(s.Command) == "" {
+			continue
+		}
+		suggestions = append(suggestions, Suggestion{
+			Command:     strings.TrimSpace(s.Command),
+			Description: s.Description,
+			Score:       math.Max(0, math.Min(s.Score, 1)) * maxExternalScore,
+			Source:      source.Label(),
+			Icon:        "🔌",
+		})
+	}
+	return suggestions, nil
+}
+
+// SetExternalSources replaces the external sources the engine runs, which
+// otherwise come from the config and the sources directory
+func (e *Engine) SetExternalSources(sources []ExternalSource, timeout time.Duration) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.sources = sources
+	e.sourceTimeout = timeout
+	e.sourcesLoaded = true
+}
+
+// externalSources returns the enabled external sources and how long each
+// may take, reading them on first use
+func (e *Engine) externalSources() ([]ExternalSource, time.Duration) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if !e.sourcesLoaded {
+		cfg := e.cfg.Smart
+		e.sources = ExternalSources(cfg, config.GetSourcesDir())
+		e.sourceTimeout = SourceTimeout(cfg)
+		e.sourcesLoaded = true
+	}
+
+	var enabled []ExternalSource
+	for _, source := range e.sources {
+		if source.Enabled {
+			enabled = append(enabled, source)
+		}
+	}
+	return enabled, e.sourceTimeout
+}
+
+// getExternalSuggestions asks every enabled external source at once. A
+// source that fails or runs out of time is logged and left out.
+func (e *Engine) getExternalSuggestions(ctx context.Context, query string, contextData *appctx.Context, limit int) []Suggestion {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil
+	}
+	sources, timeout := e.externalSources()
+	if len(sources) == 0 {
+		return nil
+	}
+
+	req := ExternalSourceRequest{
+		Query:       query,
+		WorkingDir:  contextData.WorkingDir,
+		ProjectType: contextData.ProjectType,
+		Limit:       limit,
+	}
+	log := logger.With("smart")
+
+	var (
+		mu          sync.Mutex
+		suggestions []Suggestion
+		wg          sync.WaitGroup
+	)
+	for _, source := range sources {
+		wg.Go(func() {
+			sourceCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			found, err := RunExternalSource(sourceCtx, source, req)
+			if err != nil {
+				log.Debug("external source skipped", "source", source.Name, "error", err)
+				return
+			}
+			mu.Lock()
+			suggestions = append(suggestions, found...)
+			mu.Unlock()
+		})
+	}
+	wg.Wait()
+	return suggestions
+}
@@ -0,0 +1,132 @@
+package smart
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"wut/internal/config"
+	appctx "wut/internal/context"
+)
+
+func TestExternalSources(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the sample source is a shell script")
+	}
+	dir := t.TempDir()
+	for name, mode := range map[string]os.FileMode{"jira.sh": 0755, "notes.txt": 0644, ".hidden": 0755, "runbooks": 0755} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"), mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg := config.SmartConfig{
+		Sources: []config.SourceConfig{
+			{Name: "runbooks", Command: "runbook-search --wut"},
+			{Name: "scripts", Command: `"~/My Scripts/src.sh" --title 'open issues' --tag=a\ b`},
+			{Name: "empty"},
+		},
+		DisabledSources: []string{"jira"},
+	}
+	sources := ExternalSources(cfg, dir)
+
+	var names []string
+	for _, s := range sources {
+		names = append(names, s.Name)
+	}
+	if strings.Join(names, ",") != "jira,runbooks,scripts" {
+		t.Fatalf("ExternalSources() = %v, want jira, runbooks and scripts", names)
+	}
+	if jira := sources[0]; jira.Enabled || jira.Declared || jira.Path != filepath.Join(dir, "jira.sh") {
+		t.Errorf("jira = %+v, want the disabled executable", jira)
+	}
+	if runbooks := sources[1]; !runbooks.Enabled || !runbooks.Declared || runbooks.Path != "runbook-search" || strings.Join(runbooks.Args, " ") != "--wut" {
+		t.Errorf("runbooks = %+v, want the declared command to hide the executable", runbooks)
+	}
+	home, _ := os.UserHomeDir()
+	wantArgs := []string{"--title", "open issues", "--tag=a b"}
+	if scripts := sources[2]; scripts.Path != filepath.Join(home, "My Scripts", "src.sh") || !slices.Equal(scripts.Args, wantArgs) {
+		t.Errorf("scripts = %q %q, want the quoted path and arguments kept whole", scripts.Path, scripts.Args)
+	}
+}
+
+// TestExternalSourceSuggestions runs the sample source in testdata next to
+// one that hangs and one that fails
+func TestExternalSourceSuggestions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the sample source is a shell script")
+	}
+	config.Set(&config.Config{})
+	sources := ExternalSources(config.SmartConfig{}, filepath.Join("testdata", "sources"))
+	if len(sources) != 1 || sources[0].Name != "runbooks" {
+		t.Fatalf("ExternalSources(testdata) = %+v, want the runbooks sample", sources)
+	}
+	sample := sources[0]
+	slow, broken := sample, sample
+	slow.Name, slow.Args = "slow", []string{"--slow"}
+	broken.Name, broken.Args = "broken", []string{"--fail"}
+
+	e := NewEngine(nil)
+	e.SetExternalSources([]ExternalSource{sample, slow, broken}, 500*time.Millisecond)
+	contextData := &appctx.Context{WorkingDir: t.TempDir(), ProjectType: "go"}
+
+	start := time.Now()
+	suggestions, err := e.Suggest(t.Context(), "git", contextData, 0)
+	if err != nil {
+		t.Fatalf("Suggest() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("Suggest() took %v, want the slow source cut off", elapsed)
+	}
+
+	external := map[string]Suggestion{}
+	builtIn := 0
+	for _, s := range suggestions {
+		switch {
+		case strings.Contains(s.Source, "🔌"):
+			external[s.Command] = s
+		default:
+			builtIn++
+		}
+	}
+	if builtIn == 0 {
+		t.Error("the external sources crowded out the built-in suggestions")
+	}
+	if len(external) != 2 {
+		t.Fatalf("external suggestions = %v, want the two sample lines", external)
+	}
+	open := external["runbook open git"]
+	if open.Source != "🔌 runbooks" || open.Description != "Open the git runbook for go" {
+		t.Errorf("runbook open = %+v, want the sample's suggestion for the go project", open)
+	}
+}
+
+func TestRunExternalSourceErrors(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the sample source is a shell script")
+	}
+	path, err := filepath.Abs(filepath.Join("testdata", "sources", "runbooks"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sample := ExternalSource{Name: "runbooks", Path: path, Enabled: true}
+	req := ExternalSourceRequest{Query: "deploy", WorkingDir: t.TempDir()}
+
+	suggestions, err := RunExternalSource(t.Context(), sample, req)
+	if err != nil || len(suggestions) != 2 {
+		t.Fatalf("RunExternalSource() = %v, %v; want two suggestions", suggestions, err)
+	}
+	if got := suggestions[1].Score; got != maxExternalScore {
+		t.Errorf("a score of 7 became %v, want it capped at %v", got, maxExternalScore)
+	}
+
+	broken := sample
+	broken.Args = []string{"--fail"}
+	if _, err := RunExternalSource(t.Context(), broken, req); err == nil {
+		t.Error("RunExternalSource() of a failing source returned no error")
+	}
+}
@@ -0,0 +1,100 @@
+package terminal
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/atotto/clipboard"
+)
+
+// ErrClipboardUnavailable is returned when no clipboard backend accepted the text
+var ErrClipboardUnavailable = errors.New("no clipboard backend available")
+
+// clipboardTool is an external program that reads clipboard content from stdin
+type clipboardTool struct {
+	name string
+	args []string
+	when func() bool
+}
+
+var clipboardTools = []clipboardTool{
+	{name: "wl-copy", when: func() bool { return os.Getenv("WAYLAND_DISPLAY") != "" }},
+	{name: "xclip", args: []string{"-selection", "clipboard"}, when: func() bool { return os.Getenv("DISPLAY") != "" }},
+	{name: "xsel", args: []string{"--clipboard", "--input"}, when: func() bool { return os.Getenv("DISPLAY") != "" }},
+	{name: "pbcopy"},
+	{name: "clip.exe"},
+}
+
+// ClipboardBackend returns the method Copy would try first, without touching
+// the clipboard, or an empty string when none is available.
+func ClipboardBackend() string {
+	if !clipboard.Unsupported {
+		return "system clipboard"
+	}
+	for _, tool := range clipboardTools {
+		if tool.when != nil && !tool.when() {
+			continue
+		}
+		if _, err := exec.LookPath(tool.name); err == nil {
+			return tool.name
+		}
+	}
+	if Detect().IsTTY {
+		return "OSC52"
+	}
+	return ""
+}
+
+// Copy places text on the clipboard using the first backend that works:
+// the native clipboard library, then wl-copy/xclip/xsel/pbcopy/clip.exe,
+// then an OSC52 escape sequence so copying still works over SSH. It returns
+// the name of the method that succeeded.
+func Copy(text string) (string, error) {
+	if !clipboard.Unsupported {
+		if err := clipboard.WriteAll(text); err == nil {
+			return "system clipboard", nil
+		}
+	}
+
+	for _, tool := range clipboardTools {
+		if tool.when != nil && !tool.when() {
+			continue
+		}
+		path, err := exec.LookPath(tool.name)
+		if err != nil {
+			continue
+		}
+		cmd := exec.Command(path, tool.args...)
+		cmd.Stdin = strings.NewReader(text)
+		if err := cmd.Run(); err == nil {
+			return tool.name, nil
+		}
+	}
+
+	if Detect().IsTTY {
+		if _, err := fmt.Fprint(os.Stdout, osc52Sequence(text)); err == nil {
+			return "OSC52", nil
+		}
+	}
+
+	return "", ErrClipboardUnavailable
+}
+
+// osc52Sequence builds the OSC52 "set clipboard" escape, wrapped for tmux
+// and screen when running inside them.
+func osc52Sequence(text string) string {
+	seq := "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\a"
+
+	switch {
+	case os.Getenv("TMUX") != "":
+		return "\x1bPtmux;\x1b" + seq + "\x1b\\"
+	case strings.HasPrefix(os.Getenv("TERM"), "screen"):
+		return "\x1bP" + seq + "\x1b\\"
+	default:
+		return seq
+	}
+}
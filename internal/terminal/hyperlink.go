@@ -0,0 +1,67 @@
+package terminal
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// hyperlinkPrograms are TERM_PROGRAM values of terminals known to render
+// OSC-8 hyperlinks
+var hyperlinkPrograms = map[string]bool{
+	"iterm.app": true,
+	"wezterm":   true,
+	"vscode":    true,
+	"hyper":     true,
+	"ghostty":   true,
+	"tabby":     true,
+	"rio":       true,
+}
+
+// hyperlinkTerms are substrings of TERM values that imply OSC-8 support
+var hyperlinkTerms = []string{"kitty", "alacritty", "foot", "ghostty", "wezterm", "contour"}
+
+// supportsHyperlinks guesses OSC-8 support from the environment.
+// FORCE_HYPERLINK=1 or 0 overrides detection, but links are never emitted
+// while color is off.
+func supportsHyperlinks(isTTY bool) bool {
+	if !ColorEnabled() {
+		return false
+	}
+	if force, ok := os.LookupEnv("FORCE_HYPERLINK"); ok {
+		return force != "0" && force != ""
+	}
+	if !isTTY {
+		return false
+	}
+	if os.Getenv("WT_SESSION") != "" || os.Getenv("KITTY_WINDOW_ID") != "" ||
+		os.Getenv("KONSOLE_VERSION") != "" || os.Getenv("DOMTERM") != "" {
+		return true
+	}
+	if hyperlinkPrograms[strings.ToLower(os.Getenv("TERM_PROGRAM"))] {
+		return true
+	}
+	// GNOME Terminal and other VTE terminals gained OSC-8 in 0.50
+	if vte, err := strconv.Atoi(os.Getenv("VTE_VERSION")); err == nil && vte >= 5000 {
+		return true
+	}
+	termName := strings.ToLower(os.Getenv("TERM"))
+	for _, t := range hyperlinkTerms {
+		if strings.Contains(termName, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// Hyperlink wraps text in an OSC-8 sequence pointing at url. When the
+// terminal cannot render links (or color is off) the plain text is returned.
+func Hyperlink(url, text string) string {
+	if text == "" {
+		text = url
+	}
+	if url == "" || !Detect().SupportsHyperlinks {
+		return text
+	}
+	return "\x1b]8;;" + url + "\x1b\\" + text + "\x1b]8;;\x1b\\"
+}
@@ -0,0 +1,87 @@
+// Package terminal detects terminal capabilities for WUT
+package terminal
+
+import (
+	"os"
+	"strings"
+	"sync/atomic"
+
+	"golang.org/x/term"
+)
+
+const (
+	defaultWidth  = 80
+	defaultHeight = 24
+)
+
+// forceNoColor is set by the global --no-color flag
+var forceNoColor atomic.Bool
+
+// Capabilities describes what the attached terminal can render
+type Capabilities struct {
+	IsTTY              bool
+	Color              bool
+	Supports256Colors  bool
+	SupportsTrueColor  bool
+	SupportsEmoji      bool
+	SupportsHyperlinks bool
+	Width              int
+	Height             int
+}
+
+// Detect inspects stdout and the environment to determine terminal capabilities
+func Detect() Capabilities {
+	fd := int(os.Stdout.Fd())
+	isTTY := term.IsTerminal(fd)
+
+	width, height := defaultWidth, defaultHeight
+	if isTTY {
+		if w, h, err := term.GetSize(fd); err == nil {
+			if w > 0 {
+				width = w
+			}
+			if h > 0 {
+				height = h
+			}
+		}
+	}
+
+	termName := os.Getenv("TERM")
+	color := ColorEnabled()
+
+	return Capabilities{
+		IsTTY:              isTTY,
+		Color:              color,
+		Supports256Colors:  color && termName != "dumb",
+		SupportsTrueColor:  color && os.Getenv("COLORTERM") == "truecolor",
+		SupportsEmoji:      os.Getenv("LANG") != "C" && !strings.Contains(termName, "linux"),
+		SupportsHyperlinks: supportsHyperlinks(isTTY),
+		Width:              width,
+		Height:             height,
+	}
+}
+
+// IsInteractive reports whether a full-screen TUI can run: both stdin and
+// stdout must be terminals. Commands fall back to plain output otherwise,
+// e.g. when piped into another program.
+func IsInteractive() bool {
+	return term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// SetNoColor forces colored output off regardless of the environment
+func SetNoColor(disabled bool) {
+	forceNoColor.Store(disabled)
+}
+
+// ColorEnabled reports whether colored output is allowed. Color is disabled by
+// the --no-color flag, a non-empty NO_COLOR variable (https://no-color.org),
+// or TERM=dumb.
+func ColorEnabled() bool {
+	if forceNoColor.Load() {
+		return false
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	return os.Getenv("TERM") != "dumb"
+}
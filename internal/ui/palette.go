@@ -0,0 +1,63 @@
+package ui
+
+import (
+	"github.com/charmbracelet/lipgloss"
+
+	"wut/internal/terminal"
+)
+
+// Palette colors. Each has a dark and a light variant; lipgloss picks one at
+// render time from the background set by SetTheme, so styles built from them
+// at package init follow the theme too.
+var (
+	// Primary Branding Colors
+	ColorPrimary   = lipgloss.AdaptiveColor{Dark: "#3B82F6", Light: "#1D4ED8"} // Electric Blue
+	ColorSecondary = lipgloss.AdaptiveColor{Dark: "#8B5CF6", Light: "#6D28D9"} // Violet
+	ColorAccent    = lipgloss.AdaptiveColor{Dark: "#06B6D4", Light: "#0E7490"} // Cyan
+	ColorBrand     = lipgloss.AdaptiveColor{Dark: "#7C3AED", Light: "#5B21B6"} // Purple
+	ColorBrandSoft = lipgloss.AdaptiveColor{Dark: "#A78BFA", Light: "#7C3AED"} // Lavender
+	ColorInfo      = lipgloss.AdaptiveColor{Dark: "#60A5FA", Light: "#2563EB"} // Light Blue
+	ColorPink      = lipgloss.AdaptiveColor{Dark: "#EC4899", Light: "#BE185D"}
+
+	// Semantic Colors
+	ColorSuccess   = lipgloss.AdaptiveColor{Dark: "#10B981", Light: "#047857"} // Emerald Green
+	ColorWarning   = lipgloss.AdaptiveColor{Dark: "#F59E0B", Light: "#B45309"} // Amber
+	ColorError     = lipgloss.AdaptiveColor{Dark: "#EF4444", Light: "#B91C1C"} // Red
+	ColorHighlight = lipgloss.AdaptiveColor{Dark: "#EAB308", Light: "#A16207"} // Yellow (key hints)
+
+	// Text
+	ColorText     = lipgloss.AdaptiveColor{Dark: "#E5E7EB", Light: "#1F2937"} // Normal text
+	ColorSubtle   = lipgloss.AdaptiveColor{Dark: "#9CA3AF", Light: "#4B5563"} // Secondary text
+	ColorMuted    = lipgloss.AdaptiveColor{Dark: "#6B7280", Light: "#6B7280"} // Muted text
+	ColorOnAccent = lipgloss.AdaptiveColor{Dark: "#FFFFFF", Light: "#FFFFFF"} // Text on a colored background
+	ColorInverse  = lipgloss.AdaptiveColor{Dark: "#000000", Light: "#FFFFFF"} // Text on a soft colored background
+
+	// Surfaces
+	ColorBase      = lipgloss.AdaptiveColor{Dark: "#1F2937", Light: "#F3F4F6"} // Panels
+	ColorSurface   = lipgloss.AdaptiveColor{Dark: "#374151", Light: "#E5E7EB"} // Code blocks, inactive buttons
+	ColorBorder    = lipgloss.AdaptiveColor{Dark: "#4B5563", Light: "#D1D5DB"}
+	ColorSelection = lipgloss.AdaptiveColor{Dark: "#1E3A8A", Light: "#DBEAFE"} // Selected row background
+	ColorSuccessBg = lipgloss.AdaptiveColor{Dark: "#064E3B", Light: "#D1FAE5"}
+	ColorErrorBg   = lipgloss.AdaptiveColor{Dark: "#FEE2E2", Light: "#FEE2E2"}
+)
+
+// SetTheme picks the palette variant for ui.theme: "dark" and "light" force
+// one, anything else ("auto") asks the terminal for its background. The
+// terminal is only asked when color is on and stdout is a terminal; otherwise
+// the dark variant is used.
+func SetTheme(theme string) {
+	switch theme {
+	case "dark":
+		lipgloss.SetHasDarkBackground(true)
+	case "light":
+		lipgloss.SetHasDarkBackground(false)
+	default:
+		if !terminal.ColorEnabled() || !terminal.Detect().IsTTY {
+			lipgloss.SetHasDarkBackground(true)
+			return
+		}
+		// Query now rather than on the first render, which may be inside a
+		// TUI that owns stdin
+		lipgloss.SetHasDarkBackground(lipgloss.HasDarkBackground())
+	}
+}
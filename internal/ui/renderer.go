@@ -3,10 +3,10 @@ package ui
 
 import (
 	"fmt"
-	"os"
 	"strings"
 
 	"wut/internal/config"
+	"wut/internal/terminal"
 )
 
 // Capabilities represents terminal capabilities
@@ -21,13 +21,14 @@ type Capabilities struct {
 
 // Detect detects terminal capabilities
 func detectCapabilities() *Capabilities {
+	caps := terminal.Detect()
 	return &Capabilities{
-		Supports256Colors: os.Getenv("TERM") != "dumb",
-		SupportsTrueColor: os.Getenv("COLORTERM") == "truecolor",
-		SupportsEmoji:     os.Getenv("LANG") != "C" && !strings.Contains(os.Getenv("TERM"), "linux"),
+		Supports256Colors: caps.Supports256Colors,
+		SupportsTrueColor: caps.SupportsTrueColor,
+		SupportsEmoji:     caps.SupportsEmoji,
 		SupportsNerdFonts: false, // Conservative default
-		Width:             80,
-		Height:            24,
+		Width:             caps.Width,
+		Height:            caps.Height,
 	}
 }
 
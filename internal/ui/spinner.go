@@ -7,7 +7,8 @@ import (
 	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
-	"golang.org/x/term"
+
+	"wut/internal/terminal"
 )
 
 type spinnerDoneMsg struct {
@@ -61,18 +62,18 @@ func (m spinnerModel) View() string {
 	if m.done {
 		return ""
 	}
-	return fmt.Sprintf("\n %s %s\n", m.spinner.View(), lipgloss.NewStyle().Foreground(lipgloss.Color("#90E0EF")).Render(m.text))
+	return fmt.Sprintf("\n %s %s\n", m.spinner.View(), lipgloss.NewStyle().Foreground(ColorAccent).Render(m.text))
 }
 
 // RunWithSpinner runs a long-running function with a visual spinner
 func RunWithSpinner(text string, f func() error) error {
-	if os.Getenv("WUT_NO_SPINNER") == "true" || !term.IsTerminal(int(os.Stdout.Fd())) {
+	if os.Getenv("WUT_NO_SPINNER") == "true" || !terminal.IsInteractive() {
 		return f()
 	}
 
 	s := spinner.New()
 	s.Spinner = spinner.Dot
-	s.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF70A6"))
+	s.Style = lipgloss.NewStyle().Foreground(ColorPink)
 
 	m := spinnerModel{
 		spinner: s,
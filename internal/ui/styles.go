@@ -4,21 +4,9 @@ import (
 	"fmt"
 
 	"github.com/charmbracelet/lipgloss"
-)
-
-// Color definitions for modern, cohesive Deep Navy / Electric Blue UI theming
-var (
-	// Primary Branding Colors
-	ColorPrimary   = lipgloss.Color("#3B82F6") // Electric Blue
-	ColorSecondary = lipgloss.Color("#8B5CF6") // Violet / Deep Navy hint
-	ColorAccent    = lipgloss.Color("#06B6D4") // Cyan
+	"github.com/muesli/termenv"
 
-	// Semantic Colors
-	ColorSuccess = lipgloss.Color("#10B981") // Emerald Green
-	ColorWarning = lipgloss.Color("#F59E0B") // Amber
-	ColorError   = lipgloss.Color("#EF4444") // Red
-	ColorMuted   = lipgloss.Color("#6B7280") // Gray (Muted Text)
-	ColorText    = lipgloss.Color("#E5E7EB") // Light Gray (Normal text)
+	"wut/internal/terminal"
 )
 
 var (
@@ -41,8 +29,8 @@ var (
 			Foreground(ColorSecondary)
 
 	StyleHighlight = lipgloss.NewStyle().
-			Background(lipgloss.Color("#1E3A8A")). // Dark blue background
-			Foreground(lipgloss.Color("#60A5FA")). // Light blue foreground
+			Background(ColorSelection).
+			Foreground(ColorInfo).
 			Padding(0, 1)
 )
 
@@ -88,3 +76,13 @@ const CatMascot = `      /\_/\
 
 // Mascot returns the styled cat mascot
 func Mascot() string { return StyleSecondary.Render(CatMascot) }
+
+// ApplyColorMode switches lipgloss to a plain profile when color is disabled
+// or stdout is not a terminal, so every style in WUT (including the TUIs)
+// renders without ANSI escapes.
+func ApplyColorMode() {
+	if terminal.ColorEnabled() && terminal.Detect().IsTTY {
+		return
+	}
+	lipgloss.SetColorProfile(termenv.Ascii)
+}
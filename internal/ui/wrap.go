@@ -0,0 +1,40 @@
+package ui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/reflow/truncate"
+	"github.com/muesli/reflow/wordwrap"
+	"github.com/muesli/reflow/wrap"
+)
+
+// Wrap word-wraps text to width columns, hard-breaking words that are longer
+// than a line. At most maxLines lines are returned (0 means no limit); when
+// text is cut the last line ends with "...". A width of 0 or less disables
+// wrapping.
+func Wrap(text string, width, maxLines int) []string {
+	if text == "" {
+		return nil
+	}
+	if width <= 0 {
+		return []string{text}
+	}
+
+	wrapped := wrap.String(wordwrap.String(text, width), width)
+	lines := strings.Split(wrapped, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " ")
+	}
+
+	if maxLines > 0 && len(lines) > maxLines {
+		lines = lines[:maxLines]
+		last := lines[maxLines-1] + "..."
+		if lipgloss.Width(last) > width {
+			last = truncate.StringWithTail(last, uint(width), "...")
+		}
+		lines[maxLines-1] = last
+	}
+
+	return lines
+}
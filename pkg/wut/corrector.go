@@ -0,0 +1,148 @@
+package wut
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"time"
+
+	"wut/internal/corrector"
+	"wut/internal/smart"
+)
+
+// Correction is a fix for a command
+type Correction struct {
+	Original  string
+	Corrected string
+	// Confidence is how sure the corrector is of the fix, from 0 to 1
+	Confidence  float64
+	Explanation string
+	// Dangerous is set when the command is destructive rather than
+	// mistyped; Corrected is then empty and Explanation says why
+	Dangerous bool
+}
+
+// Suggestion is a command offered for an input
+type Suggestion struct {
+	Command     string
+	Description string
+	Confidence  float64
+}
+
+// HistoryEntry is a command the user ran, for correcting a typo to a
+// command they use
+type HistoryEntry struct {
+	Command    string
+	UsageCount int
+	LastUsed   time.Time
+}
+
+// FeedbackFunc returns how often the user accepted and rejected a correction
+// from original to corrected
+type FeedbackFunc func(original, corrected string) (accepted, rejected int)
+
+// CorrectorOption configures a Corrector
+type CorrectorOption func(*Corrector)
+
+// WithMinConfidence sets the confidence a correction needs to be returned.
+// Dangerous command warnings are returned whatever their confidence.
+func WithMinConfidence(confidence float64) CorrectorOption {
+	return func(c *Corrector) { c.c.SetMinConfidence(confidence) }
+}
+
+// WithKeyboardAware counts pressing a key next to the intended one on a
+// QWERTY keyboard as half a typo, so such slips are corrected first
+func WithKeyboardAware(enabled bool) CorrectorOption {
+	return func(c *Corrector) { c.c.SetKeyboardAware(enabled) }
+}
+
+// WithAliases supplies the names of the user's shell aliases, which are
+// corrected like commands and whose arguments are left alone
+func WithAliases(names ...string) CorrectorOption {
+	names = slices.Clone(names)
+	return func(c *Corrector) { c.c.SetAliases(names) }
+}
+
+// WithHistory supplies the user's past commands. A command that no typo
+// fix explains is corrected to the past command it most resembles, when it
+// scores at least threshold; a threshold of 0 keeps the default.
+func WithHistory(entries []HistoryEntry, threshold float64) CorrectorOption {
+	history := make([]corrector.HistoryEntry, len(entries))
+	for i, e := range entries {
+		history[i] = corrector.HistoryEntry{Command: e.Command, UsageCount: e.UsageCount, LastUsed: e.LastUsed}
+	}
+	return func(c *Corrector) {
+		c.c.SetHistoryThreshold(threshold)
+		c.c.SetHistoryEntries(history)
+	}
+}
+
+// WithPersonalCorrections supplies corrections the user taught, by typo;
+// they win over every other correction
+func WithPersonalCorrections(corrections map[string]string) CorrectorOption {
+	corrections = maps.Clone(corrections)
+	return func(c *Corrector) { c.c.SetPersonalCorrections(corrections) }
+}
+
+// WithFeedback supplies how often past corrections were accepted and
+// rejected. A correction's confidence follows its acceptance rate, and one
+// rejected most of the time is no longer returned.
+func WithFeedback(feedback FeedbackFunc) CorrectorOption {
+	return func(c *Corrector) { c.c.SetFeedback(corrector.FeedbackFunc(feedback)) }
+}
+
+// Corrector fixes mistyped commands
+type Corrector struct {
+	c *corrector.Corrector
+}
+
+// NewCorrector returns a Corrector with the built-in command knowledge and
+// the given options
+func NewCorrector(opts ...CorrectorOption) *Corrector {
+	c := &Corrector{c: corrector.New()}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Correct returns the fix for command, or nil when it looks right. A
+// dangerous command is returned as a Correction with Dangerous set. The
+// error is ctx's once it is done.
+func (c *Corrector) Correct(ctx context.Context, command string) (*Correction, error) {
+	fix, err := c.c.CorrectContext(ctx, command)
+	if err != nil || fix == nil {
+		return nil, err
+	}
+	return &Correction{
+		Original:    fix.Original,
+		Corrected:   fix.Corrected,
+		Confidence:  fix.Confidence,
+		Explanation: fix.Explanation,
+		Dangerous:   fix.IsDangerous,
+	}, nil
+}
+
+// Suggestions returns up to limit commands for input, best first, the way
+// `wut fix` answers it: a task described in words, such as "list running
+// containers", gets the commands that do it, and a command gets its
+// correction. A dangerous or correct command gets none.
+func (c *Corrector) Suggestions(ctx context.Context, input string, limit int) ([]Suggestion, error) {
+	if smart.IsNaturalLanguage(input) {
+		matches, err := QuerySemantic(ctx, input, limit)
+		if err != nil {
+			return nil, err
+		}
+		suggestions := make([]Suggestion, len(matches))
+		for i, m := range matches {
+			suggestions[i] = Suggestion{Command: m.Command, Description: m.Description, Confidence: m.Confidence}
+		}
+		return suggestions, nil
+	}
+
+	fix, err := c.Correct(ctx, input)
+	if err != nil || fix == nil || fix.Dangerous {
+		return nil, err
+	}
+	return []Suggestion{{Command: fix.Corrected, Description: fix.Explanation, Confidence: fix.Confidence}}, nil
+}
@@ -0,0 +1,26 @@
+// Package wut offers WUT's command correction, semantic intent search and
+// ranked command search as a Go API, without running the CLI.
+//
+// A Corrector fixes mistyped commands and flags:
+//
+//	c := wut.NewCorrector(wut.WithMinConfidence(0.6))
+//	fix, err := c.Correct(ctx, "gti status")
+//
+// QuerySemantic turns a task described in words into commands, and a
+// Searcher ranks commands for a query from the built-in catalog and, given
+// a WUT database with WithStoragePath, the user's history, aliases,
+// bookmarks and TLDR pages.
+//
+// Nothing in this package reads the WUT configuration file or keeps state
+// between values: every setting is an option, and two Correctors or
+// Searchers do not affect each other.
+//
+// # Compatibility
+//
+// The module path is still the bare "wut", which the go command cannot
+// fetch, so for now only code inside this repository, such as wut fix and
+// wut smart, can import the package. No compatibility promise is made until
+// the module moves to a path other modules can depend on. Build values with
+// the New functions and keyed struct literals, and do not rely on which
+// suggestions are returned or how they are scored.
+package wut
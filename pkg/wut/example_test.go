@@ -0,0 +1,78 @@
+package wut_test
+
+import (
+	"context"
+	"fmt"
+
+	"wut/pkg/wut"
+)
+
+func ExampleCorrector_Correct() {
+	c := wut.NewCorrector()
+	fix, err := c.Correct(context.Background(), "git comit -m fix")
+	if err != nil || fix == nil {
+		return
+	}
+	fmt.Println(fix.Corrected)
+	fmt.Println(fix.Explanation)
+	// Output:
+	// git commit -m fix
+	// Fixed: 'comit'→'commit'
+}
+
+func ExampleCorrector_Correct_dangerous() {
+	c := wut.NewCorrector()
+	fix, _ := c.Correct(context.Background(), "rm -rf /")
+	fmt.Println(fix.Dangerous)
+	// Output: true
+}
+
+func ExampleCorrector_Suggestions() {
+	c := wut.NewCorrector()
+	suggestions, err := c.Suggestions(context.Background(), "undo the last commit", 1)
+	if err != nil {
+		return
+	}
+	for _, s := range suggestions {
+		fmt.Printf("%s  # %s\n", s.Command, s.Description)
+	}
+	// Output: git reset --soft HEAD~1  # Undo the last commit but keep the changes staged
+}
+
+func ExampleWithPersonalCorrections() {
+	c := wut.NewCorrector(wut.WithPersonalCorrections(map[string]string{"gs": "git status"}))
+	fix, _ := c.Correct(context.Background(), "gs")
+	fmt.Println(fix.Corrected)
+	// Output: git status
+}
+
+func ExampleQuerySemantic() {
+	matches, err := wut.QuerySemantic(context.Background(), "show running containers", 2)
+	if err != nil {
+		return
+	}
+	for _, m := range matches {
+		fmt.Printf("%s (%s)\n", m.Command, m.Category)
+	}
+	// Output:
+	// docker ps (docker)
+	// docker ps -a (docker)
+}
+
+func ExampleNewSearcher() {
+	searcher, err := wut.NewSearcher(wut.WithStoragePath("wut.db"))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer searcher.Close()
+
+	results, err := searcher.Search(context.Background(), "git st", 5)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for _, r := range results {
+		fmt.Println(r.Command, "-", r.Description)
+	}
+}
@@ -0,0 +1,107 @@
+package wut
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"wut/internal/config"
+	"wut/internal/db"
+	"wut/internal/smart"
+)
+
+// SearchResult is a command ranked for a search query
+type SearchResult struct {
+	Command     string
+	Description string
+	// Score ranks the results of one search; it has no fixed scale
+	Score float64
+	// Source is where the command came from, such as history or a TLDR page
+	Source string
+	// UsageCount and LastUsed come from the user's history, when the
+	// command is in it
+	UsageCount int
+	LastUsed   time.Time
+}
+
+// Searcher ranks commands for a query, taking the project in the working
+// directory into account
+type Searcher interface {
+	// Search returns up to limit results for query, best first. An empty
+	// query returns what fits the current project, and a limit of 0 or less
+	// returns every result. When ctx is done, the sources that had answered
+	// by then are ranked.
+	Search(ctx context.Context, query string, limit int) ([]SearchResult, error)
+	// Close releases the database the Searcher opened
+	Close() error
+}
+
+// SearcherOption configures a Searcher
+type SearcherOption func(*searcherOptions)
+
+type searcherOptions struct {
+	storagePath string
+}
+
+// WithStoragePath makes the Searcher read the history, aliases, bookmarks
+// and TLDR pages in the WUT database at path, creating it if it does not
+// exist. Without it only the built-in knowledge is searched.
+func WithStoragePath(path string) SearcherOption {
+	return func(o *searcherOptions) { o.storagePath = path }
+}
+
+// engineSearcher is the Searcher of the smart suggestion engine
+type engineSearcher struct {
+	engine  *smart.Engine
+	storage *db.Storage
+}
+
+// NewSearcher returns a Searcher with the given options. It runs none of
+// the external suggestion sources the CLI is configured with.
+func NewSearcher(opts ...SearcherOption) (Searcher, error) {
+	var o searcherOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	var storage *db.Storage
+	if o.storagePath != "" {
+		var err error
+		if storage, err = db.NewStorage(o.storagePath); err != nil {
+			return nil, fmt.Errorf("failed to open storage: %w", err)
+		}
+	}
+	engine := smart.NewEngineWithConfig(storage, &config.Config{})
+	engine.SetExternalSources(nil, 0)
+	return &engineSearcher{engine: engine, storage: storage}, nil
+}
+
+func (s *engineSearcher) Search(ctx context.Context, query string, limit int) ([]SearchResult, error) {
+	contextData, err := s.engine.Context(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to analyze the working directory: %w", err)
+	}
+	suggestions, err := s.engine.Suggest(ctx, query, contextData, limit)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]SearchResult, len(suggestions))
+	for i, sug := range suggestions {
+		results[i] = SearchResult{
+			Command:     sug.Command,
+			Description: sug.Description,
+			Score:       sug.Score,
+			Source:      sug.Source,
+			UsageCount:  sug.UsageCount,
+			LastUsed:    sug.LastUsed,
+		}
+	}
+	return results, nil
+}
+
+func (s *engineSearcher) Close() error {
+	if s.storage == nil {
+		return nil
+	}
+	return s.storage.Close()
+}
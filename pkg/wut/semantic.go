@@ -0,0 +1,52 @@
+package wut
+
+import (
+	"context"
+
+	"wut/internal/corrector"
+)
+
+// IntentMatch is a command that does what a query describes
+type IntentMatch struct {
+	// Command is the intent's command with the placeholders the query has
+	// values for filled in, such as "docker logs -f web"
+	Command     string
+	Description string
+	// Category is the tool or area of the intent, such as docker or git
+	Category string
+	// Score ranks the matches of one query; Confidence is the same from 0
+	// to 1 and can be compared across queries
+	Score      float64
+	Confidence float64
+}
+
+// QuerySemantic returns up to limit commands for a task described in
+// words, such as "show running containers", best first. A limit of 0 or less
+// returns up to 5. The error is ctx's when it is done before the query runs.
+func QuerySemantic(ctx context.Context, query string, limit int) ([]IntentMatch, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	found := corrector.QuerySemantic(query, limit)
+	matches := make([]IntentMatch, len(found))
+	for i, m := range found {
+		matches[i] = IntentMatch{
+			Command:     m.Command,
+			Description: m.Intent.Description,
+			Category:    m.Intent.Category,
+			Score:       m.Score,
+			Confidence:  m.Confidence,
+		}
+	}
+	return matches, nil
+}
+
+// Ambiguous reports whether the two best matches of a query score too close
+// to tell which one was meant, so both are worth offering
+func Ambiguous(matches []IntentMatch) bool {
+	internal := make([]corrector.IntentMatch, min(len(matches), 2))
+	for i := range internal {
+		internal[i].Score = matches[i].Score
+	}
+	return corrector.Ambiguous(internal)
+}
@@ -0,0 +1,126 @@
+package wut
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"wut/internal/db"
+)
+
+func TestCorrectorOptions(t *testing.T) {
+	ctx := t.Context()
+
+	taught := NewCorrector(WithPersonalCorrections(map[string]string{"gti": "git"}))
+	plain := NewCorrector()
+	for _, c := range []*Corrector{taught, plain} {
+		fix, err := c.Correct(ctx, "gti status")
+		if err != nil || fix == nil || fix.Corrected != "git status" {
+			t.Fatalf("Correct(gti status) = %+v, %v; want git status", fix, err)
+		}
+	}
+	fix, _ := taught.Correct(ctx, "gti status")
+	if fix.Confidence != 1 {
+		t.Errorf("taught correction confidence = %v, want 1", fix.Confidence)
+	}
+	// One Corrector's options do not leak into another
+	if fix, _ := plain.Correct(ctx, "gti status"); fix.Confidence == 1 {
+		t.Error("a Corrector without taught corrections used another's")
+	}
+
+	strict := NewCorrector(WithMinConfidence(0.99))
+	if fix, err := strict.Correct(ctx, "gti status"); err != nil || fix != nil {
+		t.Errorf("Correct() with a 0.99 minimum = %+v, %v; want no correction", fix, err)
+	}
+
+	history := NewCorrector(WithHistory([]HistoryEntry{
+		{Command: "deploy.sh --prod-us", UsageCount: 12, LastUsed: time.Now()},
+	}, 0))
+	if fix, err := history.Correct(ctx, "deploy.sh --prod-eu"); err != nil || fix == nil || fix.Corrected != "deploy.sh --prod-us" {
+		t.Errorf("Correct() with history = %+v, %v; want the past command", fix, err)
+	}
+}
+
+func TestCorrectorSuggestions(t *testing.T) {
+	c := NewCorrector()
+	ctx := t.Context()
+
+	suggestions, err := c.Suggestions(ctx, "list running containers", 3)
+	if err != nil || len(suggestions) == 0 || suggestions[0].Command != "docker ps" {
+		t.Fatalf("Suggestions(task) = %+v, %v; want docker ps first", suggestions, err)
+	}
+	if len(suggestions) > 3 {
+		t.Errorf("Suggestions() returned %d, want at most 3", len(suggestions))
+	}
+
+	for _, input := range []string{"ls -la", "rm -rf /"} {
+		if suggestions, err := c.Suggestions(ctx, input, 3); err != nil || len(suggestions) != 0 {
+			t.Errorf("Suggestions(%q) = %+v, %v; want none", input, suggestions, err)
+		}
+	}
+}
+
+func TestQuerySemanticCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	if matches, err := QuerySemantic(ctx, "show running containers", 3); err != context.Canceled || matches != nil {
+		t.Errorf("QuerySemantic() with a canceled context = %v, %v; want context.Canceled", matches, err)
+	}
+}
+
+func TestAmbiguous(t *testing.T) {
+	if Ambiguous([]IntentMatch{{Score: 5}}) {
+		t.Error("a single match is ambiguous")
+	}
+	if !Ambiguous([]IntentMatch{{Score: 5}, {Score: 4.8}}) {
+		t.Error("matches scoring 5 and 4.8 are not ambiguous")
+	}
+	if Ambiguous([]IntentMatch{{Score: 5}, {Score: 1}}) {
+		t.Error("matches scoring 5 and 1 are ambiguous")
+	}
+}
+
+func TestSearcher(t *testing.T) {
+	t.Chdir(t.TempDir())
+	path := filepath.Join(t.TempDir(), "wut.db")
+	storage, err := db.NewStorage(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.AddHistory(t.Context(), "kubectl get pods"); err != nil {
+		t.Fatal(err)
+	}
+	storage.Close()
+
+	searcher, err := NewSearcher(WithStoragePath(path))
+	if err != nil {
+		t.Fatalf("NewSearcher() error = %v", err)
+	}
+
+	results, err := searcher.Search(t.Context(), "kubctl get pod", 5)
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if len(results) == 0 || len(results) > 5 {
+		t.Fatalf("Search() returned %d results, want 1 to 5", len(results))
+	}
+	if !slices.ContainsFunc(results, func(r SearchResult) bool { return r.Command == "kubectl get pods" }) {
+		t.Errorf("Search(kubctl get pod) = %+v, want the command from the history", results)
+	}
+	if err := searcher.Close(); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+
+	// Without a database only the built-in knowledge is searched
+	builtIn, err := NewSearcher()
+	if err != nil {
+		t.Fatalf("NewSearcher() error = %v", err)
+	}
+	defer builtIn.Close()
+	results, err = builtIn.Search(t.Context(), "list running containers", 5)
+	if err != nil || !slices.ContainsFunc(results, func(r SearchResult) bool { return r.Command == "docker ps" }) {
+		t.Errorf("Search() without storage = %+v, %v; want docker ps", results, err)
+	}
+}